@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	apifeatures "github.com/openshift/api/features"
+	"github.com/openshift/library-go/pkg/features"
+	"github.com/spf13/cobra"
+	"k8s.io/component-base/featuregate"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/machine-api-operator/pkg/validate"
+)
+
+var (
+	validateCmd = &cobra.Command{
+		Use:   "validate",
+		Short: "Validate Machine and MachineSet manifests offline",
+		Long: "Validate Machine and MachineSet manifests with the checks of the validating webhooks, without a connection to a cluster.\n" +
+			"The checks reading from the cluster, such as the existence of the secrets, are skipped. " +
+			"Warnings and errors are printed for every manifest, the command exits with 1 when any manifest is invalid.",
+		Run: runValidateCmd,
+	}
+
+	validateOpts        validate.Options
+	validateFeatureGate = featuregate.NewFeatureGate()
+	validateGateOpts    = features.NewFeatureGateOptionsOrDie(validateFeatureGate, apifeatures.SelfManaged,
+		apifeatures.FeatureGateMachineAPIMigration,
+		apifeatures.FeatureGateVSphereStaticIPs,
+		apifeatures.FeatureGateVSphereHostVMGroupZonal,
+		apifeatures.FeatureGateVSphereMultiDisk,
+	)
+)
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+	validateCmd.Flags().StringVarP(&validateOpts.Filename, "filename", "f", "-", "File holding the Machine and MachineSet manifests to validate, - reads them from stdin.")
+	validateCmd.Flags().StringVar(&validateOpts.InfrastructureFilename, "infrastructure", "", "File holding the cluster Infrastructure the manifests are validated for.")
+	validateCmd.Flags().StringVar(&validateOpts.DNSFilename, "dns", "", "File holding the cluster DNS. The cluster is considered connected when it is not given.")
+	validateGateOpts.AddFlags(validateCmd)
+	if err := validateCmd.MarkFlagRequired("infrastructure"); err != nil {
+		klog.Fatalf("failed to mark the infrastructure flag required: %v", err)
+	}
+}
+
+func runValidateCmd(cmd *cobra.Command, args []string) {
+	warnings, err := validateGateOpts.ApplyTo(validateFeatureGate)
+	if err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "error setting feature gates: %v\n", err)
+		os.Exit(validate.ExitUsageError)
+	}
+	for _, warning := range warnings {
+		fmt.Fprintf(cmd.ErrOrStderr(), "warning setting feature gates: %s\n", warning)
+	}
+	validateOpts.FeatureGate = validateFeatureGate
+
+	os.Exit(validate.Run(validateOpts, cmd.InOrStdin(), cmd.OutOrStdout(), cmd.ErrOrStderr()))
+}
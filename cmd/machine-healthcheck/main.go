@@ -4,10 +4,12 @@ import (
 	"flag"
 	"fmt"
 	"runtime"
+	"time"
 
 	"github.com/openshift/machine-api-operator/pkg/controller/machinehealthcheck"
 	"github.com/openshift/machine-api-operator/pkg/metrics"
 	"github.com/openshift/machine-api-operator/pkg/util"
+	mapiwebhooks "github.com/openshift/machine-api-operator/pkg/webhooks"
 
 	osconfigv1 "github.com/openshift/api/config/v1"
 	machinev1 "github.com/openshift/api/machine/v1beta1"
@@ -24,6 +26,12 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+const (
+	defaultWebhookPort    = 8445
+	defaultWebhookCertdir = "/etc/machine-api-operator/tls"
 )
 
 func printVersion() {
@@ -41,7 +49,13 @@ func main() {
 	watchNamespace := flag.String(
 		"namespace",
 		"",
-		"Namespace that the controller watches to reconcile machine-api objects. If unspecified, the controller watches for machine-api objects across all namespaces.",
+		"Comma separated list of namespaces that the controller watches to reconcile machine-api objects. If unspecified, the controller watches for machine-api objects across all namespaces.",
+	)
+
+	clusterPauseNamespace := flag.String(
+		"cluster-pause-namespace",
+		controller.DefaultClusterPauseNamespace,
+		"The namespace of the machine-api-operator, in which it creates the "+controller.ClusterPauseConfigMapName+" ConfigMap to pause the controller during upgrades and maintenance.",
 	)
 
 	metricsAddress := flag.String(
@@ -75,6 +89,48 @@ func main() {
 		fmt.Sprintf("The duration that non-leader candidates will wait after observing a leadership renewal until attempting to acquire leadership of a led but unrenewed leader slot. This is effectively the maximum duration that a leader can be stopped before it is replaced by another candidate. This is only applicable if leader election is enabled. Default: (%s)", defaultLeaderElectionValues.LeaseDuration.Duration),
 	)
 
+	maxRemediations := flag.Int(
+		"max-remediations-per-window",
+		0,
+		"The maximum number of remediations started across all MachineHealthChecks within the remediation window. Remediations over the limit are delayed and retried with backoff. Zero disables the limit.",
+	)
+
+	remediationWindow := flag.Duration(
+		"remediation-window",
+		10*time.Minute,
+		"The window over which the remediations are limited by max-remediations-per-window.",
+	)
+
+	minNodeStartupTimeout := flag.Duration(
+		"min-node-startup-timeout",
+		0,
+		"The minimum nodeStartupTimeout of every MachineHealthCheck. Machines younger than that are not remediated for any reason, whatever the spec of their MachineHealthCheck. Zero disables the minimum.",
+	)
+
+	concurrency := flag.Int(
+		"concurrency",
+		0,
+		"Maximum number of concurrent reconciles of each controller. Zero keeps the controller-runtime default of a single reconcile at a time.",
+	)
+
+	webhookEnabled := flag.Bool(
+		"webhook-enabled",
+		false,
+		"Webhook server, disabled by default. When enabled, the manager will run a webhook server validating MachineHealthChecks.",
+	)
+
+	webhookPort := flag.Int(
+		"webhook-port",
+		defaultWebhookPort,
+		"Webhook Server port, only used when webhook-enabled is true.",
+	)
+
+	webhookCertdir := flag.String(
+		"webhook-cert-dir",
+		defaultWebhookCertdir,
+		"Webhook cert dir, only used when webhook-enabled is true.",
+	)
+
 	// Set log for controller-runtime
 	ctrl.SetLogger(klog.NewKlogr())
 
@@ -97,7 +153,10 @@ func main() {
 		Metrics: server.Options{
 			BindAddress: *metricsAddress,
 		},
-		HealthProbeBindAddress:  *healthAddr,
+		HealthProbeBindAddress: *healthAddr,
+		Cache: cache.Options{
+			ByObject: controller.ClusterPauseCacheByObject(*clusterPauseNamespace),
+		},
 		LeaderElection:          *leaderElect,
 		LeaderElectionNamespace: *leaderElectResourceNamespace,
 		LeaderElectionID:        "cluster-api-provider-healthcheck-leader",
@@ -106,18 +165,30 @@ func main() {
 		RenewDeadline:           &le.RenewDeadline.Duration,
 	}
 
-	if *watchNamespace != "" {
-		opts.Cache.DefaultNamespaces = map[string]cache.Config{
-			*watchNamespace: {},
-		}
-		klog.Infof("Watching machine-api objects only in namespace %q for reconciliation.", *watchNamespace)
+	opts.Controller.MaxConcurrentReconciles = *concurrency
+
+	if namespaces := util.WatchNamespaces(*watchNamespace); namespaces != nil {
+		opts.Cache.DefaultNamespaces = namespaces
+		klog.Infof("Watching machine-api objects only in namespaces %q for reconciliation.", *watchNamespace)
+	}
+
+	if *webhookEnabled {
+		opts.WebhookServer = webhook.NewServer(webhook.Options{
+			Port:    *webhookPort,
+			CertDir: *webhookCertdir,
+		})
 	}
+
 	// Create a new Cmd to provide shared dependencies and start components
 	mgr, err := manager.New(cfg, opts)
 	if err != nil {
 		klog.Fatal(err)
 	}
 
+	if *webhookEnabled {
+		mgr.GetWebhookServer().Register(mapiwebhooks.DefaultMachineHealthCheckValidatingHookPath, &webhook.Admission{Handler: mapiwebhooks.NewMachineHealthCheckValidator()})
+	}
+
 	klog.Infof("Registering Components.")
 
 	// Setup Scheme for all resources
@@ -126,7 +197,12 @@ func main() {
 	}
 
 	// Setup all Controllers
-	if err := controller.AddToManager(mgr, opts, machinehealthcheck.Add); err != nil {
+	if err := controller.AddToManager(mgr, opts, machinehealthcheck.AddWithOptions(machinehealthcheck.Options{
+		MaxRemediations:       *maxRemediations,
+		RemediationWindow:     *remediationWindow,
+		MinNodeStartupTimeout: *minNodeStartupTimeout,
+		ClusterPauseNamespace: *clusterPauseNamespace,
+	})); err != nil {
 		klog.Fatal(err)
 	}
 
@@ -134,6 +210,10 @@ func main() {
 		klog.Fatal(err)
 	}
 
+	if err := controller.AddLeaderElectionHandler(mgr); err != nil {
+		klog.Fatal(err)
+	}
+
 	if err := mgr.AddHealthzCheck("ping", healthz.Ping); err != nil {
 		klog.Fatal(err)
 	}
@@ -20,6 +20,7 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"os"
 	"strings"
 	"time"
 
@@ -47,6 +48,7 @@ import (
 	"github.com/openshift/library-go/pkg/features"
 	"github.com/openshift/machine-api-operator/pkg/controller"
 	"github.com/openshift/machine-api-operator/pkg/controller/machineset"
+	"github.com/openshift/machine-api-operator/pkg/controller/machinetopology"
 	"github.com/openshift/machine-api-operator/pkg/metrics"
 	"github.com/openshift/machine-api-operator/pkg/operator"
 	"github.com/openshift/machine-api-operator/pkg/util"
@@ -73,8 +75,12 @@ func main() {
 		klog.Fatalf("failed to set logtostderr flag: %v", err)
 	}
 	watchNamespace := flag.String("namespace", "",
-		"Namespace that the controller watches to reconcile cluster-api objects. If unspecified, the controller watches for cluster-api objects across all namespaces.")
+		"Comma separated list of namespaces that the controller watches to reconcile cluster-api objects. If unspecified, the controller watches for cluster-api objects across all namespaces.")
+	clusterPauseNamespace := flag.String("cluster-pause-namespace", controller.DefaultClusterPauseNamespace,
+		"The namespace of the machine-api-operator, in which it creates the "+controller.ClusterPauseConfigMapName+" ConfigMap to pause the controllers during upgrades and maintenance.")
 	metricsAddress := flag.String("metrics-bind-address", metrics.DefaultMachineSetMetricsAddress, "Address for hosting metrics")
+	metricsAuthentication := flag.Bool("metrics-authentication", false,
+		"Require a bearer token, authenticated with the TokenReview API and authorized with the SubjectAccessReview API, to scrape metrics.")
 
 	webhookEnabled := flag.Bool("webhook-enabled", true,
 		"Webhook server, enabled by default. When enabled, the manager will run a webhook server.")
@@ -85,6 +91,24 @@ func main() {
 	webhookCertdir := flag.String("webhook-cert-dir", defaultWebhookCertdir,
 		"Webhook cert dir, only used when webhook-enabled is true.")
 
+	machineWebhookEnabled := flag.Bool("machine-webhook-enabled", true,
+		"Machine defaulting and validating webhooks, enabled by default. Only used when webhook-enabled is true.")
+
+	machineSetWebhookEnabled := flag.Bool("machineset-webhook-enabled", true,
+		"MachineSet defaulting and validating webhooks, enabled by default. Only used when webhook-enabled is true.")
+
+	vCPUQuotaWarnings := flag.Bool("machineset-vcpu-quota-warnings", false,
+		"Warn when the replicas of a MachineSet are estimated to request more vCPUs than the "+mapiwebhooks.VCPUQuotaSoftLimitAnnotation+" annotation of the cluster infrastructure. MachineSets are never rejected.")
+
+	reportTopology := flag.Bool("report-machine-topology", false,
+		"Report Machines placed in a region or zone which does not match their MachineSet with the mapi_machine_topology_mismatch metric. Machines are never modified.")
+
+	createConcurrency := flag.Int("machine-create-concurrency", 0,
+		"Default maximum number of Machines of a MachineSet created at a time, 0 means unlimited. It can be overridden per MachineSet with the "+machineset.CreateConcurrencyAnnotation+" annotation.")
+
+	concurrency := flag.Int("concurrency", 0,
+		"Maximum number of concurrent reconciles of each controller. Zero keeps the controller-runtime default of a single reconcile at a time.")
+
 	healthAddr := flag.String(
 		"health-addr",
 		":9441",
@@ -110,6 +134,12 @@ func main() {
 		fmt.Sprintf("The duration that non-leader candidates will wait after observing a leadership renewal until attempting to acquire leadership of a led but unrenewed leader slot. This is effectively the maximum duration that a leader can be stopped before it is replaced by another candidate. This is only applicable if leader election is enabled. Default: (%s)", defaultLeaderElectionValues.LeaseDuration.Duration),
 	)
 
+	printFeatureGates := flag.Bool(
+		"print-feature-gates",
+		false,
+		"Print the resolved state of every known feature gate as JSON and exit.",
+	)
+
 	// Sets up feature gates
 	defaultMutableGate := feature.DefaultMutableFeatureGate
 	gateOpts, err := features.NewFeatureGateOptions(defaultMutableGate, apifeatures.SelfManaged, apifeatures.FeatureGateVSphereStaticIPs, apifeatures.FeatureGateMachineAPIMigration, apifeatures.FeatureGateVSphereHostVMGroupZonal, apifeatures.FeatureGateVSphereMultiDisk)
@@ -121,8 +151,26 @@ func main() {
 	gateOpts.AddFlagsToGoFlagSet(nil)
 
 	flag.Parse()
+
+	// Sets feature gates from flags
+	klog.Infof("Initializing feature gates: %s", strings.Join(defaultMutableGate.KnownFeatures(), ", "))
+	warnings, err := gateOpts.ApplyTo(defaultMutableGate)
+	if err != nil {
+		klog.Fatalf("Error setting feature gates from flags: %v", err)
+	}
+	if len(warnings) > 0 {
+		klog.Infof("Warnings setting feature gates from flags: %v", warnings)
+	}
+
+	if *printFeatureGates {
+		if err := util.PrintFeatureGates(os.Stdout, defaultMutableGate); err != nil {
+			klog.Fatalf("Error printing feature gates: %v", err)
+		}
+		os.Exit(0)
+	}
+
 	if *watchNamespace != "" {
-		log.Printf("Watching cluster-api objects only in namespace %q for reconciliation.", *watchNamespace)
+		log.Printf("Watching cluster-api objects only in namespaces %q for reconciliation.", *watchNamespace)
 	}
 
 	log.Printf("Registering Components.")
@@ -144,10 +192,9 @@ func main() {
 			BindAddress: *metricsAddress,
 		},
 		Cache: cache.Options{
-			SyncPeriod: &syncPeriod,
-			DefaultNamespaces: map[string]cache.Config{
-				*watchNamespace: {},
-			},
+			SyncPeriod:        &syncPeriod,
+			DefaultNamespaces: util.WatchNamespaces(*watchNamespace),
+			ByObject:          controller.ClusterPauseCacheByObject(*clusterPauseNamespace),
 		},
 		HealthProbeBindAddress:  *healthAddr,
 		LeaderElection:          *leaderElect,
@@ -158,6 +205,12 @@ func main() {
 		RenewDeadline:           &le.RenewDeadline.Duration,
 	}
 
+	opts.Controller.MaxConcurrentReconciles = *concurrency
+
+	if *metricsAuthentication {
+		opts.Metrics.FilterProvider = metrics.AuthenticationAndAuthorizationFilterProvider
+	}
+
 	if *webhookEnabled {
 		opts.WebhookServer = webhook.NewServer(webhook.Options{
 			Port:    *webhookPort,
@@ -170,44 +223,17 @@ func main() {
 		log.Fatal(err)
 	}
 
-	// Sets feature gates from flags
-	klog.Infof("Initializing feature gates: %s", strings.Join(defaultMutableGate.KnownFeatures(), ", "))
-	warnings, err := gateOpts.ApplyTo(defaultMutableGate)
-	if err != nil {
-		klog.Fatalf("Error setting feature gates from flags: %v", err)
-	}
-	if len(warnings) > 0 {
-		klog.Infof("Warnings setting feature gates from flags: %v", warnings)
-	}
-
 	klog.Infof("FeatureGateMachineAPIMigration initialised: %t", defaultMutableGate.Enabled(featuregate.Feature(apifeatures.FeatureGateMachineAPIMigration)))
 
 	// Enable defaulting and validating webhooks
-	machineDefaulter, err := mapiwebhooks.NewMachineDefaulter()
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	machineValidator, err := mapiwebhooks.NewMachineValidator(mgr.GetClient(), defaultMutableGate)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	machineSetDefaulter, err := mapiwebhooks.NewMachineSetDefaulter()
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	machineSetValidator, err := mapiwebhooks.NewMachineSetValidator(mgr.GetClient(), defaultMutableGate)
-	if err != nil {
-		log.Fatal(err)
-	}
-
 	if *webhookEnabled {
-		mgr.GetWebhookServer().Register(mapiwebhooks.DefaultMachineMutatingHookPath, &webhook.Admission{Handler: machineDefaulter})
-		mgr.GetWebhookServer().Register(mapiwebhooks.DefaultMachineValidatingHookPath, &webhook.Admission{Handler: machineValidator})
-		mgr.GetWebhookServer().Register(mapiwebhooks.DefaultMachineSetMutatingHookPath, &webhook.Admission{Handler: machineSetDefaulter})
-		mgr.GetWebhookServer().Register(mapiwebhooks.DefaultMachineSetValidatingHookPath, &webhook.Admission{Handler: machineSetValidator})
+		if err := mapiwebhooks.RegisterWebhooks(mgr.GetWebhookServer(), mgr.GetClient(), defaultMutableGate, mapiwebhooks.WebhookOptions{
+			MachineWebhooks:    *machineWebhookEnabled,
+			MachineSetWebhooks: *machineSetWebhookEnabled,
+			VCPUQuotaWarnings:  *vCPUQuotaWarnings,
+		}); err != nil {
+			log.Fatal(err)
+		}
 	}
 
 	log.Printf("Registering Components.")
@@ -218,14 +244,29 @@ func main() {
 	}
 
 	// Setup all Controllers
-	if err := controller.AddToManagerWithFeatureGates(mgr, opts, defaultMutableGate, machineset.Add); err != nil {
+	if err := controller.AddToManagerWithFeatureGates(mgr, opts, defaultMutableGate, machineset.AddWithOptions(machineset.Options{
+		CreateConcurrency:     *createConcurrency,
+		ClusterPauseNamespace: *clusterPauseNamespace,
+	})); err != nil {
 		log.Fatal(err)
 	}
 
+	if *reportTopology {
+		if err := controller.AddToManager(mgr, opts, machinetopology.AddWithOptions(machinetopology.Options{
+			ClusterPauseNamespace: *clusterPauseNamespace,
+		})); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	if err := mgr.AddReadyzCheck("ping", healthz.Ping); err != nil {
 		klog.Fatal(err)
 	}
 
+	if err := controller.AddLeaderElectionHandler(mgr); err != nil {
+		klog.Fatal(err)
+	}
+
 	if err := mgr.AddHealthzCheck("ping", healthz.Ping); err != nil {
 		klog.Fatal(err)
 	}
@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"runtime"
+	"strings"
 
 	osconfigv1 "github.com/openshift/api/config/v1"
 	machinev1 "github.com/openshift/api/machine/v1beta1"
@@ -12,6 +13,7 @@ import (
 	"github.com/openshift/machine-api-operator/pkg/controller/nodelink"
 	"github.com/openshift/machine-api-operator/pkg/util"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	cliflag "k8s.io/component-base/cli/flag"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
@@ -38,7 +40,13 @@ func main() {
 	watchNamespace := flag.String(
 		"namespace",
 		"",
-		"Namespace that the controller watches to reconcile machine-api objects. If unspecified, the controller watches for machine-api objects across all namespaces.",
+		"Comma separated list of namespaces that the controller watches to reconcile machine-api objects. If unspecified, the controller watches for machine-api objects across all namespaces.",
+	)
+
+	clusterPauseNamespace := flag.String(
+		"cluster-pause-namespace",
+		controller.DefaultClusterPauseNamespace,
+		"The namespace of the machine-api-operator, in which it creates the "+controller.ClusterPauseConfigMapName+" ConfigMap to pause the controller during upgrades and maintenance.",
 	)
 
 	leaderElectResourceNamespace := flag.String(
@@ -60,6 +68,25 @@ func main() {
 		fmt.Sprintf("The duration that non-leader candidates will wait after observing a leadership renewal until attempting to acquire leadership of a led but unrenewed leader slot. This is effectively the maximum duration that a leader can be stopped before it is replaced by another candidate. This is only applicable if leader election is enabled. Default: (%s)", defaultLeaderElectionValues.LeaseDuration.Duration),
 	)
 
+	concurrency := flag.Int(
+		"concurrency",
+		0,
+		"Maximum number of concurrent reconciles of each controller. Zero keeps the controller-runtime default of a single reconcile at a time.",
+	)
+
+	nodeRoleLabels := map[string]string{}
+	flag.Var(
+		cliflag.NewMapStringString(&nodeRoleLabels),
+		"node-role-labels",
+		"Comma separated list of machine role to node label mappings, e.g. infra=node-role.kubernetes.io/infra. Nodes of machines whose machine.openshift.io/cluster-api-machine-role label matches a role are given the mapped label. If unspecified, no role label is applied.",
+	)
+
+	propagatedLabelPrefixes := flag.String(
+		"propagated-node-label-prefixes",
+		"",
+		"Comma separated list of machine label prefixes, e.g. machine.openshift.io/instance-type,machine.openshift.io/zone. Machine labels with these prefixes are propagated to the linked node and removed from it once removed from the machine. If unspecified, no label is propagated.",
+	)
+
 	// Set log for controller-runtime
 	ctrl.SetLogger(klog.NewKlogr())
 
@@ -85,6 +112,9 @@ func main() {
 		Metrics: server.Options{
 			BindAddress: "0",
 		},
+		Cache: cache.Options{
+			ByObject: controller.ClusterPauseCacheByObject(*clusterPauseNamespace),
+		},
 		LeaderElection:          *leaderElect,
 		LeaderElectionNamespace: *leaderElectResourceNamespace,
 		LeaderElectionID:        "cluster-api-provider-nodelink-leader",
@@ -92,11 +122,11 @@ func main() {
 		RetryPeriod:             &le.RetryPeriod.Duration,
 		RenewDeadline:           &le.RenewDeadline.Duration,
 	}
-	if *watchNamespace != "" {
-		opts.Cache.DefaultNamespaces = map[string]cache.Config{
-			*watchNamespace: {},
-		}
-		klog.Infof("Watching machine-api objects only in namespace %q for reconciliation.", *watchNamespace)
+	opts.Controller.MaxConcurrentReconciles = *concurrency
+
+	if namespaces := util.WatchNamespaces(*watchNamespace); namespaces != nil {
+		opts.Cache.DefaultNamespaces = namespaces
+		klog.Infof("Watching machine-api objects only in namespaces %q for reconciliation.", *watchNamespace)
 	}
 	// Create a new Cmd to provide shared dependencies and start components
 	mgr, err := manager.New(cfg, opts)
@@ -112,7 +142,14 @@ func main() {
 	}
 
 	// Setup all Controllers
-	if err := controller.AddToManager(mgr, opts, nodelink.Add); err != nil {
+	nodelinkOpts := nodelink.Options{
+		NodeRoleLabels:        nodeRoleLabels,
+		ClusterPauseNamespace: *clusterPauseNamespace,
+	}
+	if *propagatedLabelPrefixes != "" {
+		nodelinkOpts.PropagatedLabelPrefixes = strings.Split(*propagatedLabelPrefixes, ",")
+	}
+	if err := controller.AddToManager(mgr, opts, nodelink.AddWithOptions(nodelinkOpts)); err != nil {
 		klog.Fatal(err)
 	}
 
@@ -14,6 +14,7 @@ import (
 	ipamv1beta1 "sigs.k8s.io/cluster-api/exp/ipam/api/v1beta1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
@@ -25,6 +26,7 @@ import (
 	machinev1 "github.com/openshift/api/machine/v1beta1"
 	"github.com/openshift/library-go/pkg/config/leaderelection"
 	"github.com/openshift/library-go/pkg/features"
+	mapicontroller "github.com/openshift/machine-api-operator/pkg/controller"
 	capimachine "github.com/openshift/machine-api-operator/pkg/controller/machine"
 	"github.com/openshift/machine-api-operator/pkg/controller/vsphere"
 	machine "github.com/openshift/machine-api-operator/pkg/controller/vsphere"
@@ -32,6 +34,7 @@ import (
 	"github.com/openshift/machine-api-operator/pkg/metrics"
 	"github.com/openshift/machine-api-operator/pkg/util"
 	"github.com/openshift/machine-api-operator/pkg/version"
+	"github.com/openshift/machine-api-operator/pkg/webhooks"
 )
 
 const timeout = 10 * time.Minute
@@ -57,7 +60,7 @@ func main() {
 	watchNamespace := flag.String(
 		"namespace",
 		"",
-		"Namespace that the controller watches to reconcile machine-api objects. If unspecified, the controller watches for machine-api objects across all namespaces.",
+		"Comma separated list of namespaces that the controller watches to reconcile machine-api objects. If unspecified, the controller watches for machine-api objects across all namespaces.",
 	)
 
 	leaderElectResourceNamespace := flag.String(
@@ -85,12 +88,118 @@ func main() {
 		"Address for hosting metrics",
 	)
 
+	metricsAuthentication := flag.Bool(
+		"metrics-authentication",
+		false,
+		"Require a bearer token, authenticated with the TokenReview API and authorized with the SubjectAccessReview API, to scrape metrics.",
+	)
+
 	healthAddr := flag.String(
 		"health-addr",
 		":9440",
 		"The address for health checking.",
 	)
 
+	machineOpts := capimachine.DefaultOptions()
+
+	flag.StringVar(
+		&machineOpts.ClusterPauseNamespace,
+		"cluster-pause-namespace",
+		machineOpts.ClusterPauseNamespace,
+		"The namespace of the machine-api-operator, in which it creates the "+mapicontroller.ClusterPauseConfigMapName+" ConfigMap to pause the controllers during upgrades and maintenance.",
+	)
+
+	flag.BoolVar(
+		&machineOpts.DetectProviderSpecDrift,
+		"detect-providerspec-drift",
+		false,
+		"Report virtual machines whose cpu or memory settings have drifted from their Machine providerSpec with a ProviderSpecDrift event and metric. Drift is only reported, not corrected.",
+	)
+
+	flag.DurationVar(
+		&machineOpts.ReconcileTimeout,
+		"machine-reconcile-timeout",
+		0,
+		"Deadline of a single Machine reconcile. Reconciles exceeding it, for example because of a slow cloud API call, are requeued. Zero disables the deadline.",
+	)
+
+	flag.DurationVar(
+		&machineOpts.ProvisioningStuckThreshold,
+		"machine-provisioning-stuck-threshold",
+		machineOpts.ProvisioningStuckThreshold,
+		"How long a Machine can be in the Provisioning phase before the Stuck condition is set on it. Zero disables the condition.",
+	)
+
+	flag.DurationVar(
+		&machineOpts.DeletingStuckThreshold,
+		"machine-deleting-stuck-threshold",
+		machineOpts.DeletingStuckThreshold,
+		"How long a Machine can be in the Deleting phase before the Stuck condition is set on it. Zero disables the condition.",
+	)
+
+	flag.DurationVar(
+		&machineOpts.ErrorLogInterval,
+		"machine-error-log-interval",
+		machineOpts.ErrorLogInterval,
+		"How often the same reconcile error of a Machine is logged. Repeated errors within the interval are suppressed and counted. Zero logs every error.",
+	)
+
+	flag.BoolVar(
+		&machineOpts.SkipDrainOnInterruption,
+		"skip-drain-on-interruption",
+		false,
+		"Skip the node drain of deleted Machines annotated as interrupted by a termination handler, so that the instance and the node are removed within the termination notice.",
+	)
+
+	nodeReadiness := flag.String(
+		"node-readiness",
+		string(machineOpts.NodeReadinessPolicy),
+		fmt.Sprintf("When the node of a provisioned Machine is considered ready for the Machine to go Running: %q once the node is linked, %q once the node is also Ready.", capimachine.NodeReadinessExists, capimachine.NodeReadinessReady),
+	)
+
+	flag.DurationVar(
+		&machineOpts.NodeReadyTimeout,
+		"node-ready-timeout",
+		machineOpts.NodeReadyTimeout,
+		"How long the node of a Machine can stay not Ready, with the Ready node readiness, before the NodeReady condition of the Machine is set to NodeReadyTimeout. Zero disables the timeout.",
+	)
+
+	concurrency := flag.Int(
+		"concurrency",
+		0,
+		"Maximum number of concurrent reconciles of each controller. Zero keeps the controller-runtime default of a single reconcile at a time.",
+	)
+
+	enableProviderSpecDebug := flag.Bool(
+		"enable-providerspec-debug",
+		false,
+		fmt.Sprintf("Serve the decoded providerSpec of a Machine and its validation errors on %s of the metrics server, given the namespace and name query parameters.", webhooks.ProviderSpecDebugPath),
+	)
+
+	shutdownGracePeriod := flag.Duration(
+		"shutdown-grace-period",
+		0,
+		"How long to wait on shutdown for in-flight vSphere create and delete tasks to reach a terminal state before exiting. Zero does not wait.",
+	)
+
+	credentialsSecret := flag.String(
+		"credentials-secret",
+		"",
+		"The namespace/name of the vSphere credentials secret. When set, the health check fails while the secret is missing or empty. Unset disables the check.",
+	)
+
+	credentialsCheckInterval := flag.Duration(
+		"credentials-check-interval",
+		time.Minute,
+		"How often the credentials secret given by credentials-secret is checked.",
+	)
+
+	printFeatureGates := flag.Bool(
+		"print-feature-gates",
+		false,
+		"Print the resolved state of every known feature gate as JSON and exit.",
+	)
+
 	// Sets up feature gates
 	defaultMutableGate := feature.DefaultMutableFeatureGate
 	gateOpts, err := features.NewFeatureGateOptions(defaultMutableGate, apifeatures.SelfManaged, apifeatures.FeatureGateVSphereStaticIPs, apifeatures.FeatureGateMachineAPIMigration, apifeatures.FeatureGateVSphereHostVMGroupZonal, apifeatures.FeatureGateVSphereMultiDisk)
@@ -108,6 +217,27 @@ func main() {
 		os.Exit(0)
 	}
 
+	// Sets feature gates from flags
+	klog.Infof("Initializing feature gates: %s", strings.Join(defaultMutableGate.KnownFeatures(), ", "))
+	warnings, err := gateOpts.ApplyTo(defaultMutableGate)
+	if err != nil {
+		klog.Fatalf("Error setting feature gates from flags: %v", err)
+	}
+	if len(warnings) > 0 {
+		klog.Infof("Warnings setting feature gates from flags: %v", warnings)
+	}
+
+	if machineOpts.NodeReadinessPolicy, err = capimachine.ParseNodeReadiness(*nodeReadiness); err != nil {
+		klog.Fatalf("Error parsing node readiness: %v", err)
+	}
+
+	if *printFeatureGates {
+		if err := util.PrintFeatureGates(os.Stdout, defaultMutableGate); err != nil {
+			klog.Fatalf("Error printing feature gates: %v", err)
+		}
+		os.Exit(0)
+	}
+
 	cfg := config.GetConfigOrDie()
 	syncPeriod := timeout
 
@@ -123,6 +253,7 @@ func main() {
 		HealthProbeBindAddress: *healthAddr,
 		Cache: cache.Options{
 			SyncPeriod: &syncPeriod,
+			ByObject:   mapicontroller.ClusterPauseCacheByObject(machineOpts.ClusterPauseNamespace),
 		},
 		LeaderElection:          *leaderElect,
 		LeaderElectionNamespace: *leaderElectResourceNamespace,
@@ -132,21 +263,15 @@ func main() {
 		RenewDeadline:           &le.RenewDeadline.Duration,
 	}
 
-	if *watchNamespace != "" {
-		opts.Cache.DefaultNamespaces = map[string]cache.Config{
-			*watchNamespace: {},
-		}
-		klog.Infof("Watching machine-api objects only in namespace %q for reconciliation.", *watchNamespace)
-	}
+	opts.Controller.MaxConcurrentReconciles = *concurrency
 
-	// Sets feature gates from flags
-	klog.Infof("Initializing feature gates: %s", strings.Join(defaultMutableGate.KnownFeatures(), ", "))
-	warnings, err := gateOpts.ApplyTo(defaultMutableGate)
-	if err != nil {
-		klog.Fatalf("Error setting feature gates from flags: %v", err)
+	if namespaces := util.WatchNamespaces(*watchNamespace); namespaces != nil {
+		opts.Cache.DefaultNamespaces = namespaces
+		klog.Infof("Watching machine-api objects only in namespaces %q for reconciliation.", *watchNamespace)
 	}
-	if len(warnings) > 0 {
-		klog.Infof("Warnings setting feature gates from flags: %v", warnings)
+
+	if *metricsAuthentication {
+		opts.Metrics.FilterProvider = metrics.AuthenticationAndAuthorizationFilterProvider
 	}
 
 	klog.Infof("FeatureGateMachineAPIMigration initialised: %t", defaultMutableGate.Enabled(featuregate.Feature(apifeatures.FeatureGateMachineAPIMigration)))
@@ -194,7 +319,7 @@ func main() {
 		klog.Fatalf("unable to add ipamv1beta1 to scheme: %v", err)
 	}
 
-	if err := capimachine.AddWithActuator(mgr, machineActuator, defaultMutableGate); err != nil {
+	if err := capimachine.AddWithActuatorOpts(mgr, machineActuator, machineOpts, defaultMutableGate); err != nil {
 		klog.Fatal(err)
 	}
 
@@ -202,20 +327,57 @@ func main() {
 	if err = (&machinesetcontroller.Reconciler{
 		Client: mgr.GetClient(),
 		Log:    ctrl.Log.WithName("controllers").WithName("MachineSet"),
+
+		ClusterPauseNamespace: machineOpts.ClusterPauseNamespace,
 	}).SetupWithManager(mgr, controller.Options{}); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "MachineSet")
 		os.Exit(1)
 	}
 
+	if *enableProviderSpecDebug {
+		debugHandler, err := webhooks.NewProviderSpecDebugHandler(mgr.GetClient(), defaultMutableGate)
+		if err != nil {
+			klog.Fatalf("Failed to set up the providerSpec debug handler: %v", err)
+		}
+		if err := mgr.AddMetricsServerExtraHandler(webhooks.ProviderSpecDebugPath, debugHandler); err != nil {
+			klog.Fatal(err)
+		}
+	}
+
 	if err := mgr.AddReadyzCheck("ping", healthz.Ping); err != nil {
 		klog.Fatal(err)
 	}
 
+	if err := mapicontroller.AddLeaderElectionHandler(mgr); err != nil {
+		klog.Fatal(err)
+	}
+
 	if err := mgr.AddHealthzCheck("ping", healthz.Ping); err != nil {
 		klog.Fatal(err)
 	}
 
+	if *credentialsSecret != "" {
+		namespace, name, ok := strings.Cut(*credentialsSecret, "/")
+		if !ok || namespace == "" || name == "" {
+			klog.Fatalf("Invalid credentials-secret %q, expected namespace/name", *credentialsSecret)
+		}
+		if *credentialsCheckInterval <= 0 {
+			klog.Fatalf("Invalid credentials-check-interval %s, it must be greater than 0", *credentialsCheckInterval)
+		}
+		if err := mapicontroller.AddCredentialsSecretHealthzCheck(mgr, client.ObjectKey{Namespace: namespace, Name: name}, *credentialsCheckInterval); err != nil {
+			klog.Fatal(err)
+		}
+	}
+
 	if err = mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		klog.Fatalf("Failed to run manager: %v", err)
 	}
+
+	// The manager has stopped, no new tasks will be started.
+	if *shutdownGracePeriod > 0 {
+		klog.Infof("Waiting up to %s for in-flight vSphere tasks to complete", *shutdownGracePeriod)
+		if pending := machineActuator.WaitForTasks(*shutdownGracePeriod); len(pending) > 0 {
+			klog.Warningf("Exiting with vSphere tasks still in flight: %s", strings.Join(pending, ", "))
+		}
+	}
 }
@@ -1,11 +1,21 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+
 	"k8s.io/component-base/featuregate"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
-// AddToManager adds all Controllers to the Manager along with a feature gate accessor
+// LeaderElectionPath is the path of the metrics server on which the leader election status of the manager is served.
+const LeaderElectionPath = "/leader-election"
+
+var errNotLeader = errors.New("leader election lease not yet acquired")
+
+// AddToManager adds all Controllers to the Manager along with a feature gate accessor.
+// The reconcilers of the Controllers are expected to be gated by WithClusterPause and to watch ClusterPauseSource.
 func AddToManagerWithFeatureGates(m manager.Manager, opts manager.Options, featureGate featuregate.MutableFeatureGate, fnList ...func(manager.Manager, manager.Options, featuregate.MutableFeatureGate) error) error {
 	for _, f := range fnList {
 		if err := f(m, opts, featureGate); err != nil {
@@ -15,7 +25,8 @@ func AddToManagerWithFeatureGates(m manager.Manager, opts manager.Options, featu
 	return nil
 }
 
-// AddToManager adds all Controllers to the Manager
+// AddToManager adds all Controllers to the Manager.
+// The reconcilers of the Controllers are expected to be gated by WithClusterPause and to watch ClusterPauseSource.
 func AddToManager(m manager.Manager, opts manager.Options, fnList ...func(manager.Manager, manager.Options) error) error {
 	for _, f := range fnList {
 		if err := f(m, opts); err != nil {
@@ -24,3 +35,25 @@ func AddToManager(m manager.Manager, opts manager.Options, fnList ...func(manage
 	}
 	return nil
 }
+
+// AddLeaderElectionHandler serves the leader election status of the manager on LeaderElectionPath of the
+// metrics server. It responds OK once the manager has been elected leader, and Service Unavailable until then.
+// The manager closes its elected channel immediately when leader election is disabled.
+// The status is deliberately not a readyz check: the controller deployments roll out a single replica without
+// unavailability, so a new pod could not become ready while the old pod holds the lease and the rollout would stall.
+func AddLeaderElectionHandler(m manager.Manager) error {
+	return m.AddMetricsServerExtraHandler(LeaderElectionPath, leaderElectionHandler(m.Elected()))
+}
+
+// leaderElectionHandler returns an http.Handler that fails until the elected channel is closed.
+func leaderElectionHandler(elected <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		select {
+		case <-elected:
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintln(w, "ok")
+		default:
+			http.Error(w, errNotLeader.Error(), http.StatusServiceUnavailable)
+		}
+	})
+}
@@ -0,0 +1,257 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/config"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+func TestLeaderElectionHandler(t *testing.T) {
+	g := NewWithT(t)
+
+	// The lease is held by another replica until it is released below.
+	lock := &memoryResourceLock{
+		identity: "new-replica",
+		record: &resourcelock.LeaderElectionRecord{
+			HolderIdentity:       "old-replica",
+			LeaseDurationSeconds: 3600,
+			AcquireTime:          metav1.Now(),
+			RenewTime:            metav1.Now(),
+		},
+	}
+
+	// The manager never reaches the API server, the lease is kept in memory.
+	mgr, err := manager.New(&rest.Config{Host: "https://127.0.0.1:1"}, manager.Options{
+		LeaderElection:                      true,
+		LeaderElectionResourceLockInterface: lock,
+		LeaseDuration:                       ptr.To(time.Hour),
+		RenewDeadline:                       ptr.To(time.Second),
+		RetryPeriod:                         ptr.To(100 * time.Millisecond),
+		Metrics:                             metricsserver.Options{BindAddress: "0"},
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	handler := leaderElectionHandler(mgr.Elected())
+	status := func() int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, LeaderElectionPath, nil))
+		return rec.Code
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- mgr.Start(ctx) }()
+	defer func() {
+		cancel()
+		g.Expect(<-done).To(Succeed())
+	}()
+
+	// The replica waiting for the lease reports that it is not the leader.
+	g.Eventually(lock.gets.Load).Should(BeNumerically(">", 1))
+	g.Consistently(status, 500*time.Millisecond).Should(Equal(http.StatusServiceUnavailable))
+
+	// Once the old replica releases the lease, the manager acquires it and reports that it is the leader.
+	lock.release()
+	g.Eventually(status, 5*time.Second).Should(Equal(http.StatusOK))
+	g.Expect(lock.holder()).To(Equal("new-replica"))
+}
+
+func TestLeaderElectionHandlerWithoutLeaderElection(t *testing.T) {
+	g := NewWithT(t)
+
+	mgr, err := manager.New(&rest.Config{Host: "https://127.0.0.1:1"}, manager.Options{
+		Metrics: metricsserver.Options{BindAddress: "0"},
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	handler := leaderElectionHandler(mgr.Elected())
+	status := func() int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, LeaderElectionPath, nil))
+		return rec.Code
+	}
+
+	g.Expect(status()).To(Equal(http.StatusServiceUnavailable))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- mgr.Start(ctx) }()
+	defer func() {
+		cancel()
+		g.Expect(<-done).To(Succeed())
+	}()
+
+	// A manager which opted out of leader election reports that it is the leader as soon as it starts.
+	g.Eventually(status, 5*time.Second).Should(Equal(http.StatusOK))
+}
+
+// memoryResourceLock is a resourcelock.Interface which keeps the leader election record in memory, so that the
+// leader election of a manager runs without an API server.
+type memoryResourceLock struct {
+	identity string
+	gets     atomic.Int32
+
+	mu     sync.Mutex
+	record *resourcelock.LeaderElectionRecord
+}
+
+func (l *memoryResourceLock) Get(_ context.Context) (*resourcelock.LeaderElectionRecord, []byte, error) {
+	l.gets.Add(1)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.record == nil {
+		return nil, nil, apierrors.NewNotFound(schema.GroupResource{Group: "coordination.k8s.io", Resource: "leases"}, "test")
+	}
+	record := *l.record
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &record, raw, nil
+}
+
+func (l *memoryResourceLock) Create(_ context.Context, ler resourcelock.LeaderElectionRecord) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.record != nil {
+		return apierrors.NewAlreadyExists(schema.GroupResource{Group: "coordination.k8s.io", Resource: "leases"}, "test")
+	}
+	l.record = &ler
+	return nil
+}
+
+func (l *memoryResourceLock) Update(_ context.Context, ler resourcelock.LeaderElectionRecord) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.record = &ler
+	return nil
+}
+
+func (l *memoryResourceLock) RecordEvent(string) {}
+
+func (l *memoryResourceLock) Identity() string {
+	return l.identity
+}
+
+func (l *memoryResourceLock) Describe() string {
+	return "memory/test"
+}
+
+// release clears the holder of the lease, as a replica releasing the lease on shutdown does.
+func (l *memoryResourceLock) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.record.HolderIdentity = ""
+}
+
+func (l *memoryResourceLock) holder() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.record.HolderIdentity
+}
+
+func TestManagerMaxConcurrentReconciles(t *testing.T) {
+	testCases := []struct {
+		name                            string
+		maxConcurrentReconciles         int
+		expectedMaxConcurrentReconciles int32
+	}{
+		{
+			name:                            "with the controller-runtime default",
+			maxConcurrentReconciles:         0,
+			expectedMaxConcurrentReconciles: 1,
+		},
+		{
+			name:                            "with a concurrency set from flags",
+			maxConcurrentReconciles:         3,
+			expectedMaxConcurrentReconciles: 3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			// The manager never reaches the API server, as the controller is only fed by a channel.
+			mgr, err := manager.New(&rest.Config{Host: "https://127.0.0.1:1"}, manager.Options{
+				Controller: config.Controller{
+					MaxConcurrentReconciles: tc.maxConcurrentReconciles,
+					SkipNameValidation:      ptr.To(true),
+				},
+				Metrics: metricsserver.Options{BindAddress: "0"},
+			})
+			g.Expect(err).ToNot(HaveOccurred())
+
+			var inFlight, maxInFlight atomic.Int32
+			release := make(chan struct{})
+			r := reconcile.Func(func(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+				current := inFlight.Add(1)
+				defer inFlight.Add(-1)
+				for {
+					peak := maxInFlight.Load()
+					if current <= peak || maxInFlight.CompareAndSwap(peak, current) {
+						break
+					}
+				}
+				select {
+				case <-release:
+				case <-ctx.Done():
+				}
+				return reconcile.Result{}, nil
+			})
+
+			// Controllers are built with their reconciler only, like the controllers of this repository.
+			c, err := controller.New("concurrency-test", mgr, controller.Options{Reconciler: r})
+			g.Expect(err).ToNot(HaveOccurred())
+
+			events := make(chan event.GenericEvent, 5)
+			g.Expect(c.Watch(source.Channel(events, &handler.EnqueueRequestForObject{}))).To(Succeed())
+			for i := range cap(events) {
+				events <- event.GenericEvent{Object: &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+					Namespace: "test",
+					Name:      fmt.Sprintf("request-%d", i),
+				}}}
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error)
+			go func() { done <- mgr.Start(ctx) }()
+			defer func() {
+				cancel()
+				g.Expect(<-done).To(Succeed())
+			}()
+			defer close(release)
+
+			g.Eventually(inFlight.Load).Should(Equal(tc.expectedMaxConcurrentReconciles))
+			g.Consistently(maxInFlight.Load, 200*time.Millisecond).Should(Equal(tc.expectedMaxConcurrentReconciles))
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// CredentialsSecretHealthzCheckName is the name under which the credentials secret health check is registered.
+const CredentialsSecretHealthzCheckName = "credentials-secret"
+
+// credentialsSecretChecker periodically checks that the credentials secret of the provider exists and
+// is not empty. The result of the last check is reported by its healthz checker.
+type credentialsSecretChecker struct {
+	reader   client.Reader
+	key      client.ObjectKey
+	interval time.Duration
+
+	lock sync.RWMutex
+	err  error
+}
+
+// AddCredentialsSecretHealthzCheck registers a healthz check which fails while the credentials secret
+// identified by key is missing or empty. The secret is read directly from the API every interval,
+// secrets are not cached by the manager.
+func AddCredentialsSecretHealthzCheck(m manager.Manager, key client.ObjectKey, interval time.Duration) error {
+	checker := newCredentialsSecretChecker(m.GetAPIReader(), key, interval)
+	if err := m.Add(checker); err != nil {
+		return err
+	}
+	return m.AddHealthzCheck(CredentialsSecretHealthzCheckName, checker.Check)
+}
+
+func newCredentialsSecretChecker(reader client.Reader, key client.ObjectKey, interval time.Duration) *credentialsSecretChecker {
+	return &credentialsSecretChecker{
+		reader:   reader,
+		key:      key,
+		interval: interval,
+	}
+}
+
+// Start checks the credentials secret every interval until the context is done.
+func (c *credentialsSecretChecker) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, c.checkSecret, c.interval)
+	return nil
+}
+
+// NeedLeaderElection returns false, every replica reports its own health.
+func (c *credentialsSecretChecker) NeedLeaderElection() bool {
+	return false
+}
+
+// Check is a healthz.Checker returning the result of the last check of the credentials secret.
+func (c *credentialsSecretChecker) Check(_ *http.Request) error {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.err
+}
+
+// checkSecret records whether the credentials secret is missing or empty. Other errors getting the secret,
+// such as API timeouts or throttling, say nothing about the secret: they are logged and the result of the
+// last check is kept, so that they don't make the replica unhealthy.
+func (c *credentialsSecretChecker) checkSecret(ctx context.Context) {
+	var err error
+	secret := &corev1.Secret{}
+	if getErr := c.reader.Get(ctx, c.key, secret); getErr != nil {
+		if !apierrors.IsNotFound(getErr) {
+			klog.Warningf("Error getting credentials secret %s, keeping the last health check result: %v", c.key, getErr)
+			return
+		}
+		err = fmt.Errorf("credentials secret %s not found", c.key)
+	} else if len(secret.Data) == 0 && len(secret.StringData) == 0 {
+		err = fmt.Errorf("credentials secret %s is empty", c.key)
+	}
+
+	if err != nil {
+		klog.Warningf("Credentials secret health check failed: %v", err)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.err = err
+}
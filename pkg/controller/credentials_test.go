@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestCredentialsSecretChecker(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	key := client.ObjectKey{Namespace: "openshift-machine-api", Name: "vsphere-cloud-credentials"}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	checker := newCredentialsSecretChecker(fakeClient, key, time.Minute)
+	req := &http.Request{}
+
+	// The secret is considered healthy until it has been checked.
+	g.Expect(checker.Check(req)).To(Succeed())
+
+	checker.checkSecret(ctx)
+	g.Expect(checker.Check(req)).To(MatchError("credentials secret openshift-machine-api/vsphere-cloud-credentials not found"))
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+	}
+	g.Expect(fakeClient.Create(ctx, secret)).To(Succeed())
+
+	checker.checkSecret(ctx)
+	g.Expect(checker.Check(req)).To(MatchError("credentials secret openshift-machine-api/vsphere-cloud-credentials is empty"))
+
+	secret.Data = map[string][]byte{"vcenter.username": []byte("user")}
+	g.Expect(fakeClient.Update(ctx, secret)).To(Succeed())
+
+	checker.checkSecret(ctx)
+	g.Expect(checker.Check(req)).To(Succeed())
+
+	// Transient errors getting the secret keep the last result.
+	failing := newCredentialsSecretChecker(interceptor.NewClient(fakeClient, interceptor.Funcs{
+		Get: func(_ context.Context, _ client.WithWatch, _ client.ObjectKey, _ client.Object, _ ...client.GetOption) error {
+			return apierrors.NewTimeoutError("request timed out", 1)
+		},
+	}), key, time.Minute)
+	failing.err = checker.err
+	failing.checkSecret(ctx)
+	g.Expect(failing.Check(req)).To(Succeed())
+
+	failing.err = errors.New("credentials secret openshift-machine-api/vsphere-cloud-credentials is empty")
+	failing.checkSecret(ctx)
+	g.Expect(failing.Check(req)).To(MatchError("credentials secret openshift-machine-api/vsphere-cloud-credentials is empty"))
+
+	g.Expect(fakeClient.Delete(ctx, secret)).To(Succeed())
+
+	checker.checkSecret(ctx)
+	g.Expect(checker.Check(req)).To(MatchError("credentials secret openshift-machine-api/vsphere-cloud-credentials not found"))
+}
@@ -18,6 +18,7 @@ package machine
 
 import (
 	"context"
+	"errors"
 
 	machinev1 "github.com/openshift/api/machine/v1beta1"
 )
@@ -34,3 +35,40 @@ type Actuator interface {
 	// Checks if the machine currently exists.
 	Exists(context.Context, *machinev1.Machine) (bool, error)
 }
+
+// DriftDetector is optionally implemented by actuators which are able to compare
+// a running instance with the providerSpec of its Machine.
+type DriftDetector interface {
+	// Drift returns the providerSpec fields for which the running instance differs from the Machine.
+	// It must not modify the instance.
+	Drift(context.Context, *machinev1.Machine) ([]ProviderSpecDrift, error)
+}
+
+// UpdateReporter is optionally implemented by actuators which are able to tell
+// whether an idempotent update made any change to the instance.
+type UpdateReporter interface {
+	// UpdateWithResult updates the machine as Update does, and returns true when the instance has been changed.
+	UpdateWithResult(context.Context, *machinev1.Machine) (bool, error)
+}
+
+// ErrVolumeAttachUnsupported is returned by a VolumeAttacher when the volumes of the providerSpec
+// can not be attached to the existing instance, which has to be replaced to get them.
+var ErrVolumeAttachUnsupported = errors.New("attaching volumes to an existing instance is not supported")
+
+// VolumeAttacher is optionally implemented by actuators which are able to attach volumes
+// added to the providerSpec of a Machine to its existing instance, without replacing it.
+type VolumeAttacher interface {
+	// AttachVolumes attaches the volumes of the providerSpec which are missing from the instance and returns their names.
+	// It must be idempotent, no names are returned when the instance already has all of its volumes.
+	AttachVolumes(context.Context, *machinev1.Machine) ([]string, error)
+}
+
+// ProviderSpecDrift describes a providerSpec field which has drifted from the running instance.
+type ProviderSpecDrift struct {
+	// Field is the path of the drifted field within the providerSpec, eg. instanceType.
+	Field string
+	// Expected is the value of the field in the providerSpec.
+	Expected string
+	// Actual is the value observed on the running instance.
+	Actual string
+}
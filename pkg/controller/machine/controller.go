@@ -21,10 +21,12 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
 	openshiftfeatures "github.com/openshift/api/features"
 	machinev1 "github.com/openshift/api/machine/v1beta1"
+	mapicontroller "github.com/openshift/machine-api-operator/pkg/controller"
 	"github.com/openshift/machine-api-operator/pkg/metrics"
 	"github.com/openshift/machine-api-operator/pkg/util"
 	"github.com/openshift/machine-api-operator/pkg/util/conditions"
@@ -51,9 +53,43 @@ const (
 	NodeNameEnvVar = "NODE_NAME"
 	requeueAfter   = 30 * time.Second
 
+	// machineControllerName is the name of the machine controller
+	machineControllerName = "machine-controller"
+
 	// ExcludeNodeDrainingAnnotation annotation explicitly skips node draining if set
 	ExcludeNodeDrainingAnnotation = "machine.openshift.io/exclude-node-draining"
 
+	// MachineInterruptedAnnotation annotation is set by a termination handler when the instance of the Machine
+	// is being reclaimed by the cloud provider, for example a spot instance. The node drain is skipped when
+	// SkipDrainOnInterruption is enabled, as the node is going away before the drain could complete.
+	MachineInterruptedAnnotation = "machine.openshift.io/interrupted"
+
+	// HoldDeletionAnnotation annotation holds the deletion of a Machine if set, for example when a lifecycle hook
+	// blocks it indefinitely. The node is uncordoned and restored to service until the annotation is removed,
+	// after which the deletion resumes with a new drain.
+	HoldDeletionAnnotation = "machine.openshift.io/hold-deletion"
+
+	// CordonOnDeletionAnnotation annotation taints the node of a Machine with the MachineDeletingTaintKey NoSchedule
+	// taint as soon as the Machine is deleted, so that no new pods are scheduled on the node while pre-drain
+	// lifecycle hooks block its drain. The taint is never removed as the node is deleted with the Machine.
+	CordonOnDeletionAnnotation = "machine.openshift.io/cordon-on-deletion"
+
+	// MachineProtectedAnnotation protects a Machine from deletion when set to "true". The deletion is denied by the
+	// Machine validating webhook, and protected Machines are skipped when their MachineSet scales down.
+	MachineProtectedAnnotation = "machine.openshift.io/protected"
+
+	// MachineForceDeleteAnnotation allows the deletion of a protected Machine when set to "true".
+	// A delete request does not carry the object, the annotation has to be set on the Machine beforehand.
+	MachineForceDeleteAnnotation = "machine.openshift.io/force-delete"
+
+	// MachineDeletingTaintKey is the key of the taint added to the node of a deleted Machine with the CordonOnDeletionAnnotation
+	MachineDeletingTaintKey = "machine.openshift.io/deleting"
+
+	// AdoptProviderIDAnnotation gives the providerID of an existing instance to adopt instead of creating a new one
+	// when the Machine is created. The Machine fails with an invalid configuration error when the instance is not found
+	// or is owned by another Machine. It can only be set when the Machine is created.
+	AdoptProviderIDAnnotation = "machine.openshift.io/adopt-provider-id"
+
 	// MachineRegionLabelName as annotation name for a machine region
 	MachineRegionLabelName = "machine.openshift.io/region"
 
@@ -73,6 +109,23 @@ const (
 	unknownInstanceState = "Unknown"
 
 	skipWaitForDeleteTimeoutSeconds = 1
+
+	// ProviderSpecDriftReason is the event reason used when a running instance has drifted from its providerSpec
+	ProviderSpecDriftReason = "ProviderSpecDrift"
+
+	// VolumesAttachedReason is the event reason used when volumes have been attached to an existing instance
+	VolumesAttachedReason = "VolumesAttached"
+
+	// VolumeAttachUnsupportedReason is the event reason used when volumes can not be attached to an existing instance
+	VolumeAttachUnsupportedReason = "VolumeAttachUnsupported"
+
+	// DeletionHeldReason is the Drained condition reason and the event reason used while the deletion of a Machine
+	// is held by the HoldDeletionAnnotation
+	DeletionHeldReason = "DeletionHeld"
+
+	// NodeTaintedReason is the event reason used when the node of a deleted Machine is tainted because of the
+	// CordonOnDeletionAnnotation
+	NodeTaintedReason = "NodeTainted"
 )
 
 // We export the PausedCondition and reasons as they're shared
@@ -87,42 +140,120 @@ const (
 
 var DefaultActuator Actuator
 
+// Options are the settings of the machine and drain controllers, which the provider machine controllers
+// usually bind to flags. DefaultOptions returns their defaults.
+type Options struct {
+	// Controller are the controller-runtime options of the machine and drain controllers.
+	Controller controller.Options
+
+	// DetectProviderSpecDrift enables reporting of running instances which have drifted from their providerSpec.
+	// It only has an effect when the actuator implements DriftDetector.
+	DetectProviderSpecDrift bool
+
+	// ReconcileTimeout is the deadline of a single Machine reconcile, so that a Machine stuck in a slow cloud API
+	// call does not hold a worker indefinitely. Reconciles exceeding it are requeued. Zero disables the deadline.
+	ReconcileTimeout time.Duration
+
+	// ProvisioningStuckThreshold and DeletingStuckThreshold are how long a Machine can be in the Provisioning and
+	// Deleting phases before the Stuck condition is set. Zero disables the condition for the phase.
+	ProvisioningStuckThreshold time.Duration
+	DeletingStuckThreshold     time.Duration
+
+	// ErrorLogInterval is how often the same reconcile error of a Machine is logged. Repeated errors within the interval
+	// are suppressed and counted. Zero logs every error.
+	ErrorLogInterval time.Duration
+
+	// SkipDrainOnInterruption skips the node drain of deleted Machines with the MachineInterruptedAnnotation, so that
+	// the instance and the node are removed within the termination notice of the cloud provider.
+	SkipDrainOnInterruption bool
+
+	// NodeReadinessPolicy selects when the node of a provisioned Machine is considered ready for the Machine to go Running.
+	NodeReadinessPolicy NodeReadiness
+
+	// NodeReadyTimeout is how long the node of a Machine can stay not Ready, with the NodeReadinessReady policy, before
+	// the NodeReady condition reason is set to NodeReadyTimeout. The Machine keeps waiting for the node. Zero disables it.
+	NodeReadyTimeout time.Duration
+
+	// ClusterPauseNamespace is the namespace of the machine-api-operator, in which it creates the
+	// ClusterPauseConfigMapName ConfigMap to pause the machine and drain controllers.
+	ClusterPauseNamespace string
+}
+
+// DefaultOptions returns the default settings of the machine and drain controllers.
+func DefaultOptions() Options {
+	return Options{
+		ProvisioningStuckThreshold: time.Hour,
+		DeletingStuckThreshold:     time.Hour,
+		ErrorLogInterval:           5 * time.Minute,
+		NodeReadinessPolicy:        NodeReadinessExists,
+		NodeReadyTimeout:           10 * time.Minute,
+		ClusterPauseNamespace:      mapicontroller.DefaultClusterPauseNamespace,
+	}
+}
+
 func AddWithActuator(mgr manager.Manager, actuator Actuator, gate featuregate.MutableFeatureGate) error {
-	return AddWithActuatorOpts(mgr, actuator, controller.Options{}, gate)
+	return AddWithActuatorOpts(mgr, actuator, DefaultOptions(), gate)
 }
 
-func AddWithActuatorOpts(mgr manager.Manager, actuator Actuator, opts controller.Options, gate featuregate.MutableFeatureGate) error {
-	machineControllerOpts := opts
-	machineControllerOpts.Reconciler = newReconciler(mgr, actuator, gate)
+// AddWithActuatorOpts adds the machine and drain controllers of the actuator to the manager with the given options.
+func AddWithActuatorOpts(mgr manager.Manager, actuator Actuator, opts Options, gate featuregate.MutableFeatureGate) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(),
+		&machinev1.Machine{},
+		machineProviderIDIndex,
+		indexMachineByProviderID,
+	); err != nil {
+		return fmt.Errorf("error setting index fields: %v", err)
+	}
 
-	if err := addWithOpts(mgr, machineControllerOpts, "machine-controller"); err != nil {
+	machineControllerOpts := opts.Controller
+	machineControllerOpts.Reconciler = mapicontroller.WithClusterPause(machineControllerName, mgr.GetClient(),
+		opts.ClusterPauseNamespace, newReconciler(mgr, actuator, gate, opts), newMachine)
+
+	if err := addWithOpts(mgr, machineControllerOpts, machineControllerName, opts.ClusterPauseNamespace); err != nil {
 		return err
 	}
 
 	if err := addWithOpts(mgr, controller.Options{
-		Reconciler:  newDrainController(mgr),
+		Reconciler: mapicontroller.WithClusterPause(drainControllerName, mgr.GetClient(),
+			opts.ClusterPauseNamespace, newDrainController(mgr, opts), newMachine),
 		RateLimiter: newDrainRateLimiter(),
-	}, "machine-drain-controller"); err != nil {
+	}, drainControllerName, opts.ClusterPauseNamespace); err != nil {
 		return err
 	}
 	return nil
 }
 
+// newMachine returns the Machine whose ClusterPausedCondition is set by the cluster pause gate.
+func newMachine() client.Object {
+	return &machinev1.Machine{}
+}
+
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager, actuator Actuator, gate featuregate.MutableFeatureGate) reconcile.Reconciler {
+func newReconciler(mgr manager.Manager, actuator Actuator, gate featuregate.MutableFeatureGate, opts Options) reconcile.Reconciler {
 	r := &ReconcileMachine{
 		Client:        mgr.GetClient(),
-		eventRecorder: mgr.GetEventRecorderFor("machine-controller"),
+		eventRecorder: mgr.GetEventRecorderFor(machineControllerName),
 		config:        mgr.GetConfig(),
 		scheme:        mgr.GetScheme(),
 		actuator:      actuator,
 		gate:          gate,
+		detectDrift:   opts.DetectProviderSpecDrift,
+
+		reconcileTimeout: opts.ReconcileTimeout,
+
+		provisioningStuckThreshold: opts.ProvisioningStuckThreshold,
+		deletingStuckThreshold:     opts.DeletingStuckThreshold,
+
+		nodeReadiness:    opts.NodeReadinessPolicy,
+		nodeReadyTimeout: opts.NodeReadyTimeout,
+
+		errorLogger: util.NewRateLimitedLogger(opts.ErrorLogInterval),
 	}
 	return r
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
-func addWithOpts(mgr manager.Manager, opts controller.Options, controllerName string) error {
+func addWithOpts(mgr manager.Manager, opts controller.Options, controllerName, clusterPauseNamespace string) error {
 	// Create a new controller
 	c, err := controller.New(controllerName, mgr, opts)
 	if err != nil {
@@ -130,10 +261,15 @@ func addWithOpts(mgr manager.Manager, opts controller.Options, controllerName st
 	}
 
 	// Watch for changes to Machine
-	return c.Watch(
+	if err := c.Watch(
 		source.Kind(mgr.GetCache(), &machinev1.Machine{},
 			&handler.TypedEnqueueRequestForObject[*machinev1.Machine]{},
-		))
+		)); err != nil {
+		return err
+	}
+
+	// Reconcile all the Machines again when the cluster pause ends
+	return c.Watch(mapicontroller.ClusterPauseSource(mgr, clusterPauseNamespace, func() client.ObjectList { return &machinev1.MachineList{} }))
 }
 
 // ReconcileMachine reconciles a Machine object
@@ -147,6 +283,26 @@ type ReconcileMachine struct {
 	actuator Actuator
 	gate     featuregate.MutableFeatureGate
 
+	// detectDrift enables providerSpec drift reporting for actuators implementing DriftDetector.
+	detectDrift bool
+
+	// reconcileTimeout is the deadline of a single reconcile, zero disables it.
+	reconcileTimeout time.Duration
+
+	// provisioningStuckThreshold and deletingStuckThreshold are how long a Machine can be in the Provisioning
+	// and Deleting phases before it is reported as stuck, zero disables the Stuck condition for the phase.
+	provisioningStuckThreshold time.Duration
+	deletingStuckThreshold     time.Duration
+
+	// nodeReadiness selects when the node of a provisioned Machine is considered ready for the Machine to go Running.
+	// nodeReadyTimeout is how long the node can stay not Ready before it is reported, zero disables the timeout.
+	nodeReadiness    NodeReadiness
+	nodeReadyTimeout time.Duration
+
+	// errorLogger rate limits the logging of errors repeated on every reconcile of a Machine.
+	// A nil errorLogger logs every error.
+	errorLogger *util.RateLimitedLogger
+
 	// nowFunc is used to mock time in testing. It should be nil in production.
 	nowFunc func() time.Time
 }
@@ -155,12 +311,33 @@ type ReconcileMachine struct {
 // and what is in the Machine.Spec
 // +kubebuilder:rbac:groups=machine.openshift.io,resources=machines;machines/status,verbs=get;list;watch;create;update;patch;delete
 func (r *ReconcileMachine) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	if r.reconcileTimeout <= 0 {
+		return r.reconcile(ctx, request)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.reconcileTimeout)
+	defer cancel()
+
+	// Only the reconciles which failed because of the deadline are requeued, a reconcile which finished or failed
+	// otherwise right when the deadline passed keeps its own result.
+	result, err := r.reconcile(ctx, request)
+	if errors.Is(err, context.DeadlineExceeded) {
+		klog.Warningf("%v: reconcile timed out after %v, requeuing: %v", request.Name, r.reconcileTimeout, err)
+		metrics.RegisterMachineReconcileTimeout()
+		return reconcile.Result{RequeueAfter: requeueAfter}, nil
+	}
+	return result, err
+}
+
+func (r *ReconcileMachine) reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
 	// Fetch the Machine instance
 	m := &machinev1.Machine{}
 	if err := r.Client.Get(ctx, request.NamespacedName, m); err != nil {
 		if apierrors.IsNotFound(err) {
 			// Object not found, return.  Created objects are automatically garbage collected.
 			// For additional cleanup logic use finalizers.
+			// The series of Machines whose finalizer was removed by someone else are dropped here.
+			deleteMachineMetrics(&metrics.MachineLabels{Name: request.Name, Namespace: request.Namespace})
 			return reconcile.Result{}, nil
 		}
 
@@ -170,6 +347,7 @@ func (r *ReconcileMachine) Reconcile(ctx context.Context, request reconcile.Requ
 
 	// Implement controller logic here
 	machineName := m.GetName()
+	machineKey := client.ObjectKeyFromObject(m).String()
 	klog.Infof("%v: reconciling Machine", machineName)
 
 	// Get the original state of conditions now so that they can be used to calculate the patch later.
@@ -255,13 +433,35 @@ func (r *ReconcileMachine) Reconcile(ctx context.Context, request reconcile.Requ
 			return reconcile.Result{}, nil
 		}
 
+		if _, held := m.ObjectMeta.Annotations[HoldDeletionAnnotation]; held {
+			klog.Infof("%v: not deleting machine: deletion held by %s annotation", machineName, HoldDeletionAnnotation)
+			return reconcile.Result{}, r.holdDeletion(ctx, m, originalConditions)
+		}
+
+		if _, cordon := m.ObjectMeta.Annotations[CordonOnDeletionAnnotation]; cordon && m.Status.NodeRef != nil {
+			tainted, err := r.taintDeletingNode(ctx, m.Status.NodeRef.Name)
+			if err != nil {
+				klog.Errorf("%v: failed to taint node %q: %v", machineName, m.Status.NodeRef.Name, err)
+				return reconcile.Result{}, err
+			}
+			if tainted {
+				r.eventRecorder.Eventf(m, corev1.EventTypeNormal, NodeTaintedReason, "Node %q tainted with %s:%s, machine is being deleted", m.Status.NodeRef.Name, MachineDeletingTaintKey, corev1.TaintEffectNoSchedule)
+			}
+		}
+
 		klog.Infof("%v: reconciling machine triggers delete", machineName)
 		// check if machine was already drained
 		drainedCondition := conditions.Get(m, machinev1.MachineDrained)
 		if drainedCondition == nil || drainedCondition.Status != corev1.ConditionTrue {
-			klog.Infof("%s: waiting for node to be drained before deleting instance", machineName)
-			// this will requeue and proceed when drain controller will set the condition
-			return reconcile.Result{}, nil
+			skipped, err := r.skipDrainOfGoneInstance(ctx, m, originalConditions)
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+			if !skipped {
+				klog.Infof("%s: waiting for node to be drained before deleting instance", machineName)
+				// this will requeue and proceed when drain controller will set the condition
+				return reconcile.Result{}, nil
+			}
 		}
 
 		// pre-term.delete lifecycle hook
@@ -279,14 +479,14 @@ func (r *ReconcileMachine) Reconcile(ctx context.Context, request reconcile.Requ
 			// we can loose instances, e.g. right after request to create one
 			// was sent and before a list of node addresses was set.
 			if len(m.Status.Addresses) > 0 || !isInvalidMachineConfigurationError(err) {
-				klog.Errorf("%v: failed to delete machine: %v", machineName, err)
+				r.errorLogger.Errorf(machineKey, "delete", "%v: failed to delete machine: %v", machineName, err)
 				return delayIfRequeueAfterError(err)
 			}
 		}
 
 		instanceExists, err := r.actuator.Exists(ctx, m)
 		if err != nil {
-			klog.Errorf("%v: failed to check if machine exists: %v", machineName, err)
+			r.errorLogger.Errorf(machineKey, "exists", "%v: failed to check if machine exists: %v", machineName, err)
 			return reconcile.Result{}, err
 		}
 
@@ -310,6 +510,8 @@ func (r *ReconcileMachine) Reconcile(ctx context.Context, request reconcile.Requ
 			return reconcile.Result{}, err
 		}
 
+		deleteMachineMetrics(&metrics.MachineLabels{Name: m.GetName(), Namespace: m.GetNamespace()})
+		r.errorLogger.Forget(machineKey)
 		klog.Infof("%v: machine deletion successful", machineName)
 		return reconcile.Result{}, nil
 	}
@@ -319,9 +521,12 @@ func (r *ReconcileMachine) Reconcile(ctx context.Context, request reconcile.Requ
 		return reconcile.Result{}, nil
 	}
 
+	r.reportDuplicateProviderID(ctx, m)
+
 	instanceExists, err := r.actuator.Exists(ctx, m)
 	if err != nil {
-		klog.Errorf("%v: failed to check if machine exists: %v", machineName, err)
+		r.errorLogger.Errorf(machineKey, "exists", "%v: failed to check if machine exists: %v", machineName, err)
+		metrics.RegisterMachineReconcileResult(metrics.MachineReconcileError)
 
 		conditions.Set(m, conditions.UnknownCondition(
 			machinev1.InstanceExistsCondition,
@@ -337,9 +542,14 @@ func (r *ReconcileMachine) Reconcile(ctx context.Context, request reconcile.Requ
 	}
 
 	if instanceExists {
+		// Check for drift before the update, so that what is reported is the instance as it was found
+		r.reportProviderSpecDrift(ctx, m)
+
 		klog.Infof("%v: reconciling machine triggers idempotent update", machineName)
-		if err := r.actuator.Update(ctx, m); err != nil {
-			klog.Errorf("%v: error updating machine: %v, retrying in %v seconds", machineName, err, requeueAfter)
+		updated, err := r.updateInstance(ctx, m)
+		if err != nil {
+			r.errorLogger.Errorf(machineKey, "update", "%v: error updating machine: %v, retrying in %v seconds", machineName, err, requeueAfter)
+			metrics.RegisterMachineReconcileResult(metrics.MachineReconcileError)
 
 			if patchErr := r.updateStatus(ctx, m, ptr.Deref(m.Status.Phase, ""), nil, originalConditions); patchErr != nil {
 				klog.Errorf("%v: error patching status: %v", machineName, patchErr)
@@ -348,6 +558,16 @@ func (r *ReconcileMachine) Reconcile(ctx context.Context, request reconcile.Requ
 			return reconcile.Result{RequeueAfter: requeueAfter}, nil
 		}
 
+		if r.attachVolumes(ctx, m) {
+			updated = true
+		}
+
+		if updated {
+			metrics.RegisterMachineReconcileResult(metrics.MachineReconcileUpdated)
+		} else {
+			metrics.RegisterMachineReconcileResult(metrics.MachineReconcileNoop)
+		}
+
 		// Mark the instance exists condition true after actuator update else the update may overwrite changes
 		conditions.MarkTrue(m, machinev1.InstanceExistsCondition)
 
@@ -369,6 +589,21 @@ func (r *ReconcileMachine) Reconcile(ctx context.Context, request reconcile.Requ
 			return reconcile.Result{RequeueAfter: requeueAfter}, nil
 		}
 
+		if ptr.Deref(m.Status.Phase, "") != machinev1.PhaseRunning {
+			nodeReady, err := r.waitForNodeReady(ctx, m)
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+			if !nodeReady {
+				// Requeue until the node is ready
+				if err := r.updateStatus(ctx, m, machinev1.PhaseProvisioned, nil, originalConditions); err != nil {
+					return reconcile.Result{}, err
+				}
+				klog.Infof("%v: node %q is not Ready yet, requeuing", machineName, m.Status.NodeRef.Name)
+				return reconcile.Result{RequeueAfter: requeueAfter}, nil
+			}
+		}
+
 		return reconcile.Result{}, r.updateStatus(ctx, m, machinev1.PhaseRunning, nil, originalConditions)
 	}
 
@@ -404,9 +639,17 @@ func (r *ReconcileMachine) Reconcile(ctx context.Context, request reconcile.Requ
 		return reconcile.Result{}, nil
 	}
 
+	// The status is not updated while the instance is being created, persist the Stuck condition once it is set
+	if r.isPhaseStuck(m, ptr.Deref(m.Status.Phase, "")) && !conditions.IsTrue(m, StuckCondition) {
+		if err := r.updateStatus(ctx, m, ptr.Deref(m.Status.Phase, ""), nil, originalConditions); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
 	klog.Infof("%v: reconciling machine triggers idempotent create", machineName)
 	if err := r.actuator.Create(ctx, m); err != nil {
-		klog.Warningf("%v: failed to create machine: %v", machineName, err)
+		r.errorLogger.Warningf(machineKey, "create", "%v: failed to create machine: %v", machineName, err)
+		metrics.RegisterMachineReconcileResult(metrics.MachineReconcileError)
 		if isInvalidMachineConfigurationError(err) {
 			if err := r.updateStatus(ctx, m, machinev1.PhaseFailed, err, originalConditions); err != nil {
 				return reconcile.Result{}, err
@@ -417,9 +660,152 @@ func (r *ReconcileMachine) Reconcile(ctx context.Context, request reconcile.Requ
 	}
 
 	klog.Infof("%v: created instance, requeuing", machineName)
+	metrics.RegisterMachineReconcileResult(metrics.MachineReconcileCreated)
 	return reconcile.Result{RequeueAfter: requeueAfter}, nil
 }
 
+// updateInstance runs the idempotent update of the actuator and returns whether the instance has been changed.
+// Actuators which are not able to tell are assumed to have changed it.
+func (r *ReconcileMachine) updateInstance(ctx context.Context, m *machinev1.Machine) (bool, error) {
+	if reporter, ok := r.actuator.(UpdateReporter); ok {
+		return reporter.UpdateWithResult(ctx, m)
+	}
+	return true, r.actuator.Update(ctx, m)
+}
+
+// attachVolumes attaches the volumes added to the providerSpec to the existing instance, when supported by the actuator,
+// and returns whether any volume has been attached. Failures are reported with events and retried on the next reconcile.
+func (r *ReconcileMachine) attachVolumes(ctx context.Context, m *machinev1.Machine) bool {
+	attacher, ok := r.actuator.(VolumeAttacher)
+	if !ok {
+		return false
+	}
+
+	volumes, err := attacher.AttachVolumes(ctx, m)
+	switch {
+	case errors.Is(err, ErrVolumeAttachUnsupported):
+		klog.Warningf("%v: unable to attach volumes: %v", m.GetName(), err)
+		r.eventRecorder.Eventf(m, corev1.EventTypeWarning, VolumeAttachUnsupportedReason, "Volumes can not be attached to the existing instance: %v", err)
+		return false
+	case err != nil:
+		klog.Errorf("%v: failed to attach volumes: %v", m.GetName(), err)
+		r.eventRecorder.Eventf(m, corev1.EventTypeWarning, "FailedAttachVolumes", "Failed to attach volumes: %v", err)
+		return false
+	case len(volumes) == 0:
+		return false
+	}
+
+	klog.Infof("%v: attached volumes %v", m.GetName(), volumes)
+	r.eventRecorder.Eventf(m, corev1.EventTypeNormal, VolumesAttachedReason, "Attached volumes %s to the existing instance", strings.Join(volumes, ", "))
+	return true
+}
+
+// reportProviderSpecDrift emits an event and increments the drift metric for each providerSpec
+// field which differs from the running instance. The drift is not corrected.
+func (r *ReconcileMachine) reportProviderSpecDrift(ctx context.Context, m *machinev1.Machine) {
+	if !r.detectDrift {
+		return
+	}
+
+	detector, ok := r.actuator.(DriftDetector)
+	if !ok {
+		return
+	}
+
+	drifts, err := detector.Drift(ctx, m)
+	if err != nil {
+		klog.Warningf("%v: failed to check for providerSpec drift: %v", m.GetName(), err)
+		return
+	}
+
+	for _, drift := range drifts {
+		klog.Warningf("%v: instance has drifted from providerSpec: %s is %q, expected %q", m.GetName(), drift.Field, drift.Actual, drift.Expected)
+		r.eventRecorder.Eventf(m, corev1.EventTypeWarning, ProviderSpecDriftReason, "Instance has drifted from providerSpec: %s is %q, expected %q", drift.Field, drift.Actual, drift.Expected)
+		metrics.RegisterProviderSpecDrift(&metrics.MachineLabels{
+			Name:      m.GetName(),
+			Namespace: m.GetNamespace(),
+		}, drift.Field)
+	}
+}
+
+// holdDeletion restores the node of a deleting Machine to service while its deletion is held.
+// The drain is marked as not done, so the drain controller drains the node again once the hold is released.
+func (r *ReconcileMachine) holdDeletion(ctx context.Context, m *machinev1.Machine, originalConditions []machinev1.Condition) error {
+	if m.Status.NodeRef != nil {
+		uncordoned, err := r.uncordonNode(ctx, m.Status.NodeRef.Name)
+		if err != nil {
+			klog.Errorf("%v: failed to uncordon node %q: %v", m.GetName(), m.Status.NodeRef.Name, err)
+			return err
+		}
+		if uncordoned {
+			r.eventRecorder.Eventf(m, corev1.EventTypeNormal, DeletionHeldReason, "Node %q uncordoned, deletion held by %s annotation", m.Status.NodeRef.Name, HoldDeletionAnnotation)
+		}
+	}
+
+	if drained := conditions.Get(m, machinev1.MachineDrained); drained == nil || drained.Reason != DeletionHeldReason {
+		conditions.Set(m, conditions.FalseCondition(
+			machinev1.MachineDrained,
+			DeletionHeldReason,
+			machinev1.ConditionSeverityInfo,
+			"Deletion held by %s annotation", HoldDeletionAnnotation,
+		))
+		r.eventRecorder.Eventf(m, corev1.EventTypeNormal, DeletionHeldReason, "Deletion held by %s annotation", HoldDeletionAnnotation)
+	}
+
+	return r.updateStatus(ctx, m, machinev1.PhaseDeleting, nil, originalConditions)
+}
+
+// uncordonNode marks a node as schedulable and returns whether it was cordoned.
+func (r *ReconcileMachine) uncordonNode(ctx context.Context, name string) (bool, error) {
+	var node corev1.Node
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: name}, &node); err != nil {
+		if apierrors.IsNotFound(err) {
+			klog.V(2).Infof("Node %q not found", name)
+			return false, nil
+		}
+		return false, err
+	}
+
+	if !node.Spec.Unschedulable {
+		return false, nil
+	}
+
+	baseToPatch := client.MergeFrom(node.DeepCopy())
+	node.Spec.Unschedulable = false
+	if err := r.Client.Patch(ctx, &node, baseToPatch); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// taintDeletingNode adds the MachineDeletingTaintKey NoSchedule taint to the node, unless it is already present.
+// It returns true when the taint was added.
+func (r *ReconcileMachine) taintDeletingNode(ctx context.Context, name string) (bool, error) {
+	var node corev1.Node
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: name}, &node); err != nil {
+		if apierrors.IsNotFound(err) {
+			klog.V(2).Infof("Node %q not found", name)
+			return false, nil
+		}
+		return false, err
+	}
+
+	taint := corev1.Taint{Key: MachineDeletingTaintKey, Effect: corev1.TaintEffectNoSchedule}
+	for _, t := range node.Spec.Taints {
+		if t.MatchTaint(&taint) {
+			return false, nil
+		}
+	}
+
+	// The taints are a list, which a merge patch replaces as a whole, so the patch must not overwrite concurrent edits
+	baseToPatch := client.MergeFromWithOptions(node.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	node.Spec.Taints = append(node.Spec.Taints, taint)
+	if err := r.Client.Patch(ctx, &node, baseToPatch); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (r *ReconcileMachine) deleteNode(ctx context.Context, name string) error {
 	var node corev1.Node
 	if err := r.Client.Get(ctx, client.ObjectKey{Name: name}, &node); err != nil {
@@ -433,6 +819,17 @@ func (r *ReconcileMachine) deleteNode(ctx context.Context, name string) error {
 	return r.Client.Delete(ctx, &node)
 }
 
+// IsDeletionProtected returns true if the deletion of the Machine is denied by the MachineProtectedAnnotation.
+func IsDeletionProtected(m *machinev1.Machine) bool {
+	return m.Annotations[MachineProtectedAnnotation] == "true" && m.Annotations[MachineForceDeleteAnnotation] != "true"
+}
+
+// deleteMachineMetrics removes the series reported by the controller for a deleted Machine.
+func deleteMachineMetrics(labels *metrics.MachineLabels) {
+	metrics.DeleteMachinePhaseStartTime(labels)
+	metrics.DeleteMachineDuplicateProviderID(labels)
+}
+
 func delayIfRequeueAfterError(err error) (reconcile.Result, error) {
 	var requeueAfterError *RequeueAfterError
 	if errors.As(err, &requeueAfterError) {
@@ -467,6 +864,9 @@ func (r *ReconcileMachine) updateStatus(ctx context.Context, machine *machinev1.
 	// Ensure the lifecycle hook conditions are accurate whenever the status is updated
 	setLifecycleHookConditions(machine)
 
+	// Report the time spent in the phase being set whenever the status is updated
+	r.setStuckCondition(machine, phase)
+
 	// Conditions need to be deep copied as they are set outside of this function.
 	// They will be restored after any updates to the base (done by patching annotations).
 	conditions := conditions.DeepCopyConditions(machine.Status.Conditions)
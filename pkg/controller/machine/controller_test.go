@@ -26,17 +26,26 @@ import (
 
 	. "github.com/onsi/gomega"
 	machinev1 "github.com/openshift/api/machine/v1beta1"
+	mapicontroller "github.com/openshift/machine-api-operator/pkg/controller"
+	"github.com/openshift/machine-api-operator/pkg/metrics"
 	"github.com/openshift/machine-api-operator/pkg/util/conditions"
 	testutils "github.com/openshift/machine-api-operator/pkg/util/testing"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -1240,3 +1249,1657 @@ func TestSetLifecycleHookConditions(t *testing.T) {
 		})
 	}
 }
+
+func TestReconcileProviderSpecDrift(t *testing.T) {
+	newRunningMachine := func(name string) *machinev1.Machine {
+		return &machinev1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       name,
+				Namespace:  "default",
+				Finalizers: []string{machinev1.MachineFinalizer},
+				Labels: map[string]string{
+					machinev1.MachineClusterIDLabel: "testcluster",
+				},
+			},
+			Spec: machinev1.MachineSpec{
+				AuthoritativeAPI: machinev1.MachineAuthorityMachineAPI,
+				ProviderID:       ptr.To[string]("providerID"),
+				ProviderSpec: machinev1.ProviderSpec{
+					Value: &runtime.RawExtension{
+						Raw: []byte("{}"),
+					},
+				},
+			},
+			Status: machinev1.MachineStatus{
+				AuthoritativeAPI: machinev1.MachineAuthorityMachineAPI,
+				Addresses: []corev1.NodeAddress{
+					{
+						Type:    corev1.NodeInternalIP,
+						Address: "0.0.0.0",
+					},
+				},
+				NodeRef: &corev1.ObjectReference{
+					Name: "a node",
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name               string
+		detectDrift        bool
+		drift              []ProviderSpecDrift
+		driftError         error
+		expectedDriftCalls int64
+		expectedEvents     []string
+	}{
+		{
+			name:        "with drift detection disabled",
+			detectDrift: false,
+			drift: []ProviderSpecDrift{
+				{Field: "instanceType", Expected: "m5.large", Actual: "m5.xlarge"},
+			},
+			expectedDriftCalls: 0,
+			expectedEvents:     []string{},
+		},
+		{
+			name:               "with an instance matching the providerSpec",
+			detectDrift:        true,
+			expectedDriftCalls: 1,
+			expectedEvents:     []string{},
+		},
+		{
+			name:        "with an instance which has drifted from the providerSpec",
+			detectDrift: true,
+			drift: []ProviderSpecDrift{
+				{Field: "instanceType", Expected: "m5.large", Actual: "m5.xlarge"},
+				{Field: "tags", Expected: "owner=team-a", Actual: ""},
+			},
+			expectedDriftCalls: 1,
+			expectedEvents: []string{
+				"Warning ProviderSpecDrift Instance has drifted from providerSpec: instanceType is \"m5.xlarge\", expected \"m5.large\"",
+				"Warning ProviderSpecDrift Instance has drifted from providerSpec: tags is \"\", expected \"owner=team-a\"",
+			},
+		},
+		{
+			name:               "when drift detection fails",
+			detectDrift:        true,
+			driftError:         errors.New("failed to describe instance"),
+			expectedDriftCalls: 1,
+			expectedEvents:     []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			gate, err := testutils.NewDefaultMutableFeatureGate()
+			g.Expect(err).ToNot(HaveOccurred())
+
+			machine := newRunningMachine("drift")
+			act := newTestActuator()
+			act.ExistsValue = true
+			act.DriftValue = tc.drift
+			act.DriftError = tc.driftError
+
+			recorder := record.NewFakeRecorder(10)
+			r := &ReconcileMachine{
+				Client:        fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(machine).WithStatusSubresource(&machinev1.Machine{}).Build(),
+				scheme:        scheme.Scheme,
+				eventRecorder: recorder,
+				actuator:      act,
+				gate:          gate,
+				detectDrift:   tc.detectDrift,
+			}
+
+			_, err = r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(machine)})
+			g.Expect(err).ToNot(HaveOccurred())
+
+			// Drift is only reported, the instance is still updated as usual
+			g.Expect(act.UpdateCallCount).To(BeEquivalentTo(1))
+			g.Expect(act.DriftCallCount).To(Equal(tc.expectedDriftCalls))
+
+			close(recorder.Events)
+			events := []string{}
+			for event := range recorder.Events {
+				events = append(events, event)
+			}
+			g.Expect(events).To(Equal(tc.expectedEvents))
+		})
+	}
+}
+
+func TestReconcileResultMetric(t *testing.T) {
+	newMachine := func(provisioned bool) *machinev1.Machine {
+		m := &machinev1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "result",
+				Namespace:  "default",
+				Finalizers: []string{machinev1.MachineFinalizer},
+				Labels: map[string]string{
+					machinev1.MachineClusterIDLabel: "testcluster",
+				},
+			},
+			Spec: machinev1.MachineSpec{
+				AuthoritativeAPI: machinev1.MachineAuthorityMachineAPI,
+				ProviderSpec: machinev1.ProviderSpec{
+					Value: &runtime.RawExtension{
+						Raw: []byte("{}"),
+					},
+				},
+			},
+			Status: machinev1.MachineStatus{
+				AuthoritativeAPI: machinev1.MachineAuthorityMachineAPI,
+				Phase:            ptr.To[string](machinev1.PhaseProvisioning),
+			},
+		}
+		if provisioned {
+			m.Spec.ProviderID = ptr.To[string]("providerID")
+			m.Status.Phase = ptr.To[string](machinev1.PhaseRunning)
+			m.Status.Addresses = []corev1.NodeAddress{
+				{
+					Type:    corev1.NodeInternalIP,
+					Address: "0.0.0.0",
+				},
+			}
+			m.Status.NodeRef = &corev1.ObjectReference{
+				Name: "a node",
+			}
+		}
+		return m
+	}
+
+	testCases := []struct {
+		name           string
+		provisioned    bool
+		existsValue    bool
+		existsError    error
+		createError    error
+		updateError    error
+		updateChanged  bool
+		expectedResult string
+	}{
+		{
+			name:           "when the instance is created",
+			existsValue:    false,
+			expectedResult: metrics.MachineReconcileCreated,
+		},
+		{
+			name:           "when the instance fails to be created",
+			existsValue:    false,
+			createError:    errors.New("failed to create instance"),
+			expectedResult: metrics.MachineReconcileError,
+		},
+		{
+			name:           "when the instance is updated",
+			provisioned:    true,
+			existsValue:    true,
+			updateChanged:  true,
+			expectedResult: metrics.MachineReconcileUpdated,
+		},
+		{
+			name:           "when the instance already matches the machine",
+			provisioned:    true,
+			existsValue:    true,
+			updateChanged:  false,
+			expectedResult: metrics.MachineReconcileNoop,
+		},
+		{
+			name:           "when the instance fails to be updated",
+			provisioned:    true,
+			existsValue:    true,
+			updateError:    errors.New("failed to update instance"),
+			expectedResult: metrics.MachineReconcileError,
+		},
+		{
+			name:           "when the instance existence cannot be checked",
+			provisioned:    true,
+			existsError:    errors.New("failed to describe instance"),
+			expectedResult: metrics.MachineReconcileError,
+		},
+	}
+
+	results := []string{
+		metrics.MachineReconcileCreated,
+		metrics.MachineReconcileUpdated,
+		metrics.MachineReconcileNoop,
+		metrics.MachineReconcileError,
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			gate, err := testutils.NewDefaultMutableFeatureGate()
+			g.Expect(err).ToNot(HaveOccurred())
+
+			machine := newMachine(tc.provisioned)
+			act := newTestActuator()
+			act.ExistsValue = tc.existsValue
+			act.ExistsError = tc.existsError
+			act.CreateError = tc.createError
+			act.UpdateError = tc.updateError
+			act.UpdateChanged = tc.updateChanged
+
+			r := &ReconcileMachine{
+				Client:        fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(machine).WithStatusSubresource(&machinev1.Machine{}).Build(),
+				scheme:        scheme.Scheme,
+				eventRecorder: record.NewFakeRecorder(10),
+				actuator:      act,
+				gate:          gate,
+			}
+
+			before := map[string]float64{}
+			for _, result := range results {
+				before[result] = reconcileResultCount(g, result)
+			}
+
+			// Errors are returned or retried depending on the branch, only the metric matters here
+			_, _ = r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(machine)})
+
+			for _, result := range results {
+				expected := before[result]
+				if result == tc.expectedResult {
+					expected++
+				}
+				g.Expect(reconcileResultCount(g, result)).To(Equal(expected), "unexpected count for result %q", result)
+			}
+		})
+	}
+}
+
+// reconcileResultCount returns the value of the mapi_machine_reconcile_total counter for a result.
+func reconcileResultCount(g *WithT, result string) float64 {
+	families, err := ctrlmetrics.Registry.Gather()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	for _, family := range families {
+		if family.GetName() != "mapi_machine_reconcile_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "result" && label.GetValue() == result {
+					return metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return 0
+}
+
+func TestReconcileHeldDeletion(t *testing.T) {
+	drainedCondition := conditions.TrueCondition(machinev1.MachineDrained)
+	drainedCondition.Message = "Drain finished successfully"
+
+	testCases := []struct {
+		name                   string
+		held                   bool
+		nodeExists             bool
+		nodeCordoned           bool
+		expectNodeCordoned     bool
+		expectedDrainedReason  string
+		expectedDrainedStatus  corev1.ConditionStatus
+		expectedEventsContains []string
+	}{
+		{
+			name:                  "with a cordoned node and no hold",
+			held:                  false,
+			nodeExists:            true,
+			nodeCordoned:          true,
+			expectNodeCordoned:    true,
+			expectedDrainedStatus: corev1.ConditionTrue,
+		},
+		{
+			name:                  "with a cordoned node and a hold",
+			held:                  true,
+			nodeExists:            true,
+			nodeCordoned:          true,
+			expectNodeCordoned:    false,
+			expectedDrainedReason: DeletionHeldReason,
+			expectedDrainedStatus: corev1.ConditionFalse,
+			expectedEventsContains: []string{
+				"Normal DeletionHeld Node \"node\" uncordoned, deletion held by machine.openshift.io/hold-deletion annotation",
+				"Normal DeletionHeld Deletion held by machine.openshift.io/hold-deletion annotation",
+			},
+		},
+		{
+			name:                  "with a schedulable node and a hold",
+			held:                  true,
+			nodeExists:            true,
+			nodeCordoned:          false,
+			expectNodeCordoned:    false,
+			expectedDrainedReason: DeletionHeldReason,
+			expectedDrainedStatus: corev1.ConditionFalse,
+			expectedEventsContains: []string{
+				"Normal DeletionHeld Deletion held by machine.openshift.io/hold-deletion annotation",
+			},
+		},
+		{
+			name:                  "with a missing node and a hold",
+			held:                  true,
+			nodeExists:            false,
+			expectedDrainedReason: DeletionHeldReason,
+			expectedDrainedStatus: corev1.ConditionFalse,
+			expectedEventsContains: []string{
+				"Normal DeletionHeld Deletion held by machine.openshift.io/hold-deletion annotation",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			gate, err := testutils.NewDefaultMutableFeatureGate()
+			g.Expect(err).ToNot(HaveOccurred())
+
+			machine := &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "held",
+					Namespace:         "default",
+					Finalizers:        []string{machinev1.MachineFinalizer},
+					DeletionTimestamp: &metav1.Time{Time: time.Now()},
+					Annotations:       map[string]string{},
+					Labels: map[string]string{
+						machinev1.MachineClusterIDLabel: "testcluster",
+					},
+				},
+				Spec: machinev1.MachineSpec{
+					AuthoritativeAPI: machinev1.MachineAuthorityMachineAPI,
+					LifecycleHooks: machinev1.LifecycleHooks{
+						PreTerminate: []machinev1.LifecycleHook{{Name: "hook", Owner: "owner"}},
+					},
+					ProviderSpec: machinev1.ProviderSpec{
+						Value: &runtime.RawExtension{
+							Raw: []byte("{}"),
+						},
+					},
+				},
+				Status: machinev1.MachineStatus{
+					AuthoritativeAPI: machinev1.MachineAuthorityMachineAPI,
+					Phase:            ptr.To[string](machinev1.PhaseDeleting),
+					NodeRef:          &corev1.ObjectReference{Name: "node"},
+					Conditions:       []machinev1.Condition{*drainedCondition},
+				},
+			}
+			if tc.held {
+				machine.Annotations[HoldDeletionAnnotation] = ""
+			}
+
+			objects := []runtime.Object{machine}
+			if tc.nodeExists {
+				objects = append(objects, &corev1.Node{
+					ObjectMeta: metav1.ObjectMeta{Name: "node"},
+					Spec:       corev1.NodeSpec{Unschedulable: tc.nodeCordoned},
+				})
+			}
+
+			act := newTestActuator()
+			recorder := record.NewFakeRecorder(10)
+			r := &ReconcileMachine{
+				Client:        fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(objects...).WithStatusSubresource(&machinev1.Machine{}).Build(),
+				scheme:        scheme.Scheme,
+				eventRecorder: recorder,
+				actuator:      act,
+				gate:          gate,
+			}
+
+			_, err = r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(machine)})
+			g.Expect(err).ToNot(HaveOccurred())
+
+			// The pre-terminate hook blocks the deletion in every case
+			g.Expect(act.DeleteCallCount).To(BeEquivalentTo(0))
+
+			got := &machinev1.Machine{}
+			g.Expect(r.Client.Get(ctx, client.ObjectKeyFromObject(machine), got)).To(Succeed())
+			drained := conditions.Get(got, machinev1.MachineDrained)
+			g.Expect(drained).ToNot(BeNil())
+			g.Expect(drained.Status).To(Equal(tc.expectedDrainedStatus))
+			g.Expect(drained.Reason).To(Equal(tc.expectedDrainedReason))
+
+			if tc.nodeExists {
+				node := &corev1.Node{}
+				g.Expect(r.Client.Get(ctx, client.ObjectKey{Name: "node"}, node)).To(Succeed())
+				g.Expect(node.Spec.Unschedulable).To(Equal(tc.expectNodeCordoned))
+			}
+
+			close(recorder.Events)
+			events := []string{}
+			for event := range recorder.Events {
+				events = append(events, event)
+			}
+			g.Expect(events).To(ConsistOf(tc.expectedEventsContains))
+		})
+	}
+}
+
+func TestReconcileCordonOnDeletion(t *testing.T) {
+	otherTaint := corev1.Taint{Key: "other", Effect: corev1.TaintEffectNoExecute}
+	deletingTaint := corev1.Taint{Key: MachineDeletingTaintKey, Effect: corev1.TaintEffectNoSchedule}
+
+	testCases := []struct {
+		name           string
+		annotations    map[string]string
+		nodeExists     bool
+		nodeTaints     []corev1.Taint
+		expectedTaints []corev1.Taint
+		expectedEvents []string
+	}{
+		{
+			name:           "without the annotation",
+			annotations:    map[string]string{},
+			nodeExists:     true,
+			nodeTaints:     []corev1.Taint{otherTaint},
+			expectedTaints: []corev1.Taint{otherTaint},
+		},
+		{
+			name:           "with the annotation",
+			annotations:    map[string]string{CordonOnDeletionAnnotation: ""},
+			nodeExists:     true,
+			nodeTaints:     []corev1.Taint{otherTaint},
+			expectedTaints: []corev1.Taint{otherTaint, deletingTaint},
+			expectedEvents: []string{
+				"Normal NodeTainted Node \"node\" tainted with machine.openshift.io/deleting:NoSchedule, machine is being deleted",
+			},
+		},
+		{
+			name:           "with the annotation and an already tainted node",
+			annotations:    map[string]string{CordonOnDeletionAnnotation: ""},
+			nodeExists:     true,
+			nodeTaints:     []corev1.Taint{deletingTaint},
+			expectedTaints: []corev1.Taint{deletingTaint},
+		},
+		{
+			name:        "with the annotation and a missing node",
+			annotations: map[string]string{CordonOnDeletionAnnotation: ""},
+			nodeExists:  false,
+		},
+		{
+			name:        "with the annotation and a held deletion",
+			annotations: map[string]string{CordonOnDeletionAnnotation: "", HoldDeletionAnnotation: ""},
+			nodeExists:  true,
+			expectedEvents: []string{
+				"Normal DeletionHeld Deletion held by machine.openshift.io/hold-deletion annotation",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			gate, err := testutils.NewDefaultMutableFeatureGate()
+			g.Expect(err).ToNot(HaveOccurred())
+
+			machine := &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "deleting",
+					Namespace:         "default",
+					Finalizers:        []string{machinev1.MachineFinalizer},
+					DeletionTimestamp: &metav1.Time{Time: time.Now()},
+					Annotations:       tc.annotations,
+					Labels: map[string]string{
+						machinev1.MachineClusterIDLabel: "testcluster",
+					},
+				},
+				Spec: machinev1.MachineSpec{
+					AuthoritativeAPI: machinev1.MachineAuthorityMachineAPI,
+					LifecycleHooks: machinev1.LifecycleHooks{
+						PreDrain: []machinev1.LifecycleHook{{Name: "hook", Owner: "owner"}},
+					},
+					ProviderSpec: machinev1.ProviderSpec{
+						Value: &runtime.RawExtension{
+							Raw: []byte("{}"),
+						},
+					},
+				},
+				Status: machinev1.MachineStatus{
+					AuthoritativeAPI: machinev1.MachineAuthorityMachineAPI,
+					Phase:            ptr.To[string](machinev1.PhaseDeleting),
+					NodeRef:          &corev1.ObjectReference{Name: "node"},
+				},
+			}
+
+			objects := []runtime.Object{machine}
+			if tc.nodeExists {
+				objects = append(objects, &corev1.Node{
+					ObjectMeta: metav1.ObjectMeta{Name: "node"},
+					Spec:       corev1.NodeSpec{Taints: tc.nodeTaints},
+				})
+			}
+
+			act := newTestActuator()
+			recorder := record.NewFakeRecorder(10)
+			r := &ReconcileMachine{
+				Client:        fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(objects...).WithStatusSubresource(&machinev1.Machine{}).Build(),
+				scheme:        scheme.Scheme,
+				eventRecorder: recorder,
+				actuator:      act,
+				gate:          gate,
+			}
+
+			// The node is only tainted once however many times the deleted Machine is reconciled
+			for range 2 {
+				_, err = r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(machine)})
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+
+			// The pre-drain hook blocks the drain and the deletion in every case
+			g.Expect(act.DeleteCallCount).To(BeEquivalentTo(0))
+
+			if tc.nodeExists {
+				node := &corev1.Node{}
+				g.Expect(r.Client.Get(ctx, client.ObjectKey{Name: "node"}, node)).To(Succeed())
+				g.Expect(node.Spec.Taints).To(Equal(tc.expectedTaints))
+			}
+
+			close(recorder.Events)
+			events := []string{}
+			for event := range recorder.Events {
+				events = append(events, event)
+			}
+			g.Expect(events).To(ConsistOf(tc.expectedEvents))
+		})
+	}
+}
+
+func TestTaintDeletingNodeConflict(t *testing.T) {
+	g := NewWithT(t)
+
+	concurrentTaint := corev1.Taint{Key: "concurrent", Effect: corev1.TaintEffectNoSchedule}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node"}}
+
+	concurrentEdit := true
+	r := &ReconcileMachine{
+		Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(node).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+					if concurrentEdit {
+						// Another client taints the node between the read and the patch of the controller
+						concurrentEdit = false
+						current := &corev1.Node{}
+						if err := c.Get(ctx, client.ObjectKey{Name: "node"}, current); err != nil {
+							return err
+						}
+						current.Spec.Taints = append(current.Spec.Taints, concurrentTaint)
+						if err := c.Update(ctx, current); err != nil {
+							return err
+						}
+					}
+					return c.Patch(ctx, obj, patch, opts...)
+				},
+			}).Build(),
+		scheme: scheme.Scheme,
+	}
+
+	_, err := r.taintDeletingNode(ctx, "node")
+	g.Expect(apierrors.IsConflict(err)).To(BeTrue(), "expected a conflict, got %v", err)
+
+	// The retry keeps the concurrent taint
+	tainted, err := r.taintDeletingNode(ctx, "node")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(tainted).To(BeTrue())
+
+	got := &corev1.Node{}
+	g.Expect(r.Client.Get(ctx, client.ObjectKey{Name: "node"}, got)).To(Succeed())
+	g.Expect(got.Spec.Taints).To(Equal([]corev1.Taint{
+		concurrentTaint,
+		{Key: MachineDeletingTaintKey, Effect: corev1.TaintEffectNoSchedule},
+	}))
+}
+
+func TestReconcileAttachVolumes(t *testing.T) {
+	machine := &machinev1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "attach",
+			Namespace:  "default",
+			Finalizers: []string{machinev1.MachineFinalizer},
+			Labels: map[string]string{
+				machinev1.MachineClusterIDLabel: "testcluster",
+			},
+		},
+		Spec: machinev1.MachineSpec{
+			AuthoritativeAPI: machinev1.MachineAuthorityMachineAPI,
+			ProviderID:       ptr.To[string]("providerID"),
+			ProviderSpec: machinev1.ProviderSpec{
+				Value: &runtime.RawExtension{
+					Raw: []byte("{}"),
+				},
+			},
+		},
+		Status: machinev1.MachineStatus{
+			AuthoritativeAPI: machinev1.MachineAuthorityMachineAPI,
+			Addresses: []corev1.NodeAddress{
+				{
+					Type:    corev1.NodeInternalIP,
+					Address: "0.0.0.0",
+				},
+			},
+			NodeRef: &corev1.ObjectReference{
+				Name: "a node",
+			},
+		},
+	}
+
+	testCases := []struct {
+		name           string
+		volumes        []string
+		attachError    error
+		expectedResult string
+		expectedEvents []string
+	}{
+		{
+			name:           "with no volume to attach",
+			expectedResult: metrics.MachineReconcileNoop,
+			expectedEvents: []string{},
+		},
+		{
+			name:           "with volumes to attach",
+			volumes:        []string{"data-0", "data-1"},
+			expectedResult: metrics.MachineReconcileUpdated,
+			expectedEvents: []string{
+				"Normal VolumesAttached Attached volumes data-0, data-1 to the existing instance",
+			},
+		},
+		{
+			name:           "when attaching volumes is not supported",
+			attachError:    fmt.Errorf("removing data disks: %w", ErrVolumeAttachUnsupported),
+			expectedResult: metrics.MachineReconcileNoop,
+			expectedEvents: []string{
+				"Warning VolumeAttachUnsupported Volumes can not be attached to the existing instance: removing data disks: attaching volumes to an existing instance is not supported",
+			},
+		},
+		{
+			name:           "when attaching volumes fails",
+			attachError:    errors.New("failed to reconfigure instance"),
+			expectedResult: metrics.MachineReconcileNoop,
+			expectedEvents: []string{
+				"Warning FailedAttachVolumes Failed to attach volumes: failed to reconfigure instance",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			gate, err := testutils.NewDefaultMutableFeatureGate()
+			g.Expect(err).ToNot(HaveOccurred())
+
+			act := newTestActuator()
+			act.ExistsValue = true
+			act.AttachVolumesValue = tc.volumes
+			act.AttachVolumesError = tc.attachError
+
+			recorder := record.NewFakeRecorder(10)
+			r := &ReconcileMachine{
+				Client:        fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(machine.DeepCopy()).WithStatusSubresource(&machinev1.Machine{}).Build(),
+				scheme:        scheme.Scheme,
+				eventRecorder: recorder,
+				actuator:      act,
+				gate:          gate,
+			}
+
+			before := reconcileResultCount(g, tc.expectedResult)
+
+			_, err = r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(machine)})
+			g.Expect(err).ToNot(HaveOccurred())
+
+			// Volumes are attached after the idempotent update, which is not affected by failures to attach them
+			g.Expect(act.UpdateCallCount).To(BeEquivalentTo(1))
+			g.Expect(act.AttachVolumesCallCount).To(BeEquivalentTo(1))
+			g.Expect(reconcileResultCount(g, tc.expectedResult)).To(Equal(before + 1))
+
+			close(recorder.Events)
+			events := []string{}
+			for event := range recorder.Events {
+				events = append(events, event)
+			}
+			g.Expect(events).To(Equal(tc.expectedEvents))
+		})
+	}
+}
+
+func TestReconcileClusterPaused(t *testing.T) {
+	testCases := []struct {
+		name            string
+		paused          bool
+		expectedResult  reconcile.Result
+		expectFinalizer bool
+	}{
+		{
+			name:            "with the cluster paused",
+			paused:          true,
+			expectedResult:  reconcile.Result{},
+			expectFinalizer: false,
+		},
+		{
+			name:            "with the cluster not paused",
+			paused:          false,
+			expectedResult:  reconcile.Result{},
+			expectFinalizer: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			gate, err := testutils.NewDefaultMutableFeatureGate()
+			g.Expect(err).ToNot(HaveOccurred())
+
+			machine := &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "machine",
+					Namespace: "default",
+					Labels: map[string]string{
+						machinev1.MachineClusterIDLabel: "testcluster",
+					},
+				},
+				Spec: machinev1.MachineSpec{
+					ProviderSpec: machinev1.ProviderSpec{
+						Value: &runtime.RawExtension{
+							Raw: []byte("{}"),
+						},
+					},
+				},
+			}
+
+			objects := []runtime.Object{machine}
+			if tc.paused {
+				objects = append(objects, &corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      mapicontroller.ClusterPauseConfigMapName,
+						Namespace: mapicontroller.DefaultClusterPauseNamespace,
+					},
+				})
+			}
+
+			act := newTestActuator()
+			r := &ReconcileMachine{
+				Client:        fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(objects...).WithStatusSubresource(&machinev1.Machine{}).Build(),
+				scheme:        scheme.Scheme,
+				eventRecorder: record.NewFakeRecorder(10),
+				actuator:      act,
+				gate:          gate,
+			}
+
+			gated := mapicontroller.WithClusterPause(machineControllerName, r.Client, mapicontroller.DefaultClusterPauseNamespace, r, newMachine)
+			result, err := gated.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(machine)})
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(result).To(Equal(tc.expectedResult))
+
+			got := &machinev1.Machine{}
+			g.Expect(r.Client.Get(ctx, client.ObjectKeyFromObject(machine), got)).To(Succeed())
+			if tc.expectFinalizer {
+				g.Expect(got.Finalizers).To(ContainElement(machinev1.MachineFinalizer))
+				g.Expect(conditions.IsTrue(got, mapicontroller.ClusterPausedCondition)).To(BeFalse())
+			} else {
+				// Nothing but the ClusterPaused condition is mutated while the cluster is paused
+				g.Expect(got.Finalizers).To(BeEmpty())
+				g.Expect(conditions.IsTrue(got, mapicontroller.ClusterPausedCondition)).To(BeTrue())
+				g.Expect(got.Status.Phase).To(BeNil())
+				g.Expect(act.ExistsCallCount).To(BeEquivalentTo(0))
+			}
+		})
+	}
+}
+
+func TestReconcileDuplicateProviderID(t *testing.T) {
+	newMachine := func(name, providerID string) *machinev1.Machine {
+		return &machinev1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       name,
+				Namespace:  "default",
+				Finalizers: []string{machinev1.MachineFinalizer},
+				Labels: map[string]string{
+					machinev1.MachineClusterIDLabel: "testcluster",
+				},
+			},
+			Spec: machinev1.MachineSpec{
+				ProviderID: ptr.To[string](providerID),
+				ProviderSpec: machinev1.ProviderSpec{
+					Value: &runtime.RawExtension{
+						Raw: []byte("{}"),
+					},
+				},
+			},
+			Status: machinev1.MachineStatus{
+				Phase: ptr.To[string](machinev1.PhaseProvisioned),
+			},
+		}
+	}
+
+	testCases := []struct {
+		name              string
+		machines          func() []runtime.Object
+		expectedCondition *machinev1.Condition
+		expectEvent       bool
+	}{
+		{
+			name: "with a unique providerID",
+			machines: func() []runtime.Object {
+				return []runtime.Object{newMachine("machine-a", "aws:///us-east-1a/i-a"), newMachine("machine-b", "aws:///us-east-1a/i-b")}
+			},
+		},
+		{
+			name: "with another machine with the same providerID",
+			machines: func() []runtime.Object {
+				return []runtime.Object{newMachine("machine-a", "aws:///us-east-1a/i-a"), newMachine("machine-b", "aws:///us-east-1a/i-a")}
+			},
+			expectedCondition: &machinev1.Condition{
+				Type:    DuplicateProviderIDCondition,
+				Status:  corev1.ConditionTrue,
+				Reason:  DuplicateProviderIDReason,
+				Message: "Machines default/machine-b have the same providerID aws:///us-east-1a/i-a",
+			},
+			expectEvent: true,
+		},
+		{
+			name: "once the duplicate has been removed",
+			machines: func() []runtime.Object {
+				m := newMachine("machine-a", "aws:///us-east-1a/i-a")
+				conditions.Set(m, conditions.TrueConditionWithReason(DuplicateProviderIDCondition, DuplicateProviderIDReason, "Machines default/machine-b have the same providerID aws:///us-east-1a/i-a"))
+				return []runtime.Object{m}
+			},
+			expectedCondition: &machinev1.Condition{
+				Type:     DuplicateProviderIDCondition,
+				Status:   corev1.ConditionFalse,
+				Severity: machinev1.ConditionSeverityInfo,
+				Reason:   UniqueProviderIDReason,
+				Message:  "No other machine has providerID aws:///us-east-1a/i-a",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			gate, err := testutils.NewDefaultMutableFeatureGate()
+			g.Expect(err).ToNot(HaveOccurred())
+
+			act := newTestActuator()
+			act.ExistsValue = true
+			recorder := record.NewFakeRecorder(10)
+			r := &ReconcileMachine{
+				Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(tc.machines()...).
+					WithStatusSubresource(&machinev1.Machine{}).
+					WithIndex(&machinev1.Machine{}, machineProviderIDIndex, indexMachineByProviderID).Build(),
+				scheme:        scheme.Scheme,
+				eventRecorder: recorder,
+				actuator:      act,
+				gate:          gate,
+			}
+
+			key := client.ObjectKey{Namespace: "default", Name: "machine-a"}
+			_, err = r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			g.Expect(err).ToNot(HaveOccurred())
+
+			got := &machinev1.Machine{}
+			g.Expect(r.Client.Get(ctx, key, got)).To(Succeed())
+			condition := conditions.Get(got, DuplicateProviderIDCondition)
+			if tc.expectedCondition == nil {
+				g.Expect(condition).To(BeNil())
+			} else {
+				g.Expect(condition).ToNot(BeNil())
+				g.Expect(condition.Status).To(Equal(tc.expectedCondition.Status))
+				g.Expect(condition.Severity).To(Equal(tc.expectedCondition.Severity))
+				g.Expect(condition.Reason).To(Equal(tc.expectedCondition.Reason))
+				g.Expect(condition.Message).To(Equal(tc.expectedCondition.Message))
+			}
+
+			var events []string
+			for len(recorder.Events) > 0 {
+				events = append(events, <-recorder.Events)
+			}
+			if tc.expectEvent {
+				g.Expect(events).To(ContainElement(ContainSubstring(DuplicateProviderIDReason)))
+			} else {
+				g.Expect(events).ToNot(ContainElement(ContainSubstring(DuplicateProviderIDReason)))
+			}
+
+			// Duplicates are only reported, never deleted
+			g.Expect(got.DeletionTimestamp).To(BeNil())
+			g.Expect(act.DeleteCallCount).To(BeEquivalentTo(0))
+		})
+	}
+}
+
+func TestReconcileDeletedMachineMetrics(t *testing.T) {
+	deletedMachine := func() *machinev1.Machine {
+		m := &machinev1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "deleted",
+				Namespace:         "default",
+				DeletionTimestamp: ptr.To(metav1.Now()),
+				Finalizers:        []string{machinev1.MachineFinalizer},
+				Labels: map[string]string{
+					machinev1.MachineClusterIDLabel: "testcluster",
+				},
+			},
+			Spec: machinev1.MachineSpec{
+				ProviderSpec: machinev1.ProviderSpec{
+					Value: &runtime.RawExtension{
+						Raw: []byte("{}"),
+					},
+				},
+			},
+		}
+		conditions.MarkTrue(m, machinev1.MachineDrained)
+		return m
+	}
+
+	testCases := []struct {
+		name     string
+		machines []runtime.Object
+	}{
+		{
+			name:     "once the machine deletion succeeded",
+			machines: []runtime.Object{deletedMachine()},
+		},
+		{
+			name: "once the machine is gone",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			gate, err := testutils.NewDefaultMutableFeatureGate()
+			g.Expect(err).ToNot(HaveOccurred())
+
+			r := &ReconcileMachine{
+				Client:        fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(tc.machines...).WithStatusSubresource(&machinev1.Machine{}).Build(),
+				scheme:        scheme.Scheme,
+				eventRecorder: record.NewFakeRecorder(10),
+				actuator:      newTestActuator(),
+				gate:          gate,
+			}
+
+			labels := &metrics.MachineLabels{Name: "deleted", Namespace: "default"}
+			metrics.SetMachineDuplicateProviderID(labels, true)
+			metrics.SetMachinePhaseStartTime(labels, machinev1.PhaseDeleting, time.Now())
+			g.Expect(hasMachineSeries(g, "mapi_machine_duplicate_provider_id", "deleted")).To(BeTrue())
+
+			_, err = r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKey{Namespace: "default", Name: "deleted"}})
+			g.Expect(err).ToNot(HaveOccurred())
+
+			g.Expect(hasMachineSeries(g, "mapi_machine_duplicate_provider_id", "deleted")).To(BeFalse())
+			g.Expect(hasMachineSeries(g, "mapi_machine_phase_start_timestamp_seconds", "deleted")).To(BeFalse())
+		})
+	}
+}
+
+// hasMachineSeries returns true if the metric has a series for the Machine.
+func hasMachineSeries(g *WithT, metric, name string) bool {
+	families, err := ctrlmetrics.Registry.Gather()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	for _, family := range families {
+		if family.GetName() != metric {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "name" && label.GetValue() == name {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// slowActuator blocks checking the existence of instances until the context is done, then returns err, or the
+// context error when err is nil.
+type slowActuator struct {
+	*TestActuator
+	err error
+}
+
+func (a *slowActuator) Exists(ctx context.Context, _ *machinev1.Machine) (bool, error) {
+	<-ctx.Done()
+	if a.err != nil {
+		return false, a.err
+	}
+	return false, fmt.Errorf("failed to describe instance: %w", ctx.Err())
+}
+
+func TestReconcileTimeout(t *testing.T) {
+	instanceErr := errors.New("instance not found")
+
+	testCases := []struct {
+		name            string
+		actuatorErr     error
+		expectedResult  reconcile.Result
+		expectedErr     error
+		expectedTimeout bool
+	}{
+		{
+			name:            "with a reconcile failing because of the deadline",
+			expectedResult:  reconcile.Result{RequeueAfter: requeueAfter},
+			expectedTimeout: true,
+		},
+		{
+			name:           "with a reconcile failing with another error once the deadline passed",
+			actuatorErr:    instanceErr,
+			expectedResult: reconcile.Result{},
+			expectedErr:    instanceErr,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			gate, err := testutils.NewDefaultMutableFeatureGate()
+			g.Expect(err).ToNot(HaveOccurred())
+
+			machine := &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "slow",
+					Namespace:  "default",
+					Finalizers: []string{machinev1.MachineFinalizer},
+					Labels: map[string]string{
+						machinev1.MachineClusterIDLabel: "testcluster",
+					},
+				},
+				Spec: machinev1.MachineSpec{
+					AuthoritativeAPI: machinev1.MachineAuthorityMachineAPI,
+					ProviderSpec: machinev1.ProviderSpec{
+						Value: &runtime.RawExtension{
+							Raw: []byte("{}"),
+						},
+					},
+				},
+				Status: machinev1.MachineStatus{
+					AuthoritativeAPI: machinev1.MachineAuthorityMachineAPI,
+				},
+			}
+
+			r := &ReconcileMachine{
+				Client:           fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(machine).WithStatusSubresource(&machinev1.Machine{}).Build(),
+				scheme:           scheme.Scheme,
+				eventRecorder:    record.NewFakeRecorder(10),
+				actuator:         &slowActuator{TestActuator: newTestActuator(), err: tc.actuatorErr},
+				gate:             gate,
+				reconcileTimeout: 100 * time.Millisecond,
+			}
+
+			before := reconcileTimeoutCount(g)
+
+			result, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(machine)})
+			if tc.expectedErr != nil {
+				g.Expect(err).To(MatchError(tc.expectedErr))
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+			g.Expect(result).To(Equal(tc.expectedResult))
+
+			if tc.expectedTimeout {
+				g.Expect(reconcileTimeoutCount(g)).To(Equal(before + 1))
+			} else {
+				g.Expect(reconcileTimeoutCount(g)).To(Equal(before))
+			}
+		})
+	}
+}
+
+func TestNewReconcilerReconcileTimeout(t *testing.T) {
+	g := NewWithT(t)
+
+	gate, err := testutils.NewDefaultMutableFeatureGate()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// The manager never reaches the API server, it only provides the client and the event recorder.
+	mgr, err := manager.New(&rest.Config{Host: "https://127.0.0.1:1"}, manager.Options{
+		Metrics: metricsserver.Options{BindAddress: "0"},
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	g.Expect(DefaultOptions().ReconcileTimeout).To(BeZero())
+
+	opts := DefaultOptions()
+	opts.ReconcileTimeout = 30 * time.Second
+
+	r, ok := newReconciler(mgr, newTestActuator(), gate, opts).(*ReconcileMachine)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(r.reconcileTimeout).To(Equal(30 * time.Second))
+}
+
+// reconcileTimeoutCount returns the value of the mapi_machine_reconcile_timeouts_total counter.
+func reconcileTimeoutCount(g *WithT) float64 {
+	families, err := ctrlmetrics.Registry.Gather()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	for _, family := range families {
+		if family.GetName() == "mapi_machine_reconcile_timeouts_total" {
+			return family.GetMetric()[0].GetCounter().GetValue()
+		}
+	}
+	return 0
+}
+
+func TestReconcileSkipDrainOfGoneInstance(t *testing.T) {
+	const otherFinalizer = "example.com/other"
+
+	unreachable := corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionUnknown}}}
+
+	testCases := []struct {
+		name               string
+		existsValue        bool
+		nodeStatus         corev1.NodeStatus
+		lifecycleHooks     machinev1.LifecycleHooks
+		expectedFinalizers []string
+		expectedExists     int64
+		expectedDelete     int64
+		expectedDrained    bool
+		expectNode         bool
+	}{
+		{
+			name:               "when the instance is gone",
+			existsValue:        false,
+			nodeStatus:         unreachable,
+			expectedFinalizers: []string{otherFinalizer},
+			expectedExists:     2,
+			expectedDelete:     1,
+			expectedDrained:    true,
+			expectNode:         false,
+		},
+		{
+			name:               "when the instance still exists",
+			existsValue:        true,
+			nodeStatus:         unreachable,
+			expectedFinalizers: []string{machinev1.MachineFinalizer, otherFinalizer},
+			expectedExists:     1,
+			expectNode:         true,
+		},
+		{
+			name:               "when the node is reachable",
+			existsValue:        false,
+			expectedFinalizers: []string{machinev1.MachineFinalizer, otherFinalizer},
+			expectedExists:     0,
+			expectNode:         true,
+		},
+		{
+			name:        "when a pre-drain hook is pending",
+			existsValue: false,
+			nodeStatus:  unreachable,
+			lifecycleHooks: machinev1.LifecycleHooks{
+				PreDrain: []machinev1.LifecycleHook{{Name: "hook", Owner: "owner"}},
+			},
+			expectedFinalizers: []string{machinev1.MachineFinalizer, otherFinalizer},
+			expectedExists:     0,
+			expectNode:         true,
+		},
+		{
+			name:        "when a pre-terminate hook is pending",
+			existsValue: false,
+			nodeStatus:  unreachable,
+			lifecycleHooks: machinev1.LifecycleHooks{
+				PreTerminate: []machinev1.LifecycleHook{{Name: "hook", Owner: "owner"}},
+			},
+			expectedFinalizers: []string{machinev1.MachineFinalizer, otherFinalizer},
+			expectedExists:     1,
+			expectedDrained:    true,
+			expectNode:         true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			gate, err := testutils.NewDefaultMutableFeatureGate()
+			g.Expect(err).ToNot(HaveOccurred())
+
+			machine := &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "stale",
+					Namespace:         "default",
+					Finalizers:        []string{machinev1.MachineFinalizer, otherFinalizer},
+					DeletionTimestamp: &metav1.Time{Time: time.Now()},
+					Labels: map[string]string{
+						machinev1.MachineClusterIDLabel: "testcluster",
+					},
+				},
+				Spec: machinev1.MachineSpec{
+					AuthoritativeAPI: machinev1.MachineAuthorityMachineAPI,
+					LifecycleHooks:   tc.lifecycleHooks,
+					ProviderSpec: machinev1.ProviderSpec{
+						Value: &runtime.RawExtension{
+							Raw: []byte("{}"),
+						},
+					},
+				},
+				Status: machinev1.MachineStatus{
+					AuthoritativeAPI: machinev1.MachineAuthorityMachineAPI,
+					Phase:            ptr.To[string](machinev1.PhaseDeleting),
+					NodeRef:          &corev1.ObjectReference{Name: "node"},
+				},
+			}
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node"}, Status: tc.nodeStatus}
+
+			act := newTestActuator()
+			act.ExistsValue = tc.existsValue
+			r := &ReconcileMachine{
+				Client:        fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(machine, node).WithStatusSubresource(&machinev1.Machine{}).Build(),
+				scheme:        scheme.Scheme,
+				eventRecorder: record.NewFakeRecorder(10),
+				actuator:      act,
+				gate:          gate,
+			}
+
+			_, err = r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(machine)})
+			g.Expect(err).ToNot(HaveOccurred())
+
+			// The instance is only ever removed through the actuator
+			g.Expect(act.DeleteCallCount).To(Equal(tc.expectedDelete))
+			g.Expect(act.ExistsCallCount).To(Equal(tc.expectedExists))
+
+			got := &machinev1.Machine{}
+			g.Expect(r.Client.Get(ctx, client.ObjectKeyFromObject(machine), got)).To(Succeed())
+			g.Expect(got.Finalizers).To(Equal(tc.expectedFinalizers))
+			g.Expect(conditions.IsTrue(got, machinev1.MachineDrained)).To(Equal(tc.expectedDrained))
+			if tc.expectedDrained {
+				g.Expect(conditions.Get(got, machinev1.MachineDrained).Message).To(Equal(DrainSkippedInstanceGoneMessage))
+			}
+
+			err = r.Client.Get(ctx, client.ObjectKeyFromObject(node), &corev1.Node{})
+			if tc.expectNode {
+				g.Expect(err).ToNot(HaveOccurred())
+			} else {
+				g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			}
+		})
+	}
+}
+
+func TestReconcileStuckPhase(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	newMachine := func() *machinev1.Machine {
+		return &machinev1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "stuck",
+				Namespace:         "default",
+				CreationTimestamp: metav1.NewTime(start),
+				Finalizers:        []string{machinev1.MachineFinalizer},
+				Labels: map[string]string{
+					machinev1.MachineClusterIDLabel: "testcluster",
+				},
+			},
+			Spec: machinev1.MachineSpec{
+				ProviderSpec: machinev1.ProviderSpec{
+					Value: &runtime.RawExtension{
+						Raw: []byte("{}"),
+					},
+				},
+			},
+			Status: machinev1.MachineStatus{
+				Phase: ptr.To[string](machinev1.PhaseProvisioning),
+			},
+		}
+	}
+
+	type step struct {
+		elapsed           time.Duration
+		expectedCondition *machinev1.Condition
+	}
+
+	testCases := []struct {
+		name    string
+		machine func() *machinev1.Machine
+		phase   string
+		steps   []step
+	}{
+		{
+			name:    "with a provisioning machine",
+			machine: newMachine,
+			phase:   machinev1.PhaseProvisioning,
+			steps: []step{
+				{
+					elapsed: 5 * time.Minute,
+				},
+				{
+					elapsed: 10 * time.Minute,
+				},
+				{
+					elapsed: 11 * time.Minute,
+					expectedCondition: &machinev1.Condition{
+						Type:    StuckCondition,
+						Status:  corev1.ConditionTrue,
+						Reason:  ProvisioningStuckReason,
+						Message: "Machine has been in phase Provisioning for longer than 10m0s",
+					},
+				},
+			},
+		},
+		{
+			name: "with a deleting machine",
+			machine: func() *machinev1.Machine {
+				m := newMachine()
+				m.DeletionTimestamp = ptr.To(metav1.NewTime(start))
+				m.Status.Phase = ptr.To[string](machinev1.PhaseRunning)
+				m.Spec.LifecycleHooks.PreTerminate = []machinev1.LifecycleHook{{Name: "hook", Owner: "owner"}}
+				conditions.MarkTrue(m, machinev1.MachineDrained)
+				return m
+			},
+			phase: machinev1.PhaseDeleting,
+			steps: []step{
+				{
+					elapsed: 10 * time.Minute,
+				},
+				{
+					elapsed: 21 * time.Minute,
+					expectedCondition: &machinev1.Condition{
+						Type:    StuckCondition,
+						Status:  corev1.ConditionTrue,
+						Reason:  DeletingStuckReason,
+						Message: "Machine has been in phase Deleting for longer than 20m0s",
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			gate, err := testutils.NewDefaultMutableFeatureGate()
+			g.Expect(err).ToNot(HaveOccurred())
+
+			machine := tc.machine()
+			now := start
+			r := &ReconcileMachine{
+				Client:        fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(machine).WithStatusSubresource(&machinev1.Machine{}).Build(),
+				scheme:        scheme.Scheme,
+				eventRecorder: record.NewFakeRecorder(10),
+				actuator:      newTestActuator(),
+				gate:          gate,
+				nowFunc:       func() time.Time { return now },
+
+				provisioningStuckThreshold: 10 * time.Minute,
+				deletingStuckThreshold:     20 * time.Minute,
+			}
+
+			key := client.ObjectKeyFromObject(machine)
+			for _, s := range tc.steps {
+				now = start.Add(s.elapsed)
+
+				_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+				g.Expect(err).ToNot(HaveOccurred())
+
+				g.Expect(machinePhaseMetric(g, "mapi_machine_phase_start_timestamp_seconds", key.Name, tc.phase)).To(Equal(float64(start.Unix())), "after %v", s.elapsed)
+				// The stuck seconds are computed from the time of the scrape rather than from the clock of the reconciler
+				g.Expect(machinePhaseMetric(g, "mapi_machine_stuck_seconds", key.Name, tc.phase)).To(BeNumerically(">", s.elapsed.Seconds()), "after %v", s.elapsed)
+
+				got := &machinev1.Machine{}
+				g.Expect(r.Client.Get(ctx, key, got)).To(Succeed())
+				condition := conditions.Get(got, StuckCondition)
+				if s.expectedCondition == nil {
+					g.Expect(condition).To(BeNil(), "after %v", s.elapsed)
+					continue
+				}
+				g.Expect(condition).ToNot(BeNil(), "after %v", s.elapsed)
+				g.Expect(condition.Status).To(Equal(s.expectedCondition.Status))
+				g.Expect(condition.Reason).To(Equal(s.expectedCondition.Reason))
+				g.Expect(condition.Message).To(Equal(s.expectedCondition.Message))
+			}
+		})
+	}
+}
+
+func TestSetStuckConditionLeavingPhase(t *testing.T) {
+	g := NewWithT(t)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := &machinev1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "unstuck",
+			Namespace:         "default",
+			CreationTimestamp: metav1.NewTime(start),
+		},
+	}
+	r := &ReconcileMachine{
+		nowFunc:                    func() time.Time { return start.Add(time.Hour) },
+		provisioningStuckThreshold: 10 * time.Minute,
+	}
+
+	r.setStuckCondition(m, machinev1.PhaseProvisioning)
+	g.Expect(conditions.IsTrue(m, StuckCondition)).To(BeTrue())
+	g.Expect(machinePhaseMetric(g, "mapi_machine_phase_start_timestamp_seconds", "unstuck", machinev1.PhaseProvisioning)).To(Equal(float64(start.Unix())))
+	g.Expect(machinePhaseMetric(g, "mapi_machine_stuck_seconds", "unstuck", machinev1.PhaseProvisioning)).ToNot(BeZero())
+
+	// Once provisioned, the condition is cleared and the metric removed
+	r.setStuckCondition(m, machinev1.PhaseProvisioned)
+	condition := conditions.Get(m, StuckCondition)
+	g.Expect(condition).ToNot(BeNil())
+	g.Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+	g.Expect(condition.Reason).To(Equal(NotStuckReason))
+	g.Expect(machinePhaseMetric(g, "mapi_machine_phase_start_timestamp_seconds", "unstuck", machinev1.PhaseProvisioning)).To(BeZero())
+	g.Expect(machinePhaseMetric(g, "mapi_machine_stuck_seconds", "unstuck", machinev1.PhaseProvisioning)).To(BeZero())
+}
+
+// machinePhaseMetric returns the value of the given gauge for a Machine in a phase.
+func machinePhaseMetric(g *WithT, metricName, name, phase string) float64 {
+	families, err := ctrlmetrics.Registry.Gather()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	for _, family := range families {
+		if family.GetName() != metricName {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["name"] == name && labels["phase"] == phase {
+				return metric.GetGauge().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func TestReconcileNodeReadiness(t *testing.T) {
+	notReady := corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionFalse}}}
+	ready := corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}}
+
+	type step struct {
+		elapsed           time.Duration
+		expectedPhase     string
+		expectedRequeue   bool
+		expectedCondition *machinev1.Condition
+	}
+
+	testCases := []struct {
+		name          string
+		nodeReadiness NodeReadiness
+		nodeStatus    corev1.NodeStatus
+		steps         []step
+	}{
+		{
+			name:          "with the Exists policy and a node which is not Ready",
+			nodeReadiness: NodeReadinessExists,
+			nodeStatus:    notReady,
+			steps: []step{
+				{
+					expectedPhase: machinev1.PhaseRunning,
+				},
+			},
+		},
+		{
+			name:          "with the Ready policy and a node which is not Ready",
+			nodeReadiness: NodeReadinessReady,
+			nodeStatus:    notReady,
+			steps: []step{
+				{
+					expectedPhase:   machinev1.PhaseProvisioned,
+					expectedRequeue: true,
+					expectedCondition: &machinev1.Condition{
+						Type:     NodeReadyCondition,
+						Status:   corev1.ConditionFalse,
+						Reason:   WaitingForNodeReadyReason,
+						Severity: machinev1.ConditionSeverityInfo,
+						Message:  `Waiting for node "node" to become Ready`,
+					},
+				},
+				{
+					elapsed:         5 * time.Minute,
+					expectedPhase:   machinev1.PhaseProvisioned,
+					expectedRequeue: true,
+					expectedCondition: &machinev1.Condition{
+						Type:     NodeReadyCondition,
+						Status:   corev1.ConditionFalse,
+						Reason:   WaitingForNodeReadyReason,
+						Severity: machinev1.ConditionSeverityInfo,
+						Message:  `Waiting for node "node" to become Ready`,
+					},
+				},
+			},
+		},
+		{
+			name:          "with the Ready policy and a node which is Ready",
+			nodeReadiness: NodeReadinessReady,
+			nodeStatus:    ready,
+			steps: []step{
+				{
+					expectedPhase: machinev1.PhaseRunning,
+					expectedCondition: &machinev1.Condition{
+						Type:   NodeReadyCondition,
+						Status: corev1.ConditionTrue,
+					},
+				},
+			},
+		},
+		{
+			name:          "with the Ready policy and a node which does not become Ready within the timeout",
+			nodeReadiness: NodeReadinessReady,
+			nodeStatus:    notReady,
+			steps: []step{
+				{
+					expectedPhase:   machinev1.PhaseProvisioned,
+					expectedRequeue: true,
+					expectedCondition: &machinev1.Condition{
+						Type:     NodeReadyCondition,
+						Status:   corev1.ConditionFalse,
+						Reason:   WaitingForNodeReadyReason,
+						Severity: machinev1.ConditionSeverityInfo,
+						Message:  `Waiting for node "node" to become Ready`,
+					},
+				},
+				{
+					elapsed:         11 * time.Minute,
+					expectedPhase:   machinev1.PhaseProvisioned,
+					expectedRequeue: true,
+					expectedCondition: &machinev1.Condition{
+						Type:     NodeReadyCondition,
+						Status:   corev1.ConditionFalse,
+						Reason:   NodeReadyTimeoutReason,
+						Severity: machinev1.ConditionSeverityWarning,
+						Message:  `Node "node" has not become Ready within 10m0s`,
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			gate, err := testutils.NewDefaultMutableFeatureGate()
+			g.Expect(err).ToNot(HaveOccurred())
+
+			machine := &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "provisioned",
+					Namespace:  "default",
+					Finalizers: []string{machinev1.MachineFinalizer},
+					Labels: map[string]string{
+						machinev1.MachineClusterIDLabel: "testcluster",
+					},
+				},
+				Spec: machinev1.MachineSpec{
+					ProviderID: ptr.To("providerID"),
+					ProviderSpec: machinev1.ProviderSpec{
+						Value: &runtime.RawExtension{
+							Raw: []byte("{}"),
+						},
+					},
+				},
+				Status: machinev1.MachineStatus{
+					Phase:     ptr.To[string](machinev1.PhaseProvisioned),
+					Addresses: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.1"}},
+					NodeRef:   &corev1.ObjectReference{Name: "node"},
+				},
+			}
+			node := &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node"},
+				Status:     tc.nodeStatus,
+			}
+
+			act := newTestActuator()
+			act.ExistsValue = true
+			start := time.Now()
+			now := start
+			r := &ReconcileMachine{
+				Client:        fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(machine, node).WithStatusSubresource(&machinev1.Machine{}).Build(),
+				scheme:        scheme.Scheme,
+				eventRecorder: record.NewFakeRecorder(10),
+				actuator:      act,
+				gate:          gate,
+				nowFunc:       func() time.Time { return now },
+
+				nodeReadiness:    tc.nodeReadiness,
+				nodeReadyTimeout: 10 * time.Minute,
+			}
+
+			key := client.ObjectKeyFromObject(machine)
+			for _, s := range tc.steps {
+				now = start.Add(s.elapsed)
+
+				result, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(result.RequeueAfter > 0).To(Equal(s.expectedRequeue), "after %v", s.elapsed)
+
+				got := &machinev1.Machine{}
+				g.Expect(r.Client.Get(ctx, key, got)).To(Succeed())
+				g.Expect(got.Status.Phase).To(HaveValue(Equal(s.expectedPhase)), "after %v", s.elapsed)
+
+				condition := conditions.Get(got, NodeReadyCondition)
+				if s.expectedCondition == nil {
+					g.Expect(condition).To(BeNil(), "after %v", s.elapsed)
+					continue
+				}
+				g.Expect(condition).ToNot(BeNil(), "after %v", s.elapsed)
+				g.Expect(condition.Status).To(Equal(s.expectedCondition.Status))
+				g.Expect(condition.Reason).To(Equal(s.expectedCondition.Reason))
+				g.Expect(condition.Severity).To(Equal(s.expectedCondition.Severity))
+				g.Expect(condition.Message).To(Equal(s.expectedCondition.Message))
+			}
+		})
+	}
+}
+
+func TestParseNodeReadiness(t *testing.T) {
+	g := NewWithT(t)
+
+	policy, err := ParseNodeReadiness("Exists")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(policy).To(Equal(NodeReadinessExists))
+
+	policy, err = ParseNodeReadiness("Ready")
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(policy).To(Equal(NodeReadinessReady))
+
+	_, err = ParseNodeReadiness("DNS")
+	g.Expect(err).To(MatchError(`unknown node readiness policy "DNS", expected "Exists" or "Ready"`))
+}
@@ -23,10 +23,13 @@ import (
 
 	machinev1 "github.com/openshift/api/machine/v1beta1"
 
+	"github.com/openshift/machine-api-operator/pkg/metrics"
 	"github.com/openshift/machine-api-operator/pkg/util/conditions"
 )
 
 const (
+	drainControllerName = "machine-drain-controller"
+
 	nodeControlPlaneLabel = "node-role.kubernetes.io/control-plane"
 	nodeMasterLabel       = "node-role.kubernetes.io/master"
 )
@@ -38,15 +41,19 @@ type machineDrainController struct {
 	scheme *runtime.Scheme
 
 	eventRecorder record.EventRecorder
+
+	skipDrainOnInterruption bool
 }
 
 // newDrainController returns a new reconcile.Reconciler for machine-drain-controller
-func newDrainController(mgr manager.Manager) reconcile.Reconciler {
+func newDrainController(mgr manager.Manager, opts Options) reconcile.Reconciler {
 	d := &machineDrainController{
 		Client:        mgr.GetClient(),
-		eventRecorder: mgr.GetEventRecorderFor("machine-drain-controller"),
+		eventRecorder: mgr.GetEventRecorderFor(drainControllerName),
 		config:        mgr.GetConfig(),
 		scheme:        mgr.GetScheme(),
+
+		skipDrainOnInterruption: opts.SkipDrainOnInterruption,
 	}
 	return d
 }
@@ -79,6 +86,12 @@ func (d *machineDrainController) Reconcile(ctx context.Context, request reconcil
 	existingDrainedCondition := conditions.Get(m, machinev1.MachineDrained)
 	alreadyDrained := existingDrainedCondition != nil && existingDrainedCondition.Status == corev1.ConditionTrue
 
+	if _, held := m.ObjectMeta.Annotations[HoldDeletionAnnotation]; held {
+		// The machine controller restores the node to service while the deletion is held
+		klog.V(3).Infof("%v: not draining machine: deletion held by %s annotation", m.Name, HoldDeletionAnnotation)
+		return reconcile.Result{}, nil
+	}
+
 	if !m.ObjectMeta.DeletionTimestamp.IsZero() && ptr.Deref(m.Status.Phase, "") == machinev1.PhaseDeleting && !alreadyDrained {
 		drainFinishedCondition := conditions.TrueCondition(machinev1.MachineDrained)
 
@@ -90,20 +103,27 @@ func (d *machineDrainController) Reconcile(ctx context.Context, request reconcil
 				d.eventRecorder.Eventf(m, corev1.EventTypeNormal, "DrainBlocked", "Drain blocked by pre-drain hook")
 				return reconcile.Result{}, nil
 			}
-			d.eventRecorder.Eventf(m, corev1.EventTypeNormal, "DrainProceeds", "Node drain proceeds")
-			if err := d.drainNode(ctx, m); err != nil {
-				klog.Errorf("%v: failed to drain node for machine: %v", m.Name, err)
-				conditions.Set(m, conditions.FalseCondition(
-					machinev1.MachineDrained,
-					machinev1.MachineDrainError,
-					machinev1.ConditionSeverityWarning,
-					"could not drain machine: %v", err,
-				))
-				d.eventRecorder.Eventf(m, corev1.EventTypeNormal, "DrainRequeued", "Node drain requeued: %v", err.Error())
-				return delayIfRequeueAfterError(err)
+			if d.isInterrupted(m) {
+				klog.Infof("%v: skipping node drain: instance interrupted as indicated by %s annotation", m.Name, MachineInterruptedAnnotation)
+				metrics.RegisterInterruptionDrainSkipped()
+				d.eventRecorder.Eventf(m, corev1.EventTypeNormal, "DrainSkipped", "Node drain skipped, instance interrupted")
+				drainFinishedCondition.Message = "Node drain skipped, instance interrupted"
+			} else {
+				d.eventRecorder.Eventf(m, corev1.EventTypeNormal, "DrainProceeds", "Node drain proceeds")
+				if err := d.drainNode(ctx, m); err != nil {
+					klog.Errorf("%v: failed to drain node for machine: %v", m.Name, err)
+					conditions.Set(m, conditions.FalseCondition(
+						machinev1.MachineDrained,
+						machinev1.MachineDrainError,
+						machinev1.ConditionSeverityWarning,
+						"could not drain machine: %v", err,
+					))
+					d.eventRecorder.Eventf(m, corev1.EventTypeNormal, "DrainRequeued", "Node drain requeued: %v", err.Error())
+					return delayIfRequeueAfterError(err)
+				}
+				d.eventRecorder.Eventf(m, corev1.EventTypeNormal, "DrainSucceeded", "Node drain succeeded")
+				drainFinishedCondition.Message = "Drain finished successfully"
 			}
-			d.eventRecorder.Eventf(m, corev1.EventTypeNormal, "DrainSucceeded", "Node drain succeeded")
-			drainFinishedCondition.Message = "Drain finished successfully"
 		} else {
 			d.eventRecorder.Eventf(m, corev1.EventTypeNormal, "DrainSkipped", "Node drain skipped")
 			drainFinishedCondition.Message = "Node drain skipped"
@@ -120,6 +140,15 @@ func (d *machineDrainController) Reconcile(ctx context.Context, request reconcil
 	return reconcile.Result{}, nil
 }
 
+// isInterrupted returns whether the drain of the Machine is skipped because its instance is being reclaimed.
+func (d *machineDrainController) isInterrupted(m *machinev1.Machine) bool {
+	if !d.skipDrainOnInterruption {
+		return false
+	}
+	_, interrupted := m.ObjectMeta.Annotations[MachineInterruptedAnnotation]
+	return interrupted
+}
+
 func (d *machineDrainController) drainNode(ctx context.Context, machine *machinev1.Machine) error {
 	kubeClient, err := kubernetes.NewForConfig(d.config)
 	if err != nil {
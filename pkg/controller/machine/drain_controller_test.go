@@ -12,6 +12,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
@@ -63,7 +64,9 @@ func TestDrainControllerReconcileRequest(t *testing.T) {
 	getDrainControllerReconciler := func(fakeObjs ...runtime.Object) (*machineDrainController, *record.FakeRecorder) {
 		recorder := record.NewFakeRecorder(10)
 		return &machineDrainController{
-			Client:        fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(fakeObjs...).WithStatusSubresource(&machinev1.Machine{}).Build(),
+			Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(fakeObjs...).WithStatusSubresource(&machinev1.Machine{}).Build(),
+			// No API server listens on the host, so that drains are attempted and fail
+			config:        &rest.Config{Host: "http://127.0.0.1:1"},
 			scheme:        scheme.Scheme,
 			eventRecorder: recorder,
 		}, recorder
@@ -121,6 +124,24 @@ func TestDrainControllerReconcileRequest(t *testing.T) {
 		g.Expect(len(updatedMachine.Status.Conditions)).To(BeZero())
 	})
 
+	t.Run("hold machine with hold-deletion annotation", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		machine := getMachine("held", machinev1.PhaseDeleting)
+		machine.ObjectMeta.Annotations[HoldDeletionAnnotation] = ""
+
+		drainController, recorder := getDrainControllerReconciler(machine)
+		request := reconcile.Request{NamespacedName: types.NamespacedName{Name: machine.Name, Namespace: machine.Namespace}}
+
+		_, err := drainController.Reconcile(context.TODO(), request)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Consistently(recorder.Events).ShouldNot(Receive())
+
+		updatedMachine := &machinev1.Machine{}
+		g.Expect(drainController.Client.Get(context.TODO(), request.NamespacedName, updatedMachine)).To(Succeed())
+		g.Expect(len(updatedMachine.Status.Conditions)).To(BeZero())
+	})
+
 	t.Run("skip machine without node", func(t *testing.T) {
 		g := NewGomegaWithT(t)
 
@@ -159,6 +180,82 @@ func TestDrainControllerReconcileRequest(t *testing.T) {
 		g.Expect(updatedMachine.Status.Conditions).To(conditions.MatchConditions(expectedConditions))
 	})
 
+	t.Run("skip interrupted machine", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		machine := getMachine("interrupted", machinev1.PhaseDeleting)
+		machine.ObjectMeta.Annotations[MachineInterruptedAnnotation] = ""
+
+		drainController, recorder := getDrainControllerReconciler(machine)
+		drainController.skipDrainOnInterruption = true
+		request := reconcile.Request{NamespacedName: types.NamespacedName{Name: machine.Name, Namespace: machine.Namespace}}
+
+		_, err := drainController.Reconcile(context.TODO(), request)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Eventually(recorder.Events).Should(Receive(ContainSubstring("Node drain skipped, instance interrupted")))
+
+		updatedMachine := &machinev1.Machine{}
+		g.Expect(drainController.Client.Get(context.TODO(), request.NamespacedName, updatedMachine)).To(Succeed())
+		expectedConditions := getDrainedConditions("Node drain skipped, instance interrupted")
+		g.Expect(updatedMachine.Status.Conditions).To(conditions.MatchConditions(expectedConditions))
+	})
+
+	t.Run("hold interrupted machine with pre-drain hook", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		machine := getMachine("interrupted-held", machinev1.PhaseDeleting)
+		machine.ObjectMeta.Annotations[MachineInterruptedAnnotation] = ""
+		machine.Spec.LifecycleHooks.PreDrain = []machinev1.LifecycleHook{{Name: "stop", Owner: "drain"}}
+
+		drainController, recorder := getDrainControllerReconciler(machine)
+		drainController.skipDrainOnInterruption = true
+		request := reconcile.Request{NamespacedName: types.NamespacedName{Name: machine.Name, Namespace: machine.Namespace}}
+
+		_, err := drainController.Reconcile(context.TODO(), request)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Eventually(recorder.Events).Should(Receive(ContainSubstring("Drain blocked by pre-drain hook")))
+
+		updatedMachine := &machinev1.Machine{}
+		g.Expect(drainController.Client.Get(context.TODO(), request.NamespacedName, updatedMachine)).To(Succeed())
+		g.Expect(len(updatedMachine.Status.Conditions)).To(BeZero())
+	})
+
+	t.Run("drain interrupted machine when skipping is disabled", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		machine := getMachine("interrupted-drained", machinev1.PhaseDeleting)
+		machine.ObjectMeta.Annotations[MachineInterruptedAnnotation] = ""
+
+		drainController, recorder := getDrainControllerReconciler(machine)
+		request := reconcile.Request{NamespacedName: types.NamespacedName{Name: machine.Name, Namespace: machine.Namespace}}
+
+		_, err := drainController.Reconcile(context.TODO(), request)
+		g.Expect(err).To(HaveOccurred())
+		g.Eventually(recorder.Events).Should(Receive(ContainSubstring("Node drain proceeds")))
+
+		updatedMachine := &machinev1.Machine{}
+		g.Expect(drainController.Client.Get(context.TODO(), request.NamespacedName, updatedMachine)).To(Succeed())
+		g.Expect(conditions.IsTrue(updatedMachine, machinev1.MachineDrained)).To(BeFalse())
+	})
+
+	t.Run("drain machine without interruption", func(t *testing.T) {
+		g := NewGomegaWithT(t)
+
+		machine := getMachine("not-interrupted", machinev1.PhaseDeleting)
+
+		drainController, recorder := getDrainControllerReconciler(machine)
+		drainController.skipDrainOnInterruption = true
+		request := reconcile.Request{NamespacedName: types.NamespacedName{Name: machine.Name, Namespace: machine.Namespace}}
+
+		_, err := drainController.Reconcile(context.TODO(), request)
+		g.Expect(err).To(HaveOccurred())
+		g.Eventually(recorder.Events).Should(Receive(ContainSubstring("Node drain proceeds")))
+
+		updatedMachine := &machinev1.Machine{}
+		g.Expect(drainController.Client.Get(context.TODO(), request.NamespacedName, updatedMachine)).To(Succeed())
+		g.Expect(conditions.IsTrue(updatedMachine, machinev1.MachineDrained)).To(BeFalse())
+	})
+
 	t.Run("ignore already drained machine", func(t *testing.T) {
 		g := NewGomegaWithT(t)
 
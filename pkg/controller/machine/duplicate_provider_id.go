@@ -0,0 +1,104 @@
+package machine
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/machine-api-operator/pkg/metrics"
+	"github.com/openshift/machine-api-operator/pkg/util/conditions"
+)
+
+const (
+	// machineProviderIDIndex indexes Machines by their providerID
+	machineProviderIDIndex = "machineProviderIDIndex"
+
+	// DuplicateProviderIDCondition is true when other Machines have the same providerID as the Machine,
+	// meaning that several Machines are backed by the same instance.
+	DuplicateProviderIDCondition machinev1.ConditionType = "DuplicateProviderID"
+
+	// DuplicateProviderIDReason is the DuplicateProviderID condition reason and the event reason
+	// used when other Machines have the same providerID.
+	DuplicateProviderIDReason = "DuplicateProviderID"
+
+	// UniqueProviderIDReason is the DuplicateProviderID condition reason used once the providerID
+	// of the Machine is no longer duplicated.
+	UniqueProviderIDReason = "UniqueProviderID"
+)
+
+// indexMachineByProviderID returns the providerID of the Machine, if any.
+func indexMachineByProviderID(object client.Object) []string {
+	machine, ok := object.(*machinev1.Machine)
+	if !ok {
+		klog.Warningf("Expected a machine for indexing field, got: %T", object)
+		return nil
+	}
+
+	if providerID := ptr.Deref(machine.Spec.ProviderID, ""); providerID != "" {
+		return []string{providerID}
+	}
+	return nil
+}
+
+// reportDuplicateProviderID sets the DuplicateProviderID condition on the Machine when other Machines
+// have the same providerID, and emits an event and the duplicate providerID metric. The duplicates are
+// never deleted, as deleting either Machine would delete the instance backing the other.
+func (r *ReconcileMachine) reportDuplicateProviderID(ctx context.Context, m *machinev1.Machine) {
+	duplicates, err := r.findDuplicateProviderID(ctx, m)
+	if err != nil {
+		klog.Warningf("%v: failed to check for machines with a duplicate providerID: %v", m.GetName(), err)
+		return
+	}
+
+	labels := &metrics.MachineLabels{Name: m.GetName(), Namespace: m.GetNamespace()}
+
+	if len(duplicates) == 0 {
+		metrics.SetMachineDuplicateProviderID(labels, false)
+		if conditions.IsTrue(m, DuplicateProviderIDCondition) {
+			conditions.MarkFalse(m, DuplicateProviderIDCondition, UniqueProviderIDReason, machinev1.ConditionSeverityInfo, "No other machine has providerID %s", ptr.Deref(m.Spec.ProviderID, ""))
+		}
+		return
+	}
+
+	names := strings.Join(duplicates, ", ")
+	klog.Warningf("%v: machines %s have the same providerID %s", m.GetName(), names, ptr.Deref(m.Spec.ProviderID, ""))
+	metrics.SetMachineDuplicateProviderID(labels, true)
+
+	if !conditions.IsTrue(m, DuplicateProviderIDCondition) {
+		r.eventRecorder.Eventf(m, corev1.EventTypeWarning, DuplicateProviderIDReason, "Machines %s have the same providerID %s", names, ptr.Deref(m.Spec.ProviderID, ""))
+	}
+	conditions.Set(m, conditions.TrueConditionWithReason(
+		DuplicateProviderIDCondition,
+		DuplicateProviderIDReason,
+		"Machines %s have the same providerID %s", names, ptr.Deref(m.Spec.ProviderID, ""),
+	))
+}
+
+// findDuplicateProviderID returns the namespaced names of the other Machines with the same providerID as the Machine.
+func (r *ReconcileMachine) findDuplicateProviderID(ctx context.Context, m *machinev1.Machine) ([]string, error) {
+	providerID := ptr.Deref(m.Spec.ProviderID, "")
+	if providerID == "" {
+		return nil, nil
+	}
+
+	machines := &machinev1.MachineList{}
+	if err := r.Client.List(ctx, machines, client.MatchingFields{machineProviderIDIndex: providerID}); err != nil {
+		return nil, err
+	}
+
+	var duplicates []string
+	for _, machine := range machines.Items {
+		if machine.GetNamespace() == m.GetNamespace() && machine.GetName() == m.GetName() {
+			continue
+		}
+		duplicates = append(duplicates, client.ObjectKeyFromObject(&machine).String())
+	}
+	sort.Strings(duplicates)
+	return duplicates, nil
+}
@@ -0,0 +1,69 @@
+package machine
+
+import (
+	"context"
+	"fmt"
+
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/machine-api-operator/pkg/util/conditions"
+)
+
+// DrainSkippedInstanceGoneMessage is the MachineDrained condition message used when the drain of a deleted Machine
+// is skipped because its instance is already gone.
+const DrainSkippedInstanceGoneMessage = "Node drain skipped, instance is already gone"
+
+// skipDrainOfGoneInstance marks a deleted Machine as drained when its instance is already gone, for example because
+// the controller stopped after the instance was removed, so that the Machine is not stuck waiting for the drain of a
+// node which no longer runs. The Machine then goes through the regular deletion path, actuator Delete included, so
+// its finalizer is only removed once the actuator confirms the instance is gone.
+// The instance is only looked up while the node of the Machine is missing or unreachable, so that Machines waiting
+// for a regular drain don't cost a cloud API call on every reconcile. Machines without a node are left to the drain
+// controller, which skips their drain. Pending pre-drain hooks are honoured.
+// It returns whether the drain was skipped.
+func (r *ReconcileMachine) skipDrainOfGoneInstance(ctx context.Context, m *machinev1.Machine, originalConditions []machinev1.Condition) (bool, error) {
+	if len(m.Spec.LifecycleHooks.PreDrain) > 0 || m.Status.NodeRef == nil {
+		return false, nil
+	}
+
+	nodeGone, err := r.isNodeGoneOrUnreachable(ctx, m)
+	if err != nil || !nodeGone {
+		return false, err
+	}
+
+	instanceExists, err := r.actuator.Exists(ctx, m)
+	if err != nil {
+		// The drain controller keeps draining, the error is reported by the regular deletion path
+		klog.V(3).Infof("%v: failed to check if machine exists: %v", m.Name, err)
+		return false, nil
+	}
+	if instanceExists {
+		return false, nil
+	}
+
+	klog.Infof("%v: cloud instance is already gone, skipping node drain", m.Name)
+	drainFinishedCondition := conditions.TrueCondition(machinev1.MachineDrained)
+	drainFinishedCondition.Message = DrainSkippedInstanceGoneMessage
+	conditions.Set(m, drainFinishedCondition)
+	if err := r.updateStatus(ctx, m, machinev1.PhaseDeleting, nil, originalConditions); err != nil {
+		return false, fmt.Errorf("%v: failed to update machine status: %w", m.Name, err)
+	}
+	r.eventRecorder.Eventf(m, corev1.EventTypeNormal, "DrainSkipped", DrainSkippedInstanceGoneMessage)
+	return true, nil
+}
+
+// isNodeGoneOrUnreachable returns true when the node of the Machine no longer exists or is unreachable.
+func (r *ReconcileMachine) isNodeGoneOrUnreachable(ctx context.Context, m *machinev1.Machine) (bool, error) {
+	node := &corev1.Node{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: m.Status.NodeRef.Name}, node); err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, fmt.Errorf("%v: error getting node %q: %w", m.Name, m.Status.NodeRef.Name, err)
+	}
+	return nodeIsUnreachable(node), nil
+}
@@ -31,6 +31,7 @@ import (
 	testutils "github.com/openshift/cluster-api-actuator-pkg/testutils"
 	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1beta1"
 	"github.com/openshift/cluster-control-plane-machine-set-operator/test/e2e/framework"
+	mapicontroller "github.com/openshift/machine-api-operator/pkg/controller"
 	"github.com/openshift/machine-api-operator/pkg/util/testing"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -64,12 +65,12 @@ var _ = Describe("Machine Reconciler", func() {
 
 		By("Setting up a new reconciler")
 		act := newTestActuator()
-		reconciler := newReconciler(mgr, act, gate)
+		reconciler := newReconciler(mgr, act, gate, DefaultOptions())
 
 		Expect(addWithOpts(mgr, controller.Options{
 			Reconciler:         reconciler,
 			SkipNameValidation: ptr.To(true),
-		}, "testing")).To(Succeed())
+		}, "testing", mapicontroller.DefaultClusterPauseNamespace)).To(Succeed())
 
 		var mgrCtx context.Context
 		mgrCtx, mgrCtxCancel = context.WithCancel(ctx)
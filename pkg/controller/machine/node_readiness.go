@@ -0,0 +1,95 @@
+package machine
+
+import (
+	"context"
+	"fmt"
+
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/machine-api-operator/pkg/util/conditions"
+)
+
+// NodeReadiness selects when the node of a provisioned Machine is considered ready for the Machine to go Running.
+type NodeReadiness string
+
+const (
+	// NodeReadinessExists marks the Machine Running as soon as it is linked to a node.
+	NodeReadinessExists NodeReadiness = "Exists"
+
+	// NodeReadinessReady marks the Machine Running once its node is linked and has the Ready condition.
+	NodeReadinessReady NodeReadiness = "Ready"
+)
+
+const (
+	// NodeReadyCondition is true once the node of the Machine is Ready. It is only set with the NodeReadinessReady policy.
+	NodeReadyCondition machinev1.ConditionType = "NodeReady"
+
+	// WaitingForNodeReadyReason is the NodeReady condition reason used while the node of the Machine is not Ready yet.
+	WaitingForNodeReadyReason = "WaitingForNodeReady"
+
+	// NodeReadyTimeoutReason is the NodeReady condition reason used once the node of the Machine has not become
+	// Ready within the node ready timeout.
+	NodeReadyTimeoutReason = "NodeReadyTimeout"
+)
+
+// ParseNodeReadiness returns the NodeReadiness policy with the given name.
+func ParseNodeReadiness(s string) (NodeReadiness, error) {
+	switch policy := NodeReadiness(s); policy {
+	case NodeReadinessExists, NodeReadinessReady:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown node readiness policy %q, expected %q or %q", s, NodeReadinessExists, NodeReadinessReady)
+	}
+}
+
+// isNodeReady returns true if the node has the Ready condition.
+func isNodeReady(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// waitForNodeReady returns true when the node of the Machine is ready according to the node readiness policy.
+// With the NodeReadinessReady policy it sets the NodeReady condition, whose reason is set to NodeReadyTimeout once
+// the node has not become Ready within the node ready timeout.
+func (r *ReconcileMachine) waitForNodeReady(ctx context.Context, m *machinev1.Machine) (bool, error) {
+	if r.nodeReadiness != NodeReadinessReady {
+		return true, nil
+	}
+
+	node := &corev1.Node{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: m.Status.NodeRef.Name}, node); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return false, fmt.Errorf("error getting node %q: %w", m.Status.NodeRef.Name, err)
+		}
+		node = nil
+	}
+
+	if node != nil && isNodeReady(node) {
+		conditions.MarkTrue(m, NodeReadyCondition)
+		return true, nil
+	}
+
+	condition := conditions.Get(m, NodeReadyCondition)
+	switch {
+	case condition != nil && condition.Reason == NodeReadyTimeoutReason:
+		// Keep the condition, and its transition time, until the node is Ready
+	case condition != nil && condition.Reason == WaitingForNodeReadyReason &&
+		r.nodeReadyTimeout > 0 && r.now().Sub(condition.LastTransitionTime.Time) > r.nodeReadyTimeout:
+		klog.Warningf("%v: node %q has not become Ready within %v", m.GetName(), m.Status.NodeRef.Name, r.nodeReadyTimeout)
+		conditions.MarkFalse(m, NodeReadyCondition, NodeReadyTimeoutReason, machinev1.ConditionSeverityWarning,
+			"Node %q has not become Ready within %v", m.Status.NodeRef.Name, r.nodeReadyTimeout)
+	default:
+		// The message is constant so that the transition time records when the wait started
+		conditions.MarkFalse(m, NodeReadyCondition, WaitingForNodeReadyReason, machinev1.ConditionSeverityInfo,
+			"Waiting for node %q to become Ready", m.Status.NodeRef.Name)
+	}
+	return false, nil
+}
@@ -0,0 +1,93 @@
+package machine
+
+import (
+	"time"
+
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/machine-api-operator/pkg/metrics"
+	"github.com/openshift/machine-api-operator/pkg/util/conditions"
+)
+
+const (
+	// StuckCondition is true when the Machine has been in the Provisioning or Deleting phase for longer
+	// than the threshold of the phase.
+	StuckCondition machinev1.ConditionType = "Stuck"
+
+	// ProvisioningStuckReason is the Stuck condition reason used when the Machine has been provisioning for too long.
+	ProvisioningStuckReason = "ProvisioningStuck"
+
+	// DeletingStuckReason is the Stuck condition reason used when the Machine has been deleting for too long.
+	DeletingStuckReason = "DeletingStuck"
+
+	// NotStuckReason is the Stuck condition reason used once the Machine has left the phase it was stuck in.
+	NotStuckReason = "NotStuck"
+)
+
+// phaseStartTime returns when the Machine entered the given phase, and false when the phase is not tracked.
+// Machines are provisioning from their creation and deleting from their deletion timestamp.
+func phaseStartTime(m *machinev1.Machine, phase string) (time.Time, bool) {
+	switch phase {
+	case machinev1.PhaseProvisioning:
+		return m.GetCreationTimestamp().Time, true
+	case machinev1.PhaseDeleting:
+		if m.GetDeletionTimestamp() == nil {
+			return time.Time{}, false
+		}
+		return m.GetDeletionTimestamp().Time, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// stuckThreshold returns the threshold of the phase and the Stuck condition reason used once it is exceeded.
+func (r *ReconcileMachine) stuckThreshold(phase string) (time.Duration, string) {
+	if phase == machinev1.PhaseDeleting {
+		return r.deletingStuckThreshold, DeletingStuckReason
+	}
+	return r.provisioningStuckThreshold, ProvisioningStuckReason
+}
+
+// isPhaseStuck returns true when the Machine has been in the given phase for longer than the threshold of the phase.
+func (r *ReconcileMachine) isPhaseStuck(m *machinev1.Machine, phase string) bool {
+	start, tracked := phaseStartTime(m, phase)
+	if !tracked {
+		return false
+	}
+	threshold, _ := r.stuckThreshold(phase)
+	return threshold > 0 && r.now().Sub(start) > threshold
+}
+
+// setStuckCondition reports when the Machine entered the given phase with the phase start timestamp metric,
+// and sets the Stuck condition once the threshold of the phase is exceeded. The condition is evaluated when the
+// Machine is reconciled, so it may only be set on the next resync after the threshold is exceeded.
+func (r *ReconcileMachine) setStuckCondition(m *machinev1.Machine, phase string) {
+	labels := &metrics.MachineLabels{Name: m.GetName(), Namespace: m.GetNamespace()}
+
+	start, tracked := phaseStartTime(m, phase)
+	if !tracked {
+		metrics.DeleteMachinePhaseStartTime(labels)
+		if conditions.IsTrue(m, StuckCondition) {
+			conditions.MarkFalse(m, StuckCondition, NotStuckReason, machinev1.ConditionSeverityInfo, "Machine is in phase %s", phase)
+		}
+		return
+	}
+
+	metrics.SetMachinePhaseStartTime(labels, phase, start)
+
+	elapsed := r.now().Sub(start)
+	threshold, reason := r.stuckThreshold(phase)
+	if threshold <= 0 || elapsed <= threshold {
+		return
+	}
+
+	if !conditions.IsTrue(m, StuckCondition) {
+		klog.Warningf("%v: machine has been in phase %s for %v, longer than %v", m.GetName(), phase, elapsed.Truncate(time.Second), threshold)
+	}
+	conditions.Set(m, conditions.TrueConditionWithReason(
+		StuckCondition,
+		reason,
+		"Machine has been in phase %s for longer than %v", phase, threshold,
+	))
+}
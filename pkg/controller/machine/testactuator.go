@@ -24,19 +24,32 @@ import (
 )
 
 var _ Actuator = &TestActuator{}
+var _ DriftDetector = &TestActuator{}
+var _ UpdateReporter = &TestActuator{}
+var _ VolumeAttacher = &TestActuator{}
 
 type TestActuator struct {
-	unblock         chan string
-	BlockOnCreate   bool
-	BlockOnDelete   bool
-	BlockOnUpdate   bool
-	BlockOnExists   bool
-	CreateCallCount int64
-	DeleteCallCount int64
-	UpdateCallCount int64
-	ExistsCallCount int64
-	ExistsValue     bool
-	Lock            sync.Mutex
+	unblock                chan string
+	BlockOnCreate          bool
+	BlockOnDelete          bool
+	BlockOnUpdate          bool
+	BlockOnExists          bool
+	CreateCallCount        int64
+	DeleteCallCount        int64
+	UpdateCallCount        int64
+	ExistsCallCount        int64
+	ExistsValue            bool
+	ExistsError            error
+	CreateError            error
+	UpdateError            error
+	UpdateChanged          bool
+	DriftValue             []ProviderSpecDrift
+	DriftError             error
+	DriftCallCount         int64
+	AttachVolumesValue     []string
+	AttachVolumesError     error
+	AttachVolumesCallCount int64
+	Lock                   sync.Mutex
 }
 
 func (a *TestActuator) Create(context.Context, *machinev1.Machine) error {
@@ -49,7 +62,7 @@ func (a *TestActuator) Create(context.Context, *machinev1.Machine) error {
 	a.Lock.Lock()
 	defer a.Lock.Unlock()
 	a.CreateCallCount++
-	return nil
+	return a.CreateError
 }
 
 func (a *TestActuator) Delete(context.Context, *machinev1.Machine) error {
@@ -74,7 +87,14 @@ func (a *TestActuator) Update(ctx context.Context, machine *machinev1.Machine) e
 	a.Lock.Lock()
 	defer a.Lock.Unlock()
 	a.UpdateCallCount++
-	return nil
+	return a.UpdateError
+}
+
+func (a *TestActuator) UpdateWithResult(ctx context.Context, machine *machinev1.Machine) (bool, error) {
+	if err := a.Update(ctx, machine); err != nil {
+		return false, err
+	}
+	return a.UpdateChanged, nil
 }
 
 func (a *TestActuator) Exists(context.Context, *machinev1.Machine) (bool, error) {
@@ -87,7 +107,21 @@ func (a *TestActuator) Exists(context.Context, *machinev1.Machine) (bool, error)
 	a.Lock.Lock()
 	defer a.Lock.Unlock()
 	a.ExistsCallCount++
-	return a.ExistsValue, nil
+	return a.ExistsValue, a.ExistsError
+}
+
+func (a *TestActuator) Drift(context.Context, *machinev1.Machine) ([]ProviderSpecDrift, error) {
+	a.Lock.Lock()
+	defer a.Lock.Unlock()
+	a.DriftCallCount++
+	return a.DriftValue, a.DriftError
+}
+
+func (a *TestActuator) AttachVolumes(context.Context, *machinev1.Machine) ([]string, error) {
+	a.Lock.Lock()
+	defer a.Lock.Unlock()
+	a.AttachVolumesCallCount++
+	return a.AttachVolumesValue, a.AttachVolumesError
 }
 
 func newTestActuator() *TestActuator {
@@ -2,6 +2,8 @@ package machinehealthcheck
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"math"
@@ -10,6 +12,7 @@ import (
 	"time"
 
 	machinev1 "github.com/openshift/api/machine/v1beta1"
+	mapicontroller "github.com/openshift/machine-api-operator/pkg/controller"
 	"github.com/openshift/machine-api-operator/pkg/metrics"
 	"github.com/openshift/machine-api-operator/pkg/util/annotations"
 	"github.com/openshift/machine-api-operator/pkg/util/conditions"
@@ -68,6 +71,15 @@ const (
 	// EventExternalAnnotationAdded is emitted when external annotation was
 	// successfully added to a Node object
 	EventExternalAnnotationAdded string = "ExternalAnnotationAdded"
+	// EventRemediationRateLimited is emitted in case a machine remediation
+	// is delayed by the cluster wide remediation rate limit
+	EventRemediationRateLimited string = "RemediationRateLimited"
+	// NodeSelectorAnnotation is an annotation that can be applied to MachineHealthCheck objects to further filter
+	// the Machines matched by the selector by the labels of their Node. Its value is a label selector, e.g.
+	// "node-role.kubernetes.io/infra,disktype!=hdd". It only applies to existing Nodes: Machines without a Node, or
+	// whose Node was deleted, are still targeted.
+	NodeSelectorAnnotation = "machine.openshift.io/node-selector"
+
 	// PausedAnnotation is an annotation that can be applied to MachineHealthCheck objects to prevent the MHC controller
 	// from processing it.
 	// TODO: move this annotation to the openshift/api package
@@ -79,14 +91,47 @@ var (
 	disabledNodeStartupTimeout = metav1.Duration{Duration: 0}
 )
 
+// Options are the settings of the MachineHealthCheck controller.
+type Options struct {
+	// MaxRemediations is the number of remediations allowed per RemediationWindow across all MachineHealthChecks.
+	// Zero disables the limit.
+	MaxRemediations int
+	// RemediationWindow is the window over which the remediations are limited by MaxRemediations.
+	RemediationWindow time.Duration
+	// MinNodeStartupTimeout is the floor of the nodeStartupTimeout of every MachineHealthCheck. Machines younger
+	// than that are not remediated for any reason, whatever their MachineHealthCheck spec. A disabled
+	// nodeStartupTimeout stays disabled. Zero disables the floor.
+	MinNodeStartupTimeout time.Duration
+	// ClusterPauseNamespace is the namespace of the machine-api-operator, in which it creates the
+	// ClusterPauseConfigMapName ConfigMap to pause the controller.
+	ClusterPauseNamespace string
+}
+
 // Add creates a new MachineHealthCheck Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and start it when the Manager is started.
 func Add(mgr manager.Manager, opts manager.Options) error {
-	r, err := newReconciler(mgr, opts)
-	if err != nil {
-		return fmt.Errorf("error building reconciler: %v", err)
+	return AddWithOptions(Options{ClusterPauseNamespace: mapicontroller.DefaultClusterPauseNamespace})(mgr, opts)
+}
+
+// AddWithOptions returns a function which creates a new MachineHealthCheck Controller with the given options,
+// and adds it to the Manager.
+func AddWithOptions(mhcOpts Options) func(manager.Manager, manager.Options) error {
+	return func(mgr manager.Manager, opts manager.Options) error {
+		limiter, err := newRemediationLimiter(mhcOpts.MaxRemediations, mhcOpts.RemediationWindow)
+		if err != nil {
+			return err
+		}
+
+		r, err := newReconciler(mgr, opts)
+		if err != nil {
+			return fmt.Errorf("error building reconciler: %v", err)
+		}
+		r.remediationLimiter = limiter
+		r.minNodeStartupTimeout = mhcOpts.MinNodeStartupTimeout
+		gated := mapicontroller.WithClusterPause(controllerName, mgr.GetClient(), mhcOpts.ClusterPauseNamespace, r,
+			func() client.Object { return &machinev1.MachineHealthCheck{} })
+		return add(mgr, gated, mhcOpts.ClusterPauseNamespace, r.mhcRequestsFromMachine, r.mhcRequestsFromNode)
 	}
-	return add(mgr, r, r.mhcRequestsFromMachine, r.mhcRequestsFromNode)
 }
 
 // newReconciler returns a new reconcile.Reconciler
@@ -121,7 +166,7 @@ func indexMachineByNodeName(object client.Object) []string {
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
-func add(mgr manager.Manager, r reconcile.Reconciler, mapMachineToMHC handler.TypedMapFunc[*machinev1.Machine, reconcile.Request], mapNodeToMHC handler.TypedMapFunc[*corev1.Node, reconcile.Request]) error {
+func add(mgr manager.Manager, r reconcile.Reconciler, clusterPauseNamespace string, mapMachineToMHC handler.TypedMapFunc[*machinev1.Machine, reconcile.Request], mapNodeToMHC handler.TypedMapFunc[*corev1.Node, reconcile.Request]) error {
 	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
 	if err != nil {
 		return err
@@ -137,7 +182,13 @@ func add(mgr manager.Manager, r reconcile.Reconciler, mapMachineToMHC handler.Ty
 		return err
 	}
 
-	return c.Watch(source.Kind(mgr.GetCache(), &corev1.Node{}, handler.TypedEnqueueRequestsFromMapFunc[*corev1.Node](mapNodeToMHC)))
+	err = c.Watch(source.Kind(mgr.GetCache(), &corev1.Node{}, handler.TypedEnqueueRequestsFromMapFunc[*corev1.Node](mapNodeToMHC)))
+	if err != nil {
+		return err
+	}
+
+	// Reconcile all the MachineHealthChecks again when the cluster pause ends
+	return c.Watch(mapicontroller.ClusterPauseSource(mgr, clusterPauseNamespace, func() client.ObjectList { return &machinev1.MachineHealthCheckList{} }))
 }
 
 var _ reconcile.Reconciler = &ReconcileMachineHealthCheck{}
@@ -149,6 +200,12 @@ type ReconcileMachineHealthCheck struct {
 	client   client.Client
 	scheme   *runtime.Scheme
 	recorder record.EventRecorder
+
+	// remediationLimiter caps the remediations started across all MachineHealthChecks, nil when unlimited.
+	remediationLimiter *remediationLimiter
+
+	// minNodeStartupTimeout is the floor of the nodeStartupTimeout of every MachineHealthCheck, zero when disabled.
+	minNodeStartupTimeout time.Duration
 }
 
 type target struct {
@@ -194,13 +251,10 @@ func (r *ReconcileMachineHealthCheck) Reconcile(ctx context.Context, request rec
 
 	metrics.ObserveMachineHealthCheckNodesCovered(mhc.Name, mhc.Namespace, totalTargets)
 
-	nodeStartupTimeout := mhc.Spec.NodeStartupTimeout
-	if nodeStartupTimeout == nil {
-		nodeStartupTimeout = &metav1.Duration{Duration: defaultNodeStartupTimeout}
-	}
+	nodeStartupTimeout := r.nodeStartupTimeout(mhc)
 
 	// health check all targets and reconcile mhc status
-	currentHealthy, needRemediationTargets, nextCheckTimes, errList := r.healthCheckTargets(targets, nodeStartupTimeout.Duration)
+	currentHealthy, needRemediationTargets, nextCheckTimes, errList := r.healthCheckTargets(targets, nodeStartupTimeout)
 	healthyCount := len(currentHealthy)
 	mhc.Status.CurrentHealthy = &healthyCount
 	mhc.Status.ExpectedMachines = &totalTargets
@@ -284,15 +338,15 @@ func (r *ReconcileMachineHealthCheck) remediate(ctx context.Context, needRemedia
 	var errList []error
 	// remediate unhealthy
 	for _, t := range needRemediationTargets {
-		klog.V(3).Infof("Reconciling %s: meet unhealthy criteria, triggers remediation", t.string())
+		klog.V(3).Infof("Reconciling %s: meet unhealthy criteria, triggers remediation", t.remediationString())
 		if m.Spec.RemediationTemplate != nil {
 			if err := r.externalRemediation(ctx, m, t); err != nil {
-				klog.Errorf("Reconciling %s: error external remediating: %v", t.string(), err)
+				klog.Errorf("Reconciling %s: error external remediating: %v", t.remediationString(), err)
 				errList = append(errList, err)
 			}
 		} else {
 			if err := r.internalRemediation(t); err != nil {
-				klog.Errorf("Reconciling %s: error remediating: %v", t.string(), err)
+				klog.Errorf("Reconciling %s: error remediating: %v", t.remediationString(), err)
 				errList = append(errList, err)
 			}
 		}
@@ -327,7 +381,7 @@ func (r *ReconcileMachineHealthCheck) cleanEMR(ctx context.Context, currentHealt
 }
 
 func (r *ReconcileMachineHealthCheck) externalRemediation(ctx context.Context, m *machinev1.MachineHealthCheck, t target) error {
-	klog.V(3).Infof(" %s: start external remediation logic", t.string())
+	klog.V(3).Infof(" %s: start external remediation logic", t.remediationString())
 	re, err := r.externalRemediationRequestExists(ctx, m, t.Machine.Name)
 	if err != nil {
 		return fmt.Errorf("error retrieving external remediation  %v %q for machine %q in namespace %q: %v", m.Spec.RemediationTemplate.GroupVersionKind(), m.Spec.RemediationTemplate.Name, t.Machine.Name, t.Machine.Namespace, err)
@@ -369,7 +423,11 @@ func (r *ReconcileMachineHealthCheck) externalRemediation(ctx context.Context, m
 	// the same Machine, users are in charge of setting health checks and remediation properly.
 	to.SetName(t.Machine.Name)
 
-	klog.V(3).Info("Target has failed health check, creating an external remediation request", "remediation request name", to.GetName(), "target", t.string())
+	if err := r.allowRemediation(t); err != nil {
+		return err
+	}
+
+	klog.V(3).Info("Target has failed health check, creating an external remediation request", "remediation request name", to.GetName(), "target", t.remediationString())
 	// Create the external clone.
 	if err := r.client.Create(ctx, to); err != nil {
 		conditions.MarkFalse(m, machinev1.ExternalRemediationRequestAvailable, machinev1.ExternalRemediationRequestCreationFailed, machinev1.ConditionSeverityError, "%s", err.Error())
@@ -455,6 +513,35 @@ func (r *ReconcileMachineHealthCheck) reconcileStatus(baseToPatch client.Patch,
 	return nil
 }
 
+// nodeStartupTimeout returns the nodeStartupTimeout of the MachineHealthCheck, raised to the minNodeStartupTimeout
+// unless it is disabled.
+func (r *ReconcileMachineHealthCheck) nodeStartupTimeout(mhc *machinev1.MachineHealthCheck) time.Duration {
+	nodeStartupTimeout := defaultNodeStartupTimeout
+	if mhc.Spec.NodeStartupTimeout != nil {
+		nodeStartupTimeout = mhc.Spec.NodeStartupTimeout.Duration
+	}
+
+	if nodeStartupTimeout != disabledNodeStartupTimeout.Duration && nodeStartupTimeout < r.minNodeStartupTimeout {
+		klog.V(3).Infof("%s/%s: nodeStartupTimeout %v is raised to the minimum of %v", mhc.Namespace, mhc.Name, nodeStartupTimeout, r.minNodeStartupTimeout)
+		return r.minNodeStartupTimeout
+	}
+	return nodeStartupTimeout
+}
+
+// remainingStartupTime returns how long the Machine of the target is still younger than the minNodeStartupTimeout,
+// zero when it is older or the minimum is disabled.
+func (r *ReconcileMachineHealthCheck) remainingStartupTime(t target) time.Duration {
+	if r.minNodeStartupTimeout <= 0 || t.Machine.CreationTimestamp.IsZero() {
+		return 0
+	}
+	remaining := r.minNodeStartupTimeout - time.Since(t.Machine.CreationTimestamp.Time)
+	if remaining <= 0 {
+		return 0
+	}
+	// Add one second to make sure the next check is after the Machine reached the minimum
+	return remaining + time.Second
+}
+
 // healthCheckTargets health checks a slice of targets
 // and gives a data to measure the average health
 func (r *ReconcileMachineHealthCheck) healthCheckTargets(targets []target, timeoutForMachineToHaveNode time.Duration) ([]target, []target, []time.Duration, []error) {
@@ -471,18 +558,24 @@ func (r *ReconcileMachineHealthCheck) healthCheckTargets(targets []target, timeo
 		}
 
 		if needsRemediation {
+			if remaining := r.remainingStartupTime(t); remaining > 0 {
+				klog.V(3).Infof("Reconciling %s: is unhealthy but younger than the minimum nodeStartupTimeout of %v, checking again in %v",
+					t.remediationString(), r.minNodeStartupTimeout, remaining)
+				nextCheckTimes = append(nextCheckTimes, remaining)
+				continue
+			}
 			needRemediationTargets = append(needRemediationTargets, t)
 			continue
 		}
 
 		if nextCheck > 0 {
-			klog.V(3).Infof("Reconciling %s: is likely to go unhealthy in %v", t.string(), nextCheck)
+			klog.V(3).Infof("Reconciling %s: is likely to go unhealthy in %v", t.remediationString(), nextCheck)
 			r.recorder.Eventf(
 				&t.Machine,
 				corev1.EventTypeNormal,
 				EventDetectedUnhealthy,
 				"Machine %v has unhealthy node %v",
-				t.string(),
+				t.remediationString(),
 				t.nodeName(),
 			)
 			nextCheckTimes = append(nextCheckTimes, nextCheck)
@@ -508,6 +601,11 @@ func (r *ReconcileMachineHealthCheck) getTargetsFromMHC(mhc machinev1.MachineHea
 		return nil, nil
 	}
 
+	nodeSelector, err := getNodeSelector(mhc)
+	if err != nil {
+		return nil, err
+	}
+
 	var targets []target
 	for k := range machines {
 		target := target{
@@ -515,6 +613,7 @@ func (r *ReconcileMachineHealthCheck) getTargetsFromMHC(mhc machinev1.MachineHea
 			Machine: machines[k],
 		}
 		node, err := r.getNodeFromMachine(machines[k])
+		nodeFound := node != nil && err == nil
 		if err != nil {
 			if !apimachineryerrors.IsNotFound(err) {
 				return nil, fmt.Errorf("error getting node: %v", err)
@@ -523,6 +622,12 @@ func (r *ReconcileMachineHealthCheck) getTargetsFromMHC(mhc machinev1.MachineHea
 			// not found node in the target
 			node.Name = machines[k].Status.NodeRef.Name
 		}
+		// The node selector only applies to existing nodes, so that machines whose node never joined or was
+		// deleted are still remediated
+		if nodeSelector != nil && nodeFound && !nodeSelector.Matches(labels.Set(node.Labels)) {
+			klog.V(4).Infof("%q machine has a node not matching the node selector of MHC %q", machines[k].GetName(), mhc.GetName())
+			continue
+		}
 		target.Node = node
 		targets = append(targets, target)
 	}
@@ -625,7 +730,7 @@ func (r *ReconcileMachineHealthCheck) mhcRequestsFromMachine(ctx context.Context
 }
 
 func (r *ReconcileMachineHealthCheck) internalRemediation(t target) error {
-	klog.Infof(" %s: start remediation logic", t.string())
+	klog.Infof(" %s: start remediation logic", t.remediationString())
 	if derefStringPointer(t.Machine.Status.Phase) != machinev1.PhaseFailed {
 		if remediationStrategy, ok := t.MHC.Annotations[remediationStrategyAnnotation]; ok {
 			if machinev1.RemediationStrategyType(remediationStrategy) == remediationStrategyExternal {
@@ -640,9 +745,9 @@ func (r *ReconcileMachineHealthCheck) internalRemediation(t target) error {
 			corev1.EventTypeNormal,
 			EventSkippedNoController,
 			"Machine %v has no controller owner, skipping remediation",
-			t.string(),
+			t.remediationString(),
 		)
-		klog.Infof("%s: no controller owner, skipping remediation", t.string())
+		klog.Infof("%s: no controller owner, skipping remediation", t.remediationString())
 		return nil
 	}
 
@@ -653,7 +758,7 @@ func (r *ReconcileMachineHealthCheck) internalRemediation(t target) error {
 			// Machine has already been deleted
 			return nil
 		}
-		return fmt.Errorf("%s: failed to get machine: %v", t.string(), err)
+		return fmt.Errorf("%s: failed to get machine: %v", t.remediationString(), err)
 	}
 
 	if !machine.GetDeletionTimestamp().IsZero() {
@@ -661,30 +766,51 @@ func (r *ReconcileMachineHealthCheck) internalRemediation(t target) error {
 		return nil
 	}
 
-	klog.Infof("%s: deleting", t.string())
+	if err := r.allowRemediation(t); err != nil {
+		return err
+	}
+
+	klog.Infof("%s: deleting", t.remediationString())
 	if err := r.client.Delete(context.TODO(), &t.Machine); err != nil {
 		r.recorder.Eventf(
 			&t.Machine,
 			corev1.EventTypeWarning,
 			EventMachineDeletionFailed,
 			"Machine %v remediation failed: unable to delete Machine object: %v",
-			t.string(),
+			t.remediationString(),
 			err,
 		)
-		return fmt.Errorf("%s: failed to delete machine: %v", t.string(), err)
+		return fmt.Errorf("%s: failed to delete machine: %v", t.remediationString(), err)
 	}
 	r.recorder.Eventf(
 		&t.Machine,
 		corev1.EventTypeNormal,
 		EventMachineDeleted,
 		"Machine %v has been remediated by requesting to delete Machine object",
-		t.string(),
+		t.remediationString(),
 	)
 	metrics.ObserveMachineHealthCheckRemediationSuccess(t.MHC.Name, t.MHC.Namespace)
 
 	return nil
 }
 
+// allowRemediation checks the cluster wide remediation limit before a remediation of the target is started.
+// When the limit is reached, an event is emitted and an error is returned so that the request is requeued with backoff.
+func (r *ReconcileMachineHealthCheck) allowRemediation(t target) error {
+	if r.remediationLimiter.allow() {
+		return nil
+	}
+
+	r.recorder.Eventf(
+		&t.MHC,
+		corev1.EventTypeWarning,
+		EventRemediationRateLimited,
+		"Remediation of machine %v delayed: the remediation rate limit has been reached",
+		t.remediationString(),
+	)
+	return fmt.Errorf("%s: %w", t.remediationString(), errRemediationRateLimited)
+}
+
 func (t *target) remediationStrategyExternal(r *ReconcileMachineHealthCheck) error {
 	// we already have external annotation on the machine, stop reconcile
 	if externalRemediationAnnotationExists(&t.Machine) {
@@ -695,7 +821,11 @@ func (t *target) remediationStrategyExternal(r *ReconcileMachineHealthCheck) err
 		t.Machine.Annotations = map[string]string{}
 	}
 
-	klog.Infof("Machine %s has been unhealthy for too long, adding external annotation", t.Machine.Name)
+	if err := r.allowRemediation(*t); err != nil {
+		return err
+	}
+
+	klog.Infof("%s: has been unhealthy for too long, adding external annotation", t.remediationString())
 	t.Machine.Annotations[machineExternalAnnotationKey] = ""
 	if err := r.client.Update(context.TODO(), &t.Machine); err != nil {
 		r.recorder.Eventf(
@@ -703,7 +833,7 @@ func (t *target) remediationStrategyExternal(r *ReconcileMachineHealthCheck) err
 			corev1.EventTypeWarning,
 			EventExternalAnnotationFailed,
 			"Requesting external remediation of node associated with machine %v failed: %v",
-			t.string(),
+			t.remediationString(),
 			err,
 		)
 		return err
@@ -713,7 +843,7 @@ func (t *target) remediationStrategyExternal(r *ReconcileMachineHealthCheck) err
 		corev1.EventTypeNormal,
 		EventExternalAnnotationAdded,
 		"Requesting external remediation of node associated with machine %v",
-		t.string(),
+		t.remediationString(),
 	)
 	return nil
 }
@@ -749,6 +879,44 @@ func (t *target) string() string {
 	)
 }
 
+// remediationString identifies the target in the events and log lines of its remediation,
+// including the correlation ID of the remediation.
+func (t *target) remediationString() string {
+	return fmt.Sprintf("%s [correlationID=%s]", t.string(), t.correlationID())
+}
+
+// correlationID returns a short identifier shared by the events and log lines of a single remediation
+// of the target, from detection to deletion. It is derived from the Machine UID and the time the target
+// became unhealthy so that retries of the same remediation reuse it.
+func (t *target) correlationID() string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%d", t.Machine.GetUID(), t.unhealthySince().Unix())))
+	return hex.EncodeToString(sum[:])[:8]
+}
+
+// unhealthySince returns the time at which the target became unhealthy.
+// It is read from the status of the Machine and Node rather than observed, so that it does not change between reconciles.
+func (t *target) unhealthySince() time.Time {
+	if derefStringPointer(t.Machine.Status.Phase) == machinev1.PhaseFailed || t.Node == nil || t.Node.UID == "" {
+		if t.Machine.Status.LastUpdated == nil {
+			return time.Time{}
+		}
+		return t.Machine.Status.LastUpdated.Time
+	}
+
+	// The earliest transition of the node into one of the unhealthy conditions
+	var since time.Time
+	for _, c := range t.MHC.Spec.UnhealthyConditions {
+		nodeCondition := conditions.GetNodeCondition(t.Node, c.Type)
+		if nodeCondition == nil || nodeCondition.Status != c.Status {
+			continue
+		}
+		if since.IsZero() || nodeCondition.LastTransitionTime.Time.Before(since) {
+			since = nodeCondition.LastTransitionTime.Time
+		}
+	}
+	return since
+}
+
 func (t *target) nodeName() string {
 	if t.Node != nil {
 		return t.Node.GetName()
@@ -762,7 +930,7 @@ func (t *target) needsRemediation(timeoutForMachineToHaveNode time.Duration) (bo
 
 	// machine has failed
 	if derefStringPointer(t.Machine.Status.Phase) == machinev1.PhaseFailed {
-		klog.V(3).Infof("%s: unhealthy: machine phase is %q", t.string(), machinev1.PhaseFailed)
+		klog.V(3).Infof("%s: unhealthy: machine phase is %q", t.remediationString(), machinev1.PhaseFailed)
 		return true, time.Duration(0), nil
 	}
 
@@ -779,7 +947,7 @@ func (t *target) needsRemediation(timeoutForMachineToHaveNode time.Duration) (bo
 			return false, timeoutForMachineToHaveNode, nil
 		}
 		if t.Machine.Status.LastUpdated.Add(timeoutForMachineToHaveNode).Before(now) {
-			klog.V(3).Infof("%s: unhealthy: machine has no node after %v", t.string(), timeoutForMachineToHaveNode)
+			klog.V(3).Infof("%s: unhealthy: machine has no node after %v", t.remediationString(), timeoutForMachineToHaveNode)
 			return true, time.Duration(0), nil
 		}
 		durationUnhealthy := now.Sub(t.Machine.Status.LastUpdated.Time)
@@ -806,7 +974,7 @@ func (t *target) needsRemediation(timeoutForMachineToHaveNode time.Duration) (bo
 		// If the condition has been in the unhealthy state for longer than the
 		// timeout, return true with no requeue time.
 		if nodeCondition.LastTransitionTime.Add(c.Timeout.Duration).Before(now) {
-			klog.V(3).Infof("%s: unhealthy: condition %v in state %v longer than %v", t.string(), c.Type, c.Status, c.Timeout)
+			klog.V(3).Infof("%s: unhealthy: condition %v in state %v longer than %v", t.remediationString(), c.Type, c.Status, c.Timeout)
 			return true, time.Duration(0), nil
 		}
 
@@ -868,6 +1036,19 @@ func hasMatchingLabels(machineHealthCheck *machinev1.MachineHealthCheck, machine
 	return true
 }
 
+// getNodeSelector returns the selector of the NodeSelectorAnnotation of the MHC, or nil when it is not set.
+func getNodeSelector(mhc machinev1.MachineHealthCheck) (labels.Selector, error) {
+	value, ok := mhc.Annotations[NodeSelectorAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	selector, err := labels.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s annotation %q: %v", NodeSelectorAnnotation, value, err)
+	}
+	return selector, nil
+}
+
 // getValueFromIntOrPercent returns the integer number value based on the
 // percentage of the total or absolute number dependent on the IntOrString given
 //
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -496,6 +497,152 @@ func TestReconcile(t *testing.T) {
 	}
 }
 
+func TestReconcileMinNodeStartupTimeout(t *testing.T) {
+	machineWithoutNode := func() []runtime.Object {
+		machine := maotesting.NewMachine("machine", "")
+		machine.Status.LastUpdated = &metav1.Time{Time: time.Now().Add(-2 * time.Minute)}
+		return []runtime.Object{machine}
+	}
+	failedMachine := func() []runtime.Object {
+		node := maotesting.NewNode("node", true)
+		machine := maotesting.NewMachine("machine", node.Name)
+		machine.Status.Phase = ptr.To[string](machinev1.PhaseFailed)
+		return []runtime.Object{machine, node}
+	}
+	machineWithUnhealthyNode := func() []runtime.Object {
+		node := maotesting.NewNode("node", false)
+		machine := maotesting.NewMachine("machine", node.Name)
+		return []runtime.Object{machine, node}
+	}
+
+	testCases := []struct {
+		name                  string
+		objects               func() []runtime.Object
+		minNodeStartupTimeout time.Duration
+		expectRemediation     bool
+	}{
+		{
+			name:              "without a minimum, a machine without node is remediated after the spec timeout",
+			objects:           machineWithoutNode,
+			expectRemediation: true,
+		},
+		{
+			name:                  "with a minimum, a machine without node younger than the minimum is not remediated",
+			objects:               machineWithoutNode,
+			minNodeStartupTimeout: 10 * time.Minute,
+			expectRemediation:     false,
+		},
+		{
+			name:              "without a minimum, a failed machine is remediated",
+			objects:           failedMachine,
+			expectRemediation: true,
+		},
+		{
+			name:                  "with a minimum, a failed machine younger than the minimum is not remediated",
+			objects:               failedMachine,
+			minNodeStartupTimeout: 10 * time.Minute,
+			expectRemediation:     false,
+		},
+		{
+			name:              "without a minimum, a machine with an unhealthy node is remediated after the condition timeout",
+			objects:           machineWithUnhealthyNode,
+			expectRemediation: true,
+		},
+		{
+			name:                  "with a minimum, a machine with an unhealthy node younger than the minimum is not remediated",
+			objects:               machineWithUnhealthyNode,
+			minNodeStartupTimeout: 10 * time.Minute,
+			expectRemediation:     false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctx := context.Background()
+
+			objects := tc.objects()
+			machine := objects[0].(*machinev1.Machine)
+			machine.CreationTimestamp = metav1.Time{Time: time.Now().Add(-2 * time.Minute)}
+
+			mhc := maotesting.NewMachineHealthCheck("machineHealthCheck")
+			mhc.Spec.NodeStartupTimeout = &metav1.Duration{Duration: time.Second}
+			for i := range mhc.Spec.UnhealthyConditions {
+				mhc.Spec.UnhealthyConditions[i].Timeout = metav1.Duration{Duration: time.Second}
+			}
+
+			r := newFakeReconcilerWithCustomRecorder(record.NewFakeRecorder(2), append(objects, mhc)...)
+			r.minNodeStartupTimeout = tc.minNodeStartupTimeout
+
+			result, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(mhc)})
+			g.Expect(err).ToNot(HaveOccurred())
+
+			err = r.client.Get(ctx, client.ObjectKeyFromObject(machine), &machinev1.Machine{})
+			if tc.expectRemediation {
+				g.Expect(apierrors.IsNotFound(err)).To(BeTrue(), "expected the machine to be deleted, got %v", err)
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			// The machine is checked again once it is older than the minimum
+			g.Expect(result.RequeueAfter).To(BeNumerically("~", 8*time.Minute, 5*time.Second))
+		})
+	}
+}
+
+func TestNodeStartupTimeout(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		nodeStartupTimeout    *metav1.Duration
+		minNodeStartupTimeout time.Duration
+		expected              time.Duration
+	}{
+		{
+			name:     "with the default timeout",
+			expected: defaultNodeStartupTimeout,
+		},
+		{
+			name:               "with a timeout in the spec",
+			nodeStartupTimeout: &metav1.Duration{Duration: time.Minute},
+			expected:           time.Minute,
+		},
+		{
+			name:                  "with a timeout in the spec under the minimum",
+			nodeStartupTimeout:    &metav1.Duration{Duration: time.Minute},
+			minNodeStartupTimeout: 5 * time.Minute,
+			expected:              5 * time.Minute,
+		},
+		{
+			name:                  "with a timeout in the spec over the minimum",
+			nodeStartupTimeout:    &metav1.Duration{Duration: 20 * time.Minute},
+			minNodeStartupTimeout: 5 * time.Minute,
+			expected:              20 * time.Minute,
+		},
+		{
+			name:                  "with the default timeout under the minimum",
+			minNodeStartupTimeout: 30 * time.Minute,
+			expected:              30 * time.Minute,
+		},
+		{
+			name:                  "with a disabled timeout and a minimum",
+			nodeStartupTimeout:    &disabledNodeStartupTimeout,
+			minNodeStartupTimeout: 5 * time.Minute,
+			expected:              0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			mhc := maotesting.NewMachineHealthCheck("machineHealthCheck")
+			mhc.Spec.NodeStartupTimeout = tc.nodeStartupTimeout
+
+			r := &ReconcileMachineHealthCheck{minNodeStartupTimeout: tc.minNodeStartupTimeout}
+			g.Expect(r.nodeStartupTimeout(mhc)).To(Equal(tc.expected))
+		})
+	}
+}
+
 func TestReconcileExternalRemediationTemplate(t *testing.T) {
 	ctx := context.Background()
 
@@ -1405,6 +1552,93 @@ func TestGetTargetsFromMHC(t *testing.T) {
 	}
 }
 
+func TestGetTargetsFromMHCNodeSelector(t *testing.T) {
+	newNode := func(name string, labels map[string]string) *corev1.Node {
+		node := maotesting.NewNode(name, true)
+		node.Labels = labels
+		return node
+	}
+	newMachine := func(name, nodeName string, labels map[string]string) *machinev1.Machine {
+		machine := maotesting.NewMachine(name, nodeName)
+		machine.Labels = labels
+		return machine
+	}
+
+	infra := map[string]string{"node-role.kubernetes.io/infra": ""}
+	fooBar := map[string]string{"foo": "bar"}
+	objects := []runtime.Object{
+		// Both the machine and the node match
+		newMachine("infra", "infra-node", fooBar),
+		newNode("infra-node", infra),
+		// Only the machine matches
+		newMachine("worker", "worker-node", fooBar),
+		newNode("worker-node", map[string]string{"node-role.kubernetes.io/worker": ""}),
+		// Only the node matches
+		newMachine("other-infra", "other-infra-node", map[string]string{"no": "match"}),
+		newNode("other-infra-node", infra),
+		// The machine matches but has no node
+		newMachine("no-node", "", fooBar),
+		// The machine matches but its node is not found
+		newMachine("node-not-found", "missing-node", fooBar),
+	}
+
+	testCases := []struct {
+		name             string
+		nodeSelector     *string
+		expectedMachines []string
+		expectedError    bool
+	}{
+		{
+			name:             "without node selector",
+			expectedMachines: []string{"infra", "no-node", "node-not-found", "worker"},
+		},
+		{
+			name:             "with an empty node selector",
+			nodeSelector:     ptr.To(""),
+			expectedMachines: []string{"infra", "no-node", "node-not-found", "worker"},
+		},
+		{
+			name:             "with a node selector",
+			nodeSelector:     ptr.To("node-role.kubernetes.io/infra"),
+			expectedMachines: []string{"infra", "no-node", "node-not-found"},
+		},
+		{
+			name:             "with a negative node selector",
+			nodeSelector:     ptr.To("!node-role.kubernetes.io/infra"),
+			expectedMachines: []string{"no-node", "node-not-found", "worker"},
+		},
+		{
+			name:          "with an invalid node selector",
+			nodeSelector:  ptr.To("node-role.kubernetes.io/infra in"),
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			mhc := maotesting.NewMachineHealthCheck("nodeSelector")
+			if tc.nodeSelector != nil {
+				mhc.Annotations = map[string]string{NodeSelectorAnnotation: *tc.nodeSelector}
+			}
+
+			targets, err := newFakeReconciler(append(objects, mhc)...).getTargetsFromMHC(*mhc)
+			if tc.expectedError {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+
+			var machines []string
+			for _, target := range targets {
+				machines = append(machines, target.Machine.Name)
+			}
+			g.Expect(machines).To(ConsistOf(tc.expectedMachines))
+		})
+	}
+}
+
 func TestGetNodeFromMachine(t *testing.T) {
 	testCases := []struct {
 		testCase      string
@@ -2182,6 +2416,104 @@ func TestRemediate(t *testing.T) {
 	}
 }
 
+func TestRemediationCorrelationID(t *testing.T) {
+	g := NewWithT(t)
+	correlationIDPattern := regexp.MustCompile(`correlationID=([0-9a-f]{8})`)
+
+	unhealthySince := metav1.NewTime(time.Now().Add(-2 * time.Minute))
+	newTarget := func(name string, uid types.UID, timeout time.Duration) target {
+		return target{
+			Machine: machinev1.Machine{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Machine",
+					APIVersion: "machine.openshift.io/v1beta1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+					UID:       uid,
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							Kind:       "MachineSet",
+							Controller: ptr.To[bool](true),
+						},
+					},
+				},
+			},
+			Node: &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: name,
+					UID:  "node-" + uid,
+				},
+				Status: corev1.NodeStatus{
+					Conditions: []corev1.NodeCondition{
+						{
+							Type:               corev1.NodeReady,
+							Status:             corev1.ConditionUnknown,
+							LastTransitionTime: unhealthySince,
+						},
+					},
+				},
+			},
+			MHC: machinev1.MachineHealthCheck{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "mhc",
+					Namespace: namespace,
+				},
+				Spec: machinev1.MachineHealthCheckSpec{
+					UnhealthyConditions: []machinev1.UnhealthyCondition{
+						{
+							Type:    corev1.NodeReady,
+							Status:  corev1.ConditionUnknown,
+							Timeout: metav1.Duration{Duration: timeout},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	// remediationCorrelationIDs returns the correlation IDs of the detection and deletion events of a machine
+	remediationCorrelationIDs := func(name string, uid types.UID) (string, string) {
+		detected := newTarget(name, uid, 5*time.Minute)
+		recorder := record.NewFakeRecorder(2)
+		r := newFakeReconcilerWithCustomRecorder(recorder, &detected.Machine)
+
+		// The node has not been unhealthy for long enough yet, only the detection is reported
+		_, needRemediation, _, errList := r.healthCheckTargets([]target{detected}, defaultNodeStartupTimeout)
+		g.Expect(errList).To(BeEmpty())
+		g.Expect(needRemediation).To(BeEmpty())
+		g.Expect(recorder.Events).To(HaveLen(1))
+		detectedEvent := <-recorder.Events
+		g.Expect(detectedEvent).To(ContainSubstring(EventDetectedUnhealthy))
+
+		// Once the timeout has passed, the machine is remediated
+		expired := newTarget(name, uid, time.Minute)
+		_, needRemediation, _, errList = r.healthCheckTargets([]target{expired}, defaultNodeStartupTimeout)
+		g.Expect(errList).To(BeEmpty())
+		g.Expect(needRemediation).To(HaveLen(1))
+		g.Expect(r.internalRemediation(needRemediation[0])).To(Succeed())
+		g.Expect(recorder.Events).To(HaveLen(1))
+		deletedEvent := <-recorder.Events
+		g.Expect(deletedEvent).To(ContainSubstring(EventMachineDeleted))
+
+		detectedID := correlationIDPattern.FindStringSubmatch(detectedEvent)
+		g.Expect(detectedID).To(HaveLen(2), "detection event should include a correlation ID: %s", detectedEvent)
+		deletedID := correlationIDPattern.FindStringSubmatch(deletedEvent)
+		g.Expect(deletedID).To(HaveLen(2), "deletion event should include a correlation ID: %s", deletedEvent)
+
+		return detectedID[1], deletedID[1]
+	}
+
+	detectedA, deletedA := remediationCorrelationIDs("machine-a", "uid-a")
+	g.Expect(deletedA).To(Equal(detectedA), "the same remediation should reuse its correlation ID")
+
+	detectedB, deletedB := remediationCorrelationIDs("machine-b", "uid-b")
+	g.Expect(deletedB).To(Equal(detectedB), "the same remediation should reuse its correlation ID")
+
+	g.Expect(detectedB).ToNot(Equal(detectedA), "different machines should have different correlation IDs")
+}
+
 func TestReconcileStatus(t *testing.T) {
 	testCases := []struct {
 		testCase            string
@@ -3142,7 +3474,15 @@ func assertExternalRemediation(t *testing.T, tc testCase, ctx context.Context, r
 	nodeReadyStatus := tc.node.Status.Conditions[0].Status
 	if tc.externalRemediationMachine == nil {
 		//Trying to get External Machine Remediation
-		verifyErm(t, tc, ctx, r.client, true)
+		erm := verifyErm(t, tc, ctx, r.client, true)
+		//The new Remediation Request should be owned by the Machine
+		g := NewWithT(t)
+		g.Expect(erm.GetOwnerReferences()).To(ConsistOf(metav1.OwnerReference{
+			APIVersion: machinev1.SchemeGroupVersion.String(),
+			Kind:       "Machine",
+			Name:       tc.machine.Name,
+			UID:        tc.machine.UID,
+		}))
 	} else if nodeReadyStatus == corev1.ConditionTrue { //When remediationTemplate is set and node transitions back to healthy, new Remediation Request should be deleted
 		//Trying to get External Machine Remediation
 		verifyErm(t, tc, ctx, r.client, false)
@@ -3150,6 +3490,12 @@ func assertExternalRemediation(t *testing.T, tc testCase, ctx context.Context, r
 		//Trying to get External Machine Remediation
 		verifyErm(t, tc, ctx, r.client, true)
 	}
+
+	//The Machine should never be deleted when remediationTemplate is set
+	g := NewWithT(t)
+	machine := &machinev1.Machine{}
+	g.Expect(r.client.Get(ctx, namespacedName(tc.machine), machine)).To(Succeed())
+	g.Expect(machine.DeletionTimestamp).To(BeNil())
 }
 
 func newMachineHealthCheckWithRemediationTemplate(infraRemediationTmpl *unstructured.Unstructured) *machinev1.MachineHealthCheck {
@@ -3182,7 +3528,7 @@ func buildRunTimeObjects(tc testCase) []runtime.Object {
 	return objects
 }
 
-func verifyErm(t *testing.T, tc testCase, ctx context.Context, client client.Client, isExist bool) {
+func verifyErm(t *testing.T, tc testCase, ctx context.Context, client client.Client, isExist bool) *unstructured.Unstructured {
 	g := NewWithT(t)
 	erm := new(unstructured.Unstructured)
 	erm.SetAPIVersion(tc.externalRemediationTemplate.GetAPIVersion())
@@ -3198,4 +3544,5 @@ func verifyErm(t *testing.T, tc testCase, ctx context.Context, client client.Cli
 	} else {
 		g.Expect(client.Get(ctx, nameSpace, erm)).NotTo(Succeed())
 	}
+	return erm
 }
@@ -0,0 +1,45 @@
+package machinehealthcheck
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// errRemediationRateLimited is returned when a remediation is blocked by the cluster wide remediation limit.
+// The request is then requeued with backoff by the controller.
+var errRemediationRateLimited = errors.New("remediation rate limit reached")
+
+// remediationLimiter caps the number of remediations started across all MachineHealthChecks within a window,
+// so that a cluster wide outage does not lead to all Machines being remediated at once.
+// A nil remediationLimiter allows all remediations.
+type remediationLimiter struct {
+	limiter *rate.Limiter
+	now     func() time.Time
+}
+
+// newRemediationLimiter returns a remediationLimiter allowing up to maxRemediations remediations per window,
+// refilled evenly over the window. It returns nil when maxRemediations is not positive.
+func newRemediationLimiter(maxRemediations int, window time.Duration) (*remediationLimiter, error) {
+	if maxRemediations <= 0 {
+		return nil, nil
+	}
+	if window <= 0 {
+		return nil, fmt.Errorf("remediation window must be positive when the remediation limit is set, got %v", window)
+	}
+
+	return &remediationLimiter{
+		limiter: rate.NewLimiter(rate.Every(window/time.Duration(maxRemediations)), maxRemediations),
+		now:     time.Now,
+	}, nil
+}
+
+// allow reports whether a remediation may be started now, consuming a token when it does.
+func (l *remediationLimiter) allow() bool {
+	if l == nil {
+		return true
+	}
+	return l.limiter.AllowN(l.now(), 1)
+}
@@ -0,0 +1,176 @@
+package machinehealthcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	maotesting "github.com/openshift/machine-api-operator/pkg/util/testing"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+)
+
+func TestNewRemediationLimiter(t *testing.T) {
+	testCases := []struct {
+		name            string
+		maxRemediations int
+		window          time.Duration
+		expectLimiter   bool
+		expectedError   string
+	}{
+		{
+			name:            "with the limit disabled",
+			maxRemediations: 0,
+			window:          time.Minute,
+		},
+		{
+			name:            "with the limit disabled and no window",
+			maxRemediations: 0,
+		},
+		{
+			name:            "with a limit",
+			maxRemediations: 3,
+			window:          time.Minute,
+			expectLimiter:   true,
+		},
+		{
+			name:            "with a limit and no window",
+			maxRemediations: 3,
+			expectedError:   "remediation window must be positive when the remediation limit is set, got 0s",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			limiter, err := newRemediationLimiter(tc.maxRemediations, tc.window)
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(tc.expectedError))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(limiter != nil).To(Equal(tc.expectLimiter))
+			g.Expect(limiter.allow()).To(BeTrue())
+		})
+	}
+}
+
+func TestRemediateRateLimited(t *testing.T) {
+	const (
+		unhealthyMachines = 10
+		maxRemediations   = 3
+		window            = 10 * time.Minute
+	)
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		remediated  func(g *WithT, r *ReconcileMachineHealthCheck, m *machinev1.Machine) bool
+	}{
+		{
+			name: "with machine deletion",
+			remediated: func(g *WithT, r *ReconcileMachineHealthCheck, m *machinev1.Machine) bool {
+				return r.client.Get(context.TODO(), namespacedName(m), &machinev1.Machine{}) != nil
+			},
+		},
+		{
+			name:        "with the external baremetal strategy",
+			annotations: map[string]string{remediationStrategyAnnotation: string(remediationStrategyExternal)},
+			remediated: func(g *WithT, r *ReconcileMachineHealthCheck, m *machinev1.Machine) bool {
+				got := &machinev1.Machine{}
+				g.Expect(r.client.Get(context.TODO(), namespacedName(m), got)).To(Succeed())
+				return externalRemediationAnnotationExists(got)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			mhc := maotesting.NewMachineHealthCheck("test")
+			mhc.Annotations = tc.annotations
+
+			var machines []*machinev1.Machine
+			var objects []runtime.Object
+			var targets []target
+			for i := 0; i < unhealthyMachines; i++ {
+				m := &machinev1.Machine{
+					TypeMeta: metav1.TypeMeta{
+						Kind:       "Machine",
+						APIVersion: "machine.openshift.io/v1beta1",
+					},
+					ObjectMeta: metav1.ObjectMeta{
+						Name: fmt.Sprintf("machine-%d", i),
+						// the fake client sets this resource version on the objects it is created with
+						ResourceVersion: "999",
+						Namespace:       namespace,
+						OwnerReferences: []metav1.OwnerReference{
+							{
+								Kind:       "MachineSet",
+								Controller: ptr.To[bool](true),
+							},
+						},
+					},
+				}
+				machines = append(machines, m)
+				objects = append(objects, m)
+				targets = append(targets, target{Machine: *m, MHC: *mhc})
+			}
+
+			recorder := record.NewFakeRecorder(2 * unhealthyMachines)
+			r := newFakeReconcilerWithCustomRecorder(recorder, objects...)
+
+			now := time.Now()
+			limiter, err := newRemediationLimiter(maxRemediations, window)
+			g.Expect(err).ToNot(HaveOccurred())
+			limiter.now = func() time.Time { return now }
+			r.remediationLimiter = limiter
+
+			countRemediated := func() int {
+				count := 0
+				for _, m := range machines {
+					if tc.remediated(g, r, m) {
+						count++
+					}
+				}
+				return count
+			}
+
+			assertRemediate := func(targets []target, expectedRemediated, expectedLimited int) {
+				errList := r.remediate(context.TODO(), targets, mhc)
+				g.Expect(errList).To(HaveLen(expectedLimited))
+				for _, err := range errList {
+					g.Expect(errors.Is(err, errRemediationRateLimited)).To(BeTrue())
+				}
+				g.Expect(countRemediated()).To(Equal(expectedRemediated))
+			}
+
+			assertRemediate(targets, maxRemediations, unhealthyMachines-maxRemediations)
+			limitedEvents := 0
+			for len(recorder.Events) > 0 {
+				event := <-recorder.Events
+				if strings.HasPrefix(event, fmt.Sprintf("%s %s ", corev1.EventTypeWarning, EventRemediationRateLimited)) {
+					limitedEvents++
+				}
+			}
+			g.Expect(limitedEvents).To(Equal(unhealthyMachines - maxRemediations))
+
+			// Retrying within the window does not start any new remediation
+			assertRemediate(targets[maxRemediations:], maxRemediations, unhealthyMachines-maxRemediations)
+
+			// Once the window has passed, the limit allows a new batch of remediations
+			now = now.Add(window)
+			assertRemediate(targets[maxRemediations:], 2*maxRemediations, unhealthyMachines-2*maxRemediations)
+		})
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"sort"
 	"strings"
 	"sync"
@@ -27,9 +28,11 @@ import (
 
 	openshiftfeatures "github.com/openshift/api/features"
 	machinev1 "github.com/openshift/api/machine/v1beta1"
+	mapicontroller "github.com/openshift/machine-api-operator/pkg/controller"
 	"github.com/openshift/machine-api-operator/pkg/controller/machine"
 	"github.com/openshift/machine-api-operator/pkg/util"
 	"github.com/openshift/machine-api-operator/pkg/util/conditions"
+	msutil "github.com/openshift/machine-api-operator/pkg/util/machineset"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -60,13 +63,52 @@ var (
 
 	// controllerName is the name of this controller
 	controllerName = "machineset_controller"
+
+	// syncedTemplateAnnotations are the annotations of the Machine template which are propagated to the existing
+	// Machines of the MachineSet when they change. Only annotations which are purely informational, and so
+	// do not affect the instance or how the Machine is managed, may be added here.
+	syncedTemplateAnnotations = []string{
+		"openshift.io/description",
+		"openshift.io/display-name",
+	}
 )
 
+// Options are the options of the MachineSet Controller.
+type Options struct {
+	// CreateConcurrency is the number of Machines of a MachineSet created at a time, unless overridden by the
+	// CreateConcurrencyAnnotation of the MachineSet. 0 means unlimited.
+	CreateConcurrency int
+	// ClusterPauseNamespace is the namespace of the machine-api-operator, in which it creates the
+	// ClusterPauseConfigMapName ConfigMap to pause the controller.
+	ClusterPauseNamespace string
+}
+
 // Add creates a new MachineSet Controller and adds it to the Manager with default RBAC.
 // The Manager will set fields on the Controller and Start it when the Manager is Started.
 func Add(mgr manager.Manager, opts manager.Options, gate featuregate.MutableFeatureGate) error {
-	r := newReconciler(mgr, gate)
-	return addWithOpts(mgr, controller.Options{Reconciler: r}, r.MachineToMachineSets)
+	return AddWithCreateConcurrency(0)(mgr, opts, gate)
+}
+
+// AddWithCreateConcurrency returns a function which creates a new MachineSet Controller and adds it to the Manager.
+// The controller creates at most defaultCreateConcurrency Machines of a MachineSet at a time, unless overridden
+// by the CreateConcurrencyAnnotation of the MachineSet. 0 means unlimited.
+func AddWithCreateConcurrency(defaultCreateConcurrency int) func(manager.Manager, manager.Options, featuregate.MutableFeatureGate) error {
+	return AddWithOptions(Options{
+		CreateConcurrency:     defaultCreateConcurrency,
+		ClusterPauseNamespace: mapicontroller.DefaultClusterPauseNamespace,
+	})
+}
+
+// AddWithOptions returns a function which creates a new MachineSet Controller with the given options,
+// and adds it to the Manager.
+func AddWithOptions(machineSetOpts Options) func(manager.Manager, manager.Options, featuregate.MutableFeatureGate) error {
+	return func(mgr manager.Manager, opts manager.Options, gate featuregate.MutableFeatureGate) error {
+		r := newReconciler(mgr, gate)
+		r.defaultCreateConcurrency = machineSetOpts.CreateConcurrency
+		gated := mapicontroller.WithClusterPause(controllerName, mgr.GetClient(), machineSetOpts.ClusterPauseNamespace, r,
+			func() client.Object { return &machinev1.MachineSet{} })
+		return addWithOpts(mgr, controller.Options{Reconciler: gated}, machineSetOpts.ClusterPauseNamespace, r.MachineToMachineSets)
+	}
 }
 
 // newReconciler returns a new reconcile.Reconciler.
@@ -79,7 +121,7 @@ func newReconciler(mgr manager.Manager, gate featuregate.MutableFeatureGate) *Re
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler.
-func addWithOpts(mgr manager.Manager, opts controller.Options, mapFn handler.TypedMapFunc[*machinev1.Machine, reconcile.Request]) error {
+func addWithOpts(mgr manager.Manager, opts controller.Options, clusterPauseNamespace string, mapFn handler.TypedMapFunc[*machinev1.Machine, reconcile.Request]) error {
 	// Create a new controller.
 	c, err := controller.New(controllerName, mgr, opts)
 	if err != nil {
@@ -105,10 +147,16 @@ func addWithOpts(mgr manager.Manager, opts controller.Options, mapFn handler.Typ
 	}
 
 	// Map Machine changes to MachineSets by machining labels.
-	return c.Watch(
+	err = c.Watch(
 		source.Kind(mgr.GetCache(), &machinev1.Machine{},
 			handler.TypedEnqueueRequestsFromMapFunc[*machinev1.Machine](mapFn),
 		))
+	if err != nil {
+		return err
+	}
+
+	// Reconcile all the MachineSets again when the cluster pause ends.
+	return c.Watch(mapicontroller.ClusterPauseSource(mgr, clusterPauseNamespace, func() client.ObjectList { return &machinev1.MachineSetList{} }))
 }
 
 // ReconcileMachineSet reconciles a MachineSet object
@@ -117,6 +165,13 @@ type ReconcileMachineSet struct {
 	scheme   *runtime.Scheme
 	recorder record.EventRecorder
 	gate     featuregate.MutableFeatureGate
+
+	// defaultCreateConcurrency is the maximum number of Machines of a MachineSet created at a time
+	// when the MachineSet has no CreateConcurrencyAnnotation, 0 meaning unlimited.
+	defaultCreateConcurrency int
+
+	// scaleEvents records the ScaledUp and ScaledDown events of the MachineSets.
+	scaleEvents scaleEvents
 }
 
 func (r *ReconcileMachineSet) MachineToMachineSets(ctx context.Context, o *machinev1.Machine) []reconcile.Request {
@@ -161,6 +216,9 @@ func (r *ReconcileMachineSet) Reconcile(ctx context.Context, request reconcile.R
 		if apierrors.IsNotFound(err) {
 			// Object not found, return.  Created objects are automatically garbage collected.
 			// For additional cleanup logic use finalizers.
+			deleteDriftMetric(request.Name, request.Namespace)
+			deleteCreatingMachinesMetric(request.Name, request.Namespace)
+			r.scaleEvents.forget(request.NamespacedName)
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
@@ -235,6 +293,11 @@ func (r *ReconcileMachineSet) reconcile(ctx context.Context, machineSet *machine
 		return reconcile.Result{}, err
 	}
 
+	// The annotations only help the autoscaler scale from zero, failing to set them must not block scaling.
+	if err := r.setScaleFromZeroAnnotations(ctx, machineSet); err != nil {
+		klog.Errorf("%v: failed to set scale from zero annotations: %v", machineSet.Name, err)
+	}
+
 	allMachines := &machinev1.MachineList{}
 
 	if err := r.Client.List(context.Background(), allMachines, client.InNamespace(machineSet.Namespace)); err != nil {
@@ -279,10 +342,26 @@ func (r *ReconcileMachineSet) reconcile(ctx context.Context, machineSet *machine
 		filteredMachines = append(filteredMachines, machineSetMachines[machineName])
 	}
 
-	syncErr := r.syncReplicas(machineSet, filteredMachines)
+	// Failing to sync the annotations of a Machine must not block scaling, the error is returned once the
+	// replicas and the status are reconciled.
+	annotationsErr := r.syncTemplateAnnotations(ctx, machineSet, filteredMachines)
+
+	r.reportProviderSpecDrift(ctx, machineSet, filteredMachines)
+
+	replicaMachines, err := r.reconcileSurge(ctx, machineSet, filteredMachines)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to reconcile surge machines: %w", err)
+	}
+
+	syncErr := r.syncReplicas(machineSet, replicaMachines)
 
 	ms := machineSet.DeepCopy()
 	newStatus := r.calculateStatus(ms, filteredMachines)
+	// The generation is only observed once its spec has been reconciled, so that clients
+	// waiting for the observedGeneration know the MachineSet has acted on their change.
+	if syncErr == nil && annotationsErr == nil {
+		newStatus.ObservedGeneration = machineSet.Generation
+	}
 
 	// Always updates status as machines come up or die.
 	updatedMS, err := updateMachineSetStatus(r.Client, machineSet, newStatus)
@@ -297,6 +376,10 @@ func (r *ReconcileMachineSet) reconcile(ctx context.Context, machineSet *machine
 		return reconcile.Result{}, fmt.Errorf("failed to sync machines: %w", syncErr)
 	}
 
+	if annotationsErr != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to sync template annotations: %w", annotationsErr)
+	}
+
 	var replicas int32
 	if updatedMS.Spec.Replicas != nil {
 		replicas = *updatedMS.Spec.Replicas
@@ -319,6 +402,36 @@ func (r *ReconcileMachineSet) reconcile(ctx context.Context, machineSet *machine
 	return reconcile.Result{}, nil
 }
 
+// setScaleFromZeroAnnotations sets the capacity annotations needed by the autoscaler to scale
+// the MachineSet up from zero, when the instance type referenced by the providerSpec is known.
+// Annotations already present on the MachineSet are never overwritten, so that values set by users or by the
+// provider MachineSet controllers are left untouched. The MachineSet is only patched when an annotation is added.
+func (r *ReconcileMachineSet) setScaleFromZeroAnnotations(ctx context.Context, machineSet *machinev1.MachineSet) error {
+	if !msutil.CanScaleFromZero(machineSet.Spec.Replicas, machineSet.Annotations) {
+		return nil
+	}
+
+	instanceType, err := msutil.InstanceTypeFromProviderSpec(machineSet.Spec.Template.Spec.ProviderSpec.Value)
+	if err != nil {
+		return err
+	}
+
+	capacity, ok := msutil.GetInstanceCapacity(instanceType)
+	if !ok {
+		klog.V(4).Infof("%v: unknown instance type %q, skipping scale from zero annotations", machineSet.Name, instanceType)
+		return nil
+	}
+
+	annotations, changed := msutil.SetScaleFromZeroAnnotations(maps.Clone(machineSet.Annotations), capacity)
+	if !changed {
+		return nil
+	}
+
+	patchBase := client.MergeFrom(machineSet.DeepCopy())
+	machineSet.Annotations = annotations
+	return r.Client.Patch(ctx, machineSet, patchBase)
+}
+
 // syncReplicas essentially scales machine resources up and down.
 func (r *ReconcileMachineSet) syncReplicas(ms *machinev1.MachineSet, machines []*machinev1.Machine) error {
 	if ms.Spec.Replicas == nil {
@@ -327,16 +440,32 @@ func (r *ReconcileMachineSet) syncReplicas(ms *machinev1.MachineSet, machines []
 
 	diff := len(machines) - int(*(ms.Spec.Replicas))
 
+	creating := countCreatingMachines(machines)
+	defer func() { setCreatingMachinesMetric(ms, creating) }()
+
 	if diff < 0 {
 		diff *= -1
+
+		// The MachineSet is reconciled again as the Machines being created get provisioned,
+		// at which point the next batch of Machines is created.
+		concurrency := createConcurrency(ms, r.defaultCreateConcurrency)
+		toCreate := machinesToCreate(diff, creating, concurrency)
+		if toCreate < diff {
+			klog.Infof("%v: %d machines are being created with a creation concurrency of %d, creating %d of the %d missing machines",
+				ms.Name, creating, concurrency, toCreate, diff)
+		}
+		if toCreate == 0 {
+			return nil
+		}
+
 		klog.Infof("Too few replicas for %v %s/%s, need %d, creating %d",
-			controllerKind, ms.Namespace, ms.Name, *(ms.Spec.Replicas), diff)
+			controllerKind, ms.Namespace, ms.Name, *(ms.Spec.Replicas), toCreate)
 
 		var machineList []*machinev1.Machine
 		var errstrings []string
-		for i := 0; i < diff; i++ {
+		for i := 0; i < toCreate; i++ {
 			klog.Infof("Creating machine %d of %d, ( spec.replicas(%d) > currentMachineCount(%d) )",
-				i+1, diff, *(ms.Spec.Replicas), len(machines))
+				i+1, toCreate, *(ms.Spec.Replicas), len(machines))
 
 			machine := r.createMachine(ms)
 			if err := r.Client.Create(context.Background(), machine); err != nil {
@@ -345,8 +474,10 @@ func (r *ReconcileMachineSet) syncReplicas(ms *machinev1.MachineSet, machines []
 				continue
 			}
 
+			creating++
 			machineList = append(machineList, machine)
 		}
+		r.scaleEvents.record(r.recorder, ms, ScaledUpReason, machineList)
 
 		if len(errstrings) > 0 {
 			return errors.New(strings.Join(errstrings, "; "))
@@ -362,25 +493,51 @@ func (r *ReconcileMachineSet) syncReplicas(ms *machinev1.MachineSet, machines []
 			return err
 		}
 		klog.Infof("Found %s delete policy", ms.Spec.DeletePolicy)
+
+		// Protected Machines are never chosen, as their deletion would be denied on every reconcile
+		deletable, protected := splitDeletionProtectedMachines(machines)
+		if diff > len(deletable) {
+			names := make([]string, 0, len(protected))
+			for _, m := range protected {
+				names = append(names, m.Name)
+			}
+			klog.Warningf("%v: cannot scale down to %d replicas, machines %s are protected from deletion by the %s annotation",
+				ms.Name, *(ms.Spec.Replicas), strings.Join(names, ", "), machine.MachineProtectedAnnotation)
+			r.recorder.Eventf(ms, corev1.EventTypeWarning, ProtectedMachinesSkippedReason,
+				"Cannot scale down to %d replicas, machines %s are protected from deletion by the %s annotation",
+				*(ms.Spec.Replicas), strings.Join(names, ", "), machine.MachineProtectedAnnotation)
+		}
+
 		// Choose which Machines to delete.
-		machinesToDelete := getMachinesToDeletePrioritized(machines, diff, deletePriorityFunc)
+		machinesToDelete := getMachinesToDeletePrioritized(deletable, diff, deletePriorityFunc)
 
 		// TODO: Add cap to limit concurrent delete calls.
-		errCh := make(chan error, diff)
+		errCh := make(chan error, len(machinesToDelete))
+		deleted := make([]bool, len(machinesToDelete))
 		var wg sync.WaitGroup
-		wg.Add(diff)
-		for _, machine := range machinesToDelete {
-			go func(targetMachine *machinev1.Machine) {
+		wg.Add(len(machinesToDelete))
+		for i, machine := range machinesToDelete {
+			go func(i int, targetMachine *machinev1.Machine) {
 				defer wg.Done()
 				err := r.Client.Delete(context.Background(), targetMachine)
 				if err != nil {
 					klog.Errorf("Unable to delete Machine %s: %v", targetMachine.Name, err)
 					errCh <- err
+					return
 				}
-			}(machine)
+				deleted[i] = true
+			}(i, machine)
 		}
 		wg.Wait()
 
+		var deletedMachines []*machinev1.Machine
+		for i, machine := range machinesToDelete {
+			if deleted[i] {
+				deletedMachines = append(deletedMachines, machine)
+			}
+		}
+		r.scaleEvents.record(r.recorder, ms, ScaledDownReason, deletedMachines)
+
 		select {
 		case err := <-errCh:
 			// all errors have been reported before and they're likely to be the same, so we'll only return the first one we hit.
@@ -18,6 +18,7 @@ package machineset
 
 import (
 	"context"
+	"errors"
 	"reflect"
 	"testing"
 	"time"
@@ -25,13 +26,19 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	machinev1 "github.com/openshift/api/machine/v1beta1"
+	mapicontroller "github.com/openshift/machine-api-operator/pkg/controller"
+	msutil "github.com/openshift/machine-api-operator/pkg/util/machineset"
 	testutils "github.com/openshift/machine-api-operator/pkg/util/testing"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -283,6 +290,194 @@ func TestAdoptOrphan(t *testing.T) {
 	}
 }
 
+func TestSetScaleFromZeroAnnotations(t *testing.T) {
+	testCases := []struct {
+		name                string
+		replicas            *int32
+		providerSpec        string
+		existingAnnotations map[string]string
+		expectedAnnotations map[string]string
+		expectPatch         bool
+	}{
+		{
+			name:         "with zero replicas and a known instance type",
+			replicas:     ptr.To[int32](0),
+			providerSpec: `{"instanceType":"g4dn.xlarge"}`,
+			expectedAnnotations: map[string]string{
+				msutil.CpuKeyDeprecated:      "4",
+				msutil.MemoryKeyDeprecated:   "16384",
+				msutil.GpuCountKeyDeprecated: "1",
+			},
+			expectPatch: true,
+		},
+		{
+			name:                "with zero replicas and an unknown instance type",
+			replicas:            ptr.To[int32](0),
+			providerSpec:        `{"vmSize":"Standard_Unknown"}`,
+			existingAnnotations: map[string]string{"foo": "bar"},
+			expectedAnnotations: map[string]string{"foo": "bar"},
+		},
+		{
+			name:         "with zero replicas and a user provided annotation",
+			replicas:     ptr.To[int32](0),
+			providerSpec: `{"machineType":"n2-standard-4"}`,
+			existingAnnotations: map[string]string{
+				msutil.MemoryKeyDeprecated: "1024",
+				"foo":                      "bar",
+			},
+			expectedAnnotations: map[string]string{
+				msutil.CpuKeyDeprecated:      "4",
+				msutil.MemoryKeyDeprecated:   "1024",
+				msutil.GpuCountKeyDeprecated: "0",
+				"foo":                        "bar",
+			},
+			expectPatch: true,
+		},
+		{
+			name:         "with zero replicas and annotations which differ from the instance type",
+			replicas:     ptr.To[int32](0),
+			providerSpec: `{"instanceType":"g4dn.xlarge"}`,
+			existingAnnotations: map[string]string{
+				msutil.CpuKeyDeprecated:      "2",
+				msutil.MemoryKeyDeprecated:   "8192",
+				msutil.GpuCountKeyDeprecated: "0",
+			},
+			expectedAnnotations: map[string]string{
+				msutil.CpuKeyDeprecated:      "2",
+				msutil.MemoryKeyDeprecated:   "8192",
+				msutil.GpuCountKeyDeprecated: "0",
+			},
+		},
+		{
+			name:         "with zero replicas and all annotations present",
+			replicas:     ptr.To[int32](0),
+			providerSpec: `{"instanceType":"m5.large"}`,
+			existingAnnotations: map[string]string{
+				msutil.CpuKeyDeprecated:      "2",
+				msutil.MemoryKeyDeprecated:   "8192",
+				msutil.GpuCountKeyDeprecated: "0",
+			},
+			expectedAnnotations: map[string]string{
+				msutil.CpuKeyDeprecated:      "2",
+				msutil.MemoryKeyDeprecated:   "8192",
+				msutil.GpuCountKeyDeprecated: "0",
+			},
+		},
+		{
+			name:         "with replicas which cannot reach zero",
+			replicas:     ptr.To[int32](2),
+			providerSpec: `{"instanceType":"m5.large"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			ms := &machinev1.MachineSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "machineset1",
+					Namespace:   "default",
+					Annotations: tc.existingAnnotations,
+				},
+				Spec: machinev1.MachineSetSpec{
+					Replicas: tc.replicas,
+					Template: machinev1.MachineTemplateSpec{
+						Spec: machinev1.MachineSpec{
+							ProviderSpec: machinev1.ProviderSpec{
+								Value: &runtime.RawExtension{Raw: []byte(tc.providerSpec)},
+							},
+						},
+					},
+				},
+			}
+
+			patches := 0
+			r := &ReconcileMachineSet{
+				Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(ms).
+					WithInterceptorFuncs(interceptor.Funcs{
+						Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+							patches++
+							return c.Patch(ctx, obj, patch, opts...)
+						},
+					}).Build(),
+				scheme: scheme.Scheme,
+			}
+			g.Expect(r.setScaleFromZeroAnnotations(ctx, ms)).To(Succeed())
+			// The annotations are set, the next reconciles don't patch the MachineSet
+			g.Expect(r.setScaleFromZeroAnnotations(ctx, ms)).To(Succeed())
+			if tc.expectPatch {
+				g.Expect(patches).To(Equal(1))
+			} else {
+				g.Expect(patches).To(BeZero())
+			}
+
+			got := &machinev1.MachineSet{}
+			g.Expect(r.Client.Get(ctx, client.ObjectKeyFromObject(ms), got)).To(Succeed())
+			g.Expect(got.Annotations).To(Equal(tc.expectedAnnotations))
+		})
+	}
+}
+
+func TestReconcileScaleFromZeroAnnotationsFailure(t *testing.T) {
+	g := NewWithT(t)
+
+	gate, err := testutils.NewDefaultMutableFeatureGate()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	machineSet := &machinev1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "machineset",
+			Namespace:   "default",
+			UID:         "uid",
+			Annotations: map[string]string{msutil.AutoscalerMinSizeAnnotation: "0"},
+		},
+		Spec: machinev1.MachineSetSpec{
+			Replicas: ptr.To[int32](2),
+			Selector: metav1.LabelSelector{
+				MatchLabels: map[string]string{"foo": "bar"},
+			},
+			Template: machinev1.MachineTemplateSpec{
+				ObjectMeta: machinev1.ObjectMeta{
+					Labels: map[string]string{"foo": "bar"},
+				},
+				Spec: machinev1.MachineSpec{
+					ProviderSpec: machinev1.ProviderSpec{
+						Value: &runtime.RawExtension{Raw: []byte(`{"instanceType":"m5.large"}`)},
+					},
+				},
+			},
+		},
+	}
+
+	r := &ReconcileMachineSet{
+		Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(machineSet).WithStatusSubresource(&machinev1.MachineSet{}).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+					if _, ok := obj.(*machinev1.MachineSet); ok {
+						return errors.New("patch failed")
+					}
+					return c.Patch(ctx, obj, patch, opts...)
+				},
+			}).Build(),
+		scheme:   scheme.Scheme,
+		recorder: record.NewFakeRecorder(10),
+		gate:     gate,
+	}
+
+	_, err = r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(machineSet)})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// The MachineSet is scaled up although its scale from zero annotations could not be set
+	machines := &machinev1.MachineList{}
+	g.Expect(r.Client.List(ctx, machines, client.InNamespace("default"))).To(Succeed())
+	g.Expect(machines.Items).To(HaveLen(2))
+
+	got := &machinev1.MachineSet{}
+	g.Expect(r.Client.Get(ctx, client.ObjectKeyFromObject(machineSet), got)).To(Succeed())
+	g.Expect(got.Annotations).ToNot(HaveKey(msutil.CpuKeyDeprecated))
+}
+
 var _ = Describe("MachineSet Reconcile", func() {
 	var r *ReconcileMachineSet
 	var result reconcile.Result
@@ -373,3 +568,159 @@ var _ = Describe("MachineSet Reconcile", func() {
 		})
 	})
 })
+
+func TestReconcileClusterPaused(t *testing.T) {
+	testCases := []struct {
+		name             string
+		paused           bool
+		expectedResult   reconcile.Result
+		expectedMachines int
+	}{
+		{
+			name:             "with the cluster paused",
+			paused:           true,
+			expectedResult:   reconcile.Result{},
+			expectedMachines: 0,
+		},
+		{
+			name:             "with the cluster not paused",
+			paused:           false,
+			expectedMachines: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			gate, err := testutils.NewDefaultMutableFeatureGate()
+			g.Expect(err).ToNot(HaveOccurred())
+
+			machineSet := &machinev1.MachineSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "machineset",
+					Namespace: "default",
+				},
+				Spec: machinev1.MachineSetSpec{
+					Replicas: ptr.To[int32](2),
+					Selector: metav1.LabelSelector{
+						MatchLabels: map[string]string{"foo": "bar"},
+					},
+					Template: machinev1.MachineTemplateSpec{
+						ObjectMeta: machinev1.ObjectMeta{
+							Labels: map[string]string{"foo": "bar"},
+						},
+					},
+				},
+			}
+
+			objects := []runtime.Object{machineSet}
+			if tc.paused {
+				objects = append(objects, &corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      mapicontroller.ClusterPauseConfigMapName,
+						Namespace: mapicontroller.DefaultClusterPauseNamespace,
+					},
+				})
+			}
+
+			r := &ReconcileMachineSet{
+				Client:   fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(objects...).WithStatusSubresource(&machinev1.MachineSet{}).Build(),
+				scheme:   scheme.Scheme,
+				recorder: record.NewFakeRecorder(10),
+				gate:     gate,
+			}
+
+			gated := mapicontroller.WithClusterPause(controllerName, r.Client, mapicontroller.DefaultClusterPauseNamespace, r,
+				func() client.Object { return &machinev1.MachineSet{} })
+			result, err := gated.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(machineSet)})
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(result).To(Equal(tc.expectedResult))
+
+			machines := &machinev1.MachineList{}
+			g.Expect(r.Client.List(context.Background(), machines, client.InNamespace("default"))).To(Succeed())
+			g.Expect(machines.Items).To(HaveLen(tc.expectedMachines))
+		})
+	}
+}
+
+func TestReconcileObservedGeneration(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	gate, err := testutils.NewDefaultMutableFeatureGate()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	machineSet := &machinev1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "machineset",
+			Namespace:  "default",
+			Generation: 1,
+		},
+		Spec: machinev1.MachineSetSpec{
+			Replicas: ptr.To[int32](1),
+			Selector: metav1.LabelSelector{
+				MatchLabels: map[string]string{"foo": "bar"},
+			},
+			Template: machinev1.MachineTemplateSpec{
+				ObjectMeta: machinev1.ObjectMeta{
+					Labels: map[string]string{"foo": "bar"},
+				},
+			},
+		},
+	}
+
+	failCreate := false
+	r := &ReconcileMachineSet{
+		Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(machineSet).WithStatusSubresource(&machinev1.MachineSet{}).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+					if failCreate {
+						return errors.New("create failed")
+					}
+					return c.Create(ctx, obj, opts...)
+				},
+			}).Build(),
+		scheme:   scheme.Scheme,
+		recorder: record.NewFakeRecorder(10),
+		gate:     gate,
+	}
+	request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(machineSet)}
+
+	observedGeneration := func() int64 {
+		stored := &machinev1.MachineSet{}
+		g.Expect(r.Client.Get(ctx, request.NamespacedName, stored)).To(Succeed())
+		return stored.Status.ObservedGeneration
+	}
+
+	_, err = r.Reconcile(ctx, request)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(observedGeneration()).To(BeEquivalentTo(1))
+
+	// A spec update which is reconciled successfully is observed
+	updateSpec := func(generation int64, replicas int32) {
+		stored := &machinev1.MachineSet{}
+		g.Expect(r.Client.Get(ctx, request.NamespacedName, stored)).To(Succeed())
+		stored.Generation = generation
+		stored.Spec.Replicas = ptr.To(replicas)
+		g.Expect(r.Client.Update(ctx, stored)).To(Succeed())
+	}
+
+	updateSpec(2, 2)
+	_, err = r.Reconcile(ctx, request)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(observedGeneration()).To(BeEquivalentTo(2))
+
+	// A spec update which fails to be reconciled is not observed
+	failCreate = true
+	updateSpec(3, 3)
+	_, err = r.Reconcile(ctx, request)
+	g.Expect(err).To(MatchError(ContainSubstring("create failed")))
+	g.Expect(observedGeneration()).To(BeEquivalentTo(2))
+
+	// Once the failure is resolved, the spec update is observed
+	failCreate = false
+	_, err = r.Reconcile(ctx, request)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(observedGeneration()).To(BeEquivalentTo(3))
+}
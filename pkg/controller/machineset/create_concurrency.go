@@ -0,0 +1,81 @@
+package machineset
+
+import (
+	"strconv"
+
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/machine-api-operator/pkg/metrics"
+)
+
+// CreateConcurrencyAnnotation bounds the number of Machines of a MachineSet which are created at a time.
+// When scaling up, new Machines are only created while fewer Machines than the bound are still
+// being created, so that large scale ups proceed in batches rather than tripping provider burst limits.
+// It overrides the default creation concurrency of the controller.
+const CreateConcurrencyAnnotation = "machine.openshift.io/create-concurrency"
+
+// createConcurrency returns the maximum number of Machines of the MachineSet which may be created at a time,
+// 0 meaning unlimited. Invalid annotation values are ignored in favour of the default.
+func createConcurrency(machineSet *machinev1.MachineSet, defaultConcurrency int) int {
+	value, ok := machineSet.Annotations[CreateConcurrencyAnnotation]
+	if !ok {
+		return defaultConcurrency
+	}
+
+	concurrency, err := strconv.Atoi(value)
+	if err != nil || concurrency < 1 {
+		klog.Warningf("%v: invalid %s annotation %q, expected a positive integer. Using the default creation concurrency %d",
+			machineSet.Name, CreateConcurrencyAnnotation, value, defaultConcurrency)
+		return defaultConcurrency
+	}
+	return concurrency
+}
+
+// isCreating returns true when the Machine has been created but its instance is not provisioned yet.
+func isCreating(machine *machinev1.Machine) bool {
+	if !machine.DeletionTimestamp.IsZero() {
+		return false
+	}
+
+	phase := ptr.Deref(machine.Status.Phase, "")
+	return phase == "" || phase == machinev1.PhaseProvisioning
+}
+
+// countCreatingMachines returns the number of Machines which are being created.
+func countCreatingMachines(machines []*machinev1.Machine) int {
+	creating := 0
+	for _, machine := range machines {
+		if isCreating(machine) {
+			creating++
+		}
+	}
+	return creating
+}
+
+// machinesToCreate returns how many of the missing Machines may be created given the creation concurrency
+// and the number of Machines already being created.
+func machinesToCreate(missing, creating, concurrency int) int {
+	if concurrency < 1 {
+		return missing
+	}
+	return max(min(missing, concurrency-creating), 0)
+}
+
+// setCreatingMachinesMetric reports the number of Machines of the MachineSet which are being created.
+func setCreatingMachinesMetric(machineSet *machinev1.MachineSet, creating int) {
+	metrics.MachineSetCreatingMachines.With(prometheus.Labels{
+		"name":      machineSet.Name,
+		"namespace": machineSet.Namespace,
+	}).Set(float64(creating))
+}
+
+// deleteCreatingMachinesMetric removes the creating Machines series of a MachineSet.
+func deleteCreatingMachinesMetric(name, namespace string) {
+	metrics.MachineSetCreatingMachines.Delete(prometheus.Labels{
+		"name":      name,
+		"namespace": namespace,
+	})
+}
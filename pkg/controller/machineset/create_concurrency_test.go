@@ -0,0 +1,173 @@
+package machineset
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/machine-api-operator/pkg/metrics"
+	testutils "github.com/openshift/machine-api-operator/pkg/util/testing"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestCreateConcurrency(t *testing.T) {
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expected    int
+	}{
+		{
+			name:     "without annotation",
+			expected: 5,
+		},
+		{
+			name:        "with a valid annotation",
+			annotations: map[string]string{CreateConcurrencyAnnotation: "2"},
+			expected:    2,
+		},
+		{
+			name:        "with a non numeric annotation",
+			annotations: map[string]string{CreateConcurrencyAnnotation: "two"},
+			expected:    5,
+		},
+		{
+			name:        "with a zero annotation",
+			annotations: map[string]string{CreateConcurrencyAnnotation: "0"},
+			expected:    5,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			machineSet := &machinev1.MachineSet{ObjectMeta: metav1.ObjectMeta{Name: "machineset", Annotations: tc.annotations}}
+			g.Expect(createConcurrency(machineSet, 5)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestReconcileCreateConcurrency(t *testing.T) {
+	defer metrics.MachineSetCreatingMachines.Reset()
+
+	newMachineSet := func(annotations map[string]string) *machinev1.MachineSet {
+		return &machinev1.MachineSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "machineset",
+				Namespace:   "default",
+				UID:         "uid",
+				Annotations: annotations,
+			},
+			Spec: machinev1.MachineSetSpec{
+				Replicas: ptr.To[int32](10),
+				Selector: metav1.LabelSelector{
+					MatchLabels: map[string]string{"foo": "bar"},
+				},
+				Template: machinev1.MachineTemplateSpec{
+					ObjectMeta: machinev1.ObjectMeta{
+						Labels: map[string]string{"foo": "bar"},
+					},
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name               string
+		machineSet         *machinev1.MachineSet
+		defaultConcurrency int
+		expectedBatches    []int
+	}{
+		{
+			name:            "without creation concurrency",
+			machineSet:      newMachineSet(nil),
+			expectedBatches: []int{10},
+		},
+		{
+			name:            "with a creation concurrency annotation",
+			machineSet:      newMachineSet(map[string]string{CreateConcurrencyAnnotation: "3"}),
+			expectedBatches: []int{3, 3, 3, 1},
+		},
+		{
+			name:               "with a default creation concurrency",
+			machineSet:         newMachineSet(nil),
+			defaultConcurrency: 4,
+			expectedBatches:    []int{4, 4, 2},
+		},
+		{
+			name:               "with a creation concurrency annotation overriding the default",
+			machineSet:         newMachineSet(map[string]string{CreateConcurrencyAnnotation: "6"}),
+			defaultConcurrency: 4,
+			expectedBatches:    []int{6, 4},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			gate, err := testutils.NewDefaultMutableFeatureGate()
+			g.Expect(err).ToNot(HaveOccurred())
+
+			r := &ReconcileMachineSet{
+				Client:                   fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(tc.machineSet).WithStatusSubresource(&machinev1.MachineSet{}, &machinev1.Machine{}).Build(),
+				scheme:                   scheme.Scheme,
+				recorder:                 record.NewFakeRecorder(10),
+				gate:                     gate,
+				defaultCreateConcurrency: tc.defaultConcurrency,
+			}
+			request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(tc.machineSet)}
+
+			total := 0
+			for _, batch := range tc.expectedBatches {
+				_, err := r.Reconcile(context.Background(), request)
+				g.Expect(err).ToNot(HaveOccurred())
+
+				machines := &machinev1.MachineList{}
+				g.Expect(r.Client.List(context.Background(), machines)).To(Succeed())
+				g.Expect(machines.Items).To(HaveLen(total + batch))
+				g.Expect(creatingMachinesMetricValue(tc.machineSet)).To(BeEquivalentTo(batch))
+
+				// No more Machines are created until the batch is provisioned
+				_, err = r.Reconcile(context.Background(), request)
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(r.Client.List(context.Background(), machines)).To(Succeed())
+				g.Expect(machines.Items).To(HaveLen(total + batch))
+
+				for i := range machines.Items {
+					machine := &machines.Items[i]
+					if ptr.Deref(machine.Status.Phase, "") != "" {
+						continue
+					}
+					machine.Status.Phase = ptr.To(machinev1.PhaseProvisioned)
+					g.Expect(r.Client.Status().Update(context.Background(), machine)).To(Succeed())
+				}
+				total += batch
+			}
+
+			_, err = r.Reconcile(context.Background(), request)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(creatingMachinesMetricValue(tc.machineSet)).To(BeEquivalentTo(0))
+		})
+	}
+}
+
+func creatingMachinesMetricValue(machineSet *machinev1.MachineSet) float64 {
+	m := &dto.Metric{}
+	if err := metrics.MachineSetCreatingMachines.With(prometheus.Labels{
+		"name":      machineSet.Name,
+		"namespace": machineSet.Namespace,
+	}).Write(m); err != nil {
+		return -1
+	}
+	return m.GetGauge().GetValue()
+}
@@ -20,9 +20,13 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"strconv"
+	"time"
 
 	machinev1 "github.com/openshift/api/machine/v1beta1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/machine-api-operator/pkg/controller/machine"
 )
 
 type deletePriority float64
@@ -38,6 +42,12 @@ const (
 	// provider could be preferred.
 	oldDeleteNodeAnnotation = "machine.openshift.io/cluster-api-delete-machine"
 
+	// DeletePriorityAnnotation is an integer hint of the order in which machines are deleted when a machineset
+	// scales down, machines with a higher value are deleted first. Machines without the annotation have a zero
+	// priority and ties are ordered by the delete policy. Machines which are being deleted, failed or marked with
+	// the DeleteNodeAnnotation are always deleted first.
+	DeletePriorityAnnotation = "machine.openshift.io/delete-priority"
+
 	mustDelete    deletePriority = 100.0
 	betterDelete  deletePriority = 50.0
 	preferDelete  deletePriority = 40.0
@@ -47,10 +57,11 @@ const (
 	secondsPerHundredDays float64 = 8640000
 )
 
-type deletePriorityFunc func(machine *machinev1.Machine) deletePriority
+// deletePriorityFunc returns the delete priority of a machine, age based priorities are computed relative to now.
+type deletePriorityFunc func(machine *machinev1.Machine, now time.Time) deletePriority
 
 // maps the creation timestamp onto the 0-100 priority range
-func oldestDeletePriority(machine *machinev1.Machine) deletePriority {
+func oldestDeletePriority(machine *machinev1.Machine, now time.Time) deletePriority {
 	if machine.DeletionTimestamp != nil && !machine.DeletionTimestamp.IsZero() {
 		return mustDelete
 	}
@@ -63,7 +74,7 @@ func oldestDeletePriority(machine *machinev1.Machine) deletePriority {
 	if machine.ObjectMeta.CreationTimestamp.Time.IsZero() {
 		return mustNotDelete
 	}
-	d := metav1.Now().Sub(machine.ObjectMeta.CreationTimestamp.Time)
+	d := now.Sub(machine.ObjectMeta.CreationTimestamp.Time)
 	if d.Seconds() < 0 {
 		return mustNotDelete
 	}
@@ -75,7 +86,7 @@ func oldestDeletePriority(machine *machinev1.Machine) deletePriority {
 	return deletePriority(float64(betterDelete) * (1.0 - math.Exp(-d.Seconds()/secondsPerHundredDays)))
 }
 
-func newestDeletePriority(machine *machinev1.Machine) deletePriority {
+func newestDeletePriority(machine *machinev1.Machine, now time.Time) deletePriority {
 	if machine.DeletionTimestamp != nil && !machine.DeletionTimestamp.IsZero() {
 		return mustDelete
 	}
@@ -85,10 +96,10 @@ func newestDeletePriority(machine *machinev1.Machine) deletePriority {
 	if machine.Status.ErrorReason != nil || machine.Status.ErrorMessage != nil {
 		return mustDelete
 	}
-	return mustDelete - oldestDeletePriority(machine)
+	return mustDelete - oldestDeletePriority(machine, now)
 }
 
-func randomDeletePolicy(machine *machinev1.Machine) deletePriority {
+func randomDeletePolicy(machine *machinev1.Machine, _ time.Time) deletePriority {
 	if machine.DeletionTimestamp != nil && !machine.DeletionTimestamp.IsZero() {
 		return mustDelete
 	}
@@ -105,15 +116,67 @@ func randomDeletePolicy(machine *machinev1.Machine) deletePriority {
 	return couldDelete
 }
 
+// deletePriorityHint returns the DeletePriorityAnnotation value of the machine. Malformed values are ignored.
+// Machines which are being deleted, marked for deletion or failed get the highest hint, so that they are
+// deleted before any hinted machine as with every delete policy.
+func deletePriorityHint(machine *machinev1.Machine) int {
+	if machine.DeletionTimestamp != nil && !machine.DeletionTimestamp.IsZero() {
+		return math.MaxInt
+	}
+	if machine.Annotations[DeleteNodeAnnotation] != "" || machine.Annotations[oldDeleteNodeAnnotation] != "" {
+		return math.MaxInt
+	}
+	if machine.Status.ErrorReason != nil || machine.Status.ErrorMessage != nil {
+		return math.MaxInt
+	}
+
+	value, ok := machine.Annotations[DeletePriorityAnnotation]
+	if !ok {
+		return 0
+	}
+	hint, err := strconv.Atoi(value)
+	if err != nil {
+		klog.V(4).Infof("%v: ignoring invalid %s annotation %q: %v", machine.Name, DeletePriorityAnnotation, value, err)
+		return 0
+	}
+	return hint
+}
+
 type sortableMachines struct {
-	machines []*machinev1.Machine
-	priority deletePriorityFunc
+	machines   []*machinev1.Machine
+	hints      []int
+	priorities []deletePriority
 }
 
-func (m sortableMachines) Len() int      { return len(m.machines) }
-func (m sortableMachines) Swap(i, j int) { m.machines[i], m.machines[j] = m.machines[j], m.machines[i] }
+func (m sortableMachines) Len() int { return len(m.machines) }
+func (m sortableMachines) Swap(i, j int) {
+	m.machines[i], m.machines[j] = m.machines[j], m.machines[i]
+	m.hints[i], m.hints[j] = m.hints[j], m.hints[i]
+	m.priorities[i], m.priorities[j] = m.priorities[j], m.priorities[i]
+}
 func (m sortableMachines) Less(i, j int) bool {
-	return m.priority(m.machines[j]) < m.priority(m.machines[i]) // high to low
+	if m.hints[i] != m.hints[j] {
+		return m.hints[j] < m.hints[i] // high to low
+	}
+	if m.priorities[i] == m.priorities[j] {
+		// Break ties, eg. machines created at the same time, by name so that the choice is stable across reconciles
+		return m.machines[i].GetName() < m.machines[j].GetName()
+	}
+	return m.priorities[j] < m.priorities[i] // high to low
+}
+
+// splitDeletionProtectedMachines returns the machines which can be deleted, and the machines whose deletion
+// would be denied by the MachineProtectedAnnotation. Machines already being deleted are not protected.
+func splitDeletionProtectedMachines(machines []*machinev1.Machine) ([]*machinev1.Machine, []*machinev1.Machine) {
+	var deletable, protected []*machinev1.Machine
+	for _, m := range machines {
+		if m.DeletionTimestamp.IsZero() && machine.IsDeletionProtected(m) {
+			protected = append(protected, m)
+			continue
+		}
+		deletable = append(deletable, m)
+	}
+	return deletable, protected
 }
 
 func getMachinesToDeletePrioritized(filteredMachines []*machinev1.Machine, diff int, fun deletePriorityFunc) []*machinev1.Machine {
@@ -123,9 +186,17 @@ func getMachinesToDeletePrioritized(filteredMachines []*machinev1.Machine, diff
 		return []*machinev1.Machine{}
 	}
 
+	// Priorities are computed once relative to the same time, so that machines created
+	// at the same time get the same priority and the ordering stays consistent while sorting
+	now := time.Now()
 	sortable := sortableMachines{
-		machines: filteredMachines,
-		priority: fun,
+		machines:   filteredMachines,
+		hints:      make([]int, len(filteredMachines)),
+		priorities: make([]deletePriority, len(filteredMachines)),
+	}
+	for i, machine := range filteredMachines {
+		sortable.hints[i] = deletePriorityHint(machine)
+		sortable.priorities[i] = fun(machine, now)
 	}
 	sort.Sort(sortable)
 
@@ -23,6 +23,8 @@ import (
 	machinev1 "github.com/openshift/api/machine/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/machine-api-operator/pkg/controller/machine"
 )
 
 func TestMachineToDelete(t *testing.T) {
@@ -375,3 +377,199 @@ func TestMachineOldestDelete(t *testing.T) {
 		}
 	}
 }
+
+func TestMachineDeleteTieBreak(t *testing.T) {
+	createdAt := metav1.NewTime(metav1.Now().AddDate(0, 0, -10))
+	newMachine := func(name string) *machinev1.Machine {
+		return &machinev1.Machine{ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: createdAt}}
+	}
+	machineA := newMachine("machine-a")
+	machineB := newMachine("machine-b")
+	machineC := newMachine("machine-c")
+	annotatedMachineC := newMachine("machine-c")
+	annotatedMachineC.Annotations = map[string]string{DeleteNodeAnnotation: "yes"}
+
+	tests := []struct {
+		desc     string
+		fun      deletePriorityFunc
+		machines []*machinev1.Machine
+		diff     int
+		expect   []*machinev1.Machine
+	}{
+		{
+			desc:     "func=randomDeletePolicy, equal timestamps",
+			fun:      randomDeletePolicy,
+			machines: []*machinev1.Machine{machineC, machineA, machineB},
+			diff:     2,
+			expect:   []*machinev1.Machine{machineA, machineB},
+		},
+		{
+			desc:     "func=newestDeletePriority, equal timestamps",
+			fun:      newestDeletePriority,
+			machines: []*machinev1.Machine{machineC, machineB, machineA},
+			diff:     2,
+			expect:   []*machinev1.Machine{machineA, machineB},
+		},
+		{
+			desc:     "func=oldestDeletePriority, equal timestamps",
+			fun:      oldestDeletePriority,
+			machines: []*machinev1.Machine{machineB, machineC, machineA},
+			diff:     2,
+			expect:   []*machinev1.Machine{machineA, machineB},
+		},
+		{
+			desc:     "func=oldestDeletePriority, equal timestamps (annotated)",
+			fun:      oldestDeletePriority,
+			machines: []*machinev1.Machine{machineB, annotatedMachineC, machineA},
+			diff:     2,
+			expect:   []*machinev1.Machine{annotatedMachineC, machineA},
+		},
+	}
+
+	for _, test := range tests {
+		// Run repeatedly, the same machines must be chosen on every scale down
+		for i := 0; i < 10; i++ {
+			machines := append([]*machinev1.Machine{}, test.machines...)
+			result := getMachinesToDeletePrioritized(machines, test.diff, test.fun)
+			if !reflect.DeepEqual(result, test.expect) {
+				t.Errorf("[case %s]", test.desc)
+				break
+			}
+		}
+	}
+}
+
+func TestMachineDeletePriorityAnnotation(t *testing.T) {
+	now := metav1.Now()
+	newMachine := func(name string, age int, annotations map[string]string) *machinev1.Machine {
+		return &machinev1.Machine{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(now.AddDate(0, 0, -age)),
+			Annotations:       annotations,
+		}}
+	}
+	oldest := newMachine("oldest", 30, nil)
+	newest := newMachine("newest", 1, nil)
+	cordoned := newMachine("cordoned", 10, map[string]string{DeletePriorityAnnotation: "10"})
+	alsoCordoned := newMachine("also-cordoned", 20, map[string]string{DeletePriorityAnnotation: "10"})
+	preferred := newMachine("preferred", 15, map[string]string{DeletePriorityAnnotation: "5"})
+	protected := newMachine("protected", 40, map[string]string{DeletePriorityAnnotation: "-1"})
+	malformed := newMachine("malformed", 5, map[string]string{DeletePriorityAnnotation: "high"})
+	markedForDeletion := newMachine("marked-for-deletion", 2, map[string]string{DeleteNodeAnnotation: "yes"})
+	deleting := newMachine("deleting", 3, nil)
+	deleting.DeletionTimestamp = &now
+	failed := newMachine("failed", 4, nil)
+	failedReason := machinev1.CreateMachineError
+	failed.Status.ErrorReason = &failedReason
+
+	tests := []struct {
+		desc     string
+		fun      deletePriorityFunc
+		machines []*machinev1.Machine
+		diff     int
+		expect   []*machinev1.Machine
+	}{
+		{
+			desc:     "func=oldestDeletePriority, mixed priorities",
+			fun:      oldestDeletePriority,
+			machines: []*machinev1.Machine{oldest, preferred, newest, cordoned},
+			diff:     3,
+			expect:   []*machinev1.Machine{cordoned, preferred, oldest},
+		},
+		{
+			desc:     "func=newestDeletePriority, mixed priorities",
+			fun:      newestDeletePriority,
+			machines: []*machinev1.Machine{oldest, preferred, newest, cordoned},
+			diff:     3,
+			expect:   []*machinev1.Machine{cordoned, preferred, newest},
+		},
+		{
+			desc:     "func=oldestDeletePriority, equal priorities fall back to the delete policy",
+			fun:      oldestDeletePriority,
+			machines: []*machinev1.Machine{cordoned, newest, alsoCordoned},
+			diff:     2,
+			expect:   []*machinev1.Machine{alsoCordoned, cordoned},
+		},
+		{
+			desc:     "func=newestDeletePriority, equal priorities fall back to the delete policy",
+			fun:      newestDeletePriority,
+			machines: []*machinev1.Machine{alsoCordoned, oldest, cordoned},
+			diff:     2,
+			expect:   []*machinev1.Machine{cordoned, alsoCordoned},
+		},
+		{
+			desc:     "func=oldestDeletePriority, negative priority",
+			fun:      oldestDeletePriority,
+			machines: []*machinev1.Machine{protected, newest},
+			diff:     1,
+			expect:   []*machinev1.Machine{newest},
+		},
+		{
+			desc:     "func=newestDeletePriority, malformed priority is ignored",
+			fun:      newestDeletePriority,
+			machines: []*machinev1.Machine{oldest, malformed, preferred},
+			diff:     2,
+			expect:   []*machinev1.Machine{preferred, malformed},
+		},
+		{
+			desc:     "func=randomDeletePolicy, deleting and marked machines are deleted first",
+			fun:      randomDeletePolicy,
+			machines: []*machinev1.Machine{cordoned, markedForDeletion, preferred, deleting},
+			diff:     3,
+			expect:   []*machinev1.Machine{deleting, markedForDeletion, cordoned},
+		},
+		{
+			desc:     "func=oldestDeletePriority, failed machines are deleted before hinted machines",
+			fun:      oldestDeletePriority,
+			machines: []*machinev1.Machine{cordoned, preferred, failed},
+			diff:     2,
+			expect:   []*machinev1.Machine{failed, cordoned},
+		},
+		{
+			desc:     "func=randomDeletePolicy, failed machines are deleted before hinted machines",
+			fun:      randomDeletePolicy,
+			machines: []*machinev1.Machine{cordoned, failed, preferred},
+			diff:     1,
+			expect:   []*machinev1.Machine{failed},
+		},
+	}
+
+	for _, test := range tests {
+		machines := append([]*machinev1.Machine{}, test.machines...)
+		result := getMachinesToDeletePrioritized(machines, test.diff, test.fun)
+		if !reflect.DeepEqual(result, test.expect) {
+			var names []string
+			for _, m := range result {
+				names = append(names, m.Name)
+			}
+			t.Errorf("[case %s] got %v", test.desc, names)
+		}
+	}
+}
+
+func TestSplitDeletionProtectedMachines(t *testing.T) {
+	now := metav1.Now()
+	newMachine := func(name string, annotations map[string]string) *machinev1.Machine {
+		return &machinev1.Machine{ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Annotations: annotations,
+		}}
+	}
+	unprotected := newMachine("unprotected", nil)
+	protected := newMachine("protected", map[string]string{machine.MachineProtectedAnnotation: "true"})
+	notProtected := newMachine("not-protected", map[string]string{machine.MachineProtectedAnnotation: "false"})
+	forced := newMachine("forced", map[string]string{
+		machine.MachineProtectedAnnotation:   "true",
+		machine.MachineForceDeleteAnnotation: "true",
+	})
+	deleting := newMachine("deleting", map[string]string{machine.MachineProtectedAnnotation: "true"})
+	deleting.DeletionTimestamp = &now
+
+	deletable, skipped := splitDeletionProtectedMachines([]*machinev1.Machine{unprotected, protected, notProtected, forced, deleting})
+	if expected := []*machinev1.Machine{unprotected, notProtected, forced, deleting}; !reflect.DeepEqual(deletable, expected) {
+		t.Errorf("expected deletable machines %v, got %v", expected, deletable)
+	}
+	if expected := []*machinev1.Machine{protected}; !reflect.DeepEqual(skipped, expected) {
+		t.Errorf("expected protected machines %v, got %v", expected, skipped)
+	}
+}
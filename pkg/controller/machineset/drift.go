@@ -0,0 +1,185 @@
+package machineset
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/machine-api-operator/pkg/metrics"
+)
+
+// MachineDriftAnnotation is set on the Machines of a MachineSet whose providerSpec differs from the
+// Machine template. Its value lists the paths of the differing providerSpec fields. Drifted Machines
+// are never recreated nor updated from the template.
+const MachineDriftAnnotation = "machine.openshift.io/drift"
+
+// reportProviderSpecDrift compares the providerSpec of the Machines with the Machine template, sets or removes
+// the MachineDriftAnnotation accordingly and reports the number of drifted Machines of the MachineSet.
+// Failures are only logged, so that reporting the drift never blocks the scaling of the MachineSet.
+func (c *ReconcileMachineSet) reportProviderSpecDrift(ctx context.Context, machineSet *machinev1.MachineSet, machines []*machinev1.Machine) {
+	drifted := 0
+	for _, m := range machines {
+		if !m.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		fields, err := providerSpecDiff(machineSet.Spec.Template.Spec.ProviderSpec.Value, m.Spec.ProviderSpec.Value)
+		if err != nil {
+			klog.Warningf("%v: failed to compare the providerSpec of Machine %v with the template: %v", machineSet.Name, m.Name, err)
+			continue
+		}
+
+		drift := strings.Join(fields, ",")
+		if drift != "" {
+			drifted++
+		}
+		current, annotated := m.Annotations[MachineDriftAnnotation]
+		if (drift == "" && !annotated) || (drift != "" && drift == current) {
+			continue
+		}
+
+		patchBase := client.MergeFrom(m.DeepCopy())
+		if drift == "" {
+			delete(m.Annotations, MachineDriftAnnotation)
+		} else {
+			klog.Infof("%v: Machine %v has drifted from the template: %s", machineSet.Name, m.Name, drift)
+			if m.Annotations == nil {
+				m.Annotations = map[string]string{}
+			}
+			m.Annotations[MachineDriftAnnotation] = drift
+		}
+		if err := c.Client.Patch(ctx, m, patchBase); err != nil {
+			klog.Warningf("%v: failed to patch drift annotation of Machine %v: %v", machineSet.Name, m.Name, err)
+		}
+	}
+
+	metrics.MachineSetDriftedMachines.With(prometheus.Labels{
+		"name":      machineSet.Name,
+		"namespace": machineSet.Namespace,
+	}).Set(float64(drifted))
+}
+
+// deleteDriftMetric removes the drifted Machines series of a MachineSet.
+func deleteDriftMetric(name, namespace string) {
+	metrics.MachineSetDriftedMachines.Delete(prometheus.Labels{
+		"name":      name,
+		"namespace": namespace,
+	})
+}
+
+// providerSpecDiff returns the sorted paths of the fields which differ between the two providerSpecs.
+// The providerSpecs are compared field by field, so that formatting, key order, the object metadata
+// and fields set to their zero value rather than omitted are not reported as differences.
+func providerSpecDiff(expected, actual *runtime.RawExtension) ([]string, error) {
+	expectedFields, err := decodeProviderSpecFields(expected)
+	if err != nil {
+		return nil, err
+	}
+	actualFields, err := decodeProviderSpecFields(actual)
+	if err != nil {
+		return nil, err
+	}
+
+	if expectedFields != nil {
+		delete(expectedFields, "metadata")
+	}
+	if actualFields != nil {
+		delete(actualFields, "metadata")
+	}
+
+	var paths []string
+	diffFields("", expectedFields, actualFields, &paths)
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func decodeProviderSpecFields(providerSpec *runtime.RawExtension) (map[string]interface{}, error) {
+	if providerSpec == nil || len(providerSpec.Raw) == 0 {
+		return nil, nil
+	}
+
+	data, err := yaml.YAMLToJSON(providerSpec.Raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode providerSpec: %w", err)
+	}
+
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode providerSpec: %w", err)
+	}
+	return fields, nil
+}
+
+// diffFields appends the paths of the differences between expected and actual to paths.
+// Objects are compared key by key and lists of the same length item by item.
+func diffFields(path string, expected, actual interface{}, paths *[]string) {
+	if isZeroField(expected) && isZeroField(actual) {
+		return
+	}
+
+	expectedMap, expectedIsMap := expected.(map[string]interface{})
+	actualMap, actualIsMap := actual.(map[string]interface{})
+	if (expectedIsMap || expected == nil) && (actualIsMap || actual == nil) && (expectedIsMap || actualIsMap) {
+		keys := map[string]struct{}{}
+		for key := range expectedMap {
+			keys[key] = struct{}{}
+		}
+		for key := range actualMap {
+			keys[key] = struct{}{}
+		}
+		for key := range keys {
+			diffFields(joinFieldPath(path, key), expectedMap[key], actualMap[key], paths)
+		}
+		return
+	}
+
+	expectedList, expectedIsList := expected.([]interface{})
+	actualList, actualIsList := actual.([]interface{})
+	if expectedIsList && actualIsList && len(expectedList) == len(actualList) {
+		for i := range expectedList {
+			diffFields(fmt.Sprintf("%s[%d]", path, i), expectedList[i], actualList[i], paths)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		*paths = append(*paths, path)
+	}
+}
+
+func joinFieldPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// isZeroField returns true for omitted fields and fields set to their zero value, which decode to the same providerSpec.
+func isZeroField(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
@@ -0,0 +1,247 @@
+package machineset
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/machine-api-operator/pkg/metrics"
+	testutils "github.com/openshift/machine-api-operator/pkg/util/testing"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestProviderSpecDiff(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected string
+		actual   string
+		diff     []string
+	}{
+		{
+			name:     "with identical providerSpecs",
+			expected: `{"instanceType":"m5.large","placement":{"region":"us-east-1"}}`,
+			actual:   `{"instanceType":"m5.large","placement":{"region":"us-east-1"}}`,
+		},
+		{
+			name:     "with a different key order and formatting",
+			expected: `{"instanceType":"m5.large","placement":{"region":"us-east-1","availabilityZone":"us-east-1a"}}`,
+			actual:   `{ "placement": { "availabilityZone": "us-east-1a", "region": "us-east-1" }, "instanceType": "m5.large" }`,
+		},
+		{
+			name:     "with YAML and JSON providerSpecs",
+			expected: `{"instanceType":"m5.large","securityGroups":[{"id":"sg-1"}]}`,
+			actual:   "instanceType: m5.large\nsecurityGroups:\n- id: sg-1\n",
+		},
+		{
+			name:     "with omitted and zero value fields",
+			expected: `{"instanceType":"m5.large","metadata":{"creationTimestamp":null},"tags":[],"spotMarketOptions":{},"publicIp":false}`,
+			actual:   `{"instanceType":"m5.large","keyName":null}`,
+		},
+		{
+			name:     "with a changed field",
+			expected: `{"instanceType":"m5.large","placement":{"region":"us-east-1"}}`,
+			actual:   `{"instanceType":"m5.xlarge","placement":{"region":"us-east-1"}}`,
+			diff:     []string{"instanceType"},
+		},
+		{
+			name:     "with added, removed and nested fields",
+			expected: `{"instanceType":"m5.large","keyName":"key","placement":{"region":"us-east-1"},"securityGroups":[{"id":"sg-1"}]}`,
+			actual:   `{"instanceType":"m5.large","placement":{"region":"us-east-1","availabilityZone":"us-east-1a"},"securityGroups":[{"id":"sg-2"}]}`,
+			diff:     []string{"keyName", "placement.availabilityZone", "securityGroups[0].id"},
+		},
+		{
+			name:     "with lists of different lengths",
+			expected: `{"securityGroups":[{"id":"sg-1"}]}`,
+			actual:   `{"securityGroups":[{"id":"sg-1"},{"id":"sg-2"}]}`,
+			diff:     []string{"securityGroups"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			diff, err := providerSpecDiff(&runtime.RawExtension{Raw: []byte(tc.expected)}, &runtime.RawExtension{Raw: []byte(tc.actual)})
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(diff).To(Equal(tc.diff))
+		})
+	}
+}
+
+func TestReconcileProviderSpecDrift(t *testing.T) {
+	defer metrics.MachineSetDriftedMachines.Reset()
+
+	machineSet := &machinev1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "machineset",
+			Namespace: "default",
+			UID:       "uid",
+		},
+		Spec: machinev1.MachineSetSpec{
+			Replicas: ptr.To[int32](3),
+			Selector: metav1.LabelSelector{
+				MatchLabels: map[string]string{"foo": "bar"},
+			},
+			Template: machinev1.MachineTemplateSpec{
+				ObjectMeta: machinev1.ObjectMeta{
+					Labels: map[string]string{"foo": "bar"},
+				},
+				Spec: machinev1.MachineSpec{
+					ProviderSpec: machinev1.ProviderSpec{
+						Value: &runtime.RawExtension{Raw: []byte(`{"instanceType":"m5.large","placement":{"region":"us-east-1"}}`)},
+					},
+				},
+			},
+		},
+	}
+	newMachine := func(name, providerSpec string, annotations map[string]string) *machinev1.Machine {
+		return &machinev1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       "default",
+				Labels:          map[string]string{"foo": "bar"},
+				Annotations:     annotations,
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(machineSet, controllerKind)},
+			},
+			Spec: machinev1.MachineSpec{
+				ProviderSpec: machinev1.ProviderSpec{
+					Value: &runtime.RawExtension{Raw: []byte(providerSpec)},
+				},
+			},
+		}
+	}
+
+	drifted := newMachine("drifted", `{"instanceType":"m5.xlarge","placement":{"region":"us-east-1"}}`, nil)
+	cosmetic := newMachine("cosmetic", `{"placement":{"region":"us-east-1"},"instanceType":"m5.large","metadata":{"creationTimestamp":null}}`, nil)
+	fixed := newMachine("fixed", `{"instanceType":"m5.large","placement":{"region":"us-east-1"}}`, map[string]string{MachineDriftAnnotation: "instanceType"})
+
+	g := NewWithT(t)
+
+	gate, err := testutils.NewDefaultMutableFeatureGate()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	r := &ReconcileMachineSet{
+		Client:   fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(machineSet, drifted, cosmetic, fixed).WithStatusSubresource(&machinev1.MachineSet{}).Build(),
+		scheme:   scheme.Scheme,
+		recorder: record.NewFakeRecorder(10),
+		gate:     gate,
+	}
+
+	_, err = r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(machineSet)})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	expectedAnnotations := map[string]string{
+		"drifted":  "instanceType",
+		"cosmetic": "",
+		"fixed":    "",
+	}
+	for name, expected := range expectedAnnotations {
+		got := &machinev1.Machine{}
+		g.Expect(r.Client.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: name}, got)).To(Succeed())
+		if expected == "" {
+			g.Expect(got.Annotations).ToNot(HaveKey(MachineDriftAnnotation), "machine %s", name)
+		} else {
+			g.Expect(got.Annotations).To(HaveKeyWithValue(MachineDriftAnnotation, expected), "machine %s", name)
+		}
+		// The providerSpec of drifted Machines is never corrected
+		g.Expect(got.Spec.ProviderSpec.Value.Raw).To(MatchJSON(map[string]*machinev1.Machine{
+			"drifted": drifted, "cosmetic": cosmetic, "fixed": fixed,
+		}[name].Spec.ProviderSpec.Value.Raw))
+	}
+
+	m := &dto.Metric{}
+	g.Expect(metrics.MachineSetDriftedMachines.With(prometheus.Labels{
+		"name":      machineSet.Name,
+		"namespace": machineSet.Namespace,
+	}).Write(m)).To(Succeed())
+	g.Expect(m.GetGauge().GetValue()).To(BeEquivalentTo(1))
+}
+
+func TestReconcileProviderSpecDriftPatchFailure(t *testing.T) {
+	defer metrics.MachineSetDriftedMachines.Reset()
+
+	g := NewWithT(t)
+
+	machineSet := &machinev1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "machineset",
+			Namespace: "default",
+			UID:       "uid",
+		},
+		Spec: machinev1.MachineSetSpec{
+			Replicas: ptr.To[int32](2),
+			Selector: metav1.LabelSelector{
+				MatchLabels: map[string]string{"foo": "bar"},
+			},
+			Template: machinev1.MachineTemplateSpec{
+				ObjectMeta: machinev1.ObjectMeta{
+					Labels: map[string]string{"foo": "bar"},
+				},
+				Spec: machinev1.MachineSpec{
+					ProviderSpec: machinev1.ProviderSpec{
+						Value: &runtime.RawExtension{Raw: []byte(`{"instanceType":"m5.large"}`)},
+					},
+				},
+			},
+		},
+	}
+	drifted := &machinev1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "drifted",
+			Namespace:       "default",
+			Labels:          map[string]string{"foo": "bar"},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(machineSet, controllerKind)},
+		},
+		Spec: machinev1.MachineSpec{
+			ProviderSpec: machinev1.ProviderSpec{
+				Value: &runtime.RawExtension{Raw: []byte(`{"instanceType":"m5.xlarge"}`)},
+			},
+		},
+	}
+
+	gate, err := testutils.NewDefaultMutableFeatureGate()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	r := &ReconcileMachineSet{
+		Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(machineSet, drifted).
+			WithStatusSubresource(&machinev1.MachineSet{}).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+					if _, ok := obj.(*machinev1.Machine); ok {
+						return errors.New("patch failed")
+					}
+					return c.Patch(ctx, obj, patch, opts...)
+				},
+			}).Build(),
+		scheme:   scheme.Scheme,
+		recorder: record.NewFakeRecorder(10),
+		gate:     gate,
+	}
+
+	_, err = r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(machineSet)})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// The MachineSet is still scaled up
+	machines := &machinev1.MachineList{}
+	g.Expect(r.Client.List(context.Background(), machines, client.InNamespace("default"))).To(Succeed())
+	g.Expect(machines.Items).To(HaveLen(2))
+
+	m := &dto.Metric{}
+	g.Expect(metrics.MachineSetDriftedMachines.With(prometheus.Labels{
+		"name":      machineSet.Name,
+		"namespace": machineSet.Namespace,
+	}).Write(m)).To(Succeed())
+	g.Expect(m.GetGauge().GetValue()).To(BeEquivalentTo(1))
+}
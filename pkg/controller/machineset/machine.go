@@ -18,10 +18,13 @@ package machineset
 
 import (
 	"context"
+	"fmt"
 
 	machinev1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/machine-api-operator/pkg/util/annotations"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	apimachineryutilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -70,3 +73,47 @@ func hasMatchingLabels(machineSet *machinev1.MachineSet, machine *machinev1.Mach
 
 	return true
 }
+
+// syncTemplateAnnotations copies the allowlisted annotations of the Machine template onto the given Machines
+// when their values differ. Annotations which are removed from the template are left on the Machines.
+// The Node hint annotations of the template spec are kept in sync on the Machine spec, including removals,
+// for the nodelink controller to propagate them to the Nodes. A Machine which fails to be patched does not stop
+// the sync of the other Machines, the errors are aggregated.
+func (c *ReconcileMachineSet) syncTemplateAnnotations(ctx context.Context, machineSet *machinev1.MachineSet, machines []*machinev1.Machine) error {
+	var errList []error
+	for _, m := range machines {
+		if !m.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		patchBase := client.MergeFrom(m.DeepCopy())
+		changed := false
+		for _, key := range syncedTemplateAnnotations {
+			value, ok := machineSet.Spec.Template.Annotations[key]
+			if !ok {
+				continue
+			}
+			if current, ok := m.Annotations[key]; ok && current == value {
+				continue
+			}
+			if m.Annotations == nil {
+				m.Annotations = map[string]string{}
+			}
+			m.Annotations[key] = value
+			changed = true
+		}
+
+		var hintsChanged bool
+		m.Spec.Annotations, hintsChanged = annotations.SyncNodeHintAnnotations(m.Spec.Annotations, machineSet.Spec.Template.Spec.Annotations)
+		changed = changed || hintsChanged
+
+		if !changed {
+			continue
+		}
+		klog.V(3).Infof("%v: syncing template annotations to Machine %v", machineSet.Name, m.Name)
+		if err := c.Client.Patch(ctx, m, patchBase); err != nil {
+			errList = append(errList, fmt.Errorf("failed to patch annotations of Machine %q: %w", m.Name, err))
+		}
+	}
+	return apimachineryutilerrors.NewAggregate(errList)
+}
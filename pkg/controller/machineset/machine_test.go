@@ -17,10 +17,23 @@ limitations under the License.
 package machineset
 
 import (
+	"context"
+	"errors"
 	"testing"
 
+	. "github.com/onsi/gomega"
 	machinev1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/machine-api-operator/pkg/util/annotations"
+	testutils "github.com/openshift/machine-api-operator/pkg/util/testing"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 func TestHasMatchingLabels(t *testing.T) {
@@ -78,3 +91,206 @@ func TestHasMatchingLabels(t *testing.T) {
 		}
 	}
 }
+
+func TestReconcileSyncTemplateAnnotations(t *testing.T) {
+	g := NewWithT(t)
+
+	gate, err := testutils.NewDefaultMutableFeatureGate()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	providerSpec := &machinev1.ProviderSpec{
+		Value: &runtime.RawExtension{Raw: []byte(`{"instanceType":"m5.large"}`)},
+	}
+	machineSet := &machinev1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "machineset",
+			Namespace: "default",
+			UID:       "uid",
+		},
+		Spec: machinev1.MachineSetSpec{
+			Replicas: ptr.To[int32](1),
+			Selector: metav1.LabelSelector{
+				MatchLabels: map[string]string{"foo": "bar"},
+			},
+			Template: machinev1.MachineTemplateSpec{
+				ObjectMeta: machinev1.ObjectMeta{
+					Labels: map[string]string{"foo": "bar"},
+					Annotations: map[string]string{
+						"openshift.io/description": "updated description",
+						"example.com/not-synced":   "updated",
+					},
+				},
+				Spec: machinev1.MachineSpec{
+					ProviderSpec: *providerSpec.DeepCopy(),
+				},
+			},
+		},
+	}
+	machine := &machinev1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "machine",
+			Namespace: "default",
+			Labels:    map[string]string{"foo": "bar"},
+			Annotations: map[string]string{
+				"openshift.io/description": "description",
+				"example.com/not-synced":   "original",
+			},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(machineSet, controllerKind)},
+		},
+		Spec: machinev1.MachineSpec{
+			ProviderSpec: machinev1.ProviderSpec{
+				Value: &runtime.RawExtension{Raw: []byte(`{"instanceType":"m5.xlarge"}`)},
+			},
+		},
+	}
+
+	r := &ReconcileMachineSet{
+		Client:   fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(machineSet, machine).WithStatusSubresource(&machinev1.MachineSet{}).Build(),
+		scheme:   scheme.Scheme,
+		recorder: record.NewFakeRecorder(10),
+		gate:     gate,
+	}
+
+	_, err = r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(machineSet)})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	got := &machinev1.Machine{}
+	g.Expect(r.Client.Get(context.Background(), client.ObjectKeyFromObject(machine), got)).To(Succeed())
+	g.Expect(got.Annotations).To(HaveKeyWithValue("openshift.io/description", "updated description"))
+	g.Expect(got.Annotations).To(HaveKeyWithValue("example.com/not-synced", "original"))
+	// The providerSpec of existing Machines is never updated from the template
+	g.Expect(got.Spec.ProviderSpec).To(Equal(machine.Spec.ProviderSpec))
+}
+
+func TestReconcileSyncTemplateAnnotationsFailure(t *testing.T) {
+	g := NewWithT(t)
+
+	gate, err := testutils.NewDefaultMutableFeatureGate()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	machineSet := &machinev1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "machineset",
+			Namespace: "default",
+			UID:       "uid",
+		},
+		Spec: machinev1.MachineSetSpec{
+			Replicas: ptr.To[int32](3),
+			Selector: metav1.LabelSelector{
+				MatchLabels: map[string]string{"foo": "bar"},
+			},
+			Template: machinev1.MachineTemplateSpec{
+				ObjectMeta: machinev1.ObjectMeta{
+					Labels:      map[string]string{"foo": "bar"},
+					Annotations: map[string]string{"openshift.io/description": "updated description"},
+				},
+			},
+		},
+	}
+	machine := &machinev1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "machine",
+			Namespace:       "default",
+			Labels:          map[string]string{"foo": "bar"},
+			Annotations:     map[string]string{"openshift.io/description": "description"},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(machineSet, controllerKind)},
+		},
+	}
+
+	r := &ReconcileMachineSet{
+		Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(machineSet, machine).WithStatusSubresource(&machinev1.MachineSet{}).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+					if _, ok := obj.(*machinev1.Machine); ok {
+						return errors.New("patch failed")
+					}
+					return c.Patch(ctx, obj, patch, opts...)
+				},
+			}).Build(),
+		scheme:   scheme.Scheme,
+		recorder: record.NewFakeRecorder(10),
+		gate:     gate,
+	}
+
+	_, err = r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(machineSet)})
+	g.Expect(err).To(MatchError(ContainSubstring(`failed to sync template annotations: failed to patch annotations of Machine "machine": patch failed`)))
+
+	// The MachineSet is scaled up although the annotations of its Machine could not be synced
+	machines := &machinev1.MachineList{}
+	g.Expect(r.Client.List(context.Background(), machines, client.InNamespace("default"))).To(Succeed())
+	g.Expect(machines.Items).To(HaveLen(3))
+
+	got := &machinev1.MachineSet{}
+	g.Expect(r.Client.Get(context.Background(), client.ObjectKeyFromObject(machineSet), got)).To(Succeed())
+	g.Expect(got.Status.Replicas).To(Equal(int32(1)))
+}
+
+func TestReconcileSyncNodeHintAnnotations(t *testing.T) {
+	g := NewWithT(t)
+
+	gate, err := testutils.NewDefaultMutableFeatureGate()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	kernelArgsHint := annotations.NodeHintAnnotationPrefix + "kernel-args"
+	swapHint := annotations.NodeHintAnnotationPrefix + "swap"
+
+	machineSet := &machinev1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "machineset",
+			Namespace: "default",
+			UID:       "uid",
+		},
+		Spec: machinev1.MachineSetSpec{
+			Replicas: ptr.To[int32](1),
+			Selector: metav1.LabelSelector{
+				MatchLabels: map[string]string{"foo": "bar"},
+			},
+			Template: machinev1.MachineTemplateSpec{
+				ObjectMeta: machinev1.ObjectMeta{
+					Labels: map[string]string{"foo": "bar"},
+				},
+				Spec: machinev1.MachineSpec{
+					ObjectMeta: machinev1.ObjectMeta{
+						Annotations: map[string]string{
+							kernelArgsHint:           "hugepages=64",
+							"example.com/not-synced": "updated",
+						},
+					},
+				},
+			},
+		},
+	}
+	machine := &machinev1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "machine",
+			Namespace:       "default",
+			Labels:          map[string]string{"foo": "bar"},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(machineSet, controllerKind)},
+		},
+		Spec: machinev1.MachineSpec{
+			ObjectMeta: machinev1.ObjectMeta{
+				Annotations: map[string]string{
+					swapHint:                 "enabled",
+					"example.com/not-synced": "original",
+				},
+			},
+		},
+	}
+
+	r := &ReconcileMachineSet{
+		Client:   fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(machineSet, machine).WithStatusSubresource(&machinev1.MachineSet{}).Build(),
+		scheme:   scheme.Scheme,
+		recorder: record.NewFakeRecorder(10),
+		gate:     gate,
+	}
+
+	_, err = r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(machineSet)})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	got := &machinev1.Machine{}
+	g.Expect(r.Client.Get(context.Background(), client.ObjectKeyFromObject(machine), got)).To(Succeed())
+	g.Expect(got.Spec.Annotations).To(Equal(map[string]string{
+		kernelArgsHint:           "hugepages=64",
+		"example.com/not-synced": "original",
+	}))
+}
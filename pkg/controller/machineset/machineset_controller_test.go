@@ -25,6 +25,7 @@ import (
 
 	machinev1 "github.com/openshift/api/machine/v1beta1"
 	machinev1resourcebuilder "github.com/openshift/cluster-api-actuator-pkg/testutils/resourcebuilder/machine/v1beta1"
+	mapicontroller "github.com/openshift/machine-api-operator/pkg/controller"
 	"github.com/openshift/machine-api-operator/pkg/controller/machine"
 
 	testutils "github.com/openshift/cluster-api-actuator-pkg/testutils"
@@ -69,7 +70,7 @@ var _ = Describe("MachineSet Reconciler", func() {
 		Expect(addWithOpts(mgr, controller.Options{
 			Reconciler:         reconciler,
 			SkipNameValidation: ptr.To(true),
-		}, reconciler.MachineToMachineSets)).To(Succeed())
+		}, mapicontroller.DefaultClusterPauseNamespace, reconciler.MachineToMachineSets)).To(Succeed())
 
 		var mgrCtx context.Context
 		mgrCtx, mgrCtxCancel = context.WithCancel(ctx)
@@ -0,0 +1,92 @@
+package machineset
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	// ScaledUpReason is the reason of the event recorded on a MachineSet when it creates Machines to scale up.
+	ScaledUpReason = "ScaledUp"
+	// ScaledDownReason is the reason of the event recorded on a MachineSet when it deletes Machines to scale down.
+	ScaledDownReason = "ScaledDown"
+	// ProtectedMachinesSkippedReason is the reason of the event recorded on a MachineSet when it can't scale down
+	// to its replicas because the remaining Machines are protected from deletion.
+	ProtectedMachinesSkippedReason = "ProtectedMachinesSkipped"
+)
+
+const (
+	// scaleCauseSpecChange is the cause of a scale following a change of the spec of the MachineSet.
+	scaleCauseSpecChange = "spec change"
+	// scaleCauseRemediation is the cause of a scale restoring the replicas of an unchanged MachineSet,
+	// e.g. when Machines deleted by a MachineHealthCheck are replaced.
+	scaleCauseRemediation = "remediation"
+)
+
+// scaleCause returns why the MachineSet is scaling. A scale is caused by a spec change while the
+// generation of the MachineSet has not been observed, by remediation otherwise.
+func scaleCause(ms *machinev1.MachineSet) string {
+	if ms.Generation != ms.Status.ObservedGeneration {
+		return scaleCauseSpecChange
+	}
+	return scaleCauseRemediation
+}
+
+// scaleEvents records a single event per scale of a MachineSet, naming the Machines created or deleted.
+// An event identical to the last one recorded for the MachineSet, e.g. when the deletion of the same
+// Machines is retried, is not recorded again. The zero value is ready to use.
+type scaleEvents struct {
+	lock sync.Mutex
+	last map[types.NamespacedName]string
+}
+
+// record records the reason event on the MachineSet for the Machines, unless it was the last one recorded.
+func (e *scaleEvents) record(recorder record.EventRecorder, ms *machinev1.MachineSet, reason string, machines []*machinev1.Machine) {
+	if recorder == nil || len(machines) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(machines))
+	for _, machine := range machines {
+		names = append(names, machine.Name)
+	}
+
+	var action string
+	switch reason {
+	case ScaledUpReason:
+		action = "Created"
+	case ScaledDownReason:
+		action = "Deleted"
+	}
+	var replicas int32
+	if ms.Spec.Replicas != nil {
+		replicas = *ms.Spec.Replicas
+	}
+	message := fmt.Sprintf("%s Machines %s to scale to %d replicas, cause: %s", action, strings.Join(names, ", "), replicas, scaleCause(ms))
+
+	key := types.NamespacedName{Namespace: ms.Namespace, Name: ms.Name}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.last == nil {
+		e.last = map[types.NamespacedName]string{}
+	}
+	if e.last[key] == reason+message {
+		return
+	}
+	e.last[key] = reason + message
+
+	recorder.Event(ms, corev1.EventTypeNormal, reason, message)
+}
+
+// forget drops the last event recorded for the MachineSet.
+func (e *scaleEvents) forget(key types.NamespacedName) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	delete(e.last, key)
+}
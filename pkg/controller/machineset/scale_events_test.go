@@ -0,0 +1,239 @@
+package machineset
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/machine-api-operator/pkg/controller/machine"
+	testutils "github.com/openshift/machine-api-operator/pkg/util/testing"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcileScaleEvents(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	gate, err := testutils.NewDefaultMutableFeatureGate()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	machineSet := &machinev1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "machineset",
+			Namespace:  "default",
+			Generation: 1,
+		},
+		Spec: machinev1.MachineSetSpec{
+			Replicas: ptr.To[int32](2),
+			Selector: metav1.LabelSelector{
+				MatchLabels: map[string]string{"foo": "bar"},
+			},
+			Template: machinev1.MachineTemplateSpec{
+				ObjectMeta: machinev1.ObjectMeta{
+					Labels: map[string]string{"foo": "bar"},
+				},
+			},
+		},
+	}
+
+	recorder := record.NewFakeRecorder(10)
+	r := &ReconcileMachineSet{
+		Client:   fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(machineSet).WithStatusSubresource(&machinev1.MachineSet{}).Build(),
+		scheme:   scheme.Scheme,
+		recorder: recorder,
+		gate:     gate,
+	}
+	request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(machineSet)}
+
+	listMachines := func() []machinev1.Machine {
+		machines := &machinev1.MachineList{}
+		g.Expect(r.Client.List(ctx, machines, client.InNamespace("default"))).To(Succeed())
+		return machines.Items
+	}
+
+	// Scaling up for a spec change records a single event naming the created Machines
+	_, err = r.Reconcile(ctx, request)
+	g.Expect(err).ToNot(HaveOccurred())
+	machines := listMachines()
+	g.Expect(machines).To(HaveLen(2))
+	g.Expect(recorder.Events).To(HaveLen(1))
+	event := <-recorder.Events
+	g.Expect(event).To(HavePrefix("Normal ScaledUp Created Machines "))
+	g.Expect(event).To(HaveSuffix(" to scale to 2 replicas, cause: spec change"))
+	g.Expect(event).To(ContainSubstring(machines[0].Name))
+	g.Expect(event).To(ContainSubstring(machines[1].Name))
+
+	// Reconciling again without scaling records no event
+	_, err = r.Reconcile(ctx, request)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(recorder.Events).To(BeEmpty())
+
+	// Replacing a deleted Machine of the unchanged MachineSet is a remediation
+	g.Expect(r.Client.Delete(ctx, &machines[0])).To(Succeed())
+	_, err = r.Reconcile(ctx, request)
+	g.Expect(err).ToNot(HaveOccurred())
+	var replacement string
+	for _, machine := range listMachines() {
+		if machine.Name != machines[1].Name {
+			replacement = machine.Name
+		}
+	}
+	g.Expect(replacement).ToNot(BeEmpty())
+	g.Expect(recorder.Events).To(HaveLen(1))
+	g.Expect(<-recorder.Events).To(Equal(fmt.Sprintf("Normal ScaledUp Created Machines %s to scale to 2 replicas, cause: remediation", replacement)))
+	machines = listMachines()
+
+	// Scaling down for a spec change records a single event naming the deleted Machine
+	stored := &machinev1.MachineSet{}
+	g.Expect(r.Client.Get(ctx, request.NamespacedName, stored)).To(Succeed())
+	stored.Generation = 2
+	stored.Spec.Replicas = ptr.To[int32](1)
+	g.Expect(r.Client.Update(ctx, stored)).To(Succeed())
+
+	_, err = r.Reconcile(ctx, request)
+	g.Expect(err).ToNot(HaveOccurred())
+	remaining := listMachines()
+	g.Expect(remaining).To(HaveLen(1))
+	var deleted string
+	for _, machine := range machines {
+		if machine.Name != remaining[0].Name {
+			deleted = machine.Name
+		}
+	}
+	g.Expect(recorder.Events).To(HaveLen(1))
+	g.Expect(<-recorder.Events).To(Equal(fmt.Sprintf("Normal ScaledDown Deleted Machines %s to scale to 1 replicas, cause: spec change", deleted)))
+}
+
+func TestScaleEventsDeduplication(t *testing.T) {
+	g := NewWithT(t)
+
+	machineSet := &machinev1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "machineset",
+			Namespace: "default",
+		},
+		Spec: machinev1.MachineSetSpec{
+			Replicas: ptr.To[int32](1),
+		},
+	}
+	machines := []*machinev1.Machine{
+		{ObjectMeta: metav1.ObjectMeta{Name: "machine-a"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "machine-b"}},
+	}
+
+	recorder := record.NewFakeRecorder(10)
+	events := scaleEvents{}
+
+	events.record(recorder, machineSet, ScaledDownReason, machines)
+	g.Expect(recorder.Events).To(HaveLen(1))
+	g.Expect(<-recorder.Events).To(Equal("Normal ScaledDown Deleted Machines machine-a, machine-b to scale to 1 replicas, cause: remediation"))
+
+	// The same event is not recorded again, e.g. when the deletion is retried
+	events.record(recorder, machineSet, ScaledDownReason, machines)
+	g.Expect(recorder.Events).To(BeEmpty())
+
+	// Without Machines, no event is recorded
+	events.record(recorder, machineSet, ScaledUpReason, nil)
+	g.Expect(recorder.Events).To(BeEmpty())
+
+	// A different event is recorded
+	events.record(recorder, machineSet, ScaledDownReason, machines[:1])
+	g.Expect(recorder.Events).To(HaveLen(1))
+	g.Expect(<-recorder.Events).To(Equal("Normal ScaledDown Deleted Machines machine-a to scale to 1 replicas, cause: remediation"))
+
+	// Once the MachineSet is forgotten, its events are recorded again
+	events.forget(client.ObjectKeyFromObject(machineSet))
+	events.record(recorder, machineSet, ScaledDownReason, machines[:1])
+	g.Expect(recorder.Events).To(HaveLen(1))
+}
+
+func TestReconcileProtectedMachines(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	gate, err := testutils.NewDefaultMutableFeatureGate()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	machineSet := &machinev1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "machineset",
+			Namespace:  "default",
+			Generation: 1,
+		},
+		Spec: machinev1.MachineSetSpec{
+			Replicas:     ptr.To[int32](2),
+			DeletePolicy: string(machinev1.OldestMachineSetDeletePolicy),
+			Selector: metav1.LabelSelector{
+				MatchLabels: map[string]string{"foo": "bar"},
+			},
+			Template: machinev1.MachineTemplateSpec{
+				ObjectMeta: machinev1.ObjectMeta{
+					Labels: map[string]string{"foo": "bar"},
+				},
+			},
+		},
+	}
+
+	recorder := record.NewFakeRecorder(10)
+	r := &ReconcileMachineSet{
+		Client:   fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(machineSet).WithStatusSubresource(&machinev1.MachineSet{}).Build(),
+		scheme:   scheme.Scheme,
+		recorder: recorder,
+		gate:     gate,
+	}
+	request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(machineSet)}
+
+	listMachines := func() []machinev1.Machine {
+		machines := &machinev1.MachineList{}
+		g.Expect(r.Client.List(ctx, machines, client.InNamespace("default"))).To(Succeed())
+		return machines.Items
+	}
+
+	_, err = r.Reconcile(ctx, request)
+	g.Expect(err).ToNot(HaveOccurred())
+	machines := listMachines()
+	g.Expect(machines).To(HaveLen(2))
+	<-recorder.Events
+
+	// Protect the oldest Machine, which the delete policy would otherwise choose
+	protected := machines[0]
+	protected.Annotations = map[string]string{machine.MachineProtectedAnnotation: "true"}
+	protected.CreationTimestamp = metav1.NewTime(machines[1].CreationTimestamp.Add(-time.Hour))
+	g.Expect(r.Client.Update(ctx, &protected)).To(Succeed())
+
+	scaleTo := func(replicas int32) {
+		stored := &machinev1.MachineSet{}
+		g.Expect(r.Client.Get(ctx, request.NamespacedName, stored)).To(Succeed())
+		stored.Generation++
+		stored.Spec.Replicas = ptr.To(replicas)
+		g.Expect(r.Client.Update(ctx, stored)).To(Succeed())
+	}
+
+	// Scaling down deletes the unprotected Machine
+	scaleTo(1)
+	_, err = r.Reconcile(ctx, request)
+	g.Expect(err).ToNot(HaveOccurred())
+	remaining := listMachines()
+	g.Expect(remaining).To(HaveLen(1))
+	g.Expect(remaining[0].Name).To(Equal(protected.Name))
+	g.Expect(recorder.Events).To(HaveLen(1))
+	g.Expect(<-recorder.Events).To(HavePrefix("Normal ScaledDown Deleted Machines " + machines[1].Name))
+
+	// The protected Machine is skipped and reported, rather than failing every reconcile
+	scaleTo(0)
+	_, err = r.Reconcile(ctx, request)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(listMachines()).To(HaveLen(1))
+	g.Expect(recorder.Events).To(HaveLen(1))
+	g.Expect(<-recorder.Events).To(Equal(fmt.Sprintf("Warning ProtectedMachinesSkipped Cannot scale down to 0 replicas, machines %s are protected from deletion by the %s annotation",
+		protected.Name, machine.MachineProtectedAnnotation)))
+}
@@ -21,18 +21,40 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 
 	machinev1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/machine-api-operator/pkg/util/conditions"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
 	// The number of times we retry updating a MachineSet's status.
 	statusUpdateRetries = 1
+
+	// ReadyCondition is True when all the replicas requested by the MachineSet are ready.
+	ReadyCondition machinev1.ConditionType = "Ready"
+
+	// MachinesFailedReason is set on the Ready condition when machines of the MachineSet have failed,
+	// usually because of an error from the infrastructure provider.
+	MachinesFailedReason = "MachinesFailed"
+
+	// ScalingUpReason is set on the Ready condition while machines are missing.
+	ScalingUpReason = "ScalingUp"
+
+	// ScalingDownReason is set on the Ready condition while surplus machines are pending deletion.
+	ScalingDownReason = "ScalingDown"
+
+	// MachinesNotReadyReason is set on the Ready condition while the nodes of machines are not ready yet.
+	MachinesNotReadyReason = "MachinesNotReady"
+
+	// maxFailedMachineNames is the number of failed machines named in the message of the Ready condition.
+	maxFailedMachineNames = 5
 )
 
 func (c *ReconcileMachineSet) calculateStatus(ms *machinev1.MachineSet, filteredMachines []*machinev1.Machine) machinev1.MachineSetStatus {
@@ -67,10 +89,58 @@ func (c *ReconcileMachineSet) calculateStatus(ms *machinev1.MachineSet, filtered
 	newStatus.FullyLabeledReplicas = int32(fullyLabeledReplicasCount)
 	newStatus.ReadyReplicas = int32(readyReplicasCount)
 	newStatus.AvailableReplicas = int32(availableReplicasCount)
-	return newStatus
+
+	// Set the condition on a copy so that the conditions of the given MachineSet are not modified
+	statusHolder := &machinev1.MachineSet{Status: newStatus}
+	statusHolder.Status.Conditions = append([]machinev1.Condition(nil), newStatus.Conditions...)
+	conditions.Set(statusHolder, readyCondition(ms, filteredMachines, newStatus.ReadyReplicas))
+	return statusHolder.Status
+}
+
+// readyCondition returns the Ready condition of the MachineSet, with the reason why it is not ready when it is not.
+func readyCondition(ms *machinev1.MachineSet, filteredMachines []*machinev1.Machine, readyReplicas int32) *machinev1.Condition {
+	var replicas int32
+	if ms.Spec.Replicas != nil {
+		replicas = *ms.Spec.Replicas
+	}
+
+	var failed []string
+	for _, machine := range filteredMachines {
+		if machine.Status.ErrorReason != nil || ptr.Deref(machine.Status.Phase, "") == machinev1.PhaseFailed {
+			failed = append(failed, machine.Name)
+		}
+	}
+
+	current := int32(len(filteredMachines))
+	switch {
+	case len(failed) > 0:
+		return conditions.FalseCondition(ReadyCondition, MachinesFailedReason, machinev1.ConditionSeverityError,
+			"%d of %d machines have failed: %s", len(failed), current, machineNamesMessage(failed, maxFailedMachineNames))
+	case current < replicas:
+		return conditions.FalseCondition(ReadyCondition, ScalingUpReason, machinev1.ConditionSeverityInfo,
+			"Scaling up from %d to %d replicas", current, replicas)
+	case current > replicas:
+		return conditions.FalseCondition(ReadyCondition, ScalingDownReason, machinev1.ConditionSeverityInfo,
+			"Scaling down from %d to %d replicas", current, replicas)
+	case readyReplicas != replicas:
+		return conditions.FalseCondition(ReadyCondition, MachinesNotReadyReason, machinev1.ConditionSeverityInfo,
+			"%d of %d replicas are ready", readyReplicas, replicas)
+	default:
+		return conditions.TrueCondition(ReadyCondition)
+	}
+}
+
+// machineNamesMessage returns the comma separated list of the first max names, followed by the number of names
+// left out, so that the message stays short when many machines are listed.
+func machineNamesMessage(names []string, max int) string {
+	if len(names) <= max {
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(names[:max], ", "), len(names)-max)
 }
 
 // updateMachineSetStatus attempts to update the Status.Replicas of the given MachineSet, with a single GET/PUT retry.
+// The Status.ObservedGeneration is updated to the one of the newStatus.
 func updateMachineSetStatus(c client.Client, ms *machinev1.MachineSet, newStatus machinev1.MachineSetStatus) (*machinev1.MachineSet, error) {
 	// This is the steady state. It happens when the MachineSet doesn't have any expectations, since
 	// we do a periodic relist every 30s. If the generations differ but the replicas are
@@ -80,15 +150,14 @@ func updateMachineSetStatus(c client.Client, ms *machinev1.MachineSet, newStatus
 		ms.Status.ReadyReplicas == newStatus.ReadyReplicas &&
 		ms.Status.AvailableReplicas == newStatus.AvailableReplicas &&
 		reflect.DeepEqual(ms.Status.Conditions, newStatus.Conditions) &&
-		ms.Generation == ms.Status.ObservedGeneration {
+		ms.Status.ObservedGeneration == newStatus.ObservedGeneration {
 		return ms, nil
 	}
 
-	// Save the generation number we acted on, otherwise we might wrongfully indicate
-	// that we've seen a spec update when we retry.
+	// The generation number acted on is set by the caller, only once the spec has been successfully reconciled,
+	// otherwise we might wrongfully indicate that we've seen a spec update when we retry.
 	// TODO: This can clobber an update if we allow multiple agents to write to the
 	// same status.
-	newStatus.ObservedGeneration = ms.Generation
 
 	var getErr, updateErr error
 	for i := 0; ; i++ {
@@ -0,0 +1,274 @@
+package machineset
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/machine-api-operator/pkg/util/conditions"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCalculateStatusReadyCondition(t *testing.T) {
+	readyMachine := func(name string) (*machinev1.Machine, *corev1.Node) {
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+			},
+		}
+		machine := &machinev1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Status: machinev1.MachineStatus{
+				NodeRef: &corev1.ObjectReference{Name: name},
+			},
+		}
+		return machine, node
+	}
+
+	readyMachines := func(count int) ([]*machinev1.Machine, []runtime.Object) {
+		var machines []*machinev1.Machine
+		var nodes []runtime.Object
+		for i := 0; i < count; i++ {
+			machine, node := readyMachine(fmt.Sprintf("machine-%d", i))
+			machines = append(machines, machine)
+			nodes = append(nodes, node)
+		}
+		return machines, nodes
+	}
+
+	testCases := []struct {
+		name            string
+		replicas        int32
+		machines        func() ([]*machinev1.Machine, []runtime.Object)
+		expectedStatus  corev1.ConditionStatus
+		expectedReason  string
+		expectedMessage string
+	}{
+		{
+			name:     "with all replicas ready",
+			replicas: 2,
+			machines: func() ([]*machinev1.Machine, []runtime.Object) {
+				return readyMachines(2)
+			},
+			expectedStatus: corev1.ConditionTrue,
+		},
+		{
+			name:     "with no replicas",
+			replicas: 0,
+			machines: func() ([]*machinev1.Machine, []runtime.Object) {
+				return nil, nil
+			},
+			expectedStatus: corev1.ConditionTrue,
+		},
+		{
+			name:     "when scaling up",
+			replicas: 3,
+			machines: func() ([]*machinev1.Machine, []runtime.Object) {
+				return readyMachines(2)
+			},
+			expectedStatus: corev1.ConditionFalse,
+			expectedReason: ScalingUpReason,
+		},
+		{
+			name:     "when scaling down",
+			replicas: 1,
+			machines: func() ([]*machinev1.Machine, []runtime.Object) {
+				return readyMachines(2)
+			},
+			expectedStatus: corev1.ConditionFalse,
+			expectedReason: ScalingDownReason,
+		},
+		{
+			name:     "with a machine without a node",
+			replicas: 2,
+			machines: func() ([]*machinev1.Machine, []runtime.Object) {
+				machines, nodes := readyMachines(2)
+				machines[1].Status.NodeRef = nil
+				return machines, nodes
+			},
+			expectedStatus: corev1.ConditionFalse,
+			expectedReason: MachinesNotReadyReason,
+		},
+		{
+			name:     "with a failed machine",
+			replicas: 2,
+			machines: func() ([]*machinev1.Machine, []runtime.Object) {
+				machines, nodes := readyMachines(2)
+				machines[1].Status.NodeRef = nil
+				machines[1].Status.Phase = ptr.To[string](machinev1.PhaseFailed)
+				return machines, nodes
+			},
+			expectedStatus:  corev1.ConditionFalse,
+			expectedReason:  MachinesFailedReason,
+			expectedMessage: "1 of 2 machines have failed: machine-1",
+		},
+		{
+			name:     "with a provider error",
+			replicas: 3,
+			machines: func() ([]*machinev1.Machine, []runtime.Object) {
+				machines, nodes := readyMachines(2)
+				machines[0].Status.ErrorReason = ptr.To[machinev1.MachineStatusError](machinev1.InvalidConfigurationMachineError)
+				return machines, nodes
+			},
+			expectedStatus:  corev1.ConditionFalse,
+			expectedReason:  MachinesFailedReason,
+			expectedMessage: "1 of 2 machines have failed: machine-0",
+		},
+		{
+			name:     "with many failed machines",
+			replicas: 8,
+			machines: func() ([]*machinev1.Machine, []runtime.Object) {
+				machines, nodes := readyMachines(8)
+				for _, machine := range machines {
+					machine.Status.Phase = ptr.To[string](machinev1.PhaseFailed)
+				}
+				return machines, nodes
+			},
+			expectedStatus:  corev1.ConditionFalse,
+			expectedReason:  MachinesFailedReason,
+			expectedMessage: "8 of 8 machines have failed: machine-0, machine-1, machine-2, machine-3, machine-4 and 3 more",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			machines, nodes := tc.machines()
+			ms := &machinev1.MachineSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "machineset", Namespace: "default"},
+				Spec: machinev1.MachineSetSpec{
+					Replicas: ptr.To[int32](tc.replicas),
+				},
+			}
+
+			r := &ReconcileMachineSet{
+				Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(nodes...).Build(),
+				scheme: scheme.Scheme,
+			}
+
+			status := r.calculateStatus(ms, machines)
+			g.Expect(ms.Status.Conditions).To(BeEmpty())
+
+			condition := conditions.Get(&machinev1.MachineSet{Status: status}, ReadyCondition)
+			g.Expect(condition).ToNot(BeNil())
+			g.Expect(condition.Status).To(Equal(tc.expectedStatus))
+			g.Expect(condition.Reason).To(Equal(tc.expectedReason))
+			if tc.expectedMessage != "" {
+				g.Expect(condition.Message).To(Equal(tc.expectedMessage))
+			}
+		})
+	}
+}
+
+func TestReadyConditionTransitions(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	newMachine := func(name string) *machinev1.Machine {
+		return &machinev1.Machine{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Status: machinev1.MachineStatus{
+				NodeRef: &corev1.ObjectReference{Name: name},
+			},
+		}
+	}
+	newNode := func(name string, ready corev1.ConditionStatus) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.NodeStatus{
+				Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}},
+			},
+		}
+	}
+
+	ms := &machinev1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "machineset", Namespace: "default"},
+		Spec: machinev1.MachineSetSpec{
+			Replicas: ptr.To[int32](2),
+		},
+	}
+
+	r := &ReconcileMachineSet{
+		Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(ms, newNode("machine-0", corev1.ConditionTrue)).WithStatusSubresource(&machinev1.MachineSet{}).Build(),
+		scheme: scheme.Scheme,
+	}
+
+	steps := []struct {
+		name           string
+		update         func() []*machinev1.Machine
+		expectedStatus corev1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			name: "with a missing machine",
+			update: func() []*machinev1.Machine {
+				return []*machinev1.Machine{newMachine("machine-0")}
+			},
+			expectedStatus: corev1.ConditionFalse,
+			expectedReason: ScalingUpReason,
+		},
+		{
+			name: "with a machine whose node is not ready",
+			update: func() []*machinev1.Machine {
+				g.Expect(r.Client.Create(ctx, newNode("machine-1", corev1.ConditionFalse))).To(Succeed())
+				return []*machinev1.Machine{newMachine("machine-0"), newMachine("machine-1")}
+			},
+			expectedStatus: corev1.ConditionFalse,
+			expectedReason: MachinesNotReadyReason,
+		},
+		{
+			name: "with all nodes ready",
+			update: func() []*machinev1.Machine {
+				node := &corev1.Node{}
+				g.Expect(r.Client.Get(ctx, client.ObjectKey{Name: "machine-1"}, node)).To(Succeed())
+				node.Status.Conditions[0].Status = corev1.ConditionTrue
+				g.Expect(r.Client.Status().Update(ctx, node)).To(Succeed())
+				return []*machinev1.Machine{newMachine("machine-0"), newMachine("machine-1")}
+			},
+			expectedStatus: corev1.ConditionTrue,
+		},
+		{
+			name: "with fewer replicas requested",
+			update: func() []*machinev1.Machine {
+				ms.Spec.Replicas = ptr.To[int32](1)
+				g.Expect(r.Client.Update(ctx, ms)).To(Succeed())
+				return []*machinev1.Machine{newMachine("machine-0"), newMachine("machine-1")}
+			},
+			expectedStatus: corev1.ConditionFalse,
+			expectedReason: ScalingDownReason,
+		},
+		{
+			name: "once the surplus machine is deleted",
+			update: func() []*machinev1.Machine {
+				return []*machinev1.Machine{newMachine("machine-0")}
+			},
+			expectedStatus: corev1.ConditionTrue,
+		},
+	}
+
+	for _, step := range steps {
+		machines := step.update()
+
+		var err error
+		ms, err = updateMachineSetStatus(r.Client, ms, r.calculateStatus(ms, machines))
+		g.Expect(err).ToNot(HaveOccurred(), step.name)
+
+		stored := &machinev1.MachineSet{}
+		g.Expect(r.Client.Get(ctx, client.ObjectKeyFromObject(ms), stored)).To(Succeed(), step.name)
+
+		condition := conditions.Get(stored, ReadyCondition)
+		g.Expect(condition).ToNot(BeNil(), step.name)
+		g.Expect(condition.Status).To(Equal(step.expectedStatus), step.name)
+		g.Expect(condition.Reason).To(Equal(step.expectedReason), step.name)
+	}
+}
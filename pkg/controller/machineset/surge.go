@@ -0,0 +1,138 @@
+package machineset
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strconv"
+
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// SurgeAnnotation opts a MachineSet into surge replacements. Its value is the maximum number of
+	// replacement Machines created at a time, on top of the replicas, for the Machines marked with the
+	// ReplaceMachineAnnotation. Each marked Machine is only deleted once its replacement is Running,
+	// so that the running capacity never drops below the replicas while Machines are replaced.
+	SurgeAnnotation = "machine.openshift.io/surge"
+
+	// ReplaceMachineAnnotation marks the Machines of a MachineSet with the SurgeAnnotation which must be
+	// replaced by a new Machine before being deleted.
+	ReplaceMachineAnnotation = "machine.openshift.io/replace-machine"
+
+	// SurgeMachineLabel is set on the replacement Machines created on top of the replicas. Its value is the
+	// UID of the replaced Machine. The label is removed once the replaced Machine is deleted, at which point
+	// the replacement counts towards the replicas.
+	SurgeMachineLabel = "machine.openshift.io/surge-replacement-for"
+)
+
+// surgeCount returns the maximum number of surge Machines of the MachineSet, 0 when surge replacements are disabled.
+// Invalid annotation values disable surge replacements.
+func surgeCount(machineSet *machinev1.MachineSet) int {
+	value, ok := machineSet.Annotations[SurgeAnnotation]
+	if !ok {
+		return 0
+	}
+
+	surge, err := strconv.Atoi(value)
+	if err != nil || surge < 0 {
+		klog.Warningf("%v: invalid %s annotation %q, expected a non negative integer. Surge replacements are disabled",
+			machineSet.Name, SurgeAnnotation, value)
+		return 0
+	}
+	return surge
+}
+
+// reconcileSurge creates the replacement Machines of the Machines marked with the ReplaceMachineAnnotation,
+// and deletes the replaced Machines once their replacement is Running. It returns the Machines which count
+// towards the replicas, that is all the Machines but the replacements of Machines which are not deleted yet.
+func (r *ReconcileMachineSet) reconcileSurge(ctx context.Context, ms *machinev1.MachineSet, machines []*machinev1.Machine) ([]*machinev1.Machine, error) {
+	machinesByUID := make(map[string]*machinev1.Machine, len(machines))
+	for _, machine := range machines {
+		machinesByUID[string(machine.UID)] = machine
+	}
+
+	var active, surging []*machinev1.Machine
+	replaced, deleted := map[string]bool{}, map[string]bool{}
+	for _, machine := range machines {
+		replacedUID, ok := machine.Labels[SurgeMachineLabel]
+		if !ok {
+			active = append(active, machine)
+			continue
+		}
+
+		// The replaced Machine is only deleted once its replacement is Running, and the replacement then
+		// takes over. It also takes over when the replaced Machine was deleted by other means.
+		replacedMachine := machinesByUID[replacedUID]
+		if replacedMachine != nil && ptr.Deref(machine.Status.Phase, "") == machinev1.PhaseRunning {
+			klog.Infof("%v: replacement Machine %v is running, deleting replaced Machine %v", ms.Name, machine.Name, replacedMachine.Name)
+			if err := r.Client.Delete(ctx, replacedMachine); err != nil && !apierrors.IsNotFound(err) {
+				return nil, fmt.Errorf("failed to delete replaced Machine %q: %w", replacedMachine.Name, err)
+			}
+			if err := r.waitForMachineDeletion([]*machinev1.Machine{replacedMachine}); err != nil {
+				return nil, err
+			}
+			deleted[replacedUID] = true
+			replacedMachine = nil
+		}
+		if replacedMachine == nil {
+			patchBase := client.MergeFrom(machine.DeepCopy())
+			delete(machine.Labels, SurgeMachineLabel)
+			if err := r.Client.Patch(ctx, machine, patchBase); err != nil {
+				return nil, fmt.Errorf("failed to remove surge label of Machine %q: %w", machine.Name, err)
+			}
+			active = append(active, machine)
+			continue
+		}
+
+		surging = append(surging, machine)
+		replaced[replacedUID] = true
+	}
+
+	// The replaced Machines deleted above no longer count towards the replicas
+	if len(deleted) > 0 {
+		var remaining []*machinev1.Machine
+		for _, machine := range active {
+			if !deleted[string(machine.UID)] {
+				remaining = append(remaining, machine)
+			}
+		}
+		active = remaining
+	}
+
+	// Do not surge while scaling down, the Machines are deleted anyway
+	surge := surgeCount(ms)
+	if surge == 0 || len(active) > int(ptr.Deref(ms.Spec.Replicas, 0)) {
+		return active, nil
+	}
+
+	var created []*machinev1.Machine
+	for _, machine := range active {
+		if len(surging)+len(created) >= surge {
+			break
+		}
+		if _, ok := machine.Annotations[ReplaceMachineAnnotation]; !ok || replaced[string(machine.UID)] {
+			continue
+		}
+
+		replacement := r.createMachine(ms)
+		replacement.Labels = make(map[string]string, len(ms.Spec.Template.Labels)+1)
+		maps.Copy(replacement.Labels, ms.Spec.Template.Labels)
+		replacement.Labels[SurgeMachineLabel] = string(machine.UID)
+
+		klog.Infof("%v: creating a replacement for Machine %v", ms.Name, machine.Name)
+		if err := r.Client.Create(ctx, replacement); err != nil {
+			return nil, fmt.Errorf("failed to create replacement of Machine %q: %w", machine.Name, err)
+		}
+		created = append(created, replacement)
+	}
+
+	if err := r.waitForMachineCreation(created); err != nil {
+		return nil, err
+	}
+	return active, nil
+}
@@ -0,0 +1,231 @@
+package machineset
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	testutils "github.com/openshift/machine-api-operator/pkg/util/testing"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestSurgeCount(t *testing.T) {
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expected    int
+	}{
+		{
+			name:     "without annotation",
+			expected: 0,
+		},
+		{
+			name:        "with a valid annotation",
+			annotations: map[string]string{SurgeAnnotation: "2"},
+			expected:    2,
+		},
+		{
+			name:        "with a non numeric annotation",
+			annotations: map[string]string{SurgeAnnotation: "two"},
+			expected:    0,
+		},
+		{
+			name:        "with a negative annotation",
+			annotations: map[string]string{SurgeAnnotation: "-1"},
+			expected:    0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			machineSet := &machinev1.MachineSet{ObjectMeta: metav1.ObjectMeta{Name: "machineset", Annotations: tc.annotations}}
+			g.Expect(surgeCount(machineSet)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestReconcileSurge(t *testing.T) {
+	newMachineSet := func(replicas int32, annotations map[string]string) *machinev1.MachineSet {
+		return &machinev1.MachineSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "machineset",
+				Namespace:   "default",
+				UID:         "uid",
+				Annotations: annotations,
+			},
+			Spec: machinev1.MachineSetSpec{
+				Replicas: ptr.To(replicas),
+				Selector: metav1.LabelSelector{
+					MatchLabels: map[string]string{"foo": "bar"},
+				},
+				Template: machinev1.MachineTemplateSpec{
+					ObjectMeta: machinev1.ObjectMeta{
+						Labels: map[string]string{"foo": "bar"},
+					},
+				},
+			},
+		}
+	}
+	newMachine := func(machineSet *machinev1.MachineSet, name string, replace bool) *machinev1.Machine {
+		machine := &machinev1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				Namespace:       "default",
+				UID:             types.UID(name + "-uid"),
+				Labels:          map[string]string{"foo": "bar"},
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(machineSet, controllerKind)},
+			},
+			Status: machinev1.MachineStatus{
+				Phase: ptr.To(machinev1.PhaseRunning),
+			},
+		}
+		if replace {
+			machine.Annotations = map[string]string{ReplaceMachineAnnotation: ""}
+		}
+		return machine
+	}
+
+	newReconciler := func(g *WithT, objects ...client.Object) *ReconcileMachineSet {
+		gate, err := testutils.NewDefaultMutableFeatureGate()
+		g.Expect(err).ToNot(HaveOccurred())
+
+		return &ReconcileMachineSet{
+			Client:   fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objects...).WithStatusSubresource(&machinev1.MachineSet{}, &machinev1.Machine{}).Build(),
+			scheme:   scheme.Scheme,
+			recorder: record.NewFakeRecorder(10),
+			gate:     gate,
+		}
+	}
+	reconcileMachineSet := func(g *WithT, r *ReconcileMachineSet, machineSet *machinev1.MachineSet) {
+		_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(machineSet)})
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+	// listMachines returns the names of the Machines and the names of the Machines they replace, if any
+	listMachines := func(g *WithT, r *ReconcileMachineSet) (map[string]string, []*machinev1.Machine) {
+		machines := &machinev1.MachineList{}
+		g.Expect(r.Client.List(context.Background(), machines)).To(Succeed())
+
+		names := map[string]string{}
+		var replacements []*machinev1.Machine
+		for i := range machines.Items {
+			machine := &machines.Items[i]
+			replaced, ok := machine.Labels[SurgeMachineLabel]
+			if ok {
+				replacements = append(replacements, machine)
+			}
+			names[machine.Name] = replaced
+		}
+		return names, replacements
+	}
+	setRunning := func(g *WithT, r *ReconcileMachineSet, machine *machinev1.Machine) {
+		machine.Status.Phase = ptr.To(machinev1.PhaseRunning)
+		g.Expect(r.Client.Status().Update(context.Background(), machine)).To(Succeed())
+	}
+
+	t.Run("without surge annotation, marked machines are not replaced", func(t *testing.T) {
+		g := NewWithT(t)
+
+		machineSet := newMachineSet(3, nil)
+		r := newReconciler(g, machineSet, newMachine(machineSet, "a", true), newMachine(machineSet, "b", false), newMachine(machineSet, "c", false))
+		reconcileMachineSet(g, r, machineSet)
+
+		names, replacements := listMachines(g, r)
+		g.Expect(names).To(Equal(map[string]string{"a": "", "b": "", "c": ""}))
+		g.Expect(replacements).To(BeEmpty())
+	})
+
+	t.Run("with surge annotation, marked machines are replaced before being deleted", func(t *testing.T) {
+		g := NewWithT(t)
+
+		machineSet := newMachineSet(3, map[string]string{SurgeAnnotation: "1"})
+		r := newReconciler(g, machineSet, newMachine(machineSet, "a", true), newMachine(machineSet, "b", true), newMachine(machineSet, "c", false))
+
+		// Surge up: a replacement of the first marked Machine is created on top of the replicas
+		reconcileMachineSet(g, r, machineSet)
+		names, replacements := listMachines(g, r)
+		g.Expect(names).To(HaveLen(4))
+		g.Expect(names).To(HaveKeyWithValue("a", ""))
+		g.Expect(names).To(HaveKeyWithValue("b", ""))
+		g.Expect(replacements).To(HaveLen(1))
+		g.Expect(replacements[0].Labels).To(HaveKeyWithValue(SurgeMachineLabel, "a-uid"))
+
+		// Nothing changes until the replacement is Running
+		reconcileMachineSet(g, r, machineSet)
+		names, _ = listMachines(g, r)
+		g.Expect(names).To(HaveLen(4))
+		g.Expect(names).To(HaveKey("a"))
+
+		// Once Running, the replaced Machine is deleted and the next marked Machine is replaced
+		setRunning(g, r, replacements[0])
+		reconcileMachineSet(g, r, machineSet)
+		names, replacements = listMachines(g, r)
+		g.Expect(names).To(HaveLen(4))
+		g.Expect(names).ToNot(HaveKey("a"))
+		g.Expect(replacements).To(HaveLen(1))
+		g.Expect(replacements[0].Labels).To(HaveKeyWithValue(SurgeMachineLabel, "b-uid"))
+
+		// Converge down to the replicas once all marked Machines are replaced
+		setRunning(g, r, replacements[0])
+		reconcileMachineSet(g, r, machineSet)
+		names, replacements = listMachines(g, r)
+		g.Expect(names).To(HaveLen(3))
+		g.Expect(names).ToNot(HaveKey("a"))
+		g.Expect(names).ToNot(HaveKey("b"))
+		g.Expect(names).To(HaveKey("c"))
+		g.Expect(replacements).To(BeEmpty())
+
+		// And stay there
+		reconcileMachineSet(g, r, machineSet)
+		names, _ = listMachines(g, r)
+		g.Expect(names).To(HaveLen(3))
+	})
+
+	t.Run("with surge annotation, several machines are replaced at a time", func(t *testing.T) {
+		g := NewWithT(t)
+
+		machineSet := newMachineSet(3, map[string]string{SurgeAnnotation: "2"})
+		r := newReconciler(g, machineSet, newMachine(machineSet, "a", true), newMachine(machineSet, "b", true), newMachine(machineSet, "c", true))
+		reconcileMachineSet(g, r, machineSet)
+
+		names, replacements := listMachines(g, r)
+		g.Expect(names).To(HaveLen(5))
+		g.Expect(replacements).To(HaveLen(2))
+	})
+
+	t.Run("with surge annotation, the replacement takes over when the replaced machine is deleted", func(t *testing.T) {
+		g := NewWithT(t)
+
+		machineSet := newMachineSet(2, map[string]string{SurgeAnnotation: "1"})
+		replacement := newMachine(machineSet, "replacement", false)
+		replacement.Labels[SurgeMachineLabel] = "a-uid"
+		replacement.Status.Phase = ptr.To(machinev1.PhaseProvisioning)
+		r := newReconciler(g, machineSet, newMachine(machineSet, "b", false), replacement)
+		reconcileMachineSet(g, r, machineSet)
+
+		names, replacements := listMachines(g, r)
+		g.Expect(names).To(Equal(map[string]string{"b": "", "replacement": ""}))
+		g.Expect(replacements).To(BeEmpty())
+	})
+
+	t.Run("with surge annotation, marked machines are not replaced while scaling down", func(t *testing.T) {
+		g := NewWithT(t)
+
+		machineSet := newMachineSet(2, map[string]string{SurgeAnnotation: "1"})
+		r := newReconciler(g, machineSet, newMachine(machineSet, "a", true), newMachine(machineSet, "b", false), newMachine(machineSet, "c", false))
+		reconcileMachineSet(g, r, machineSet)
+
+		names, replacements := listMachines(g, r)
+		g.Expect(names).To(HaveLen(2))
+		g.Expect(replacements).To(BeEmpty())
+	})
+}
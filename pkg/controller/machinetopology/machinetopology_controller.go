@@ -0,0 +1,231 @@
+package machinetopology
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	mapicontroller "github.com/openshift/machine-api-operator/pkg/controller"
+	"github.com/openshift/machine-api-operator/pkg/controller/machine"
+	"github.com/openshift/machine-api-operator/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const controllerName = "machine-topology-reporter"
+
+// blank assignment to verify that ReconcileMachineTopology implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileMachineTopology{}
+
+// ReconcileMachineTopology reports Machines whose region or zone does not match the placement
+// requested by the providerSpec of their MachineSet. It is report only and never modifies Machines.
+type ReconcileMachineTopology struct {
+	client client.Client
+}
+
+// Options are the options of the Machine topology reporter.
+type Options struct {
+	// ClusterPauseNamespace is the namespace of the machine-api-operator, in which it creates the
+	// ClusterPauseConfigMapName ConfigMap to pause the reporter.
+	ClusterPauseNamespace string
+}
+
+// Add creates a new Machine topology reporter and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager, opts manager.Options) error {
+	return AddWithOptions(Options{ClusterPauseNamespace: mapicontroller.DefaultClusterPauseNamespace})(mgr, opts)
+}
+
+// AddWithOptions returns a function which creates a new Machine topology reporter with the given options,
+// and adds it to the Manager.
+func AddWithOptions(topologyOpts Options) func(manager.Manager, manager.Options) error {
+	return func(mgr manager.Manager, _ manager.Options) error {
+		return add(mgr, topologyOpts.ClusterPauseNamespace)
+	}
+}
+
+// add adds a new Machine topology reporter to mgr.
+func add(mgr manager.Manager, clusterPauseNamespace string) error {
+	r := &ReconcileMachineTopology{client: mgr.GetClient()}
+
+	gated := mapicontroller.WithClusterPause(controllerName, mgr.GetClient(), clusterPauseNamespace, r, nil)
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: gated})
+	if err != nil {
+		return err
+	}
+
+	if err := c.Watch(
+		source.Kind(mgr.GetCache(), &machinev1.Machine{},
+			&handler.TypedEnqueueRequestForObject[*machinev1.Machine]{},
+		)); err != nil {
+		return err
+	}
+
+	// The expected topology comes from the MachineSet, so its Machines are re-evaluated when it changes
+	if err := c.Watch(
+		source.Kind(mgr.GetCache(), &machinev1.MachineSet{},
+			handler.TypedEnqueueRequestsFromMapFunc[*machinev1.MachineSet](r.machineSetToMachines),
+		)); err != nil {
+		return err
+	}
+
+	// Reconcile all the Machines again when the cluster pause ends
+	return c.Watch(mapicontroller.ClusterPauseSource(mgr, clusterPauseNamespace, func() client.ObjectList { return &machinev1.MachineList{} }))
+}
+
+// Reconcile compares the region and zone labels of a Machine with the providerSpec of its MachineSet
+// and records the result in the mapi_machine_topology_mismatch metric.
+func (r *ReconcileMachineTopology) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	klog.V(3).Infof("Reconciling topology of Machine %s", request.NamespacedName)
+
+	m := &machinev1.Machine{}
+	if err := r.client.Get(ctx, request.NamespacedName, m); err != nil {
+		if apierrors.IsNotFound(err) {
+			deleteMismatchMetric(request.Name, request.Namespace)
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	if m.GetDeletionTimestamp() != nil {
+		deleteMismatchMetric(m.GetName(), m.GetNamespace())
+		return reconcile.Result{}, nil
+	}
+
+	ref := metav1.GetControllerOf(m)
+	if ref == nil || ref.Kind != "MachineSet" {
+		deleteMismatchMetric(m.GetName(), m.GetNamespace())
+		return reconcile.Result{}, nil
+	}
+
+	ms := &machinev1.MachineSet{}
+	if err := r.client.Get(ctx, client.ObjectKey{Namespace: m.GetNamespace(), Name: ref.Name}, ms); err != nil {
+		if apierrors.IsNotFound(err) {
+			deleteMismatchMetric(m.GetName(), m.GetNamespace())
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	expected, err := topologyFromProviderSpec(ms.Spec.Template.Spec.ProviderSpec.Value)
+	if err != nil {
+		// Retrying will not help until the MachineSet changes, which triggers a new reconcile
+		klog.Warningf("%s: unable to read expected topology from MachineSet %s: %v", m.GetName(), ms.GetName(), err)
+		deleteMismatchMetric(m.GetName(), m.GetNamespace())
+		return reconcile.Result{}, nil
+	}
+
+	actual := topology{
+		Region: m.GetLabels()[machine.MachineRegionLabelName],
+		Zone:   m.GetLabels()[machine.MachineAZLabelName],
+	}
+
+	mismatch := 0.0
+	if diff := expected.mismatch(actual); diff != "" {
+		klog.Warningf("%s: placement does not match MachineSet %s: %s", m.GetName(), ms.GetName(), diff)
+		mismatch = 1
+	}
+
+	deleteMismatchMetric(m.GetName(), m.GetNamespace())
+	metrics.MachineTopologyMismatch.With(prometheus.Labels{
+		"name":       m.GetName(),
+		"namespace":  m.GetNamespace(),
+		"machineset": ms.GetName(),
+	}).Set(mismatch)
+
+	return reconcile.Result{}, nil
+}
+
+// machineSetToMachines maps a MachineSet to the Machines it controls.
+func (r *ReconcileMachineTopology) machineSetToMachines(ctx context.Context, ms *machinev1.MachineSet) []reconcile.Request {
+	machines := &machinev1.MachineList{}
+	if err := r.client.List(ctx, machines, client.InNamespace(ms.GetNamespace()), client.MatchingLabels(ms.Spec.Selector.MatchLabels)); err != nil {
+		klog.Errorf("No-op: Unable to list machines for MachineSet %s: %v", ms.GetName(), err)
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, m := range machines.Items {
+		if metav1.IsControlledBy(&m, ms) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&m)})
+		}
+	}
+	return requests
+}
+
+// deleteMismatchMetric removes the series of a Machine, whichever MachineSet it was reported against.
+func deleteMismatchMetric(name, namespace string) {
+	metrics.MachineTopologyMismatch.DeletePartialMatch(prometheus.Labels{
+		"name":      name,
+		"namespace": namespace,
+	})
+}
+
+// topology is the region and zone of a Machine.
+// Empty values are unknown and are not compared.
+type topology struct {
+	Region string
+	Zone   string
+}
+
+// mismatch returns a description of the differences between the expected and the actual topology,
+// or an empty string when they are consistent.
+func (expected topology) mismatch(actual topology) string {
+	var diff string
+	if expected.Region != "" && actual.Region != "" && expected.Region != actual.Region {
+		diff = fmt.Sprintf("region is %q, expected %q", actual.Region, expected.Region)
+	}
+	if expected.Zone != "" && actual.Zone != "" && expected.Zone != actual.Zone {
+		if diff != "" {
+			diff += ", "
+		}
+		diff += fmt.Sprintf("zone is %q, expected %q", actual.Zone, expected.Zone)
+	}
+	return diff
+}
+
+// providerSpecTopology holds the providerSpec fields describing the placement on each provider.
+type providerSpecTopology struct {
+	// Placement is used by AWS.
+	Placement struct {
+		Region           string `json:"region,omitempty"`
+		AvailabilityZone string `json:"availabilityZone,omitempty"`
+	} `json:"placement,omitempty"`
+	// Region is used by GCP.
+	Region string `json:"region,omitempty"`
+	// Location is used by Azure.
+	Location string `json:"location,omitempty"`
+	// Zone is used by GCP and Azure.
+	Zone string `json:"zone,omitempty"`
+}
+
+// topologyFromProviderSpec returns the region and zone requested by a providerSpec.
+func topologyFromProviderSpec(providerSpec *runtime.RawExtension) (topology, error) {
+	if providerSpec == nil || providerSpec.Raw == nil {
+		return topology{}, nil
+	}
+
+	fields := &providerSpecTopology{}
+	if err := json.Unmarshal(providerSpec.Raw, fields); err != nil {
+		return topology{}, fmt.Errorf("failed to unmarshal providerSpec: %w", err)
+	}
+
+	switch {
+	case fields.Placement.Region != "" || fields.Placement.AvailabilityZone != "":
+		return topology{Region: fields.Placement.Region, Zone: fields.Placement.AvailabilityZone}, nil
+	case fields.Location != "":
+		return topology{Region: fields.Location, Zone: fields.Zone}, nil
+	default:
+		return topology{Region: fields.Region, Zone: fields.Zone}, nil
+	}
+}
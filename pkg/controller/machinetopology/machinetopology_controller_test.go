@@ -0,0 +1,218 @@
+package machinetopology
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/machine-api-operator/pkg/controller/machine"
+	"github.com/openshift/machine-api-operator/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const namespace = "openshift-machine-api"
+
+func init() {
+	// Add types to scheme
+	if err := machinev1.Install(scheme.Scheme); err != nil {
+		panic(err)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	awsMachineSet := newMachineSet("aws", `{"placement":{"region":"us-east-1","availabilityZone":"us-east-1a"}}`)
+	gcpMachineSet := newMachineSet("gcp", `{"region":"us-central1","zone":"us-central1-a"}`)
+	azureMachineSet := newMachineSet("azure", `{"location":"centralus","zone":"1"}`)
+
+	testCases := []struct {
+		name           string
+		machineSet     *machinev1.MachineSet
+		machine        *machinev1.Machine
+		expectedMetric *float64
+	}{
+		{
+			name:           "with a consistent AWS placement",
+			machineSet:     awsMachineSet,
+			machine:        newMachine("machine", awsMachineSet, "us-east-1", "us-east-1a"),
+			expectedMetric: ptr.To[float64](0),
+		},
+		{
+			name:           "with an AWS machine in another zone",
+			machineSet:     awsMachineSet,
+			machine:        newMachine("machine", awsMachineSet, "us-east-1", "us-east-1b"),
+			expectedMetric: ptr.To[float64](1),
+		},
+		{
+			name:           "with a consistent GCP placement",
+			machineSet:     gcpMachineSet,
+			machine:        newMachine("machine", gcpMachineSet, "us-central1", "us-central1-a"),
+			expectedMetric: ptr.To[float64](0),
+		},
+		{
+			name:           "with a GCP machine in another region",
+			machineSet:     gcpMachineSet,
+			machine:        newMachine("machine", gcpMachineSet, "europe-west1", "europe-west1-b"),
+			expectedMetric: ptr.To[float64](1),
+		},
+		{
+			name:           "with a consistent Azure placement",
+			machineSet:     azureMachineSet,
+			machine:        newMachine("machine", azureMachineSet, "centralus", "1"),
+			expectedMetric: ptr.To[float64](0),
+		},
+		{
+			name:           "with an Azure machine in another zone",
+			machineSet:     azureMachineSet,
+			machine:        newMachine("machine", azureMachineSet, "centralus", "2"),
+			expectedMetric: ptr.To[float64](1),
+		},
+		{
+			name:           "with a machine which has not reported its placement yet",
+			machineSet:     awsMachineSet,
+			machine:        newMachine("machine", awsMachineSet, "", ""),
+			expectedMetric: ptr.To[float64](0),
+		},
+		{
+			name:           "with a machine which is not owned by a machineset",
+			machineSet:     awsMachineSet,
+			machine:        newMachine("machine", nil, "eu-west-1", "eu-west-1a"),
+			expectedMetric: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			defer metrics.MachineTopologyMismatch.Reset()
+
+			r := &ReconcileMachineTopology{
+				client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(tc.machineSet, tc.machine).Build(),
+			}
+
+			_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(tc.machine)})
+			g.Expect(err).ToNot(HaveOccurred())
+
+			value, found := mismatchMetricValue(g, tc.machine.GetName())
+			if tc.expectedMetric == nil {
+				g.Expect(found).To(BeFalse())
+				return
+			}
+			g.Expect(found).To(BeTrue())
+			g.Expect(value).To(Equal(*tc.expectedMetric))
+
+			// The reporter must never modify the machine
+			got := &machinev1.Machine{}
+			g.Expect(r.client.Get(context.Background(), client.ObjectKeyFromObject(tc.machine), got)).To(Succeed())
+			g.Expect(got.GetLabels()).To(Equal(tc.machine.GetLabels()))
+		})
+	}
+}
+
+func TestReconcileDeletedMachine(t *testing.T) {
+	g := NewWithT(t)
+	defer metrics.MachineTopologyMismatch.Reset()
+
+	machineSet := newMachineSet("aws", `{"placement":{"region":"us-east-1","availabilityZone":"us-east-1a"}}`)
+	m := newMachine("machine", machineSet, "us-east-1", "us-east-1b")
+	r := &ReconcileMachineTopology{
+		client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(machineSet, m).Build(),
+	}
+
+	_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(m)})
+	g.Expect(err).ToNot(HaveOccurred())
+	_, found := mismatchMetricValue(g, m.GetName())
+	g.Expect(found).To(BeTrue())
+
+	g.Expect(r.client.Delete(context.Background(), m)).To(Succeed())
+	_, err = r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(m)})
+	g.Expect(err).ToNot(HaveOccurred())
+	_, found = mismatchMetricValue(g, m.GetName())
+	g.Expect(found).To(BeFalse())
+}
+
+func TestMachineSetToMachines(t *testing.T) {
+	g := NewWithT(t)
+
+	machineSet := newMachineSet("aws", `{}`)
+	otherMachineSet := newMachineSet("other", `{}`)
+	otherMachineSet.UID = "other-uid"
+	r := &ReconcileMachineTopology{
+		client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(
+			newMachine("owned", machineSet, "", ""),
+			newMachine("owned-by-other", otherMachineSet, "", ""),
+			newMachine("orphan", nil, "", ""),
+		).Build(),
+	}
+
+	requests := r.machineSetToMachines(context.Background(), machineSet)
+	g.Expect(requests).To(ConsistOf(reconcile.Request{NamespacedName: client.ObjectKey{Namespace: namespace, Name: "owned"}}))
+}
+
+func newMachineSet(name, providerSpec string) *machinev1.MachineSet {
+	return &machinev1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			UID:       "uid",
+		},
+		Spec: machinev1.MachineSetSpec{
+			Selector: metav1.LabelSelector{
+				MatchLabels: map[string]string{"foo": "bar"},
+			},
+			Template: machinev1.MachineTemplateSpec{
+				Spec: machinev1.MachineSpec{
+					ProviderSpec: machinev1.ProviderSpec{
+						Value: &runtime.RawExtension{Raw: []byte(providerSpec)},
+					},
+				},
+			},
+		},
+	}
+}
+
+func newMachine(name string, owner *machinev1.MachineSet, region, zone string) *machinev1.Machine {
+	m := &machinev1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"foo": "bar"},
+		},
+	}
+	if region != "" {
+		m.Labels[machine.MachineRegionLabelName] = region
+	}
+	if zone != "" {
+		m.Labels[machine.MachineAZLabelName] = zone
+	}
+	if owner != nil {
+		m.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(owner, machinev1.SchemeGroupVersion.WithKind("MachineSet"))}
+	}
+	return m
+}
+
+// mismatchMetricValue returns the value of the topology mismatch metric for a machine, if it has been reported.
+func mismatchMetricValue(g *WithT, name string) (float64, bool) {
+	metricsCh := make(chan prometheus.Metric, 10)
+	metrics.MachineTopologyMismatch.Collect(metricsCh)
+	close(metricsCh)
+
+	for metric := range metricsCh {
+		m := &dto.Metric{}
+		g.Expect(metric.Write(m)).To(Succeed())
+		for _, label := range m.GetLabel() {
+			if label.GetName() == "name" && label.GetValue() == name {
+				return m.GetGauge().GetValue(), true
+			}
+		}
+	}
+	return 0, false
+}
@@ -3,13 +3,20 @@ package nodelink
 import (
 	"context"
 	"fmt"
+	"net/netip"
 	"reflect"
+	"strings"
 
 	machinev1 "github.com/openshift/api/machine/v1beta1"
+	mapicontroller "github.com/openshift/machine-api-operator/pkg/controller"
+	"github.com/openshift/machine-api-operator/pkg/util/annotations"
+	"github.com/openshift/machine-api-operator/pkg/util/conditions"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -19,13 +26,33 @@ import (
 )
 
 const (
+	controllerName = "nodelink-controller"
+
 	machineAnnotationKey   = "machine.openshift.io/machine"
+	machineRoleLabel       = "machine.openshift.io/cluster-api-machine-role"
+	machineExternalIPIndex = "machineExternalIPIndex"
 	machineInternalIPIndex = "machineInternalIPIndex"
+	machineNodeRefIndex    = "machineNodeRefIndex"
 	machineProviderIDIndex = "machineProviderIDIndex"
+	nodeExternalIPIndex    = "nodeExternalIPIndex"
 	nodeInternalIPIndex    = "nodeInternalIPIndex"
 	nodeProviderIDIndex    = "nodeProviderIDIndex"
 )
 
+const (
+	// NodeLinkedCondition is false once the node referenced by the nodeRef of the Machine is deleted, and true again
+	// once the Machine is linked to a node. The nodeRef is kept, the MachineHealthCheck remediates Machines whose node
+	// is gone from it.
+	NodeLinkedCondition machinev1.ConditionType = "NodeLinked"
+
+	// NodeDeletedReason is the NodeLinked condition reason used when the node of the Machine was deleted.
+	NodeDeletedReason = "NodeDeleted"
+)
+
+// ipAddressTypes are the address types machines and nodes are matched by, in order of preference.
+// External IPs are only used when no node or machine matches the internal IPs.
+var ipAddressTypes = []corev1.NodeAddressType{corev1.NodeInternalIP, corev1.NodeExternalIP}
+
 // blank assignment to verify that ReconcileNodeLink implements reconcile.Reconciler
 var _ reconcile.Reconciler = &ReconcileNodeLink{}
 
@@ -39,16 +66,85 @@ type ReconcileNodeLink struct {
 	listNodesByFieldFunc    func(ctx context.Context, key, value string) ([]corev1.Node, error)
 	listMachinesByFieldFunc func(ctx context.Context, key, value string) ([]machinev1.Machine, error)
 	nodeReadinessCache      map[string]bool
+	// nodeRoleLabels maps the role of a machine, given by its machine.openshift.io/cluster-api-machine-role
+	// label, to the label applied to its node.
+	nodeRoleLabels map[string]string
+	// propagatedLabelPrefixes are the prefixes of the machine labels propagated to its node.
+	propagatedLabelPrefixes []string
+}
+
+// Options are the options of the Nodelink Controller.
+type Options struct {
+	// NodeRoleLabels maps the role of a machine, given by its machine.openshift.io/cluster-api-machine-role
+	// label, to the label applied to its node.
+	NodeRoleLabels map[string]string
+	// PropagatedLabelPrefixes are the prefixes of the machine labels, such as the instance type and zone labels
+	// set from the providerSpec, which are propagated to its node. Node labels with these prefixes are owned
+	// by the machine and removed from the node once removed from the machine. No label is propagated when empty.
+	PropagatedLabelPrefixes []string
+	// ClusterPauseNamespace is the namespace of the machine-api-operator, in which it creates the
+	// ClusterPauseConfigMapName ConfigMap to pause the controller.
+	ClusterPauseNamespace string
 }
 
 // Add creates a new Nodelink Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager, opts manager.Options) error {
-	reconciler, err := newReconciler(mgr)
-	if err != nil {
-		return fmt.Errorf("error building reconciler: %v", err)
+	return AddWithNodeRoleLabels(nil)(mgr, opts)
+}
+
+// AddWithNodeRoleLabels returns a function which creates a new Nodelink Controller applying the given
+// machine role to node label mapping, and adds it to the Manager.
+func AddWithNodeRoleLabels(nodeRoleLabels map[string]string) func(manager.Manager, manager.Options) error {
+	return AddWithOptions(Options{
+		NodeRoleLabels:        nodeRoleLabels,
+		ClusterPauseNamespace: mapicontroller.DefaultClusterPauseNamespace,
+	})
+}
+
+// AddWithOptions returns a function which creates a new Nodelink Controller with the given options,
+// and adds it to the Manager.
+func AddWithOptions(nodelinkOpts Options) func(manager.Manager, manager.Options) error {
+	return func(mgr manager.Manager, opts manager.Options) error {
+		if err := validateNodeRoleLabels(nodelinkOpts.NodeRoleLabels); err != nil {
+			return err
+		}
+		if err := validatePropagatedLabelPrefixes(nodelinkOpts.PropagatedLabelPrefixes); err != nil {
+			return err
+		}
+
+		reconciler, err := newReconciler(mgr)
+		if err != nil {
+			return fmt.Errorf("error building reconciler: %v", err)
+		}
+		reconciler.nodeRoleLabels = nodelinkOpts.NodeRoleLabels
+		reconciler.propagatedLabelPrefixes = nodelinkOpts.PropagatedLabelPrefixes
+		gated := mapicontroller.WithClusterPause(controllerName, mgr.GetClient(), nodelinkOpts.ClusterPauseNamespace, reconciler, nil)
+		return add(mgr, gated, nodelinkOpts.ClusterPauseNamespace, reconciler.nodeRequestFromMachine)
 	}
-	return add(mgr, reconciler, reconciler.nodeRequestFromMachine)
+}
+
+// validateNodeRoleLabels checks that the node labels of the role mapping are valid label keys.
+func validateNodeRoleLabels(nodeRoleLabels map[string]string) error {
+	for role, label := range nodeRoleLabels {
+		if errs := validation.IsQualifiedName(label); len(errs) > 0 {
+			return fmt.Errorf("invalid node label %q for machine role %q: %v", label, role, errs)
+		}
+	}
+	return nil
+}
+
+// validatePropagatedLabelPrefixes checks that the propagated label prefixes are non-empty prefixes of valid label keys.
+func validatePropagatedLabelPrefixes(prefixes []string) error {
+	for _, prefix := range prefixes {
+		if prefix == "" {
+			return fmt.Errorf("invalid propagated label prefix %q: must not be empty", prefix)
+		}
+		if errs := validation.IsQualifiedName(prefix + "a"); len(errs) > 0 {
+			return fmt.Errorf("invalid propagated label prefix %q: %v", prefix, errs)
+		}
+	}
+	return nil
 }
 
 func indexNodeByProviderID(object client.Object) []string {
@@ -77,42 +173,110 @@ func indexMachineByProvider(object client.Object) []string {
 	return nil
 }
 
+func indexMachineByNodeRef(object client.Object) []string {
+	machine, ok := object.(*machinev1.Machine)
+	if !ok {
+		klog.Warningf("Expected a machine for indexing field, got: %T", object)
+		return nil
+	}
+
+	if machine.Status.NodeRef != nil && machine.Status.NodeRef.Name != "" {
+		klog.V(3).Infof("Adding nodeRef %q for machine %q to indexer", machine.Status.NodeRef.Name, machine.GetName())
+		return []string{machine.Status.NodeRef.Name}
+	}
+	return nil
+}
+
 func indexNodeByInternalIP(object client.Object) []string {
+	return indexNodeByIP(object, corev1.NodeInternalIP)
+}
+
+func indexNodeByExternalIP(object client.Object) []string {
+	return indexNodeByIP(object, corev1.NodeExternalIP)
+}
+
+func indexNodeByIP(object client.Object, addressType corev1.NodeAddressType) []string {
 	node, ok := object.(*corev1.Node)
 	if !ok {
 		klog.Warningf("expected a node for indexing field, got: %T", object)
 		return nil
 	}
 
-	var keys []string
-	for _, a := range node.Status.Addresses {
-		if a.Type == corev1.NodeInternalIP {
-			keys = append(keys, a.Address)
-			klog.V(3).Infof("Adding internal IP %q for node %q to indexer", a.Address, node.GetName())
-		}
+	keys := ipAddresses(node.Status.Addresses, addressType)
+	for _, key := range keys {
+		klog.V(3).Infof("Adding %s %q for node %q to indexer", addressType, key, node.GetName())
 	}
 
 	return keys
 }
 
 func indexMachineByInternalIP(object client.Object) []string {
+	return indexMachineByIP(object, corev1.NodeInternalIP)
+}
+
+func indexMachineByExternalIP(object client.Object) []string {
+	return indexMachineByIP(object, corev1.NodeExternalIP)
+}
+
+func indexMachineByIP(object client.Object, addressType corev1.NodeAddressType) []string {
 	machine, ok := object.(*machinev1.Machine)
 	if !ok {
 		klog.Warningf("Expected a machine for indexing field, got: %T", object)
 		return nil
 	}
 
-	var keys []string
-	for _, a := range machine.Status.Addresses {
-		if a.Type == corev1.NodeInternalIP {
-			keys = append(keys, a.Address)
-			klog.V(3).Infof("Adding internal IP %q for machine %q to indexer", a.Address, machine.GetName())
-		}
+	keys := ipAddresses(machine.Status.Addresses, addressType)
+	for _, key := range keys {
+		klog.V(3).Infof("Adding %s %q for machine %q to indexer", addressType, key, machine.GetName())
 	}
 
 	return keys
 }
 
+// ipAddresses returns the normalized addresses of the given type, IPv4 and IPv6 alike, without duplicates
+// and in the order they are reported.
+func ipAddresses(addresses []corev1.NodeAddress, addressType corev1.NodeAddressType) []string {
+	var ips []string
+	seen := map[string]bool{}
+	for _, a := range addresses {
+		if a.Type != addressType {
+			continue
+		}
+		ip := normalizeIP(a.Address)
+		if seen[ip] {
+			continue
+		}
+		seen[ip] = true
+		ips = append(ips, ip)
+	}
+	return ips
+}
+
+// normalizeIP returns the canonical form of an IP address, so that the different notations of the same IPv6
+// address, or an IPv4-mapped IPv6 address and its IPv4 address, match. Addresses which are not IPs are returned as is.
+func normalizeIP(address string) string {
+	ip, err := netip.ParseAddr(address)
+	if err != nil {
+		return address
+	}
+	return ip.Unmap().WithZone("").String()
+}
+
+// nodeIPIndex and machineIPIndex return the index of the node and machine addresses of the given type.
+func nodeIPIndex(addressType corev1.NodeAddressType) string {
+	if addressType == corev1.NodeExternalIP {
+		return nodeExternalIPIndex
+	}
+	return nodeInternalIPIndex
+}
+
+func machineIPIndex(addressType corev1.NodeAddressType) string {
+	if addressType == corev1.NodeExternalIP {
+		return machineExternalIPIndex
+	}
+	return machineInternalIPIndex
+}
+
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager) (*ReconcileNodeLink, error) {
 	// set convenient indexers
@@ -148,6 +312,30 @@ func newReconciler(mgr manager.Manager) (*ReconcileNodeLink, error) {
 		return nil, fmt.Errorf("error setting index fields: %v", err)
 	}
 
+	if err := mgr.GetCache().IndexField(context.TODO(),
+		&corev1.Node{},
+		nodeExternalIPIndex,
+		indexNodeByExternalIP,
+	); err != nil {
+		return nil, fmt.Errorf("error setting index fields: %v", err)
+	}
+
+	if err := mgr.GetCache().IndexField(context.TODO(),
+		&machinev1.Machine{},
+		machineExternalIPIndex,
+		indexMachineByExternalIP,
+	); err != nil {
+		return nil, fmt.Errorf("error setting index fields: %v", err)
+	}
+
+	if err := mgr.GetCache().IndexField(context.TODO(),
+		&machinev1.Machine{},
+		machineNodeRefIndex,
+		indexMachineByNodeRef,
+	); err != nil {
+		return nil, fmt.Errorf("error setting index fields: %v", err)
+	}
+
 	r := ReconcileNodeLink{
 		client: mgr.GetClient(),
 	}
@@ -161,9 +349,9 @@ func newReconciler(mgr manager.Manager) (*ReconcileNodeLink, error) {
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
-func add(mgr manager.Manager, r reconcile.Reconciler, mapFn handler.TypedMapFunc[*machinev1.Machine, reconcile.Request]) error {
+func add(mgr manager.Manager, r reconcile.Reconciler, clusterPauseNamespace string, mapFn handler.TypedMapFunc[*machinev1.Machine, reconcile.Request]) error {
 	// Create a new controller
-	c, err := controller.New("nodelink-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
 	if err != nil {
 		return err
 	}
@@ -180,6 +368,12 @@ func add(mgr manager.Manager, r reconcile.Reconciler, mapFn handler.TypedMapFunc
 		return err
 	}
 
+	// Reconcile all the Nodes again when the cluster pause ends
+	err = c.Watch(mapicontroller.ClusterPauseSource(mgr, clusterPauseNamespace, func() client.ObjectList { return &corev1.NodeList{} }))
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -195,9 +389,10 @@ func (r *ReconcileNodeLink) Reconcile(ctx context.Context, request reconcile.Req
 	err := r.client.Get(context.TODO(), request.NamespacedName, node)
 	if err != nil {
 		if errors.IsNotFound(err) {
-			// Request object not found, could have been deleted after reconcile request.
-			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
-			// Return and don't requeue
+			// The node was deleted, the Machines still referencing it are unlinked.
+			if err := r.unlinkDeletedNode(ctx, request.Name); err != nil {
+				return reconcile.Result{}, fmt.Errorf("error unlinking deleted node %q: %v", request.Name, err)
+			}
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
@@ -234,6 +429,8 @@ func (r *ReconcileNodeLink) Reconcile(ctx context.Context, request reconcile.Req
 		klog.V(4).Infof("Copying annotation %s = %s", k, v)
 		modNode.Annotations[k] = v
 	}
+	// Node hint annotations are owned by the Machine, drop those which were removed from it.
+	modNode.Annotations, _ = annotations.SyncNodeHintAnnotations(modNode.Annotations, machine.Spec.Annotations)
 
 	if modNode.Labels == nil {
 		modNode.Labels = map[string]string{}
@@ -244,6 +441,8 @@ func (r *ReconcileNodeLink) Reconcile(ctx context.Context, request reconcile.Req
 		modNode.Labels[k] = v
 	}
 
+	syncPropagatedLabels(modNode, machine, r.propagatedLabelPrefixes)
+	addRoleLabelToNode(modNode, machine, r.nodeRoleLabels)
 	addTaintsToNode(modNode, machine)
 
 	if !reflect.DeepEqual(node, modNode) {
@@ -280,6 +479,9 @@ func (r *ReconcileNodeLink) updateNodeRef(machine *machinev1.Machine, node *core
 		Name: node.GetName(),
 		UID:  node.GetUID(),
 	}
+	if conditions.Get(machine, NodeLinkedCondition) != nil {
+		conditions.MarkTrue(machine, NodeLinkedCondition)
+	}
 	if err := r.client.Status().Update(context.Background(), machine); err != nil {
 		return fmt.Errorf("error updating machine %q: %v", machine.GetName(), err)
 	}
@@ -289,6 +491,46 @@ func (r *ReconcileNodeLink) updateNodeRef(machine *machinev1.Machine, node *core
 	return nil
 }
 
+// unlinkDeletedNode sets the NodeLinked condition of the machines referencing a deleted node to false, so that they
+// show as unlinked. Their nodeRef is kept, so that the MachineHealthCheck remediates them right away. The condition
+// is not set when a node with the machine providerID exists, as that node is linked to the machine when it is reconciled.
+func (r *ReconcileNodeLink) unlinkDeletedNode(ctx context.Context, nodeName string) error {
+	delete(r.nodeReadinessCache, nodeName)
+
+	machines, err := r.listMachinesByFieldFunc(ctx, machineNodeRefIndex, nodeName)
+	if err != nil {
+		return err
+	}
+
+	for i := range machines {
+		machine := machines[i].DeepCopy()
+		if machine.Status.NodeRef == nil || machine.Status.NodeRef.Name != nodeName {
+			continue
+		}
+
+		if providerID := ptr.Deref(machine.Spec.ProviderID, ""); providerID != "" {
+			nodes, err := r.listNodesByFieldFunc(ctx, nodeProviderIDIndex, providerID)
+			if err != nil {
+				return err
+			}
+			if len(nodes) > 0 {
+				klog.V(3).Infof("Node %q for machine %q was replaced by node %q, keeping nodeRef", nodeName, machine.GetName(), nodes[0].GetName())
+				continue
+			}
+		}
+
+		if conditions.IsFalse(machine, NodeLinkedCondition) {
+			continue
+		}
+		conditions.MarkFalse(machine, NodeLinkedCondition, NodeDeletedReason, machinev1.ConditionSeverityWarning, "Node %q was deleted", nodeName)
+		if err := r.client.Status().Update(ctx, machine); err != nil {
+			return fmt.Errorf("error updating machine %q: %v", machine.GetName(), err)
+		}
+		klog.Infof("Marked machine %q as unlinked as node %q was deleted", machine.GetName(), nodeName)
+	}
+	return nil
+}
+
 // nodeRequestFromMachine returns a reconcile.request for the node backed by the received machine
 func (r *ReconcileNodeLink) nodeRequestFromMachine(ctx context.Context, o *machinev1.Machine) []reconcile.Request {
 	klog.V(3).Infof("Watched machine event, finding node to reconcile.Request")
@@ -374,37 +616,44 @@ func (r *ReconcileNodeLink) findNodeFromMachineByProviderID(ctx context.Context,
 	return nil, nil
 }
 
+// findNodeFromMachineByIP finds the node with the first internal IP of the machine that any node has, or with its
+// first external IP that a single node has when no node has its internal IPs. IPv4 and IPv6 addresses are
+// compared in their canonical form, so the machine and the node may report them in a different order.
 func (r *ReconcileNodeLink) findNodeFromMachineByIP(ctx context.Context, machine *machinev1.Machine) (*corev1.Node, error) {
 	klog.V(3).Infof("Finding node from machine %q by IP", machine.GetName())
-	var machineInternalAddress string
-	for _, a := range machine.Status.Addresses {
-		if a.Type == corev1.NodeInternalIP {
-			machineInternalAddress = a.Address
-			klog.V(3).Infof("Found internal IP for machine %q: %q", machine.GetName(), machineInternalAddress)
-			break
+	hasIP := false
+	for _, addressType := range ipAddressTypes {
+		machineAddresses := ipAddresses(machine.Status.Addresses, addressType)
+		if len(machineAddresses) == 0 {
+			continue
+		}
+		hasIP = true
+		klog.V(3).Infof("Found %s for machine %q: %q", addressType, machine.GetName(), machineAddresses)
+
+		// The first address matching a node is used. External addresses are only used when no internal address
+		// matches, and may be shared by several nodes, such as a NAT or virtual IP, in which case they are skipped.
+		for _, address := range machineAddresses {
+			nodes, err := r.listNodesByFieldFunc(ctx, nodeIPIndex(addressType), address)
+			if err != nil {
+				return nil, fmt.Errorf("failed getting node list: %v", err)
+			}
+			switch {
+			case len(nodes) == 1:
+				klog.V(3).Infof("Found node %q for machine %q with %s %q", nodes[0].GetName(), machine.GetName(), addressType, address)
+				return nodes[0].DeepCopy(), nil
+			case len(nodes) > 1 && addressType == corev1.NodeInternalIP:
+				return nil, fmt.Errorf("failed getting node: expected 1 node, got %v", len(nodes))
+			case len(nodes) > 1:
+				klog.V(3).Infof("Skipping %s %q of machine %q shared by %d nodes", addressType, address, machine.GetName(), len(nodes))
+			}
 		}
-	}
-
-	if machineInternalAddress == "" {
-		klog.Warningf("not found internal IP for machine %q", machine.GetName())
-		return nil, nil
-	}
-
-	nodes, err := r.listNodesByFieldFunc(ctx, nodeInternalIPIndex, machineInternalAddress)
-	if err != nil {
-		return nil, fmt.Errorf("failed getting node list: %v", err)
-	}
 
-	if len(nodes) > 1 {
-		return nil, fmt.Errorf("failed getting node: expected 1 node, got %v", len(nodes))
+		klog.V(3).Infof("Matching node not found for machine %q with %s %q", machine.GetName(), addressType, machineAddresses)
 	}
 
-	if len(nodes) == 1 {
-		klog.V(3).Infof("Found node %q for machine %q with internal IP %q", nodes[0].GetName(), machine.GetName(), machineInternalAddress)
-		return nodes[0].DeepCopy(), nil
+	if !hasIP {
+		klog.Warningf("not found internal or external IP for machine %q", machine.GetName())
 	}
-
-	klog.V(3).Infof("Matching node not found for machine %q with internal IP %q", machine.GetName(), machineInternalAddress)
 	return nil, nil
 }
 
@@ -448,38 +697,99 @@ func (r *ReconcileNodeLink) findMachineFromNodeByProviderID(ctx context.Context,
 	return nil, nil
 }
 
+// findMachineFromNodeByIP finds the machine with the first internal IP of the node that any machine has, or with its
+// first external IP that a single machine has when no machine has its internal IPs. IPv4 and IPv6 addresses are
+// compared in their canonical form, so the machine and the node may report them in a different order.
 func (r *ReconcileNodeLink) findMachineFromNodeByIP(ctx context.Context, node *corev1.Node) (*machinev1.Machine, error) {
 	klog.V(3).Infof("Finding machine from node %q by IP", node.GetName())
-	var nodeInternalAddress string
-	for _, a := range node.Status.Addresses {
-		if a.Type == corev1.NodeInternalIP {
-			nodeInternalAddress = a.Address
-			klog.V(3).Infof("Found internal IP for node %q: %q", node.GetName(), nodeInternalAddress)
-			break
+	hasIP := false
+	for _, addressType := range ipAddressTypes {
+		nodeAddresses := ipAddresses(node.Status.Addresses, addressType)
+		if len(nodeAddresses) == 0 {
+			continue
 		}
+		hasIP = true
+		klog.V(3).Infof("Found %s for node %q: %q", addressType, node.GetName(), nodeAddresses)
+
+		// The first address matching a machine is used. External addresses are only used when no internal address
+		// matches, and may be shared by several machines, such as a NAT or virtual IP, in which case they are skipped.
+		for _, address := range nodeAddresses {
+			machines, err := r.listMachinesByFieldFunc(ctx, machineIPIndex(addressType), address)
+			if err != nil {
+				return nil, fmt.Errorf("failed getting node list: %v", err)
+			}
+			switch {
+			case len(machines) == 1:
+				klog.V(3).Infof("Found machine %q for node %q with %s %q", machines[0].GetName(), node.GetName(), addressType, address)
+				return machines[0].DeepCopy(), nil
+			case len(machines) > 1 && addressType == corev1.NodeInternalIP:
+				return nil, fmt.Errorf("failed getting machine: expected 1 machine, got %v", len(machines))
+			case len(machines) > 1:
+				klog.V(3).Infof("Skipping %s %q of node %q shared by %d machines", addressType, address, node.GetName(), len(machines))
+			}
+		}
+
+		klog.V(3).Infof("Matching machine not found for node %q with %s %q", node.GetName(), addressType, nodeAddresses)
 	}
 
-	if nodeInternalAddress == "" {
-		klog.Warningf("Node %q has no internal IP", node.GetName())
-		return nil, nil
+	if !hasIP {
+		klog.Warningf("Node %q has no internal or external IP", node.GetName())
 	}
+	return nil, nil
+}
 
-	machines, err := r.listMachinesByFieldFunc(ctx, machineInternalIPIndex, nodeInternalAddress)
-	if err != nil {
-		return nil, fmt.Errorf("failed getting node list: %v", err)
+// syncPropagatedLabels copies the machine labels with a propagated prefix to the node. Node labels with a propagated
+// prefix are owned by the machine, those which were removed from the machine are removed from the node, unless they
+// are set by the machine spec. Other node labels, such as the ones managed by the kubelet, are left untouched.
+func syncPropagatedLabels(node *corev1.Node, machine *machinev1.Machine, prefixes []string) {
+	if len(prefixes) == 0 {
+		return
 	}
 
-	if len(machines) > 1 {
-		return nil, fmt.Errorf("failed getting machine: expected 1 machine, got %v", len(machines))
+	for key := range node.Labels {
+		if !hasAnyPrefix(key, prefixes) {
+			continue
+		}
+		if _, ok := machine.Labels[key]; ok {
+			continue
+		}
+		if _, ok := machine.Spec.Labels[key]; ok {
+			continue
+		}
+		klog.V(4).Infof("Removing propagated label %s from node %q", key, node.GetName())
+		delete(node.Labels, key)
 	}
 
-	if len(machines) == 1 {
-		klog.V(3).Infof("Found machine %q for node %q with internal IP %q", machines[0].GetName(), node.GetName(), nodeInternalAddress)
-		return machines[0].DeepCopy(), nil
+	for key, value := range machine.Labels {
+		if !hasAnyPrefix(key, prefixes) {
+			continue
+		}
+		klog.V(4).Infof("Propagating label %s = %s from machine %q to node %q", key, value, machine.GetName(), node.GetName())
+		node.Labels[key] = value
+	}
+}
+
+func hasAnyPrefix(key string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
 	}
+	return false
+}
 
-	klog.V(3).Infof("Matching machine not found for node %q with internal IP %q", node.GetName(), nodeInternalAddress)
-	return nil, nil
+// addRoleLabelToNode adds the node label mapped to the role of the machine, if any, to the node object.
+// Existing node labels are left untouched so that a label set by the user is not overridden.
+func addRoleLabelToNode(node *corev1.Node, machine *machinev1.Machine, nodeRoleLabels map[string]string) {
+	label, ok := nodeRoleLabels[machine.GetLabels()[machineRoleLabel]]
+	if !ok {
+		return
+	}
+	if _, ok := node.Labels[label]; ok {
+		return
+	}
+	klog.V(4).Infof("Adding role label %s from machine %q to node %q", label, machine.GetName(), node.GetName())
+	node.Labels[label] = ""
 }
 
 // addTaintsToNode adds taints from machine object to the node object
@@ -5,11 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
 	machinev1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/machine-api-operator/pkg/util/annotations"
+	"github.com/openshift/machine-api-operator/pkg/util/conditions"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -150,7 +153,7 @@ func (r *fakeReconciler) buildFakeNodeIndexer(nodes ...corev1.Node) {
 			r.fakeNodeIndexer[nodes[i].Spec.ProviderID] = nodes[i]
 		}
 		for j := range nodes[i].Status.Addresses {
-			r.fakeNodeIndexer[nodes[i].Status.Addresses[j].Address] = nodes[i]
+			r.fakeNodeIndexer[normalizeIP(nodes[i].Status.Addresses[j].Address)] = nodes[i]
 		}
 	}
 }
@@ -161,7 +164,7 @@ func (r *fakeReconciler) buildFakeMachineIndexer(machines ...machinev1.Machine)
 			r.fakeMachineIndexer[*machines[i].Spec.ProviderID] = machines[i]
 		}
 		for j := range machines[i].Status.Addresses {
-			r.fakeMachineIndexer[machines[i].Status.Addresses[j].Address] = machines[i]
+			r.fakeMachineIndexer[normalizeIP(machines[i].Status.Addresses[j].Address)] = machines[i]
 		}
 	}
 }
@@ -202,6 +205,35 @@ func TestFindMachineFromNodeByProviderID(t *testing.T) {
 	}
 }
 
+var (
+	ipv6Addresses = []corev1.NodeAddress{
+		{
+			Type:    corev1.NodeInternalIP,
+			Address: "fd00::10",
+		},
+	}
+	dualStackAddresses = []corev1.NodeAddress{
+		{
+			Type:    corev1.NodeInternalIP,
+			Address: "10.0.0.10",
+		},
+		{
+			Type:    corev1.NodeInternalIP,
+			Address: "fd00::20",
+		},
+	}
+	externalAddresses = []corev1.NodeAddress{
+		{
+			Type:    corev1.NodeInternalIP,
+			Address: "172.16.0.10",
+		},
+		{
+			Type:    corev1.NodeExternalIP,
+			Address: "2001:DB8::10",
+		},
+	}
+)
+
 func TestFindMachineFromNodeByIP(t *testing.T) {
 	testCases := []struct {
 		machine  *machinev1.Machine
@@ -248,6 +280,44 @@ func TestFindMachineFromNodeByIP(t *testing.T) {
 			}, nil),
 			expected: nil,
 		},
+		{
+			machine: machine("ipv6InternalIP", "test", ipv6Addresses, nil, nil),
+			node: node("ipv6InternalIP", "test", []corev1.NodeAddress{
+				{
+					Type:    corev1.NodeInternalIP,
+					Address: "FD00:0:0::10",
+				},
+			}, nil),
+			expected: machine("ipv6InternalIP", "test", ipv6Addresses, nil, nil),
+		},
+		{
+			machine: machine("dualStackInternalIP", "test", dualStackAddresses, nil, nil),
+			node: node("dualStackInternalIP", "test", []corev1.NodeAddress{
+				{
+					Type:    corev1.NodeInternalIP,
+					Address: "fd00::20",
+				},
+				{
+					Type:    corev1.NodeInternalIP,
+					Address: "10.0.0.10",
+				},
+			}, nil),
+			expected: machine("dualStackInternalIP", "test", dualStackAddresses, nil, nil),
+		},
+		{
+			machine: machine("externalIP", "test", externalAddresses, nil, nil),
+			node: node("externalIP", "test", []corev1.NodeAddress{
+				{
+					Type:    corev1.NodeInternalIP,
+					Address: "192.168.0.10",
+				},
+				{
+					Type:    corev1.NodeExternalIP,
+					Address: "2001:db8::10",
+				},
+			}, nil),
+			expected: machine("externalIP", "test", externalAddresses, nil, nil),
+		},
 	}
 	for _, tc := range testCases {
 		r := newFakeReconciler(fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(tc.machine).Build(), tc.machine, tc.node)
@@ -349,6 +419,51 @@ func TestFindNodeFromMachineByIP(t *testing.T) {
 			}, nil),
 			expected: nil,
 		},
+		{
+			machine:  machine("ipv6InternalIP", "test", ipv6Addresses, nil, nil),
+			node:     node("ipv6InternalIP", "test", ipv6Addresses, nil),
+			expected: node("ipv6InternalIP", "test", ipv6Addresses, nil),
+		},
+		{
+			machine: machine("ipv6InternalIPNotation", "test", []corev1.NodeAddress{
+				{
+					Type:    corev1.NodeInternalIP,
+					Address: "fd00:0000::0010",
+				},
+			}, nil, nil),
+			node:     node("ipv6InternalIPNotation", "test", ipv6Addresses, nil),
+			expected: node("ipv6InternalIPNotation", "test", ipv6Addresses, nil),
+		},
+		{
+			// The machine reports an IPv6 address first which the node does not report
+			machine: machine("dualStackInternalIP", "test", []corev1.NodeAddress{
+				{
+					Type:    corev1.NodeInternalIP,
+					Address: "fd00::30",
+				},
+				{
+					Type:    corev1.NodeInternalIP,
+					Address: "10.0.0.10",
+				},
+			}, nil, nil),
+			node:     node("dualStackInternalIP", "test", dualStackAddresses, nil),
+			expected: node("dualStackInternalIP", "test", dualStackAddresses, nil),
+		},
+		{
+			machine: machine("ipv4MappedInternalIP", "test", []corev1.NodeAddress{
+				{
+					Type:    corev1.NodeInternalIP,
+					Address: "::ffff:10.0.0.10",
+				},
+			}, nil, nil),
+			node:     node("ipv4MappedInternalIP", "test", dualStackAddresses, nil),
+			expected: node("ipv4MappedInternalIP", "test", dualStackAddresses, nil),
+		},
+		{
+			machine:  machine("externalIP", "test", externalAddresses, nil, nil),
+			node:     node("externalIP", "test", externalAddresses, nil),
+			expected: node("externalIP", "test", externalAddresses, nil),
+		},
 	}
 	for _, tc := range testCases {
 		r := newFakeReconciler(fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(tc.node).Build(), tc.machine, tc.node)
@@ -407,6 +522,299 @@ func TestAddTaintsToNode(t *testing.T) {
 	}
 }
 
+func TestAddRoleLabelToNode(t *testing.T) {
+	nodeRoleLabels := map[string]string{
+		"infra":  "node-role.kubernetes.io/infra",
+		"worker": "node-role.kubernetes.io/app",
+	}
+
+	testCases := []struct {
+		description        string
+		nodeRoleLabels     map[string]string
+		machineRole        string
+		nodeLabels         map[string]string
+		expectedNodeLabels map[string]string
+	}{
+		{
+			description:        "no mapping configured",
+			nodeRoleLabels:     nil,
+			machineRole:        "infra",
+			nodeLabels:         map[string]string{},
+			expectedNodeLabels: map[string]string{},
+		},
+		{
+			description:        "machine role is mapped",
+			nodeRoleLabels:     nodeRoleLabels,
+			machineRole:        "infra",
+			nodeLabels:         map[string]string{},
+			expectedNodeLabels: map[string]string{"node-role.kubernetes.io/infra": ""},
+		},
+		{
+			description:        "machine role is not mapped",
+			nodeRoleLabels:     nodeRoleLabels,
+			machineRole:        "master",
+			nodeLabels:         map[string]string{},
+			expectedNodeLabels: map[string]string{},
+		},
+		{
+			description:        "machine has no role",
+			nodeRoleLabels:     nodeRoleLabels,
+			machineRole:        "",
+			nodeLabels:         map[string]string{},
+			expectedNodeLabels: map[string]string{},
+		},
+		{
+			description:        "node already has the label",
+			nodeRoleLabels:     nodeRoleLabels,
+			machineRole:        "worker",
+			nodeLabels:         map[string]string{"node-role.kubernetes.io/app": "user-value"},
+			expectedNodeLabels: map[string]string{"node-role.kubernetes.io/app": "user-value"},
+		},
+	}
+
+	for _, test := range testCases {
+		machine := machine("", "", nil, nil, nil)
+		if test.machineRole != "" {
+			machine.Labels[machineRoleLabel] = test.machineRole
+		}
+		node := node("", "", nil, nil)
+		node.Labels = test.nodeLabels
+		addRoleLabelToNode(node, machine, test.nodeRoleLabels)
+		if !reflect.DeepEqual(node.Labels, test.expectedNodeLabels) {
+			t.Errorf("Test case: %s. Expected: %v, got: %v", test.description, test.expectedNodeLabels, node.Labels)
+		}
+	}
+}
+
+func TestValidateNodeRoleLabels(t *testing.T) {
+	testCases := []struct {
+		description    string
+		nodeRoleLabels map[string]string
+		expectedError  bool
+	}{
+		{
+			description:    "no mapping",
+			nodeRoleLabels: nil,
+			expectedError:  false,
+		},
+		{
+			description:    "valid mapping",
+			nodeRoleLabels: map[string]string{"infra": "node-role.kubernetes.io/infra"},
+			expectedError:  false,
+		},
+		{
+			description:    "invalid node label",
+			nodeRoleLabels: map[string]string{"infra": "node-role.kubernetes.io/in fra"},
+			expectedError:  true,
+		},
+	}
+
+	for _, test := range testCases {
+		err := validateNodeRoleLabels(test.nodeRoleLabels)
+		if (err != nil) != test.expectedError {
+			t.Errorf("Test case: %s. Expected error: %v, got: %v", test.description, test.expectedError, err)
+		}
+	}
+}
+
+func TestReconcileNodeRoleLabels(t *testing.T) {
+	m := machine("infraMachine", "match", nil, nil, nil)
+	m.Labels[machineRoleLabel] = "infra"
+	n := node("infraNode", "match", nil, nil)
+
+	r := newFakeReconciler(fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(n, m).WithStatusSubresource(&machinev1.Machine{}).Build(), m, n)
+	r.nodeRoleLabels = map[string]string{"infra": "node-role.kubernetes.io/infra"}
+
+	if _, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKey{Name: n.GetName()}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	freshNode := &corev1.Node{}
+	if err := r.client.Get(ctx, client.ObjectKey{Name: n.GetName()}, freshNode); err != nil {
+		t.Fatalf("unexpected error getting node: %v", err)
+	}
+	if value, ok := freshNode.GetLabels()["node-role.kubernetes.io/infra"]; !ok || value != "" {
+		t.Errorf("expected node to have the infra role label, got labels: %v", freshNode.GetLabels())
+	}
+}
+
+func TestValidatePropagatedLabelPrefixes(t *testing.T) {
+	testCases := []struct {
+		description   string
+		prefixes      []string
+		expectedError bool
+	}{
+		{
+			description:   "no prefix",
+			prefixes:      nil,
+			expectedError: false,
+		},
+		{
+			description:   "valid prefixes",
+			prefixes:      []string{"machine.openshift.io/", "example.com/team-"},
+			expectedError: false,
+		},
+		{
+			description:   "empty prefix",
+			prefixes:      []string{""},
+			expectedError: true,
+		},
+		{
+			description:   "invalid prefix",
+			prefixes:      []string{"example com/"},
+			expectedError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		err := validatePropagatedLabelPrefixes(test.prefixes)
+		if (err != nil) != test.expectedError {
+			t.Errorf("Test case: %s. Expected error: %v, got: %v", test.description, test.expectedError, err)
+		}
+	}
+}
+
+func TestReconcilePropagatedLabels(t *testing.T) {
+	const (
+		instanceTypeLabel = "machine.openshift.io/instance-type"
+		zoneLabel         = "machine.openshift.io/zone"
+		regionLabel       = "machine.openshift.io/region"
+		kubeletZoneLabel  = "topology.kubernetes.io/zone"
+	)
+
+	m := machine("labelledMachine", "match", nil, nil, nil)
+	m.Labels[instanceTypeLabel] = "m5.large"
+	m.Labels[zoneLabel] = "us-east-1a"
+	n := node("labelledNode", "match", nil, nil)
+	n.Labels = map[string]string{
+		regionLabel:      "us-east-1",
+		kubeletZoneLabel: "us-east-1a",
+	}
+
+	r := newFakeReconciler(fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(n, m).WithStatusSubresource(&machinev1.Machine{}).Build(), m, n)
+	r.propagatedLabelPrefixes = []string{instanceTypeLabel, zoneLabel, regionLabel}
+
+	reconcileNode := func() map[string]string {
+		if _, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKey{Name: n.GetName()}}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		freshNode := &corev1.Node{}
+		if err := r.client.Get(ctx, client.ObjectKey{Name: n.GetName()}, freshNode); err != nil {
+			t.Fatalf("unexpected error getting node: %v", err)
+		}
+		return freshNode.GetLabels()
+	}
+
+	// The labels of the machine are applied, a propagated label not on the machine is removed
+	got := reconcileNode()
+	if got[instanceTypeLabel] != "m5.large" || got[zoneLabel] != "us-east-1a" {
+		t.Errorf("expected the machine labels to be propagated, got labels: %v", got)
+	}
+	if _, ok := got[regionLabel]; ok {
+		t.Errorf("expected the region label not on the machine to be removed, got labels: %v", got)
+	}
+	if got[kubeletZoneLabel] != "us-east-1a" {
+		t.Errorf("expected labels without a propagated prefix to be kept, got labels: %v", got)
+	}
+
+	// An updated machine label is updated on the node
+	freshMachine := &machinev1.Machine{}
+	if err := r.client.Get(ctx, client.ObjectKeyFromObject(m), freshMachine); err != nil {
+		t.Fatalf("unexpected error getting machine: %v", err)
+	}
+	freshMachine.Labels[instanceTypeLabel] = "m5.xlarge"
+	delete(freshMachine.Labels, zoneLabel)
+	if err := r.client.Update(ctx, freshMachine); err != nil {
+		t.Fatalf("unexpected error updating machine: %v", err)
+	}
+	r.buildFakeMachineIndexer(*freshMachine)
+
+	// The updated label is updated and the label removed from the machine is removed from the node
+	got = reconcileNode()
+	if got[instanceTypeLabel] != "m5.xlarge" {
+		t.Errorf("expected the updated instance type label to be propagated, got labels: %v", got)
+	}
+	if _, ok := got[zoneLabel]; ok {
+		t.Errorf("expected the zone label removed from the machine to be removed, got labels: %v", got)
+	}
+	if got[kubeletZoneLabel] != "us-east-1a" {
+		t.Errorf("expected labels without a propagated prefix to be kept, got labels: %v", got)
+	}
+}
+
+func TestSyncPropagatedLabels(t *testing.T) {
+	m := machine("machine", "", nil, nil, nil)
+	m.Labels["machine.openshift.io/instance-type"] = "m5.large"
+	m.Spec.Labels = map[string]string{"machine.openshift.io/custom": "spec"}
+
+	t.Run("without prefixes", func(t *testing.T) {
+		n := node("node", "", nil, nil)
+		n.Labels = map[string]string{"machine.openshift.io/zone": "us-east-1a"}
+
+		syncPropagatedLabels(n, m, nil)
+
+		expected := map[string]string{"machine.openshift.io/zone": "us-east-1a"}
+		if !reflect.DeepEqual(n.Labels, expected) {
+			t.Errorf("Expected: %v, got: %v", expected, n.Labels)
+		}
+	})
+
+	t.Run("with a prefix", func(t *testing.T) {
+		n := node("node", "", nil, nil)
+		n.Labels = map[string]string{
+			"machine.openshift.io/zone":   "us-east-1a",
+			"machine.openshift.io/custom": "spec",
+			"kubernetes.io/hostname":      "node",
+		}
+
+		syncPropagatedLabels(n, m, []string{"machine.openshift.io/"})
+
+		// Labels set from the machine spec are kept
+		expected := map[string]string{
+			"machine.openshift.io/instance-type": "m5.large",
+			"machine.openshift.io/custom":        "spec",
+			"kubernetes.io/hostname":             "node",
+		}
+		if !reflect.DeepEqual(n.Labels, expected) {
+			t.Errorf("Expected: %v, got: %v", expected, n.Labels)
+		}
+	})
+}
+
+func TestReconcileNodeHintAnnotations(t *testing.T) {
+	m := machine("hintMachine", "match", nil, nil, nil)
+	m.Spec.Annotations = map[string]string{
+		annotations.NodeHintAnnotationPrefix + "kernel-args": "hugepages=64",
+	}
+	n := node("hintNode", "match", nil, nil)
+	n.Annotations = map[string]string{
+		annotations.NodeHintAnnotationPrefix + "kernel-args": "hugepages=32",
+		annotations.NodeHintAnnotationPrefix + "swap":        "enabled",
+		"example.com/unrelated":                              "kept",
+	}
+
+	r := newFakeReconciler(fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(n, m).WithStatusSubresource(&machinev1.Machine{}).Build(), m, n)
+
+	if _, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKey{Name: n.GetName()}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	freshNode := &corev1.Node{}
+	if err := r.client.Get(ctx, client.ObjectKey{Name: n.GetName()}, freshNode); err != nil {
+		t.Fatalf("unexpected error getting node: %v", err)
+	}
+	got := freshNode.GetAnnotations()
+	if value := got[annotations.NodeHintAnnotationPrefix+"kernel-args"]; value != "hugepages=64" {
+		t.Errorf("expected the kernel-args hint to be updated from the machine, got annotations: %v", got)
+	}
+	if _, ok := got[annotations.NodeHintAnnotationPrefix+"swap"]; ok {
+		t.Errorf("expected the swap hint removed from the machine to be removed, got annotations: %v", got)
+	}
+	if value := got["example.com/unrelated"]; value != "kept" {
+		t.Errorf("expected annotations other than hints to be kept, got annotations: %v", got)
+	}
+}
+
 func TestNodeRequestFromMachine(t *testing.T) {
 	testCases := []struct {
 		machine  *machinev1.Machine
@@ -658,6 +1066,27 @@ func TestIndexNodeByInternalIP(t *testing.T) {
 			}, nil),
 			expected: []string{"ip1", "ip2"},
 		},
+		{
+			object: node("dualStackInternalIPs", "test", []corev1.NodeAddress{
+				{
+					Type:    corev1.NodeInternalIP,
+					Address: "FD00:0::1",
+				},
+				{
+					Type:    corev1.NodeExternalIP,
+					Address: "2001:db8::1",
+				},
+				{
+					Type:    corev1.NodeInternalIP,
+					Address: "10.0.0.1",
+				},
+				{
+					Type:    corev1.NodeInternalIP,
+					Address: "::ffff:10.0.0.1",
+				},
+			}, nil),
+			expected: []string{"fd00::1", "10.0.0.1"},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -703,6 +1132,19 @@ func TestIndexMachineByInternalIP(t *testing.T) {
 			}, nil, nil),
 			expected: []string{"ip1", "ip2"},
 		},
+		{
+			object: machine("dualStackInternalIPs", "test", []corev1.NodeAddress{
+				{
+					Type:    corev1.NodeInternalIP,
+					Address: "fd00:0::1",
+				},
+				{
+					Type:    corev1.NodeInternalIP,
+					Address: "10.0.0.1",
+				},
+			}, nil, nil),
+			expected: []string{"fd00::1", "10.0.0.1"},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -713,6 +1155,148 @@ func TestIndexMachineByInternalIP(t *testing.T) {
 	}
 }
 
+func TestIndexByExternalIP(t *testing.T) {
+	addresses := []corev1.NodeAddress{
+		{
+			Type:    corev1.NodeInternalIP,
+			Address: "10.0.0.1",
+		},
+		{
+			Type:    corev1.NodeExternalIP,
+			Address: "2001:DB8::1",
+		},
+		{
+			Type:    corev1.NodeExternalIP,
+			Address: "203.0.113.1",
+		},
+	}
+	expected := []string{"2001:db8::1", "203.0.113.1"}
+
+	if got := indexNodeByExternalIP(node("externalIPs", "test", addresses, nil)); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected: %v, got: %v", expected, got)
+	}
+	if got := indexMachineByExternalIP(machine("externalIPs", "test", addresses, nil, nil)); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected: %v, got: %v", expected, got)
+	}
+}
+
+// nodeIPIndexFuncs and machineIPIndexFuncs are the index functions of the IP indexes, for tests listing
+// several objects with the same address.
+var (
+	nodeIPIndexFuncs = map[string]func(client.Object) []string{
+		nodeInternalIPIndex: indexNodeByInternalIP,
+		nodeExternalIPIndex: indexNodeByExternalIP,
+	}
+	machineIPIndexFuncs = map[string]func(client.Object) []string{
+		machineInternalIPIndex: indexMachineByInternalIP,
+		machineExternalIPIndex: indexMachineByExternalIP,
+	}
+)
+
+func TestFindNodeFromMachineByIPWithMultipleNodes(t *testing.T) {
+	sharedExternalIP := corev1.NodeAddress{Type: corev1.NodeExternalIP, Address: "203.0.113.10"}
+
+	testCases := []struct {
+		name          string
+		machine       *machinev1.Machine
+		nodes         []*corev1.Node
+		expectedNode  string
+		expectedError string
+	}{
+		{
+			// The machine reports the IPv4 address of one node and the IPv6 address of another
+			name:         "with internal IPs of different nodes",
+			machine:      machine("machine", "", dualStackAddresses, nil, nil),
+			nodes:        []*corev1.Node{node("ipv4", "", dualStackAddresses[:1], nil), node("ipv6", "", dualStackAddresses[1:], nil)},
+			expectedNode: "ipv4",
+		},
+		{
+			name:          "with an internal IP shared by several nodes",
+			machine:       machine("machine", "", dualStackAddresses[:1], nil, nil),
+			nodes:         []*corev1.Node{node("first", "", dualStackAddresses[:1], nil), node("second", "", dualStackAddresses[:1], nil)},
+			expectedError: "expected 1 node, got 2",
+		},
+		{
+			name:    "with an external IP shared by several nodes and a matching internal IP",
+			machine: machine("machine", "", []corev1.NodeAddress{dualStackAddresses[0], sharedExternalIP}, nil, nil),
+			nodes: []*corev1.Node{
+				node("matching", "", []corev1.NodeAddress{dualStackAddresses[0], sharedExternalIP}, nil),
+				node("other", "", []corev1.NodeAddress{dualStackAddresses[1], sharedExternalIP}, nil),
+			},
+			expectedNode: "matching",
+		},
+		{
+			name:    "with only an external IP shared by several nodes",
+			machine: machine("machine", "", []corev1.NodeAddress{sharedExternalIP}, nil, nil),
+			nodes: []*corev1.Node{
+				node("first", "", []corev1.NodeAddress{dualStackAddresses[0], sharedExternalIP}, nil),
+				node("second", "", []corev1.NodeAddress{dualStackAddresses[1], sharedExternalIP}, nil),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newFakeReconciler(fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(), tc.machine, tc.nodes[0])
+			r.listNodesByFieldFunc = func(_ context.Context, key, value string) ([]corev1.Node, error) {
+				var found []corev1.Node
+				for _, n := range tc.nodes {
+					if slices.Contains(nodeIPIndexFuncs[key](n), value) {
+						found = append(found, *n)
+					}
+				}
+				return found, nil
+			}
+
+			got, err := r.findNodeFromMachineByIP(context.Background(), tc.machine)
+			if tc.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedError) {
+					t.Errorf("expected error to contain %q, got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error finding node from machine by IP: %v", err)
+			}
+
+			var gotName string
+			if got != nil {
+				gotName = got.GetName()
+			}
+			if gotName != tc.expectedNode {
+				t.Errorf("expected node %q, got %q", tc.expectedNode, gotName)
+			}
+		})
+	}
+}
+
+func TestFindMachineFromNodeByIPWithMultipleMachines(t *testing.T) {
+	sharedExternalIP := corev1.NodeAddress{Type: corev1.NodeExternalIP, Address: "203.0.113.10"}
+	testNode := node("node", "", []corev1.NodeAddress{sharedExternalIP}, nil)
+	first := machine("first", "", []corev1.NodeAddress{dualStackAddresses[0], sharedExternalIP}, nil, nil)
+	second := machine("second", "", []corev1.NodeAddress{dualStackAddresses[1], sharedExternalIP}, nil, nil)
+
+	r := newFakeReconciler(fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(), first, testNode)
+	r.listMachinesByFieldFunc = func(_ context.Context, key, value string) ([]machinev1.Machine, error) {
+		var found []machinev1.Machine
+		for _, m := range []*machinev1.Machine{first, second} {
+			if slices.Contains(machineIPIndexFuncs[key](m), value) {
+				found = append(found, *m)
+			}
+		}
+		return found, nil
+	}
+
+	// An external IP shared by several machines does not link the node to any of them
+	got, err := r.findMachineFromNodeByIP(context.Background(), testNode)
+	if err != nil {
+		t.Fatalf("unexpected error finding machine from node by IP: %v", err)
+	}
+	if got != nil {
+		t.Errorf("expected no machine, got %q", got.GetName())
+	}
+}
+
 func TestUpdateNodeRef(t *testing.T) {
 	testCases := []struct {
 		machine            *machinev1.Machine
@@ -823,3 +1407,124 @@ func TestFindMachineFromNodeDoesNotPanicBZ1747246(t *testing.T) {
 		t.Errorf("expected error to contain %q, got %v", errmsg, err)
 	}
 }
+
+func TestReconcileDeletedNode(t *testing.T) {
+	nodeRef := &corev1.ObjectReference{Kind: "Node", Name: "deleted"}
+
+	testCases := []struct {
+		name             string
+		machine          *machinev1.Machine
+		nodes            []*corev1.Node
+		expectedUnlinked bool
+	}{
+		{
+			name:             "when the node is deleted",
+			machine:          machine("machine", "providerID", nil, nil, nodeRef),
+			expectedUnlinked: true,
+		},
+		{
+			name:             "when the node is deleted and recreated with the same providerID",
+			machine:          machine("machine", "providerID", nil, nil, nodeRef),
+			nodes:            []*corev1.Node{node("recreated", "providerID", nil, nil)},
+			expectedUnlinked: false,
+		},
+		{
+			name:             "when the node is deleted and a node with another providerID exists",
+			machine:          machine("machine", "providerID", nil, nil, nodeRef),
+			nodes:            []*corev1.Node{node("other", "otherProviderID", nil, nil)},
+			expectedUnlinked: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			builder := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(tc.machine).WithStatusSubresource(&machinev1.Machine{})
+			for _, n := range tc.nodes {
+				builder = builder.WithRuntimeObjects(n)
+			}
+
+			r := newFakeReconciler(builder.Build(), tc.machine, node("unrelated", "", nil, nil))
+			for _, n := range tc.nodes {
+				r.buildFakeNodeIndexer(*n)
+			}
+			r.fakeMachineIndexer[nodeRef.Name] = *tc.machine
+			r.nodeReadinessCache[nodeRef.Name] = true
+
+			_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKey{Name: nodeRef.Name}})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := &machinev1.Machine{}
+			if err := r.client.Get(ctx, client.ObjectKeyFromObject(tc.machine), got); err != nil {
+				t.Fatalf("unexpected error getting machine: %v", err)
+			}
+			// The nodeRef is kept for the MachineHealthCheck to remediate the machine
+			if !reflect.DeepEqual(got.Status.NodeRef, nodeRef) {
+				t.Errorf("expected nodeRef %v, got: %v", nodeRef, got.Status.NodeRef)
+			}
+			if unlinked := conditions.IsFalse(got, NodeLinkedCondition); unlinked != tc.expectedUnlinked {
+				t.Errorf("expected unlinked %v, got conditions: %v", tc.expectedUnlinked, got.Status.Conditions)
+			}
+			if tc.expectedUnlinked {
+				condition := conditions.Get(got, NodeLinkedCondition)
+				if condition.Reason != NodeDeletedReason || condition.Message != `Node "deleted" was deleted` {
+					t.Errorf("unexpected NodeLinked condition: %v", condition)
+				}
+			}
+
+			// A node recreated with the same name is linked again
+			if _, ok := r.nodeReadinessCache[nodeRef.Name]; ok {
+				t.Errorf("expected node readiness of %q to be dropped", nodeRef.Name)
+			}
+		})
+	}
+}
+
+func TestUpdateNodeRefRelinksMachine(t *testing.T) {
+	m := machine("machine", "providerID", nil, nil, &corev1.ObjectReference{Kind: "Node", Name: "deleted"})
+	conditions.MarkFalse(m, NodeLinkedCondition, NodeDeletedReason, machinev1.ConditionSeverityWarning, "Node %q was deleted", "deleted")
+	n := node("recreated", "providerID", nil, nil)
+
+	r := newFakeReconciler(fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(m, n).WithStatusSubresource(&machinev1.Machine{}).Build(), m, n)
+	if err := r.updateNodeRef(m.DeepCopy(), n); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := &machinev1.Machine{}
+	if err := r.client.Get(ctx, client.ObjectKeyFromObject(m), got); err != nil {
+		t.Fatalf("unexpected error getting machine: %v", err)
+	}
+	if got.Status.NodeRef == nil || got.Status.NodeRef.Name != "recreated" {
+		t.Errorf("expected nodeRef to the recreated node, got: %v", got.Status.NodeRef)
+	}
+	if !conditions.IsTrue(got, NodeLinkedCondition) {
+		t.Errorf("expected the NodeLinked condition to be true, got conditions: %v", got.Status.Conditions)
+	}
+}
+
+func TestIndexMachineByNodeRef(t *testing.T) {
+	testCases := []struct {
+		object   client.Object
+		expected []string
+	}{
+		{
+			object:   machine("withNodeRef", "", nil, nil, &corev1.ObjectReference{Name: "node"}),
+			expected: []string{"node"},
+		},
+		{
+			object:   machine("withoutNodeRef", "", nil, nil, nil),
+			expected: nil,
+		},
+		{
+			object:   &corev1.Node{},
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		if got := indexMachineByNodeRef(tc.object); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("expected %v, got: %v", tc.expected, got)
+		}
+	}
+}
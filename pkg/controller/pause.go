@@ -0,0 +1,195 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/machine-api-operator/pkg/metrics"
+	"github.com/openshift/machine-api-operator/pkg/util/conditions"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// ClusterPauseConfigMapName is the name of the ConfigMap created by the machine-api-operator in its own
+	// namespace while the cluster is upgrading or paused for maintenance. While it exists, the machine API
+	// controllers keep watching their resources but suspend their reconciles in all namespaces.
+	ClusterPauseConfigMapName = "machine-api-cluster-pause"
+
+	// DefaultClusterPauseNamespace is the namespace in which the machine API controllers look for the
+	// ClusterPauseConfigMapName ConfigMap unless told otherwise, the usual namespace of the machine-api-operator.
+	DefaultClusterPauseNamespace = "openshift-machine-api"
+
+	// MaintenancePauseAnnotation is set to "true" on the cluster Infrastructure by administrators to pause
+	// the machine API controllers during cluster maintenance without scaling them down. The
+	// machine-api-operator creates the ClusterPauseConfigMapName ConfigMap while it is set.
+	MaintenancePauseAnnotation = "machine.openshift.io/maintenance-paused"
+
+	// ClusterPausedCondition is set to True on the Machines, MachineSets and MachineHealthChecks skipped by a
+	// controller while the cluster pause is in effect. It is distinct from the Paused condition set while the
+	// Machine API is not authoritative for a resource, so that neither pause overrides the other.
+	ClusterPausedCondition machinev1.ConditionType = "ClusterPaused"
+
+	// ClusterPausedReason is the reason of the ClusterPausedCondition while the cluster pause is in effect.
+	ClusterPausedReason = "ClusterPaused"
+
+	// ClusterResumedReason is the reason of the ClusterPausedCondition once the cluster pause has ended.
+	ClusterResumedReason = "ClusterResumed"
+)
+
+// IsClusterPaused returns true if the machine-api-operator running in namespace has paused the reconciliation
+// of the machine API resources. c is expected to be the cached client of the manager, so that the
+// ClusterPauseConfigMapName ConfigMap is watched rather than read from the API server on every reconcile.
+func IsClusterPaused(ctx context.Context, c client.Reader, namespace string) (bool, error) {
+	cm := &corev1.ConfigMap{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ClusterPauseConfigMapName}, cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// ClusterPauseCacheByObject returns the cache configuration of the ConfigMaps read by IsClusterPaused, to be
+// used as the cache.Options ByObject of the managers of the controllers gated by WithClusterPause. Only the
+// ClusterPauseConfigMapName ConfigMap of namespace is cached, whichever namespaces are watched by the manager.
+func ClusterPauseCacheByObject(namespace string) map[client.Object]cache.ByObject {
+	return map[client.Object]cache.ByObject{
+		&corev1.ConfigMap{}: {
+			Namespaces: map[string]cache.Config{namespace: {}},
+			Field:      fields.OneTermEqualSelector("metadata.name", ClusterPauseConfigMapName),
+		},
+	}
+}
+
+// ClusterPauseSource returns a source.Source which enqueues all the objects listed with newList, the
+// resources reconciled by a controller gated by WithClusterPause, when the cluster pause of namespace starts
+// or ends. The paused reconciles are not requeued, they are triggered again by this source once the pause ends.
+func ClusterPauseSource(mgr manager.Manager, namespace string, newList func() client.ObjectList) source.Source {
+	return source.Kind(mgr.GetCache(), &corev1.ConfigMap{},
+		handler.TypedEnqueueRequestsFromMapFunc(clusterPauseRequests(mgr.GetClient(), newList)),
+		predicate.NewTypedPredicateFuncs(func(cm *corev1.ConfigMap) bool {
+			return cm.GetNamespace() == namespace && cm.GetName() == ClusterPauseConfigMapName
+		}),
+	)
+}
+
+// clusterPauseRequests returns a handler.TypedMapFunc which maps the ClusterPauseConfigMapName ConfigMap
+// to the requests of all the objects listed with newList.
+func clusterPauseRequests(c client.Reader, newList func() client.ObjectList) handler.TypedMapFunc[*corev1.ConfigMap, reconcile.Request] {
+	return func(ctx context.Context, _ *corev1.ConfigMap) []reconcile.Request {
+		list := newList()
+		if err := c.List(ctx, list); err != nil {
+			klog.Errorf("No-op: Unable to list resources on cluster pause change: %v", err)
+			return nil
+		}
+
+		var requests []reconcile.Request
+		if err := meta.EachListItem(list, func(obj runtime.Object) error {
+			o, ok := obj.(client.Object)
+			if !ok {
+				return fmt.Errorf("expected a client.Object, got %T", obj)
+			}
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(o)})
+			return nil
+		}); err != nil {
+			klog.Errorf("No-op: Unable to list resources on cluster pause change: %v", err)
+			return nil
+		}
+		return requests
+	}
+}
+
+// clusterPauseGate is a reconcile.Reconciler which only calls the wrapped reconciler while the cluster
+// pause is not in effect.
+type clusterPauseGate struct {
+	name       string
+	client     client.Client
+	namespace  string
+	reconciler reconcile.Reconciler
+	newObject  func() client.Object
+}
+
+// WithClusterPause returns a reconcile.Reconciler which skips the reconciles of r while the cluster
+// pause of the machine-api-operator running in namespace is in effect, and reports the pause of the
+// controller named name in the mapi_controller_reconcile_paused metric. The controller is expected to
+// watch ClusterPauseSource, so that the skipped reconciles run again once the pause ends. When newObject
+// is not nil, the ClusterPausedCondition of the reconciled object is kept up to date; it must return a
+// Machine, MachineSet or MachineHealthCheck.
+func WithClusterPause(name string, c client.Client, namespace string, r reconcile.Reconciler, newObject func() client.Object) reconcile.Reconciler {
+	return &clusterPauseGate{
+		name:       name,
+		client:     c,
+		namespace:  namespace,
+		reconciler: r,
+		newObject:  newObject,
+	}
+}
+
+// Reconcile implements reconcile.Reconciler.
+func (g *clusterPauseGate) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	paused, err := IsClusterPaused(ctx, g.client, g.namespace)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("%v: failed to check the cluster pause: %w", request.Name, err)
+	}
+	metrics.SetReconcilePaused(g.name, paused)
+
+	if err := g.setClusterPausedCondition(ctx, request, paused); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if paused {
+		klog.Infof("%v: machine API reconciliation is paused cluster-wide, taking no action", request.Name)
+		return reconcile.Result{}, nil
+	}
+
+	return g.reconciler.Reconcile(ctx, request)
+}
+
+// setClusterPausedCondition sets the ClusterPausedCondition of the reconciled object to True while paused.
+// Once the pause has ended, the condition is set to False, so that objects which were never paused are
+// left alone.
+func (g *clusterPauseGate) setClusterPausedCondition(ctx context.Context, request reconcile.Request, paused bool) error {
+	if g.newObject == nil {
+		return nil
+	}
+
+	obj := g.newObject()
+	if err := g.client.Get(ctx, request.NamespacedName, obj); err != nil {
+		// The wrapped reconciler deals with deleted objects
+		return client.IgnoreNotFound(err)
+	}
+
+	existing := conditions.Get(obj, ClusterPausedCondition)
+	var condition *machinev1.Condition
+	switch {
+	case paused && (existing == nil || existing.Status != corev1.ConditionTrue):
+		condition = conditions.TrueConditionWithReason(ClusterPausedCondition, ClusterPausedReason,
+			"Machine API reconciliation is paused cluster-wide")
+	case !paused && existing != nil && existing.Status == corev1.ConditionTrue:
+		condition = conditions.FalseCondition(ClusterPausedCondition, ClusterResumedReason, machinev1.ConditionSeverityInfo,
+			"Machine API reconciliation has resumed")
+	default:
+		return nil
+	}
+
+	patchBase := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+	conditions.Set(obj, condition)
+	if err := g.client.Status().Patch(ctx, obj, patchBase); err != nil {
+		return fmt.Errorf("%v: failed to set the %s condition: %w", request.Name, ClusterPausedCondition, err)
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/machine-api-operator/pkg/util/conditions"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// operatorNamespace is the namespace of the machine-api-operator in the tests, which differs from the
+// DefaultClusterPauseNamespace to check that the namespace given to the controllers is used.
+const operatorNamespace = "test-operator-namespace"
+
+// countingReconciler counts the reconciles which went through the cluster pause gate.
+type countingReconciler struct {
+	calls int
+}
+
+func (r *countingReconciler) Reconcile(_ context.Context, _ reconcile.Request) (reconcile.Result, error) {
+	r.calls++
+	return reconcile.Result{}, nil
+}
+
+func TestIsClusterPaused(t *testing.T) {
+	testCases := []struct {
+		name     string
+		objects  []client.Object
+		expected bool
+	}{
+		{
+			name:     "with nothing paused",
+			expected: false,
+		},
+		{
+			name:     "with the pause ConfigMap in the operator namespace",
+			objects:  []client.Object{pauseConfigMap(operatorNamespace)},
+			expected: true,
+		},
+		{
+			name:     "with the pause ConfigMap in the default namespace",
+			objects:  []client.Object{pauseConfigMap(DefaultClusterPauseNamespace)},
+			expected: false,
+		},
+		{
+			name:     "with the pause ConfigMap in another namespace",
+			objects:  []client.Object{pauseConfigMap("default")},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			c := fake.NewClientBuilder().WithScheme(newPauseScheme(g)).WithObjects(tc.objects...).Build()
+
+			paused, err := IsClusterPaused(context.Background(), c, operatorNamespace)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(paused).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestWithClusterPause(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	machine := &machinev1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "machine",
+			Namespace: "default",
+		},
+	}
+	cm := pauseConfigMap(operatorNamespace)
+
+	c := fake.NewClientBuilder().WithScheme(newPauseScheme(g)).
+		WithObjects(machine, cm).
+		WithStatusSubresource(&machinev1.Machine{}).
+		Build()
+
+	inner := &countingReconciler{}
+	gate := WithClusterPause("test-controller", c, operatorNamespace, inner, func() client.Object { return &machinev1.Machine{} })
+	request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(machine)}
+
+	getMachine := func() *machinev1.Machine {
+		got := &machinev1.Machine{}
+		g.Expect(c.Get(ctx, request.NamespacedName, got)).To(Succeed())
+		return got
+	}
+
+	// Reconciles are skipped without being requeued and the Machine reports the pause while paused
+	result, err := gate.Reconcile(ctx, request)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(result).To(Equal(reconcile.Result{}))
+	g.Expect(inner.calls).To(Equal(0))
+
+	condition := conditions.Get(getMachine(), ClusterPausedCondition)
+	g.Expect(condition).ToNot(BeNil())
+	g.Expect(condition.Status).To(Equal(corev1.ConditionTrue))
+	g.Expect(condition.Reason).To(Equal(ClusterPausedReason))
+
+	// Resuming sets the condition to False and reconciles again
+	g.Expect(c.Delete(ctx, cm)).To(Succeed())
+
+	result, err = gate.Reconcile(ctx, request)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(result).To(Equal(reconcile.Result{}))
+	g.Expect(inner.calls).To(Equal(1))
+
+	condition = conditions.Get(getMachine(), ClusterPausedCondition)
+	g.Expect(condition).ToNot(BeNil())
+	g.Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+	g.Expect(condition.Reason).To(Equal(ClusterResumedReason))
+
+	// Deleted objects are left to the wrapped reconciler
+	result, err = gate.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "deleted"}})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(result).To(Equal(reconcile.Result{}))
+	g.Expect(inner.calls).To(Equal(2))
+}
+
+func TestWithClusterPauseAuthoritativeAPIPause(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	// The Machine is already paused as the Machine API is not authoritative for it
+	authoritativeAPIPaused := conditions.TrueConditionWithReason("Paused", "AuthoritativeAPINotMachineAPI", "The AuthoritativeAPI is set to ClusterAPI")
+	machine := &machinev1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "machine",
+			Namespace: "default",
+		},
+		Status: machinev1.MachineStatus{
+			Conditions: []machinev1.Condition{*authoritativeAPIPaused},
+		},
+	}
+	cm := pauseConfigMap(operatorNamespace)
+
+	c := fake.NewClientBuilder().WithScheme(newPauseScheme(g)).
+		WithObjects(machine, cm).
+		WithStatusSubresource(&machinev1.Machine{}).
+		Build()
+
+	inner := &countingReconciler{}
+	gate := WithClusterPause("test-controller", c, operatorNamespace, inner, func() client.Object { return &machinev1.Machine{} })
+	request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(machine)}
+
+	// The cluster pause is reported alongside the AuthoritativeAPI pause, which is left alone both while the
+	// cluster is paused and once it has resumed
+	for _, resume := range []bool{false, true} {
+		if resume {
+			g.Expect(c.Delete(ctx, cm)).To(Succeed())
+		}
+
+		_, err := gate.Reconcile(ctx, request)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		got := &machinev1.Machine{}
+		g.Expect(c.Get(ctx, request.NamespacedName, got)).To(Succeed())
+
+		condition := conditions.Get(got, authoritativeAPIPaused.Type)
+		g.Expect(condition).ToNot(BeNil())
+		g.Expect(condition.Status).To(Equal(corev1.ConditionTrue))
+		g.Expect(condition.Reason).To(Equal(authoritativeAPIPaused.Reason))
+
+		condition = conditions.Get(got, ClusterPausedCondition)
+		g.Expect(condition).ToNot(BeNil())
+		if resume {
+			g.Expect(condition.Status).To(Equal(corev1.ConditionFalse))
+			g.Expect(condition.Reason).To(Equal(ClusterResumedReason))
+		} else {
+			g.Expect(condition.Status).To(Equal(corev1.ConditionTrue))
+			g.Expect(condition.Reason).To(Equal(ClusterPausedReason))
+		}
+	}
+	g.Expect(inner.calls).To(Equal(1))
+}
+
+func TestWithClusterPauseNeverPaused(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	machineSet := &machinev1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "machineset",
+			Namespace: "default",
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(newPauseScheme(g)).
+		WithObjects(machineSet).
+		WithStatusSubresource(&machinev1.MachineSet{}).
+		Build()
+
+	inner := &countingReconciler{}
+	gate := WithClusterPause("test-controller", c, operatorNamespace, inner, func() client.Object { return &machinev1.MachineSet{} })
+
+	_, err := gate.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(machineSet)})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(inner.calls).To(Equal(1))
+
+	// Objects which were never paused don't get the condition
+	got := &machinev1.MachineSet{}
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(machineSet), got)).To(Succeed())
+	g.Expect(got.Status.Conditions).To(BeEmpty())
+}
+
+func TestClusterPauseRequests(t *testing.T) {
+	g := NewWithT(t)
+
+	machines := []client.Object{
+		&machinev1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "machine-0", Namespace: "default"}},
+		&machinev1.Machine{ObjectMeta: metav1.ObjectMeta{Name: "machine-1", Namespace: "other"}},
+	}
+	c := fake.NewClientBuilder().WithScheme(newPauseScheme(g)).WithObjects(machines...).Build()
+
+	mapFn := clusterPauseRequests(c, func() client.ObjectList { return &machinev1.MachineList{} })
+	g.Expect(mapFn(context.Background(), pauseConfigMap(operatorNamespace))).To(ConsistOf(
+		reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "machine-0"}},
+		reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "other", Name: "machine-1"}},
+	))
+}
+
+func newPauseScheme(g *WithT) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	g.Expect(corev1.AddToScheme(scheme)).To(Succeed())
+	g.Expect(machinev1.Install(scheme)).To(Succeed())
+	return scheme
+}
+
+func pauseConfigMap(namespace string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ClusterPauseConfigMapName,
+			Namespace: namespace,
+		},
+	}
+}
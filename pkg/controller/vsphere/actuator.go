@@ -35,8 +35,12 @@ type Actuator struct {
 	TaskIDCache              map[string]string
 	FeatureGates             featuregate.MutableFeatureGate
 	openshiftConfigNamespace string
+	inFlightTasks            *inFlightTasks
 }
 
+var _ machinecontroller.DriftDetector = &Actuator{}
+var _ machinecontroller.UpdateReporter = &Actuator{}
+
 // ActuatorParams holds parameter information for Actuator.
 type ActuatorParams struct {
 	Client                   runtimeclient.Client
@@ -56,6 +60,7 @@ func NewActuator(params ActuatorParams) *Actuator {
 		TaskIDCache:              params.TaskIDCache,
 		FeatureGates:             params.FeatureGates,
 		openshiftConfigNamespace: params.OpenshiftConfigNamespace,
+		inFlightTasks:            newInFlightTasks(),
 	}
 }
 
@@ -100,6 +105,7 @@ func (a *Actuator) Create(ctx context.Context, machine *machinev1.Machine) error
 	// save the taskRef in our cache in case of any error with patch.
 	if scope.providerStatus.TaskRef != "" {
 		a.TaskIDCache[machine.Name] = scope.providerStatus.TaskRef
+		a.trackTask(scope)
 	}
 	if err != nil {
 		fmtErr := fmt.Errorf(reconcilerFailFmt, machine.GetName(), createEventAction, err)
@@ -132,6 +138,12 @@ func (a *Actuator) Exists(ctx context.Context, machine *machinev1.Machine) (bool
 }
 
 func (a *Actuator) Update(ctx context.Context, machine *machinev1.Machine) error {
+	_, err := a.UpdateWithResult(ctx, machine)
+	return err
+}
+
+// UpdateWithResult updates the machine, and returns true when tags have been attached to the virtual machine.
+func (a *Actuator) UpdateWithResult(ctx context.Context, machine *machinev1.Machine) (bool, error) {
 	klog.Infof("%s: actuator updating machine", machine.GetName())
 	// Cleanup TaskIDCache so we don't continually grow
 	delete(a.TaskIDCache, machine.Name)
@@ -146,20 +158,21 @@ func (a *Actuator) Update(ctx context.Context, machine *machinev1.Machine) error
 	})
 	if err != nil {
 		fmtErr := fmt.Errorf(scopeFailFmt, machine.GetName(), err)
-		return a.handleMachineError(machine, fmtErr, updateEventAction)
+		return false, a.handleMachineError(machine, fmtErr, updateEventAction)
 	}
-	if err := newReconciler(scope).update(); err != nil {
+	updated, err := newReconciler(scope).update()
+	if err != nil {
 		// Update machine and machine status in case it was modified
 		if err := scope.PatchMachine(); err != nil {
-			return err
+			return updated, err
 		}
 		fmtErr := fmt.Errorf(reconcilerFailFmt, machine.GetName(), updateEventAction, err)
-		return a.handleMachineError(machine, fmtErr, updateEventAction)
+		return updated, a.handleMachineError(machine, fmtErr, updateEventAction)
 	}
 	previousResourceVersion := scope.machine.ResourceVersion
 
 	if err := scope.PatchMachine(); err != nil {
-		return err
+		return updated, err
 	}
 
 	currentResourceVersion := scope.machine.ResourceVersion
@@ -169,7 +182,51 @@ func (a *Actuator) Update(ctx context.Context, machine *machinev1.Machine) error
 		a.eventRecorder.Eventf(machine, corev1.EventTypeNormal, updateEventAction, "Updated Machine %v", machine.GetName())
 	}
 
-	return nil
+	return updated, nil
+}
+
+// AttachVolumes attaches the data disks added to the providerSpec to the existing virtual machine.
+func (a *Actuator) AttachVolumes(ctx context.Context, machine *machinev1.Machine) ([]string, error) {
+	if dataDisksUpToDate(machine) {
+		return nil, nil
+	}
+
+	scope, err := newMachineScope(machineScopeParams{
+		Context:                  ctx,
+		client:                   a.client,
+		machine:                  machine,
+		apiReader:                a.apiReader,
+		featureGates:             a.FeatureGates,
+		openshiftConfigNameSpace: a.openshiftConfigNamespace,
+	})
+	if err != nil {
+		return nil, fmt.Errorf(scopeFailFmt, machine.GetName(), err)
+	}
+
+	volumes, err := newReconciler(scope).attachDataDisks()
+	if dataDisksUpToDate(scope.machine) {
+		// Record the generation of the attached data disks
+		if patchErr := scope.PatchMachine(); patchErr != nil {
+			return nil, patchErr
+		}
+	}
+	return volumes, err
+}
+
+// Drift returns the cpu and memory settings of the providerSpec for which the virtual machine differs.
+func (a *Actuator) Drift(ctx context.Context, machine *machinev1.Machine) ([]machinecontroller.ProviderSpecDrift, error) {
+	scope, err := newMachineScope(machineScopeParams{
+		Context:                  ctx,
+		client:                   a.client,
+		machine:                  machine,
+		apiReader:                a.apiReader,
+		featureGates:             a.FeatureGates,
+		openshiftConfigNameSpace: a.openshiftConfigNamespace,
+	})
+	if err != nil {
+		return nil, fmt.Errorf(scopeFailFmt, machine.GetName(), err)
+	}
+	return newReconciler(scope).drift()
 }
 
 func (a *Actuator) Delete(ctx context.Context, machine *machinev1.Machine) error {
@@ -190,7 +247,11 @@ func (a *Actuator) Delete(ctx context.Context, machine *machinev1.Machine) error
 		fmtErr := fmt.Errorf(scopeFailFmt, machine.GetName(), err)
 		return a.handleMachineError(machine, fmtErr, deleteEventAction)
 	}
-	if err := newReconciler(scope).delete(); err != nil {
+	err = newReconciler(scope).delete()
+	if scope.providerStatus.TaskRef != "" {
+		a.trackTask(scope)
+	}
+	if err != nil {
 		if err := scope.PatchMachine(); err != nil {
 			return err
 		}
@@ -200,3 +261,22 @@ func (a *Actuator) Delete(ctx context.Context, machine *machinev1.Machine) error
 	a.eventRecorder.Eventf(machine, corev1.EventTypeNormal, deleteEventAction, "Deleted machine %v", machine.GetName())
 	return scope.PatchMachine()
 }
+
+// trackTask records the task of the machine scope as in flight until it reaches a terminal state.
+func (a *Actuator) trackTask(scope *machineScope) {
+	if a.inFlightTasks == nil {
+		return
+	}
+	taskRef := scope.providerStatus.TaskRef
+	a.inFlightTasks.track(scope.machine.GetName(), taskRef, sessionTaskState(scope.GetSession(), taskRef))
+}
+
+// WaitForTasks blocks until the create and delete tasks started by the actuator have reached
+// a terminal state, or the grace period has elapsed. It is meant to be called on shutdown,
+// once the machine controller has stopped, and returns the references of the tasks still in flight.
+func (a *Actuator) WaitForTasks(gracePeriod time.Duration) []string {
+	if a.inFlightTasks == nil || gracePeriod <= 0 {
+		return nil
+	}
+	return a.inFlightTasks.wait(gracePeriod, taskDrainPollInterval)
+}
@@ -10,6 +10,7 @@ import (
 	machinev1 "github.com/openshift/api/machine/v1beta1"
 	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
 	"github.com/openshift/machine-api-operator/pkg/controller/vsphere/session"
+	"github.com/openshift/machine-api-operator/pkg/render"
 	apicorev1 "k8s.io/api/core/v1"
 	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
 	apimachineryutilerrors "k8s.io/apimachinery/pkg/util/errors"
@@ -20,6 +21,9 @@ import (
 
 const (
 	userDataSecretKey = "userData"
+	// userDataTemplateSecretKey holds a userData template, rendered with the values of the Machine, in place
+	// of the userData key.
+	userDataTemplateSecretKey = "userDataTemplate"
 )
 
 // machineScopeParams defines the input parameters used to create a new MachineScope.
@@ -243,13 +247,35 @@ func (s *machineScope) GetUserData() ([]byte, error) {
 	}
 
 	userData, exists := userDataSecret.Data[userDataSecretKey]
-	if !exists {
+	userDataTemplate, templateExists := userDataSecret.Data[userDataTemplateSecretKey]
+	switch {
+	case exists && templateExists:
+		return nil, machinecontroller.InvalidMachineConfiguration("secret %s sets both the %s and %s keys", objKey, userDataSecretKey, userDataTemplateSecretKey)
+	case templateExists:
+		return s.renderUserData(objKey, userDataTemplate)
+	case !exists:
 		return nil, fmt.Errorf("secret %s missing %s key", objKey, userDataSecretKey)
 	}
 
 	return userData, nil
 }
 
+// renderUserData renders the userData template of the secret with the values of the Machine. The region and
+// zone are only known once the Machine labels have been set from the tags of the virtual machine.
+func (s *machineScope) renderUserData(objKey runtimeclient.ObjectKey, userDataTemplate []byte) ([]byte, error) {
+	userData, err := render.UserData(userDataTemplate, render.MachineContext{
+		Name:      s.machine.Name,
+		Namespace: s.machine.Namespace,
+		Region:    s.machine.Labels[machinecontroller.MachineRegionLabelName],
+		Zone:      s.machine.Labels[machinecontroller.MachineAZLabelName],
+	})
+	if err != nil {
+		return nil, machinecontroller.InvalidMachineConfiguration("secret %s: %v", objKey, err)
+	}
+
+	return userData, nil
+}
+
 // getCredentialsSecret returns the username and password from the VSphere credentials secret.
 // The secret is expected to be in the format documented here:
 // https://vmware.github.io/vsphere-storage-for-kubernetes/documentation/k8s-secret.html
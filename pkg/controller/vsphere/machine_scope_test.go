@@ -74,6 +74,50 @@ func TestGetUserData(t *testing.T) {
 			expectedUserdata: []byte("{}"),
 			expectError:      false,
 		},
+		{
+			testCase: "userData template",
+			userDataSecret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      userDataSecretName,
+					Namespace: TestNamespace,
+				},
+				Data: map[string][]byte{
+					userDataTemplateSecretKey: []byte(`{"hostname": {{ quote .Name }}, "namespace": {{ quote .Namespace }}}`),
+				},
+			},
+			providerSpec:     defaultProviderSpec,
+			expectedUserdata: []byte(`{"hostname": "vsphere-test", "namespace": "vsphere-test"}`),
+			expectError:      false,
+		},
+		{
+			testCase: "userData template referencing an unset value",
+			userDataSecret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      userDataSecretName,
+					Namespace: TestNamespace,
+				},
+				Data: map[string][]byte{
+					userDataTemplateSecretKey: []byte(`{"zone": {{ quote .Zone }}}`),
+				},
+			},
+			providerSpec: defaultProviderSpec,
+			expectError:  true,
+		},
+		{
+			testCase: "both userData and userData template",
+			userDataSecret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      userDataSecretName,
+					Namespace: TestNamespace,
+				},
+				Data: map[string][]byte{
+					userDataSecretKey:         []byte("{}"),
+					userDataTemplateSecretKey: []byte("{}"),
+				},
+			},
+			providerSpec: defaultProviderSpec,
+			expectError:  true,
+		},
 		{
 			testCase:       "missing secret",
 			userDataSecret: nil,
@@ -134,6 +178,10 @@ func TestGetUserData(t *testing.T) {
 				t.Errorf("Unexpected error: %v", err)
 			}
 
+			if tc.expectError && err == nil {
+				t.Errorf("Expected error, got nil")
+			}
+
 			if !bytes.Equal(userData, tc.expectedUserdata) {
 				t.Errorf("Got: %q, Want: %q", userData, tc.expectedUserdata)
 			}
@@ -4,11 +4,16 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
 	machinev1 "github.com/openshift/api/machine/v1beta1"
+	mapicontroller "github.com/openshift/machine-api-operator/pkg/controller"
 	mapierrors "github.com/openshift/machine-api-operator/pkg/controller/machine"
 	vsphereutil "github.com/openshift/machine-api-operator/pkg/controller/vsphere"
+	"github.com/openshift/machine-api-operator/pkg/util/annotations"
+	msutil "github.com/openshift/machine-api-operator/pkg/util/machineset"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -19,11 +24,9 @@ import (
 )
 
 const (
-	// This exposes compute information based on the providerSpec input.
-	// This is needed by the autoscaler to foresee upcoming capacity when scaling from zero.
-	// https://github.com/openshift/enhancements/pull/186
-	cpuKey    = "machine.openshift.io/vCPU"
-	memoryKey = "machine.openshift.io/memoryMb"
+	controllerName = "machineset-controller"
+
+	globalInfrastructureName = "cluster"
 )
 
 // Reconciler reconciles machineSets.
@@ -31,6 +34,10 @@ type Reconciler struct {
 	Client client.Client
 	Log    logr.Logger
 
+	// ClusterPauseNamespace is the namespace of the machine-api-operator, in which it creates the
+	// ClusterPauseConfigMapName ConfigMap to pause the controller.
+	ClusterPauseNamespace string
+
 	recorder record.EventRecorder
 	scheme   *runtime.Scheme
 }
@@ -39,14 +46,15 @@ type Reconciler struct {
 func (r *Reconciler) SetupWithManager(mgr ctrl.Manager, options controller.Options) error {
 	_, err := ctrl.NewControllerManagedBy(mgr).
 		For(&machinev1.MachineSet{}).
+		WatchesRawSource(mapicontroller.ClusterPauseSource(mgr, r.ClusterPauseNamespace, func() client.ObjectList { return &machinev1.MachineSetList{} })).
 		WithOptions(options).
-		Build(r)
+		Build(mapicontroller.WithClusterPause(controllerName, mgr.GetClient(), r.ClusterPauseNamespace, r, func() client.Object { return &machinev1.MachineSet{} }))
 
 	if err != nil {
 		return fmt.Errorf("failed setting up with a controller manager: %w", err)
 	}
 
-	r.recorder = mgr.GetEventRecorderFor("machineset-controller")
+	r.recorder = mgr.GetEventRecorderFor(controllerName)
 	r.scheme = mgr.GetScheme()
 	return nil
 }
@@ -74,7 +82,12 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 	}
 	originalMachineSetToPatch := client.MergeFrom(machineSet.DeepCopy())
 
-	result, err := reconcile(machineSet)
+	failureDomains, err := r.getFailureDomains(ctx)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	result, err := reconcile(machineSet, failureDomains)
 	if err != nil {
 		logger.Error(err, "Failed to reconcile MachineSet")
 		r.recorder.Eventf(machineSet, corev1.EventTypeWarning, "ReconcileError", "%v", err)
@@ -104,19 +117,106 @@ func isInvalidConfigurationError(err error) bool {
 	return false
 }
 
-func reconcile(machineSet *machinev1.MachineSet) (ctrl.Result, error) {
+// getFailureDomains returns the vSphere failure domains of the cluster infrastructure.
+func (r *Reconciler) getFailureDomains(ctx context.Context) ([]configv1.VSpherePlatformFailureDomainSpec, error) {
+	infra := &configv1.Infrastructure{}
+	if err := r.Client.Get(ctx, client.ObjectKey{Name: globalInfrastructureName}, infra); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get infrastructure: %w", err)
+	}
+
+	if infra.Spec.PlatformSpec.VSphere == nil {
+		return nil, nil
+	}
+	return infra.Spec.PlatformSpec.VSphere.FailureDomains, nil
+}
+
+func reconcile(machineSet *machinev1.MachineSet, failureDomains []configv1.VSpherePlatformFailureDomainSpec) (ctrl.Result, error) {
 	providerConfig, err := vsphereutil.ProviderSpecFromRawExtension(machineSet.Spec.Template.Spec.ProviderSpec.Value)
 	if err != nil {
 		return ctrl.Result{}, mapierrors.InvalidMachineConfiguration("failed to get providerConfig: %v", err)
 	}
 
+	defaultOSDiskProvisioningMode(machineSet)
+
+	// This exposes compute information based on the providerSpec input.
+	// This is needed by the autoscaler to foresee upcoming capacity when scaling from zero.
+	// https://github.com/openshift/enhancements/pull/186
+	if !msutil.CanScaleFromZero(machineSet.Spec.Replicas, machineSet.Annotations) {
+		return ctrl.Result{}, nil
+	}
+
 	if machineSet.Annotations == nil {
 		machineSet.Annotations = make(map[string]string)
 	}
 
-	// TODO: get annotations keys from machine API
-	machineSet.Annotations[cpuKey] = strconv.FormatInt(int64(providerConfig.NumCPUs), 10)
-	machineSet.Annotations[memoryKey] = strconv.FormatInt(providerConfig.MemoryMiB, 10)
+	// The cpu and memory annotations are owned by this controller and follow the providerSpec,
+	// the gpu annotation is left to users.
+	machineSet.Annotations[msutil.CpuKeyDeprecated] = strconv.FormatInt(int64(providerConfig.NumCPUs), 10)
+	machineSet.Annotations[msutil.MemoryKeyDeprecated] = strconv.FormatInt(providerConfig.MemoryMiB, 10)
+
+	// The autoscaler needs the region and zone of the machines to balance similar MachineSets across zones.
+	if failureDomain := failureDomainForWorkspace(providerConfig.Workspace, failureDomains); failureDomain != nil {
+		machineSet.Annotations = setFailureDomainAnnotations(machineSet.Annotations, failureDomain)
+	}
 
 	return ctrl.Result{}, nil
 }
+
+// defaultOSDiskProvisioningMode copies the OS disk provisioning mode annotation of the MachineSet to its Machine
+// template, unless the template sets it, so that it is the default of the Machines created by the MachineSet.
+func defaultOSDiskProvisioningMode(machineSet *machinev1.MachineSet) {
+	mode, ok := machineSet.Annotations[annotations.VSphereOSDiskProvisioningModeAnnotation]
+	if !ok {
+		return
+	}
+	if _, ok := machineSet.Spec.Template.ObjectMeta.Annotations[annotations.VSphereOSDiskProvisioningModeAnnotation]; ok {
+		return
+	}
+
+	if machineSet.Spec.Template.ObjectMeta.Annotations == nil {
+		machineSet.Spec.Template.ObjectMeta.Annotations = make(map[string]string)
+	}
+	machineSet.Spec.Template.ObjectMeta.Annotations[annotations.VSphereOSDiskProvisioningModeAnnotation] = mode
+}
+
+// failureDomainForWorkspace returns the failure domain whose topology matches the workspace, if any.
+func failureDomainForWorkspace(workspace *machinev1.Workspace, failureDomains []configv1.VSpherePlatformFailureDomainSpec) *configv1.VSpherePlatformFailureDomainSpec {
+	if workspace == nil {
+		return nil
+	}
+
+	for i := range failureDomains {
+		failureDomain := &failureDomains[i]
+		topology := failureDomain.Topology
+		if failureDomain.Server != workspace.Server || topology.Datacenter != workspace.Datacenter || topology.Datastore != workspace.Datastore {
+			continue
+		}
+		// When the failure domain has no resource pool, machines use the resource pools of its compute cluster
+		if topology.ResourcePool != "" && topology.ResourcePool != workspace.ResourcePool {
+			continue
+		}
+		if topology.ResourcePool == "" && !strings.HasPrefix(workspace.ResourcePool, topology.ComputeCluster+"/") {
+			continue
+		}
+		return failureDomain
+	}
+	return nil
+}
+
+// setFailureDomainAnnotations sets the region and zone annotations of the failure domain, unless they are already set.
+func setFailureDomainAnnotations(annotations map[string]string, failureDomain *configv1.VSpherePlatformFailureDomainSpec) map[string]string {
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	if _, ok := annotations[mapierrors.MachineRegionLabelName]; !ok && failureDomain.Region != "" {
+		annotations[mapierrors.MachineRegionLabelName] = failureDomain.Region
+	}
+	if _, ok := annotations[mapierrors.MachineAZLabelName]; !ok && failureDomain.Zone != "" {
+		annotations[mapierrors.MachineAZLabelName] = failureDomain.Zone
+	}
+	return annotations
+}
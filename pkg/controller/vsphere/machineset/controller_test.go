@@ -23,13 +23,20 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	gtypes "github.com/onsi/gomega/types"
+	configv1 "github.com/openshift/api/config/v1"
 	machinev1 "github.com/openshift/api/machine/v1beta1"
+	mapicontroller "github.com/openshift/machine-api-operator/pkg/controller"
+	mapierrors "github.com/openshift/machine-api-operator/pkg/controller/machine"
+	"github.com/openshift/machine-api-operator/pkg/util/annotations"
+	msutil "github.com/openshift/machine-api-operator/pkg/util/machineset"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -51,8 +58,9 @@ var _ = Describe("Reconciler", func() {
 		Expect(err).ToNot(HaveOccurred())
 
 		r := Reconciler{
-			Client: mgr.GetClient(),
-			Log:    log.Log,
+			Client:                mgr.GetClient(),
+			Log:                   log.Log,
+			ClusterPauseNamespace: mapicontroller.DefaultClusterPauseNamespace,
 		}
 		Expect(r.SetupWithManager(mgr, controller.Options{
 			SkipNameValidation: ptr.To(true),
@@ -117,8 +125,8 @@ var _ = Describe("Reconciler", func() {
 			vmMemoryMiB:         8192,
 			existingAnnotations: make(map[string]string),
 			expectedAnnotations: map[string]string{
-				cpuKey:    "2",
-				memoryKey: "8192",
+				msutil.CpuKeyDeprecated:    "2",
+				msutil.MemoryKeyDeprecated: "8192",
 			},
 			expectedEvents: []string{},
 		}),
@@ -127,8 +135,8 @@ var _ = Describe("Reconciler", func() {
 			vmMemoryMiB:         16384,
 			existingAnnotations: make(map[string]string),
 			expectedAnnotations: map[string]string{
-				cpuKey:    "4",
-				memoryKey: "16384",
+				msutil.CpuKeyDeprecated:    "4",
+				msutil.MemoryKeyDeprecated: "16384",
 			},
 			expectedEvents: []string{},
 		}),
@@ -169,6 +177,7 @@ func TestReconcile(t *testing.T) {
 		name                string
 		vmNumCPUs           int32
 		vmMemoryMiB         int64
+		replicas            *int32
 		existingAnnotations map[string]string
 		expectedAnnotations map[string]string
 		expectErr           bool
@@ -179,8 +188,8 @@ func TestReconcile(t *testing.T) {
 			vmMemoryMiB:         8192,
 			existingAnnotations: make(map[string]string),
 			expectedAnnotations: map[string]string{
-				cpuKey:    "2",
-				memoryKey: "8192",
+				msutil.CpuKeyDeprecated:    "2",
+				msutil.MemoryKeyDeprecated: "8192",
 			},
 			expectErr: false,
 		},
@@ -190,8 +199,64 @@ func TestReconcile(t *testing.T) {
 			vmMemoryMiB:         16384,
 			existingAnnotations: make(map[string]string),
 			expectedAnnotations: map[string]string{
-				cpuKey:    "4",
-				memoryKey: "16384",
+				msutil.CpuKeyDeprecated:    "4",
+				msutil.MemoryKeyDeprecated: "16384",
+			},
+			expectErr: false,
+		},
+		{
+			name:        "with stale cpu and memory annotations",
+			vmNumCPUs:   4,
+			vmMemoryMiB: 16384,
+			existingAnnotations: map[string]string{
+				msutil.CpuKeyDeprecated:    "8",
+				msutil.MemoryKeyDeprecated: "32768",
+			},
+			expectedAnnotations: map[string]string{
+				msutil.CpuKeyDeprecated:    "4",
+				msutil.MemoryKeyDeprecated: "16384",
+			},
+			expectErr: false,
+		},
+		{
+			name:        "with a user provided gpu annotation",
+			vmNumCPUs:   4,
+			vmMemoryMiB: 16384,
+			existingAnnotations: map[string]string{
+				msutil.GpuCountKeyDeprecated: "1",
+			},
+			expectedAnnotations: map[string]string{
+				msutil.CpuKeyDeprecated:      "4",
+				msutil.MemoryKeyDeprecated:   "16384",
+				msutil.GpuCountKeyDeprecated: "1",
+			},
+			expectErr: false,
+		},
+		{
+			name:        "with replicas which cannot reach zero",
+			vmNumCPUs:   4,
+			vmMemoryMiB: 16384,
+			replicas:    ptr.To[int32](3),
+			existingAnnotations: map[string]string{
+				msutil.AutoscalerMinSizeAnnotation: "1",
+			},
+			expectedAnnotations: map[string]string{
+				msutil.AutoscalerMinSizeAnnotation: "1",
+			},
+			expectErr: false,
+		},
+		{
+			name:        "with replicas which the autoscaler can scale to zero",
+			vmNumCPUs:   4,
+			vmMemoryMiB: 16384,
+			replicas:    ptr.To[int32](3),
+			existingAnnotations: map[string]string{
+				msutil.AutoscalerMinSizeAnnotation: "0",
+			},
+			expectedAnnotations: map[string]string{
+				msutil.AutoscalerMinSizeAnnotation: "0",
+				msutil.CpuKeyDeprecated:            "4",
+				msutil.MemoryKeyDeprecated:         "16384",
 			},
 			expectErr: false,
 		},
@@ -203,14 +268,202 @@ func TestReconcile(t *testing.T) {
 
 			machineSet, err := newTestMachineSet("default", tc.vmNumCPUs, tc.vmMemoryMiB, tc.existingAnnotations)
 			g.Expect(err).ToNot(HaveOccurred())
+			machineSet.Spec.Replicas = tc.replicas
 
-			_, err = reconcile(machineSet)
+			_, err = reconcile(machineSet, nil)
 			g.Expect(err != nil).To(Equal(tc.expectErr))
 			g.Expect(machineSet.Annotations).To(Equal(tc.expectedAnnotations))
 		})
 	}
 }
 
+func TestReconcileFailureDomainAnnotations(t *testing.T) {
+	infra := &configv1.Infrastructure{
+		ObjectMeta: metav1.ObjectMeta{Name: globalInfrastructureName},
+		Spec: configv1.InfrastructureSpec{
+			PlatformSpec: configv1.PlatformSpec{
+				Type: configv1.VSpherePlatformType,
+				VSphere: &configv1.VSpherePlatformSpec{
+					FailureDomains: []configv1.VSpherePlatformFailureDomainSpec{
+						{
+							Name:   "us-east-1a",
+							Region: "us-east",
+							Zone:   "us-east-1a",
+							Server: "vcenter.example.com",
+							Topology: configv1.VSpherePlatformTopology{
+								Datacenter:     "dc1",
+								ComputeCluster: "/dc1/host/cluster1",
+								Datastore:      "/dc1/datastore/ds1",
+							},
+						},
+						{
+							Name:   "us-east-1b",
+							Region: "us-east",
+							Zone:   "us-east-1b",
+							Server: "vcenter.example.com",
+							Topology: configv1.VSpherePlatformTopology{
+								Datacenter:     "dc1",
+								ComputeCluster: "/dc1/host/cluster2",
+								Datastore:      "/dc1/datastore/ds2",
+								ResourcePool:   "/dc1/host/cluster2/Resources/pool",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name                string
+		workspace           *machinev1.Workspace
+		existingAnnotations map[string]string
+		expectedRegion      string
+		expectedZone        string
+	}{
+		{
+			name: "with a workspace in the compute cluster of a failure domain",
+			workspace: &machinev1.Workspace{
+				Server:       "vcenter.example.com",
+				Datacenter:   "dc1",
+				Datastore:    "/dc1/datastore/ds1",
+				ResourcePool: "/dc1/host/cluster1/Resources",
+			},
+			expectedRegion: "us-east",
+			expectedZone:   "us-east-1a",
+		},
+		{
+			name: "with a workspace in the resource pool of a failure domain",
+			workspace: &machinev1.Workspace{
+				Server:       "vcenter.example.com",
+				Datacenter:   "dc1",
+				Datastore:    "/dc1/datastore/ds2",
+				ResourcePool: "/dc1/host/cluster2/Resources/pool",
+			},
+			expectedRegion: "us-east",
+			expectedZone:   "us-east-1b",
+		},
+		{
+			name: "with a workspace matching no failure domain",
+			workspace: &machinev1.Workspace{
+				Server:       "vcenter.example.com",
+				Datacenter:   "dc1",
+				Datastore:    "/dc1/datastore/ds1",
+				ResourcePool: "/dc1/host/cluster2/Resources/pool",
+			},
+		},
+		{
+			name: "without workspace",
+		},
+		{
+			name: "with existing region and zone annotations",
+			workspace: &machinev1.Workspace{
+				Server:       "vcenter.example.com",
+				Datacenter:   "dc1",
+				Datastore:    "/dc1/datastore/ds1",
+				ResourcePool: "/dc1/host/cluster1/Resources",
+			},
+			existingAnnotations: map[string]string{
+				mapierrors.MachineRegionLabelName: "my-region",
+				mapierrors.MachineAZLabelName:     "my-zone",
+			},
+			expectedRegion: "my-region",
+			expectedZone:   "my-zone",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			testScheme := runtime.NewScheme()
+			g.Expect(machinev1.Install(testScheme)).To(Succeed())
+			g.Expect(configv1.Install(testScheme)).To(Succeed())
+
+			machineSet, err := newTestMachineSet("default", 4, 16384, tc.existingAnnotations)
+			g.Expect(err).ToNot(HaveOccurred())
+			machineSet.Name = "machineset"
+			machineSet.Spec.Replicas = ptr.To[int32](0)
+			providerSpec, err := providerSpecFromMachine(&machinev1.VSphereMachineProviderSpec{
+				NumCPUs:   4,
+				MemoryMiB: 16384,
+				Workspace: tc.workspace,
+			})
+			g.Expect(err).ToNot(HaveOccurred())
+			machineSet.Spec.Template.Spec.ProviderSpec = providerSpec
+
+			r := &Reconciler{
+				Client:   fake.NewClientBuilder().WithScheme(testScheme).WithObjects(infra, machineSet).Build(),
+				Log:      log.Log,
+				recorder: record.NewFakeRecorder(1),
+				scheme:   testScheme,
+			}
+			_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(machineSet)})
+			g.Expect(err).ToNot(HaveOccurred())
+
+			got := &machinev1.MachineSet{}
+			g.Expect(r.Client.Get(ctx, client.ObjectKeyFromObject(machineSet), got)).To(Succeed())
+			g.Expect(got.Annotations).To(HaveKeyWithValue(msutil.CpuKeyDeprecated, "4"))
+			if tc.expectedRegion == "" {
+				g.Expect(got.Annotations).ToNot(HaveKey(mapierrors.MachineRegionLabelName))
+			} else {
+				g.Expect(got.Annotations).To(HaveKeyWithValue(mapierrors.MachineRegionLabelName, tc.expectedRegion))
+			}
+			if tc.expectedZone == "" {
+				g.Expect(got.Annotations).ToNot(HaveKey(mapierrors.MachineAZLabelName))
+			} else {
+				g.Expect(got.Annotations).To(HaveKeyWithValue(mapierrors.MachineAZLabelName, tc.expectedZone))
+			}
+		})
+	}
+}
+
+func TestReconcileOSDiskProvisioningMode(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		machineSetAnnotations map[string]string
+		templateAnnotations   map[string]string
+		expected              map[string]string
+	}{
+		{
+			name:                "without the MachineSet annotation",
+			templateAnnotations: map[string]string{"foo": "bar"},
+			expected:            map[string]string{"foo": "bar"},
+		},
+		{
+			name:                  "with the MachineSet annotation",
+			machineSetAnnotations: map[string]string{annotations.VSphereOSDiskProvisioningModeAnnotation: "Thin"},
+			templateAnnotations:   map[string]string{"foo": "bar"},
+			expected:              map[string]string{"foo": "bar", annotations.VSphereOSDiskProvisioningModeAnnotation: "Thin"},
+		},
+		{
+			name:                  "with the MachineSet annotation and no template annotations",
+			machineSetAnnotations: map[string]string{annotations.VSphereOSDiskProvisioningModeAnnotation: "Thin"},
+			expected:              map[string]string{annotations.VSphereOSDiskProvisioningModeAnnotation: "Thin"},
+		},
+		{
+			name:                  "with the MachineSet annotation overridden by the template",
+			machineSetAnnotations: map[string]string{annotations.VSphereOSDiskProvisioningModeAnnotation: "Thin"},
+			templateAnnotations:   map[string]string{annotations.VSphereOSDiskProvisioningModeAnnotation: "EagerlyZeroed"},
+			expected:              map[string]string{annotations.VSphereOSDiskProvisioningModeAnnotation: "EagerlyZeroed"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			machineSet, err := newTestMachineSet("default", 4, 16384, tc.machineSetAnnotations)
+			g.Expect(err).ToNot(HaveOccurred())
+			machineSet.Spec.Template.ObjectMeta.Annotations = tc.templateAnnotations
+
+			_, err = reconcile(machineSet, nil)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(machineSet.Spec.Template.ObjectMeta.Annotations).To(Equal(tc.expected))
+		})
+	}
+}
+
 func newTestMachineSet(namespace string, vmNumCPUs int32, vmMemoryMiB int64, existingAnnotations map[string]string) (*machinev1.MachineSet, error) {
 	// Copy anntotations map so we don't modify the input
 	annotations := make(map[string]string)
@@ -41,6 +41,7 @@ import (
 	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
 	"github.com/openshift/machine-api-operator/pkg/controller/vsphere/session"
 	"github.com/openshift/machine-api-operator/pkg/metrics"
+	"github.com/openshift/machine-api-operator/pkg/util/annotations"
 )
 
 const (
@@ -55,6 +56,20 @@ const (
 	// Not all controllers support up to 30, but the maximum is 30.
 	// xref: https://docs.vmware.com/en/VMware-vSphere/8.0/vsphere-vm-administration/GUID-5872D173-A076-42FE-8D0B-9DB0EB0E7362.html#:~:text=If%20you%20add%20a%20hard,values%20from%200%20to%2014.
 	maxUnitNumber = 30
+
+	// diskProvisioningModeThin, diskProvisioningModeThick and diskProvisioningModeEagerlyZeroed are the
+	// provisioning modes which may be requested for the OS disk of a clone.
+	diskProvisioningModeThin          = "Thin"
+	diskProvisioningModeThick         = "Thick"
+	diskProvisioningModeEagerlyZeroed = "EagerlyZeroed"
+
+	// dataDisksGenerationAnnotation records the generation of the Machine whose data disks were last attached
+	// to its virtual machine.
+	dataDisksGenerationAnnotation = "machine.openshift.io/vsphere-data-disks-generation"
+
+	// nodeControlPlaneLabel and nodeMasterLabel mark the nodes of the control plane, whose VMs are never adopted.
+	nodeControlPlaneLabel = "node-role.kubernetes.io/control-plane"
+	nodeMasterLabel       = "node-role.kubernetes.io/master"
 )
 
 // These are the guestinfo variables used by Ignition.
@@ -127,6 +142,10 @@ func (r *Reconciler) create() error {
 		}
 	}
 
+	if providerID, ok := r.machine.GetAnnotations()[machinecontroller.AdoptProviderIDAnnotation]; ok {
+		return r.adopt(providerID)
+	}
+
 	// We only clone the VM template if we have no taskRef.
 	if r.providerStatus.TaskRef == "" {
 		klog.V(4).Infof("%v: ProviderStatus does not have TaskRef", r.machine.GetName())
@@ -162,6 +181,12 @@ func (r *Reconciler) create() error {
 
 		klog.Infof("%v: cloning", r.machine.GetName())
 		task, err := clone(r.machineScope)
+		var spaceErr *insufficientDatastoreSpaceError
+		if errors.As(err, &spaceErr) {
+			klog.Warningf("%v: waiting for free space on the datastore: %v", r.machine.GetName(), err)
+			r.providerStatus.Conditions = setConditions(conditionInsufficientDatastoreSpace(spaceErr), r.providerStatus.Conditions)
+			return &machinecontroller.RequeueAfterError{RequeueAfter: datastoreSpaceRequeuePeriod}
+		}
 		if err != nil {
 			metrics.RegisterFailedInstanceCreate(&metrics.MachineLabels{
 				Name:      r.machine.Name,
@@ -176,6 +201,7 @@ func (r *Reconciler) create() error {
 			}
 			return err
 		}
+		r.providerStatus.Conditions = removeCondition(r.providerStatus.Conditions, datastoreSpaceCondition)
 		return setProviderStatus(task, conditionSuccess(), r.machineScope, nil)
 	}
 
@@ -208,6 +234,7 @@ func (r *Reconciler) create() error {
 			if statusError != nil {
 				return fmt.Errorf("failed to set provider status: %w", statusError)
 			}
+			r.providerStatus.Conditions = setConditions(conditionTaskFailed(moTask, err), r.providerStatus.Conditions)
 			return machinecontroller.CreateMachine("%s", err.Error())
 		} else {
 			return fmt.Errorf("failed to check task status: %w", err)
@@ -215,7 +242,9 @@ func (r *Reconciler) create() error {
 	} else {
 		if taskIsFinished {
 			klog.V(4).Infof("%v task %v has completed", moTask.Info.DescriptionId, moTask.Reference().Value)
+			r.providerStatus.Conditions = removeCondition(r.providerStatus.Conditions, instanceCreateCondition)
 		} else {
+			r.providerStatus.Conditions = setConditions(conditionTaskInProgress(moTask), r.providerStatus.Conditions)
 			return fmt.Errorf("%v task %v has not finished", moTask.Info.DescriptionId, moTask.Reference().Value)
 		}
 	}
@@ -259,10 +288,68 @@ func (r *Reconciler) create() error {
 	return nil
 }
 
-// update finds a vm and reconciles the machine resource status against it.
-func (r *Reconciler) update() error {
+// adopt takes over the existing VM of the providerID given by the AdoptProviderIDAnnotation instead of cloning
+// a new one. A VM already owned by another Machine, or running a control plane node, is never adopted.
+// The adopted VM is recorded in the provider status, which is what findVM reads from then on.
+// The VM is powered on when it is powered off, the next reconcile updates the machine from it.
+func (r *Reconciler) adopt(providerID string) error {
+	if _, ok := adoptedBIOSUUID(providerID); !ok {
+		return machinecontroller.InvalidMachineConfiguration("%s annotation %q must be a providerID of the form %s<BIOS UUID>", machinecontroller.AdoptProviderIDAnnotation, providerID, providerIDPrefix)
+	}
+
+	if err := r.checkAdoptable(providerID); err != nil {
+		return err
+	}
+
+	vmRef, err := findVMToAdopt(r.machineScope, providerID)
+	if err != nil {
+		if !isNotFound(err) {
+			return err
+		}
+		metrics.RegisterFailedInstanceCreate(&metrics.MachineLabels{
+			Name:      r.machine.Name,
+			Namespace: r.machine.Namespace,
+			Reason:    "Instance to adopt not found",
+		})
+		return machinecontroller.InvalidMachineConfiguration("instance %s to adopt, given by the %s annotation, not found", providerID, machinecontroller.AdoptProviderIDAnnotation)
+	}
+
+	vm := &virtualMachine{
+		Context: r.Context,
+		Obj:     object.NewVirtualMachine(r.session.Client.Client, vmRef),
+		Ref:     vmRef,
+	}
+	powerState, err := vm.getPowerState()
+	if err != nil {
+		return fmt.Errorf("%v: failed checking the power state of the adopted instance: %w", r.machine.GetName(), err)
+	}
+
+	klog.Infof("%v: adopting instance %s", r.machine.GetName(), providerID)
+	if err := setProviderStatus("", conditionSuccess(), r.machineScope, vm); err != nil {
+		return fmt.Errorf("failed to set provider status: %w", err)
+	}
+	if powerState != types.VirtualMachinePowerStatePoweredOff {
+		return nil
+	}
+
+	klog.Infof("%v: powering on adopted instance %s", r.machine.GetName(), providerID)
+	task, err := powerOn(r.machineScope)
+	if err != nil {
+		conditionFailed := conditionFailed()
+		conditionFailed.Message = err.Error()
+		if statusError := setProviderStatus(task, conditionFailed, r.machineScope, nil); statusError != nil {
+			return fmt.Errorf("failed to set provider status: %w", statusError)
+		}
+		return fmt.Errorf("%v: failed to power on adopted instance: %w", r.machine.GetName(), err)
+	}
+	return setProviderStatus(task, conditionSuccess(), r.machineScope, nil)
+}
+
+// update finds a vm and reconciles the machine resource status against it. It returns true when the vm has
+// been changed, which only happens when tags are attached to it.
+func (r *Reconciler) update() (bool, error) {
 	if err := validateMachine(*r.machine); err != nil {
-		return fmt.Errorf("%v: failed validating machine provider spec: %w", r.machine.GetName(), err)
+		return false, fmt.Errorf("%v: failed validating machine provider spec: %w", r.machine.GetName(), err)
 	}
 
 	if r.providerStatus.TaskRef != "" {
@@ -274,7 +361,7 @@ func (r *Reconciler) update() error {
 					Namespace: r.machine.Namespace,
 					Reason:    "GetTask finished with error",
 				})
-				return err
+				return false, err
 			}
 		}
 		if moTask != nil {
@@ -284,9 +371,9 @@ func (r *Reconciler) update() error {
 					Namespace: r.machine.Namespace,
 					Reason:    "Task finished with error",
 				})
-				return fmt.Errorf("%v task %v finished with error: %w", moTask.Info.DescriptionId, moTask.Reference().Value, err)
+				return false, fmt.Errorf("%v task %v finished with error: %w", moTask.Info.DescriptionId, moTask.Reference().Value, err)
 			} else if !taskIsFinished {
-				return fmt.Errorf("%v task %v has not finished", moTask.Info.DescriptionId, moTask.Reference().Value)
+				return false, fmt.Errorf("%v task %v has not finished", moTask.Info.DescriptionId, moTask.Reference().Value)
 			}
 		}
 	}
@@ -299,9 +386,9 @@ func (r *Reconciler) update() error {
 			Reason:    "FindVM finished with error",
 		})
 		if !isNotFound(err) {
-			return err
+			return false, err
 		}
-		return fmt.Errorf("vm not found on update: %w", err)
+		return false, fmt.Errorf("vm not found on update: %w", err)
 	}
 
 	vm := &virtualMachine{
@@ -310,13 +397,14 @@ func (r *Reconciler) update() error {
 		Ref:     vmRef,
 	}
 
-	if err := vm.reconcileTags(r.Context, r.session, r.machine, r.providerSpec); err != nil {
+	tagsAttached, err := vm.reconcileTags(r.Context, r.session, r.machine, r.providerSpec)
+	if err != nil {
 		metrics.RegisterFailedInstanceUpdate(&metrics.MachineLabels{
 			Name:      r.machine.Name,
 			Namespace: r.machine.Namespace,
 			Reason:    "ReconcileTags finished with error",
 		})
-		return fmt.Errorf("failed to reconcile tags: %w", err)
+		return false, fmt.Errorf("failed to reconcile tags: %w", err)
 	}
 
 	if err := r.reconcileMachineWithCloudState(vm, r.providerStatus.TaskRef); err != nil {
@@ -325,10 +413,164 @@ func (r *Reconciler) update() error {
 			Namespace: r.machine.Namespace,
 			Reason:    "ReconcileWithCloudState finished with error",
 		})
-		return err
+		return false, err
 	}
 
-	return nil
+	return tagsAttached, nil
+}
+
+// drift returns the cpu and memory settings of the providerSpec for which the virtual machine differs, as they
+// are only applied when the virtual machine is cloned. Nothing is reported for a missing virtual machine.
+func (r *Reconciler) drift() ([]machinecontroller.ProviderSpecDrift, error) {
+	if err := validateMachine(*r.machine); err != nil {
+		return nil, fmt.Errorf("%v: failed validating machine provider spec: %w", r.machine.GetName(), err)
+	}
+
+	vmRef, err := findVM(r.machineScope)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var vm mo.VirtualMachine
+	vmObj := object.NewVirtualMachine(r.machineScope.session.Client.Client, vmRef)
+	if err := vmObj.Properties(r.Context, vmRef, []string{"config.hardware"}, &vm); err != nil {
+		return nil, fmt.Errorf("%v: failed to get the hardware of the vm: %w", r.machine.GetName(), err)
+	}
+	if vm.Config == nil {
+		return nil, fmt.Errorf("%v: config.hardware of the vm is nil", r.machine.GetName())
+	}
+	hardware := vm.Config.Hardware
+
+	// The same defaults as clone apply
+	numCoresPerSocket := r.providerSpec.NumCoresPerSocket
+	if numCoresPerSocket == 0 {
+		numCoresPerSocket = r.providerSpec.NumCPUs
+	}
+
+	var drifts []machinecontroller.ProviderSpecDrift
+	if r.providerSpec.NumCPUs > 0 && hardware.NumCPU != r.providerSpec.NumCPUs {
+		drifts = append(drifts, machinecontroller.ProviderSpecDrift{
+			Field:    "numCPUs",
+			Expected: strconv.Itoa(int(r.providerSpec.NumCPUs)),
+			Actual:   strconv.Itoa(int(hardware.NumCPU)),
+		})
+	}
+	if numCoresPerSocket > 0 && hardware.NumCoresPerSocket != numCoresPerSocket {
+		drifts = append(drifts, machinecontroller.ProviderSpecDrift{
+			Field:    "numCoresPerSocket",
+			Expected: strconv.Itoa(int(numCoresPerSocket)),
+			Actual:   strconv.Itoa(int(hardware.NumCoresPerSocket)),
+		})
+	}
+	if r.providerSpec.MemoryMiB > 0 && int64(hardware.MemoryMB) != r.providerSpec.MemoryMiB {
+		drifts = append(drifts, machinecontroller.ProviderSpecDrift{
+			Field:    "memoryMiB",
+			Expected: strconv.FormatInt(r.providerSpec.MemoryMiB, 10),
+			Actual:   strconv.Itoa(int(hardware.MemoryMB)),
+		})
+	}
+	return drifts, nil
+}
+
+// dataDisksUpToDate returns whether the data disks of the Machine have been attached to its virtual machine
+// since the last change of its spec.
+func dataDisksUpToDate(machine *machinev1.Machine) bool {
+	return machine.GetAnnotations()[dataDisksGenerationAnnotation] == strconv.FormatInt(machine.GetGeneration(), 10)
+}
+
+// attachDataDisks adds the data disks appended to the providerSpec to an existing virtual machine,
+// and returns their names. The virtual machine is only looked up when the Machine spec changed since
+// the data disks were last attached, which is recorded by the dataDisksGenerationAnnotation.
+func (r *Reconciler) attachDataDisks() ([]string, error) {
+	if dataDisksUpToDate(r.machine) {
+		return nil, nil
+	}
+
+	names, err := r.attachNewDataDisks()
+	if err != nil && !errors.Is(err, machinecontroller.ErrVolumeAttachUnsupported) {
+		return nil, err
+	}
+
+	// Unsupported changes are reported once, until the spec changes again
+	metav1.SetMetaDataAnnotation(&r.machine.ObjectMeta, dataDisksGenerationAnnotation, strconv.FormatInt(r.machine.GetGeneration(), 10))
+	return names, err
+}
+
+// attachNewDataDisks adds the data disks of the providerSpec which are missing from the virtual machine.
+// Data disks are matched by position, as their names are not recorded on the virtual machine.
+func (r *Reconciler) attachNewDataDisks() ([]string, error) {
+	if len(r.providerSpec.DataDisks) == 0 {
+		return nil, nil
+	}
+
+	vmRef, err := findVM(r.machineScope)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	vm := object.NewVirtualMachine(r.session.Client.Client, vmRef)
+	devices, err := vm.Device(r.Context)
+	if err != nil {
+		return nil, fmt.Errorf("error getting devices: %w", err)
+	}
+
+	existingDataDisks, err := countDataDisks(devices)
+	if err != nil {
+		return nil, err
+	}
+	if existingDataDisks > len(r.providerSpec.DataDisks) {
+		return nil, fmt.Errorf("virtual machine has %d data disks but %d are defined, removing data disks: %w",
+			existingDataDisks, len(r.providerSpec.DataDisks), machinecontroller.ErrVolumeAttachUnsupported)
+	}
+	if existingDataDisks == len(r.providerSpec.DataDisks) {
+		return nil, nil
+	}
+
+	newDataDisks := r.providerSpec.DataDisks[existingDataDisks:]
+	diskSpecs, err := newDataDiskSpecs(r.machineScope, devices, newDataDisks)
+	if err != nil {
+		return nil, fmt.Errorf("error getting additional disk specs: %w", err)
+	}
+
+	task, err := vm.Reconfigure(r.Context, types.VirtualMachineConfigSpec{DeviceChange: diskSpecs})
+	if err != nil {
+		return nil, fmt.Errorf("error triggering reconfigure op for machine %v: %w", r.machine.GetName(), err)
+	}
+	if err := task.Wait(r.Context); err != nil {
+		return nil, fmt.Errorf("error attaching data disks to machine %v: %w", r.machine.GetName(), err)
+	}
+
+	names := make([]string, 0, len(newDataDisks))
+	for _, dataDisk := range newDataDisks {
+		names = append(names, dataDisk.Name)
+	}
+	return names, nil
+}
+
+// countDataDisks returns the number of data disks of the virtual machine. Like newDataDiskSpecs, data disks are
+// the disks on the controller of the primary disk, other than the primary disk. First class disks, such as the
+// volumes attached by the vSphere CSI driver, are not data disks.
+func countDataDisks(devices object.VirtualDeviceList) (int, error) {
+	disks := devices.SelectByType((*types.VirtualDisk)(nil))
+	if len(disks) == 0 {
+		return 0, fmt.Errorf("invalid disk count: %d", len(disks))
+	}
+	primaryDisk := disks[0].(*types.VirtualDisk)
+
+	count := 0
+	for _, device := range disks[1:] {
+		disk := device.(*types.VirtualDisk)
+		if disk.ControllerKey == primaryDisk.ControllerKey && disk.VDiskId == nil {
+			count++
+		}
+	}
+	return count, nil
 }
 
 // exists returns true if machine exists.
@@ -718,6 +960,10 @@ func validateMachine(machine machinev1.Machine) error {
 }
 
 func findVM(s *machineScope) (types.ManagedObjectReference, error) {
+	if _, ok := s.machine.GetAnnotations()[machinecontroller.AdoptProviderIDAnnotation]; ok {
+		return findAdoptedVM(s)
+	}
+
 	uuid := string(s.machine.UID)
 
 	vm, err := s.GetSession().FindVM(s.Context, uuid, s.machine.Name)
@@ -735,6 +981,76 @@ func findVM(s *machineScope) (types.ManagedObjectReference, error) {
 	return vm.Reference(), nil
 }
 
+// adoptedBIOSUUID returns the BIOS UUID of the providerID given by the AdoptProviderIDAnnotation, and false
+// when it is not a vSphere providerID.
+func adoptedBIOSUUID(providerID string) (string, bool) {
+	biosUUID, ok := strings.CutPrefix(providerID, providerIDPrefix)
+	return biosUUID, ok && uuid.Validate(biosUUID) == nil
+}
+
+// findAdoptedVM finds the VM adopted by a Machine with the AdoptProviderIDAnnotation, by the BIOS UUID recorded
+// in the provider status once adopted. The VM is not found until it is adopted, so that a VM which was not taken
+// over by the create path is never acted upon, and in particular never deleted.
+func findAdoptedVM(s *machineScope) (types.ManagedObjectReference, error) {
+	biosUUID := ptr.Deref(s.providerStatus.InstanceID, "")
+	if biosUUID == "" {
+		return types.ManagedObjectReference{}, errNotFound{uuid: s.machine.GetAnnotations()[machinecontroller.AdoptProviderIDAnnotation]}
+	}
+	return findVMByBIOSUUID(s, biosUUID)
+}
+
+// findVMToAdopt finds the VM of the providerID given by the AdoptProviderIDAnnotation, by its BIOS UUID.
+// A malformed providerID is not found.
+func findVMToAdopt(s *machineScope, providerID string) (types.ManagedObjectReference, error) {
+	biosUUID, ok := adoptedBIOSUUID(providerID)
+	if !ok {
+		return types.ManagedObjectReference{}, errNotFound{uuid: providerID}
+	}
+	return findVMByBIOSUUID(s, biosUUID)
+}
+
+// findVMByBIOSUUID finds a VM by its BIOS UUID.
+func findVMByBIOSUUID(s *machineScope, biosUUID string) (types.ManagedObjectReference, error) {
+	ref, err := s.GetSession().FindRefByBIOSUUID(s.Context, biosUUID)
+	if err != nil {
+		return types.ManagedObjectReference{}, err
+	}
+	if ref == nil {
+		return types.ManagedObjectReference{}, errNotFound{uuid: biosUUID}
+	}
+	return ref.Reference(), nil
+}
+
+// checkAdoptable returns an invalid configuration error when the VM of the providerID is owned by another Machine,
+// or runs a control plane node, so that a mistaken annotation never hands such a VM over to this Machine.
+func (r *Reconciler) checkAdoptable(providerID string) error {
+	machines := &machinev1.MachineList{}
+	if err := r.client.List(r.Context, machines); err != nil {
+		return fmt.Errorf("%v: failed to list machines: %w", r.machine.GetName(), err)
+	}
+	for _, m := range machines.Items {
+		if m.UID != r.machine.UID && ptr.Deref(m.Spec.ProviderID, "") == providerID {
+			return machinecontroller.InvalidMachineConfiguration("instance %s to adopt, given by the %s annotation, is already owned by Machine %s/%s", providerID, machinecontroller.AdoptProviderIDAnnotation, m.Namespace, m.Name)
+		}
+	}
+
+	nodes := &corev1.NodeList{}
+	if err := r.client.List(r.Context, nodes); err != nil {
+		return fmt.Errorf("%v: failed to list nodes: %w", r.machine.GetName(), err)
+	}
+	for _, node := range nodes.Items {
+		if node.Spec.ProviderID != providerID {
+			continue
+		}
+		for _, label := range []string{nodeControlPlaneLabel, nodeMasterLabel} {
+			if _, ok := node.Labels[label]; ok {
+				return machinecontroller.InvalidMachineConfiguration("instance %s to adopt, given by the %s annotation, runs control plane node %s", providerID, machinecontroller.AdoptProviderIDAnnotation, node.Name)
+			}
+		}
+	}
+	return nil
+}
+
 // errNotFound is returned by the findVM function when a VM is not found.
 type errNotFound struct {
 	instanceUUID bool
@@ -958,25 +1274,22 @@ func clone(s *machineScope) (string, error) {
 
 	folder, err := s.GetSession().Finder.FolderOrDefault(s, folderPath)
 	if err != nil {
-		const multipleFoundMsg = "multiple folders found, specify one in config"
-		const notFoundMsg = "folder not found, specify valid value"
+		multipleFoundMsg, notFoundMsg := workspaceLookupMessages("folder", folderPath)
 		defaultError := fmt.Errorf("unable to get folder for %q: %w", folderPath, err)
 		return "", handleVSphereError(multipleFoundMsg, notFoundMsg, defaultError, err)
 	}
 
 	datastore, err := s.GetSession().Finder.DatastoreOrDefault(s, datastorePath)
 	if err != nil {
-		const multipleFoundMsg = "multiple datastores found, specify one in config"
-		const notFoundMsg = "datastore not found, specify valid value"
+		multipleFoundMsg, notFoundMsg := workspaceLookupMessages("datastore", datastorePath)
 		defaultError := fmt.Errorf("unable to get datastore for %q: %w", datastorePath, err)
 		return "", handleVSphereError(multipleFoundMsg, notFoundMsg, defaultError, err)
 	}
 
 	resourcepool, err := s.GetSession().Finder.ResourcePoolOrDefault(s, resourcepoolPath)
 	if err != nil {
-		const multipleFoundMsg = "multiple resource pools found, specify one in config"
-		const notFoundMsg = "resource pool not found, specify valid value"
-		defaultError := fmt.Errorf("unable to get resource pool for %q: %w", resourcepool, err)
+		multipleFoundMsg, notFoundMsg := workspaceLookupMessages("resource pool", resourcepoolPath)
+		defaultError := fmt.Errorf("unable to get resource pool for %q: %w", resourcepoolPath, err)
 		return "", handleVSphereError(multipleFoundMsg, notFoundMsg, defaultError, err)
 	}
 
@@ -1004,6 +1317,16 @@ func clone(s *machineScope) (string, error) {
 		deviceSpecs = append(deviceSpecs, diskSpec)
 	}
 
+	osDiskLocator, err := getOSDiskLocator(s, devices, datastore.Reference())
+	if err != nil {
+		return "", fmt.Errorf("error getting OS disk locator: %w", err)
+	}
+	if osDiskLocator != nil && snapshotRef != nil {
+		klog.Warningf("%v: LinkedClone mode is set. OS disk provisioning mode from the %s annotation will be ignored",
+			s.machine.GetName(), annotations.VSphereOSDiskProvisioningModeAnnotation)
+		osDiskLocator = nil
+	}
+
 	// Process all DataDisks definitions to dynamically create and add disks to the VM
 	additionalDisks, err := createDataDisks(s, devices)
 	if err != nil {
@@ -1011,6 +1334,10 @@ func clone(s *machineScope) (string, error) {
 	}
 	deviceSpecs = append(deviceSpecs, additionalDisks...)
 
+	if err := checkDatastoreFreeSpace(s, datastore, deviceSpecs, osDiskLocator); err != nil {
+		return "", err
+	}
+
 	klog.V(3).Infof("Getting network devices")
 	networkDevices, err := getNetworkDevices(s, resourcepool, devices)
 	if err != nil {
@@ -1069,6 +1396,9 @@ func clone(s *machineScope) (string, error) {
 		PowerOn:  false, // Create powered off machine, for power it on later in "create" procedure
 		Snapshot: snapshotRef,
 	}
+	if osDiskLocator != nil {
+		spec.Location.Disk = []types.VirtualMachineRelocateSpecDiskLocator{*osDiskLocator}
+	}
 
 	task, err := vmTemplate.Clone(s, folder, s.machine.GetName(), spec)
 	if err != nil {
@@ -1211,11 +1541,116 @@ func getDiskSpec(s *machineScope, devices object.VirtualDeviceList) (types.BaseV
 	}, nil
 }
 
+// getOSDiskLocator returns the locator placing the OS disk of the clone on the datastore with the provisioning mode
+// requested by the VSphereOSDiskProvisioningModeAnnotation of the machine. It returns nil when no mode is requested,
+// in which case the OS disk keeps the provisioning mode of the template disk.
+func getOSDiskLocator(s *machineScope, devices object.VirtualDeviceList, datastore types.ManagedObjectReference) (*types.VirtualMachineRelocateSpecDiskLocator, error) {
+	mode, ok := s.machine.GetAnnotations()[annotations.VSphereOSDiskProvisioningModeAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	var thinProvisioned, eagerlyScrub bool
+	switch mode {
+	case diskProvisioningModeThin:
+		thinProvisioned = true
+	case diskProvisioningModeThick:
+	case diskProvisioningModeEagerlyZeroed:
+		eagerlyScrub = true
+	default:
+		return nil, machinecontroller.InvalidMachineConfiguration(
+			"invalid %s annotation %q, expected one of %s, %s or %s", annotations.VSphereOSDiskProvisioningModeAnnotation,
+			mode, diskProvisioningModeEagerlyZeroed, diskProvisioningModeThick, diskProvisioningModeThin)
+	}
+
+	disks := devices.SelectByType((*types.VirtualDisk)(nil))
+	if len(disks) == 0 {
+		return nil, fmt.Errorf("invalid disk count: %d", len(disks))
+	}
+	disk := disks[0].(*types.VirtualDisk)
+
+	return &types.VirtualMachineRelocateSpecDiskLocator{
+		DiskId:    disk.Key,
+		Datastore: datastore,
+		DiskBackingInfo: &types.VirtualDiskFlatVer2BackingInfo{
+			DiskMode:        string(types.VirtualDiskModePersistent),
+			ThinProvisioned: types.NewBool(thinProvisioned),
+			EagerlyScrub:    types.NewBool(eagerlyScrub),
+		},
+	}, nil
+}
+
+// insufficientDatastoreSpaceError is returned when the datastore cannot hold the disks of the clone.
+// It is not terminal, the clone is retried until enough space is freed on the datastore.
+type insufficientDatastoreSpaceError struct {
+	datastore   string
+	requiredKB  int64
+	availableKB int64
+}
+
+func (e *insufficientDatastoreSpaceError) Error() string {
+	return fmt.Sprintf("insufficient free space on datastore %q: %dKiB required, %dKiB available",
+		e.datastore, e.requiredKB, e.availableKB)
+}
+
+// checkDatastoreFreeSpace ensures the datastore can hold the disks of the clone before it is triggered.
+// Disks that are not part of deviceSpecs, such as the delta disk of a linked clone, are not accounted for.
+func checkDatastoreFreeSpace(s *machineScope, datastore *object.Datastore, deviceSpecs []types.BaseVirtualDeviceConfigSpec, osDiskLocator *types.VirtualMachineRelocateSpecDiskLocator) error {
+	requiredKB := requiredDatastoreSpaceKB(deviceSpecs, osDiskLocator)
+	if requiredKB == 0 {
+		return nil
+	}
+
+	var ds mo.Datastore
+	if err := datastore.Properties(s.Context, datastore.Reference(), []string{"summary"}, &ds); err != nil {
+		return fmt.Errorf("unable to get summary of datastore %q: %w", datastore.Name(), err)
+	}
+
+	requiredBytes := requiredKB * 1024
+	if requiredBytes > ds.Summary.FreeSpace {
+		return &insufficientDatastoreSpaceError{
+			datastore:   datastore.Name(),
+			requiredKB:  requiredKB,
+			availableKB: ds.Summary.FreeSpace / 1024,
+		}
+	}
+
+	return nil
+}
+
+// requiredDatastoreSpaceKB returns the space allocated upfront on the datastore for the disks of deviceSpecs.
+// Only thick provisioned disks, lazily or eagerly zeroed, are allocated their full capacity, thin provisioned disks
+// are not accounted for. The OS disk is provisioned with the backing of osDiskLocator when it is not nil.
+func requiredDatastoreSpaceKB(deviceSpecs []types.BaseVirtualDeviceConfigSpec, osDiskLocator *types.VirtualMachineRelocateSpecDiskLocator) int64 {
+	var requiredKB int64
+	for _, spec := range deviceSpecs {
+		disk, ok := spec.GetVirtualDeviceConfigSpec().Device.(*types.VirtualDisk)
+		if !ok {
+			continue
+		}
+
+		backing := disk.Backing
+		if osDiskLocator != nil && osDiskLocator.DiskId == disk.Key {
+			backing = osDiskLocator.DiskBackingInfo
+		}
+		if flat, ok := backing.(*types.VirtualDiskFlatVer2BackingInfo); ok && ptr.Deref(flat.ThinProvisioned, false) {
+			continue
+		}
+		requiredKB += disk.CapacityInKB
+	}
+	return requiredKB
+}
+
 func createDataDisks(s *machineScope, devices object.VirtualDeviceList) ([]types.BaseVirtualDeviceConfigSpec, error) {
+	return newDataDiskSpecs(s, devices, s.providerSpec.DataDisks)
+}
+
+// newDataDiskSpecs returns the specs adding the given data disks to the controller of the primary disk.
+func newDataDiskSpecs(s *machineScope, devices object.VirtualDeviceList, dataDisks []machinev1.VSphereDisk) ([]types.BaseVirtualDeviceConfigSpec, error) {
 	var diskSpecs []types.BaseVirtualDeviceConfigSpec
 
 	// Only add additional disks if the feature gate is enabled.
-	if len(s.providerSpec.DataDisks) > 0 && !s.featureGates.Enabled(featuregate.Feature(apifeatures.FeatureGateVSphereMultiDisk)) {
+	if len(dataDisks) > 0 && !s.featureGates.Enabled(featuregate.Feature(apifeatures.FeatureGateVSphereMultiDisk)) {
 		return nil, machinecontroller.InvalidMachineConfiguration(
 			"machines cannot contain additional disks due to VSphereMultiDisk feature gate being disabled")
 	}
@@ -1242,7 +1677,7 @@ func createDataDisks(s *machineScope, devices object.VirtualDeviceList) ([]types
 	}
 
 	// Let's create the data disks now
-	for i, dataDisk := range s.providerSpec.DataDisks {
+	for i, dataDisk := range dataDisks {
 		klog.V(2).InfoS("Adding disk", "name", dataDisk.Name, "spec", dataDisk)
 
 		dev := &types.VirtualDisk{
@@ -1461,6 +1896,15 @@ func setProviderStatus(taskRef string, condition metav1.Condition, scope *machin
 	return nil
 }
 
+// workspaceLookupMessages returns the messages of the errors returned when the workspace object of the given kind
+// is not found or is ambiguous. The path is left out when the default object of the datacenter is looked up.
+func workspaceLookupMessages(kind, path string) (string, string) {
+	if path == "" {
+		return fmt.Sprintf("multiple %ss found, specify one in config", kind), fmt.Sprintf("%s not found, specify valid value", kind)
+	}
+	return fmt.Sprintf("multiple %ss found for %q, specify one in config", kind, path), fmt.Sprintf("%s %q not found, specify valid value", kind, path)
+}
+
 func handleVSphereError(multipleFoundMsg, notFoundMsg string, defaultError, vsphereError error) error {
 	var multipleFoundError *find.MultipleFoundError
 	if errors.As(vsphereError, &multipleFoundError) {
@@ -1588,7 +2032,9 @@ func (vm *virtualMachine) getPowerState() (types.VirtualMachinePowerState, error
 
 // reconcileTags ensures that the required tags are present on the virtual machine, eg the Cluster ID
 // that is used by the installer on cluster deletion to ensure ther are no leaked resources.
-func (vm *virtualMachine) reconcileTags(ctx context.Context, sessionInstance *session.Session, machine *machinev1.Machine, providerSpec *machinev1.VSphereMachineProviderSpec) error {
+// It returns true when a tag has been attached.
+func (vm *virtualMachine) reconcileTags(ctx context.Context, sessionInstance *session.Session, machine *machinev1.Machine, providerSpec *machinev1.VSphereMachineProviderSpec) (bool, error) {
+	tagsAttached := false
 	if err := sessionInstance.WithCachingTagsManager(vm.Context, func(c *session.CachingTagsManager) error {
 		klog.Infof("%v: Reconciling attached tags", machine.GetName())
 
@@ -1608,14 +2054,15 @@ func (vm *virtualMachine) reconcileTags(ctx context.Context, sessionInstance *se
 				if err := c.AttachTag(ctx, tagID, vm.Ref); err != nil {
 					return err
 				}
+				tagsAttached = true
 			}
 		}
 		return nil
 	}); err != nil {
-		return err
+		return tagsAttached, err
 	}
 
-	return nil
+	return tagsAttached, nil
 }
 
 // checkAttachedTag returns true if tag is already attached to a vm or tag doesn't exist
@@ -23,6 +23,7 @@ import (
 	"os"
 	"path"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -33,6 +34,7 @@ import (
 	"github.com/vmware/govmomi/vapi/rest"
 	"github.com/vmware/govmomi/vapi/tags"
 	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
 
@@ -41,6 +43,7 @@ import (
 	apimachinerytypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	vsphere "k8s.io/cloud-provider-vsphere/pkg/common/config"
+	"k8s.io/utils/ptr"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
@@ -52,6 +55,7 @@ import (
 
 	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
 	"github.com/openshift/machine-api-operator/pkg/controller/vsphere/session"
+	"github.com/openshift/machine-api-operator/pkg/util/annotations"
 	testutils "github.com/openshift/machine-api-operator/pkg/util/testing"
 
 	_ "github.com/vmware/govmomi/vapi/simulator"
@@ -206,9 +210,11 @@ func TestClone(t *testing.T) {
 		testCase              string
 		cloneVM               bool
 		expectedError         error
+		expectedRetry         bool
 		setupFailureCondition func() error
 		providerSpec          machinev1.VSphereMachineProviderSpec
 		machineName           string
+		machineAnnotations    map[string]string
 	}{
 		{
 			testCase: "clone machine from default values",
@@ -265,6 +271,43 @@ func TestClone(t *testing.T) {
 			cloneVM:     true,
 			machineName: "test0",
 		},
+		{
+			testCase: "clone machine with an OS disk provisioning mode",
+			providerSpec: machinev1.VSphereMachineProviderSpec{
+				CredentialsSecret: &corev1.LocalObjectReference{
+					Name: "test",
+				},
+				Workspace: &machinev1.Workspace{
+					Server: server.URL.Host,
+				},
+				DiskGiB:  diskSize,
+				Template: vm.Name,
+				UserDataSecret: &corev1.LocalObjectReference{
+					Name: userDataSecretName,
+				},
+			},
+			machineAnnotations: map[string]string{annotations.VSphereOSDiskProvisioningModeAnnotation: "EagerlyZeroed"},
+			cloneVM:            true,
+			machineName:        "test2",
+		},
+		{
+			testCase: "fail on invalid OS disk provisioning mode",
+			providerSpec: machinev1.VSphereMachineProviderSpec{
+				CredentialsSecret: &corev1.LocalObjectReference{
+					Name: "test",
+				},
+				Workspace: &machinev1.Workspace{
+					Server: server.URL.Host,
+				},
+				DiskGiB:  diskSize,
+				Template: vm.Name,
+				UserDataSecret: &corev1.LocalObjectReference{
+					Name: userDataSecretName,
+				},
+			},
+			machineAnnotations: map[string]string{annotations.VSphereOSDiskProvisioningModeAnnotation: "Sparse"},
+			expectedError:      errors.New("error getting OS disk locator: invalid machine.openshift.io/vsphere-os-disk-provisioning-mode annotation \"Sparse\", expected one of EagerlyZeroed, Thick or Thin"),
+		},
 		{
 			testCase: "fail on disc resize down",
 			providerSpec: machinev1.VSphereMachineProviderSpec{
@@ -297,7 +340,7 @@ func TestClone(t *testing.T) {
 					Name: userDataSecretName,
 				},
 			},
-			expectedError: errors.New("resource pool not found, specify valid value"),
+			expectedError: errors.New("resource pool \"invalid\" not found, specify valid value"),
 		},
 		{
 			testCase: "fail on multiple resource pools",
@@ -314,7 +357,7 @@ func TestClone(t *testing.T) {
 					Name: userDataSecretName,
 				},
 			},
-			expectedError: errors.New("multiple resource pools found, specify one in config"),
+			expectedError: errors.New("multiple resource pools found for \"/DC0/host/DC0_C0/Resources/...\", specify one in config"),
 			setupFailureCondition: func() error {
 				// Create resource pools
 				defaultResourcePool, err := session.Finder.ResourcePool(context.Background(), "/DC0/host/DC0_C0/Resources")
@@ -335,7 +378,7 @@ func TestClone(t *testing.T) {
 		},
 		{
 			testCase:      "fail on invalid folder",
-			expectedError: errors.New("folder not found, specify valid value"),
+			expectedError: errors.New("folder \"invalid\" not found, specify valid value"),
 			providerSpec: machinev1.VSphereMachineProviderSpec{
 				CredentialsSecret: &corev1.LocalObjectReference{
 					Name: "test",
@@ -365,7 +408,7 @@ func TestClone(t *testing.T) {
 					Name: userDataSecretName,
 				},
 			},
-			expectedError: errors.New("multiple folders found, specify one in config"),
+			expectedError: errors.New("multiple folders found for \"/DC0/vm/...\", specify one in config"),
 			setupFailureCondition: func() error {
 				// Create folders
 				defaultFolder, err := session.Finder.Folder(context.Background(), "/DC0/vm")
@@ -398,7 +441,7 @@ func TestClone(t *testing.T) {
 					Name: userDataSecretName,
 				},
 			},
-			expectedError: errors.New("datastore not found, specify valid value"),
+			expectedError: errors.New("datastore \"invalid\" not found, specify valid value"),
 		},
 		{
 			testCase: "fail on multiple datastores",
@@ -415,7 +458,7 @@ func TestClone(t *testing.T) {
 					Name: userDataSecretName,
 				},
 			},
-			expectedError: errors.New("multiple datastores found, specify one in config"),
+			expectedError: errors.New("multiple datastores found for \"/DC0/...\", specify one in config"),
 			setupFailureCondition: func() error {
 				// Create datastores
 				hostSystem, err := session.Finder.HostSystem(context.Background(), "/DC0/host/DC0_C0/DC0_C0_H0")
@@ -451,6 +494,47 @@ func TestClone(t *testing.T) {
 			},
 			expectedError: errors.New("template not found, specify valid value"),
 		},
+		{
+			testCase: "fail on insufficient datastore free space",
+			providerSpec: machinev1.VSphereMachineProviderSpec{
+				CredentialsSecret: &corev1.LocalObjectReference{
+					Name: "test",
+				},
+				Workspace: &machinev1.Workspace{
+					Server:    server.URL.Host,
+					Datastore: "small-datastore",
+				},
+				DiskGiB:  diskSize,
+				Template: vm.Name,
+				UserDataSecret: &corev1.LocalObjectReference{
+					Name: userDataSecretName,
+				},
+			},
+			machineAnnotations: map[string]string{annotations.VSphereOSDiskProvisioningModeAnnotation: "Thick"},
+			expectedRetry:      true,
+			expectedError:      fmt.Errorf("insufficient free space on datastore \"small-datastore\": %vKiB required, %vKiB available", diskSize*1024*1024, 1024*1024),
+			setupFailureCondition: func() error {
+				// Create a datastore with only 1GiB of free space
+				hostSystem, err := session.Finder.HostSystem(context.Background(), "/DC0/host/DC0_C0/DC0_C0_H0")
+				if err != nil {
+					return err
+				}
+				dss, err := hostSystem.ConfigManager().DatastoreSystem(context.Background())
+				if err != nil {
+					return err
+				}
+				dir, err := os.MkdirTemp("", "tmpdir")
+				if err != nil {
+					return err
+				}
+				ds, err := dss.CreateLocalDatastore(context.Background(), "small-datastore", dir)
+				if err != nil {
+					return err
+				}
+				simulator.Map.Get(ds.Reference()).(*simulator.Datastore).Summary.FreeSpace = 1024 * 1024 * 1024
+				return nil
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -464,6 +548,7 @@ func TestClone(t *testing.T) {
 			if tc.machineName != "" {
 				machineScope.machine.Name = tc.machineName
 			}
+			machineScope.machine.Annotations = tc.machineAnnotations
 
 			taskRef, err := clone(machineScope)
 
@@ -477,6 +562,10 @@ func TestClone(t *testing.T) {
 				if err.Error() != tc.expectedError.Error() {
 					t.Fatalf("expected: %v, got %v", tc.expectedError, err)
 				}
+				var spaceErr *insufficientDatastoreSpaceError
+				if errors.As(err, &spaceErr) != tc.expectedRetry {
+					t.Fatalf("expected the clone to be retried to be %v, got error %T", tc.expectedRetry, err)
+				}
 			} else {
 				if err != nil {
 					t.Fatalf("clone() was not expected to return error: %v", err)
@@ -829,6 +918,268 @@ func TestTaskIsFinished(t *testing.T) {
 	}
 }
 
+func TestCreateTaskProgressCondition(t *testing.T) {
+	g := NewWithT(t)
+
+	model, session, server := initSimulator(t)
+	defer model.Remove()
+	defer server.Close()
+
+	ctx := context.TODO()
+	taskTypeID := "com.openshift.machine-api.test"
+	extensionManager := object.NewExtensionManager(session.Client.Client)
+	g.Expect(extensionManager.Register(ctx, types.Extension{
+		Key:      taskTypeID,
+		TaskList: []types.ExtensionTaskTypeInfo{{TaskID: taskTypeID}},
+	})).To(Succeed())
+
+	vm := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine)
+	res, err := methods.CreateTask(ctx, session.Client.Client, &types.CreateTask{
+		This:       *session.Client.Client.ServiceContent.TaskManager,
+		Obj:        vm.Reference(),
+		TaskTypeId: taskTypeID,
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+	taskRef := res.Returnval.Task
+	task := object.NewTask(session.Client.Client, taskRef)
+
+	machine := &machinev1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "test",
+			Labels: map[string]string{
+				machinev1.MachineClusterIDLabel: "CLUSTERID",
+			},
+		},
+	}
+	reconciler := newReconciler(&machineScope{
+		Context:      ctx,
+		machine:      machine,
+		providerSpec: &machinev1.VSphereMachineProviderSpec{Workspace: &machinev1.Workspace{}},
+		providerStatus: &machinev1.VSphereMachineProviderStatus{
+			TaskRef: taskRef.Value,
+		},
+		session: session,
+	})
+
+	instanceCreate := func() *metav1.Condition {
+		return findCondition(reconciler.providerStatus.Conditions, instanceCreateCondition)
+	}
+
+	// The task is created asynchronously by the simulator
+	g.Eventually(func() error {
+		_, err := session.GetTask(ctx, taskRef.Value)
+		return err
+	}).Should(Succeed())
+
+	g.Expect(reconciler.create()).ToNot(Succeed())
+	g.Expect(instanceCreate()).ToNot(BeNil())
+	g.Expect(instanceCreate().Status).To(Equal(metav1.ConditionFalse))
+	g.Expect(instanceCreate().Reason).To(Equal(instanceCreateInProgressReason))
+	g.Expect(instanceCreate().Message).To(ContainSubstring("is queued"))
+
+	g.Expect(task.SetState(ctx, types.TaskInfoStateRunning, nil, nil)).To(Succeed())
+	for _, progress := range []int{10, 60} {
+		g.Expect(task.UpdateProgress(ctx, progress)).To(Succeed())
+
+		g.Expect(reconciler.create()).ToNot(Succeed())
+		g.Expect(instanceCreate().Reason).To(Equal(instanceCreateInProgressReason))
+		g.Expect(instanceCreate().Message).To(Equal(fmt.Sprintf("%s task %s is %d%% complete", taskTypeID, taskRef.Value, progress)))
+	}
+
+	g.Expect(task.SetState(ctx, types.TaskInfoStateSuccess, nil, nil)).To(Succeed())
+	g.Expect(reconciler.create()).To(Succeed())
+	g.Expect(instanceCreate()).To(BeNil())
+}
+
+func TestCreateTaskFailedCondition(t *testing.T) {
+	g := NewWithT(t)
+
+	model, session, server := initSimulator(t)
+	defer model.Remove()
+	defer server.Close()
+
+	obj := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine)
+	obj.Runtime.PowerState = types.VirtualMachinePowerStatePoweredOff
+	vm := object.NewVirtualMachine(session.Client.Client, obj.Reference())
+	task, err := vm.PowerOff(context.TODO())
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(task.Wait(context.TODO())).ToNot(Succeed())
+
+	reconciler := newReconciler(&machineScope{
+		Context: context.TODO(),
+		machine: &machinev1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test",
+				Namespace: "test",
+				Labels: map[string]string{
+					machinev1.MachineClusterIDLabel: "CLUSTERID",
+				},
+			},
+		},
+		providerSpec: &machinev1.VSphereMachineProviderSpec{Workspace: &machinev1.Workspace{}},
+		providerStatus: &machinev1.VSphereMachineProviderStatus{
+			TaskRef: task.Reference().Value,
+		},
+		session: session,
+	})
+
+	g.Expect(reconciler.create()).ToNot(Succeed())
+	condition := findCondition(reconciler.providerStatus.Conditions, instanceCreateCondition)
+	g.Expect(condition).ToNot(BeNil())
+	g.Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+	g.Expect(condition.Reason).To(Equal(instanceCreateFailedReason))
+}
+
+func TestAdoptInstance(t *testing.T) {
+	model, session, server := initSimulator(t)
+	defer model.Remove()
+	defer server.Close()
+
+	vm := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine)
+	vmProviderID := providerIDPrefix + vm.Config.Uuid
+	provisioning := machinev1.PhaseProvisioning
+
+	testCases := []struct {
+		name          string
+		providerID    string
+		objects       []runtimeclient.Object
+		expectedError string
+	}{
+		{
+			name:       "with an existing instance",
+			providerID: vmProviderID,
+			objects: []runtimeclient.Object{
+				&corev1.Node{
+					ObjectMeta: metav1.ObjectMeta{Name: "worker", Labels: map[string]string{"node-role.kubernetes.io/worker": ""}},
+					Spec:       corev1.NodeSpec{ProviderID: vmProviderID},
+				},
+			},
+		},
+		{
+			name:          "with an instance which does not exist",
+			providerID:    providerIDPrefix + "4225d5f2-6d19-4a0a-b3f8-8e6e0f0e7a1c",
+			expectedError: "instance vsphere://4225d5f2-6d19-4a0a-b3f8-8e6e0f0e7a1c to adopt, given by the machine.openshift.io/adopt-provider-id annotation, not found",
+		},
+		{
+			name:          "with a providerID of another platform",
+			providerID:    "aws:///us-east-1a/i-0123456789abcdef0",
+			expectedError: "machine.openshift.io/adopt-provider-id annotation \"aws:///us-east-1a/i-0123456789abcdef0\" must be a providerID of the form vsphere://<BIOS UUID>",
+		},
+		{
+			name:       "with an instance owned by another Machine",
+			providerID: vmProviderID,
+			objects: []runtimeclient.Object{
+				&machinev1.Machine{
+					ObjectMeta: metav1.ObjectMeta{Name: "owner", Namespace: "test", UID: "owner"},
+					Spec:       machinev1.MachineSpec{ProviderID: ptr.To(vmProviderID)},
+				},
+			},
+			expectedError: fmt.Sprintf("instance %s to adopt, given by the machine.openshift.io/adopt-provider-id annotation, is already owned by Machine test/owner", vmProviderID),
+		},
+		{
+			name:       "with an instance running a control plane node",
+			providerID: vmProviderID,
+			objects: []runtimeclient.Object{
+				&corev1.Node{
+					ObjectMeta: metav1.ObjectMeta{Name: "master-0", Labels: map[string]string{"node-role.kubernetes.io/master": ""}},
+					Spec:       corev1.NodeSpec{ProviderID: vmProviderID},
+				},
+			},
+			expectedError: fmt.Sprintf("instance %s to adopt, given by the machine.openshift.io/adopt-provider-id annotation, runs control plane node master-0", vmProviderID),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			machine := &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "adopt",
+					Namespace: "test",
+					UID:       "adopt",
+					Labels: map[string]string{
+						machinev1.MachineClusterIDLabel: "CLUSTERID",
+					},
+					Annotations: map[string]string{
+						machinecontroller.AdoptProviderIDAnnotation: tc.providerID,
+					},
+				},
+				Status: machinev1.MachineStatus{
+					Phase: &provisioning,
+				},
+			}
+
+			reconciler := newReconciler(&machineScope{
+				Context:        context.TODO(),
+				client:         fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(tc.objects...).Build(),
+				machine:        machine,
+				providerSpec:   &machinev1.VSphereMachineProviderSpec{Workspace: &machinev1.Workspace{}},
+				providerStatus: &machinev1.VSphereMachineProviderStatus{},
+				session:        session,
+			})
+
+			// The instance is not found until it is adopted by the create path
+			exists, err := reconciler.exists()
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(exists).To(BeFalse())
+
+			err = reconciler.create()
+			if tc.expectedError == "" {
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(reconciler.providerStatus.InstanceID).To(HaveValue(Equal(vm.Config.Uuid)))
+
+				exists, err := reconciler.exists()
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(exists).To(BeTrue())
+
+				vmRef, err := findVM(reconciler.machineScope)
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(vmRef).To(Equal(vm.Reference()))
+				return
+			}
+
+			var machineError *machinecontroller.MachineError
+			g.Expect(errors.As(err, &machineError)).To(BeTrue())
+			g.Expect(machineError.Reason).To(Equal(machinev1.InvalidConfigurationMachineError))
+			g.Expect(machineError.Message).To(Equal(tc.expectedError))
+			g.Expect(reconciler.providerStatus.InstanceID).To(BeNil())
+		})
+	}
+}
+
+func TestFindAdoptedVMIgnoresAnnotationChanges(t *testing.T) {
+	g := NewWithT(t)
+
+	model, session, server := initSimulator(t)
+	defer model.Remove()
+	defer server.Close()
+
+	vms := simulator.Map.All("VirtualMachine")
+	g.Expect(len(vms)).To(BeNumerically(">=", 2))
+	adopted := vms[0].(*simulator.VirtualMachine)
+	other := vms[1].(*simulator.VirtualMachine)
+
+	// The annotation points to another VM than the one recorded when the Machine adopted its VM
+	scope := &machineScope{
+		Context: context.TODO(),
+		machine: &machinev1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "adopt",
+				Namespace:   "test",
+				Annotations: map[string]string{machinecontroller.AdoptProviderIDAnnotation: providerIDPrefix + other.Config.Uuid},
+			},
+		},
+		providerStatus: &machinev1.VSphereMachineProviderStatus{InstanceID: ptr.To(adopted.Config.Uuid)},
+		session:        session,
+	}
+
+	vmRef, err := findVM(scope)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(vmRef).To(Equal(adopted.Reference()))
+}
+
 func TestStaticIPs(t *testing.T) {
 	model, session, server := initSimulator(t)
 	defer model.Remove()
@@ -1053,7 +1404,7 @@ func testGetNetworkDevicesWithSimulator(t *testing.T, model *simulator.Model, se
 	}{
 		{
 			testCase:     "no Network",
-			providerSpec: &machinev1.VSphereMachineProviderSpec{},
+			providerSpec: &machinev1.VSphereMachineProviderSpec{Workspace: &machinev1.Workspace{}},
 			expected: func(gotDevices []types.BaseVirtualDeviceConfigSpec, err error) bool {
 				if err != nil {
 					t.Fatal(err)
@@ -1279,6 +1630,176 @@ func TestGetDiskSpec(t *testing.T) {
 	}
 }
 
+func TestGetOSDiskLocator(t *testing.T) {
+	model, session, server := initSimulator(t)
+	defer model.Remove()
+	defer server.Close()
+
+	managedObj := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine)
+	objVM := object.NewVirtualMachine(session.Client.Client, managedObj.Reference())
+	devices, err := objVM.Device(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	disk := devices.SelectByType((*types.VirtualDisk)(nil))[0].(*types.VirtualDisk)
+	datastore := types.ManagedObjectReference{Type: "Datastore", Value: "datastore-1"}
+
+	testCases := []struct {
+		name                    string
+		annotations             map[string]string
+		expectedLocator         bool
+		expectedThinProvisioned bool
+		expectedEagerlyScrub    bool
+		expectedError           error
+	}{
+		{
+			name: "Keep the template provisioning mode without annotation",
+		},
+		{
+			name:                    "Thin provisioned OS disk",
+			annotations:             map[string]string{annotations.VSphereOSDiskProvisioningModeAnnotation: "Thin"},
+			expectedLocator:         true,
+			expectedThinProvisioned: true,
+		},
+		{
+			name:            "Thick provisioned OS disk",
+			annotations:     map[string]string{annotations.VSphereOSDiskProvisioningModeAnnotation: "Thick"},
+			expectedLocator: true,
+		},
+		{
+			name:                 "Eagerly zeroed OS disk",
+			annotations:          map[string]string{annotations.VSphereOSDiskProvisioningModeAnnotation: "EagerlyZeroed"},
+			expectedLocator:      true,
+			expectedEagerlyScrub: true,
+		},
+		{
+			name:          "Fail on invalid provisioning mode",
+			annotations:   map[string]string{annotations.VSphereOSDiskProvisioningModeAnnotation: "thin"},
+			expectedError: errors.New("invalid machine.openshift.io/vsphere-os-disk-provisioning-mode annotation \"thin\", expected one of EagerlyZeroed, Thick or Thin"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			machineScope := &machineScope{
+				Context: context.TODO(),
+				machine: &machinev1.Machine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "test",
+						Annotations: tc.annotations,
+					},
+				},
+				session: session,
+			}
+			locator, err := getOSDiskLocator(machineScope, devices, datastore)
+
+			if tc.expectedError != nil {
+				if err == nil {
+					t.Fatal("getOSDiskLocator was expected to return an error")
+				}
+				if tc.expectedError.Error() != err.Error() {
+					t.Fatalf("Expected error %v , got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !tc.expectedLocator {
+				if locator != nil {
+					t.Fatalf("Expected no OS disk locator, got %+v", locator)
+				}
+				return
+			}
+			if locator == nil {
+				t.Fatal("Expected an OS disk locator")
+			}
+			if locator.DiskId != disk.Key {
+				t.Fatalf("Expected disk id to be %v, got %v", disk.Key, locator.DiskId)
+			}
+			if locator.Datastore != datastore {
+				t.Fatalf("Expected datastore to be %v, got %v", datastore, locator.Datastore)
+			}
+			backing := locator.DiskBackingInfo.(*types.VirtualDiskFlatVer2BackingInfo)
+			if *backing.ThinProvisioned != tc.expectedThinProvisioned {
+				t.Fatalf("Expected thin provisioned to be %v, got %v", tc.expectedThinProvisioned, *backing.ThinProvisioned)
+			}
+			if *backing.EagerlyScrub != tc.expectedEagerlyScrub {
+				t.Fatalf("Expected eagerly scrub to be %v, got %v", tc.expectedEagerlyScrub, *backing.EagerlyScrub)
+			}
+		})
+	}
+}
+
+func TestRequiredDatastoreSpaceKB(t *testing.T) {
+	const capacityInKB = 1024 * 1024
+
+	newDiskSpec := func(key int32, thinProvisioned *bool) types.BaseVirtualDeviceConfigSpec {
+		return &types.VirtualDeviceConfigSpec{
+			Operation: types.VirtualDeviceConfigSpecOperationAdd,
+			Device: &types.VirtualDisk{
+				VirtualDevice: types.VirtualDevice{
+					Key:     key,
+					Backing: &types.VirtualDiskFlatVer2BackingInfo{ThinProvisioned: thinProvisioned},
+				},
+				CapacityInKB: capacityInKB,
+			},
+		}
+	}
+	newOSDiskLocator := func(thinProvisioned bool) *types.VirtualMachineRelocateSpecDiskLocator {
+		return &types.VirtualMachineRelocateSpecDiskLocator{
+			DiskId:          1,
+			DiskBackingInfo: &types.VirtualDiskFlatVer2BackingInfo{ThinProvisioned: ptr.To(thinProvisioned)},
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		deviceSpecs   []types.BaseVirtualDeviceConfigSpec
+		osDiskLocator *types.VirtualMachineRelocateSpecDiskLocator
+		expectedKB    int64
+	}{
+		{
+			name:        "Thick provisioned disks are counted",
+			deviceSpecs: []types.BaseVirtualDeviceConfigSpec{newDiskSpec(1, nil), newDiskSpec(2, ptr.To(false))},
+			expectedKB:  2 * capacityInKB,
+		},
+		{
+			name:        "Thin provisioned disks are not counted",
+			deviceSpecs: []types.BaseVirtualDeviceConfigSpec{newDiskSpec(1, nil), newDiskSpec(2, ptr.To(true))},
+			expectedKB:  capacityInKB,
+		},
+		{
+			name:          "Thin provisioning mode of the OS disk is used",
+			deviceSpecs:   []types.BaseVirtualDeviceConfigSpec{newDiskSpec(1, nil), newDiskSpec(2, ptr.To(true))},
+			osDiskLocator: newOSDiskLocator(true),
+			expectedKB:    0,
+		},
+		{
+			name:          "Thick provisioning mode of the OS disk is used",
+			deviceSpecs:   []types.BaseVirtualDeviceConfigSpec{newDiskSpec(1, ptr.To(true))},
+			osDiskLocator: newOSDiskLocator(false),
+			expectedKB:    capacityInKB,
+		},
+		{
+			name: "Other devices are not counted",
+			deviceSpecs: []types.BaseVirtualDeviceConfigSpec{
+				&types.VirtualDeviceConfigSpec{Device: &types.VirtualVmxnet3{}},
+			},
+			expectedKB: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if requiredKB := requiredDatastoreSpaceKB(tc.deviceSpecs, tc.osDiskLocator); requiredKB != tc.expectedKB {
+				t.Fatalf("Expected %dKiB to be required, got %dKiB", tc.expectedKB, requiredKB)
+			}
+		})
+	}
+}
+
 func printOperations(networkDevices []types.BaseVirtualDeviceConfigSpec) string {
 	var output string
 	for i := range networkDevices {
@@ -1419,6 +1940,154 @@ func TestCreateDataDisks(t *testing.T) {
 	}
 }
 
+func TestAttachDataDisks(t *testing.T) {
+	model, session, server := initSimulator(t)
+	t.Cleanup(model.Remove)
+	t.Cleanup(server.Close)
+
+	vm := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine)
+	instanceUUID := "a5764857-ae35-34dc-8f25-a9c9e73aa898"
+	vm.Config.InstanceUuid = instanceUUID
+
+	countDisks := func() int {
+		devices, err := object.NewVirtualMachine(session.Client.Client, vm.Reference()).Device(context.TODO())
+		if err != nil {
+			t.Fatalf("Failed to obtain vm devices: %v", err)
+		}
+		return len(devices.SelectByType((*types.VirtualDisk)(nil)))
+	}
+	initialDisks := countDisks()
+
+	getReconciler := func(dataDisks []machinev1.VSphereDisk, generation int64, annotations map[string]string) *Reconciler {
+		gates, _ := testutils.NewDefaultMutableFeatureGate()
+		return newReconciler(&machineScope{
+			Context: context.TODO(),
+			machine: &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test",
+					Namespace:   "test",
+					UID:         apimachinerytypes.UID(instanceUUID),
+					Generation:  generation,
+					Annotations: annotations,
+				},
+			},
+			providerSpec: &machinev1.VSphereMachineProviderSpec{
+				DataDisks: dataDisks,
+			},
+			session:        session,
+			providerStatus: &machinev1.VSphereMachineProviderStatus{},
+			featureGates:   gates,
+		})
+	}
+
+	// addVolume attaches a disk to a new controller of the virtual machine, like the volumes of the vSphere CSI driver.
+	addVolume := func(t *testing.T) {
+		vmObj := object.NewVirtualMachine(session.Client.Client, vm.Reference())
+		devices, err := vmObj.Device(context.TODO())
+		if err != nil {
+			t.Fatalf("Failed to obtain vm devices: %v", err)
+		}
+		controller, err := devices.CreateSCSIController("pvscsi")
+		if err != nil {
+			t.Fatalf("Failed to create controller: %v", err)
+		}
+		if err := vmObj.AddDevice(context.TODO(), controller); err != nil {
+			t.Fatalf("Failed to add controller: %v", err)
+		}
+
+		devices, err = vmObj.Device(context.TODO())
+		if err != nil {
+			t.Fatalf("Failed to obtain vm devices: %v", err)
+		}
+		controllers := devices.SelectByType((*types.ParaVirtualSCSIController)(nil))
+		newController := controllers[len(controllers)-1].(types.BaseVirtualController)
+		disk := devices.CreateDisk(newController, types.ManagedObjectReference{}, "")
+		disk.CapacityInKB = 1024 * 1024
+		if err := vmObj.AddDevice(context.TODO(), disk); err != nil {
+			t.Fatalf("Failed to add volume: %v", err)
+		}
+	}
+
+	// Steps run in order against the same virtual machine
+	steps := []struct {
+		name                string
+		dataDisks           []machinev1.VSphereDisk
+		upToDate            bool
+		addVolume           bool
+		expectedNames       []string
+		expectedDisks       int
+		expectedUnsupported bool
+	}{
+		{
+			name:          "without data disks",
+			expectedDisks: initialDisks,
+		},
+		{
+			name:          "attach a data disk",
+			dataDisks:     []machinev1.VSphereDisk{{Name: "disk_0", SizeGiB: 1}},
+			expectedNames: []string{"disk_0"},
+			expectedDisks: initialDisks + 1,
+		},
+		{
+			name:          "with the data disk already attached",
+			dataDisks:     []machinev1.VSphereDisk{{Name: "disk_0", SizeGiB: 1}},
+			expectedDisks: initialDisks + 1,
+		},
+		{
+			name:          "with a volume attached to another controller",
+			dataDisks:     []machinev1.VSphereDisk{{Name: "disk_0", SizeGiB: 1}},
+			addVolume:     true,
+			expectedDisks: initialDisks + 2,
+		},
+		{
+			name:          "with the data disks attached since the last spec change",
+			dataDisks:     []machinev1.VSphereDisk{{Name: "disk_0", SizeGiB: 1}, {Name: "disk_1", SizeGiB: 2}},
+			upToDate:      true,
+			expectedDisks: initialDisks + 2,
+		},
+		{
+			name:          "attach another data disk",
+			dataDisks:     []machinev1.VSphereDisk{{Name: "disk_0", SizeGiB: 1}, {Name: "disk_1", SizeGiB: 2}},
+			expectedNames: []string{"disk_1"},
+			expectedDisks: initialDisks + 3,
+		},
+		{
+			name:                "remove a data disk",
+			dataDisks:           []machinev1.VSphereDisk{{Name: "disk_0", SizeGiB: 1}},
+			expectedDisks:       initialDisks + 3,
+			expectedUnsupported: true,
+		},
+	}
+
+	for i, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			if step.addVolume {
+				addVolume(t)
+			}
+
+			// Each step changes the spec, unless the data disks are up to date
+			generation := int64(i + 1)
+			annotations := map[string]string{dataDisksGenerationAnnotation: strconv.FormatInt(generation-1, 10)}
+			if step.upToDate {
+				annotations[dataDisksGenerationAnnotation] = strconv.FormatInt(generation, 10)
+			}
+
+			r := getReconciler(step.dataDisks, generation, annotations)
+			names, err := r.attachDataDisks()
+			if step.expectedUnsupported {
+				g.Expect(errors.Is(err, machinecontroller.ErrVolumeAttachUnsupported)).To(BeTrue(), "expected unsupported error, got %v", err)
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+			g.Expect(names).To(Equal(step.expectedNames))
+			g.Expect(countDisks()).To(Equal(step.expectedDisks))
+			g.Expect(dataDisksUpToDate(r.machine)).To(BeTrue())
+		})
+	}
+}
+
 func createAdditionalDisks(devices object.VirtualDeviceList, controller types.BaseVirtualController, numOfDisks int) object.VirtualDeviceList {
 	deviceList := devices
 	disks := devices.SelectByType((*types.VirtualDisk)(nil))
@@ -1649,12 +2318,13 @@ func TestReconcileTags(t *testing.T) {
 				}
 			}
 
-			err := vm.reconcileTags(context.TODO(), sessionObj, &machinev1.Machine{
+			machine := &machinev1.Machine{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:   "machine",
 					Labels: map[string]string{machinev1.MachineClusterIDLabel: tc.tagName},
 				},
-			}, providerSpec)
+			}
+			attached, err := vm.reconcileTags(context.TODO(), sessionObj, machine, providerSpec)
 
 			if tc.expectedError {
 				if err == nil {
@@ -1664,8 +2334,18 @@ func TestReconcileTags(t *testing.T) {
 				if err != nil {
 					t.Fatalf("Not expected error %v", err)
 				}
+				if attached != tc.attachTag {
+					t.Fatalf("Expected tags attached to be %v, got %v", tc.attachTag, attached)
+				}
 
 				if tc.attachTag {
+					// Tags which are already attached are not reported again
+					if attached, err := vm.reconcileTags(context.TODO(), sessionObj, machine, providerSpec); err != nil {
+						t.Fatalf("Not expected error %v", err)
+					} else if attached {
+						t.Fatal("Expected no tags to be attached on the second reconcile")
+					}
+
 					if err := sessionObj.WithCachingTagsManager(context.TODO(), func(tagMgr *session.CachingTagsManager) error {
 
 						tags, err := tagMgr.GetAttachedTags(context.TODO(), managedObjRef)
@@ -2662,6 +3342,7 @@ func TestCreate(t *testing.T) {
 		ipAddressClaim        *ipamv1beta1.IPAddressClaim
 		ipAddress             *ipamv1beta1.IPAddress
 		featureGatesEnabled   map[string]bool
+		expectNoClone         bool
 	}{
 		{
 			name:        "Successfully create machine",
@@ -2861,6 +3542,67 @@ func TestCreate(t *testing.T) {
 			},
 			expectedError: errors.New("test-6: failed validating machine provider spec: test-6: missing \"machine.openshift.io/cluster-api-cluster\" label"),
 		},
+		{
+			name:        "Successfully create machine in the workspace folder and resource pool",
+			machineName: "test-8",
+			providerSpec: machinev1.VSphereMachineProviderSpec{
+				Template: vmName,
+				Workspace: &machinev1.Workspace{
+					Server:       host,
+					Folder:       "/DC0/vm",
+					ResourcePool: resourcePoolInventoryPath,
+				},
+				CredentialsSecret: &corev1.LocalObjectReference{
+					Name: "test",
+				},
+				DiskGiB: 10,
+				UserDataSecret: &corev1.LocalObjectReference{
+					Name: userDataSecretName,
+				},
+			},
+		},
+		{
+			name:        "Fail without a clone task when the workspace folder does not exist",
+			machineName: "test-9",
+			providerSpec: machinev1.VSphereMachineProviderSpec{
+				Template: vmName,
+				Workspace: &machinev1.Workspace{
+					Server:       host,
+					Folder:       "/DC0/vm/missing",
+					ResourcePool: resourcePoolInventoryPath,
+				},
+				CredentialsSecret: &corev1.LocalObjectReference{
+					Name: "test",
+				},
+				DiskGiB: 10,
+				UserDataSecret: &corev1.LocalObjectReference{
+					Name: userDataSecretName,
+				},
+			},
+			expectedError: errors.New("folder \"/DC0/vm/missing\" not found, specify valid value"),
+			expectNoClone: true,
+		},
+		{
+			name:        "Fail without a clone task when the workspace resource pool does not exist",
+			machineName: "test-10",
+			providerSpec: machinev1.VSphereMachineProviderSpec{
+				Template: vmName,
+				Workspace: &machinev1.Workspace{
+					Server:       host,
+					Folder:       "/DC0/vm",
+					ResourcePool: path.Join(resourcePoolInventoryPath, "missing"),
+				},
+				CredentialsSecret: &corev1.LocalObjectReference{
+					Name: "test",
+				},
+				DiskGiB: 10,
+				UserDataSecret: &corev1.LocalObjectReference{
+					Name: userDataSecretName,
+				},
+			},
+			expectedError: fmt.Errorf("resource pool %q not found, specify valid value", path.Join(resourcePoolInventoryPath, "missing")),
+			expectNoClone: true,
+		},
 		{
 			name:        "Fail on not connected to vCenter",
 			machineName: "test-7",
@@ -2972,6 +3714,15 @@ func TestCreate(t *testing.T) {
 				if err.Error() != tc.expectedError.Error() {
 					t.Fatalf("Expected: %v, got %v", tc.expectedError, err)
 				}
+				if tc.expectNoClone {
+					if reconciler.providerStatus.TaskRef != "" {
+						t.Fatalf("Expected no clone task, got %v", reconciler.providerStatus.TaskRef)
+					}
+					condition := findCondition(reconciler.providerStatus.Conditions, string(machinev1.MachineCreation))
+					if condition == nil || condition.Status != metav1.ConditionFalse || condition.Message != tc.expectedError.Error() {
+						t.Fatalf("Expected a failed machine creation condition with message %q, got %v", tc.expectedError, condition)
+					}
+				}
 			} else {
 				if err != nil {
 					t.Fatalf("reconciler was not expected to return error: %v", err)
@@ -3170,7 +3921,7 @@ func TestUpdate(t *testing.T) {
 
 			reconciler := newReconciler(machineScope)
 
-			err = reconciler.update()
+			_, err = reconciler.update()
 
 			if tc.expectedError != nil {
 				if err == nil {
@@ -3321,6 +4072,92 @@ func TestExists(t *testing.T) {
 	}
 }
 
+func TestDrift(t *testing.T) {
+	model, session, server := initSimulator(t)
+	defer model.Remove()
+	defer server.Close()
+
+	vm := simulator.Map.Any("VirtualMachine").(*simulator.VirtualMachine)
+	hardware := vm.Config.Hardware
+
+	cases := []struct {
+		name           string
+		vmExists       bool
+		providerSpec   machinev1.VSphereMachineProviderSpec
+		expectedDrifts []machinecontroller.ProviderSpecDrift
+	}{
+		{
+			name:     "VM matches the providerSpec",
+			vmExists: true,
+			providerSpec: machinev1.VSphereMachineProviderSpec{
+				NumCPUs:           hardware.NumCPU,
+				NumCoresPerSocket: hardware.NumCoresPerSocket,
+				MemoryMiB:         int64(hardware.MemoryMB),
+			},
+		},
+		{
+			name:         "providerSpec without cpu and memory",
+			vmExists:     true,
+			providerSpec: machinev1.VSphereMachineProviderSpec{},
+		},
+		{
+			name:     "VM differs from the providerSpec",
+			vmExists: true,
+			providerSpec: machinev1.VSphereMachineProviderSpec{
+				NumCPUs:   hardware.NumCPU + 2,
+				MemoryMiB: int64(hardware.MemoryMB) * 2,
+			},
+			expectedDrifts: []machinecontroller.ProviderSpecDrift{
+				{Field: "numCPUs", Expected: strconv.Itoa(int(hardware.NumCPU + 2)), Actual: strconv.Itoa(int(hardware.NumCPU))},
+				{Field: "numCoresPerSocket", Expected: strconv.Itoa(int(hardware.NumCPU + 2)), Actual: strconv.Itoa(int(hardware.NumCoresPerSocket))},
+				{Field: "memoryMiB", Expected: strconv.Itoa(int(hardware.MemoryMB * 2)), Actual: strconv.Itoa(int(hardware.MemoryMB))},
+			},
+		},
+		{
+			name:     "VM doesn't exist",
+			vmExists: false,
+			providerSpec: machinev1.VSphereMachineProviderSpec{
+				NumCPUs: hardware.NumCPU + 2,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			machineObj := &machinev1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test",
+					Namespace: "test",
+					Labels: map[string]string{
+						machinev1.MachineClusterIDLabel: "CLUSTERID",
+					},
+				},
+			}
+			if tc.vmExists {
+				machineObj.UID = apimachinerytypes.UID(vm.Config.InstanceUuid)
+			}
+
+			providerSpec := tc.providerSpec
+			providerSpec.Template = vm.Name
+
+			machineScope := &machineScope{
+				Context:        context.TODO(),
+				machine:        machineObj,
+				providerSpec:   &providerSpec,
+				providerStatus: &machinev1.VSphereMachineProviderStatus{},
+				session:        session,
+				client:         fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(machineObj).Build(),
+			}
+
+			drifts, err := newReconciler(machineScope).drift()
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(drifts).To(Equal(tc.expectedDrifts))
+		})
+	}
+}
+
 func TestReconcileMachineWithCloudState(t *testing.T) {
 	model, session, server := initSimulator(t)
 	defer model.Remove()
@@ -154,6 +154,11 @@ func (s *Session) FindRefByInstanceUUID(ctx context.Context, UUID string) (objec
 	return s.findRefByUUID(ctx, UUID, true)
 }
 
+// FindRefByBIOSUUID finds an object by its BIOS UUID, the UUID of the providerID of vSphere Machines.
+func (s *Session) FindRefByBIOSUUID(ctx context.Context, UUID string) (object.Reference, error) {
+	return s.findRefByUUID(ctx, UUID, false)
+}
+
 func (s *Session) findRefByUUID(ctx context.Context, UUID string, findByInstanceUUID bool) (object.Reference, error) {
 	if s.Client == nil {
 		return nil, errors.New("vSphere client is not initialized")
@@ -0,0 +1,94 @@
+package vsphere
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"github.com/openshift/machine-api-operator/pkg/controller/vsphere/session"
+)
+
+// taskDrainPollInterval is how often in-flight tasks are checked while draining.
+const taskDrainPollInterval = 2 * time.Second
+
+// taskStateFunc reports whether a vSphere task has reached a terminal state.
+type taskStateFunc func(ctx context.Context) (bool, error)
+
+// inFlightTask is a create or delete task started by the actuator.
+type inFlightTask struct {
+	taskRef    string
+	isFinished taskStateFunc
+}
+
+// inFlightTasks tracks the latest create or delete task of each machine so that
+// shutdown can wait for them to reach a terminal state.
+type inFlightTasks struct {
+	lock  sync.Mutex
+	tasks map[string]inFlightTask
+}
+
+func newInFlightTasks() *inFlightTasks {
+	return &inFlightTasks{tasks: map[string]inFlightTask{}}
+}
+
+// track records the task of the machine, replacing any previous task.
+func (t *inFlightTasks) track(machineName, taskRef string, isFinished taskStateFunc) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.tasks[machineName] = inFlightTask{taskRef: taskRef, isFinished: isFinished}
+}
+
+// remaining checks the tracked tasks, forgets those which have reached a terminal
+// state and returns the references of the others.
+func (t *inFlightTasks) remaining(ctx context.Context) []string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var refs []string
+	for name, task := range t.tasks {
+		finished, err := task.isFinished(ctx)
+		if err != nil {
+			klog.Warningf("%s: failed to check state of task %s: %v", name, task.taskRef, err)
+		}
+		if finished {
+			delete(t.tasks, name)
+			continue
+		}
+		refs = append(refs, task.taskRef)
+	}
+	return refs
+}
+
+// wait blocks until all tracked tasks have reached a terminal state or the grace period
+// has elapsed. It returns the references of the tasks still in flight.
+func (t *inFlightTasks) wait(gracePeriod, pollInterval time.Duration) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancel()
+
+	var refs []string
+	_ = wait.PollUntilContextCancel(ctx, pollInterval, true, func(ctx context.Context) (bool, error) {
+		refs = t.remaining(ctx)
+		return len(refs) == 0, nil
+	})
+	return refs
+}
+
+// sessionTaskState returns a taskStateFunc which looks the task up with the session.
+// Tasks which vCenter no longer knows about are considered finished.
+func sessionTaskState(s *session.Session, taskRef string) taskStateFunc {
+	return func(ctx context.Context) (bool, error) {
+		moTask, err := s.GetTask(ctx, taskRef)
+		if err != nil {
+			if isRetrieveMONotFound(taskRef, err) {
+				return true, nil
+			}
+			return false, err
+		}
+		// A task which finished with an error is still terminal.
+		finished, _ := taskIsFinished(moTask)
+		return finished, nil
+	}
+}
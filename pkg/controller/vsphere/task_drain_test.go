@@ -0,0 +1,84 @@
+package vsphere
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestInFlightTasksWait(t *testing.T) {
+	const pollInterval = 10 * time.Millisecond
+
+	t.Run("returns immediately without tasks", func(t *testing.T) {
+		g := NewWithT(t)
+
+		start := time.Now()
+		g.Expect(newInFlightTasks().wait(time.Minute, pollInterval)).To(BeEmpty())
+		g.Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+	})
+
+	t.Run("blocks until the in-flight task completes", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var done atomic.Bool
+		tasks := newInFlightTasks()
+		tasks.track("machine", "task-1", func(context.Context) (bool, error) {
+			return done.Load(), nil
+		})
+
+		go func() {
+			time.Sleep(200 * time.Millisecond)
+			done.Store(true)
+		}()
+
+		start := time.Now()
+		g.Expect(tasks.wait(time.Minute, pollInterval)).To(BeEmpty())
+		g.Expect(time.Since(start)).To(BeNumerically(">=", 200*time.Millisecond))
+		g.Expect(time.Since(start)).To(BeNumerically("<", 10*time.Second))
+	})
+
+	t.Run("gives up once the grace period has elapsed", func(t *testing.T) {
+		g := NewWithT(t)
+
+		tasks := newInFlightTasks()
+		tasks.track("machine", "task-1", func(context.Context) (bool, error) {
+			return false, nil
+		})
+		tasks.track("other-machine", "task-2", func(context.Context) (bool, error) {
+			return true, nil
+		})
+
+		start := time.Now()
+		g.Expect(tasks.wait(200*time.Millisecond, pollInterval)).To(ConsistOf("task-1"))
+		g.Expect(time.Since(start)).To(BeNumerically(">=", 200*time.Millisecond))
+	})
+
+	t.Run("keeps waiting when the task state cannot be checked", func(t *testing.T) {
+		g := NewWithT(t)
+
+		tasks := newInFlightTasks()
+		tasks.track("machine", "task-1", func(context.Context) (bool, error) {
+			return false, errors.New("connection refused")
+		})
+
+		g.Expect(tasks.wait(100*time.Millisecond, pollInterval)).To(ConsistOf("task-1"))
+	})
+
+	t.Run("tracks only the latest task of a machine", func(t *testing.T) {
+		g := NewWithT(t)
+
+		tasks := newInFlightTasks()
+		tasks.track("machine", "create-task", func(context.Context) (bool, error) {
+			return false, nil
+		})
+		tasks.track("machine", "delete-task", func(context.Context) (bool, error) {
+			return false, nil
+		})
+
+		g.Expect(tasks.wait(50*time.Millisecond, pollInterval)).To(ConsistOf("delete-task"))
+	})
+}
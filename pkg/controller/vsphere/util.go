@@ -7,9 +7,12 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	configv1 "github.com/openshift/api/config/v1"
 	machinev1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
@@ -133,6 +136,64 @@ func conditionFailed() metav1.Condition {
 	}
 }
 
+// instanceCreateCondition reports the progress of the vCenter task tracked while creating the instance.
+const (
+	instanceCreateCondition        = "InstanceCreate"
+	instanceCreateInProgressReason = "TaskInProgress"
+	instanceCreateFailedReason     = "TaskFailed"
+)
+
+func conditionTaskInProgress(task *mo.Task) metav1.Condition {
+	message := fmt.Sprintf("%s task %s is %d%% complete", task.Info.DescriptionId, task.Reference().Value, task.Info.Progress)
+	if task.Info.State == types.TaskInfoStateQueued {
+		message = fmt.Sprintf("%s task %s is queued", task.Info.DescriptionId, task.Reference().Value)
+	}
+
+	return metav1.Condition{
+		Type:    instanceCreateCondition,
+		Status:  metav1.ConditionFalse,
+		Reason:  instanceCreateInProgressReason,
+		Message: message,
+	}
+}
+
+func conditionTaskFailed(task *mo.Task, err error) metav1.Condition {
+	return metav1.Condition{
+		Type:    instanceCreateCondition,
+		Status:  metav1.ConditionFalse,
+		Reason:  instanceCreateFailedReason,
+		Message: fmt.Sprintf("%s task %s failed: %v", task.Info.DescriptionId, task.Reference().Value, err),
+	}
+}
+
+// datastoreSpaceCondition reports a clone waiting for free space on its datastore.
+const (
+	datastoreSpaceCondition          = "DatastoreSpace"
+	insufficientDatastoreSpaceReason = "InsufficientDatastoreSpace"
+
+	// datastoreSpaceRequeuePeriod is how often the free space of the datastore is checked again.
+	datastoreSpaceRequeuePeriod = 2 * time.Minute
+)
+
+func conditionInsufficientDatastoreSpace(err *insufficientDatastoreSpaceError) metav1.Condition {
+	return metav1.Condition{
+		Type:    datastoreSpaceCondition,
+		Status:  metav1.ConditionFalse,
+		Reason:  insufficientDatastoreSpaceReason,
+		Message: err.Error(),
+	}
+}
+
+func removeCondition(conditions []metav1.Condition, conditionType string) []metav1.Condition {
+	var filtered []metav1.Condition
+	for _, condition := range conditions {
+		if condition.Type != conditionType {
+			filtered = append(filtered, condition)
+		}
+	}
+	return filtered
+}
+
 func getVCenterPortFromConfig(config *vsphere.Config, vcenter string) string {
 	if config != nil {
 		for _, vc := range config.VirtualCenter {
@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-logr/logr"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	authenticationclientv1 "k8s.io/client-go/kubernetes/typed/authentication/v1"
+	authorizationclientv1 "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
+)
+
+// TokenAuthenticator authenticates the bearer tokens of the metrics requests.
+type TokenAuthenticator interface {
+	// AuthenticateToken returns the user of the token, and false if the token is not authenticated.
+	AuthenticateToken(ctx context.Context, token string) (authenticationv1.UserInfo, bool, error)
+}
+
+// RequestAuthorizer authorizes the users of the metrics requests.
+type RequestAuthorizer interface {
+	// Authorize returns true if the user is allowed the verb on the non-resource path.
+	Authorize(ctx context.Context, user authenticationv1.UserInfo, verb, path string) (bool, error)
+}
+
+// tokenReviewAuthenticator authenticates bearer tokens with the TokenReview API.
+type tokenReviewAuthenticator struct {
+	client authenticationclientv1.TokenReviewInterface
+}
+
+// NewTokenReviewAuthenticator returns a TokenAuthenticator backed by the TokenReview API.
+func NewTokenReviewAuthenticator(client authenticationclientv1.TokenReviewInterface) TokenAuthenticator {
+	return &tokenReviewAuthenticator{client: client}
+}
+
+func (a *tokenReviewAuthenticator) AuthenticateToken(ctx context.Context, token string) (authenticationv1.UserInfo, bool, error) {
+	review, err := a.client.Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return authenticationv1.UserInfo{}, false, fmt.Errorf("failed to review token: %w", err)
+	}
+	return review.Status.User, review.Status.Authenticated, nil
+}
+
+// subjectAccessReviewAuthorizer authorizes users with the SubjectAccessReview API.
+type subjectAccessReviewAuthorizer struct {
+	client authorizationclientv1.SubjectAccessReviewInterface
+}
+
+// NewSubjectAccessReviewAuthorizer returns a RequestAuthorizer backed by the SubjectAccessReview API.
+func NewSubjectAccessReviewAuthorizer(client authorizationclientv1.SubjectAccessReviewInterface) RequestAuthorizer {
+	return &subjectAccessReviewAuthorizer{client: client}
+}
+
+func (a *subjectAccessReviewAuthorizer) Authorize(ctx context.Context, user authenticationv1.UserInfo, verb, path string) (bool, error) {
+	extra := map[string]authorizationv1.ExtraValue{}
+	for key, value := range user.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+
+	review, err := a.client.Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			NonResourceAttributes: &authorizationv1.NonResourceAttributes{
+				Path: path,
+				Verb: verb,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to review subject access: %w", err)
+	}
+	return review.Status.Allowed, nil
+}
+
+// WithAuthenticationAndAuthorization wraps the handler so that only the requests with a bearer token accepted by
+// the authenticator, whose user is allowed the request by the authorizer, are served. Requests without an
+// authenticated token get a 401 Unauthorized response, and requests of users who are not allowed get a
+// 403 Forbidden response.
+func WithAuthenticationAndAuthorization(log logr.Logger, authenticator TokenAuthenticator, authorizer RequestAuthorizer, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		token, ok := bearerToken(req)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		user, authenticated, err := authenticator.AuthenticateToken(req.Context(), token)
+		if err != nil {
+			log.Error(err, "Failed to authenticate metrics request")
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		if !authenticated {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		allowed, err := authorizer.Authorize(req.Context(), user, strings.ToLower(req.Method), req.URL.Path)
+		if err != nil {
+			log.Error(err, "Failed to authorize metrics request", "user", user.Username)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		handler.ServeHTTP(w, req)
+	})
+}
+
+func bearerToken(req *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(req.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// AuthenticationAndAuthorizationFilterProvider is a metrics server FilterProvider which requires the metrics
+// requests to carry a bearer token accepted by the TokenReview API, whose user is allowed to get the metrics
+// path by the SubjectAccessReview API, like the kube-rbac-proxy sidecars do.
+func AuthenticationAndAuthorizationFilterProvider(config *rest.Config, httpClient *http.Client) (server.Filter, error) {
+	clientset, err := kubernetes.NewForConfigAndClient(config, httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client for token and subject access reviews: %w", err)
+	}
+
+	authenticator := NewTokenReviewAuthenticator(clientset.AuthenticationV1().TokenReviews())
+	authorizer := NewSubjectAccessReviewAuthorizer(clientset.AuthorizationV1().SubjectAccessReviews())
+	return func(log logr.Logger, handler http.Handler) (http.Handler, error) {
+		return WithAuthenticationAndAuthorization(log, authenticator, authorizer, handler), nil
+	}, nil
+}
@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/klog/v2/textlogger"
+)
+
+type fakeTokenAuthenticator struct {
+	validToken     string
+	forbiddenToken string
+	err            error
+}
+
+func (f *fakeTokenAuthenticator) AuthenticateToken(_ context.Context, token string) (authenticationv1.UserInfo, bool, error) {
+	return authenticationv1.UserInfo{Username: token}, token == f.validToken || token == f.forbiddenToken, f.err
+}
+
+type fakeRequestAuthorizer struct {
+	allowedUser string
+	err         error
+}
+
+func (f *fakeRequestAuthorizer) Authorize(_ context.Context, user authenticationv1.UserInfo, verb, path string) (bool, error) {
+	return user.Username == f.allowedUser && verb == "get" && path == "/metrics", f.err
+}
+
+func TestWithAuthenticationAndAuthorization(t *testing.T) {
+	testCases := []struct {
+		name           string
+		authorization  string
+		err            error
+		authorizeErr   error
+		expectedStatus int
+	}{
+		{
+			name:           "with a valid token",
+			authorization:  "Bearer valid",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "with an invalid token",
+			authorization:  "Bearer invalid",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "with a valid token of a user who is not allowed",
+			authorization:  "Bearer forbidden",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "without a token",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "with a non bearer authorization",
+			authorization:  "Basic valid",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "when the token review fails",
+			authorization:  "Bearer valid",
+			err:            errors.New("review failed"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "when the subject access review fails",
+			authorization:  "Bearer valid",
+			authorizeErr:   errors.New("review failed"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			authenticator := &fakeTokenAuthenticator{validToken: "valid", forbiddenToken: "forbidden", err: tc.err}
+			authorizer := &fakeRequestAuthorizer{allowedUser: "valid", err: tc.authorizeErr}
+			handler := WithAuthenticationAndAuthorization(textlogger.NewLogger(textlogger.NewConfig()), authenticator, authorizer,
+				http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+					w.WriteHeader(http.StatusOK)
+				}))
+
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tc.expectedStatus {
+				t.Errorf("expected status %d, got %d", tc.expectedStatus, rec.Code)
+			}
+			if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") != "Bearer" {
+				t.Errorf("expected a WWW-Authenticate Bearer header, got %q", rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package metrics
 
 import (
+	"sync"
+	"time"
+
 	machinev1 "github.com/openshift/api/machine/v1beta1"
 	machineinformers "github.com/openshift/client-go/machine/informers/externalversions/machine/v1beta1"
 	machinelisters "github.com/openshift/client-go/machine/listers/machine/v1beta1"
@@ -62,6 +65,46 @@ var (
 			Help: "Number of times provider instance delete has failed.",
 		}, []string{"name", "namespace", "reason"},
 	)
+
+	providerSpecDriftCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mapi_instance_providerspec_drift",
+			Help: "Number of times a provider instance has been found to differ from its providerSpec.",
+		}, []string{"name", "namespace", "field"},
+	)
+
+	machineReconcileCount = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mapi_machine_reconcile_total",
+			Help: "Number of Machine reconciles which reached the provider, by result.",
+		}, []string{"result"},
+	)
+
+	machineReconcileTimeoutCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "mapi_machine_reconcile_timeouts_total",
+			Help: "Number of Machine reconciles which exceeded the reconcile timeout and were requeued.",
+		},
+	)
+
+	interruptionDrainSkippedCount = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "mapi_machine_interruption_drain_skipped_total",
+			Help: "Number of times the node drain of a deleted Machine has been skipped because its instance was interrupted.",
+		},
+	)
+)
+
+// Results of a Machine reconcile reported by the mapi_machine_reconcile_total metric
+const (
+	// MachineReconcileCreated is reported when an instance has been created
+	MachineReconcileCreated = "created"
+	// MachineReconcileUpdated is reported when an existing instance has been updated
+	MachineReconcileUpdated = "updated"
+	// MachineReconcileNoop is reported when an existing instance already matched its Machine
+	MachineReconcileNoop = "noop"
+	// MachineReconcileError is reported when the provider failed to check, create or update an instance
+	MachineReconcileError = "error"
 )
 
 // Metrics for use in the Machine controller
@@ -74,15 +117,84 @@ var (
 			Buckets: []float64{5, 10, 20, 30, 60, 90, 120, 180, 240, 300, 360, 480, 600},
 		}, []string{"phase"},
 	)
+
+	// MachineTopologyMismatch is a metric reporting Machines placed outside of the region or zone of their MachineSet
+	MachineTopologyMismatch = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mapi_machine_topology_mismatch",
+			Help: "Machine is placed in a region or zone which does not match its MachineSet, 1 when mismatched.",
+		}, []string{"name", "namespace", "machineset"},
+	)
+
+	// MachineSetDriftedMachines is a metric reporting the number of Machines of a MachineSet whose providerSpec
+	// differs from the Machine template
+	MachineSetDriftedMachines = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mapi_machineset_drifted_machines",
+			Help: "Number of Machines of the MachineSet whose providerSpec differs from the Machine template.",
+		}, []string{"name", "namespace"},
+	)
+
+	// MachineSetCreatingMachines is a metric reporting the number of Machines of a MachineSet which are being created
+	MachineSetCreatingMachines = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mapi_machineset_creating_machines",
+			Help: "Number of Machines of the MachineSet which are being created and are not provisioned yet.",
+		}, []string{"name", "namespace"},
+	)
+
+	// machinePhaseStartTimestamp is a metric reporting when Machines entered their Provisioning or Deleting phase,
+	// so that the time spent in the phase is computed with time() - mapi_machine_phase_start_timestamp_seconds.
+	machinePhaseStartTimestamp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mapi_machine_phase_start_timestamp_seconds",
+			Help: "Timestamp at which the Machine entered its current Provisioning or Deleting phase.",
+		}, []string{"name", "namespace", "phase"},
+	)
+
+	// machineStuckSeconds is a metric reporting how long Machines have been in their Provisioning or Deleting phase.
+	// It is computed when the metrics are scraped, so that it keeps increasing between reconciles of the Machine.
+	machineStuckSeconds = newMachinePhaseDurationCollector(prometheus.NewDesc(
+		"mapi_machine_stuck_seconds",
+		"Number of seconds the Machine has been in its current Provisioning or Deleting phase.",
+		[]string{"name", "namespace", "phase"}, nil,
+	))
+
+	// reconcilePaused is a metric reporting whether the reconciliation of a controller is paused cluster-wide
+	reconcilePaused = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mapi_controller_reconcile_paused",
+			Help: "Reconciliation of the controller is paused cluster-wide, 1 when paused.",
+		}, []string{"controller"},
+	)
+
+	// machineDuplicateProviderID is a metric reporting Machines which have the same providerID as other Machines
+	machineDuplicateProviderID = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mapi_machine_duplicate_provider_id",
+			Help: "Machine has the same providerID as other Machines, 1 when duplicated.",
+		}, []string{"name", "namespace"},
+	)
 )
 
 func init() {
 	prometheus.MustRegister(MachineCollectorUp)
 	metrics.Registry.MustRegister(MachinePhaseTransitionSeconds)
+	metrics.Registry.MustRegister(MachineTopologyMismatch)
+	metrics.Registry.MustRegister(machineDuplicateProviderID)
+	metrics.Registry.MustRegister(machinePhaseStartTimestamp)
+	metrics.Registry.MustRegister(machineStuckSeconds)
+	metrics.Registry.MustRegister(reconcilePaused)
+	metrics.Registry.MustRegister(MachineSetDriftedMachines)
+	metrics.Registry.MustRegister(MachineSetCreatingMachines)
 	metrics.Registry.MustRegister(
 		failedInstanceCreateCount,
 		failedInstanceUpdateCount,
 		failedInstanceDeleteCount,
+		providerSpecDriftCount,
+		machineReconcileCount,
+		machineReconcileTimeoutCount,
+		interruptionDrainSkippedCount,
 	)
 }
 
@@ -226,3 +338,129 @@ func RegisterFailedInstanceDelete(labels *MachineLabels) {
 		"reason":    labels.Reason,
 	}).Inc()
 }
+
+func RegisterProviderSpecDrift(labels *MachineLabels, field string) {
+	providerSpecDriftCount.With(prometheus.Labels{
+		"name":      labels.Name,
+		"namespace": labels.Namespace,
+		"field":     field,
+	}).Inc()
+}
+
+func RegisterMachineReconcileResult(result string) {
+	machineReconcileCount.With(prometheus.Labels{
+		"result": result,
+	}).Inc()
+}
+
+// RegisterMachineReconcileTimeout reports a Machine reconcile which exceeded the reconcile timeout.
+func RegisterMachineReconcileTimeout() {
+	machineReconcileTimeoutCount.Inc()
+}
+
+// RegisterInterruptionDrainSkipped reports a node drain skipped because the instance of the Machine was interrupted.
+func RegisterInterruptionDrainSkipped() {
+	interruptionDrainSkippedCount.Inc()
+}
+
+// SetMachineDuplicateProviderID reports whether the Machine has the same providerID as other Machines.
+func SetMachineDuplicateProviderID(labels *MachineLabels, duplicated bool) {
+	value := 0.0
+	if duplicated {
+		value = 1
+	}
+	machineDuplicateProviderID.With(prometheus.Labels{
+		"name":      labels.Name,
+		"namespace": labels.Namespace,
+	}).Set(value)
+}
+
+// DeleteMachineDuplicateProviderID removes the duplicate providerID series of the Machine.
+func DeleteMachineDuplicateProviderID(labels *MachineLabels) {
+	machineDuplicateProviderID.Delete(prometheus.Labels{
+		"name":      labels.Name,
+		"namespace": labels.Namespace,
+	})
+}
+
+// SetReconcilePaused reports whether the reconciliation of the controller is paused cluster-wide.
+func SetReconcilePaused(controller string, paused bool) {
+	value := 0.0
+	if paused {
+		value = 1
+	}
+	reconcilePaused.With(prometheus.Labels{"controller": controller}).Set(value)
+}
+
+// SetMachinePhaseStartTime reports when the Machine entered its current phase, and the time it has spent in the
+// phase since. The series of the other phases of the Machine are removed.
+func SetMachinePhaseStartTime(labels *MachineLabels, phase string, start time.Time) {
+	DeleteMachinePhaseStartTime(labels)
+	machinePhaseStartTimestamp.With(prometheus.Labels{
+		"name":      labels.Name,
+		"namespace": labels.Namespace,
+		"phase":     phase,
+	}).Set(float64(start.Unix()))
+	machineStuckSeconds.set(*labels, phase, start)
+}
+
+// DeleteMachinePhaseStartTime removes the phase start timestamp and the stuck seconds series of the Machine.
+func DeleteMachinePhaseStartTime(labels *MachineLabels) {
+	machinePhaseStartTimestamp.DeletePartialMatch(prometheus.Labels{
+		"name":      labels.Name,
+		"namespace": labels.Namespace,
+	})
+	machineStuckSeconds.delete(*labels)
+}
+
+// machinePhaseStart is the phase of a Machine and the time at which the Machine entered it.
+type machinePhaseStart struct {
+	phase string
+	start time.Time
+}
+
+// machinePhaseDurationCollector reports the time Machines have spent in their phase, relative to the time of the scrape.
+type machinePhaseDurationCollector struct {
+	desc *prometheus.Desc
+	now  func() time.Time
+
+	lock   sync.Mutex
+	phases map[MachineLabels]machinePhaseStart
+}
+
+func newMachinePhaseDurationCollector(desc *prometheus.Desc) *machinePhaseDurationCollector {
+	return &machinePhaseDurationCollector{
+		desc:   desc,
+		now:    time.Now,
+		phases: make(map[MachineLabels]machinePhaseStart),
+	}
+}
+
+func (c *machinePhaseDurationCollector) set(labels MachineLabels, phase string, start time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.phases[MachineLabels{Name: labels.Name, Namespace: labels.Namespace}] = machinePhaseStart{phase: phase, start: start}
+}
+
+func (c *machinePhaseDurationCollector) delete(labels MachineLabels) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.phases, MachineLabels{Name: labels.Name, Namespace: labels.Namespace})
+}
+
+// Describe implements the prometheus.Collector interface.
+func (c *machinePhaseDurationCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect implements the prometheus.Collector interface.
+func (c *machinePhaseDurationCollector) Collect(ch chan<- prometheus.Metric) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.now()
+	for labels, phase := range c.phases {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue,
+			now.Sub(phase.start).Seconds(), labels.Name, labels.Namespace, phase.phase)
+	}
+}
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestMachinePhaseDurationCollector(t *testing.T) {
+	g := NewWithT(t)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	collector := newMachinePhaseDurationCollector(prometheus.NewDesc(
+		"mapi_machine_stuck_seconds", "test", []string{"name", "namespace", "phase"}, nil,
+	))
+	collector.now = func() time.Time { return now }
+
+	registry := prometheus.NewPedanticRegistry()
+	g.Expect(registry.Register(collector)).To(Succeed())
+
+	stuckSeconds := func() map[string]float64 {
+		families, err := registry.Gather()
+		g.Expect(err).ToNot(HaveOccurred())
+
+		values := map[string]float64{}
+		for _, family := range families {
+			for _, metric := range family.GetMetric() {
+				labels := map[string]string{}
+				for _, label := range metric.GetLabel() {
+					labels[label.GetName()] = label.GetValue()
+				}
+				values[labels["namespace"]+"/"+labels["name"]+"/"+labels["phase"]] = metric.GetGauge().GetValue()
+			}
+		}
+		return values
+	}
+
+	labels := MachineLabels{Name: "machine", Namespace: "default", Reason: "ignored"}
+	collector.set(labels, "Provisioning", start)
+	g.Expect(stuckSeconds()).To(Equal(map[string]float64{"default/machine/Provisioning": 0}))
+
+	// The time spent in the phase increases with the time of the scrape, without the Machine being reconciled
+	now = start.Add(10 * time.Minute)
+	g.Expect(stuckSeconds()).To(Equal(map[string]float64{"default/machine/Provisioning": 600}))
+
+	// The Machine has a single series, for its current phase
+	collector.set(labels, "Deleting", start.Add(5*time.Minute))
+	g.Expect(stuckSeconds()).To(Equal(map[string]float64{"default/machine/Deleting": 300}))
+
+	collector.delete(MachineLabels{Name: "machine", Namespace: "default"})
+	g.Expect(stuckSeconds()).To(BeEmpty())
+}
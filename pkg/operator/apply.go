@@ -0,0 +1,119 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	appsv1ac "k8s.io/client-go/applyconfigurations/apps/v1"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
+	appsclientv1 "k8s.io/client-go/kubernetes/typed/apps/v1"
+	"k8s.io/client-go/util/csaupgrade"
+	"k8s.io/klog/v2"
+)
+
+// operatorFieldManager is the field manager of the fields set by the operator with server-side apply.
+const operatorFieldManager = "machine-api-operator"
+
+// applyDeployment server-side applies the desired deployment, see applyWithConflicts.
+func (optr *Operator) applyDeployment(ctx context.Context, desired *appsv1.Deployment) (*appsv1.Deployment, error) {
+	deployment := &appsv1ac.DeploymentApplyConfiguration{}
+	if err := toApplyConfiguration(desired, deployment); err != nil {
+		return nil, err
+	}
+	deployment.WithKind("Deployment").WithAPIVersion("apps/v1")
+	// The status is not owned by the operator
+	deployment.Status = nil
+
+	deployments := optr.kubeClient.AppsV1().Deployments(desired.Namespace)
+
+	if err := upgradeDeploymentManagedFields(ctx, deployments, desired.Name); err != nil {
+		return nil, err
+	}
+
+	var applied *appsv1.Deployment
+	err := applyWithConflicts("Deployment", desired.Namespace, desired.Name, func(force bool) error {
+		var err error
+		applied, err = deployments.Apply(ctx, deployment, metav1.ApplyOptions{FieldManager: operatorFieldManager, Force: force})
+		return err
+	})
+	return applied, err
+}
+
+// upgradeDeploymentManagedFields hands the fields of the deployment previously owned by the operator with
+// client-side updates over to its server-side apply field manager. Without it, the fields the operator stops
+// setting stay owned by the update manager and are never removed by the apply. Nothing is done once the
+// managed fields have been upgraded.
+func upgradeDeploymentManagedFields(ctx context.Context, deployments appsclientv1.DeploymentInterface, name string) error {
+	existing, err := deployments.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	patch, err := csaupgrade.UpgradeManagedFieldsPatch(existing, sets.New(operatorFieldManager), operatorFieldManager)
+	if err != nil {
+		return fmt.Errorf("error upgrading the managed fields of Deployment %s/%s: %w", existing.Namespace, name, err)
+	}
+	if patch == nil {
+		return nil
+	}
+
+	klog.Infof("Upgrading the managed fields of Deployment %s/%s to server-side apply", existing.Namespace, name)
+	if _, err := deployments.Patch(ctx, name, types.JSONPatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("error upgrading the managed fields of Deployment %s/%s: %w", existing.Namespace, name, err)
+	}
+	return nil
+}
+
+// applyConfigMap server-side applies the desired config map, see applyWithConflicts.
+func (optr *Operator) applyConfigMap(ctx context.Context, desired *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+	configMap := &corev1ac.ConfigMapApplyConfiguration{}
+	if err := toApplyConfiguration(desired, configMap); err != nil {
+		return nil, err
+	}
+	configMap.WithKind("ConfigMap").WithAPIVersion("v1")
+
+	configMaps := optr.kubeClient.CoreV1().ConfigMaps(desired.Namespace)
+
+	var applied *corev1.ConfigMap
+	err := applyWithConflicts("ConfigMap", desired.Namespace, desired.Name, func(force bool) error {
+		var err error
+		applied, err = configMaps.Apply(ctx, configMap, metav1.ApplyOptions{FieldManager: operatorFieldManager, Force: force})
+		return err
+	})
+	return applied, err
+}
+
+// applyWithConflicts applies without forcing first, so that the fields owned by other field managers with
+// a different value are reported instead of silently taken over. The operator stays authoritative for the
+// fields it sets, the apply is then forced. Fields it does not set are left to their owners.
+func applyWithConflicts(kind, namespace, name string, apply func(force bool) error) error {
+	err := apply(false)
+	if !apierrors.IsConflict(err) {
+		return err
+	}
+
+	klog.Warningf("%s %s/%s has fields set by other managers, they are overwritten: %v", kind, namespace, name, err)
+	return apply(true)
+}
+
+// toApplyConfiguration converts a typed object into its apply configuration, their serialization is the same.
+func toApplyConfiguration(obj, applyConfiguration interface{}) error {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("error encoding %T: %w", obj, err)
+	}
+	if err := json.Unmarshal(data, applyConfiguration); err != nil {
+		return fmt.Errorf("error decoding %T: %w", applyConfiguration, err)
+	}
+	return nil
+}
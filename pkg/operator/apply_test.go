@@ -0,0 +1,158 @@
+package operator
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestApplyDeployment(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	optr, err := newFakeOperator(nil, nil, nil, "", nil, stopCh)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	desired := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "machine-api-controllers",
+			Namespace: targetNamespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.To[int32](1),
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"k8s-app": "controller"},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"k8s-app": "controller"},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "machine-controller", Image: "controller:v1"}},
+				},
+			},
+		},
+	}
+
+	applied, err := optr.applyDeployment(ctx, desired)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(applied.ManagedFields).To(ContainElement(SatisfyAll(
+		HaveField("Manager", operatorFieldManager),
+		HaveField("Operation", metav1.ManagedFieldsOperationApply),
+	)))
+
+	deployments := optr.kubeClient.AppsV1().Deployments(targetNamespace)
+
+	// Another controller sets a field not set by the operator, and a field set by the operator
+	other, err := deployments.Get(ctx, desired.Name, metav1.GetOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	other.Annotations = map[string]string{"example.com/owner": "other"}
+	other.Spec.Replicas = ptr.To[int32](3)
+	_, err = deployments.Update(ctx, other, metav1.UpdateOptions{FieldManager: "other-controller"})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	desired.Spec.Template.Spec.Containers[0].Image = "controller:v2"
+	_, err = optr.applyDeployment(ctx, desired)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	got, err := deployments.Get(ctx, desired.Name, metav1.GetOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	// The field owned by the other controller only is kept
+	g.Expect(got.Annotations).To(HaveKeyWithValue("example.com/owner", "other"))
+	// The conflicting field and the changed field are set to the desired value
+	g.Expect(got.Spec.Replicas).To(Equal(ptr.To[int32](1)))
+	g.Expect(got.Spec.Template.Spec.Containers[0].Image).To(Equal("controller:v2"))
+}
+
+func TestApplyDeploymentUpgradesManagedFields(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	optr, err := newFakeOperator(nil, nil, nil, "", nil, stopCh)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	desired := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "machine-api-controllers",
+			Namespace: targetNamespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"k8s-app": "controller"},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"k8s-app": "controller"},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "machine-controller", Image: "controller:v1"}},
+				},
+			},
+		},
+	}
+
+	// The deployment was previously updated by the operator without server-side apply, with an
+	// annotation the operator no longer sets
+	previous := desired.DeepCopy()
+	previous.Annotations = map[string]string{"example.com/removed": "true"}
+	deployments := optr.kubeClient.AppsV1().Deployments(targetNamespace)
+	_, err = deployments.Create(ctx, previous, metav1.CreateOptions{FieldManager: operatorFieldManager})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	applied, err := optr.applyDeployment(ctx, desired)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(applied.ManagedFields).To(ConsistOf(SatisfyAll(
+		HaveField("Manager", operatorFieldManager),
+		HaveField("Operation", metav1.ManagedFieldsOperationApply),
+	)))
+	g.Expect(applied.Annotations).ToNot(HaveKey("example.com/removed"))
+}
+
+func TestApplyConfigMap(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	optr, err := newFakeOperator(nil, nil, nil, "", nil, stopCh)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	desired := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: targetNamespace,
+		},
+		Data: map[string]string{"operator": "value"},
+	}
+
+	applied, err := optr.applyConfigMap(ctx, desired)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(applied.ManagedFields).To(ContainElement(SatisfyAll(
+		HaveField("Manager", operatorFieldManager),
+		HaveField("Operation", metav1.ManagedFieldsOperationApply),
+	)))
+
+	configMaps := optr.kubeClient.CoreV1().ConfigMaps(targetNamespace)
+
+	other, err := configMaps.Get(ctx, desired.Name, metav1.GetOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	other.Data["other"] = "value"
+	_, err = configMaps.Update(ctx, other, metav1.UpdateOptions{FieldManager: "other-controller"})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	_, err = optr.applyConfigMap(ctx, desired)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	got, err := configMaps.Get(ctx, desired.Name, metav1.GetOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(got.Data).To(Equal(map[string]string{"operator": "value", "other": "value"}))
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	configv1 "github.com/openshift/api/config/v1"
 )
@@ -75,30 +76,64 @@ func getImagesFromJSONFile(filePath string) (*Images, error) {
 	return &i, nil
 }
 
+// image is an image of images.json, identified by its key.
+type image struct {
+	key   string
+	value func(Images) string
+}
+
+// providerControllerImages maps platforms to the image of their machine controller.
+// Platforms missing from the map do not need a machine controller image.
+var providerControllerImages = map[configv1.PlatformType]image{
+	configv1.AWSPlatformType:       {"clusterAPIControllerAWS", func(i Images) string { return i.ClusterAPIControllerAWS }},
+	configv1.LibvirtPlatformType:   {"clusterAPIControllerLibvirt", func(i Images) string { return i.ClusterAPIControllerLibvirt }},
+	configv1.OpenStackPlatformType: {"clusterAPIControllerOpenStack", func(i Images) string { return i.ClusterAPIControllerOpenStack }},
+	configv1.AzurePlatformType:     {"clusterAPIControllerAzure", func(i Images) string { return i.ClusterAPIControllerAzure }},
+	configv1.GCPPlatformType:       {"clusterAPIControllerGCP", func(i Images) string { return i.ClusterAPIControllerGCP }},
+	configv1.BareMetalPlatformType: {"clusterAPIControllerBareMetal", func(i Images) string { return i.ClusterAPIControllerBareMetal }},
+	configv1.OvirtPlatformType:     {"clusterAPIControllerOvirt", func(i Images) string { return i.ClusterAPIControllerOvirt }},
+	configv1.VSpherePlatformType:   {"clusterAPIControllerVSphere", func(i Images) string { return i.ClusterAPIControllerVSphere }},
+	configv1.IBMCloudPlatformType:  {"clusterAPIControllerIBMCloud", func(i Images) string { return i.ClusterAPIControllerIBMCloud }},
+	configv1.PowerVSPlatformType:   {"clusterAPIControllerPowerVS", func(i Images) string { return i.ClusterAPIControllerPowerVS }},
+	configv1.NutanixPlatformType:   {"clusterAPIControllerNutanix", func(i Images) string { return i.ClusterAPIControllerNutanix }},
+}
+
+// missingImagesError is returned when images required on the platform are not set in images.json.
+type missingImagesError struct {
+	keys []string
+}
+
+func (e *missingImagesError) Error() string {
+	return fmt.Sprintf("images.json is missing required images: %s", strings.Join(e.keys, ", "))
+}
+
+// validateImages checks that every image required to run the machine API on the platform
+// is present and non-empty, and returns a missingImagesError listing the keys of those which are not.
+func validateImages(platform configv1.PlatformType, images Images) error {
+	required := []image{{"machineAPIOperator", func(i Images) string { return i.MachineAPIOperator }}}
+	if providerImage, ok := providerControllerImages[platform]; ok {
+		required = append(required, providerImage)
+	}
+	required = append(required, image{"kubeRBACProxy", func(i Images) string { return i.KubeRBACProxy }})
+
+	var missing []string
+	for _, img := range required {
+		if img.value(images) == "" {
+			missing = append(missing, img.key)
+		}
+	}
+	if len(missing) > 0 {
+		return &missingImagesError{keys: missing}
+	}
+	return nil
+}
+
 func getProviderControllerFromImages(platform configv1.PlatformType, images Images) (string, error) {
+	if providerImage, ok := providerControllerImages[platform]; ok {
+		return providerImage.value(images), nil
+	}
+
 	switch platform {
-	case configv1.AWSPlatformType:
-		return images.ClusterAPIControllerAWS, nil
-	case configv1.LibvirtPlatformType:
-		return images.ClusterAPIControllerLibvirt, nil
-	case configv1.OpenStackPlatformType:
-		return images.ClusterAPIControllerOpenStack, nil
-	case configv1.AzurePlatformType:
-		return images.ClusterAPIControllerAzure, nil
-	case configv1.GCPPlatformType:
-		return images.ClusterAPIControllerGCP, nil
-	case configv1.BareMetalPlatformType:
-		return images.ClusterAPIControllerBareMetal, nil
-	case configv1.OvirtPlatformType:
-		return images.ClusterAPIControllerOvirt, nil
-	case configv1.VSpherePlatformType:
-		return images.ClusterAPIControllerVSphere, nil
-	case configv1.IBMCloudPlatformType:
-		return images.ClusterAPIControllerIBMCloud, nil
-	case configv1.PowerVSPlatformType:
-		return images.ClusterAPIControllerPowerVS, nil
-	case configv1.NutanixPlatformType:
-		return images.ClusterAPIControllerNutanix, nil
 	case kubemarkPlatform:
 		return clusterAPIControllerKubemark, nil
 	case configv1.NonePlatformType, configv1.ExternalPlatformType:
@@ -515,3 +515,76 @@ func TestImagesInImageReferences(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateImages(t *testing.T) {
+	imagesJSONFile, err := createImagesJSONFromManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.Remove(imagesJSONFile); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	img, err := getImagesFromJSONFile(imagesJSONFile)
+	if err != nil {
+		t.Fatal(fmt.Errorf("failed getImagesFromJSONFile, %v", err))
+	}
+
+	withoutAWS := *img
+	withoutAWS.ClusterAPIControllerAWS = ""
+	withoutOperators := *img
+	withoutOperators.MachineAPIOperator = ""
+	withoutOperators.KubeRBACProxy = ""
+
+	testCases := []struct {
+		name          string
+		platform      configv1.PlatformType
+		images        Images
+		expectedError string
+	}{
+		{
+			name:     "with all images",
+			platform: configv1.AWSPlatformType,
+			images:   *img,
+		},
+		{
+			name:          "without the provider image",
+			platform:      configv1.AWSPlatformType,
+			images:        withoutAWS,
+			expectedError: "images.json is missing required images: clusterAPIControllerAWS",
+		},
+		{
+			name:     "without the image of another provider",
+			platform: configv1.GCPPlatformType,
+			images:   withoutAWS,
+		},
+		{
+			name:     "without a provider image on a platform which does not need one",
+			platform: configv1.NonePlatformType,
+			images:   Images{MachineAPIOperator: expectedMachineAPIOperatorImage, KubeRBACProxy: expectedKubeRBACProxyImage},
+		},
+		{
+			name:          "without the operator images",
+			platform:      configv1.NonePlatformType,
+			images:        withoutOperators,
+			expectedError: "images.json is missing required images: machineAPIOperator, kubeRBACProxy",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateImages(tc.platform, tc.images)
+			if tc.expectedError == "" {
+				if err != nil {
+					t.Errorf("failed validateImages: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.expectedError {
+				t.Errorf("failed validateImages. Expected error: %q, got: %v", tc.expectedError, err)
+			}
+		})
+	}
+}
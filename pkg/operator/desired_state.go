@@ -0,0 +1,115 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+)
+
+const (
+	// desiredStateConfigMapName is the name of the ConfigMap holding the summary of the desired state of the operands
+	desiredStateConfigMapName = "machine-api-operator-desired-state"
+	// desiredStateConfigMapKey is the ConfigMap key holding the JSON desired state summary
+	desiredStateConfigMapKey = "desiredState.json"
+)
+
+// desiredState summarises the operands the operator deploys, for debugging purposes.
+type desiredState struct {
+	// Images of the containers of the operands, keyed by <operand>/<container>.
+	Images map[string]string `json:"images"`
+	// Replicas of the operand deployments, keyed by deployment name.
+	Replicas map[string]int32 `json:"replicas"`
+	// LastChangeTime is the time the desired state of the operands last changed. It is not updated by the syncs
+	// which leave the desired state unchanged.
+	LastChangeTime metav1.Time `json:"lastChangeTime"`
+}
+
+// newDesiredState returns the desired state of the operands rendered from the config. It is derived from the very
+// objects which are applied, so that it always reflects what the operator deploys.
+func newDesiredState(config *OperatorConfig, changeTime time.Time) *desiredState {
+	state := &desiredState{
+		Images:         map[string]string{},
+		Replicas:       map[string]int32{},
+		LastChangeTime: metav1.NewTime(changeTime),
+	}
+
+	addImages := func(operand string, podSpec corev1.PodSpec) {
+		for _, container := range append(podSpec.InitContainers, podSpec.Containers...) {
+			state.Images[operand+"/"+container.Name] = container.Image
+		}
+	}
+
+	deployment := newDeployment(config, config.Features)
+	addImages(deployment.Name, deployment.Spec.Template.Spec)
+	if deployment.Spec.Replicas != nil {
+		state.Replicas[deployment.Name] = *deployment.Spec.Replicas
+	}
+
+	if config.Controllers.TerminationHandler != clusterAPIControllerNoOp {
+		daemonSet := newTerminationDaemonSet(config)
+		addImages(daemonSet.Name, daemonSet.Spec.Template.Spec)
+	}
+
+	return state
+}
+
+// syncDesiredState writes the desired state of the operands to the desired state ConfigMap. The LastChangeTime of
+// the ConfigMap is kept while the desired state does not change, so that it is not rewritten on every sync.
+func (optr *Operator) syncDesiredState(config *OperatorConfig) error {
+	state := newDesiredState(config, time.Now())
+	previous, err := optr.getDesiredState(config.TargetNamespace)
+	if err != nil {
+		return err
+	}
+	if previous != nil && equality.Semantic.DeepEqual(previous.Images, state.Images) &&
+		equality.Semantic.DeepEqual(previous.Replicas, state.Replicas) {
+		state.LastChangeTime = previous.LastChangeTime
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal desired state: %w", err)
+	}
+
+	_, _, err = resourceapply.ApplyConfigMap(context.TODO(), optr.kubeClient.CoreV1(),
+		events.NewLoggingEventRecorder(optr.name, clock.RealClock{}), &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      desiredStateConfigMapName,
+				Namespace: config.TargetNamespace,
+				Annotations: map[string]string{
+					maoOwnedAnnotation: "",
+				},
+			},
+			Data: map[string]string{
+				desiredStateConfigMapKey: string(data),
+			},
+		})
+	return err
+}
+
+// getDesiredState returns the desired state stored in the desired state ConfigMap, nil if there is none.
+func (optr *Operator) getDesiredState(namespace string) (*desiredState, error) {
+	cm, err := optr.kubeClient.CoreV1().ConfigMaps(namespace).Get(context.TODO(), desiredStateConfigMapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get desired state: %w", err)
+	}
+
+	state := &desiredState{}
+	if err := json.Unmarshal([]byte(cm.Data[desiredStateConfigMapKey]), state); err != nil {
+		// The ConfigMap is rewritten from scratch
+		return nil, nil
+	}
+	return state, nil
+}
@@ -72,6 +72,9 @@ type Operator struct {
 	proxyLister       configlistersv1.ProxyLister
 	proxyListerSynced cache.InformerSynced
 
+	infrastructureLister       configlistersv1.InfrastructureLister
+	infrastructureListerSynced cache.InformerSynced
+
 	cache                         resourceapply.ResourceCache
 	validatingWebhookLister       admissionlisterv1.ValidatingWebhookConfigurationLister
 	validatingWebhookListerSynced cache.InformerSynced
@@ -102,6 +105,7 @@ func New(
 	validatingWebhookInformer admissioninformersv1.ValidatingWebhookConfigurationInformer,
 	mutatingWebhookInformer admissioninformersv1.MutatingWebhookConfigurationInformer,
 	proxyInformer configinformersv1.ProxyInformer,
+	infrastructureInformer configinformersv1.InfrastructureInformer,
 	kubeClient kubernetes.Interface,
 	osClient osclientset.Interface,
 	machineClient machineclientset.Interface,
@@ -157,6 +161,10 @@ func New(
 	if err != nil {
 		return nil, fmt.Errorf("error adding event handler to clusteroperator informer: %v", err)
 	}
+	_, err = infrastructureInformer.Informer().AddEventHandler(optr.eventHandlerSingleton(isClusterInfrastructure))
+	if err != nil {
+		return nil, fmt.Errorf("error adding event handler to infrastructure informer: %v", err)
+	}
 
 	desiredVersion := releaseVersion
 	missingVersion := "0.0.1-snapshot"
@@ -200,6 +208,9 @@ func New(
 	optr.proxyLister = proxyInformer.Lister()
 	optr.proxyListerSynced = proxyInformer.Informer().HasSynced
 
+	optr.infrastructureLister = infrastructureInformer.Lister()
+	optr.infrastructureListerSynced = infrastructureInformer.Informer().HasSynced
+
 	optr.cache = resourceapply.NewResourceCache()
 	optr.validatingWebhookLister = validatingWebhookInformer.Lister()
 	optr.validatingWebhookListerSynced = validatingWebhookInformer.Informer().HasSynced
@@ -222,7 +233,8 @@ func (optr *Operator) Run(workers int, stopCh <-chan struct{}) {
 		optr.validatingWebhookListerSynced,
 		optr.deployListerSynced,
 		optr.daemonsetListerSynced,
-		optr.proxyListerSynced) {
+		optr.proxyListerSynced,
+		optr.infrastructureListerSynced) {
 		klog.Error("Failed to sync caches")
 		return
 	}
@@ -357,6 +369,15 @@ func isSelfClusterOperator(obj interface{}) bool {
 	return false
 }
 
+func isClusterInfrastructure(obj interface{}) bool {
+	infrastructure, ok := obj.(*osconfigv1.Infrastructure)
+	if ok {
+		return infrastructure.Name == "cluster"
+	}
+
+	return false
+}
+
 func (optr *Operator) worker() {
 	for optr.processNextWorkItem() {
 	}
@@ -409,8 +430,29 @@ func (optr *Operator) sync(key string) (reconcile.Result, error) {
 		klog.V(4).Infof("Finished syncing operator %q (%v)", key, time.Since(startTime))
 	}()
 
+	paused, err := optr.isPaused()
+	if err != nil {
+		klog.Errorf("Failed checking whether the operator is paused: %v", err)
+		return reconcile.Result{}, err
+	}
+	if paused {
+		klog.Infof("Sync is paused by the %s annotation on the %s ClusterOperator", OperatorPausedAnnotation, clusterOperatorName)
+		if err := optr.statusPaused(); err != nil {
+			klog.Errorf("Error syncing ClusterOperatorStatus: %v", err)
+			return reconcile.Result{}, fmt.Errorf("error syncing ClusterOperatorStatus: %v", err)
+		}
+		return reconcile.Result{}, nil
+	}
+
 	operatorConfig, err := optr.maoConfigFromInfrastructure()
 	if err != nil {
+		var missingImages *missingImagesError
+		if errors.As(err, &missingImages) {
+			// The operands cannot be deployed until images.json is fixed
+			if err := optr.statusDegraded(err.Error()); err != nil {
+				klog.Errorf("Error syncing ClusterOperatorStatus: %v", err)
+			}
+		}
 		klog.Errorf("Failed getting operator config: %v", err)
 		return reconcile.Result{}, err
 	}
@@ -433,6 +475,10 @@ func (optr *Operator) maoConfigFromInfrastructure() (*OperatorConfig, error) {
 		return nil, err
 	}
 
+	if err := validateImages(provider, *images); err != nil {
+		return nil, err
+	}
+
 	providerControllerImage, err := getProviderControllerFromImages(provider, *images)
 	if err != nil {
 		return nil, err
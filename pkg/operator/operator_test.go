@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"syscall"
 	"testing"
 	"time"
@@ -60,7 +61,7 @@ var (
 )
 
 func newFakeOperator(kubeObjects, osObjects, machineObjects []runtime.Object, imagesFile string, fg *openshiftv1.FeatureGate, stopCh <-chan struct{}) (*Operator, error) {
-	kubeClient := fakekube.NewSimpleClientset(kubeObjects...)
+	kubeClient := fakekube.NewClientset(kubeObjects...)
 	osClient := fakeos.NewSimpleClientset(osObjects...)
 	machineClient := fakemachine.NewSimpleClientset(machineObjects...)
 	dynamicClient := fakedynamic.NewSimpleDynamicClient(scheme.Scheme, kubeObjects...)
@@ -68,6 +69,7 @@ func newFakeOperator(kubeObjects, osObjects, machineObjects []runtime.Object, im
 	configSharedInformer := configinformersv1.NewSharedInformerFactoryWithOptions(osClient, 2*time.Minute)
 	deployInformer := kubeNamespacedSharedInformer.Apps().V1().Deployments()
 	proxyInformer := configSharedInformer.Config().V1().Proxies()
+	infrastructureInformer := configSharedInformer.Config().V1().Infrastructures()
 	daemonsetInformer := kubeNamespacedSharedInformer.Apps().V1().DaemonSets()
 	mutatingWebhookInformer := kubeNamespacedSharedInformer.Admissionregistration().V1().MutatingWebhookConfigurations()
 	validatingWebhookInformer := kubeNamespacedSharedInformer.Admissionregistration().V1().ValidatingWebhookConfigurations()
@@ -100,6 +102,7 @@ func newFakeOperator(kubeObjects, osObjects, machineObjects []runtime.Object, im
 		dynamicClient:           dynamicClient,
 		deployLister:            deployInformer.Lister(),
 		proxyLister:             proxyInformer.Lister(),
+		infrastructureLister:    infrastructureInformer.Lister(),
 		daemonsetLister:         daemonsetInformer.Lister(),
 		mutatingWebhookLister:   mutatingWebhookInformer.Lister(),
 		validatingWebhookLister: validatingWebhookInformer.Lister(),
@@ -112,6 +115,7 @@ func newFakeOperator(kubeObjects, osObjects, machineObjects []runtime.Object, im
 		}),
 		deployListerSynced:            deployInformer.Informer().HasSynced,
 		proxyListerSynced:             proxyInformer.Informer().HasSynced,
+		infrastructureListerSynced:    infrastructureInformer.Informer().HasSynced,
 		daemonsetListerSynced:         daemonsetInformer.Informer().HasSynced,
 		cache:                         resourceapply.NewResourceCache(),
 		mutatingWebhookListerSynced:   mutatingWebhookInformer.Informer().HasSynced,
@@ -286,6 +290,242 @@ func TestOperatorSync_NoOp(t *testing.T) {
 	}
 }
 
+func TestOperatorSyncMissingImages(t *testing.T) {
+	g := NewWithT(t)
+
+	imagesJSONData, err := extractImagesJSONFromManifest()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	imageMap := make(map[string]string)
+	g.Expect(json.Unmarshal(imagesJSONData, &imageMap)).To(Succeed())
+	delete(imageMap, "clusterAPIControllerAWS")
+	imagesJSONData, err = json.Marshal(imageMap)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	imagesJSONFile := filepath.Join(t.TempDir(), "images.json")
+	g.Expect(os.WriteFile(imagesJSONFile, imagesJSONData, 0600)).To(Succeed())
+
+	infra := &openshiftv1.Infrastructure{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cluster",
+		},
+		Status: openshiftv1.InfrastructureStatus{
+			PlatformStatus: &openshiftv1.PlatformStatus{
+				Type: openshiftv1.AWSPlatformType,
+			},
+		},
+	}
+
+	proxy := &openshiftv1.Proxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cluster",
+		},
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	optr, err := newFakeOperator(nil, []runtime.Object{infra, proxy}, nil, imagesJSONFile, nil, stopCh)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	_, err = optr.sync("trigger")
+	g.Expect(err).To(MatchError(ContainSubstring("clusterAPIControllerAWS")))
+
+	co, err := optr.osClient.ConfigV1().ClusterOperators().Get(context.Background(), clusterOperatorName, metav1.GetOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	var degraded *openshiftv1.ClusterOperatorStatusCondition
+	for i := range co.Status.Conditions {
+		if co.Status.Conditions[i].Type == openshiftv1.OperatorDegraded {
+			degraded = &co.Status.Conditions[i]
+		}
+	}
+	g.Expect(degraded).ToNot(BeNil())
+	g.Expect(degraded.Status).To(Equal(openshiftv1.ConditionTrue))
+	g.Expect(degraded.Reason).To(Equal(string(ReasonSyncFailed)))
+	g.Expect(degraded.Message).To(ContainSubstring("images.json is missing required images: clusterAPIControllerAWS"))
+}
+
+func TestOperatorSyncPaused(t *testing.T) {
+	g := NewWithT(t)
+
+	imagesJSONFile, err := createImagesJSONFromManifest()
+	g.Expect(err).ToNot(HaveOccurred())
+	defer func() {
+		g.Expect(os.Remove(imagesJSONFile)).To(Succeed())
+	}()
+
+	infra := &openshiftv1.Infrastructure{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cluster",
+		},
+		Status: openshiftv1.InfrastructureStatus{
+			PlatformStatus: &openshiftv1.PlatformStatus{
+				Type: openshiftv1.AWSPlatformType,
+			},
+		},
+	}
+
+	proxy := &openshiftv1.Proxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cluster",
+		},
+	}
+
+	co := &openshiftv1.ClusterOperator{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        clusterOperatorName,
+			Annotations: map[string]string{OperatorPausedAnnotation: ""},
+		},
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	optr, err := newFakeOperator(nil, []runtime.Object{infra, proxy, co}, nil, imagesJSONFile, nil, stopCh)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	getConditions := func() map[openshiftv1.ClusterStatusConditionType]openshiftv1.ClusterOperatorStatusCondition {
+		co, err := optr.osClient.ConfigV1().ClusterOperators().Get(context.Background(), clusterOperatorName, metav1.GetOptions{})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		conditions := map[openshiftv1.ClusterStatusConditionType]openshiftv1.ClusterOperatorStatusCondition{}
+		for _, c := range co.Status.Conditions {
+			conditions[c.Type] = c
+		}
+		return conditions
+	}
+
+	// The sync is a no-op while paused
+	_, err = optr.sync("trigger")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	_, err = optr.kubeClient.AppsV1().Deployments(targetNamespace).Get(context.Background(), deploymentName, metav1.GetOptions{})
+	g.Expect(kerrors.IsNotFound(err)).To(BeTrue(), "expected the deployment not to be synced while paused, got %v", err)
+
+	conditions := getConditions()
+	for _, conditionType := range []openshiftv1.ClusterStatusConditionType{openshiftv1.OperatorProgressing, openshiftv1.OperatorUpgradeable} {
+		g.Expect(conditions[conditionType].Status).To(Equal(openshiftv1.ConditionFalse), "unexpected %s status", conditionType)
+		g.Expect(conditions[conditionType].Reason).To(Equal(string(ReasonPaused)), "unexpected %s reason", conditionType)
+		g.Expect(conditions[conditionType].Message).To(ContainSubstring(OperatorPausedAnnotation))
+	}
+
+	// The sync resumes once the annotation is removed
+	co, err = optr.osClient.ConfigV1().ClusterOperators().Get(context.Background(), clusterOperatorName, metav1.GetOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	delete(co.Annotations, OperatorPausedAnnotation)
+	_, err = optr.osClient.ConfigV1().ClusterOperators().Update(context.Background(), co, metav1.UpdateOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	_, err = optr.sync("trigger")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	_, err = optr.kubeClient.AppsV1().Deployments(targetNamespace).Get(context.Background(), deploymentName, metav1.GetOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	conditions = getConditions()
+	g.Expect(conditions[openshiftv1.OperatorProgressing].Status).To(Equal(openshiftv1.ConditionTrue))
+	g.Expect(conditions[openshiftv1.OperatorProgressing].Reason).To(Equal(string(ReasonSyncing)))
+	g.Expect(conditions[openshiftv1.OperatorUpgradeable].Status).To(Equal(openshiftv1.ConditionTrue))
+}
+
+func TestOperatorSyncDesiredState(t *testing.T) {
+	g := NewWithT(t)
+
+	imagesJSONData, err := extractImagesJSONFromManifest()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	imageMap := make(map[string]string)
+	g.Expect(json.Unmarshal(imagesJSONData, &imageMap)).To(Succeed())
+	imageMap["machineAPIOperator"] = "example.com/machine-api-operator:test"
+	imageMap["clusterAPIControllerAWS"] = "example.com/machine-api-provider-aws:test"
+	imageMap["kubeRBACProxy"] = "example.com/kube-rbac-proxy:test"
+	imagesJSONData, err = json.Marshal(imageMap)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	imagesJSONFile := filepath.Join(t.TempDir(), "images.json")
+	g.Expect(os.WriteFile(imagesJSONFile, imagesJSONData, 0600)).To(Succeed())
+
+	infra := &openshiftv1.Infrastructure{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cluster",
+		},
+		Status: openshiftv1.InfrastructureStatus{
+			PlatformStatus: &openshiftv1.PlatformStatus{
+				Type: openshiftv1.AWSPlatformType,
+			},
+		},
+	}
+
+	proxy := &openshiftv1.Proxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cluster",
+		},
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	optr, err := newFakeOperator(nil, []runtime.Object{infra, proxy}, nil, imagesJSONFile, nil, stopCh)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	beforeSync := time.Now().Truncate(time.Second)
+	_, err = optr.sync("trigger")
+	g.Expect(err).ToNot(HaveOccurred())
+
+	cm, err := optr.kubeClient.CoreV1().ConfigMaps(targetNamespace).Get(context.Background(), desiredStateConfigMapName, metav1.GetOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	state := &desiredState{}
+	g.Expect(json.Unmarshal([]byte(cm.Data[desiredStateConfigMapKey]), state)).To(Succeed())
+
+	g.Expect(state.Images).To(HaveKeyWithValue("machine-api-controllers/machineset-controller", "example.com/machine-api-operator:test"))
+	g.Expect(state.Images).To(HaveKeyWithValue("machine-api-controllers/nodelink-controller", "example.com/machine-api-operator:test"))
+	g.Expect(state.Images).To(HaveKeyWithValue("machine-api-controllers/machine-controller", "example.com/machine-api-provider-aws:test"))
+	g.Expect(state.Images).To(HaveKeyWithValue("machine-api-controllers/kube-rbac-proxy-machine-mtrc", "example.com/kube-rbac-proxy:test"))
+	g.Expect(state.Images).To(HaveKeyWithValue("machine-api-termination-handler/termination-handler", "example.com/machine-api-provider-aws:test"))
+	g.Expect(state.Replicas).To(Equal(map[string]int32{"machine-api-controllers": 1}))
+	g.Expect(state.LastChangeTime.Time).To(BeTemporally(">=", beforeSync))
+
+	// The summary matches the applied deployment
+	deployment, err := optr.kubeClient.AppsV1().Deployments(targetNamespace).Get(context.Background(), "machine-api-controllers", metav1.GetOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		g.Expect(state.Images).To(HaveKeyWithValue("machine-api-controllers/"+container.Name, container.Image))
+	}
+
+	setStoredState := func(mutate func(*desiredState)) {
+		stored := &desiredState{Images: map[string]string{}, Replicas: state.Replicas}
+		for key, image := range state.Images {
+			stored.Images[key] = image
+		}
+		mutate(stored)
+		data, err := json.Marshal(stored)
+		g.Expect(err).ToNot(HaveOccurred())
+		cm.Data[desiredStateConfigMapKey] = string(data)
+		_, err = optr.kubeClient.CoreV1().ConfigMaps(targetNamespace).Update(context.Background(), cm, metav1.UpdateOptions{})
+		g.Expect(err).ToNot(HaveOccurred())
+	}
+	syncState := func() *desiredState {
+		_, err := optr.sync("trigger")
+		g.Expect(err).ToNot(HaveOccurred())
+		cm, err = optr.kubeClient.CoreV1().ConfigMaps(targetNamespace).Get(context.Background(), desiredStateConfigMapName, metav1.GetOptions{})
+		g.Expect(err).ToNot(HaveOccurred())
+		synced := &desiredState{}
+		g.Expect(json.Unmarshal([]byte(cm.Data[desiredStateConfigMapKey]), synced)).To(Succeed())
+		return synced
+	}
+	lastChange := metav1.NewTime(beforeSync.Add(-time.Hour))
+
+	// The LastChangeTime is kept while the desired state does not change
+	setStoredState(func(s *desiredState) { s.LastChangeTime = lastChange })
+	g.Expect(syncState().LastChangeTime.Time).To(BeTemporally("==", lastChange.Time))
+
+	// The LastChangeTime is updated once the desired state changes
+	setStoredState(func(s *desiredState) {
+		s.LastChangeTime = lastChange
+		s.Images["machine-api-controllers/machine-controller"] = "example.com/machine-api-provider-aws:previous"
+	})
+	g.Expect(syncState().LastChangeTime.Time).To(BeTemporally(">=", beforeSync))
+}
+
 func TestIsOwned(t *testing.T) {
 	testCases := []struct {
 		testCase      string
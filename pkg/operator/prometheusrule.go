@@ -0,0 +1,129 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openshift/library-go/pkg/operator/events"
+	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+)
+
+const (
+	machineAPIHealthRulesName = "machine-api-health-rules"
+	monitoringGroupVersion    = "monitoring.coreos.com/v1"
+	prometheusRuleResource    = "prometheusrules"
+)
+
+var prometheusRuleGVR = schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1", Resource: prometheusRuleResource}
+
+// syncPrometheusRule reconciles the machine API health alerting rules.
+// Nothing is done when the PrometheusRule CRD is not installed on the cluster,
+// or when an existing rule set does not carry the ownership annotation,
+// which means it has been taken over and customised by the user.
+func (optr *Operator) syncPrometheusRule(config *OperatorConfig) error {
+	available, err := optr.isPrometheusRuleAvailable()
+	if err != nil {
+		return fmt.Errorf("unable to discover PrometheusRule API: %w", err)
+	}
+	if !available {
+		klog.V(3).Infof("%s API is not available, skipping PrometheusRule synchronisation", monitoringGroupVersion)
+		return nil
+	}
+
+	existing, err := optr.dynamicClient.Resource(prometheusRuleGVR).Namespace(config.TargetNamespace).Get(context.TODO(), machineAPIHealthRulesName, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		if _, owned := existing.GetAnnotations()[maoOwnedAnnotation]; !owned {
+			klog.V(3).Infof("PrometheusRule %s/%s is not owned by the operator, skipping", config.TargetNamespace, machineAPIHealthRulesName)
+			return nil
+		}
+	}
+
+	_, _, err = resourceapply.ApplyPrometheusRule(context.TODO(), optr.dynamicClient,
+		events.NewLoggingEventRecorder(optr.name, clock.RealClock{}), newPrometheusRule(config))
+	return err
+}
+
+// isPrometheusRuleAvailable checks whether the PrometheusRule CRD is served by the API server.
+func (optr *Operator) isPrometheusRuleAvailable() (bool, error) {
+	resources, err := optr.kubeClient.Discovery().ServerResourcesForGroupVersion(monitoringGroupVersion)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	for _, resource := range resources.APIResources {
+		if resource.Name == prometheusRuleResource {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func newPrometheusRule(config *OperatorConfig) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": monitoringGroupVersion,
+			"kind":       "PrometheusRule",
+			"metadata": map[string]interface{}{
+				"name":      machineAPIHealthRulesName,
+				"namespace": config.TargetNamespace,
+				"labels": map[string]interface{}{
+					"prometheus": "k8s",
+					"role":       "alert-rules",
+				},
+				"annotations": map[string]interface{}{
+					maoOwnedAnnotation: "",
+				},
+			},
+			"spec": map[string]interface{}{
+				"groups": []interface{}{
+					map[string]interface{}{
+						"name": "machine-api-health",
+						"rules": []interface{}{
+							newAlertingRule(
+								"MachineSetWithoutAvailableReplicas",
+								`(mapi_machine_set_status_replicas > 0) and on (name, namespace) (mapi_machine_set_status_replicas_available == 0)`,
+								"30m",
+								"machine set {{ $labels.name }} has no available replicas",
+								"The machine set expects replicas but none of its machines are available. Check the status of the machines owned by the machine set.",
+							),
+							newAlertingRule(
+								"MachineAPIProviderErrorRateHigh",
+								`sum by (namespace) (rate({__name__=~"mapi_instance_(create|update|delete)_failed"}[15m])) > 0.1`,
+								"15m",
+								"machine api provider requests are failing in namespace {{ $labels.namespace }}",
+								"Instance create, update or delete requests to the infrastructure provider are failing. Check the machine-controller logs for details.",
+							),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func newAlertingRule(alert, expr, duration, summary, description string) map[string]interface{} {
+	return map[string]interface{}{
+		"alert": alert,
+		"expr":  expr,
+		"for":   duration,
+		"labels": map[string]interface{}{
+			"severity": "warning",
+		},
+		"annotations": map[string]interface{}{
+			"summary":     summary,
+			"description": description,
+		},
+	}
+}
@@ -0,0 +1,107 @@
+package operator
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestSyncPrometheusRule(t *testing.T) {
+	userRule := newPrometheusRule(&OperatorConfig{TargetNamespace: targetNamespace})
+	userRule.SetAnnotations(nil)
+	g := NewWithT(t)
+	g.Expect(unstructured.SetNestedSlice(userRule.Object, []interface{}{}, "spec", "groups")).To(Succeed())
+
+	testCases := []struct {
+		name             string
+		crdAvailable     bool
+		existingObjects  []runtime.Object
+		expectRuleExists bool
+		expectOwned      bool
+	}{
+		{
+			name:             "when the PrometheusRule CRD is not available",
+			crdAvailable:     false,
+			expectRuleExists: false,
+		},
+		{
+			name:             "when the PrometheusRule CRD is available",
+			crdAvailable:     true,
+			expectRuleExists: true,
+			expectOwned:      true,
+		},
+		{
+			name:             "when the PrometheusRule has been customised by the user",
+			crdAvailable:     true,
+			existingObjects:  []runtime.Object{userRule},
+			expectRuleExists: true,
+			expectOwned:      false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			kubeClient := fakekube.NewSimpleClientset()
+			if tc.crdAvailable {
+				kubeClient.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+					{
+						GroupVersion: monitoringGroupVersion,
+						APIResources: []metav1.APIResource{{Name: prometheusRuleResource, Namespaced: true, Kind: "PrometheusRule"}},
+					},
+				}
+			}
+
+			dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(scheme.Scheme,
+				map[schema.GroupVersionResource]string{prometheusRuleGVR: "PrometheusRuleList"}, tc.existingObjects...)
+
+			optr := &Operator{
+				name:          "machine-api-operator",
+				namespace:     targetNamespace,
+				kubeClient:    kubeClient,
+				dynamicClient: dynamicClient,
+			}
+
+			g.Expect(optr.syncPrometheusRule(&OperatorConfig{TargetNamespace: targetNamespace})).To(Succeed())
+
+			rule, err := dynamicClient.Resource(prometheusRuleGVR).Namespace(targetNamespace).Get(context.Background(), machineAPIHealthRulesName, metav1.GetOptions{})
+			if !tc.expectRuleExists {
+				g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+
+			_, owned := rule.GetAnnotations()[maoOwnedAnnotation]
+			g.Expect(owned).To(Equal(tc.expectOwned))
+
+			groups, _, err := unstructured.NestedSlice(rule.Object, "spec", "groups")
+			g.Expect(err).ToNot(HaveOccurred())
+			if tc.expectOwned {
+				g.Expect(groups).To(HaveLen(1))
+
+				// Stuck deleting Machines and short-circuited MachineHealthChecks are alerted on by the
+				// static rules of the install manifests, they must not be alerted on twice
+				rules, _, err := unstructured.NestedSlice(groups[0].(map[string]interface{}), "rules")
+				g.Expect(err).ToNot(HaveOccurred())
+				alerts := []string{}
+				for _, rule := range rules {
+					alerts = append(alerts, rule.(map[string]interface{})["alert"].(string))
+				}
+				g.Expect(alerts).To(ConsistOf("MachineSetWithoutAvailableReplicas", "MachineAPIProviderErrorRateHigh"))
+			} else {
+				g.Expect(groups).To(BeEmpty())
+			}
+		})
+	}
+}
@@ -26,10 +26,15 @@ const (
 	ReasonInitializing StatusReason = "Initializing"
 	ReasonSyncing      StatusReason = "SyncingResources"
 	ReasonSyncFailed   StatusReason = "SyncingFailed"
+	ReasonPaused       StatusReason = "Paused"
 )
 
 const (
 	clusterOperatorName = "machine-api"
+
+	// OperatorPausedAnnotation pauses the sync of the operator while it is set on the machine-api
+	// ClusterOperator, so that its managed resources can be modified manually, e.g. when debugging.
+	OperatorPausedAnnotation = "machine.openshift.io/paused"
 )
 
 var (
@@ -134,6 +139,26 @@ func (optr *Operator) statusDegraded(error string) error {
 	return optr.syncStatus(co, conds)
 }
 
+// statusPaused sets the Progressing and Upgradeable conditions to False while the
+// sync is paused by the OperatorPausedAnnotation. It does not modify any existing
+// Available or Degraded conditions.
+func (optr *Operator) statusPaused() error {
+	message := fmt.Sprintf("Reconciliation is paused by the %s annotation on the %s ClusterOperator, remove it to resume",
+		OperatorPausedAnnotation, clusterOperatorName)
+
+	conds := []osconfigv1.ClusterOperatorStatusCondition{
+		newClusterOperatorStatusCondition(osconfigv1.OperatorProgressing, osconfigv1.ConditionFalse, string(ReasonPaused), message),
+		newClusterOperatorStatusCondition(osconfigv1.OperatorUpgradeable, osconfigv1.ConditionFalse, string(ReasonPaused), message),
+	}
+
+	co, err := optr.getOrCreateClusterOperator()
+	if err != nil {
+		return err
+	}
+	klog.V(2).Info("Syncing status: paused")
+	return optr.syncStatus(co, conds)
+}
+
 func newClusterOperatorStatusCondition(conditionType osconfigv1.ClusterStatusConditionType,
 	conditionStatus osconfigv1.ConditionStatus, reason string,
 	message string) osconfigv1.ClusterOperatorStatusCondition {
@@ -345,6 +370,42 @@ func (optr *Operator) printOperandVersions() string {
 	return strings.Join(versionsOutput, ", ")
 }
 
+// isUpgrading determines if the operator is progressing from a previous version towards the desired
+// versions. The versions are only reported once the operator is available, so this is false during installation.
+func (optr *Operator) isUpgrading() (bool, error) {
+	currentVersions, err := optr.getCurrentVersions()
+	if err != nil {
+		return false, err
+	}
+	return len(currentVersions) > 0 && !reflect.DeepEqual(optr.operandVersions, currentVersions), nil
+}
+
+// isDegraded determines if the Degraded condition of the ClusterOperator is True.
+func (optr *Operator) isDegraded() (bool, error) {
+	co, err := optr.getClusterOperator()
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("could not get cluster operator: %w", err)
+	}
+	return v1helpers.IsStatusConditionTrue(co.Status.Conditions, osconfigv1.OperatorDegraded), nil
+}
+
+// isPaused determines if the sync of the operator is paused by the OperatorPausedAnnotation.
+func (optr *Operator) isPaused() (bool, error) {
+	co, err := optr.getClusterOperator()
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("could not get cluster operator: %w", err)
+	}
+
+	_, paused := co.Annotations[OperatorPausedAnnotation]
+	return paused, nil
+}
+
 // isInitializing determines if the operator Available condition is still in the initializing
 // phase. This means the operator has never reached an available status.
 func (optr *Operator) isInitializing() (bool, error) {
@@ -444,3 +444,52 @@ func TestIsInitializing(t *testing.T) {
 		})
 	}
 }
+
+func TestIsUpgrading(t *testing.T) {
+	desiredVersions := []osconfigv1.OperandVersion{{Name: "operator", Version: "2.0.0"}}
+
+	testCases := []struct {
+		name              string
+		currentVersions   []osconfigv1.OperandVersion
+		expectedUpgrading bool
+	}{
+		{
+			name:              "with no reported versions",
+			currentVersions:   nil,
+			expectedUpgrading: false,
+		},
+		{
+			name:              "with the desired versions reported",
+			currentVersions:   []osconfigv1.OperandVersion{{Name: "operator", Version: "2.0.0"}},
+			expectedUpgrading: false,
+		},
+		{
+			name:              "with previous versions reported",
+			currentVersions:   []osconfigv1.OperandVersion{{Name: "operator", Version: "1.0.0"}},
+			expectedUpgrading: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			co := &osconfigv1.ClusterOperator{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: clusterOperatorName,
+				},
+				Status: osconfigv1.ClusterOperatorStatus{
+					Versions: tc.currentVersions,
+				},
+			}
+			optr := Operator{
+				osClient:        fakeconfigclientset.NewSimpleClientset(co),
+				operandVersions: desiredVersions,
+			}
+
+			upgrading, err := optr.isUpgrading()
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(upgrading).To(Equal(tc.expectedUpgrading))
+		})
+	}
+}
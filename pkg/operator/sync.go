@@ -26,6 +26,7 @@ import (
 	"github.com/openshift/library-go/pkg/operator/resource/resourceapply"
 	"github.com/openshift/library-go/pkg/operator/resource/resourcehash"
 	"github.com/openshift/library-go/pkg/operator/resource/resourcemerge"
+	mapicontroller "github.com/openshift/machine-api-operator/pkg/controller"
 	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
 	"github.com/openshift/machine-api-operator/pkg/metrics"
 	"github.com/openshift/machine-api-operator/pkg/util/conditions"
@@ -38,6 +39,7 @@ const (
 	machineAPITerminationHandler        = "machine-api-termination-handler"
 	MachineWebhookPort                  = 8440
 	MachineSetWebhookPort               = 8443
+	MachineHealthCheckWebhookPort       = 8445
 	machineExposeMetricsPort            = 8441
 	machineSetExposeMetricsPort         = 8442
 	machineHealthCheckExposeMetricsPort = 8444
@@ -52,8 +54,10 @@ const (
 	operatorStatusNoOpMessage           = "Cluster Machine API Operator is in NoOp mode"
 	machineSetWebhookVolumeName         = "machineset-webhook-cert"
 	machineWebhookVolumeName            = "machine-webhook-cert"
+	machineHealthCheckWebhookVolumeName = "machinehealthcheck-webhook-cert"
 	kubernetesOSlabel                   = "kubernetes.io/os"
 	kubernetesOSlabelLinux              = "linux"
+	vSphereCredentialsSecretName        = "vsphere-cloud-credentials"
 
 	minimumWorkerReplicas = int32(2)
 )
@@ -83,6 +87,31 @@ func (optr *Operator) syncAll(config *OperatorConfig) (reconcile.Result, error)
 		return reconcile.Result{}, nil
 	}
 
+	maintenance, err := optr.isMaintenancePaused()
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("error determining if the cluster is paused for maintenance: %v", err)
+	}
+	upgrading, err := optr.isUpgrading()
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("error determining if the cluster is upgrading: %v", err)
+	}
+	// Suspend the machine reconciliation until the controllers have been upgraded. Once the upgrade has
+	// failed it is not suspended again, so that it does not stay paused until the operator recovers.
+	pauseForUpgrade := false
+	if upgrading {
+		degraded, err := optr.isDegraded()
+		if err != nil {
+			return reconcile.Result{}, fmt.Errorf("error determining if the operator is degraded: %v", err)
+		}
+		pauseForUpgrade = !degraded
+	}
+	// The maintenance pause is requested by administrators, it is kept whatever the outcome of the sync
+	if maintenance || pauseForUpgrade {
+		if err := optr.syncClusterPause(true); err != nil {
+			return reconcile.Result{}, fmt.Errorf("error pausing machine API reconciliation: %v", err)
+		}
+	}
+
 	errors := []error{}
 	// Sync webhook configuration
 	if err := optr.syncWebhookConfiguration(config); err != nil {
@@ -100,6 +129,10 @@ func (optr *Operator) syncAll(config *OperatorConfig) (reconcile.Result, error)
 		}
 	}
 
+	if err := optr.syncPrometheusRule(config); err != nil {
+		errors = append(errors, fmt.Errorf("error syncing machine API prometheus rules: %w", err))
+	}
+
 	if len(errors) > 0 {
 		err := utilerrors.NewAggregate(errors)
 		if err := optr.statusDegraded(err.Error()); err != nil {
@@ -108,9 +141,15 @@ func (optr *Operator) syncAll(config *OperatorConfig) (reconcile.Result, error)
 			klog.Errorf("Error syncing ClusterOperatorStatus: %v", err)
 		}
 		klog.Errorf("Error syncing machine controller components: %v", err)
+		optr.resumeClusterPauseAfterFailure(maintenance)
 		return reconcile.Result{}, err
 	}
 
+	// The desired state is informational only, failing to record it must not degrade the operator
+	if err := optr.syncDesiredState(config); err != nil {
+		klog.Errorf("Error syncing machine API operator desired state: %v", err)
+	}
+
 	result, err := optr.checkRolloutStatus(config)
 	if err != nil {
 		if err := optr.statusDegraded(err.Error()); err != nil {
@@ -119,6 +158,7 @@ func (optr *Operator) syncAll(config *OperatorConfig) (reconcile.Result, error)
 			klog.Errorf("Error syncing ClusterOperatorStatus: %v", err)
 		}
 		klog.Errorf("Error waiting for resource to sync: %v", err)
+		optr.resumeClusterPauseAfterFailure(maintenance)
 		return reconcile.Result{}, err
 	}
 	if result.Requeue || result.RequeueAfter > 0 {
@@ -136,6 +176,7 @@ func (optr *Operator) syncAll(config *OperatorConfig) (reconcile.Result, error)
 			klog.Errorf("Error syncing ClusterOperatorStatus: %v", err)
 		}
 		klog.Errorf("Error determining state of operator: %v", err)
+		optr.resumeClusterPauseAfterFailure(maintenance)
 		return reconcile.Result{}, err
 	}
 
@@ -161,9 +202,70 @@ func (optr *Operator) syncAll(config *OperatorConfig) (reconcile.Result, error)
 		klog.Errorf("Error syncing ClusterOperatorStatus: %v", err)
 		return reconcile.Result{}, fmt.Errorf("error syncing ClusterOperatorStatus: %v", err)
 	}
+
+	// The controllers are now running at the desired version, resume the machine reconciliation
+	if err := optr.syncClusterPause(maintenance); err != nil {
+		return reconcile.Result{}, fmt.Errorf("error resuming machine API reconciliation: %v", err)
+	}
 	return reconcile.Result{}, nil
 }
 
+// isMaintenancePaused determines if the machine API controllers are paused for maintenance with the
+// MaintenancePauseAnnotation of the cluster Infrastructure.
+func (optr *Operator) isMaintenancePaused() (bool, error) {
+	infra, err := optr.infrastructureLister.Get("cluster")
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("could not get infrastructure: %w", err)
+	}
+	return infra.GetAnnotations()[mapicontroller.MaintenancePauseAnnotation] == "true", nil
+}
+
+// resumeClusterPauseAfterFailure resumes the machine reconciliation after a failed sync, which has degraded
+// the operator, unless it is paused for maintenance. Otherwise a failed upgrade would leave the machines
+// unreconciled until the operator recovers.
+func (optr *Operator) resumeClusterPauseAfterFailure(maintenance bool) {
+	if maintenance {
+		return
+	}
+	if err := optr.syncClusterPause(false); err != nil {
+		klog.Errorf("Error resuming machine API reconciliation: %v", err)
+	}
+}
+
+// syncClusterPause creates or removes the ConfigMap which pauses the reconciliation of the machine API
+// resources by the machine API controllers, during upgrades and while the MaintenancePauseAnnotation is
+// set. It is always kept in the namespace of the operator, which is passed to the controllers with the
+// --cluster-pause-namespace flag whatever the namespace of the resources they reconcile.
+func (optr *Operator) syncClusterPause(paused bool) error {
+	configMaps := optr.kubeClient.CoreV1().ConfigMaps(optr.namespace)
+
+	if !paused {
+		err := configMaps.Delete(context.TODO(), mapicontroller.ClusterPauseConfigMapName, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		if err == nil {
+			klog.V(2).Info("Resumed machine API reconciliation")
+		}
+		return nil
+	}
+
+	_, err := optr.applyConfigMap(context.TODO(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      mapicontroller.ClusterPauseConfigMapName,
+			Namespace: optr.namespace,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	klog.V(4).Info("Paused machine API reconciliation")
+	return nil
+}
+
 func (optr *Operator) checkRolloutStatus(config *OperatorConfig) (reconcile.Result, error) {
 	// Check for machine-controllers deployment
 	result, err := optr.checkDeploymentRolloutStatus(newDeployment(config, nil))
@@ -202,16 +304,11 @@ func (optr *Operator) syncClusterAPIController(config *OperatorConfig) error {
 	}
 	ensureDependecyAnnotations(inputHashes, controllersDeployment)
 
-	expectedGeneration := resourcemerge.ExpectedDeploymentGeneration(controllersDeployment, optr.generations)
-
-	d, updated, err := resourceapply.ApplyDeployment(context.TODO(), optr.kubeClient.AppsV1(),
-		events.NewLoggingEventRecorder(optr.name, clock.RealClock{}), controllersDeployment, expectedGeneration)
+	d, err := optr.applyDeployment(context.TODO(), controllersDeployment)
 	if err != nil {
 		return err
 	}
-	if updated {
-		resourcemerge.SetDeploymentGeneration(&optr.generations, d)
-	}
+	resourcemerge.SetDeploymentGeneration(&optr.generations, d)
 
 	return nil
 }
@@ -576,6 +673,26 @@ func newPodTemplateSpec(config *OperatorConfig, features map[string]bool) *corev
 				},
 			},
 		},
+		{
+			Name: machineHealthCheckWebhookVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					// keep this aligned with service.beta.openshift.io/serving-cert-secret-name annotation on its services
+					SecretName:  "machine-api-operator-machinehealthcheck-webhook-cert",
+					DefaultMode: ptr.To[int32](readOnly),
+					Items: []corev1.KeyToPath{
+						{
+							Key:  "tls.crt",
+							Path: "tls.crt",
+						},
+						{
+							Key:  "tls.key",
+							Path: "tls.key",
+						},
+					},
+				},
+			},
+		},
 		{
 			Name: "bound-sa-token",
 			VolumeSource: corev1.VolumeSource{
@@ -671,12 +788,34 @@ func newContainers(config *OperatorConfig, features map[string]bool) []corev1.Co
 	// --feature-gates=<name>=<bool>,<name>=<bool>... arg
 	featureGateArgs := append(args, buildFeatureGatesString(features))
 
+	// The controllers built from this repository look for the cluster pause ConfigMap in the namespace of the
+	// operator. The machine controllers of the other providers don't know the flag yet and use the default.
+	clusterPauseArg := fmt.Sprintf("--cluster-pause-namespace=%s", config.TargetNamespace)
+
+	machineSetArgs := append([]string{}, featureGateArgs...)
+	machineSetArgs = append(machineSetArgs, clusterPauseArg)
+
 	machineControllerArgs := append([]string{}, featureGateArgs...)
 	switch config.PlatformType {
 	case v1.AzurePlatformType:
 		machineControllerArgs = append(machineControllerArgs, "--max-concurrent-reconciles=10")
+	case v1.VSpherePlatformType:
+		// Fail the health checks of the controller while the secret requested by its CredentialsRequest is missing
+		machineControllerArgs = append(machineControllerArgs,
+			fmt.Sprintf("--credentials-secret=%s/%s", config.TargetNamespace, vSphereCredentialsSecretName),
+			clusterPauseArg)
 	}
 
+	nodeLinkArgs := append([]string{}, args...)
+	nodeLinkArgs = append(nodeLinkArgs, clusterPauseArg)
+
+	machineHealthCheckArgs := append([]string{}, args...)
+	machineHealthCheckArgs = append(machineHealthCheckArgs,
+		"--webhook-enabled=true",
+		fmt.Sprintf("--webhook-port=%d", MachineHealthCheckWebhookPort),
+		clusterPauseArg,
+	)
+
 	proxyEnvArgs := getProxyArgs(config)
 
 	containers := []corev1.Container{
@@ -684,7 +823,7 @@ func newContainers(config *OperatorConfig, features map[string]bool) []corev1.Co
 			Name:      "machineset-controller",
 			Image:     config.Controllers.MachineSet,
 			Command:   []string{"/machineset-controller"},
-			Args:      featureGateArgs,
+			Args:      machineSetArgs,
 			Resources: resources,
 			Env:       proxyEnvArgs,
 			Ports: []corev1.ContainerPort{
@@ -811,7 +950,7 @@ func newContainers(config *OperatorConfig, features map[string]bool) []corev1.Co
 			Name:                     "nodelink-controller",
 			Image:                    config.Controllers.NodeLink,
 			Command:                  []string{"/nodelink-controller"},
-			Args:                     args,
+			Args:                     nodeLinkArgs,
 			Env:                      proxyEnvArgs,
 			Resources:                resources,
 			TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
@@ -822,10 +961,14 @@ func newContainers(config *OperatorConfig, features map[string]bool) []corev1.Co
 			Name:      "machine-healthcheck-controller",
 			Image:     config.Controllers.MachineHealthCheck,
 			Command:   []string{"/machine-healthcheck"},
-			Args:      args,
+			Args:      machineHealthCheckArgs,
 			Env:       proxyEnvArgs,
 			Resources: resources,
 			Ports: []corev1.ContainerPort{
+				{
+					Name:          "mhc-webhook",
+					ContainerPort: MachineHealthCheckWebhookPort,
+				},
 				{
 					Name:          "healthz",
 					ContainerPort: defaultMachineHealthCheckHealthPort,
@@ -848,6 +991,13 @@ func newContainers(config *OperatorConfig, features map[string]bool) []corev1.Co
 				},
 			},
 			TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+			VolumeMounts: []corev1.VolumeMount{
+				{
+					MountPath: "/etc/machine-api-operator/tls",
+					Name:      machineHealthCheckWebhookVolumeName,
+					ReadOnly:  true,
+				},
+			},
 		})
 	}
 	return containers
@@ -1,6 +1,7 @@
 package operator
 
 import (
+	"context"
 	"errors"
 	"os"
 	"testing"
@@ -9,12 +10,17 @@ import (
 	. "github.com/onsi/gomega"
 	v1 "github.com/openshift/api/config/v1"
 	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	mapicontroller "github.com/openshift/machine-api-operator/pkg/controller"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/diff"
+	fakekube "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
 )
 
 func TestCheckDeploymentRolloutStatus(t *testing.T) {
@@ -459,6 +465,109 @@ func TestCheckMinimumWorkerMachines(t *testing.T) {
 	}
 }
 
+func TestNewContainersMachineControllerArgs(t *testing.T) {
+	testCases := []struct {
+		name               string
+		platformType       v1.PlatformType
+		expectedArgs       []string
+		expectedArgsAbsent []string
+	}{
+		{
+			name:               "vSphere checks its credentials secret",
+			platformType:       v1.VSpherePlatformType,
+			expectedArgs:       []string{"--credentials-secret=openshift-machine-api/vsphere-cloud-credentials"},
+			expectedArgsAbsent: []string{"--max-concurrent-reconciles=10"},
+		},
+		{
+			name:               "Azure raises the reconcile concurrency",
+			platformType:       v1.AzurePlatformType,
+			expectedArgs:       []string{"--max-concurrent-reconciles=10"},
+			expectedArgsAbsent: []string{"--credentials-secret=openshift-machine-api/vsphere-cloud-credentials"},
+		},
+		{
+			name:               "AWS uses the common arguments",
+			platformType:       v1.AWSPlatformType,
+			expectedArgsAbsent: []string{"--max-concurrent-reconciles=10", "--credentials-secret=openshift-machine-api/vsphere-cloud-credentials"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			config := &OperatorConfig{
+				TargetNamespace: "openshift-machine-api",
+				PlatformType:    tc.platformType,
+			}
+
+			var args []string
+			for _, container := range newContainers(config, map[string]bool{}) {
+				if container.Name == "machine-controller" {
+					args = container.Args
+				}
+			}
+			g.Expect(args).ToNot(BeEmpty(), "machine-controller container not found")
+
+			for _, arg := range tc.expectedArgs {
+				g.Expect(args).To(ContainElement(arg))
+			}
+			for _, arg := range tc.expectedArgsAbsent {
+				g.Expect(args).ToNot(ContainElement(arg))
+			}
+		})
+	}
+}
+
+func TestNewContainersClusterPauseNamespace(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		platformType         v1.PlatformType
+		containersWithArg    []string
+		containersWithoutArg []string
+	}{
+		{
+			name:              "vSphere passes the namespace to its machine controller",
+			platformType:      v1.VSpherePlatformType,
+			containersWithArg: []string{"machineset-controller", "machine-controller", "nodelink-controller", "machine-healthcheck-controller"},
+		},
+		{
+			name:                 "AWS does not pass the namespace to its machine controller",
+			platformType:         v1.AWSPlatformType,
+			containersWithArg:    []string{"machineset-controller", "nodelink-controller", "machine-healthcheck-controller"},
+			containersWithoutArg: []string{"machine-controller"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			config := &OperatorConfig{
+				TargetNamespace: targetNamespace,
+				PlatformType:    tc.platformType,
+				Controllers: Controllers{
+					MachineHealthCheck: "mhc-image",
+				},
+			}
+
+			args := map[string][]string{}
+			for _, container := range newContainers(config, map[string]bool{}) {
+				args[container.Name] = container.Args
+			}
+
+			arg := "--cluster-pause-namespace=" + targetNamespace
+			for _, name := range tc.containersWithArg {
+				g.Expect(args).To(HaveKey(name))
+				g.Expect(args[name]).To(ContainElement(arg), "container %s", name)
+			}
+			for _, name := range tc.containersWithoutArg {
+				g.Expect(args).To(HaveKey(name))
+				g.Expect(args[name]).ToNot(ContainElement(arg), "container %s", name)
+			}
+		})
+	}
+}
+
 func TestSyncWebhookConfiguration(t *testing.T) {
 
 	testCases := []struct {
@@ -511,3 +620,154 @@ func TestSyncWebhookConfiguration(t *testing.T) {
 		})
 	}
 }
+
+func TestSyncClusterPause(t *testing.T) {
+	g := NewWithT(t)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	optr, err := newFakeOperator(nil, nil, nil, "", nil, stopCh)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	isPaused := func() bool {
+		_, err := optr.kubeClient.CoreV1().ConfigMaps(targetNamespace).Get(context.TODO(), mapicontroller.ClusterPauseConfigMapName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return false
+		}
+		g.Expect(err).ToNot(HaveOccurred())
+		return true
+	}
+
+	// Resuming when not paused is a no-op
+	g.Expect(optr.syncClusterPause(false)).To(Succeed())
+	g.Expect(isPaused()).To(BeFalse())
+
+	g.Expect(optr.syncClusterPause(true)).To(Succeed())
+	g.Expect(isPaused()).To(BeTrue())
+
+	// Pausing is idempotent, it happens on every sync during an upgrade
+	g.Expect(optr.syncClusterPause(true)).To(Succeed())
+	g.Expect(isPaused()).To(BeTrue())
+
+	g.Expect(optr.syncClusterPause(false)).To(Succeed())
+	g.Expect(isPaused()).To(BeFalse())
+}
+
+func TestSyncAllClusterPauseOnFailure(t *testing.T) {
+	g := NewWithT(t)
+
+	// The reported versions differ from the desired ones while upgrading
+	co := &v1.ClusterOperator{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: clusterOperatorName,
+		},
+		Status: v1.ClusterOperatorStatus{
+			Versions: []v1.OperandVersion{{Name: "operator", Version: "0.0.0.previous"}},
+		},
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	optr, err := newFakeOperator(nil, []runtime.Object{co}, nil, "", nil, stopCh)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	kubeClient := optr.kubeClient.(*fakekube.Clientset)
+	kubeClient.PrependReactor("patch", "deployments", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("failed to create deployment")
+	})
+
+	pauseCreated := func() bool {
+		for _, action := range kubeClient.Actions() {
+			if patch, ok := action.(clienttesting.PatchAction); ok && patch.GetResource().Resource == "configmaps" &&
+				patch.GetName() == mapicontroller.ClusterPauseConfigMapName {
+				return true
+			}
+		}
+		return false
+	}
+	isPaused := func() bool {
+		_, err := kubeClient.CoreV1().ConfigMaps(targetNamespace).Get(context.TODO(), mapicontroller.ClusterPauseConfigMapName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return false
+		}
+		g.Expect(err).ToNot(HaveOccurred())
+		return true
+	}
+
+	config := &OperatorConfig{TargetNamespace: targetNamespace, PlatformType: v1.AWSPlatformType}
+
+	// The failed upgrade degrades the operator and resumes the reconciliation it had paused
+	_, err = optr.syncAll(config)
+	g.Expect(err).To(MatchError(ContainSubstring("failed to create deployment")))
+	g.Expect(pauseCreated()).To(BeTrue())
+	g.Expect(isPaused()).To(BeFalse())
+
+	degraded, err := optr.isDegraded()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(degraded).To(BeTrue())
+
+	// The reconciliation is not paused again while the upgrade is degraded
+	kubeClient.ClearActions()
+	_, err = optr.syncAll(config)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(pauseCreated()).To(BeFalse())
+	g.Expect(isPaused()).To(BeFalse())
+}
+
+func TestSyncAllMaintenancePause(t *testing.T) {
+	testCases := []struct {
+		name           string
+		annotations    map[string]string
+		expectedPaused bool
+	}{
+		{
+			name:           "with the maintenance annotation",
+			annotations:    map[string]string{mapicontroller.MaintenancePauseAnnotation: "true"},
+			expectedPaused: true,
+		},
+		{
+			name:           "with the maintenance annotation disabled",
+			annotations:    map[string]string{mapicontroller.MaintenancePauseAnnotation: "false"},
+			expectedPaused: false,
+		},
+		{
+			name:           "without the maintenance annotation",
+			expectedPaused: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			infra := &v1.Infrastructure{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "cluster",
+					Annotations: tc.annotations,
+				},
+			}
+
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			optr, err := newFakeOperator(nil, []runtime.Object{infra}, nil, "", nil, stopCh)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(cache.WaitForCacheSync(stopCh, optr.infrastructureListerSynced)).To(BeTrue())
+
+			kubeClient := optr.kubeClient.(*fakekube.Clientset)
+			kubeClient.PrependReactor("patch", "deployments", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				return true, nil, errors.New("failed to create deployment")
+			})
+
+			// The maintenance pause is kept even though the sync fails
+			_, err = optr.syncAll(&OperatorConfig{TargetNamespace: targetNamespace, PlatformType: v1.AWSPlatformType})
+			g.Expect(err).To(MatchError(ContainSubstring("failed to create deployment")))
+
+			_, err = kubeClient.CoreV1().ConfigMaps(targetNamespace).Get(context.TODO(), mapicontroller.ClusterPauseConfigMapName, metav1.GetOptions{})
+			if tc.expectedPaused {
+				g.Expect(err).ToNot(HaveOccurred())
+			} else {
+				g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			}
+		})
+	}
+}
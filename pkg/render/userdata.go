@@ -0,0 +1,67 @@
+// Package render renders userData templates with the values of the Machine they are provisioned for.
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// MachineContext holds the Machine specific values a userData template can reference. Empty values are
+// not set, referencing them in a template is an error.
+type MachineContext struct {
+	// Name is the name of the Machine, referenced as {{ .Name }}.
+	Name string
+	// Namespace is the namespace of the Machine, referenced as {{ .Namespace }}.
+	Namespace string
+	// Region is the region the instance is created in, referenced as {{ .Region }}.
+	Region string
+	// Zone is the zone the instance is created in, referenced as {{ .Zone }}.
+	Zone string
+}
+
+// userDataFuncs are the only functions, besides the builtins of text/template, available to userData templates.
+// They operate on strings only so that a template can't reach anything but the values of the MachineContext.
+var userDataFuncs = template.FuncMap{
+	"lower":     strings.ToLower,
+	"upper":     strings.ToUpper,
+	"trimSpace": strings.TrimSpace,
+	"quote":     strconv.Quote,
+}
+
+// UserData renders the userData template with the values of the Machine. Referencing a value which is not
+// set or a function which is not available fails the rendering. The rendered values are not evaluated again.
+func UserData(userDataTemplate []byte, machineContext MachineContext) ([]byte, error) {
+	tmpl, err := template.New("userData").
+		Option("missingkey=error").
+		Funcs(userDataFuncs).
+		Parse(string(userDataTemplate))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse userData template: %w", err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, machineContext.values()); err != nil {
+		return nil, fmt.Errorf("failed to render userData template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// values returns the values of the MachineContext which are set. A map is rendered instead of the struct,
+// so that missing values are reported by the missingkey=error option and no method is reachable.
+func (c MachineContext) values() map[string]string {
+	values := map[string]string{}
+	for key, value := range map[string]string{
+		"Name":      c.Name,
+		"Namespace": c.Namespace,
+		"Region":    c.Region,
+		"Zone":      c.Zone,
+	} {
+		if value != "" {
+			values[key] = value
+		}
+	}
+	return values
+}
@@ -0,0 +1,93 @@
+package render
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestUserData(t *testing.T) {
+	machineContext := MachineContext{
+		Name:      "worker-a-1",
+		Namespace: "openshift-machine-api",
+		Region:    "us-east-1",
+		Zone:      "us-east-1a",
+	}
+
+	testCases := []struct {
+		name           string
+		template       string
+		machineContext MachineContext
+		expected       string
+		expectedError  string
+	}{
+		{
+			name:           "without template actions",
+			template:       "#!/bin/bash\necho hello\n",
+			machineContext: machineContext,
+			expected:       "#!/bin/bash\necho hello\n",
+		},
+		{
+			name:           "with the values of the machine",
+			template:       `{"hostname":"{{ .Name }}","namespace":"{{ .Namespace }}","location":"{{ .Region }}/{{ .Zone }}"}`,
+			machineContext: machineContext,
+			expected:       `{"hostname":"worker-a-1","namespace":"openshift-machine-api","location":"us-east-1/us-east-1a"}`,
+		},
+		{
+			name:           "with the available functions",
+			template:       `{{ upper .Zone }} {{ lower "ZONE" }} {{ trimSpace "  zone  " }} {{ quote .Name }}`,
+			machineContext: machineContext,
+			expected:       `US-EAST-1A zone zone "worker-a-1"`,
+		},
+		{
+			name:           "with a value which is not set",
+			template:       "zone={{ .Zone }}",
+			machineContext: MachineContext{Name: "worker-a-1"},
+			expectedError:  `failed to render userData template: template: userData:1:8: executing "userData" at <.Zone>: map has no entry for key "Zone"`,
+		},
+		{
+			name:           "with an unknown value",
+			template:       "{{ .Password }}",
+			machineContext: machineContext,
+			expectedError:  `failed to render userData template: template: userData:1:3: executing "userData" at <.Password>: map has no entry for key "Password"`,
+		},
+		{
+			name:           "with a function which is not available",
+			template:       `{{ exec "rm -rf /" }}`,
+			machineContext: machineContext,
+			expectedError:  `failed to parse userData template: template: userData:1: function "exec" not defined`,
+		},
+		{
+			name:           "with a value called as a function",
+			template:       "{{ call .Name }}",
+			machineContext: machineContext,
+			expectedError:  `failed to render userData template: template: userData:1:3: executing "userData" at <call .Name>: error calling call: non-function .Name of type string`,
+		},
+		{
+			name:           "with a value holding template actions",
+			template:       "name={{ .Name }}",
+			machineContext: MachineContext{Name: `{{ .Zone }}`, Zone: "us-east-1a"},
+			expected:       "name={{ .Zone }}",
+		},
+		{
+			name:           "with an invalid template",
+			template:       "{{ .Name ",
+			machineContext: machineContext,
+			expectedError:  "failed to parse userData template: template: userData:1: unclosed action",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			userData, err := UserData([]byte(tc.template), tc.machineContext)
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(tc.expectedError))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(string(userData)).To(Equal(tc.expected))
+		})
+	}
+}
@@ -2,6 +2,8 @@
 package annotations
 
 import (
+	"strings"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -10,6 +12,17 @@ const (
 	// from processing it.
 	// TODO: move this annotation to the openshift/api package
 	PausedAnnotation = "cluster.x-k8s.io/paused"
+
+	// NodeHintAnnotationPrefix is the prefix of Node annotations which carry hints, such as desired kernel arguments,
+	// for the operators managing the operating system of the Node. The Machine API does not act on them, but keeps
+	// the hints of the Machine template of a MachineSet in sync on its Machines and their Nodes, removing them
+	// when they are removed from the template.
+	NodeHintAnnotationPrefix = "node-hints.machine.openshift.io/"
+
+	// VSphereOSDiskProvisioningModeAnnotation sets the provisioning mode, Thin, Thick or EagerlyZeroed, of the OS disk
+	// of a vSphere Machine when it is cloned from its template. When set on a MachineSet, the vSphere MachineSet
+	// controller copies it to the Machine template, unless the template sets it.
+	VSphereOSDiskProvisioningModeAnnotation = "machine.openshift.io/vsphere-os-disk-provisioning-mode"
 )
 
 // IsPaused returns true if the Cluster is paused or the object has the `paused` annotation.
@@ -31,3 +44,36 @@ func hasAnnotation(o metav1.Object, annotation string) bool {
 	_, ok := annotations[annotation]
 	return ok
 }
+
+// IsNodeHintAnnotation returns true if the annotation is a Node hint annotation.
+func IsNodeHintAnnotation(key string) bool {
+	return strings.HasPrefix(key, NodeHintAnnotationPrefix)
+}
+
+// SyncNodeHintAnnotations sets the Node hint annotations of desired on current and removes those of current
+// which are not in desired. Other annotations are left untouched. It returns the resulting annotations,
+// which may be current modified in place, and whether they changed.
+func SyncNodeHintAnnotations(current, desired map[string]string) (map[string]string, bool) {
+	changed := false
+	for key := range current {
+		if _, ok := desired[key]; IsNodeHintAnnotation(key) && !ok {
+			delete(current, key)
+			changed = true
+		}
+	}
+
+	for key, value := range desired {
+		if !IsNodeHintAnnotation(key) {
+			continue
+		}
+		if existing, ok := current[key]; ok && existing == value {
+			continue
+		}
+		if current == nil {
+			current = map[string]string{}
+		}
+		current[key] = value
+		changed = true
+	}
+	return current, changed
+}
@@ -0,0 +1,26 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"k8s.io/component-base/featuregate"
+)
+
+// PrintFeatureGates writes the resolved state of every feature gate known to the gate as a JSON object,
+// keyed by gate name with the gate enabled state as value.
+func PrintFeatureGates(w io.Writer, gate featuregate.MutableFeatureGate) error {
+	state := map[string]bool{}
+	for name := range gate.GetAll() {
+		state[string(name)] = gate.Enabled(name)
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal feature gates: %w", err)
+	}
+
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
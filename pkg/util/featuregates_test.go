@@ -0,0 +1,34 @@
+package util
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/component-base/featuregate"
+)
+
+func TestPrintFeatureGates(t *testing.T) {
+	g := NewWithT(t)
+
+	gate := featuregate.NewFeatureGate()
+	g.Expect(gate.Add(map[featuregate.Feature]featuregate.FeatureSpec{
+		"GateA": {Default: false, PreRelease: featuregate.Alpha},
+		"GateB": {Default: false, PreRelease: featuregate.Beta},
+		"GateC": {Default: true, PreRelease: featuregate.GA},
+	})).To(Succeed())
+	g.Expect(gate.SetFromMap(map[string]bool{
+		"GateA": true,
+		"GateC": false,
+	})).To(Succeed())
+
+	var out bytes.Buffer
+	g.Expect(PrintFeatureGates(&out, gate)).To(Succeed())
+	g.Expect(out.String()).To(MatchJSON(`{
+		"AllAlpha": false,
+		"AllBeta": false,
+		"GateA": true,
+		"GateB": false,
+		"GateC": false
+	}`))
+}
@@ -0,0 +1,96 @@
+// Package instancetypes provides the compute capacity and data disk limits of common cloud instance types.
+package instancetypes
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	osconfigv1 "github.com/openshift/api/config/v1"
+)
+
+// catalogFiles holds the instance type catalog of each provider, keyed by instance type name.
+//
+//go:embed catalogs/*.json
+var catalogFiles embed.FS
+
+// catalogFileNames maps the platforms with a catalog to their catalog file.
+var catalogFileNames = map[osconfigv1.PlatformType]string{
+	osconfigv1.AWSPlatformType:   "catalogs/aws.json",
+	osconfigv1.AzurePlatformType: "catalogs/azure.json",
+	osconfigv1.GCPPlatformType:   "catalogs/gcp.json",
+}
+
+// instanceCapacity is the capacity of an instance type in a catalog.
+type instanceCapacity struct {
+	CPU       int64 `json:"cpu"`
+	MemoryMiB int64 `json:"memoryMiB"`
+	GPU       int64 `json:"gpu,omitempty"`
+	// MaxDataDisks is the number of data disks which can be attached to the instance type, zero when unknown.
+	MaxDataDisks int `json:"maxDataDisks,omitempty"`
+}
+
+// loadCatalogs parses the embedded catalogs once. The catalogs are part of the binary, so a malformed
+// catalog is a programming error.
+var loadCatalogs = sync.OnceValue(func() map[osconfigv1.PlatformType]map[string]instanceCapacity {
+	catalogs := make(map[osconfigv1.PlatformType]map[string]instanceCapacity, len(catalogFileNames))
+	for platform, fileName := range catalogFileNames {
+		catalog, err := readCatalog(fileName)
+		if err != nil {
+			panic(err)
+		}
+		catalogs[platform] = catalog
+	}
+	return catalogs
+})
+
+func readCatalog(fileName string) (map[string]instanceCapacity, error) {
+	data, err := catalogFiles.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read instance type catalog %s: %w", fileName, err)
+	}
+
+	catalog := map[string]instanceCapacity{}
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("failed to parse instance type catalog %s: %w", fileName, err)
+	}
+	return catalog, nil
+}
+
+// Platforms returns the platforms which have an instance type catalog.
+func Platforms() []osconfigv1.PlatformType {
+	return []osconfigv1.PlatformType{osconfigv1.AWSPlatformType, osconfigv1.AzurePlatformType, osconfigv1.GCPPlatformType}
+}
+
+// Capacity returns the number of CPUs, the memory in MiB and the number of GPUs of an instance type of the platform.
+// ok is false when the platform has no catalog or the instance type is not in it, in which case callers should
+// leave the capacity to be provided by other means.
+func Capacity(platform osconfigv1.PlatformType, instanceType string) (cpu, memMiB, gpu int64, ok bool) {
+	capacity, ok := loadCatalogs()[platform][instanceType]
+	if !ok {
+		return 0, 0, 0, false
+	}
+	return capacity.CPU, capacity.MemoryMiB, capacity.GPU, true
+}
+
+// MaxDataDisks returns the number of data disks which can be attached to an instance type of the platform.
+// Instance types are matched case-insensitively, as cloud providers do. ok is false when the platform has no
+// catalog, or the instance type or its data disk limit is not in it.
+func MaxDataDisks(platform osconfigv1.PlatformType, instanceType string) (maxDataDisks int, ok bool) {
+	catalog := loadCatalogs()[platform]
+	capacity, ok := catalog[instanceType]
+	if !ok {
+		for name, c := range catalog {
+			if strings.EqualFold(name, instanceType) {
+				capacity, ok = c, true
+				break
+			}
+		}
+	}
+	if !ok || capacity.MaxDataDisks == 0 {
+		return 0, false
+	}
+	return capacity.MaxDataDisks, true
+}
@@ -0,0 +1,172 @@
+package instancetypes
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	osconfigv1 "github.com/openshift/api/config/v1"
+)
+
+func TestCatalogs(t *testing.T) {
+	for platform, fileName := range catalogFileNames {
+		t.Run(string(platform), func(t *testing.T) {
+			g := NewWithT(t)
+
+			catalog, err := readCatalog(fileName)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(catalog).ToNot(BeEmpty())
+
+			for instanceType, capacity := range catalog {
+				g.Expect(capacity.CPU).To(BeNumerically(">", 0), "instance type %s has no cpu", instanceType)
+				g.Expect(capacity.MemoryMiB).To(BeNumerically(">", 0), "instance type %s has no memory", instanceType)
+			}
+		})
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	testCases := []struct {
+		name           string
+		platform       osconfigv1.PlatformType
+		instanceType   string
+		expectedCPU    int64
+		expectedMemMiB int64
+		expectedGPU    int64
+		expectedOk     bool
+	}{
+		{
+			name:           "with a known AWS instance type",
+			platform:       osconfigv1.AWSPlatformType,
+			instanceType:   "m5.xlarge",
+			expectedCPU:    4,
+			expectedMemMiB: 16384,
+			expectedOk:     true,
+		},
+		{
+			name:           "with a known AWS GPU instance type",
+			platform:       osconfigv1.AWSPlatformType,
+			instanceType:   "g4dn.xlarge",
+			expectedCPU:    4,
+			expectedMemMiB: 16384,
+			expectedGPU:    1,
+			expectedOk:     true,
+		},
+		{
+			name:           "with a known Azure instance type",
+			platform:       osconfigv1.AzurePlatformType,
+			instanceType:   "Standard_D4s_v3",
+			expectedCPU:    4,
+			expectedMemMiB: 16384,
+			expectedOk:     true,
+		},
+		{
+			name:           "with a known Azure GPU instance type",
+			platform:       osconfigv1.AzurePlatformType,
+			instanceType:   "Standard_NC6s_v3",
+			expectedCPU:    6,
+			expectedMemMiB: 114688,
+			expectedGPU:    1,
+			expectedOk:     true,
+		},
+		{
+			name:           "with a known GCP instance type",
+			platform:       osconfigv1.GCPPlatformType,
+			instanceType:   "n1-standard-4",
+			expectedCPU:    4,
+			expectedMemMiB: 15360,
+			expectedOk:     true,
+		},
+		{
+			name:           "with a known GCP GPU instance type",
+			platform:       osconfigv1.GCPPlatformType,
+			instanceType:   "a2-highgpu-1g",
+			expectedCPU:    12,
+			expectedMemMiB: 87040,
+			expectedGPU:    1,
+			expectedOk:     true,
+		},
+		{
+			name:         "with an unknown instance type",
+			platform:     osconfigv1.AWSPlatformType,
+			instanceType: "m5.unknown",
+			expectedOk:   false,
+		},
+		{
+			name:         "with an instance type of another platform",
+			platform:     osconfigv1.AWSPlatformType,
+			instanceType: "n1-standard-4",
+			expectedOk:   false,
+		},
+		{
+			name:         "with a platform without catalog",
+			platform:     osconfigv1.VSpherePlatformType,
+			instanceType: "m5.xlarge",
+			expectedOk:   false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			cpu, memMiB, gpu, ok := Capacity(tc.platform, tc.instanceType)
+			g.Expect(ok).To(Equal(tc.expectedOk))
+			g.Expect(cpu).To(Equal(tc.expectedCPU))
+			g.Expect(memMiB).To(Equal(tc.expectedMemMiB))
+			g.Expect(gpu).To(Equal(tc.expectedGPU))
+		})
+	}
+}
+
+func TestMaxDataDisks(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		platform             osconfigv1.PlatformType
+		instanceType         string
+		expectedMaxDataDisks int
+		expectedOk           bool
+	}{
+		{
+			name:                 "with a known Azure instance type",
+			platform:             osconfigv1.AzurePlatformType,
+			instanceType:         "Standard_D4s_v3",
+			expectedMaxDataDisks: 8,
+			expectedOk:           true,
+		},
+		{
+			name:                 "with a known Azure instance type in another case",
+			platform:             osconfigv1.AzurePlatformType,
+			instanceType:         "standard_d16s_v5",
+			expectedMaxDataDisks: 32,
+			expectedOk:           true,
+		},
+		{
+			name:         "with an unknown Azure instance type",
+			platform:     osconfigv1.AzurePlatformType,
+			instanceType: "Standard_Unknown",
+			expectedOk:   false,
+		},
+		{
+			name:         "with an instance type without a data disk limit",
+			platform:     osconfigv1.AWSPlatformType,
+			instanceType: "m5.xlarge",
+			expectedOk:   false,
+		},
+		{
+			name:         "with a platform without catalog",
+			platform:     osconfigv1.VSpherePlatformType,
+			instanceType: "Standard_D4s_v3",
+			expectedOk:   false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			maxDataDisks, ok := MaxDataDisks(tc.platform, tc.instanceType)
+			g.Expect(ok).To(Equal(tc.expectedOk))
+			g.Expect(maxDataDisks).To(Equal(tc.expectedMaxDataDisks))
+		})
+	}
+}
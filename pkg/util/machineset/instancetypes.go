@@ -0,0 +1,98 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/openshift/machine-api-operator/pkg/util/instancetypes"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// AutoscalerMinSizeAnnotation is the annotation used by the cluster autoscaler to record
+// the minimum size of the node group backed by a MachineSet.
+const AutoscalerMinSizeAnnotation = "machine.openshift.io/cluster-api-autoscaler-node-group-min-size"
+
+// InstanceCapacity describes the compute resources offered by a cloud instance type.
+type InstanceCapacity struct {
+	CPU      int64
+	MemoryMb int64
+	GPU      int64
+}
+
+// GetInstanceCapacity returns the capacity of a known instance type. Instance type names do not overlap
+// between providers, so the catalogs of all the platforms are searched.
+func GetInstanceCapacity(instanceType string) (InstanceCapacity, bool) {
+	for _, platform := range instancetypes.Platforms() {
+		if cpu, memMiB, gpu, ok := instancetypes.Capacity(platform, instanceType); ok {
+			return InstanceCapacity{CPU: cpu, MemoryMb: memMiB, GPU: gpu}, true
+		}
+	}
+	return InstanceCapacity{}, false
+}
+
+// instanceTypeFields holds the providerSpec fields naming the instance type on each provider.
+type instanceTypeFields struct {
+	// InstanceType is used by AWS.
+	InstanceType string `json:"instanceType,omitempty"`
+	// VMSize is used by Azure.
+	VMSize string `json:"vmSize,omitempty"`
+	// MachineType is used by GCP.
+	MachineType string `json:"machineType,omitempty"`
+}
+
+// InstanceTypeFromProviderSpec returns the instance type referenced by the providerSpec,
+// or an empty string if the providerSpec does not reference one.
+func InstanceTypeFromProviderSpec(providerSpec *runtime.RawExtension) (string, error) {
+	if providerSpec == nil || providerSpec.Raw == nil {
+		return "", nil
+	}
+
+	fields := &instanceTypeFields{}
+	if err := json.Unmarshal(providerSpec.Raw, fields); err != nil {
+		return "", fmt.Errorf("failed to unmarshal providerSpec: %w", err)
+	}
+
+	switch {
+	case fields.InstanceType != "":
+		return fields.InstanceType, nil
+	case fields.VMSize != "":
+		return fields.VMSize, nil
+	default:
+		return fields.MachineType, nil
+	}
+}
+
+// CanScaleFromZero returns true when a MachineSet may end up with zero replicas,
+// either because it is currently scaled to zero or because the autoscaler may scale it down to zero.
+func CanScaleFromZero(replicas *int32, annotations map[string]string) bool {
+	if replicas == nil || *replicas == 0 {
+		return true
+	}
+
+	return annotations[AutoscalerMinSizeAnnotation] == "0"
+}
+
+// SetScaleFromZeroAnnotations sets the cpu, memory and gpu scale from zero annotations
+// from the given capacity. Annotations which are already present are left untouched so that
+// user provided values are respected. It returns true if any annotation was added.
+func SetScaleFromZeroAnnotations(annotations map[string]string, capacity InstanceCapacity) (map[string]string, bool) {
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	changed := false
+	for key, value := range map[string]int64{
+		CpuKeyDeprecated:      capacity.CPU,
+		MemoryKeyDeprecated:   capacity.MemoryMb,
+		GpuCountKeyDeprecated: capacity.GPU,
+	} {
+		if _, ok := annotations[key]; ok {
+			continue
+		}
+		annotations[key] = strconv.FormatInt(value, 10)
+		changed = true
+	}
+
+	return annotations, changed
+}
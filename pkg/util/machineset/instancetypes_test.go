@@ -0,0 +1,167 @@
+package util
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+)
+
+func TestInstanceTypeFromProviderSpec(t *testing.T) {
+	tests := []struct {
+		name                 string
+		providerSpec         *runtime.RawExtension
+		expectedInstanceType string
+		expectErr            bool
+	}{
+		{
+			name:                 "with a nil providerSpec",
+			providerSpec:         nil,
+			expectedInstanceType: "",
+		},
+		{
+			name:                 "with an AWS instanceType",
+			providerSpec:         &runtime.RawExtension{Raw: []byte(`{"instanceType":"m5.large"}`)},
+			expectedInstanceType: "m5.large",
+		},
+		{
+			name:                 "with an Azure vmSize",
+			providerSpec:         &runtime.RawExtension{Raw: []byte(`{"vmSize":"Standard_D4s_v3"}`)},
+			expectedInstanceType: "Standard_D4s_v3",
+		},
+		{
+			name:                 "with a GCP machineType",
+			providerSpec:         &runtime.RawExtension{Raw: []byte(`{"machineType":"n1-standard-4"}`)},
+			expectedInstanceType: "n1-standard-4",
+		},
+		{
+			name:                 "without an instance type",
+			providerSpec:         &runtime.RawExtension{Raw: []byte(`{"numCPUs":4}`)},
+			expectedInstanceType: "",
+		},
+		{
+			name:         "with an invalid providerSpec",
+			providerSpec: &runtime.RawExtension{Raw: []byte(`{`)},
+			expectErr:    true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			instanceType, err := InstanceTypeFromProviderSpec(tc.providerSpec)
+			if tc.expectErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(instanceType).To(Equal(tc.expectedInstanceType))
+		})
+	}
+}
+
+func TestCanScaleFromZero(t *testing.T) {
+	tests := []struct {
+		name        string
+		replicas    *int32
+		annotations map[string]string
+		expected    bool
+	}{
+		{
+			name:     "with nil replicas",
+			expected: true,
+		},
+		{
+			name:     "with zero replicas",
+			replicas: ptr.To[int32](0),
+			expected: true,
+		},
+		{
+			name:     "with replicas and no autoscaler",
+			replicas: ptr.To[int32](3),
+			expected: false,
+		},
+		{
+			name:        "with replicas and an autoscaler minimum of zero",
+			replicas:    ptr.To[int32](3),
+			annotations: map[string]string{AutoscalerMinSizeAnnotation: "0"},
+			expected:    true,
+		},
+		{
+			name:        "with replicas and an autoscaler minimum of one",
+			replicas:    ptr.To[int32](3),
+			annotations: map[string]string{AutoscalerMinSizeAnnotation: "1"},
+			expected:    false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(CanScaleFromZero(tc.replicas, tc.annotations)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestSetScaleFromZeroAnnotations(t *testing.T) {
+	tests := []struct {
+		name                string
+		capacity            InstanceCapacity
+		suppliedAnnotations map[string]string
+		expectedAnnotations map[string]string
+		expectedChanged     bool
+	}{
+		{
+			name:                "with nil annotations",
+			capacity:            InstanceCapacity{CPU: 4, MemoryMb: 16384, GPU: 1},
+			suppliedAnnotations: nil,
+			expectedAnnotations: map[string]string{
+				CpuKeyDeprecated:      "4",
+				MemoryKeyDeprecated:   "16384",
+				GpuCountKeyDeprecated: "1",
+			},
+			expectedChanged: true,
+		},
+		{
+			name:     "with user provided annotations",
+			capacity: InstanceCapacity{CPU: 4, MemoryMb: 16384},
+			suppliedAnnotations: map[string]string{
+				CpuKeyDeprecated: "2",
+			},
+			expectedAnnotations: map[string]string{
+				CpuKeyDeprecated:      "2",
+				MemoryKeyDeprecated:   "16384",
+				GpuCountKeyDeprecated: "0",
+			},
+			expectedChanged: true,
+		},
+		{
+			name:     "with all annotations already set",
+			capacity: InstanceCapacity{CPU: 4, MemoryMb: 16384},
+			suppliedAnnotations: map[string]string{
+				CpuKeyDeprecated:      "2",
+				MemoryKeyDeprecated:   "1024",
+				GpuCountKeyDeprecated: "0",
+			},
+			expectedAnnotations: map[string]string{
+				CpuKeyDeprecated:      "2",
+				MemoryKeyDeprecated:   "1024",
+				GpuCountKeyDeprecated: "0",
+			},
+			expectedChanged: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			annotations, changed := SetScaleFromZeroAnnotations(tc.suppliedAnnotations, tc.capacity)
+			g.Expect(changed).To(Equal(tc.expectedChanged))
+			g.Expect(annotations).To(Equal(tc.expectedAnnotations))
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package util
+
+import (
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+)
+
+// WatchNamespaces returns the cache configuration of the namespaces of a comma separated list, to be used
+// as the cache.Options DefaultNamespaces. It returns nil for an empty list, so that all namespaces are watched.
+func WatchNamespaces(namespaces string) map[string]cache.Config {
+	var config map[string]cache.Config
+	for _, namespace := range strings.Split(namespaces, ",") {
+		namespace = strings.TrimSpace(namespace)
+		if namespace == "" {
+			continue
+		}
+		if config == nil {
+			config = map[string]cache.Config{}
+		}
+		config[namespace] = cache.Config{}
+	}
+	return config
+}
@@ -0,0 +1,44 @@
+package util
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+)
+
+func TestWatchNamespaces(t *testing.T) {
+	testCases := []struct {
+		name       string
+		namespaces string
+		expected   map[string]cache.Config
+	}{
+		{
+			name:       "with a single namespace",
+			namespaces: "openshift-machine-api",
+			expected: map[string]cache.Config{
+				"openshift-machine-api": {},
+			},
+		},
+		{
+			name:       "with multiple namespaces",
+			namespaces: "openshift-machine-api, other-machine-api,",
+			expected: map[string]cache.Config{
+				"openshift-machine-api": {},
+				"other-machine-api":     {},
+			},
+		},
+		{
+			name:       "with no namespace",
+			namespaces: "",
+			expected:   nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(WatchNamespaces(tc.namespaces)).To(Equal(tc.expected))
+		})
+	}
+}
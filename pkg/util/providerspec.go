@@ -0,0 +1,115 @@
+package util
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	kjson "sigs.k8s.io/json"
+	"sigs.k8s.io/yaml"
+)
+
+// ErrProviderSpecNotSet is returned when decoding a providerSpec which has no value.
+var ErrProviderSpecNotSet = errors.New("providerSpec value is not set")
+
+// maxInflatedProviderSpecSize bounds the size of a gzipped providerSpec once inflated.
+const maxInflatedProviderSpecSize = 1 << 20
+
+// gzipMagic is the header of gzip data.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// DecodeProviderSpec decodes the raw providerSpec of a Machine into the provider config type T.
+// In strict mode, the paths of the fields of the providerSpec which are not part of T are returned,
+// these fields are ignored when decoding and are not an error.
+// Base64 encoded providerSpecs, optionally gzipped, are decoded, see InflateProviderSpec.
+func DecodeProviderSpec[T any](providerSpec *runtime.RawExtension, strict bool) (*T, []string, error) {
+	if providerSpec == nil || providerSpec.Raw == nil {
+		return nil, nil, ErrProviderSpecNotSet
+	}
+
+	raw, err := InflateProviderSpec(providerSpec.Raw)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	config := new(T)
+	if err := yaml.Unmarshal(raw, config); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode providerSpec: %w", err)
+	}
+
+	if !strict {
+		return config, nil, nil
+	}
+
+	unknownFields, err := unknownProviderSpecFields[T](raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	return config, unknownFields, nil
+}
+
+// unknownProviderSpecFields returns the paths of the fields of the raw providerSpec which are not part of T.
+func unknownProviderSpecFields[T any](raw []byte) ([]string, error) {
+	data, err := yaml.YAMLToJSON(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode providerSpec: %w", err)
+	}
+
+	strictErrs, err := kjson.UnmarshalStrict(data, new(T), kjson.DisallowUnknownFields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode providerSpec: %w", err)
+	}
+
+	var unknownFields []string
+	for _, strictErr := range strictErrs {
+		var fieldErr kjson.FieldError
+		if errors.As(strictErr, &fieldErr) {
+			unknownFields = append(unknownFields, fieldErr.FieldPath())
+		}
+	}
+	return unknownFields, nil
+}
+
+// InflateProviderSpec returns the providerSpec encoded in the raw providerSpec when it is a string holding
+// the base64 encoding of the providerSpec, gzipped or not. Tooling stores large providerSpecs this way to stay
+// under size limits. Any other raw providerSpec, such as plain JSON, is returned untouched.
+func InflateProviderSpec(raw []byte) ([]byte, error) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 || trimmed[0] != '"' {
+		return raw, nil
+	}
+
+	var encoded string
+	if err := json.Unmarshal(trimmed, &encoded); err != nil {
+		return nil, fmt.Errorf("failed to decode providerSpec: %w", err)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode base64 providerSpec: %w", err)
+	}
+
+	if !bytes.HasPrefix(decoded, gzipMagic) {
+		return decoded, nil
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(decoded))
+	if err != nil {
+		return nil, fmt.Errorf("failed to inflate gzipped providerSpec: %w", err)
+	}
+	defer reader.Close()
+
+	inflated, err := io.ReadAll(io.LimitReader(reader, maxInflatedProviderSpecSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to inflate gzipped providerSpec: %w", err)
+	}
+	if len(inflated) > maxInflatedProviderSpecSize {
+		return nil, fmt.Errorf("failed to inflate gzipped providerSpec: larger than %d bytes", maxInflatedProviderSpecSize)
+	}
+	return inflated, nil
+}
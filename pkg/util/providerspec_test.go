@@ -0,0 +1,180 @@
+package util
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"strconv"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestDecodeProviderSpec(t *testing.T) {
+	t.Run("with an AWS providerSpec", func(t *testing.T) {
+		g := NewWithT(t)
+
+		config, unknownFields, err := DecodeProviderSpec[machinev1beta1.AWSMachineProviderConfig](&runtime.RawExtension{
+			Raw: []byte(`{"instanceType":"m5.large","placement":{"region":"us-east-1","availabilityZone":"us-east-1a"}}`),
+		}, true)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(unknownFields).To(BeEmpty())
+		g.Expect(config.InstanceType).To(Equal("m5.large"))
+		g.Expect(config.Placement.Region).To(Equal("us-east-1"))
+		g.Expect(config.Placement.AvailabilityZone).To(Equal("us-east-1a"))
+	})
+
+	t.Run("with an Azure providerSpec", func(t *testing.T) {
+		g := NewWithT(t)
+
+		config, unknownFields, err := DecodeProviderSpec[machinev1beta1.AzureMachineProviderSpec](&runtime.RawExtension{
+			Raw: []byte(`{"vmSize":"Standard_D4s_v3","location":"centralus","zone":"1"}`),
+		}, true)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(unknownFields).To(BeEmpty())
+		g.Expect(config.VMSize).To(Equal("Standard_D4s_v3"))
+		g.Expect(config.Location).To(Equal("centralus"))
+		g.Expect(config.Zone).To(Equal("1"))
+	})
+
+	t.Run("with a GCP providerSpec in YAML", func(t *testing.T) {
+		g := NewWithT(t)
+
+		config, unknownFields, err := DecodeProviderSpec[machinev1beta1.GCPMachineProviderSpec](&runtime.RawExtension{
+			Raw: []byte("machineType: n2-standard-4\nregion: us-central1\nzone: us-central1-a\n"),
+		}, true)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(unknownFields).To(BeEmpty())
+		g.Expect(config.MachineType).To(Equal("n2-standard-4"))
+		g.Expect(config.Region).To(Equal("us-central1"))
+		g.Expect(config.Zone).To(Equal("us-central1-a"))
+	})
+
+	t.Run("with unknown fields in strict mode", func(t *testing.T) {
+		g := NewWithT(t)
+
+		config, unknownFields, err := DecodeProviderSpec[machinev1beta1.AWSMachineProviderConfig](&runtime.RawExtension{
+			Raw: []byte(`{"instanceType":"m5.large","randomField":"foo","placement":{"region":"us-east-1","randomNestedField":"bar"}}`),
+		}, true)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(unknownFields).To(ConsistOf("randomField", "placement.randomNestedField"))
+		g.Expect(config.InstanceType).To(Equal("m5.large"))
+		g.Expect(config.Placement.Region).To(Equal("us-east-1"))
+	})
+
+	t.Run("with unknown fields outside of strict mode", func(t *testing.T) {
+		g := NewWithT(t)
+
+		config, unknownFields, err := DecodeProviderSpec[machinev1beta1.AWSMachineProviderConfig](&runtime.RawExtension{
+			Raw: []byte(`{"instanceType":"m5.large","randomField":"foo"}`),
+		}, false)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(unknownFields).To(BeNil())
+		g.Expect(config.InstanceType).To(Equal("m5.large"))
+	})
+
+	t.Run("with an invalid providerSpec", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, _, err := DecodeProviderSpec[machinev1beta1.AWSMachineProviderConfig](&runtime.RawExtension{
+			Raw: []byte(`{"instanceType":1}`),
+		}, true)
+		g.Expect(err).To(MatchError(ContainSubstring("failed to decode providerSpec")))
+	})
+
+	t.Run("with a gzipped base64 providerSpec", func(t *testing.T) {
+		g := NewWithT(t)
+
+		config, unknownFields, err := DecodeProviderSpec[machinev1beta1.AWSMachineProviderConfig](&runtime.RawExtension{
+			Raw: gzippedBase64ProviderSpec(t, `{"instanceType":"m5.large","randomField":"foo"}`),
+		}, true)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(unknownFields).To(ConsistOf("randomField"))
+		g.Expect(config.InstanceType).To(Equal("m5.large"))
+	})
+
+	t.Run("with a nil providerSpec", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, _, err := DecodeProviderSpec[machinev1beta1.AWSMachineProviderConfig](nil, true)
+		g.Expect(err).To(MatchError(ErrProviderSpecNotSet))
+	})
+
+	t.Run("with an empty providerSpec", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, _, err := DecodeProviderSpec[machinev1beta1.AWSMachineProviderConfig](&runtime.RawExtension{}, false)
+		g.Expect(err).To(MatchError(ErrProviderSpecNotSet))
+	})
+}
+
+func TestInflateProviderSpec(t *testing.T) {
+	const providerSpec = `{"instanceType":"m5.large"}`
+
+	testCases := []struct {
+		name          string
+		raw           []byte
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "with a plain JSON providerSpec",
+			raw:      []byte(providerSpec),
+			expected: providerSpec,
+		},
+		{
+			name:     "with a YAML providerSpec",
+			raw:      []byte("instanceType: m5.large\n"),
+			expected: "instanceType: m5.large\n",
+		},
+		{
+			name:     "with a gzipped base64 providerSpec",
+			raw:      gzippedBase64ProviderSpec(t, providerSpec),
+			expected: providerSpec,
+		},
+		{
+			name:     "with a base64 providerSpec",
+			raw:      []byte(strconv.Quote(base64.StdEncoding.EncodeToString([]byte(providerSpec)))),
+			expected: providerSpec,
+		},
+		{
+			name:          "with a corrupt gzipped base64 providerSpec",
+			raw:           []byte(strconv.Quote(base64.StdEncoding.EncodeToString([]byte{0x1f, 0x8b, 0x08, 0x00, 0x01, 0x02}))),
+			expectedError: "failed to inflate gzipped providerSpec: unexpected EOF",
+		},
+		{
+			name:          "with a string which is not base64",
+			raw:           []byte(`"not base64!"`),
+			expectedError: "failed to decode base64 providerSpec: illegal base64 data at input byte 3",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			raw, err := InflateProviderSpec(tc.raw)
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(tc.expectedError))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(string(raw)).To(Equal(tc.expected))
+		})
+	}
+}
+
+// gzippedBase64ProviderSpec returns the providerSpec gzipped and base64 encoded, as a JSON string.
+func gzippedBase64ProviderSpec(t *testing.T, providerSpec string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(providerSpec)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return []byte(strconv.Quote(base64.StdEncoding.EncodeToString(buf.Bytes())))
+}
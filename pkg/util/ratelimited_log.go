@@ -0,0 +1,148 @@
+package util
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// RateLimitedLogger logs identical messages of the same kind for the same object at most once per interval.
+// Messages logged within the interval of the last identical one are suppressed and counted, the count is
+// reported with the next logged message. Messages which differ, e.g. because the error has changed, are
+// rate limited separately. It is meant for errors which repeat on every reconcile of a misconfigured object.
+// A nil RateLimitedLogger logs every message.
+type RateLimitedLogger struct {
+	interval time.Duration
+
+	lock    sync.Mutex
+	entries map[rateLimitedLogKey]*rateLimitedLogEntry
+
+	// nowFunc is used to mock time in testing. It should be nil in production.
+	nowFunc func() time.Time
+	// errorDepth and warningDepth are used to capture the log output in testing. They should be nil in production.
+	errorDepth   func(depth int, args ...interface{})
+	warningDepth func(depth int, args ...interface{})
+}
+
+type rateLimitedLogKey struct {
+	object string
+	kind   string
+	// message is a hash of the formatted message, so that only identical messages are suppressed
+	message uint64
+}
+
+type rateLimitedLogEntry struct {
+	lastLogged time.Time
+	suppressed int
+}
+
+// NewRateLimitedLogger returns a RateLimitedLogger logging identical messages of the same kind for the same
+// object at most once per interval. Zero disables the rate limiting.
+func NewRateLimitedLogger(interval time.Duration) *RateLimitedLogger {
+	return &RateLimitedLogger{interval: interval}
+}
+
+// Errorf logs the message as an error unless an identical message of the same kind was logged for the
+// object within the interval.
+func (l *RateLimitedLogger) Errorf(object, kind, format string, args ...interface{}) {
+	msg, ok := l.message(object, kind, format, args...)
+	switch {
+	case !ok:
+	case l == nil || l.errorDepth == nil:
+		klog.ErrorDepth(1, msg)
+	default:
+		l.errorDepth(1, msg)
+	}
+}
+
+// Warningf logs the message as a warning unless an identical message of the same kind was logged for the
+// object within the interval.
+func (l *RateLimitedLogger) Warningf(object, kind, format string, args ...interface{}) {
+	msg, ok := l.message(object, kind, format, args...)
+	switch {
+	case !ok:
+	case l == nil || l.warningDepth == nil:
+		klog.WarningDepth(1, msg)
+	default:
+		l.warningDepth(1, msg)
+	}
+}
+
+// Forget drops the state kept for the object, so that its next message is logged.
+// It is meant to be called once the object is deleted.
+func (l *RateLimitedLogger) Forget(object string) {
+	if l == nil {
+		return
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for key := range l.entries {
+		if key.object == object {
+			delete(l.entries, key)
+		}
+	}
+}
+
+// message returns the message to log and true when it is not suppressed.
+func (l *RateLimitedLogger) message(object, kind, format string, args ...interface{}) (string, bool) {
+	msg := fmt.Sprintf(format, args...)
+	if l == nil || l.interval <= 0 {
+		return msg, true
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.now()
+	key := rateLimitedLogKey{object: object, kind: kind, message: hashMessage(msg)}
+	entry, ok := l.entries[key]
+	if !ok {
+		if l.entries == nil {
+			l.entries = map[rateLimitedLogKey]*rateLimitedLogEntry{}
+		}
+		l.pruneExpired(object, kind, now)
+		l.entries[key] = &rateLimitedLogEntry{lastLogged: now}
+		return msg, true
+	}
+
+	if now.Sub(entry.lastLogged) < l.interval {
+		entry.suppressed++
+		return "", false
+	}
+
+	if entry.suppressed > 0 {
+		msg = fmt.Sprintf("%s (%d identical messages suppressed since %s)", msg, entry.suppressed, entry.lastLogged.Format(time.RFC3339))
+	}
+	entry.lastLogged = now
+	entry.suppressed = 0
+	return msg, true
+}
+
+// pruneExpired drops the entries of the object and kind whose interval has elapsed without suppressing
+// any message, so that errors which keep changing don't grow the entries until the object is forgotten.
+func (l *RateLimitedLogger) pruneExpired(object, kind string, now time.Time) {
+	for key, entry := range l.entries {
+		if key.object == object && key.kind == kind && entry.suppressed == 0 && now.Sub(entry.lastLogged) >= l.interval {
+			delete(l.entries, key)
+		}
+	}
+}
+
+// hashMessage returns the hash of the message used in the keys of the entries.
+func hashMessage(msg string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(msg))
+	return h.Sum64()
+}
+
+func (l *RateLimitedLogger) now() time.Time {
+	if l.nowFunc != nil {
+		return l.nowFunc()
+	}
+	return time.Now()
+}
@@ -0,0 +1,119 @@
+package util
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+func newTestRateLimitedLogger(interval time.Duration, now *time.Time) (*RateLimitedLogger, *[]string) {
+	logged := []string{}
+	l := NewRateLimitedLogger(interval)
+	l.nowFunc = func() time.Time { return *now }
+	l.errorDepth = func(_ int, args ...interface{}) {
+		logged = append(logged, "E "+fmt.Sprint(args...))
+	}
+	l.warningDepth = func(_ int, args ...interface{}) {
+		logged = append(logged, "W "+fmt.Sprint(args...))
+	}
+	return l, &logged
+}
+
+func TestRateLimitedLogger(t *testing.T) {
+	g := NewWithT(t)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	l, logged := newTestRateLimitedLogger(5*time.Minute, &now)
+
+	for i := 0; i < 10; i++ {
+		l.Errorf("test/machine", "create", "failed to create machine: %v", "invalid zone")
+		now = now.Add(10 * time.Second)
+	}
+	g.Expect(*logged).To(Equal([]string{
+		"E failed to create machine: invalid zone",
+	}))
+
+	// Other kinds and other objects are rate limited separately
+	l.Warningf("test/machine", "exists", "failed to check if machine exists: %v", "timeout")
+	l.Errorf("test/other", "create", "failed to create machine: %v", "invalid zone")
+	g.Expect(*logged).To(Equal([]string{
+		"E failed to create machine: invalid zone",
+		"W failed to check if machine exists: timeout",
+		"E failed to create machine: invalid zone",
+	}))
+
+	// Once the interval has elapsed the message is logged with the number of suppressed messages
+	now = start.Add(5 * time.Minute)
+	l.Errorf("test/machine", "create", "failed to create machine: %v", "invalid zone")
+	g.Expect(*logged).To(HaveLen(4))
+	g.Expect((*logged)[3]).To(Equal("E failed to create machine: invalid zone (9 identical messages suppressed since 2024-01-01T00:00:00Z)"))
+
+	// The suppressed count is reset once reported
+	now = now.Add(5 * time.Minute)
+	l.Errorf("test/machine", "create", "failed to create machine: %v", "invalid zone")
+	g.Expect(*logged).To(HaveLen(5))
+	g.Expect((*logged)[4]).To(Equal("E failed to create machine: invalid zone"))
+
+	// Forgotten objects are logged again straight away
+	l.Forget("test/machine")
+	l.Errorf("test/machine", "create", "failed to create machine: %v", "invalid zone")
+	l.Warningf("test/machine", "exists", "failed to check if machine exists: %v", "timeout")
+	g.Expect(*logged).To(HaveLen(7))
+	g.Expect((*logged)[5:]).To(Equal([]string{
+		"E failed to create machine: invalid zone",
+		"W failed to check if machine exists: timeout",
+	}))
+}
+
+func TestRateLimitedLoggerDifferentMessages(t *testing.T) {
+	g := NewWithT(t)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	l, logged := newTestRateLimitedLogger(5*time.Minute, &now)
+
+	// A different error for the same object and kind is not suppressed
+	l.Errorf("test/machine", "create", "failed to create machine: %v", "invalid zone")
+	l.Errorf("test/machine", "create", "failed to create machine: %v", "quota exceeded")
+	l.Errorf("test/machine", "create", "failed to create machine: %v", "invalid zone")
+	l.Errorf("test/machine", "create", "failed to create machine: %v", "quota exceeded")
+	g.Expect(*logged).To(Equal([]string{
+		"E failed to create machine: invalid zone",
+		"E failed to create machine: quota exceeded",
+	}))
+
+	// Each message reports its own suppressed count
+	now = start.Add(5 * time.Minute)
+	l.Errorf("test/machine", "create", "failed to create machine: %v", "quota exceeded")
+	g.Expect(*logged).To(HaveLen(3))
+	g.Expect((*logged)[2]).To(Equal("E failed to create machine: quota exceeded (1 identical messages suppressed since 2024-01-01T00:00:00Z)"))
+
+	// Expired entries without suppressed messages are dropped when another message is logged
+	now = start.Add(15 * time.Minute)
+	l.Errorf("test/machine", "create", "failed to create machine: %v", "instance limit reached")
+	g.Expect(l.entries).To(HaveLen(2))
+}
+
+func TestRateLimitedLoggerWithoutInterval(t *testing.T) {
+	g := NewWithT(t)
+
+	now := time.Now()
+	l, logged := newTestRateLimitedLogger(0, &now)
+
+	for i := 0; i < 3; i++ {
+		l.Errorf("test/machine", "create", "failed to create machine")
+	}
+	g.Expect(*logged).To(HaveLen(3))
+}
+
+func TestNilRateLimitedLogger(t *testing.T) {
+	var l *RateLimitedLogger
+
+	// A nil logger logs every message and keeps no state
+	l.Errorf("test/machine", "create", "failed to create machine")
+	l.Warningf("test/machine", "create", "failed to create machine")
+	l.Forget("test/machine")
+}
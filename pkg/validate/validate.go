@@ -0,0 +1,169 @@
+// Package validate implements the validate subcommand of the machine-api-operator, which lints Machine
+// and MachineSet manifests with the checks of the validating webhooks before they are applied.
+package validate
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	osconfigv1 "github.com/openshift/api/config/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/component-base/featuregate"
+	"sigs.k8s.io/yaml"
+
+	"github.com/openshift/machine-api-operator/pkg/webhooks"
+)
+
+// Exit codes of the validate subcommand.
+const (
+	// ExitValid is returned when every manifest is valid, warnings may have been printed.
+	ExitValid = 0
+	// ExitInvalid is returned when at least one manifest is rejected by the validation.
+	ExitInvalid = 1
+	// ExitUsageError is returned when the input or the fixtures can't be read.
+	ExitUsageError = 2
+)
+
+// Options are the options of the validate subcommand.
+type Options struct {
+	// Filename is the file holding the manifests to validate, "-" reads them from stdin.
+	Filename string
+	// InfrastructureFilename is the file holding the cluster Infrastructure the manifests are validated for.
+	InfrastructureFilename string
+	// DNSFilename is the file holding the cluster DNS. The cluster is considered connected when it is empty.
+	DNSFilename string
+	// FeatureGate is the feature gate the manifests are validated with.
+	FeatureGate featuregate.MutableFeatureGate
+}
+
+// Run validates the Machines and MachineSets of the input, prints the warnings and errors of every manifest
+// to stdout and returns the exit code of the subcommand. Usage errors are printed to stderr.
+func Run(opts Options, stdin io.Reader, stdout, stderr io.Writer) int {
+	infra := &osconfigv1.Infrastructure{}
+	if err := readFile(opts.InfrastructureFilename, infra); err != nil {
+		fmt.Fprintf(stderr, "error reading infrastructure: %v\n", err)
+		return ExitUsageError
+	}
+	if infra.Status.PlatformStatus == nil {
+		fmt.Fprintf(stderr, "infrastructure %s has no status.platformStatus\n", opts.InfrastructureFilename)
+		return ExitUsageError
+	}
+
+	var dns *osconfigv1.DNS
+	if opts.DNSFilename != "" {
+		dns = &osconfigv1.DNS{}
+		if err := readFile(opts.DNSFilename, dns); err != nil {
+			fmt.Fprintf(stderr, "error reading dns: %v\n", err)
+			return ExitUsageError
+		}
+	}
+
+	in := stdin
+	if opts.Filename != "-" {
+		f, err := os.Open(opts.Filename)
+		if err != nil {
+			fmt.Fprintf(stderr, "error reading manifests: %v\n", err)
+			return ExitUsageError
+		}
+		defer f.Close()
+		in = f
+	}
+
+	valid, err := validateManifests(in, stdout, infra, dns, opts.FeatureGate)
+	if err != nil {
+		fmt.Fprintf(stderr, "error reading manifests: %v\n", err)
+		return ExitUsageError
+	}
+	if !valid {
+		return ExitInvalid
+	}
+	return ExitValid
+}
+
+// validateManifests validates every YAML or JSON document of the input and returns false when any of them is invalid.
+func validateManifests(in io.Reader, out io.Writer, infra *osconfigv1.Infrastructure, dns *osconfigv1.DNS, featureGate featuregate.MutableFeatureGate) (bool, error) {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(in))
+
+	valid := true
+	count := 0
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return false, err
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		typeMeta := metav1.TypeMeta{}
+		if err := yaml.Unmarshal(doc, &typeMeta); err != nil {
+			return false, err
+		}
+
+		var name string
+		var warnings []string
+		var validationErr error
+		switch typeMeta.Kind {
+		case "Machine":
+			m := &machinev1beta1.Machine{}
+			if err := yaml.Unmarshal(doc, m); err != nil {
+				return false, err
+			}
+			name = objectName(typeMeta.Kind, m.ObjectMeta)
+			warnings, validationErr = webhooks.ValidateMachine(infra, dns, featureGate, m)
+		case "MachineSet":
+			ms := &machinev1beta1.MachineSet{}
+			if err := yaml.Unmarshal(doc, ms); err != nil {
+				return false, err
+			}
+			name = objectName(typeMeta.Kind, ms.ObjectMeta)
+			warnings, validationErr = webhooks.ValidateMachineSet(infra, dns, featureGate, ms)
+		default:
+			return false, fmt.Errorf("unsupported kind %q, expected Machine or MachineSet", typeMeta.Kind)
+		}
+		count++
+
+		for _, warning := range warnings {
+			fmt.Fprintf(out, "%s: warning: %s\n", name, warning)
+		}
+		if validationErr != nil {
+			fmt.Fprintf(out, "%s: error: %v\n", name, validationErr)
+			valid = false
+		}
+	}
+
+	if count == 0 {
+		return false, errors.New("no Machine or MachineSet found")
+	}
+	if valid {
+		fmt.Fprintf(out, "%d manifest(s) valid\n", count)
+	}
+	return valid, nil
+}
+
+func objectName(kind string, meta metav1.ObjectMeta) string {
+	if meta.Namespace == "" {
+		return fmt.Sprintf("%s %s", kind, meta.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", kind, meta.Namespace, meta.Name)
+}
+
+func readFile(filename string, obj interface{}) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(data, obj); err != nil {
+		return fmt.Errorf("error decoding %s: %w", filename, err)
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+package validate
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	testutils "github.com/openshift/machine-api-operator/pkg/util/testing"
+)
+
+const awsInfrastructure = `apiVersion: config.openshift.io/v1
+kind: Infrastructure
+metadata:
+  name: cluster
+status:
+  infrastructureName: clusterID
+  platformStatus:
+    type: AWS
+    aws:
+      region: us-east-1
+`
+
+const azureInfrastructure = `apiVersion: config.openshift.io/v1
+kind: Infrastructure
+metadata:
+  name: cluster
+status:
+  infrastructureName: clusterID
+  platformStatus:
+    type: Azure
+    azure:
+      cloudName: AzurePublicCloud
+`
+
+const disconnectedDNS = `apiVersion: config.openshift.io/v1
+kind: DNS
+metadata:
+  name: cluster
+spec:
+  baseDomain: example.com
+`
+
+const awsMachineSet = `apiVersion: machine.openshift.io/v1beta1
+kind: MachineSet
+metadata:
+  name: worker
+  namespace: openshift-machine-api
+spec:
+  selector:
+    matchLabels:
+      machine.openshift.io/cluster-api-machineset: worker
+  template:
+    metadata:
+      labels:
+        machine.openshift.io/cluster-api-machineset: worker
+    spec:
+      providerSpec:
+        value:
+          apiVersion: machine.openshift.io/v1beta1
+          kind: AWSMachineProviderConfig
+          ami:
+            id: %AMI%
+          instanceType: m5.large
+          placement:
+            region: us-east-1
+          iamInstanceProfile:
+            id: profile
+          securityGroups:
+          - id: sg
+          subnet:
+            id: subnet
+          userDataSecret:
+            name: worker-user-data
+          credentialsSecret:
+            name: aws-cloud-credentials
+`
+
+const azureMachine = `apiVersion: machine.openshift.io/v1beta1
+kind: Machine
+metadata:
+  name: worker
+  namespace: openshift-machine-api
+spec:
+  providerSpec:
+    value:
+      apiVersion: machine.openshift.io/v1beta1
+      kind: AzureMachineProviderSpec
+      vmSize: %VMSIZE%
+      location: westus
+      publicIP: true
+      image:
+        resourceID: image
+      osDisk:
+        diskSizeGB: 128
+      userDataSecret:
+        name: worker-user-data
+      credentialsSecret:
+        name: azure-cloud-credentials
+        namespace: openshift-machine-api
+`
+
+func TestRun(t *testing.T) {
+	validAWSMachineSet := strings.ReplaceAll(awsMachineSet, "%AMI%", "ami")
+	invalidAWSMachineSet := strings.ReplaceAll(awsMachineSet, "          ami:\n            id: %AMI%\n", "")
+	validAzureMachine := strings.ReplaceAll(azureMachine, "%VMSIZE%", "Standard_D4s_v3")
+	invalidAzureMachine := strings.ReplaceAll(azureMachine, "%VMSIZE%", "''")
+
+	testCases := []struct {
+		name             string
+		infrastructure   string
+		dns              string
+		manifests        string
+		expectedExitCode int
+		expectedOutput   []string
+		expectedError    string
+	}{
+		{
+			name:             "with a valid AWS MachineSet",
+			infrastructure:   awsInfrastructure,
+			manifests:        validAWSMachineSet,
+			expectedExitCode: ExitValid,
+			expectedOutput:   []string{"1 manifest(s) valid"},
+		},
+		{
+			name:             "with an invalid AWS MachineSet",
+			infrastructure:   awsInfrastructure,
+			manifests:        invalidAWSMachineSet,
+			expectedExitCode: ExitInvalid,
+			expectedOutput: []string{
+				"MachineSet openshift-machine-api/worker: error: providerSpec.ami: Required value: expected providerSpec.ami.id to be populated",
+			},
+		},
+		{
+			name:             "with a valid Azure Machine",
+			infrastructure:   azureInfrastructure,
+			manifests:        validAzureMachine,
+			expectedExitCode: ExitValid,
+			expectedOutput:   []string{"1 manifest(s) valid"},
+		},
+		{
+			name:             "with an invalid Azure Machine",
+			infrastructure:   azureInfrastructure,
+			manifests:        invalidAzureMachine,
+			expectedExitCode: ExitInvalid,
+			expectedOutput: []string{
+				"Machine openshift-machine-api/worker: error: providerSpec.vmSize: Required value: vmSize should be set to one of the supported Azure VM sizes",
+			},
+		},
+		{
+			name:             "with an Azure Machine with a public IP in a disconnected cluster",
+			infrastructure:   azureInfrastructure,
+			dns:              disconnectedDNS,
+			manifests:        validAzureMachine,
+			expectedExitCode: ExitInvalid,
+			expectedOutput: []string{
+				"Machine openshift-machine-api/worker: error: providerSpec.publicIP: Forbidden: publicIP is not allowed in Azure disconnected installation with publish strategy as internal",
+			},
+		},
+		{
+			name:             "with several manifests of which one is invalid",
+			infrastructure:   awsInfrastructure,
+			manifests:        validAWSMachineSet + "---\n" + strings.ReplaceAll(invalidAWSMachineSet, "name: worker\n", "name: invalid\n"),
+			expectedExitCode: ExitInvalid,
+			expectedOutput: []string{
+				"MachineSet openshift-machine-api/invalid: error: providerSpec.ami: Required value: expected providerSpec.ami.id to be populated",
+			},
+		},
+		{
+			name:             "with an unsupported kind",
+			infrastructure:   awsInfrastructure,
+			manifests:        "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\n",
+			expectedExitCode: ExitUsageError,
+			expectedError:    "error reading manifests: unsupported kind \"ConfigMap\", expected Machine or MachineSet",
+		},
+		{
+			name:             "without manifests",
+			infrastructure:   awsInfrastructure,
+			expectedExitCode: ExitUsageError,
+			expectedError:    "error reading manifests: no Machine or MachineSet found",
+		},
+		{
+			name:             "with an infrastructure without platform status",
+			infrastructure:   "apiVersion: config.openshift.io/v1\nkind: Infrastructure\nmetadata:\n  name: cluster\n",
+			manifests:        validAWSMachineSet,
+			expectedExitCode: ExitUsageError,
+			expectedError:    "has no status.platformStatus",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			dir := t.TempDir()
+
+			gate, err := testutils.NewDefaultMutableFeatureGate()
+			g.Expect(err).ToNot(HaveOccurred())
+
+			opts := Options{
+				Filename:               "-",
+				InfrastructureFilename: filepath.Join(dir, "infrastructure.yaml"),
+				FeatureGate:            gate,
+			}
+			g.Expect(os.WriteFile(opts.InfrastructureFilename, []byte(tc.infrastructure), 0600)).To(Succeed())
+			if tc.dns != "" {
+				opts.DNSFilename = filepath.Join(dir, "dns.yaml")
+				g.Expect(os.WriteFile(opts.DNSFilename, []byte(tc.dns), 0600)).To(Succeed())
+			}
+
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			exitCode := Run(opts, strings.NewReader(tc.manifests), stdout, stderr)
+
+			g.Expect(exitCode).To(Equal(tc.expectedExitCode), "stdout: %s\nstderr: %s", stdout, stderr)
+			for _, line := range tc.expectedOutput {
+				g.Expect(stdout.String()).To(ContainSubstring(line))
+			}
+			if tc.expectedError != "" {
+				g.Expect(stderr.String()).To(ContainSubstring(tc.expectedError))
+			} else {
+				g.Expect(stderr.String()).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestRunWithFile(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+
+	gate, err := testutils.NewDefaultMutableFeatureGate()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	opts := Options{
+		Filename:               filepath.Join(dir, "machineset.yaml"),
+		InfrastructureFilename: filepath.Join(dir, "infrastructure.yaml"),
+		FeatureGate:            gate,
+	}
+	g.Expect(os.WriteFile(opts.InfrastructureFilename, []byte(awsInfrastructure), 0600)).To(Succeed())
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	g.Expect(Run(opts, nil, stdout, stderr)).To(Equal(ExitUsageError))
+	g.Expect(stderr.String()).To(ContainSubstring("no such file or directory"))
+
+	g.Expect(os.WriteFile(opts.Filename, []byte(strings.ReplaceAll(awsMachineSet, "%AMI%", "ami")), 0600)).To(Succeed())
+
+	stderr.Reset()
+	g.Expect(Run(opts, nil, stdout, stderr)).To(Equal(ExitValid))
+	g.Expect(stdout.String()).To(Equal("1 manifest(s) valid\n"))
+	g.Expect(stderr.String()).To(BeEmpty())
+}
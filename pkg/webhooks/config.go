@@ -14,6 +14,7 @@ const (
 	DefaultMachineValidatingHookPath                   = "/validate-machine-openshift-io-v1beta1-machine"
 	DefaultMachineSetMutatingHookPath                  = "/mutate-machine-openshift-io-v1beta1-machineset"
 	DefaultMachineSetValidatingHookPath                = "/validate-machine-openshift-io-v1beta1-machineset"
+	DefaultMachineHealthCheckValidatingHookPath        = "/validate-machine-openshift-io-v1beta1-machinehealthcheck"
 	DefaultMetal3RemediationMutatingHookPath           = "/mutate-infrastructure-cluster-x-k8s-io-v1beta1-metal3remediation"
 	DefaultMetal3RemediationValidatingHookPath         = "/validate-infrastructure-cluster-x-k8s-io-v1beta1-metal3remediation"
 	DefaultMetal3RemediationTemplateMutatingHookPath   = "/mutate-infrastructure-cluster-x-k8s-io-v1beta1-metal3remediationtemplate"
@@ -28,6 +29,8 @@ const (
 	// Name and port of the webhook service pointing to the machine-controller / provider container
 	// Used by metal3 remediation webhooks in the CAPBM image
 	defaultProviderWebhookServiceName = "machine-api-operator-machine-webhook"
+	// Name and port of the webhook service pointing to the machine-healthcheck-controller container
+	defaultMachineHealthCheckWebhookServiceName = "machine-api-operator-machinehealthcheck-webhook"
 )
 
 var (
@@ -43,7 +46,7 @@ var (
 	webhookSideEffects   = admissionregistrationv1.SideEffectClassNone
 )
 
-// NewMachineValidatingWebhookConfiguration creates a validation webhook configuration with configured Machine and MachineSet webhooks
+// NewMachineValidatingWebhookConfiguration creates a validation webhook configuration with configured Machine, MachineSet and MachineHealthCheck webhooks
 func NewMachineValidatingWebhookConfiguration() *admissionregistrationv1.ValidatingWebhookConfiguration {
 	validatingWebhookConfiguration := &admissionregistrationv1.ValidatingWebhookConfiguration{
 		ObjectMeta: metav1.ObjectMeta{
@@ -55,6 +58,7 @@ func NewMachineValidatingWebhookConfiguration() *admissionregistrationv1.Validat
 		Webhooks: []admissionregistrationv1.ValidatingWebhook{
 			MachineValidatingWebhook(),
 			MachineSetValidatingWebhook(),
+			MachineHealthCheckValidatingWebhook(),
 		},
 	}
 
@@ -90,6 +94,7 @@ func MachineValidatingWebhook() admissionregistrationv1.ValidatingWebhook {
 				Operations: []admissionregistrationv1.OperationType{
 					admissionregistrationv1.Create,
 					admissionregistrationv1.Update,
+					admissionregistrationv1.Delete,
 				},
 			},
 		},
@@ -128,6 +133,38 @@ func MachineSetValidatingWebhook() admissionregistrationv1.ValidatingWebhook {
 	}
 }
 
+// MachineHealthCheckValidatingWebhook returns validating webhooks for machineHealthCheck to populate the configuration
+func MachineHealthCheckValidatingWebhook() admissionregistrationv1.ValidatingWebhook {
+	serviceReference := admissionregistrationv1.ServiceReference{
+		Namespace: defaultWebhookServiceNamespace,
+		Name:      defaultMachineHealthCheckWebhookServiceName,
+		Path:      ptr.To[string](DefaultMachineHealthCheckValidatingHookPath),
+		Port:      ptr.To[int32](defaultWebhookServicePort),
+	}
+	return admissionregistrationv1.ValidatingWebhook{
+		AdmissionReviewVersions: []string{"v1"},
+		Name:                    "validation.machinehealthcheck.machine.openshift.io",
+		FailurePolicy:           &webhookFailurePolicy,
+		SideEffects:             &webhookSideEffects,
+		ClientConfig: admissionregistrationv1.WebhookClientConfig{
+			Service: &serviceReference,
+		},
+		Rules: []admissionregistrationv1.RuleWithOperations{
+			{
+				Rule: admissionregistrationv1.Rule{
+					APIGroups:   []string{machinev1beta1.GroupName},
+					APIVersions: []string{machinev1beta1.SchemeGroupVersion.Version},
+					Resources:   []string{"machinehealthchecks"},
+				},
+				Operations: []admissionregistrationv1.OperationType{
+					admissionregistrationv1.Create,
+					admissionregistrationv1.Update,
+				},
+			},
+		},
+	}
+}
+
 // NewMetal3RemediationValidatingWebhookConfiguration creates a validation webhook configuration with configured
 // metal3remediation(template) webhooks. Metal3Remediation(Templates) were backported from metal3, their CRDs and the
 // actual webhook implementation can be found in cluster-api-provider-baremetal
@@ -261,6 +298,7 @@ func MachineMutatingWebhook() admissionregistrationv1.MutatingWebhook {
 				},
 				Operations: []admissionregistrationv1.OperationType{
 					admissionregistrationv1.Create,
+					admissionregistrationv1.Update,
 				},
 			},
 		},
@@ -292,6 +330,7 @@ func MachineSetMutatingWebhook() admissionregistrationv1.MutatingWebhook {
 				},
 				Operations: []admissionregistrationv1.OperationType{
 					admissionregistrationv1.Create,
+					admissionregistrationv1.Update,
 				},
 			},
 		},
@@ -0,0 +1,97 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog/v2"
+)
+
+// DefaultedFieldsAnnotation lists, as a JSON array, the fields of a Machine set by the defaulting webhook
+// and their resulting values. The values of fields referencing secrets are omitted.
+const DefaultedFieldsAnnotation = "machine.openshift.io/defaulted-fields"
+
+// defaultedField is a field set by the defaulting webhook.
+type defaultedField struct {
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// providerSpecFields decodes the raw providerSpec of the Machine, it returns nil when it can't be decoded.
+func providerSpecFields(m *machinev1beta1.Machine) interface{} {
+	if m.Spec.ProviderSpec.Value == nil || m.Spec.ProviderSpec.Value.Raw == nil {
+		return nil
+	}
+
+	var fields interface{}
+	if err := json.Unmarshal(m.Spec.ProviderSpec.Value.Raw, &fields); err != nil {
+		return nil
+	}
+	return fields
+}
+
+// diffDefaultedFields returns the fields which are set in after but not in before. The providerSpec is
+// re-encoded by the defaulters, fields with a zero value are not considered as defaulted.
+func diffDefaultedFields(path *field.Path, before, after interface{}) []defaultedField {
+	switch after := after.(type) {
+	case map[string]interface{}:
+		beforeMap, _ := before.(map[string]interface{})
+
+		keys := make([]string, 0, len(after))
+		for key := range after {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+
+		var fields []defaultedField
+		for _, key := range keys {
+			fields = append(fields, diffDefaultedFields(path.Child(key), beforeMap[key], after[key])...)
+		}
+		return fields
+	case []interface{}:
+		beforeSlice, _ := before.([]interface{})
+
+		var fields []defaultedField
+		for i := range after {
+			var beforeValue interface{}
+			if i < len(beforeSlice) {
+				beforeValue = beforeSlice[i]
+			}
+			fields = append(fields, diffDefaultedFields(path.Index(i), beforeValue, after[i])...)
+		}
+		return fields
+	}
+
+	if after == nil || reflect.ValueOf(after).IsZero() || reflect.DeepEqual(before, after) {
+		return nil
+	}
+
+	defaulted := defaultedField{Path: path.String(), Value: after}
+	if strings.Contains(strings.ToLower(path.String()), "secret") {
+		defaulted.Value = nil
+	}
+	return []defaultedField{defaulted}
+}
+
+// setDefaultedFieldsAnnotation records the defaulted fields on the Machine, it is left untouched
+// when no field was defaulted.
+func setDefaultedFieldsAnnotation(m *machinev1beta1.Machine, fields []defaultedField) {
+	if len(fields) == 0 {
+		return
+	}
+
+	value, err := json.Marshal(fields)
+	if err != nil {
+		klog.Errorf("failed to encode the defaulted fields of Machine %s: %v", m.GetName(), err)
+		return
+	}
+
+	if m.Annotations == nil {
+		m.Annotations = make(map[string]string)
+	}
+	m.Annotations[DefaultedFieldsAnnotation] = string(value)
+}
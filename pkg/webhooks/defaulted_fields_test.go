@@ -0,0 +1,116 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	osconfigv1 "github.com/openshift/api/config/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+)
+
+func TestDefaultedFieldsAnnotation(t *testing.T) {
+	clusterID := "clusterID"
+
+	testCases := []struct {
+		name           string
+		platformStatus *osconfigv1.PlatformStatus
+		labels         map[string]string
+		providerSpec   interface{}
+		expectedFields []defaultedField
+	}{
+		{
+			name: "with a minimal AWS machine",
+			platformStatus: &osconfigv1.PlatformStatus{
+				Type: osconfigv1.AWSPlatformType,
+				AWS:  &osconfigv1.AWSPlatformStatus{Region: "us-east-1"},
+			},
+			providerSpec: &machinev1beta1.AWSMachineProviderConfig{
+				AMI: machinev1beta1.AWSResourceReference{ID: ptr.To[string]("ami")},
+			},
+			expectedFields: []defaultedField{
+				{Path: "metadata.labels[machine.openshift.io/cluster-api-cluster]", Value: clusterID},
+				{Path: "spec.providerSpec.value.credentialsSecret.name"},
+				{Path: "spec.providerSpec.value.instanceType", Value: defaultAWSX86InstanceType},
+				{Path: "spec.providerSpec.value.placement.region", Value: "us-east-1"},
+				{Path: "spec.providerSpec.value.tags[0].name", Value: awsClusterTagName(clusterID)},
+				{Path: "spec.providerSpec.value.tags[0].value", Value: awsClusterTagOwned},
+				{Path: "spec.providerSpec.value.userDataSecret.name"},
+			},
+		},
+		{
+			name: "with a minimal Azure machine",
+			platformStatus: &osconfigv1.PlatformStatus{
+				Type: osconfigv1.AzurePlatformType,
+			},
+			labels: map[string]string{machinev1beta1.MachineClusterIDLabel: clusterID},
+			providerSpec: &machinev1beta1.AzureMachineProviderSpec{
+				Location: "eastus",
+			},
+			expectedFields: []defaultedField{
+				{Path: "spec.providerSpec.value.credentialsSecret.name"},
+				{Path: "spec.providerSpec.value.credentialsSecret.namespace"},
+				{Path: "spec.providerSpec.value.image.resourceID", Value: defaultAzureImageResourceID(clusterID, AMD64)},
+				{Path: "spec.providerSpec.value.subnet", Value: defaultAzureSubnet(clusterID)},
+				{Path: "spec.providerSpec.value.userDataSecret.name"},
+				{Path: "spec.providerSpec.value.vmSize", Value: defaultAzureX86VMSize},
+				{Path: "spec.providerSpec.value.vnet", Value: defaultAzureVnet(clusterID)},
+			},
+		},
+		{
+			name: "with nothing to default",
+			platformStatus: &osconfigv1.PlatformStatus{
+				Type: osconfigv1.AWSPlatformType,
+				AWS:  &osconfigv1.AWSPlatformStatus{Region: "us-east-1"},
+			},
+			labels: map[string]string{machinev1beta1.MachineClusterIDLabel: clusterID},
+			providerSpec: &machinev1beta1.AWSMachineProviderConfig{
+				AMI:               machinev1beta1.AWSResourceReference{ID: ptr.To[string]("ami")},
+				InstanceType:      "m5.large",
+				Placement:         machinev1beta1.Placement{Region: "us-east-1"},
+				UserDataSecret:    &corev1.LocalObjectReference{Name: "user-data"},
+				CredentialsSecret: &corev1.LocalObjectReference{Name: "credentials"},
+				Tags:              []machinev1beta1.TagSpecification{{Name: awsClusterTagName(clusterID), Value: awsClusterTagOwned}},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rawBytes, err := json.Marshal(tc.providerSpec)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			m := &machinev1beta1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "test",
+					Labels: tc.labels,
+				},
+				Spec: machinev1beta1.MachineSpec{
+					ProviderSpec: machinev1beta1.ProviderSpec{
+						Value: &kruntime.RawExtension{Raw: rawBytes},
+					},
+				},
+			}
+
+			h := createMachineDefaulter(tc.platformStatus, clusterID)
+			g.Expect(h.Default(context.Background(), m)).To(Succeed())
+
+			if tc.expectedFields == nil {
+				g.Expect(m.Annotations).ToNot(HaveKey(DefaultedFieldsAnnotation))
+				return
+			}
+
+			g.Expect(m.Annotations).To(HaveKey(DefaultedFieldsAnnotation))
+			var fields []defaultedField
+			g.Expect(json.Unmarshal([]byte(m.Annotations[DefaultedFieldsAnnotation]), &fields)).To(Succeed())
+			g.Expect(fields).To(Equal(tc.expectedFields))
+		})
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"regexp"
 	goruntime "runtime"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -28,14 +30,15 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
-	"sigs.k8s.io/kube-storage-version-migrator/pkg/clients/clientset/scheme"
-	"sigs.k8s.io/yaml"
 
 	osconfigv1 "github.com/openshift/api/config/v1"
 	apifeatures "github.com/openshift/api/features"
 	machinev1 "github.com/openshift/api/machine/v1"
 	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
 	osclientset "github.com/openshift/client-go/config/clientset/versioned"
+	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
+	"github.com/openshift/machine-api-operator/pkg/util"
+	"github.com/openshift/machine-api-operator/pkg/util/instancetypes"
 	"github.com/openshift/machine-api-operator/pkg/util/lifecyclehooks"
 )
 
@@ -60,7 +63,7 @@ var (
 	defaultAzureNetworkResourceGroup = func(clusterID string) string {
 		return fmt.Sprintf("%s-rg", clusterID)
 	}
-	defaultAzureImageResourceID = func(clusterID string) string {
+	defaultAzureImageResourceID = func(clusterID string, arch machineArch) string {
 		// image gallery names cannot have dashes
 		galleryName := strings.Replace(clusterID, "-", "_", -1)
 		imageName := clusterID
@@ -90,7 +93,7 @@ var (
 		return []string{fmt.Sprintf("%s-worker", clusterID)}
 	}
 
-	defaultGCPDiskImage = func() string {
+	defaultGCPDiskImage = func(arch machineArch) string {
 		if arch == ARM64 {
 			return defaultGCPARMDiskImage
 		}
@@ -131,6 +134,33 @@ var (
 
 	// vSphereDataDiskNamePattern is used to validate the name of a data disk
 	vSphereDataDiskNamePattern = regexp.MustCompile(`^[a-zA-Z0-9]([-_a-zA-Z0-9]*[a-zA-Z0-9])?$`)
+
+	// Instance identity variables
+
+	// awsIAMInstanceProfilePattern matches the name of an AWS IAM instance profile
+	awsIAMInstanceProfilePattern = regexp.MustCompile(`^[\w+=,.@-]{1,128}$`)
+
+	// azureManagedIdentityPattern matches either the name or the resource ID of an Azure user assigned identity
+	azureManagedIdentityPattern = regexp.MustCompile(`^(?:[a-zA-Z0-9][\w-]{2,127}|(?i:/subscriptions/[^/]+/resourcegroups/[^/]+/providers/microsoft\.managedidentity/userassignedidentities/[^/]+))$`)
+
+	// gcpServiceAccountEmailPattern matches the email of a GCP service account, or the default compute service account
+	gcpServiceAccountEmailPattern = regexp.MustCompile(`^(?:default|[^@\s]+@[^@\s]+\.[^@\s]+)$`)
+
+	// gcpQualifiedSubnetworkPattern matches a subnetwork qualified with its project, either as a partial
+	// or as a full resource URL, capturing the project
+	gcpQualifiedSubnetworkPattern = regexp.MustCompile(`^(?:https://www\.googleapis\.com/compute/v1/)?projects/([^/]+)/regions/[^/]+/subnetworks/[^/]+$`)
+
+	// placeholderIdentityValues are values commonly left in templates in place of a real identity reference
+	placeholderIdentityValues = sets.New("changeme", "change-me", "change_me", "replaceme", "replace-me", "placeholder", "todo", "tbd", "xxx", "example")
+
+	// azureRegionsWithZones are the Azure regions which support availability zones
+	azureRegionsWithZones = sets.New(
+		"australiaeast", "brazilsouth", "canadacentral", "centralindia", "centralus", "eastasia", "eastus", "eastus2",
+		"francecentral", "germanywestcentral", "israelcentral", "italynorth", "japaneast", "japanwest", "koreacentral",
+		"mexicocentral", "newzealandnorth", "northeurope", "norwayeast", "polandcentral", "qatarcentral", "southafricanorth",
+		"southcentralus", "southeastasia", "spaincentral", "swedencentral", "switzerlandnorth", "uaenorth", "uksouth",
+		"westeurope", "westus2", "westus3", "usgovvirginia", "chinanorth3",
+	)
 )
 
 const (
@@ -138,6 +168,9 @@ const (
 	ARM64 machineArch = "arm64"
 	AMD64 machineArch = "amd64"
 
+	// machineArchLabel is the node label giving the architecture of a Machine
+	machineArchLabel = "kubernetes.io/arch"
+
 	defaultUserDataSecret  = "worker-user-data"
 	defaultSecretNamespace = "openshift-machine-api"
 
@@ -218,6 +251,34 @@ const (
 // reference: https://cloud.google.com/compute/confidential-vm/docs/os-and-machine-type#machine-type
 var gcpConfidentialComputeSupportedMachineSeries = []string{"n2d", "c2d"}
 
+// GCP Hyperdisks are known to be supported by Compute Engine machine types in the following series:
+// reference: https://cloud.google.com/compute/docs/disks/hyperdisks#machine-type-support
+var gcpHyperdiskSupportedMachineSeries = []string{"a3", "c3", "c3d", "c4", "c4a", "c4d", "h3", "m1", "m2", "m3", "n4", "x4", "z3"}
+
+// GCP Hyperdisks are known not to be supported by Compute Engine machine types in the following series:
+// reference: https://cloud.google.com/compute/docs/disks/hyperdisks#machine-type-support
+var gcpHyperdiskUnsupportedMachineSeries = []string{"a2", "c2", "c2d", "e2", "f1", "g1", "g2", "n1", "n2", "n2d", "t2a", "t2d"}
+
+const (
+	// gcpMaxLabels is the maximum number of labels GCP allows on a resource.
+	// reference: https://cloud.google.com/compute/docs/labeling-resources#requirements
+	gcpMaxLabels = 64
+	// gcpMaxLabelLength is the maximum length of the keys and values of GCP labels.
+	gcpMaxLabelLength = 63
+)
+
+// machineArchitecture returns the architecture of the Machine, given by the kubernetes.io/arch label of the
+// Node (spec.metadata.labels) or of the Machine itself. When neither is set, the control plane architecture is returned.
+func machineArchitecture(m *machinev1beta1.Machine) machineArch {
+	if hint, ok := m.Spec.Labels[machineArchLabel]; ok && hint != "" {
+		return machineArch(hint)
+	}
+	if hint, ok := m.Labels[machineArchLabel]; ok && hint != "" {
+		return machineArch(hint)
+	}
+	return arch
+}
+
 // defaultInstanceTypeForCloudProvider returns the default instance type for the given cloud provider and architecture.
 // If the cloud provider is not supported, an empty string is returned.
 // If the architecture is not supported, the default instance type for AMD64 is returned as a fallback.
@@ -240,7 +301,7 @@ func defaultInstanceTypeForCloudProvider(cloudProvider osconfigv1.PlatformType,
 	if cloudProviderMap, ok := cloudProviderArchMachineTypes[cloudProvider]; ok {
 		if instanceType, ok := cloudProviderArchMachineTypes[cloudProvider][arch]; ok {
 			*warnings = append(*warnings, fmt.Sprintf("setting the default instance type %q "+
-				"for cloud provider %q, based on the control plane architecture (%q)", instanceType, cloudProvider, arch))
+				"for cloud provider %q, based on the machine architecture (%q)", instanceType, cloudProvider, arch))
 			return instanceType
 		}
 		// If the arch is not supported, return the default for AMD64.
@@ -255,25 +316,105 @@ func defaultInstanceTypeForCloudProvider(cloudProvider osconfigv1.PlatformType,
 	return ""
 }
 
-func secretExists(c client.Client, name, namespace string) (bool, error) {
-	key := client.ObjectKey{
-		Name:      name,
-		Namespace: namespace,
+// credentialsSecretKeys lists the alternative sets of keys a provider reads from its credentials secret.
+// A well-formed credentials secret contains all the keys of at least one of the sets.
+type credentialsSecretKeys [][]string
+
+var (
+	awsCredentialsSecretKeys = credentialsSecretKeys{
+		{"aws_access_key_id", "aws_secret_access_key"},
+		// A shared credentials file, eg. referencing a role to assume
+		{"credentials"},
+	}
+	azureCredentialsSecretKeys = credentialsSecretKeys{
+		{"azure_subscription_id", "azure_client_id", "azure_tenant_id", "azure_client_secret"},
+		// Workload identity
+		{"azure_subscription_id", "azure_client_id", "azure_tenant_id", "azure_federated_token_file"},
+	}
+	gcpCredentialsSecretKeys     = credentialsSecretKeys{{"service_account.json"}}
+	nutanixCredentialsSecretKeys = credentialsSecretKeys{{"credentials"}}
+	powerVSCredentialsSecretKeys = credentialsSecretKeys{{"ibmcloud_api_key"}}
+)
+
+// vSphereCredentialsSecretKeys returns the keys of the credentials of the workspace vCenter server in the
+// credentials secret. The keys cannot be known when the workspace server is not set.
+func vSphereCredentialsSecretKeys(workspace *machinev1beta1.Workspace) credentialsSecretKeys {
+	if workspace == nil || workspace.Server == "" {
+		return nil
+	}
+	return credentialsSecretKeys{{workspace.Server + ".username", workspace.Server + ".password"}}
+}
+
+// missingKeys returns the keys of the closest set which are absent or empty in the secret,
+// or nil when the secret contains one of the sets.
+func (k credentialsSecretKeys) missingKeys(secret *corev1.Secret) []string {
+	var closest []string
+	for i, keys := range k {
+		var missing []string
+		for _, key := range keys {
+			if len(secret.Data[key]) == 0 {
+				missing = append(missing, key)
+			}
+		}
+		if len(missing) == 0 {
+			return nil
+		}
+		if i == 0 || len(missing) < len(closest) {
+			closest = missing
+		}
+	}
+	return closest
+}
+
+func (k credentialsSecretKeys) String() string {
+	sets := make([]string, len(k))
+	for i, keys := range k {
+		sets[i] = strings.Join(keys, ", ")
+	}
+	if len(sets) == 1 {
+		return sets[0]
+	}
+	return "either " + strings.Join(sets, " or ")
+}
+
+// userDataSecretWarnings returns a warning when the user data secret does not exist. Machines fail to boot
+// without it, but it may be created after the Machine, so this is a warning rather than an error.
+func userDataSecretWarnings(c client.Client, name, namespace string) []string {
+	if c == nil {
+		// The secret cannot be checked when validating offline
+		return nil
 	}
-	obj := &corev1.Secret{}
 
-	if err := c.Get(context.Background(), key, obj); err != nil {
+	if err := c.Get(context.Background(), client.ObjectKey{Name: name, Namespace: namespace}, &corev1.Secret{}); err != nil {
+		detail := fmt.Sprintf("failed to get userDataSecret: %v", err)
 		if apierrors.IsNotFound(err) {
-			return false, nil
+			detail = "not found. Expected UserDataSecret to exist"
 		}
-		return false, err
+		return []string{field.Invalid(field.NewPath("providerSpec", "userDataSecret"), name, detail).Error()}
 	}
-	return true, nil
+	return nil
 }
 
-func credentialsSecretExists(c client.Client, name, namespace string) []string {
-	secretExists, err := secretExists(c, name, namespace)
-	if err != nil {
+// credentialsSecretWarnings returns warnings when the credentials secret does not exist or does not
+// contain the keys expected by the provider. As the format of the secret varies, for example with
+// the credentials mode of the cluster, these are warnings rather than errors.
+func credentialsSecretWarnings(c client.Client, name, namespace string, expectedKeys credentialsSecretKeys) []string {
+	if c == nil {
+		// The secret cannot be checked when validating offline
+		return nil
+	}
+
+	secret := &corev1.Secret{}
+	if err := c.Get(context.Background(), client.ObjectKey{Name: name, Namespace: namespace}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return []string{
+				field.Invalid(
+					field.NewPath("providerSpec", "credentialsSecret"),
+					name,
+					"not found. Expected CredentialsSecret to exist",
+				).Error(),
+			}
+		}
 		return []string{
 			field.Invalid(
 				field.NewPath("providerSpec", "credentialsSecret"),
@@ -283,12 +424,12 @@ func credentialsSecretExists(c client.Client, name, namespace string) []string {
 		}
 	}
 
-	if !secretExists {
+	if missing := expectedKeys.missingKeys(secret); len(missing) > 0 {
 		return []string{
 			field.Invalid(
 				field.NewPath("providerSpec", "credentialsSecret"),
 				name,
-				"not found. Expected CredentialsSecret to exist",
+				fmt.Sprintf("is missing keys %s. Expected CredentialsSecret to contain %s", strings.Join(missing, ", "), expectedKeys),
 			).Error(),
 		}
 	}
@@ -296,6 +437,48 @@ func credentialsSecretExists(c client.Client, name, namespace string) []string {
 	return []string{}
 }
 
+// instanceIdentityWarnings returns warnings when an instance identity reference is clearly a placeholder
+// or is not well-formed. Cloud identities cannot be looked up from the cluster, so unlike the
+// credentialsSecret, their existence cannot be verified by the webhook.
+func instanceIdentityWarnings(fldPath *field.Path, value string, pattern *regexp.Regexp, expected string) []string {
+	if isPlaceholderIdentity(value) {
+		return []string{field.Invalid(fldPath, value, "appears to be a placeholder. Expected a reference to an existing identity").Error()}
+	}
+
+	if !pattern.MatchString(value) {
+		return []string{field.Invalid(fldPath, value, fmt.Sprintf("is not well-formed. Expected %s", expected)).Error()}
+	}
+
+	return nil
+}
+
+// isPlaceholderIdentity checks for well known placeholder values and unrendered template markers.
+func isPlaceholderIdentity(value string) bool {
+	v := strings.ToLower(strings.TrimSpace(value))
+	if strings.ContainsAny(v, "<>{}$") {
+		return true
+	}
+
+	// Only consider the local part of email style references, eg. changeme@project.iam.gserviceaccount.com
+	local, _, _ := strings.Cut(v, "@")
+	return placeholderIdentityValues.Has(v) || placeholderIdentityValues.Has(local)
+}
+
+// awsResourceReferenceFields returns the names of the fields set on the AWS resource reference.
+func awsResourceReferenceFields(ref *machinev1beta1.AWSResourceReference) []string {
+	var fields []string
+	if ref.ID != nil {
+		fields = append(fields, "id")
+	}
+	if ref.ARN != nil {
+		fields = append(fields, "arn")
+	}
+	if ref.Filters != nil {
+		fields = append(fields, "filters")
+	}
+	return fields
+}
+
 func getInfra() (*osconfigv1.Infrastructure, error) {
 	cfg, err := ctrl.GetConfig()
 	if err != nil {
@@ -337,6 +520,8 @@ type admissionConfig struct {
 	dnsDisconnected bool
 	client          client.Client
 	featureGates    featuregate.MutableFeatureGate
+	// nutanixFailureDomains are the failure domains of the cluster infrastructure on Nutanix.
+	nutanixFailureDomains []osconfigv1.NutanixFailureDomain
 }
 
 type admissionHandler struct {
@@ -365,28 +550,14 @@ type machineDefaulterHandler struct {
 	*admissionHandler
 }
 
-// NewValidator returns a new machineValidatorHandler.
-func NewMachineValidator(client client.Client, featureGate featuregate.MutableFeatureGate) (*admission.Webhook, error) {
-	infra, err := getInfra()
-	if err != nil {
-		return nil, err
-	}
-
-	dns, err := getDNS()
-	if err != nil {
-		return nil, err
-	}
-
-	return admission.WithCustomValidator(scheme.Scheme, &machinev1beta1.Machine{}, createMachineValidator(infra, client, dns, featureGate)), nil
-}
-
 func createMachineValidator(infra *osconfigv1.Infrastructure, client client.Client, dns *osconfigv1.DNS, featureGate featuregate.MutableFeatureGate) *machineValidatorHandler {
 	admissionConfig := &admissionConfig{
-		dnsDisconnected: dns.Spec.PublicZone == nil,
-		clusterID:       infra.Status.InfrastructureName,
-		platformStatus:  infra.Status.PlatformStatus,
-		client:          client,
-		featureGates:    featureGate,
+		dnsDisconnected:       dns.Spec.PublicZone == nil,
+		clusterID:             infra.Status.InfrastructureName,
+		platformStatus:        infra.Status.PlatformStatus,
+		client:                client,
+		featureGates:          featureGate,
+		nutanixFailureDomains: getNutanixFailureDomains(infra),
 	}
 	return &machineValidatorHandler{
 		admissionHandler: &admissionHandler{
@@ -396,6 +567,32 @@ func createMachineValidator(infra *osconfigv1.Infrastructure, client client.Clie
 	}
 }
 
+// ValidateMachine validates a Machine with the same checks as the Machine validating webhook, without a
+// connection to a cluster, see ValidateMachineSet.
+func ValidateMachine(infra *osconfigv1.Infrastructure, dns *osconfigv1.DNS, featureGate featuregate.MutableFeatureGate, m *machinev1beta1.Machine) ([]string, error) {
+	h := createMachineValidator(infra, nil, &osconfigv1.DNS{}, featureGate)
+	h.dnsDisconnected = isDNSDisconnected(dns)
+
+	ok, warnings, errs := h.validateMachine(m, nil)
+	if !ok {
+		return warnings, errs.ToAggregate()
+	}
+	return warnings, nil
+}
+
+// isDNSDisconnected returns true when the cluster DNS has no public zone. A nil dns is considered connected.
+func isDNSDisconnected(dns *osconfigv1.DNS) bool {
+	return dns != nil && dns.Spec.PublicZone == nil
+}
+
+// getNutanixFailureDomains returns the Nutanix failure domains of the cluster infrastructure, if any.
+func getNutanixFailureDomains(infra *osconfigv1.Infrastructure) []osconfigv1.NutanixFailureDomain {
+	if infra.Spec.PlatformSpec.Nutanix == nil {
+		return nil
+	}
+	return infra.Spec.PlatformSpec.Nutanix.FailureDomains
+}
+
 func getMachineValidatorOperation(platform osconfigv1.PlatformType) machineAdmissionFn {
 	switch platform {
 	case osconfigv1.AWSPlatformType:
@@ -418,20 +615,10 @@ func getMachineValidatorOperation(platform osconfigv1.PlatformType) machineAdmis
 	}
 }
 
-// NewDefaulter returns a new machineDefaulterHandler.
-func NewMachineDefaulter() (*admission.Webhook, error) {
-	infra, err := getInfra()
-	if err != nil {
-		return nil, err
-	}
-
-	return admission.WithCustomDefaulter(scheme.Scheme, &machinev1beta1.Machine{}, createMachineDefaulter(infra.Status.PlatformStatus, infra.Status.InfrastructureName)), nil
-}
-
 func createMachineDefaulter(platformStatus *osconfigv1.PlatformStatus, clusterID string) *machineDefaulterHandler {
 	return &machineDefaulterHandler{
 		admissionHandler: &admissionHandler{
-			admissionConfig:   &admissionConfig{clusterID: clusterID},
+			admissionConfig:   &admissionConfig{clusterID: clusterID, platformStatus: platformStatus},
 			webhookOperations: getMachineDefaulterOperation(platformStatus),
 		},
 	}
@@ -477,6 +664,7 @@ func (h *machineValidatorHandler) validateMachine(m, oldM *machinev1beta1.Machin
 	}
 
 	errs := validateMachineLifecycleHooks(m, oldM)
+	errs = append(errs, validateAdoptProviderIDAnnotation(m, oldM)...)
 
 	ok, warnings, opErrs := h.webhookOperations(m, h.admissionConfig)
 	if !ok {
@@ -528,6 +716,14 @@ func (h *machineValidatorHandler) ValidateUpdate(ctx context.Context, oldObj, ob
 	return warnings, nil
 }
 
+const (
+	// MachineProtectedAnnotation protects a Machine from deletion when set to "true".
+	MachineProtectedAnnotation = machinecontroller.MachineProtectedAnnotation
+	// MachineForceDeleteAnnotation allows the deletion of a protected Machine when set to "true".
+	// A delete request does not carry the object, the annotation has to be set on the Machine beforehand.
+	MachineForceDeleteAnnotation = machinecontroller.MachineForceDeleteAnnotation
+)
+
 // Handle handles HTTP requests for admission webhook servers.
 func (h *machineValidatorHandler) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
 	m, ok := obj.(*machinev1beta1.Machine)
@@ -537,12 +733,16 @@ func (h *machineValidatorHandler) ValidateDelete(ctx context.Context, obj runtim
 
 	klog.V(3).Infof("Validate webhook called for Machine: %s", m.GetName())
 
-	ok, warnings, errs := h.validateMachine(m, nil)
-	if !ok {
-		return warnings, errs.ToAggregate()
+	// The spec of a Machine being deleted is not validated, an invalid Machine must remain deletable
+	if machinecontroller.IsDeletionProtected(m) {
+		errs := field.ErrorList{field.Forbidden(
+			field.NewPath("metadata", "annotations").Key(MachineProtectedAnnotation),
+			fmt.Sprintf("Machine is protected from deletion, set the %s annotation to \"true\" to delete it", MachineForceDeleteAnnotation),
+		)}
+		return nil, errs.ToAggregate()
 	}
 
-	return warnings, nil
+	return nil, nil
 }
 
 // Handle handles HTTP requests for admission webhook servers.
@@ -554,22 +754,49 @@ func (h *machineDefaulterHandler) Default(ctx context.Context, obj runtime.Objec
 
 	klog.V(3).Infof("Mutate webhook called for Machine: %s", m.GetName())
 
+	// Encoded providerSpecs are also inflated on update, the machine controllers can't read them,
+	// and legacy API groups are rewritten. The Machine is otherwise only defaulted when it is created.
+	if isUpdateRequest(ctx) {
+		if err := inflateProviderSpec(m); err != nil {
+			return field.ErrorList{err}.ToAggregate()
+		}
+		normalizeProviderSpecGroup(m, h.platformStatus)
+		return nil
+	}
+
 	// Only enforce the clusterID if it's not set.
 	// Otherwise a discrepancy on the value would leave the machine orphan
 	// and would trigger a new machine creation by the machineSet.
 	// https://bugzilla.redhat.com/show_bug.cgi?id=1857175
+	var defaulted []defaultedField
 	if m.Labels == nil {
 		m.Labels = make(map[string]string)
 	}
 	if _, ok := m.Labels[machinev1beta1.MachineClusterIDLabel]; !ok {
 		m.Labels[machinev1beta1.MachineClusterIDLabel] = h.clusterID
+		defaulted = append(defaulted, defaultedField{
+			Path:  field.NewPath("metadata", "labels").Key(machinev1beta1.MachineClusterIDLabel).String(),
+			Value: h.clusterID,
+		})
+	}
+
+	if err := inflateProviderSpec(m); err != nil {
+		return field.ErrorList{err}.ToAggregate()
 	}
+	normalizeProviderSpecGroup(m, h.platformStatus)
+
+	providerSpecBefore := providerSpecFields(m)
+	mergeClusterResourceTags(m, h.platformStatus)
+	defaultAWSClusterTag(m, h.platformStatus, h.clusterID)
 
 	ok, _, errs := h.webhookOperations(m, h.admissionConfig)
 	if !ok {
 		return errs.ToAggregate()
 	}
 
+	defaulted = append(defaulted, diffDefaultedFields(field.NewPath("spec", "providerSpec", "value"), providerSpecBefore, providerSpecFields(m))...)
+	setDefaultedFieldsAnnotation(m, defaulted)
+
 	return nil
 }
 
@@ -589,7 +816,7 @@ func (a awsDefaulter) defaultAWS(m *machinev1beta1.Machine, config *admissionCon
 	}
 
 	if providerSpec.InstanceType == "" {
-		providerSpec.InstanceType = defaultInstanceTypeForCloudProvider(osconfigv1.AWSPlatformType, arch, &warnings)
+		providerSpec.InstanceType = defaultInstanceTypeForCloudProvider(osconfigv1.AWSPlatformType, machineArchitecture(m), &warnings)
 	}
 
 	if providerSpec.InstanceType == "" {
@@ -623,30 +850,36 @@ func (a awsDefaulter) defaultAWS(m *machinev1beta1.Machine, config *admissionCon
 	return true, warnings, nil
 }
 
-func unmarshalInto(m *machinev1beta1.Machine, providerSpec interface{}) *field.Error {
-	if m.Spec.ProviderSpec.Value == nil {
+func unmarshalInto[T any](m *machinev1beta1.Machine, providerSpec *T) *field.Error {
+	decoded, _, err := util.DecodeProviderSpec[T](m.Spec.ProviderSpec.Value, false)
+	if errors.Is(err, util.ErrProviderSpecNotSet) {
 		return field.Required(field.NewPath("providerSpec", "value"), "a value must be provided")
 	}
-
-	if err := yaml.Unmarshal(m.Spec.ProviderSpec.Value.Raw, &providerSpec); err != nil {
+	if err != nil {
 		return field.Invalid(field.NewPath("providerSpec", "value"), providerSpec, err.Error())
 	}
+	*providerSpec = *decoded
 	return nil
 }
 
-func validateUnknownFields(m *machinev1beta1.Machine, providerSpec interface{}) error {
-	if err := yaml.Unmarshal(m.Spec.ProviderSpec.Value.Raw, &providerSpec, yaml.DisallowUnknownFields); err != nil {
-		if strings.Contains(err.Error(), "unknown field") {
-			unknownField := strings.Replace(strings.Split(err.Error(), "unknown field ")[1], "\"", "", -1)
-			return &field.Error{
-				Type:     field.ErrorTypeNotSupported,
-				Field:    field.NewPath("providerSpec", "value").String(),
-				BadValue: unknownField,
-				Detail:   fmt.Sprintf("Unknown field (%s) will be ignored", unknownField),
-			}
+// validateUnknownFields returns a warning for each field of the providerSpec which is not part of the provider config.
+func validateUnknownFields[T any](m *machinev1beta1.Machine, _ *T) []string {
+	_, unknownFields, err := util.DecodeProviderSpec[T](m.Spec.ProviderSpec.Value, true)
+	if err != nil {
+		return nil
+	}
+
+	var warnings []string
+	for _, unknownField := range unknownFields {
+		fieldErr := &field.Error{
+			Type:     field.ErrorTypeNotSupported,
+			Field:    field.NewPath("providerSpec", "value").String(),
+			BadValue: unknownField,
+			Detail:   fmt.Sprintf("Unknown field (%s) will be ignored", unknownField),
 		}
+		warnings = append(warnings, fieldErr.Error())
 	}
-	return nil
+	return warnings
 }
 
 func validateAWS(m *machinev1beta1.Machine, config *admissionConfig) (bool, []string, field.ErrorList) {
@@ -660,13 +893,14 @@ func validateAWS(m *machinev1beta1.Machine, config *admissionConfig) (bool, []st
 		return false, warnings, errs
 	}
 
-	if err := validateUnknownFields(m, providerSpec); err != nil {
-		warnings = append(warnings, err.Error())
-	}
+	warnings = append(warnings, validateUnknownFields(m, providerSpec)...)
 
 	if !validateGVK(providerSpec.GroupVersionKind(), osconfigv1.AWSPlatformType) {
 		warnings = append(warnings, fmt.Sprintf("incorrect GroupVersionKind for AWSMachineProviderConfig object: %s", providerSpec.GroupVersionKind()))
 	}
+	if warning := deprecatedGroupWarning(providerSpec.GroupVersionKind(), osconfigv1.AWSPlatformType); warning != "" {
+		warnings = append(warnings, warning)
+	}
 
 	if providerSpec.AMI.ID == nil {
 		errs = append(
@@ -700,6 +934,15 @@ func validateAWS(m *machinev1beta1.Machine, config *admissionConfig) (bool, []st
 				"expected providerSpec.placement.region to be populated",
 			),
 		)
+	} else if providerSpec.Placement.AvailabilityZone != "" && !strings.HasPrefix(providerSpec.Placement.AvailabilityZone, providerSpec.Placement.Region) {
+		errs = append(
+			errs,
+			field.Invalid(
+				field.NewPath("providerSpec", "placement", "availabilityZone"),
+				providerSpec.Placement.AvailabilityZone,
+				fmt.Sprintf("availability zone is not within region %q", providerSpec.Placement.Region),
+			),
+		)
 	}
 
 	if providerSpec.InstanceType == "" {
@@ -720,6 +963,8 @@ func validateAWS(m *machinev1beta1.Machine, config *admissionConfig) (bool, []st
 				"expected providerSpec.userDataSecret to be populated",
 			),
 		)
+	} else if providerSpec.UserDataSecret.Name != "" {
+		warnings = append(warnings, userDataSecretWarnings(config.client, providerSpec.UserDataSecret.Name, m.GetNamespace())...)
 	}
 
 	if providerSpec.CredentialsSecret == nil {
@@ -731,7 +976,7 @@ func validateAWS(m *machinev1beta1.Machine, config *admissionConfig) (bool, []st
 			),
 		)
 	} else {
-		warnings = append(warnings, credentialsSecretExists(config.client, providerSpec.CredentialsSecret.Name, m.GetNamespace())...)
+		warnings = append(warnings, credentialsSecretWarnings(config.client, providerSpec.CredentialsSecret.Name, m.GetNamespace(), awsCredentialsSecretKeys)...)
 	}
 
 	if providerSpec.Subnet.ARN == nil && providerSpec.Subnet.ID == nil && providerSpec.Subnet.Filters == nil {
@@ -751,6 +996,12 @@ func validateAWS(m *machinev1beta1.Machine, config *admissionConfig) (bool, []st
 	if providerSpec.IAMInstanceProfile == nil {
 		warnings = append(warnings, "providerSpec.iamInstanceProfile: no IAM instance profile provided: nodes may be unable to join the cluster")
 	} else {
+		if identityFields := awsResourceReferenceFields(providerSpec.IAMInstanceProfile); len(identityFields) > 1 {
+			warnings = append(
+				warnings,
+				fmt.Sprintf("providerSpec.iamInstanceProfile: multiple ways of referencing the IAM instance profile are set (%s): only providerSpec.iamInstanceProfile.id is used", strings.Join(identityFields, ", ")),
+			)
+		}
 
 		if providerSpec.IAMInstanceProfile.ARN != nil {
 			warnings = append(
@@ -765,11 +1016,30 @@ func validateAWS(m *machinev1beta1.Machine, config *admissionConfig) (bool, []st
 				"can't use providerSpec.iamInstanceProfile.filters, only providerSpec.iamInstanceProfile.id can be used to reference IAMInstanceProfile",
 			)
 		}
+
+		if providerSpec.IAMInstanceProfile.ID != nil {
+			warnings = append(warnings, instanceIdentityWarnings(
+				field.NewPath("providerSpec", "iamInstanceProfile", "id"),
+				*providerSpec.IAMInstanceProfile.ID,
+				awsIAMInstanceProfilePattern,
+				"an IAM instance profile name of up to 128 alphanumeric or '+=,.@-_' characters",
+			)...)
+		}
 	}
 
 	if providerSpec.CapacityReservationID != "" {
 		if err := validateAwsCapacityReservationId(providerSpec.CapacityReservationID); err != nil {
 			errs = append(errs, field.Invalid(field.NewPath("providerSpec", "capacityReservationId"), providerSpec.CapacityReservationID, err.Error()))
+		} else {
+			// Capacity reservations are bound to an availability zone, which can't be looked up from here.
+			if providerSpec.Subnet.ID != nil {
+				warnings = append(warnings, fmt.Sprintf("providerSpec.capacityReservationId: capacity reservation %s must be in the same availability zone as subnet %s, instances will fail to launch otherwise",
+					providerSpec.CapacityReservationID, *providerSpec.Subnet.ID))
+			}
+			if providerSpec.Placement.AvailabilityZone != "" {
+				warnings = append(warnings, fmt.Sprintf("providerSpec.capacityReservationId: capacity reservation %s must be in availability zone %s, instances will fail to launch otherwise",
+					providerSpec.CapacityReservationID, providerSpec.Placement.AvailabilityZone))
+			}
 		}
 	}
 
@@ -820,6 +1090,7 @@ func validateAWS(m *machinev1beta1.Machine, config *admissionConfig) (bool, []st
 	if len(duplicatedTags) > 0 {
 		warnings = append(warnings, fmt.Sprintf("providerSpec.tags: duplicated tag names (%s): only the first value will be used.", strings.Join(duplicatedTags, ",")))
 	}
+	warnings = append(warnings, awsClusterTagWarnings(providerSpec.Tags, config.clusterID)...)
 
 	switch providerSpec.NetworkInterfaceType {
 	case "", machinev1beta1.AWSENANetworkInterfaceType, machinev1beta1.AWSEFANetworkInterfaceType:
@@ -885,7 +1156,7 @@ func defaultAzure(m *machinev1beta1.Machine, config *admissionConfig) (bool, []s
 	}
 
 	if providerSpec.VMSize == "" {
-		providerSpec.VMSize = defaultInstanceTypeForCloudProvider(osconfigv1.AzurePlatformType, arch, &warnings)
+		providerSpec.VMSize = defaultInstanceTypeForCloudProvider(osconfigv1.AzurePlatformType, machineArchitecture(m), &warnings)
 	}
 
 	if providerSpec.VMSize == "" {
@@ -901,7 +1172,7 @@ func defaultAzure(m *machinev1beta1.Machine, config *admissionConfig) (bool, []s
 	}
 
 	if providerSpec.Image == (machinev1beta1.Image{}) {
-		providerSpec.Image.ResourceID = defaultAzureImageResourceID(config.clusterID)
+		providerSpec.Image.ResourceID = defaultAzureImageResourceID(config.clusterID, machineArchitecture(m))
 	}
 
 	if providerSpec.UserDataSecret == nil {
@@ -945,13 +1216,14 @@ func validateAzure(m *machinev1beta1.Machine, config *admissionConfig) (bool, []
 		return false, warnings, errs
 	}
 
-	if err := validateUnknownFields(m, providerSpec); err != nil {
-		warnings = append(warnings, err.Error())
-	}
+	warnings = append(warnings, validateUnknownFields(m, providerSpec)...)
 
 	if !validateGVK(providerSpec.GroupVersionKind(), osconfigv1.AzurePlatformType) {
 		warnings = append(warnings, fmt.Sprintf("incorrect GroupVersionKind for AzureMachineProviderSpec object: %s", providerSpec.GroupVersionKind()))
 	}
+	if warning := deprecatedGroupWarning(providerSpec.GroupVersionKind(), osconfigv1.AzurePlatformType); warning != "" {
+		warnings = append(warnings, warning)
+	}
 
 	if providerSpec.VMSize == "" {
 		errs = append(errs, field.Required(field.NewPath("providerSpec", "vmSize"), "vmSize should be set to one of the supported Azure VM sizes"))
@@ -970,12 +1242,27 @@ func validateAzure(m *machinev1beta1.Machine, config *admissionConfig) (bool, []
 		errs = append(errs, field.Required(field.NewPath("providerSpec", "vnet"), "must provide a virtual network when supplying subnets"))
 	}
 
+	// Azure does not allow a virtual machine to be both in an availability set and an availability zone
+	if providerSpec.AvailabilitySet != "" && providerSpec.Zone != "" {
+		errs = append(errs, field.Forbidden(field.NewPath("providerSpec", "availabilitySet"), "availabilitySet cannot be set when a zone is specified"))
+	}
+
+	if providerSpec.AvailabilitySet == "" && providerSpec.Zone == "" && azureRegionsWithZones.Has(strings.ToLower(providerSpec.Location)) {
+		warnings = append(warnings, fmt.Sprintf("providerSpec.zone: neither zone nor availabilitySet is set, machines in region %s will not be distributed across availability zones", providerSpec.Location))
+	}
+
 	errs = append(errs, validateAzureImage(providerSpec.Image)...)
 
 	if providerSpec.UserDataSecret == nil {
 		errs = append(errs, field.Required(field.NewPath("providerSpec", "userDataSecret"), "userDataSecret must be provided"))
 	} else if providerSpec.UserDataSecret.Name == "" {
 		errs = append(errs, field.Required(field.NewPath("providerSpec", "userDataSecret", "name"), "name must be provided"))
+	} else {
+		namespace := providerSpec.UserDataSecret.Namespace
+		if namespace == "" {
+			namespace = m.GetNamespace()
+		}
+		warnings = append(warnings, userDataSecretWarnings(config.client, providerSpec.UserDataSecret.Name, namespace)...)
 	}
 
 	if providerSpec.CredentialsSecret == nil {
@@ -988,10 +1275,19 @@ func validateAzure(m *machinev1beta1.Machine, config *admissionConfig) (bool, []
 			errs = append(errs, field.Required(field.NewPath("providerSpec", "credentialsSecret", "name"), "name must be provided"))
 		}
 		if providerSpec.CredentialsSecret.Name != "" && providerSpec.CredentialsSecret.Namespace != "" {
-			warnings = append(warnings, credentialsSecretExists(config.client, providerSpec.CredentialsSecret.Name, providerSpec.CredentialsSecret.Namespace)...)
+			warnings = append(warnings, credentialsSecretWarnings(config.client, providerSpec.CredentialsSecret.Name, providerSpec.CredentialsSecret.Namespace, azureCredentialsSecretKeys)...)
 		}
 	}
 
+	if providerSpec.ManagedIdentity != "" {
+		warnings = append(warnings, instanceIdentityWarnings(
+			field.NewPath("providerSpec", "managedIdentity"),
+			providerSpec.ManagedIdentity,
+			azureManagedIdentityPattern,
+			"the name or resource ID of a user assigned managed identity",
+		)...)
+	}
+
 	if providerSpec.OSDisk.DiskSizeGB <= 0 || providerSpec.OSDisk.DiskSizeGB >= azureMaxDiskSizeGB {
 		errs = append(errs, field.Invalid(field.NewPath("providerSpec", "osDisk", "diskSizeGB"), providerSpec.OSDisk.DiskSizeGB, "diskSizeGB must be greater than zero and less than 32768"))
 	}
@@ -1034,6 +1330,8 @@ func validateAzure(m *machinev1beta1.Machine, config *admissionConfig) (bool, []
 
 	errs = append(errs, validateAzureDiagnostics(providerSpec.Diagnostics, field.NewPath("providerSpec", "diagnostics"))...)
 
+	errs = append(errs, validateAzureSpotVMOptions(providerSpec.SpotVMOptions, field.NewPath("providerSpec", "spotVMOptions"))...)
+
 	if isAzureGovCloud(config.platformStatus) && providerSpec.SpotVMOptions != nil {
 		warnings = append(warnings, "spot VMs may not be supported when using GovCloud region")
 	}
@@ -1101,6 +1399,23 @@ func validateAzureDiagnostics(diagnosticsSpec machinev1beta1.AzureDiagnostics, p
 	return errs
 }
 
+// validateAzureSpotVMOptions checks that the maxPrice of Spot VMs is either unset or -1, both paying up to
+// the on-demand price, or a positive price.
+func validateAzureSpotVMOptions(spotVMOptions *machinev1beta1.SpotVMOptions, parentPath *field.Path) field.ErrorList {
+	if spotVMOptions == nil || spotVMOptions.MaxPrice == nil {
+		return nil
+	}
+
+	maxPrice := spotVMOptions.MaxPrice
+	if maxPrice.Sign() > 0 || maxPrice.Cmp(resource.MustParse("-1")) == 0 {
+		return nil
+	}
+
+	return field.ErrorList{
+		field.Invalid(parentPath.Child("maxPrice"), maxPrice.String(), "maxPrice must be either -1, to pay up to the on-demand price, or a positive price"),
+	}
+}
+
 func defaultGCP(m *machinev1beta1.Machine, config *admissionConfig) (bool, []string, field.ErrorList) {
 	klog.V(3).Infof("Defaulting GCP providerSpec")
 
@@ -1113,7 +1428,7 @@ func defaultGCP(m *machinev1beta1.Machine, config *admissionConfig) (bool, []str
 	}
 
 	if providerSpec.MachineType == "" {
-		providerSpec.MachineType = defaultInstanceTypeForCloudProvider(osconfigv1.GCPPlatformType, arch, &warnings)
+		providerSpec.MachineType = defaultInstanceTypeForCloudProvider(osconfigv1.GCPPlatformType, machineArchitecture(m), &warnings)
 	}
 
 	if providerSpec.MachineType == "" {
@@ -1129,7 +1444,7 @@ func defaultGCP(m *machinev1beta1.Machine, config *admissionConfig) (bool, []str
 		})
 	}
 
-	providerSpec.Disks = defaultGCPDisks(providerSpec.Disks, config.clusterID)
+	providerSpec.Disks = defaultGCPDisks(providerSpec.Disks, config.clusterID, machineArchitecture(m))
 
 	if len(providerSpec.GPUs) != 0 {
 		// In case Count was not set it should default to 1, since there is no valid reason for it to be purposely set to 0.
@@ -1163,7 +1478,7 @@ func defaultGCP(m *machinev1beta1.Machine, config *admissionConfig) (bool, []str
 	return true, warnings, nil
 }
 
-func defaultGCPDisks(disks []*machinev1beta1.GCPDisk, clusterID string) []*machinev1beta1.GCPDisk {
+func defaultGCPDisks(disks []*machinev1beta1.GCPDisk, clusterID string, arch machineArch) []*machinev1beta1.GCPDisk {
 	if len(disks) == 0 {
 		return []*machinev1beta1.GCPDisk{
 			{
@@ -1171,7 +1486,7 @@ func defaultGCPDisks(disks []*machinev1beta1.GCPDisk, clusterID string) []*machi
 				Boot:       true,
 				SizeGB:     defaultGCPDiskSizeGb,
 				Type:       defaultGCPDiskType,
-				Image:      defaultGCPDiskImage(),
+				Image:      defaultGCPDiskImage(arch),
 			},
 		}
 	}
@@ -1182,7 +1497,7 @@ func defaultGCPDisks(disks []*machinev1beta1.GCPDisk, clusterID string) []*machi
 		}
 
 		if disk.Image == "" {
-			disk.Image = defaultGCPDiskImage()
+			disk.Image = defaultGCPDiskImage(arch)
 		}
 	}
 
@@ -1200,13 +1515,14 @@ func validateGCP(m *machinev1beta1.Machine, config *admissionConfig) (bool, []st
 		return false, warnings, errs
 	}
 
-	if err := validateUnknownFields(m, providerSpec); err != nil {
-		warnings = append(warnings, err.Error())
-	}
+	warnings = append(warnings, validateUnknownFields(m, providerSpec)...)
 
 	if !validateGVK(providerSpec.GroupVersionKind(), osconfigv1.GCPPlatformType) {
 		warnings = append(warnings, fmt.Sprintf("incorrect GroupVersionKind for GCPMachineProviderSpec object: %s", providerSpec.GroupVersionKind()))
 	}
+	if warning := deprecatedGroupWarning(providerSpec.GroupVersionKind(), osconfigv1.GCPPlatformType); warning != "" {
+		warnings = append(warnings, warning)
+	}
 
 	if providerSpec.Region == "" {
 		errs = append(errs, field.Required(field.NewPath("providerSpec", "region"), "region is required"))
@@ -1239,13 +1555,27 @@ func validateGCP(m *machinev1beta1.Machine, config *admissionConfig) (bool, []st
 	}
 
 	errs = append(errs, validateGCPNetworkInterfaces(providerSpec.NetworkInterfaces, field.NewPath("providerSpec", "networkInterfaces"))...)
-	errs = append(errs, validateGCPDisks(providerSpec.Disks, field.NewPath("providerSpec", "disks"))...)
+	warnings = append(warnings, gcpSharedVPCSubnetworkWarnings(providerSpec, field.NewPath("providerSpec", "networkInterfaces"))...)
+	errs = append(errs, validateGCPDisks(providerSpec.Disks, field.NewPath("providerSpec", "disks"), providerSpec.MachineType)...)
+	warnings = append(warnings, gcpHyperdiskMachineSeriesWarnings(providerSpec.Disks, field.NewPath("providerSpec", "disks"), providerSpec.MachineType)...)
 	errs = append(errs, validateGCPGPUs(providerSpec.GPUs, field.NewPath("providerSpec", "gpus"), providerSpec.MachineType)...)
+	errs = append(errs, validateGCPLabels(providerSpec.Labels, field.NewPath("providerSpec", "labels"))...)
 
 	if len(providerSpec.ServiceAccounts) == 0 {
 		warnings = append(warnings, "providerSpec.serviceAccounts: no service account provided: nodes may be unable to join the cluster")
 	} else {
 		errs = append(errs, validateGCPServiceAccounts(providerSpec.ServiceAccounts, field.NewPath("providerSpec", "serviceAccounts"))...)
+
+		for i, serviceAccount := range providerSpec.ServiceAccounts {
+			if serviceAccount.Email != "" {
+				warnings = append(warnings, instanceIdentityWarnings(
+					field.NewPath("providerSpec", "serviceAccounts").Index(i).Child("email"),
+					serviceAccount.Email,
+					gcpServiceAccountEmailPattern,
+					"the email of a service account",
+				)...)
+			}
+		}
 	}
 
 	if providerSpec.UserDataSecret == nil {
@@ -1253,6 +1583,8 @@ func validateGCP(m *machinev1beta1.Machine, config *admissionConfig) (bool, []st
 	} else {
 		if providerSpec.UserDataSecret.Name == "" {
 			errs = append(errs, field.Required(field.NewPath("providerSpec", "userDataSecret", "name"), "name must be provided"))
+		} else {
+			warnings = append(warnings, userDataSecretWarnings(config.client, providerSpec.UserDataSecret.Name, m.GetNamespace())...)
 		}
 	}
 
@@ -1262,7 +1594,7 @@ func validateGCP(m *machinev1beta1.Machine, config *admissionConfig) (bool, []st
 		if providerSpec.CredentialsSecret.Name == "" {
 			errs = append(errs, field.Required(field.NewPath("providerSpec", "credentialsSecret", "name"), "name must be provided"))
 		} else {
-			warnings = append(warnings, credentialsSecretExists(config.client, providerSpec.CredentialsSecret.Name, m.GetNamespace())...)
+			warnings = append(warnings, credentialsSecretWarnings(config.client, providerSpec.CredentialsSecret.Name, m.GetNamespace(), gcpCredentialsSecretKeys)...)
 		}
 	}
 
@@ -1322,6 +1654,12 @@ func validateGCPConfidentialComputing(providerSpec *machinev1beta1.GCPMachinePro
 				fmt.Sprintf("ConfidentialCompute require machine type in the following series: %s", strings.Join(gcpConfidentialComputeSupportedMachineSeries, `,`))),
 			)
 		}
+		// Confidential VMs are rejected by GCP when the virtual TPM is explicitly disabled
+		if providerSpec.ShieldedInstanceConfig.VirtualizedTrustedPlatformModule == machinev1beta1.VirtualizedTrustedPlatformModulePolicyDisabled {
+			errs = append(errs, field.Invalid(field.NewPath("providerSpec", "shieldedInstanceConfig", "virtualizedTrustedPlatformModule"),
+				providerSpec.ShieldedInstanceConfig.VirtualizedTrustedPlatformModule,
+				fmt.Sprintf("ConfidentialCompute require virtualizedTrustedPlatformModule to be either %s or unset", machinev1beta1.VirtualizedTrustedPlatformModulePolicyEnabled)))
+		}
 	case machinev1beta1.ConfidentialComputePolicyDisabled, "":
 	default:
 		errs = append(errs, field.Invalid(field.NewPath("providerSpec", "confidentialCompute"),
@@ -1353,7 +1691,31 @@ func validateGCPNetworkInterfaces(networkInterfaces []*machinev1beta1.GCPNetwork
 	return errs
 }
 
-func validateGCPDisks(disks []*machinev1beta1.GCPDisk, parentPath *field.Path) field.ErrorList {
+// gcpSharedVPCSubnetworkWarnings returns a warning for each network interface in a shared VPC, that is
+// with a projectID other than the one of the instance, whose subnetwork is not fully qualified with
+// that host project. An unqualified subnetwork may resolve in the wrong VPC.
+func gcpSharedVPCSubnetworkWarnings(providerSpec *machinev1beta1.GCPMachineProviderSpec, parentPath *field.Path) []string {
+	var warnings []string
+	for i, ni := range providerSpec.NetworkInterfaces {
+		if ni == nil || ni.Subnetwork == "" || ni.ProjectID == "" || ni.ProjectID == providerSpec.ProjectID {
+			continue
+		}
+
+		fldPath := parentPath.Index(i).Child("subnetwork")
+		match := gcpQualifiedSubnetworkPattern.FindStringSubmatch(ni.Subnetwork)
+		switch {
+		case match == nil:
+			warnings = append(warnings, field.Invalid(fldPath, ni.Subnetwork,
+				fmt.Sprintf("subnetwork should be fully qualified as projects/%s/regions/<region>/subnetworks/<name> in the shared VPC host project", ni.ProjectID)).Error())
+		case match[1] != ni.ProjectID:
+			warnings = append(warnings, field.Invalid(fldPath, ni.Subnetwork,
+				fmt.Sprintf("subnetwork belongs to project %s instead of the shared VPC host project %s", match[1], ni.ProjectID)).Error())
+		}
+	}
+	return warnings
+}
+
+func validateGCPDisks(disks []*machinev1beta1.GCPDisk, parentPath *field.Path, machineType string) field.ErrorList {
 	if len(disks) == 0 {
 		return field.ErrorList{field.Required(parentPath, "at least 1 disk is required")}
 	}
@@ -1375,12 +1737,40 @@ func validateGCPDisks(disks []*machinev1beta1.GCPDisk, parentPath *field.Path) f
 			if !diskTypes.Has(disk.Type) {
 				errs = append(errs, field.NotSupported(fldPath.Child("type"), disk.Type, diskTypes.List()))
 			}
+
+			machineSeries := strings.Split(machineType, "-")[0]
+			if strings.HasPrefix(disk.Type, "hyperdisk-") && slices.Contains(gcpHyperdiskUnsupportedMachineSeries, machineSeries) {
+				errs = append(errs, field.Invalid(fldPath.Child("type"),
+					disk.Type,
+					fmt.Sprintf("%s disks require machine type in the following series: %s, the current machine type is: %s", disk.Type, strings.Join(gcpHyperdiskSupportedMachineSeries, `,`), machineType)),
+				)
+			}
 		}
 	}
 
 	return errs
 }
 
+// gcpHyperdiskMachineSeriesWarnings warns about hyperdisks on machine types of series neither known to support
+// them nor known not to. It does not reject them, so that the series GCP adds do not need a new release to be used.
+// Hyperdisks on the series known not to support them are rejected by validateGCPDisks.
+func gcpHyperdiskMachineSeriesWarnings(disks []*machinev1beta1.GCPDisk, parentPath *field.Path, machineType string) []string {
+	machineSeries := strings.Split(machineType, "-")[0]
+	if machineType == "" || slices.Contains(gcpHyperdiskSupportedMachineSeries, machineSeries) || slices.Contains(gcpHyperdiskUnsupportedMachineSeries, machineSeries) {
+		return nil
+	}
+
+	var warnings []string
+	for i, disk := range disks {
+		if disk == nil || !strings.HasPrefix(disk.Type, "hyperdisk-") {
+			continue
+		}
+		warnings = append(warnings, field.Invalid(parentPath.Index(i).Child("type"), disk.Type,
+			fmt.Sprintf("%s disks may not be supported by machine type %s, they are known to be supported by the following series: %s", disk.Type, machineType, strings.Join(gcpHyperdiskSupportedMachineSeries, `,`))).Error())
+	}
+	return warnings
+}
+
 func validateGCPGPUs(guestAccelerators []machinev1beta1.GCPGPUConfig, parentPath *field.Path, machineType string) field.ErrorList {
 	var errs field.ErrorList
 
@@ -1404,6 +1794,40 @@ func validateGCPGPUs(guestAccelerators []machinev1beta1.GCPGPUConfig, parentPath
 	return errs
 }
 
+func validateGCPLabels(labels map[string]string, parentPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	if len(labels) > gcpMaxLabels {
+		errs = append(errs, field.TooMany(parentPath, len(labels), gcpMaxLabels))
+	}
+
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fldPath := parentPath.Key(key)
+		value := labels[key]
+
+		switch {
+		case key == "":
+			errs = append(errs, field.Invalid(fldPath, key, "label keys must not be empty"))
+		case len(key) > gcpMaxLabelLength:
+			errs = append(errs, field.Invalid(fldPath, key, fmt.Sprintf("label keys must be at most %d characters", gcpMaxLabelLength)))
+		case strings.ToLower(key) != key:
+			errs = append(errs, field.Invalid(fldPath, key, "label keys must be lowercase"))
+		}
+
+		if len(value) > gcpMaxLabelLength {
+			errs = append(errs, field.Invalid(fldPath, value, fmt.Sprintf("label values must be at most %d characters", gcpMaxLabelLength)))
+		}
+	}
+
+	return errs
+}
+
 func validateGCPServiceAccounts(serviceAccounts []machinev1beta1.GCPServiceAccount, parentPath *field.Path) field.ErrorList {
 	if len(serviceAccounts) != 1 {
 		return field.ErrorList{field.Invalid(parentPath, fmt.Sprintf("%d service accounts supplied", len(serviceAccounts)), "exactly 1 service account must be supplied")}
@@ -1467,13 +1891,14 @@ func validateVSphere(m *machinev1beta1.Machine, config *admissionConfig) (bool,
 		return false, warnings, errs
 	}
 
-	if err := validateUnknownFields(m, providerSpec); err != nil {
-		warnings = append(warnings, err.Error())
-	}
+	warnings = append(warnings, validateUnknownFields(m, providerSpec)...)
 
 	if !validateGVK(providerSpec.GroupVersionKind(), osconfigv1.VSpherePlatformType) {
 		warnings = append(warnings, fmt.Sprintf("incorrect GroupVersionKind for VSphereMachineProviderSpec object: %s", providerSpec.GroupVersionKind()))
 	}
+	if warning := deprecatedGroupWarning(providerSpec.GroupVersionKind(), osconfigv1.VSpherePlatformType); warning != "" {
+		warnings = append(warnings, warning)
+	}
 
 	if providerSpec.Template == "" {
 		errs = append(errs, field.Required(field.NewPath("providerSpec", "template"), "template must be provided"))
@@ -1503,6 +1928,8 @@ func validateVSphere(m *machinev1beta1.Machine, config *admissionConfig) (bool,
 	} else {
 		if providerSpec.UserDataSecret.Name == "" {
 			errs = append(errs, field.Required(field.NewPath("providerSpec", "userDataSecret", "name"), "name must be provided"))
+		} else {
+			warnings = append(warnings, userDataSecretWarnings(config.client, providerSpec.UserDataSecret.Name, m.GetNamespace())...)
 		}
 	}
 
@@ -1516,13 +1943,19 @@ func validateVSphere(m *machinev1beta1.Machine, config *admissionConfig) (bool,
 		}
 	}
 
+	// Host VM group zonal placement needs the zone of the machine, which is given by its tags
+	if config.featureGates.Enabled(featuregate.Feature(apifeatures.FeatureGateVSphereHostVMGroupZonal)) &&
+		providerSpec.Workspace != nil && providerSpec.Workspace.VMGroup != "" && len(providerSpec.TagIDs) == 0 {
+		errs = append(errs, field.Required(field.NewPath("providerSpec", "tagIDs"), "at least one zone tag ID must be provided when workspace.vmGroup is set"))
+	}
+
 	if providerSpec.CredentialsSecret == nil {
 		errs = append(errs, field.Required(field.NewPath("providerSpec", "credentialsSecret"), "credentialsSecret must be provided"))
 	} else {
 		if providerSpec.CredentialsSecret.Name == "" {
 			errs = append(errs, field.Required(field.NewPath("providerSpec", "credentialsSecret", "name"), "name must be provided"))
 		} else {
-			warnings = append(warnings, credentialsSecretExists(config.client, providerSpec.CredentialsSecret.Name, m.GetNamespace())...)
+			warnings = append(warnings, credentialsSecretWarnings(config.client, providerSpec.CredentialsSecret.Name, m.GetNamespace(), vSphereCredentialsSecretKeys(providerSpec.Workspace))...)
 		}
 	}
 
@@ -1665,9 +2098,7 @@ func validateNutanix(m *machinev1beta1.Machine, config *admissionConfig) (bool,
 		return false, warnings, errs
 	}
 
-	if err := validateUnknownFields(m, providerSpec); err != nil {
-		warnings = append(warnings, err.Error())
-	}
+	warnings = append(warnings, validateUnknownFields(m, providerSpec)...)
 
 	if err := validateNutanixResourceIdentifier("cluster", providerSpec.Cluster); err != nil {
 		errs = append(errs, err)
@@ -1678,6 +2109,11 @@ func validateNutanix(m *machinev1beta1.Machine, config *admissionConfig) (bool,
 
 	numSubnets := len(providerSpec.Subnets)
 	switch {
+	case numSubnets == 0 && providerSpec.FailureDomain != nil:
+		// The subnets may be provided by the failure domain instead
+		if err := validateNutanixFailureDomainSubnets(providerSpec.FailureDomain.Name, config.nutanixFailureDomains); err != nil {
+			errs = append(errs, err)
+		}
 	case numSubnets == 0:
 		subnets, _ := json.Marshal(providerSpec.Subnets)
 		errs = append(errs, field.Invalid(field.NewPath("providerSpec", "subnets"), string(subnets), "missing subnets: nodes may fail to start if no subnets are configured"))
@@ -1733,6 +2169,8 @@ func validateNutanix(m *machinev1beta1.Machine, config *admissionConfig) (bool,
 	} else {
 		if providerSpec.UserDataSecret.Name == "" {
 			errs = append(errs, field.Required(field.NewPath("providerSpec", "userDataSecret", "name"), "name must be provided"))
+		} else {
+			warnings = append(warnings, userDataSecretWarnings(config.client, providerSpec.UserDataSecret.Name, m.GetNamespace())...)
 		}
 	}
 
@@ -1742,13 +2180,15 @@ func validateNutanix(m *machinev1beta1.Machine, config *admissionConfig) (bool,
 		if providerSpec.CredentialsSecret.Name == "" {
 			errs = append(errs, field.Required(field.NewPath("providerSpec", "credentialsSecret", "name"), "name must be provided"))
 		} else {
-			warnings = append(warnings, credentialsSecretExists(config.client, providerSpec.CredentialsSecret.Name, m.GetNamespace())...)
+			warnings = append(warnings, credentialsSecretWarnings(config.client, providerSpec.CredentialsSecret.Name, m.GetNamespace(), nutanixCredentialsSecretKeys)...)
 		}
 	}
 
 	// validate bootType
 	if err := validateNutanixBootType(providerSpec.BootType); err != nil {
 		errs = append(errs, err)
+	} else if providerSpec.BootType == machinev1.NutanixSecureBoot {
+		warnings = append(warnings, fmt.Sprintf("providerSpec.bootType: %s is not supported by all images: nodes may fail to boot if the image does not support Secure Boot", providerSpec.BootType))
 	}
 
 	// validate project if configured
@@ -1803,6 +2243,22 @@ func validateNutanix(m *machinev1beta1.Machine, config *admissionConfig) (bool,
 	return true, warnings, nil
 }
 
+// validateNutanixFailureDomainSubnets checks that the referenced failure domain exists and provides the subnets
+// of a machine which does not configure any.
+func validateNutanixFailureDomainSubnets(name string, failureDomains []osconfigv1.NutanixFailureDomain) *field.Error {
+	fldPath := field.NewPath("providerSpec", "failureDomain", "name")
+	for _, fd := range failureDomains {
+		if fd.Name != name {
+			continue
+		}
+		if len(fd.Subnets) == 0 {
+			return field.Invalid(fldPath, name, "missing subnets: no subnets are configured on the machine or on the failure domain, nodes may fail to start")
+		}
+		return nil
+	}
+	return field.Invalid(fldPath, name, "missing subnets: no subnets are configured on the machine and the failure domain is not defined in the cluster infrastructure, nodes may fail to start")
+}
+
 func validateNutanixDataDisks(disks []machinev1.NutanixVMDisk) (fldErrs []*field.Error) {
 	fldPath := field.NewPath("providerSpec", "dataDisks")
 	var errMsg string
@@ -1944,6 +2400,24 @@ func validateMachineLifecycleHooks(m, oldM *machinev1beta1.Machine) field.ErrorL
 	return errs
 }
 
+// validateAdoptProviderIDAnnotation rejects any change of the AdoptProviderIDAnnotation once the Machine is created,
+// so that the instance a Machine adopts, and deletes with it, is never swapped for another one.
+func validateAdoptProviderIDAnnotation(m, oldM *machinev1beta1.Machine) field.ErrorList {
+	if oldM == nil {
+		return nil
+	}
+
+	oldProviderID, oldSet := oldM.Annotations[machinecontroller.AdoptProviderIDAnnotation]
+	providerID, set := m.Annotations[machinecontroller.AdoptProviderIDAnnotation]
+	if oldSet == set && oldProviderID == providerID {
+		return nil
+	}
+	return field.ErrorList{field.Forbidden(
+		field.NewPath("metadata", "annotations").Key(machinecontroller.AdoptProviderIDAnnotation),
+		"the annotation is immutable, it can only be set when the Machine is created",
+	)}
+}
+
 func validateAzureSecurityProfile(machineName string, spec *machinev1beta1.AzureMachineProviderSpec, parentPath *field.Path) field.ErrorList {
 	var errs field.ErrorList
 
@@ -2029,6 +2503,10 @@ func validateAzureDataDisks(machineName string, spec *machinev1beta1.AzureMachin
 	// and can only contain letters, numbers, underscores, periods or hyphens.
 	reg := regexp.MustCompile(`^[a-zA-Z0-9](?:[\w\.-]*[a-zA-Z0-9])?$`)
 
+	if maxDataDisks, ok := instancetypes.MaxDataDisks(osconfigv1.AzurePlatformType, spec.VMSize); ok && len(spec.DataDisks) > maxDataDisks {
+		errs = append(errs, field.Invalid(parentPath, len(spec.DataDisks), fmt.Sprintf("data disk count must not exceed %d for vmSize %s", maxDataDisks, spec.VMSize)))
+	}
+
 	for i, disk := range spec.DataDisks {
 		fldPath := parentPath.Index(i)
 
@@ -2144,9 +2622,7 @@ func validatePowerVS(m *machinev1beta1.Machine, config *admissionConfig) (bool,
 		return false, warnings, errs
 	}
 
-	if err := validateUnknownFields(m, providerSpec); err != nil {
-		warnings = append(warnings, err.Error())
-	}
+	warnings = append(warnings, validateUnknownFields(m, providerSpec)...)
 
 	if providerSpec.KeyPairName == "" {
 		errs = append(errs, field.Required(field.NewPath("providerSpec", "keyPairName"), "providerSpec.keyPairName must be provided"))
@@ -2166,6 +2642,8 @@ func validatePowerVS(m *machinev1beta1.Machine, config *admissionConfig) (bool,
 	} else {
 		if providerSpec.UserDataSecret.Name == "" {
 			errs = append(errs, field.Required(field.NewPath("providerSpec", "userDataSecret", "name"), "providerSpec.userDataSecret.name must be provided"))
+		} else {
+			warnings = append(warnings, userDataSecretWarnings(config.client, providerSpec.UserDataSecret.Name, m.GetNamespace())...)
 		}
 	}
 
@@ -2175,7 +2653,7 @@ func validatePowerVS(m *machinev1beta1.Machine, config *admissionConfig) (bool,
 		if providerSpec.CredentialsSecret.Name == "" {
 			errs = append(errs, field.Required(field.NewPath("providerSpec", "credentialsSecret", "name"), "providerSpec.credentialsSecret.name must be provided"))
 		} else {
-			warnings = append(warnings, credentialsSecretExists(config.client, providerSpec.CredentialsSecret.Name, m.GetNamespace())...)
+			warnings = append(warnings, credentialsSecretWarnings(config.client, providerSpec.CredentialsSecret.Name, m.GetNamespace(), powerVSCredentialsSecretKeys)...)
 		}
 	}
 
@@ -2256,8 +2734,13 @@ func validateMachineConfigurations(providerSpec *machinev1.PowerVSMachineProvide
 		}
 		if processor < 0 {
 			errs = append(errs, field.Invalid(parentPath.Child("processor"), processor, "processor value cannot be negative"))
-		} else if providerSpec.ProcessorType == machinev1.PowerVSProcessorTypeDedicated && processor < val.minProcessorDedicated {
-			warnings = append(warnings, fmt.Sprintf("providerspec.Processor %f is less than the minimum value %f for providerSpec.ProcessorType: %s", processor, val.minProcessorDedicated, providerSpec.ProcessorType))
+		} else if providerSpec.ProcessorType == machinev1.PowerVSProcessorTypeDedicated {
+			// Dedicated processors are bound to whole physical cores, PowerVS rejects fractional values at create time
+			if processor != math.Trunc(processor) {
+				errs = append(errs, field.Invalid(parentPath.Child("processor"), processor, fmt.Sprintf("processor value must be a whole number for %s processorType", providerSpec.ProcessorType)))
+			} else if processor < val.minProcessorDedicated {
+				warnings = append(warnings, fmt.Sprintf("providerspec.Processor %f is less than the minimum value %f for providerSpec.ProcessorType: %s", processor, val.minProcessorDedicated, providerSpec.ProcessorType))
+			}
 		} else if processor < val.minProcessorSharedCapped {
 			warnings = append(warnings, fmt.Sprintf("providerspec.Processor %f is less than the minimum value %f for providerSpec.ProcessorType: %s", processor, val.minProcessorSharedCapped, providerSpec.ProcessorType))
 		}
@@ -2297,19 +2780,143 @@ func isFinalizerOnlyRemoval(m, oldM *machinev1beta1.Machine) (bool, error) {
 	return string(data) == `{"metadata":{"finalizers":null}}`, nil
 }
 
+// providerSpecKinds are the kinds of the providerSpecs of the platforms whose GroupVersionKind is validated.
+var providerSpecKinds = map[osconfigv1.PlatformType]string{
+	osconfigv1.AWSPlatformType:     "AWSMachineProviderConfig",
+	osconfigv1.AzurePlatformType:   "AzureMachineProviderSpec",
+	osconfigv1.GCPPlatformType:     "GCPMachineProviderSpec",
+	osconfigv1.VSpherePlatformType: "VSphereMachineProviderSpec",
+}
+
+// legacyProviderSpecGroups are the provider specific API groups of the providerSpecs which were
+// replaced by the machine.openshift.io group. They are still accepted but deprecated.
+var legacyProviderSpecGroups = map[osconfigv1.PlatformType]string{
+	osconfigv1.AWSPlatformType:     "awsproviderconfig.openshift.io",
+	osconfigv1.AzurePlatformType:   "azureproviderconfig.openshift.io",
+	osconfigv1.GCPPlatformType:     "gcpprovider.openshift.io",
+	osconfigv1.VSpherePlatformType: "vsphereprovider.openshift.io",
+}
+
 func validateGVK(gvk schema.GroupVersionKind, platform osconfigv1.PlatformType) bool {
-	switch platform {
-	case osconfigv1.AWSPlatformType:
-		return gvk.Kind == "AWSMachineProviderConfig" && (gvk.Group == "awsproviderconfig.openshift.io" || gvk.Group == "machine.openshift.io") && (gvk.Version == "v1beta1" || gvk.Version == "v1")
-	case osconfigv1.AzurePlatformType:
-		return gvk.Kind == "AzureMachineProviderSpec" && (gvk.Group == "azureproviderconfig.openshift.io" || gvk.Group == "machine.openshift.io") && (gvk.Version == "v1beta1" || gvk.Version == "v1")
-	case osconfigv1.GCPPlatformType:
-		return gvk.Kind == "GCPMachineProviderSpec" && (gvk.Group == "gcpprovider.openshift.io" || gvk.Group == "machine.openshift.io") && (gvk.Version == "v1beta1" || gvk.Version == "v1")
-	case osconfigv1.VSpherePlatformType:
-		return gvk.Kind == "VSphereMachineProviderSpec" && (gvk.Group == "vsphereprovider.openshift.io" || gvk.Group == "machine.openshift.io") && (gvk.Version == "v1beta1" || gvk.Version == "v1")
-	default:
+	kind, ok := providerSpecKinds[platform]
+	if !ok {
 		return true
 	}
+	return gvk.Kind == kind && (gvk.Group == legacyProviderSpecGroups[platform] || gvk.Group == machinev1beta1.GroupName) && (gvk.Version == "v1beta1" || gvk.Version == "v1")
+}
+
+// deprecatedGroupWarning returns a warning when the providerSpec uses the legacy API group of the platform.
+func deprecatedGroupWarning(gvk schema.GroupVersionKind, platform osconfigv1.PlatformType) string {
+	legacyGroup, ok := legacyProviderSpecGroups[platform]
+	if !ok || gvk.Group != legacyGroup {
+		return ""
+	}
+	return fmt.Sprintf("providerSpec.value.apiVersion: %s is deprecated, use %s instead", gvk.GroupVersion(), machinev1beta1.GroupVersion)
+}
+
+// deprecatedGroupWarningHandler adds the warning of deprecatedGroupWarning to the responses of a defaulting webhook.
+// The defaulting webhooks rewrite the legacy API groups, the validating webhooks never see them and can't warn.
+type deprecatedGroupWarningHandler struct {
+	admission.Handler
+	platform osconfigv1.PlatformType
+	// providerSpec returns the providerSpec of the raw admitted object.
+	providerSpec func(raw []byte) (*kruntime.RawExtension, error)
+}
+
+// Handle handles admission requests.
+func (h *deprecatedGroupWarningHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	resp := h.Handler.Handle(ctx, req)
+	if !resp.Allowed || req.Object.Raw == nil {
+		return resp
+	}
+
+	providerSpec, err := h.providerSpec(req.Object.Raw)
+	if err != nil || providerSpec == nil || providerSpec.Raw == nil {
+		return resp
+	}
+
+	raw, err := util.InflateProviderSpec(providerSpec.Raw)
+	if err != nil {
+		return resp
+	}
+
+	typeMeta := &metav1.TypeMeta{}
+	if err := json.Unmarshal(raw, typeMeta); err != nil {
+		return resp
+	}
+
+	if warning := deprecatedGroupWarning(typeMeta.GroupVersionKind(), h.platform); warning != "" {
+		resp.Warnings = append(resp.Warnings, warning)
+	}
+	return resp
+}
+
+// machineProviderSpec returns the providerSpec of a raw Machine.
+func machineProviderSpec(raw []byte) (*kruntime.RawExtension, error) {
+	m := &machinev1beta1.Machine{}
+	if err := json.Unmarshal(raw, m); err != nil {
+		return nil, err
+	}
+	return m.Spec.ProviderSpec.Value, nil
+}
+
+// machineSetProviderSpec returns the providerSpec of the Machine template of a raw MachineSet.
+func machineSetProviderSpec(raw []byte) (*kruntime.RawExtension, error) {
+	ms := &machinev1beta1.MachineSet{}
+	if err := json.Unmarshal(raw, ms); err != nil {
+		return nil, err
+	}
+	return ms.Spec.Template.Spec.ProviderSpec.Value, nil
+}
+
+// inflateProviderSpec replaces a base64 encoded, optionally gzipped, providerSpec by the plain JSON providerSpec
+// it holds, so that stored Machines can be read by the machine controllers. Plain providerSpecs are left untouched.
+func inflateProviderSpec(m *machinev1beta1.Machine) *field.Error {
+	if m.Spec.ProviderSpec.Value == nil || m.Spec.ProviderSpec.Value.Raw == nil {
+		return nil
+	}
+
+	raw, err := util.InflateProviderSpec(m.Spec.ProviderSpec.Value.Raw)
+	if err != nil {
+		return field.Invalid(field.NewPath("providerSpec", "value"), field.OmitValueType{}, err.Error())
+	}
+	m.Spec.ProviderSpec.Value.Raw = raw
+	return nil
+}
+
+// normalizeProviderSpecGroup rewrites the legacy API group of the providerSpec to the machine.openshift.io group,
+// so that stored Machines are consistent. Only the apiVersion is rewritten, the kinds are the same in both groups.
+// It is a no-op when the providerSpec already uses the machine.openshift.io group or can't be decoded.
+func normalizeProviderSpecGroup(m *machinev1beta1.Machine, platformStatus *osconfigv1.PlatformStatus) {
+	if platformStatus == nil || m.Spec.ProviderSpec.Value == nil || m.Spec.ProviderSpec.Value.Raw == nil {
+		return
+	}
+
+	legacyGroup, ok := legacyProviderSpecGroups[platformStatus.Type]
+	if !ok {
+		return
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(m.Spec.ProviderSpec.Value.Raw, &fields); err != nil {
+		return
+	}
+
+	apiVersion, _ := fields["apiVersion"].(string)
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil || gv.Group != legacyGroup {
+		return
+	}
+
+	fields["apiVersion"] = schema.GroupVersion{Group: machinev1beta1.GroupName, Version: gv.Version}.String()
+	rawBytes, err := json.Marshal(fields)
+	if err != nil {
+		klog.Errorf("failed to encode the providerSpec of Machine %s: %v", m.GetName(), err)
+		return
+	}
+
+	klog.V(3).Infof("Rewrote the providerSpec apiVersion of Machine %s from %s to %s", m.GetName(), apiVersion, fields["apiVersion"])
+	m.Spec.ProviderSpec.Value = &kruntime.RawExtension{Raw: rawBytes}
 }
 
 // validateAzureCapacityReservationGroupID validate capacity reservation group ID.
@@ -1,23 +1,30 @@
 package webhooks
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/openshift/api/features"
+	machinecontroller "github.com/openshift/machine-api-operator/pkg/controller/machine"
 	testutils "github.com/openshift/machine-api-operator/pkg/util/testing"
 
 	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
@@ -72,36 +79,59 @@ func TestMachineCreation(t *testing.T) {
 			Name:      defaultAWSCredentialsSecret,
 			Namespace: namespace.Name,
 		},
+		Data: map[string][]byte{
+			"aws_access_key_id":     []byte("id"),
+			"aws_secret_access_key": []byte("secret"),
+		},
 	}
 	vSphereSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      defaultVSphereCredentialsSecret,
 			Namespace: namespace.Name,
 		},
+		Data: map[string][]byte{
+			"server.username": []byte("username"),
+			"server.password": []byte("password"),
+		},
 	}
 	GCPSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      defaultGCPCredentialsSecret,
 			Namespace: namespace.Name,
 		},
+		Data: map[string][]byte{
+			"service_account.json": []byte("{}"),
+		},
 	}
 	azureSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      defaultAzureCredentialsSecret,
 			Namespace: defaultSecretNamespace,
 		},
+		Data: map[string][]byte{
+			"azure_subscription_id": []byte("subscription"),
+			"azure_client_id":       []byte("client"),
+			"azure_tenant_id":       []byte("tenant"),
+			"azure_client_secret":   []byte("secret"),
+		},
 	}
 	powerVSSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      defaultPowerVSCredentialsSecret,
 			Namespace: defaultSecretNamespace,
 		},
+		Data: map[string][]byte{
+			"ibmcloud_api_key": []byte("key"),
+		},
 	}
 	nutanixSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      defaultNutanixCredentialsSecret,
 			Namespace: defaultSecretNamespace,
 		},
+		Data: map[string][]byte{
+			"credentials": []byte("[]"),
+		},
 	}
 	g.Expect(c.Create(ctx, awsSecret)).To(Succeed())
 	g.Expect(c.Create(ctx, vSphereSecret)).To(Succeed())
@@ -1376,7 +1406,7 @@ func TestMachineUpdate(t *testing.T) {
 		Subnet:               defaultAzureSubnet(azureClusterID),
 		NetworkResourceGroup: defaultAzureNetworkResourceGroup(azureClusterID),
 		Image: machinev1beta1.Image{
-			ResourceID: defaultAzureImageResourceID(azureClusterID),
+			ResourceID: defaultAzureImageResourceID(azureClusterID, arch),
 		},
 		ManagedIdentity: defaultAzureManagedIdentiy(azureClusterID),
 		ResourceGroup:   defaultAzureResourceGroup(azureClusterID),
@@ -1418,7 +1448,7 @@ func TestMachineUpdate(t *testing.T) {
 				Boot:       true,
 				SizeGB:     defaultGCPDiskSizeGb,
 				Type:       defaultGCPDiskType,
-				Image:      defaultGCPDiskImage(),
+				Image:      defaultGCPDiskImage(arch),
 			},
 		},
 		Tags: defaultGCPTags(gcpClusterID),
@@ -1503,30 +1533,50 @@ func TestMachineUpdate(t *testing.T) {
 			Name:      defaultAWSCredentialsSecret,
 			Namespace: namespace.Name,
 		},
+		Data: map[string][]byte{
+			"aws_access_key_id":     []byte("id"),
+			"aws_secret_access_key": []byte("secret"),
+		},
 	}
 	vSphereSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      defaultVSphereCredentialsSecret,
 			Namespace: namespace.Name,
 		},
+		Data: map[string][]byte{
+			"server.username": []byte("username"),
+			"server.password": []byte("password"),
+		},
 	}
 	GCPSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      defaultGCPCredentialsSecret,
 			Namespace: namespace.Name,
 		},
+		Data: map[string][]byte{
+			"service_account.json": []byte("{}"),
+		},
 	}
 	azureSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      defaultAzureCredentialsSecret,
 			Namespace: defaultSecretNamespace,
 		},
+		Data: map[string][]byte{
+			"azure_subscription_id": []byte("subscription"),
+			"azure_client_id":       []byte("client"),
+			"azure_tenant_id":       []byte("tenant"),
+			"azure_client_secret":   []byte("secret"),
+		},
 	}
 	powerVSSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      defaultPowerVSCredentialsSecret,
 			Namespace: defaultSecretNamespace,
 		},
+		Data: map[string][]byte{
+			"ibmcloud_api_key": []byte("key"),
+		},
 	}
 	g.Expect(c.Create(ctx, awsSecret)).To(Succeed())
 	g.Expect(c.Create(ctx, vSphereSecret)).To(Succeed())
@@ -2188,6 +2238,60 @@ func TestValidateAWSProviderSpec(t *testing.T) {
 			expectedOk:    false,
 			expectedError: "providerSpec.ami: Required value: expected providerSpec.ami.id to be populated",
 		},
+		{
+			testCase: "with the legacy API group it warns",
+			modifySpec: func(p *machinev1beta1.AWSMachineProviderConfig) {
+				p.APIVersion = "awsproviderconfig.openshift.io/v1beta1"
+			},
+			expectedOk:       true,
+			expectedWarnings: []string{"providerSpec.value.apiVersion: awsproviderconfig.openshift.io/v1beta1 is deprecated, use machine.openshift.io/v1beta1 instead"},
+		},
+		{
+			testCase: "with a userDataSecret which does not exist it warns",
+			modifySpec: func(p *machinev1beta1.AWSMachineProviderConfig) {
+				p.UserDataSecret = &corev1.LocalObjectReference{Name: "missing"}
+			},
+			expectedOk:       true,
+			expectedWarnings: []string{"providerSpec.userDataSecret: Invalid value: \"missing\": not found. Expected UserDataSecret to exist"},
+		},
+		{
+			testCase: "with a capacity reservation and a subnet ID it warns",
+			modifySpec: func(p *machinev1beta1.AWSMachineProviderConfig) {
+				p.CapacityReservationID = "cr-12345678901234567"
+			},
+			expectedOk:       true,
+			expectedWarnings: []string{"providerSpec.capacityReservationId: capacity reservation cr-12345678901234567 must be in the same availability zone as subnet subnet, instances will fail to launch otherwise"},
+		},
+		{
+			testCase: "with a capacity reservation and an availability zone it warns",
+			modifySpec: func(p *machinev1beta1.AWSMachineProviderConfig) {
+				p.CapacityReservationID = "cr-12345678901234567"
+				p.Subnet = machinev1beta1.AWSResourceReference{
+					Filters: []machinev1beta1.Filter{{Name: "tag:Name", Values: []string{"subnet"}}},
+				}
+				p.Placement.AvailabilityZone = "regiona"
+			},
+			expectedOk:       true,
+			expectedWarnings: []string{"providerSpec.capacityReservationId: capacity reservation cr-12345678901234567 must be in availability zone regiona, instances will fail to launch otherwise"},
+		},
+		{
+			testCase: "with a capacity reservation and a subnet filter it does not warn",
+			modifySpec: func(p *machinev1beta1.AWSMachineProviderConfig) {
+				p.CapacityReservationID = "cr-12345678901234567"
+				p.Subnet = machinev1beta1.AWSResourceReference{
+					Filters: []machinev1beta1.Filter{{Name: "tag:Name", Values: []string{"subnet"}}},
+				}
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with an invalid capacity reservation it only fails",
+			modifySpec: func(p *machinev1beta1.AWSMachineProviderConfig) {
+				p.CapacityReservationID = "cr-123"
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.capacityReservationId: Invalid value: \"cr-123\": invalid value for capacityReservationId: \"cr-123\", it must start with 'cr-' and be exactly 20 characters long with 17 hexadecimal characters",
+		},
 		{
 			testCase: "with no region values it fails",
 			modifySpec: func(p *machinev1beta1.AWSMachineProviderConfig) {
@@ -2196,6 +2300,39 @@ func TestValidateAWSProviderSpec(t *testing.T) {
 			expectedOk:    false,
 			expectedError: "providerSpec.placement.region: Required value: expected providerSpec.placement.region to be populated",
 		},
+		{
+			testCase: "with an availability zone within the region",
+			modifySpec: func(p *machinev1beta1.AWSMachineProviderConfig) {
+				p.Placement.Region = "us-east-1"
+				p.Placement.AvailabilityZone = "us-east-1a"
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with a local zone within the region",
+			modifySpec: func(p *machinev1beta1.AWSMachineProviderConfig) {
+				p.Placement.Region = "us-east-1"
+				p.Placement.AvailabilityZone = "us-east-1-bos-1a"
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with an availability zone in another region it fails",
+			modifySpec: func(p *machinev1beta1.AWSMachineProviderConfig) {
+				p.Placement.Region = "us-east-1"
+				p.Placement.AvailabilityZone = "us-west-2a"
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.placement.availabilityZone: Invalid value: \"us-west-2a\": availability zone is not within region \"us-east-1\"",
+		},
+		{
+			testCase: "with an empty availability zone",
+			modifySpec: func(p *machinev1beta1.AWSMachineProviderConfig) {
+				p.Placement.Region = "us-east-1"
+				p.Placement.AvailabilityZone = ""
+			},
+			expectedOk: true,
+		},
 		{
 			testCase: "with no instanceType it fails",
 			modifySpec: func(p *machinev1beta1.AWSMachineProviderConfig) {
@@ -2317,6 +2454,58 @@ func TestValidateAWSProviderSpec(t *testing.T) {
 			expectedOk:       true,
 			expectedWarnings: []string{"providerSpec.iamInstanceProfile: no IAM instance profile provided: nodes may be unable to join the cluster"},
 		},
+		{
+			testCase: "with a placeholder iam instance profile",
+			modifySpec: func(p *machinev1beta1.AWSMachineProviderConfig) {
+				p.IAMInstanceProfile = &machinev1beta1.AWSResourceReference{ID: ptr.To[string]("<instance-profile>")}
+			},
+			expectedOk:       true,
+			expectedWarnings: []string{"providerSpec.iamInstanceProfile.id: Invalid value: \"<instance-profile>\": appears to be a placeholder. Expected a reference to an existing identity"},
+		},
+		{
+			testCase: "with a malformed iam instance profile",
+			modifySpec: func(p *machinev1beta1.AWSMachineProviderConfig) {
+				p.IAMInstanceProfile = &machinev1beta1.AWSResourceReference{ID: ptr.To[string]("worker profile")}
+			},
+			expectedOk:       true,
+			expectedWarnings: []string{"providerSpec.iamInstanceProfile.id: Invalid value: \"worker profile\": is not well-formed. Expected an IAM instance profile name of up to 128 alphanumeric or '+=,.@-_' characters"},
+		},
+		{
+			testCase: "with a single iam instance profile id",
+			modifySpec: func(p *machinev1beta1.AWSMachineProviderConfig) {
+				p.IAMInstanceProfile = &machinev1beta1.AWSResourceReference{ID: ptr.To[string]("worker-profile")}
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with both an iam instance profile id and arn",
+			modifySpec: func(p *machinev1beta1.AWSMachineProviderConfig) {
+				p.IAMInstanceProfile = &machinev1beta1.AWSResourceReference{
+					ID:  ptr.To[string]("worker-profile"),
+					ARN: ptr.To[string]("arn:aws:iam::123456789012:instance-profile/worker-profile"),
+				}
+			},
+			expectedOk: true,
+			expectedWarnings: []string{
+				"providerSpec.iamInstanceProfile: multiple ways of referencing the IAM instance profile are set (id, arn): only providerSpec.iamInstanceProfile.id is used",
+				"can't use providerSpec.iamInstanceProfile.arn, only providerSpec.iamInstanceProfile.id can be used to reference IAMInstanceProfile",
+			},
+		},
+		{
+			testCase: "with an iam instance profile arn and filters",
+			modifySpec: func(p *machinev1beta1.AWSMachineProviderConfig) {
+				p.IAMInstanceProfile = &machinev1beta1.AWSResourceReference{
+					ARN:     ptr.To[string]("arn:aws:iam::123456789012:instance-profile/worker-profile"),
+					Filters: []machinev1beta1.Filter{{Name: "tag:Name", Values: []string{"worker-profile"}}},
+				}
+			},
+			expectedOk: true,
+			expectedWarnings: []string{
+				"providerSpec.iamInstanceProfile: multiple ways of referencing the IAM instance profile are set (arn, filters): only providerSpec.iamInstanceProfile.id is used",
+				"can't use providerSpec.iamInstanceProfile.arn, only providerSpec.iamInstanceProfile.id can be used to reference IAMInstanceProfile",
+				"can't use providerSpec.iamInstanceProfile.filters, only providerSpec.iamInstanceProfile.id can be used to reference IAMInstanceProfile",
+			},
+		},
 		{
 			testCase: "with double tag names, lists duplicated tags",
 			modifySpec: func(p *machinev1beta1.AWSMachineProviderConfig) {
@@ -2359,6 +2548,21 @@ func TestValidateAWSProviderSpec(t *testing.T) {
 			expectedOk:       true,
 			expectedWarnings: []string{"providerSpec.tags: duplicated tag names (Tag-A): only the first value will be used."},
 		},
+		{
+			testCase: "with the cluster tag owned",
+			modifySpec: func(p *machinev1beta1.AWSMachineProviderConfig) {
+				p.Tags = []machinev1beta1.TagSpecification{{Name: "kubernetes.io/cluster/clusterID", Value: "owned"}}
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with the cluster tag shared, warns",
+			modifySpec: func(p *machinev1beta1.AWSMachineProviderConfig) {
+				p.Tags = []machinev1beta1.TagSpecification{{Name: "kubernetes.io/cluster/clusterID", Value: "shared"}}
+			},
+			expectedOk:       true,
+			expectedWarnings: []string{"providerSpec.tags: tag kubernetes.io/cluster/clusterID is set to \"shared\" instead of \"owned\": the instances of the Machine may not be discovered as owned by the cluster"},
+		},
 		{
 			testCase: "with alternately cased tag names, AWS tags are case sensitive, does not list duplicated tags",
 			modifySpec: func(p *machinev1beta1.AWSMachineProviderConfig) {
@@ -2462,6 +2666,10 @@ func TestValidateAWSProviderSpec(t *testing.T) {
 			Name:      "secret",
 			Namespace: namespace.Name,
 		},
+		Data: map[string][]byte{
+			"aws_access_key_id":     []byte("id"),
+			"aws_secret_access_key": []byte("secret"),
+		},
 	}
 	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(secret).Build()
 
@@ -2505,7 +2713,7 @@ func TestValidateAWSProviderSpec(t *testing.T) {
 				},
 				TypeMeta: metav1.TypeMeta{
 					Kind:       "AWSMachineProviderConfig",
-					APIVersion: "awsproviderconfig.openshift.io/v1beta1",
+					APIVersion: "machine.openshift.io/v1beta1",
 				},
 			}
 			if tc.modifySpec != nil {
@@ -2656,6 +2864,46 @@ func TestValidateAzureProviderSpec(t *testing.T) {
 			expectedOk:    false,
 			expectedError: "providerSpec.vmSize: Required value: vmSize should be set to one of the supported Azure VM sizes",
 		},
+		{
+			testCase: "with more data disks than the vm size supports",
+			modifySpec: func(p *machinev1beta1.AzureMachineProviderSpec) {
+				p.VMSize = "Standard_D2s_v3"
+				p.DataDisks = azureDataDisks(5)
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.dataDisks: Invalid value: 5: data disk count must not exceed 4 for vmSize Standard_D2s_v3",
+		},
+		{
+			testCase: "with as many data disks as a large vm size supports",
+			modifySpec: func(p *machinev1beta1.AzureMachineProviderSpec) {
+				p.VMSize = "Standard_D16s_v5"
+				p.DataDisks = azureDataDisks(32)
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with a managed identity resource ID",
+			modifySpec: func(p *machinev1beta1.AzureMachineProviderSpec) {
+				p.ManagedIdentity = "/subscriptions/123/resourceGroups/rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/identity"
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with a placeholder managed identity",
+			modifySpec: func(p *machinev1beta1.AzureMachineProviderSpec) {
+				p.ManagedIdentity = "changeme"
+			},
+			expectedOk:       true,
+			expectedWarnings: []string{"providerSpec.managedIdentity: Invalid value: \"changeme\": appears to be a placeholder. Expected a reference to an existing identity"},
+		},
+		{
+			testCase: "with a malformed managed identity",
+			modifySpec: func(p *machinev1beta1.AzureMachineProviderSpec) {
+				p.ManagedIdentity = "/subscriptions/123/identity"
+			},
+			expectedOk:       true,
+			expectedWarnings: []string{"providerSpec.managedIdentity: Invalid value: \"/subscriptions/123/identity\": is not well-formed. Expected the name or resource ID of a user assigned managed identity"},
+		},
 		{
 			testCase: "with ephemeral storage but no caching type it fails",
 			modifySpec: func(p *machinev1beta1.AzureMachineProviderSpec) {
@@ -2665,6 +2913,47 @@ func TestValidateAzureProviderSpec(t *testing.T) {
 			expectedOk:    false,
 			expectedError: "providerSpec.osDisk.cachingType: Invalid value: \"\": Instances using an ephemeral OS disk support only Readonly caching",
 		},
+		{
+			testCase: "with an availabilitySet and a zone it fails",
+			modifySpec: func(p *machinev1beta1.AzureMachineProviderSpec) {
+				p.Location = "centralus"
+				p.AvailabilitySet = "availabilitySet"
+				p.Zone = "1"
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.availabilitySet: Forbidden: availabilitySet cannot be set when a zone is specified",
+		},
+		{
+			testCase: "with only an availabilitySet",
+			modifySpec: func(p *machinev1beta1.AzureMachineProviderSpec) {
+				p.Location = "centralus"
+				p.AvailabilitySet = "availabilitySet"
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with only a zone",
+			modifySpec: func(p *machinev1beta1.AzureMachineProviderSpec) {
+				p.Location = "centralus"
+				p.Zone = "1"
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with neither an availabilitySet nor a zone in a region with zones",
+			modifySpec: func(p *machinev1beta1.AzureMachineProviderSpec) {
+				p.Location = "centralus"
+			},
+			expectedOk:       true,
+			expectedWarnings: []string{"providerSpec.zone: neither zone nor availabilitySet is set, machines in region centralus will not be distributed across availability zones"},
+		},
+		{
+			testCase: "with neither an availabilitySet nor a zone in a region without zones",
+			modifySpec: func(p *machinev1beta1.AzureMachineProviderSpec) {
+				p.Location = "westus"
+			},
+			expectedOk: true,
+		},
 		{
 			testCase: "with a vnet but no subnet it fails",
 			modifySpec: func(p *machinev1beta1.AzureMachineProviderSpec) {
@@ -3035,6 +3324,44 @@ func TestValidateAzureProviderSpec(t *testing.T) {
 			},
 			expectedOk: true,
 		},
+		{
+			testCase: "with spot VMs and a max price of -1",
+			modifySpec: func(p *machinev1beta1.AzureMachineProviderSpec) {
+				p.SpotVMOptions = &machinev1beta1.SpotVMOptions{
+					MaxPrice: ptr.To(resource.MustParse("-1")),
+				}
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with spot VMs and a positive max price",
+			modifySpec: func(p *machinev1beta1.AzureMachineProviderSpec) {
+				p.SpotVMOptions = &machinev1beta1.SpotVMOptions{
+					MaxPrice: ptr.To(resource.MustParse("0.05")),
+				}
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with spot VMs and a zero max price",
+			modifySpec: func(p *machinev1beta1.AzureMachineProviderSpec) {
+				p.SpotVMOptions = &machinev1beta1.SpotVMOptions{
+					MaxPrice: ptr.To(resource.MustParse("0")),
+				}
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.spotVMOptions.maxPrice: Invalid value: \"0\": maxPrice must be either -1, to pay up to the on-demand price, or a positive price",
+		},
+		{
+			testCase: "with spot VMs and a negative other than -1 max price",
+			modifySpec: func(p *machinev1beta1.AzureMachineProviderSpec) {
+				p.SpotVMOptions = &machinev1beta1.SpotVMOptions{
+					MaxPrice: ptr.To(resource.MustParse("-0.5")),
+				}
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.spotVMOptions.maxPrice: Invalid value: \"-500m\": maxPrice must be either -1, to pay up to the on-demand price, or a positive price",
+		},
 		{
 			testCase: "with Azure Managed boot diagnostics",
 			modifySpec: func(p *machinev1beta1.AzureMachineProviderSpec) {
@@ -3105,6 +3432,28 @@ func TestValidateAzureProviderSpec(t *testing.T) {
 			expectedOk:    false,
 			expectedError: "providerSpec.diagnostics.boot.storageAccountType: Invalid value: \"invalid\": storageAccountType must be one of: AzureManaged, CustomerManaged",
 		},
+		{
+			testCase: "with a userDataSecret which does not exist",
+			modifySpec: func(p *machinev1beta1.AzureMachineProviderSpec) {
+				p.UserDataSecret = &corev1.SecretReference{Name: "missing"}
+			},
+			azurePlatformStatus: &osconfigv1.AzurePlatformStatus{
+				CloudName: osconfigv1.AzurePublicCloud,
+			},
+			expectedOk:       true,
+			expectedWarnings: []string{"providerSpec.userDataSecret: Invalid value: \"missing\": not found. Expected UserDataSecret to exist"},
+		},
+		{
+			testCase: "with a userDataSecret in another namespace which does not exist",
+			modifySpec: func(p *machinev1beta1.AzureMachineProviderSpec) {
+				p.UserDataSecret = &corev1.SecretReference{Name: "name", Namespace: "other"}
+			},
+			azurePlatformStatus: &osconfigv1.AzurePlatformStatus{
+				CloudName: osconfigv1.AzurePublicCloud,
+			},
+			expectedOk:       true,
+			expectedWarnings: []string{"providerSpec.userDataSecret: Invalid value: \"name\": not found. Expected UserDataSecret to exist"},
+		},
 		{
 			testCase: "with invalid GroupVersionKind",
 			modifySpec: func(p *machinev1beta1.AzureMachineProviderSpec) {
@@ -3137,6 +3486,12 @@ func TestValidateAzureProviderSpec(t *testing.T) {
 					Name:      "name",
 					Namespace: namespace.Name,
 				},
+				Data: map[string][]byte{
+					"azure_subscription_id": []byte("subscription"),
+					"azure_client_id":       []byte("client"),
+					"azure_tenant_id":       []byte("tenant"),
+					"azure_client_secret":   []byte("secret"),
+				},
 			}
 			c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(secret).Build()
 			infra := plainInfra.DeepCopy()
@@ -3169,7 +3524,7 @@ func TestValidateAzureProviderSpec(t *testing.T) {
 				},
 				TypeMeta: metav1.TypeMeta{
 					Kind:       "AzureMachineProviderSpec",
-					APIVersion: "azureproviderconfig.openshift.io/v1beta1",
+					APIVersion: "machine.openshift.io/v1beta1",
 				},
 			}
 			if tc.modifySpec != nil {
@@ -3325,7 +3680,7 @@ func TestDefaultAzureProviderSpec(t *testing.T) {
 				Vnet:   defaultAzureVnet(clusterID),
 				Subnet: defaultAzureSubnet(clusterID),
 				Image: machinev1beta1.Image{
-					ResourceID: defaultAzureImageResourceID(clusterID),
+					ResourceID: defaultAzureImageResourceID(clusterID, arch),
 				},
 				UserDataSecret: &corev1.SecretReference{
 					Name: defaultUserDataSecret,
@@ -3463,6 +3818,65 @@ func TestValidateGCPProviderSpec(t *testing.T) {
 			expectedOk:    false,
 			expectedError: "providerSpec.networkInterfaces[1].subnetwork: Required value: subnetwork is required",
 		},
+		{
+			testCase: "with an unqualified subnetwork in a shared VPC",
+			modifySpec: func(p *machinev1beta1.GCPMachineProviderSpec) {
+				p.NetworkInterfaces = []*machinev1beta1.GCPNetworkInterface{
+					{
+						Network:    "network",
+						ProjectID:  "host-project",
+						Subnetwork: "subnetwork",
+					},
+				}
+			},
+			expectedOk:       true,
+			expectedWarnings: []string{"providerSpec.networkInterfaces[0].subnetwork: Invalid value: \"subnetwork\": subnetwork should be fully qualified as projects/host-project/regions/<region>/subnetworks/<name> in the shared VPC host project"},
+		},
+		{
+			testCase: "with a fully qualified subnetwork in a shared VPC",
+			modifySpec: func(p *machinev1beta1.GCPMachineProviderSpec) {
+				p.NetworkInterfaces = []*machinev1beta1.GCPNetworkInterface{
+					{
+						Network:    "network",
+						ProjectID:  "host-project",
+						Subnetwork: "projects/host-project/regions/region/subnetworks/subnetwork",
+					},
+					{
+						Network:    "network",
+						ProjectID:  "host-project",
+						Subnetwork: "https://www.googleapis.com/compute/v1/projects/host-project/regions/region/subnetworks/subnetwork",
+					},
+				}
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with a subnetwork of another project in a shared VPC",
+			modifySpec: func(p *machinev1beta1.GCPMachineProviderSpec) {
+				p.NetworkInterfaces = []*machinev1beta1.GCPNetworkInterface{
+					{
+						Network:    "network",
+						ProjectID:  "host-project",
+						Subnetwork: "projects/other-project/regions/region/subnetworks/subnetwork",
+					},
+				}
+			},
+			expectedOk:       true,
+			expectedWarnings: []string{"providerSpec.networkInterfaces[0].subnetwork: Invalid value: \"projects/other-project/regions/region/subnetworks/subnetwork\": subnetwork belongs to project other-project instead of the shared VPC host project host-project"},
+		},
+		{
+			testCase: "with an unqualified subnetwork in the project of the instance",
+			modifySpec: func(p *machinev1beta1.GCPMachineProviderSpec) {
+				p.NetworkInterfaces = []*machinev1beta1.GCPNetworkInterface{
+					{
+						Network:    "network",
+						ProjectID:  p.ProjectID,
+						Subnetwork: "subnetwork",
+					},
+				}
+			},
+			expectedOk: true,
+		},
 		{
 			testCase: "with no disks",
 			modifySpec: func(p *machinev1beta1.GCPMachineProviderSpec) {
@@ -3521,22 +3935,133 @@ func TestValidateGCPProviderSpec(t *testing.T) {
 			expectedOk: true,
 		},
 		{
-			testCase: "with no service accounts",
+			testCase: "with a hyperdisk on a supported machine type",
 			modifySpec: func(p *machinev1beta1.GCPMachineProviderSpec) {
-				p.ServiceAccounts = nil
+				p.MachineType = "c3-standard-4"
+				p.Disks = []*machinev1beta1.GCPDisk{
+					{
+						SizeGB: 16,
+						Type:   "hyperdisk-balanced",
+					},
+				}
 			},
-			expectedOk:       true,
-			expectedError:    "",
-			expectedWarnings: []string{"providerSpec.serviceAccounts: no service account provided: nodes may be unable to join the cluster"},
+			expectedOk: true,
 		},
 		{
-			testCase: "with multiple service accounts",
+			testCase: "with a hyperdisk on an unsupported machine type",
 			modifySpec: func(p *machinev1beta1.GCPMachineProviderSpec) {
-				p.ServiceAccounts = []machinev1beta1.GCPServiceAccount{
-					{},
-					{},
-				}
-			},
+				p.MachineType = "e2-standard-4"
+				p.Disks = []*machinev1beta1.GCPDisk{
+					{
+						SizeGB: 16,
+						Type:   "hyperdisk-balanced",
+					},
+				}
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.disks[0].type: Invalid value: \"hyperdisk-balanced\": hyperdisk-balanced disks require machine type in the following series: a3,c3,c3d,c4,c4a,c4d,h3,m1,m2,m3,n4,x4,z3, the current machine type is: e2-standard-4",
+		},
+		{
+			testCase: "with a hyperdisk on a machine type of an unknown series",
+			modifySpec: func(p *machinev1beta1.GCPMachineProviderSpec) {
+				p.MachineType = "n9-standard-4"
+				p.Disks = []*machinev1beta1.GCPDisk{
+					{
+						SizeGB: 16,
+						Type:   "hyperdisk-balanced",
+					},
+				}
+			},
+			expectedOk:       true,
+			expectedWarnings: []string{"providerSpec.disks[0].type: Invalid value: \"hyperdisk-balanced\": hyperdisk-balanced disks may not be supported by machine type n9-standard-4, they are known to be supported by the following series: a3,c3,c3d,c4,c4a,c4d,h3,m1,m2,m3,n4,x4,z3"},
+		},
+		{
+			testCase: "with a pd disk on a machine type which does not support hyperdisks",
+			modifySpec: func(p *machinev1beta1.GCPMachineProviderSpec) {
+				p.MachineType = "e2-standard-4"
+				p.Disks = []*machinev1beta1.GCPDisk{
+					{
+						SizeGB: 16,
+						Type:   "pd-ssd",
+					},
+				}
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with empty labels",
+			modifySpec: func(p *machinev1beta1.GCPMachineProviderSpec) {
+				p.Labels = map[string]string{}
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with valid labels",
+			modifySpec: func(p *machinev1beta1.GCPMachineProviderSpec) {
+				p.Labels = map[string]string{"env": "prod", "team_name": "", "cost-center": "1234"}
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with an uppercase label key",
+			modifySpec: func(p *machinev1beta1.GCPMachineProviderSpec) {
+				p.Labels = map[string]string{"Env": "prod"}
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.labels[Env]: Invalid value: \"Env\": label keys must be lowercase",
+		},
+		{
+			testCase: "with an empty label key",
+			modifySpec: func(p *machinev1beta1.GCPMachineProviderSpec) {
+				p.Labels = map[string]string{"": "prod"}
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.labels[]: Invalid value: \"\": label keys must not be empty",
+		},
+		{
+			testCase: "with a label key longer than 63 characters",
+			modifySpec: func(p *machinev1beta1.GCPMachineProviderSpec) {
+				p.Labels = map[string]string{strings.Repeat("k", 64): "prod"}
+			},
+			expectedOk:    false,
+			expectedError: fmt.Sprintf("providerSpec.labels[%[1]s]: Invalid value: \"%[1]s\": label keys must be at most 63 characters", strings.Repeat("k", 64)),
+		},
+		{
+			testCase: "with a label value longer than 63 characters",
+			modifySpec: func(p *machinev1beta1.GCPMachineProviderSpec) {
+				p.Labels = map[string]string{"env": strings.Repeat("v", 64)}
+			},
+			expectedOk:    false,
+			expectedError: fmt.Sprintf("providerSpec.labels[env]: Invalid value: \"%s\": label values must be at most 63 characters", strings.Repeat("v", 64)),
+		},
+		{
+			testCase: "with more than 64 labels",
+			modifySpec: func(p *machinev1beta1.GCPMachineProviderSpec) {
+				p.Labels = map[string]string{}
+				for i := 0; i < 65; i++ {
+					p.Labels[fmt.Sprintf("label-%d", i)] = "value"
+				}
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.labels: Too many: 65: must have at most 64 items",
+		},
+		{
+			testCase: "with no service accounts",
+			modifySpec: func(p *machinev1beta1.GCPMachineProviderSpec) {
+				p.ServiceAccounts = nil
+			},
+			expectedOk:       true,
+			expectedError:    "",
+			expectedWarnings: []string{"providerSpec.serviceAccounts: no service account provided: nodes may be unable to join the cluster"},
+		},
+		{
+			testCase: "with multiple service accounts",
+			modifySpec: func(p *machinev1beta1.GCPMachineProviderSpec) {
+				p.ServiceAccounts = []machinev1beta1.GCPServiceAccount{
+					{},
+					{},
+				}
+			},
 			expectedOk:    false,
 			expectedError: "providerSpec.serviceAccounts: Invalid value: \"2 service accounts supplied\": exactly 1 service account must be supplied",
 		},
@@ -3557,13 +4082,36 @@ func TestValidateGCPProviderSpec(t *testing.T) {
 			modifySpec: func(p *machinev1beta1.GCPMachineProviderSpec) {
 				p.ServiceAccounts = []machinev1beta1.GCPServiceAccount{
 					{
-						Email: "email",
+						Email: "email@project.iam.gserviceaccount.com",
 					},
 				}
 			},
 			expectedOk:    false,
 			expectedError: "providerSpec.serviceAccounts[0].scopes: Required value: at least 1 scope is required",
 		},
+		{
+			testCase: "with the default service account",
+			modifySpec: func(p *machinev1beta1.GCPMachineProviderSpec) {
+				p.ServiceAccounts[0].Email = "default"
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with a placeholder service account email",
+			modifySpec: func(p *machinev1beta1.GCPMachineProviderSpec) {
+				p.ServiceAccounts[0].Email = "${SERVICE_ACCOUNT}"
+			},
+			expectedOk:       true,
+			expectedWarnings: []string{"providerSpec.serviceAccounts[0].email: Invalid value: \"${SERVICE_ACCOUNT}\": appears to be a placeholder. Expected a reference to an existing identity"},
+		},
+		{
+			testCase: "with a malformed service account email",
+			modifySpec: func(p *machinev1beta1.GCPMachineProviderSpec) {
+				p.ServiceAccounts[0].Email = "email"
+			},
+			expectedOk:       true,
+			expectedWarnings: []string{"providerSpec.serviceAccounts[0].email: Invalid value: \"email\": is not well-formed. Expected the email of a service account"},
+		},
 		{
 			testCase: "with no user data secret",
 			modifySpec: func(p *machinev1beta1.GCPMachineProviderSpec) {
@@ -3775,6 +4323,45 @@ func TestValidateGCPProviderSpec(t *testing.T) {
 			expectedOk:    false,
 			expectedError: "providerSpec.machineType: Invalid value: \"e2-standard-4\": ConfidentialCompute require machine type in the following series: n2d,c2d",
 		},
+		{
+			testCase: "with ConfidentialCompute enabled and shieldedInstanceConfig enabled",
+			modifySpec: func(p *machinev1beta1.GCPMachineProviderSpec) {
+				p.ConfidentialCompute = machinev1beta1.ConfidentialComputePolicyEnabled
+				p.OnHostMaintenance = machinev1beta1.TerminateHostMaintenanceType
+				p.MachineType = "n2d-standard-4"
+				p.ShieldedInstanceConfig = machinev1beta1.GCPShieldedInstanceConfig{
+					SecureBoot:                       machinev1beta1.SecureBootPolicyEnabled,
+					IntegrityMonitoring:              machinev1beta1.IntegrityMonitoringPolicyEnabled,
+					VirtualizedTrustedPlatformModule: machinev1beta1.VirtualizedTrustedPlatformModulePolicyEnabled,
+				}
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with ConfidentialCompute enabled and virtualizedTrustedPlatformModule disabled",
+			modifySpec: func(p *machinev1beta1.GCPMachineProviderSpec) {
+				p.ConfidentialCompute = machinev1beta1.ConfidentialComputePolicyEnabled
+				p.OnHostMaintenance = machinev1beta1.TerminateHostMaintenanceType
+				p.MachineType = "n2d-standard-4"
+				p.ShieldedInstanceConfig = machinev1beta1.GCPShieldedInstanceConfig{
+					IntegrityMonitoring:              machinev1beta1.IntegrityMonitoringPolicyDisabled,
+					VirtualizedTrustedPlatformModule: machinev1beta1.VirtualizedTrustedPlatformModulePolicyDisabled,
+				}
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.shieldedInstanceConfig.virtualizedTrustedPlatformModule: Invalid value: \"Disabled\": ConfidentialCompute require virtualizedTrustedPlatformModule to be either Enabled or unset",
+		},
+		{
+			testCase: "with ConfidentialCompute disabled and virtualizedTrustedPlatformModule disabled",
+			modifySpec: func(p *machinev1beta1.GCPMachineProviderSpec) {
+				p.ConfidentialCompute = machinev1beta1.ConfidentialComputePolicyDisabled
+				p.ShieldedInstanceConfig = machinev1beta1.GCPShieldedInstanceConfig{
+					IntegrityMonitoring:              machinev1beta1.IntegrityMonitoringPolicyDisabled,
+					VirtualizedTrustedPlatformModule: machinev1beta1.VirtualizedTrustedPlatformModulePolicyDisabled,
+				}
+			},
+			expectedOk: true,
+		},
 		{
 			testCase: "with GPUs and Migrate onHostMaintenance",
 			modifySpec: func(p *machinev1beta1.GCPMachineProviderSpec) {
@@ -3807,7 +4394,7 @@ func TestValidateGCPProviderSpec(t *testing.T) {
 		},
 		{
 			testCase:         "with unknown fields in the providerSpec",
-			overrideRawBytes: []byte(`{"kind":"GCPMachineProviderSpec","apiVersion":"gcpprovider.openshift.io/v1beta1","metadata":{"creationTimestamp":null},"userDataSecret":{"name":"name"},"credentialsSecret":{"name":"name"},"canIPForward":false,"deletionProtection":false,"disks":[{"autoDelete":false,"boot":false,"sizeGb":16,"type":"","image":"","labels":null}],"networkInterfaces":[{"network":"network","subnetwork":"subnetwork"}],"serviceAccounts":[{"email":"email","scopes":["scope"]}],"machineType":"machineType","region":"region","zone":"region-zone","projectID":"projectID","gpus":[{"count":0,"type":"type"}],"onHostMaintenance":"Terminate","randomField-1": "something"}`),
+			overrideRawBytes: []byte(`{"kind":"GCPMachineProviderSpec","apiVersion":"machine.openshift.io/v1beta1","metadata":{"creationTimestamp":null},"userDataSecret":{"name":"name"},"credentialsSecret":{"name":"name"},"canIPForward":false,"deletionProtection":false,"disks":[{"autoDelete":false,"boot":false,"sizeGb":16,"type":"","image":"","labels":null}],"networkInterfaces":[{"network":"network","subnetwork":"subnetwork"}],"serviceAccounts":[{"email":"email@project.iam.gserviceaccount.com","scopes":["scope"]}],"machineType":"machineType","region":"region","zone":"region-zone","projectID":"projectID","gpus":[{"count":0,"type":"type"}],"onHostMaintenance":"Terminate","randomField-1": "something"}`),
 			expectedOk:       true,
 			expectedError:    "",
 			expectedWarnings: []string{"providerSpec.value: Unsupported value: \"randomField-1\": Unknown field (randomField-1) will be ignored"},
@@ -3819,6 +4406,9 @@ func TestValidateGCPProviderSpec(t *testing.T) {
 			Name:      "name",
 			Namespace: namespace.Name,
 		},
+		Data: map[string][]byte{
+			"service_account.json": []byte("{}"),
+		},
 	}
 	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(secret).Build()
 	infra := plainInfra.DeepCopy()
@@ -3857,7 +4447,7 @@ func TestValidateGCPProviderSpec(t *testing.T) {
 			},
 			ServiceAccounts: []machinev1beta1.GCPServiceAccount{
 				{
-					Email:  "email",
+					Email:  "email@project.iam.gserviceaccount.com",
 					Scopes: []string{"scope"},
 				},
 			},
@@ -3869,7 +4459,7 @@ func TestValidateGCPProviderSpec(t *testing.T) {
 			},
 			TypeMeta: metav1.TypeMeta{
 				Kind:       "GCPMachineProviderSpec",
-				APIVersion: "gcpprovider.openshift.io/v1beta1",
+				APIVersion: "machine.openshift.io/v1beta1",
 			},
 		}
 
@@ -3954,7 +4544,7 @@ func TestDefaultGCPProviderSpec(t *testing.T) {
 						Boot:       false,
 						SizeGB:     32,
 						Type:       defaultGCPDiskType,
-						Image:      defaultGCPDiskImage(),
+						Image:      defaultGCPDiskImage(arch),
 					},
 				}
 			},
@@ -4008,7 +4598,7 @@ func TestDefaultGCPProviderSpec(t *testing.T) {
 					Boot:       true,
 					SizeGB:     defaultGCPDiskSizeGb,
 					Type:       defaultGCPDiskType,
-					Image:      defaultGCPDiskImage(),
+					Image:      defaultGCPDiskImage(arch),
 				},
 			},
 			Tags: defaultGCPTags(clusterID),
@@ -4276,6 +4866,7 @@ func TestValidateVSphereProviderSpec(t *testing.T) {
 			testCase: "with vmGroup greater than 80 characters",
 			modifySpec: func(p *machinev1beta1.VSphereMachineProviderSpec) {
 				p.Workspace.VMGroup = "thisvmgroupnameismorethaneightycharactersthisvmgroupnameismorethaneightycharactersthisvmgroupnameismorethaneightycharacters"
+				p.TagIDs = []string{"urn:vmomi:InventoryServiceTag:5736bf56-49f5-4667-b38c-b97e09dc9578:GLOBAL"}
 			},
 			expectedOk: false,
 			featureGatesEnabled: func() map[string]bool {
@@ -4302,6 +4893,47 @@ func TestValidateVSphereProviderSpec(t *testing.T) {
 			testCase: "with vmGroup configured with feature gate enabled",
 			modifySpec: func(p *machinev1beta1.VSphereMachineProviderSpec) {
 				p.Workspace.VMGroup = "thisisavmgroup"
+				p.TagIDs = []string{"urn:vmomi:InventoryServiceTag:5736bf56-49f5-4667-b38c-b97e09dc9578:GLOBAL"}
+			},
+			expectedOk: true,
+			featureGatesEnabled: func() map[string]bool {
+				fg := make(map[string]bool)
+				fg[string(features.FeatureGateVSphereHostVMGroupZonal)] = true
+				return fg
+			}(),
+		},
+		{
+			testCase: "with vmGroup configured without tag IDs with feature gate enabled",
+			modifySpec: func(p *machinev1beta1.VSphereMachineProviderSpec) {
+				p.Workspace.VMGroup = "thisisavmgroup"
+				p.TagIDs = nil
+			},
+			expectedOk: false,
+			featureGatesEnabled: func() map[string]bool {
+				fg := make(map[string]bool)
+				fg[string(features.FeatureGateVSphereHostVMGroupZonal)] = true
+				return fg
+			}(),
+			expectedError: "providerSpec.tagIDs: Required value: at least one zone tag ID must be provided when workspace.vmGroup is set",
+		},
+		{
+			testCase: "with vmGroup configured with an invalid tag ID with feature gate enabled",
+			modifySpec: func(p *machinev1beta1.VSphereMachineProviderSpec) {
+				p.Workspace.VMGroup = "thisisavmgroup"
+				p.TagIDs = []string{"bad:tag:InventoryServiceTag:5736bf56-49f5-4667-b38c-b97e09dc9578:GLOBAL"}
+			},
+			expectedOk: false,
+			featureGatesEnabled: func() map[string]bool {
+				fg := make(map[string]bool)
+				fg[string(features.FeatureGateVSphereHostVMGroupZonal)] = true
+				return fg
+			}(),
+			expectedError: "providerSpec.tagIDs: Required value: tag ID must be in the format of urn:vmomi:InventoryServiceTag:<UUID>:GLOBAL",
+		},
+		{
+			testCase: "with tag IDs and no vmGroup with feature gate enabled",
+			modifySpec: func(p *machinev1beta1.VSphereMachineProviderSpec) {
+				p.TagIDs = []string{"urn:vmomi:InventoryServiceTag:5736bf56-49f5-4667-b38c-b97e09dc9578:GLOBAL"}
 			},
 			expectedOk: true,
 			featureGatesEnabled: func() map[string]bool {
@@ -4439,6 +5071,10 @@ func TestValidateVSphereProviderSpec(t *testing.T) {
 			Name:      "name",
 			Namespace: namespace.Name,
 		},
+		Data: map[string][]byte{
+			"server.username": []byte("username"),
+			"server.password": []byte("password"),
+		},
 	}
 	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(secret).Build()
 	infra := plainInfra.DeepCopy()
@@ -4483,7 +5119,7 @@ func TestValidateVSphereProviderSpec(t *testing.T) {
 				DiskGiB:   minVSphereDiskGiB,
 				TypeMeta: metav1.TypeMeta{
 					Kind:       "VSphereMachineProviderSpec",
-					APIVersion: "vsphereprovider.openshift.io/v1beta1",
+					APIVersion: "machine.openshift.io/v1beta1",
 				},
 			}
 			if tc.modifySpec != nil {
@@ -4681,6 +5317,10 @@ func TestUpdateFinalizer(t *testing.T) {
 			Name:      "secret",
 			Namespace: namespace.Name,
 		},
+		Data: map[string][]byte{
+			"aws_access_key_id":     []byte("id"),
+			"aws_secret_access_key": []byte("secret"),
+		},
 	}
 	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(secret).Build()
 
@@ -5082,12 +5722,105 @@ func TestValidatePowerVSProviderSpec(t *testing.T) {
 		},
 		{
 			testCase: "with processor less than minimum value for dedicated Processor type",
+			modifySpec: func(p *machinev1.PowerVSMachineProviderConfig) {
+				p.ProcessorType = machinev1.PowerVSProcessorTypeDedicated
+				p.Processors = intstr.FromInt(0)
+			},
+			expectedOk:       true,
+			expectedWarnings: []string{"providerspec.Processor 0.000000 is less than the minimum value 1.000000 for providerSpec.ProcessorType: Dedicated"},
+		},
+		{
+			testCase: "with fractional processor value for dedicated Processor type",
 			modifySpec: func(p *machinev1.PowerVSMachineProviderConfig) {
 				p.ProcessorType = machinev1.PowerVSProcessorTypeDedicated
 				p.Processors = intstr.FromString("0.9")
 			},
+			expectedOk:    false,
+			expectedError: "providerSpec.processor: Invalid value: 0.9: processor value must be a whole number for Dedicated processorType",
+		},
+		{
+			testCase: "with fractional processor value above the minimum for dedicated Processor type",
+			modifySpec: func(p *machinev1.PowerVSMachineProviderConfig) {
+				p.ProcessorType = machinev1.PowerVSProcessorTypeDedicated
+				p.Processors = intstr.FromString("2.5")
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.processor: Invalid value: 2.5: processor value must be a whole number for Dedicated processorType",
+		},
+		{
+			testCase: "with whole processor string value for dedicated Processor type",
+			modifySpec: func(p *machinev1.PowerVSMachineProviderConfig) {
+				p.ProcessorType = machinev1.PowerVSProcessorTypeDedicated
+				p.Processors = intstr.FromString("2")
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with whole processor int value for dedicated Processor type",
+			modifySpec: func(p *machinev1.PowerVSMachineProviderConfig) {
+				p.ProcessorType = machinev1.PowerVSProcessorTypeDedicated
+				p.Processors = intstr.FromInt(2)
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with processor greater than supported value for dedicated Processor type",
+			modifySpec: func(p *machinev1.PowerVSMachineProviderConfig) {
+				p.ProcessorType = machinev1.PowerVSProcessorTypeDedicated
+				p.Processors = intstr.FromInt(16)
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.processor: Invalid value: 16: for s922 systemtype the maximum supported processor value is 15.000000",
+		},
+		{
+			testCase: "with fractional processor value for shared Processor type",
+			modifySpec: func(p *machinev1.PowerVSMachineProviderConfig) {
+				p.Processors = intstr.FromString("2.5")
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with fractional processor value for capped Processor type",
+			modifySpec: func(p *machinev1.PowerVSMachineProviderConfig) {
+				p.ProcessorType = machinev1.PowerVSProcessorTypeCapped
+				p.Processors = intstr.FromString("0.75")
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with processor less than minimum value for capped Processor type",
+			modifySpec: func(p *machinev1.PowerVSMachineProviderConfig) {
+				p.ProcessorType = machinev1.PowerVSProcessorTypeCapped
+				p.Processors = intstr.FromString("0.25")
+			},
 			expectedOk:       true,
-			expectedWarnings: []string{"providerspec.Processor 0.900000 is less than the minimum value 1.000000 for providerSpec.ProcessorType: Dedicated"},
+			expectedWarnings: []string{"providerspec.Processor 0.250000 is less than the minimum value 0.500000 for providerSpec.ProcessorType: Capped"},
+		},
+		{
+			testCase: "with processor greater than supported value for e980 systemtype",
+			modifySpec: func(p *machinev1.PowerVSMachineProviderConfig) {
+				p.SystemType = powerVSSystemTypeE980
+				p.Processors = intstr.FromString("143.5")
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.processor: Invalid value: 143.5: for e980 systemtype the maximum supported processor value is 143.000000",
+		},
+		{
+			testCase: "with memory greater than supported value for e880 systemtype",
+			modifySpec: func(p *machinev1.PowerVSMachineProviderConfig) {
+				p.SystemType = powerVSSystemTypeE880
+				p.MemoryGiB = 8000
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.memoryGiB: Invalid value: 8000: for e880 systemtype the maximum supported memory value is 7463",
+		},
+		{
+			testCase: "with supported memory value for e980 systemtype",
+			modifySpec: func(p *machinev1.PowerVSMachineProviderConfig) {
+				p.SystemType = powerVSSystemTypeE980
+				p.MemoryGiB = 8000
+			},
+			expectedOk: true,
 		},
 		{
 			testCase: "with processor less than minimum value for shared Processor type",
@@ -5117,8 +5850,17 @@ func TestValidatePowerVSProviderSpec(t *testing.T) {
 			Name:      defaultPowerVSCredentialsSecret,
 			Namespace: namespace.Name,
 		},
+		Data: map[string][]byte{
+			"ibmcloud_api_key": []byte("key"),
+		},
 	}
-	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(secret).Build()
+	userDataSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      defaultUserDataSecret,
+			Namespace: namespace.Name,
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(secret, userDataSecret).Build()
 	infra := plainInfra.DeepCopy()
 	infra.Status.InfrastructureName = "clusterID"
 	infra.Status.PlatformStatus.Type = osconfigv1.PowerVSPlatformType
@@ -5344,6 +6086,41 @@ func TestValidateNutanixProviderSpec(t *testing.T) {
 			expectedError: "providerSpec.subnets: Invalid value: \"[]\": missing subnets: nodes may fail to start if no subnets are configured",
 			//expectedWarnings: []string{"providerSpec.subnets: missing subnets: nodes may fail to start if no subnets are configured"},
 		},
+		{
+			testCase: "with subnets provided by the failure domain",
+			modifySpec: func(p *machinev1.NutanixMachineProviderConfig) {
+				p.Subnets = nil
+				p.FailureDomain = &machinev1.NutanixFailureDomainReference{Name: "fd-with-subnets"}
+			},
+			expectedOk:    true,
+			expectedError: "",
+		},
+		{
+			testCase: "with subnets provided by the machine and a failure domain",
+			modifySpec: func(p *machinev1.NutanixMachineProviderConfig) {
+				p.FailureDomain = &machinev1.NutanixFailureDomainReference{Name: "fd-without-subnets"}
+			},
+			expectedOk:    true,
+			expectedError: "",
+		},
+		{
+			testCase: "with no subnets provided by the machine or the failure domain",
+			modifySpec: func(p *machinev1.NutanixMachineProviderConfig) {
+				p.Subnets = nil
+				p.FailureDomain = &machinev1.NutanixFailureDomainReference{Name: "fd-without-subnets"}
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.failureDomain.name: Invalid value: \"fd-without-subnets\": missing subnets: no subnets are configured on the machine or on the failure domain, nodes may fail to start",
+		},
+		{
+			testCase: "with no subnets provided and an unknown failure domain",
+			modifySpec: func(p *machinev1.NutanixMachineProviderConfig) {
+				p.Subnets = nil
+				p.FailureDomain = &machinev1.NutanixFailureDomainReference{Name: "fd-unknown"}
+			},
+			expectedOk:    false,
+			expectedError: "providerSpec.failureDomain.name: Invalid value: \"fd-unknown\": missing subnets: no subnets are configured on the machine and the failure domain is not defined in the cluster infrastructure, nodes may fail to start",
+		},
 		{
 			testCase: "with no userDataSecret provided",
 			modifySpec: func(p *machinev1.NutanixMachineProviderConfig) {
@@ -5368,6 +6145,36 @@ func TestValidateNutanixProviderSpec(t *testing.T) {
 			expectedOk:    false,
 			expectedError: fmt.Sprintf("providerSpec.bootType: Invalid value: \"invalid\": valid bootType values are: \"\", %q, %q, %q.", machinev1.NutanixLegacyBoot, machinev1.NutanixUEFIBoot, machinev1.NutanixSecureBoot),
 		},
+		{
+			testCase: "with Legacy bootType provided",
+			modifySpec: func(p *machinev1.NutanixMachineProviderConfig) {
+				p.BootType = machinev1.NutanixLegacyBoot
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with UEFI bootType provided",
+			modifySpec: func(p *machinev1.NutanixMachineProviderConfig) {
+				p.BootType = machinev1.NutanixUEFIBoot
+			},
+			expectedOk: true,
+		},
+		{
+			testCase: "with SecureBoot bootType provided",
+			modifySpec: func(p *machinev1.NutanixMachineProviderConfig) {
+				p.BootType = machinev1.NutanixSecureBoot
+			},
+			expectedOk:       true,
+			expectedWarnings: []string{"providerSpec.bootType: SecureBoot is not supported by all images: nodes may fail to boot if the image does not support Secure Boot"},
+		},
+		{
+			testCase: "with lowercase bootType provided",
+			modifySpec: func(p *machinev1.NutanixMachineProviderConfig) {
+				p.BootType = "uefi"
+			},
+			expectedOk:    false,
+			expectedError: fmt.Sprintf("providerSpec.bootType: Invalid value: \"uefi\": valid bootType values are: \"\", %q, %q, %q.", machinev1.NutanixLegacyBoot, machinev1.NutanixUEFIBoot, machinev1.NutanixSecureBoot),
+		},
 		{
 			testCase: "with invalid categories provided",
 			modifySpec: func(p *machinev1.NutanixMachineProviderConfig) {
@@ -5405,11 +6212,33 @@ func TestValidateNutanixProviderSpec(t *testing.T) {
 			Name:      defaultNutanixCredentialsSecret,
 			Namespace: namespace.Name,
 		},
+		Data: map[string][]byte{
+			"credentials": []byte("[]"),
+		},
+	}
+	userDataSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      defaultUserDataSecret,
+			Namespace: namespace.Name,
+		},
 	}
-	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(secret).Build()
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(secret, userDataSecret).Build()
 	infra := plainInfra.DeepCopy()
 	infra.Status.InfrastructureName = "clusterID"
 	infra.Status.PlatformStatus.Type = osconfigv1.NutanixPlatformType
+	infra.Spec.PlatformSpec.Nutanix = &osconfigv1.NutanixPlatformSpec{
+		FailureDomains: []osconfigv1.NutanixFailureDomain{
+			{
+				Name:    "fd-with-subnets",
+				Cluster: osconfigv1.NutanixResourceIdentifier{Type: osconfigv1.NutanixIdentifierName, Name: ptr.To[string]("cluster-1")},
+				Subnets: []osconfigv1.NutanixResourceIdentifier{{Type: osconfigv1.NutanixIdentifierName, Name: ptr.To[string]("subnet-1")}},
+			},
+			{
+				Name:    "fd-without-subnets",
+				Cluster: osconfigv1.NutanixResourceIdentifier{Type: osconfigv1.NutanixIdentifierName, Name: ptr.To[string]("cluster-1")},
+			},
+		},
+	}
 
 	gate, err := testutils.NewDefaultMutableFeatureGate()
 	if err != nil {
@@ -5470,6 +6299,448 @@ func TestValidateNutanixProviderSpec(t *testing.T) {
 	}
 }
 
+func TestValidateGVK(t *testing.T) {
+	testCases := []struct {
+		platform osconfigv1.PlatformType
+		gvk      schema.GroupVersionKind
+		expected bool
+	}{
+		{
+			platform: osconfigv1.AWSPlatformType,
+			gvk:      schema.GroupVersionKind{Group: "machine.openshift.io", Version: "v1beta1", Kind: "AWSMachineProviderConfig"},
+			expected: true,
+		},
+		{
+			platform: osconfigv1.AzurePlatformType,
+			gvk:      schema.GroupVersionKind{Group: "azureproviderconfig.openshift.io", Version: "v1beta1", Kind: "AzureMachineProviderSpec"},
+			expected: true,
+		},
+		{
+			platform: osconfigv1.GCPPlatformType,
+			gvk:      schema.GroupVersionKind{Group: "awsproviderconfig.openshift.io", Version: "v1beta1", Kind: "GCPMachineProviderSpec"},
+		},
+		{
+			platform: osconfigv1.VSpherePlatformType,
+			gvk:      schema.GroupVersionKind{Group: "machine.openshift.io", Version: "v1alpha1", Kind: "VSphereMachineProviderSpec"},
+		},
+		{
+			platform: osconfigv1.AWSPlatformType,
+			gvk:      schema.GroupVersionKind{Group: "machine.openshift.io", Version: "v1beta1", Kind: "GCPMachineProviderSpec"},
+		},
+		{
+			platform: osconfigv1.NutanixPlatformType,
+			gvk:      schema.GroupVersionKind{Group: "machine.openshift.io", Version: "v1", Kind: "NutanixMachineProviderConfig"},
+			expected: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%s with %s", tc.platform, tc.gvk), func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(validateGVK(tc.gvk, tc.platform)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestDeprecatedGroupWarning(t *testing.T) {
+	testCases := []struct {
+		platform osconfigv1.PlatformType
+		gvk      schema.GroupVersionKind
+		expected string
+	}{
+		{
+			platform: osconfigv1.AWSPlatformType,
+			gvk:      schema.GroupVersionKind{Group: "awsproviderconfig.openshift.io", Version: "v1beta1", Kind: "AWSMachineProviderConfig"},
+			expected: "providerSpec.value.apiVersion: awsproviderconfig.openshift.io/v1beta1 is deprecated, use machine.openshift.io/v1beta1 instead",
+		},
+		{
+			platform: osconfigv1.AWSPlatformType,
+			gvk:      schema.GroupVersionKind{Group: "machine.openshift.io", Version: "v1beta1", Kind: "AWSMachineProviderConfig"},
+		},
+		{
+			platform: osconfigv1.AzurePlatformType,
+			gvk:      schema.GroupVersionKind{Group: "azureproviderconfig.openshift.io", Version: "v1beta1", Kind: "AzureMachineProviderSpec"},
+			expected: "providerSpec.value.apiVersion: azureproviderconfig.openshift.io/v1beta1 is deprecated, use machine.openshift.io/v1beta1 instead",
+		},
+		{
+			platform: osconfigv1.AzurePlatformType,
+			gvk:      schema.GroupVersionKind{Group: "machine.openshift.io", Version: "v1beta1", Kind: "AzureMachineProviderSpec"},
+		},
+		{
+			platform: osconfigv1.GCPPlatformType,
+			gvk:      schema.GroupVersionKind{Group: "gcpprovider.openshift.io", Version: "v1beta1", Kind: "GCPMachineProviderSpec"},
+			expected: "providerSpec.value.apiVersion: gcpprovider.openshift.io/v1beta1 is deprecated, use machine.openshift.io/v1beta1 instead",
+		},
+		{
+			platform: osconfigv1.GCPPlatformType,
+			gvk:      schema.GroupVersionKind{Group: "machine.openshift.io", Version: "v1beta1", Kind: "GCPMachineProviderSpec"},
+		},
+		{
+			platform: osconfigv1.VSpherePlatformType,
+			gvk:      schema.GroupVersionKind{Group: "vsphereprovider.openshift.io", Version: "v1beta1", Kind: "VSphereMachineProviderSpec"},
+			expected: "providerSpec.value.apiVersion: vsphereprovider.openshift.io/v1beta1 is deprecated, use machine.openshift.io/v1beta1 instead",
+		},
+		{
+			platform: osconfigv1.VSpherePlatformType,
+			gvk:      schema.GroupVersionKind{Group: "machine.openshift.io", Version: "v1beta1", Kind: "VSphereMachineProviderSpec"},
+		},
+		{
+			platform: osconfigv1.AWSPlatformType,
+			gvk:      schema.GroupVersionKind{Group: "gcpprovider.openshift.io", Version: "v1beta1", Kind: "AWSMachineProviderConfig"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%s with %s", tc.platform, tc.gvk.GroupVersion()), func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(deprecatedGroupWarning(tc.gvk, tc.platform)).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestDeprecatedGroupWarningHandler(t *testing.T) {
+	legacyProviderSpec := `{"apiVersion":"awsproviderconfig.openshift.io/v1beta1","kind":"AWSMachineProviderConfig"}`
+	expectedWarning := "providerSpec.value.apiVersion: awsproviderconfig.openshift.io/v1beta1 is deprecated, use machine.openshift.io/v1beta1 instead"
+
+	testCases := []struct {
+		name             string
+		object           kruntime.Object
+		providerSpec     func(raw []byte) (*kruntime.RawExtension, error)
+		denied           bool
+		expectedWarnings []string
+	}{
+		{
+			name:             "with a Machine using the legacy API group",
+			object:           &machinev1beta1.Machine{Spec: machinev1beta1.MachineSpec{ProviderSpec: machinev1beta1.ProviderSpec{Value: &kruntime.RawExtension{Raw: []byte(legacyProviderSpec)}}}},
+			providerSpec:     machineProviderSpec,
+			expectedWarnings: []string{expectedWarning},
+		},
+		{
+			name:         "with a Machine using the machine.openshift.io API group",
+			object:       &machinev1beta1.Machine{Spec: machinev1beta1.MachineSpec{ProviderSpec: machinev1beta1.ProviderSpec{Value: &kruntime.RawExtension{Raw: []byte(`{"apiVersion":"machine.openshift.io/v1beta1","kind":"AWSMachineProviderConfig"}`)}}}},
+			providerSpec: machineProviderSpec,
+		},
+		{
+			name: "with a MachineSet using the legacy API group in an encoded providerSpec",
+			object: &machinev1beta1.MachineSet{Spec: machinev1beta1.MachineSetSpec{Template: machinev1beta1.MachineTemplateSpec{Spec: machinev1beta1.MachineSpec{ProviderSpec: machinev1beta1.ProviderSpec{
+				Value: &kruntime.RawExtension{Raw: []byte(fmt.Sprintf("%q", base64.StdEncoding.EncodeToString([]byte(legacyProviderSpec))))},
+			}}}}},
+			providerSpec:     machineSetProviderSpec,
+			expectedWarnings: []string{expectedWarning},
+		},
+		{
+			name:         "with a denied request",
+			object:       &machinev1beta1.Machine{Spec: machinev1beta1.MachineSpec{ProviderSpec: machinev1beta1.ProviderSpec{Value: &kruntime.RawExtension{Raw: []byte(legacyProviderSpec)}}}},
+			providerSpec: machineProviderSpec,
+			denied:       true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			raw, err := json.Marshal(tc.object)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			h := &deprecatedGroupWarningHandler{
+				Handler: admission.HandlerFunc(func(context.Context, admission.Request) admission.Response {
+					if tc.denied {
+						return admission.Denied("denied")
+					}
+					return admission.Allowed("")
+				}),
+				platform:     osconfigv1.AWSPlatformType,
+				providerSpec: tc.providerSpec,
+			}
+
+			resp := h.Handle(context.Background(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+				Object: kruntime.RawExtension{Raw: raw},
+			}})
+			g.Expect(resp.Allowed).To(Equal(!tc.denied))
+			g.Expect(resp.Warnings).To(Equal(tc.expectedWarnings))
+		})
+	}
+}
+
+func TestNormalizeProviderSpecGroup(t *testing.T) {
+	awsPlatformStatus := &osconfigv1.PlatformStatus{
+		Type: osconfigv1.AWSPlatformType,
+		AWS:  &osconfigv1.AWSPlatformStatus{Region: "us-east-1"},
+	}
+
+	testCases := []struct {
+		name               string
+		platformStatus     *osconfigv1.PlatformStatus
+		providerSpec       string
+		expectedAPIVersion string
+		expectUnchanged    bool
+	}{
+		{
+			name:               "with the legacy AWS group",
+			platformStatus:     awsPlatformStatus,
+			providerSpec:       `{"apiVersion":"awsproviderconfig.openshift.io/v1beta1","kind":"AWSMachineProviderConfig","instanceType":"m5.large"}`,
+			expectedAPIVersion: "machine.openshift.io/v1beta1",
+		},
+		{
+			name:               "with the canonical group",
+			platformStatus:     awsPlatformStatus,
+			providerSpec:       `{"apiVersion":"machine.openshift.io/v1beta1","kind":"AWSMachineProviderConfig","instanceType":"m5.large"}`,
+			expectedAPIVersion: "machine.openshift.io/v1beta1",
+			expectUnchanged:    true,
+		},
+		{
+			name:               "with the legacy group of another platform",
+			platformStatus:     awsPlatformStatus,
+			providerSpec:       `{"apiVersion":"gcpprovider.openshift.io/v1beta1","kind":"AWSMachineProviderConfig","instanceType":"m5.large"}`,
+			expectedAPIVersion: "gcpprovider.openshift.io/v1beta1",
+			expectUnchanged:    true,
+		},
+		{
+			name:               "with the legacy GCP group",
+			platformStatus:     &osconfigv1.PlatformStatus{Type: osconfigv1.GCPPlatformType},
+			providerSpec:       `{"apiVersion":"gcpprovider.openshift.io/v1beta1","kind":"GCPMachineProviderSpec","machineType":"n1-standard-4"}`,
+			expectedAPIVersion: "machine.openshift.io/v1beta1",
+		},
+		{
+			name:               "on a platform without a legacy group",
+			platformStatus:     &osconfigv1.PlatformStatus{Type: osconfigv1.NutanixPlatformType},
+			providerSpec:       `{"apiVersion":"machine.openshift.io/v1","kind":"NutanixMachineProviderConfig"}`,
+			expectedAPIVersion: "machine.openshift.io/v1",
+			expectUnchanged:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			m := &machinev1beta1.Machine{
+				Spec: machinev1beta1.MachineSpec{
+					ProviderSpec: machinev1beta1.ProviderSpec{
+						Value: &kruntime.RawExtension{Raw: []byte(tc.providerSpec)},
+					},
+				},
+			}
+
+			normalizeProviderSpecGroup(m, tc.platformStatus)
+			if tc.expectUnchanged {
+				g.Expect(string(m.Spec.ProviderSpec.Value.Raw)).To(Equal(tc.providerSpec))
+			}
+
+			typeMeta := metav1.TypeMeta{}
+			g.Expect(json.Unmarshal(m.Spec.ProviderSpec.Value.Raw, &typeMeta)).To(Succeed())
+			g.Expect(typeMeta.APIVersion).To(Equal(tc.expectedAPIVersion))
+		})
+	}
+
+	t.Run("when defaulting an AWS Machine with the legacy group", func(t *testing.T) {
+		g := NewWithT(t)
+
+		m := &machinev1beta1.Machine{
+			Spec: machinev1beta1.MachineSpec{
+				ProviderSpec: machinev1beta1.ProviderSpec{
+					Value: &kruntime.RawExtension{Raw: []byte(`{"apiVersion":"awsproviderconfig.openshift.io/v1beta1","kind":"AWSMachineProviderConfig"}`)},
+				},
+			},
+		}
+
+		h := createMachineDefaulter(awsPlatformStatus, "clusterID")
+		g.Expect(h.Default(context.Background(), m)).To(Succeed())
+
+		providerSpec := &machinev1beta1.AWSMachineProviderConfig{}
+		g.Expect(json.Unmarshal(m.Spec.ProviderSpec.Value.Raw, providerSpec)).To(Succeed())
+		g.Expect(providerSpec.APIVersion).To(Equal("machine.openshift.io/v1beta1"))
+		g.Expect(providerSpec.Kind).To(Equal("AWSMachineProviderConfig"))
+		g.Expect(providerSpec.Placement.Region).To(Equal("us-east-1"))
+	})
+}
+
+func TestInflateProviderSpec(t *testing.T) {
+	awsPlatformStatus := &osconfigv1.PlatformStatus{
+		Type: osconfigv1.AWSPlatformType,
+		AWS:  &osconfigv1.AWSPlatformStatus{Region: "us-east-1"},
+	}
+
+	gzipped := &bytes.Buffer{}
+	w := gzip.NewWriter(gzipped)
+	_, err := w.Write([]byte(`{"apiVersion":"machine.openshift.io/v1beta1","kind":"AWSMachineProviderConfig","instanceType":"m5.large"}`))
+	NewWithT(t).Expect(err).ToNot(HaveOccurred())
+	NewWithT(t).Expect(w.Close()).To(Succeed())
+
+	testCases := []struct {
+		name                 string
+		operation            admissionv1.Operation
+		providerSpec         []byte
+		expectedInstanceType string
+		expectedRegion       string
+		expectedError        string
+	}{
+		{
+			name:                 "with a plain providerSpec",
+			providerSpec:         []byte(`{"apiVersion":"machine.openshift.io/v1beta1","kind":"AWSMachineProviderConfig","instanceType":"m5.large"}`),
+			expectedInstanceType: "m5.large",
+			expectedRegion:       "us-east-1",
+		},
+		{
+			name:                 "with a gzipped base64 providerSpec",
+			providerSpec:         []byte(fmt.Sprintf("%q", base64.StdEncoding.EncodeToString(gzipped.Bytes()))),
+			expectedInstanceType: "m5.large",
+			expectedRegion:       "us-east-1",
+		},
+		{
+			name:                 "with a gzipped base64 providerSpec on update",
+			operation:            admissionv1.Update,
+			providerSpec:         []byte(fmt.Sprintf("%q", base64.StdEncoding.EncodeToString(gzipped.Bytes()))),
+			expectedInstanceType: "m5.large",
+		},
+		{
+			name:          "with a corrupt gzipped base64 providerSpec",
+			providerSpec:  []byte(fmt.Sprintf("%q", base64.StdEncoding.EncodeToString(gzipped.Bytes()[:16]))),
+			expectedError: "providerSpec.value: Invalid value: failed to inflate gzipped providerSpec: unexpected EOF",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			m := &machinev1beta1.Machine{
+				Spec: machinev1beta1.MachineSpec{
+					ProviderSpec: machinev1beta1.ProviderSpec{
+						Value: &kruntime.RawExtension{Raw: tc.providerSpec},
+					},
+				},
+			}
+
+			ctx := admission.NewContextWithRequest(context.Background(), admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{Operation: tc.operation},
+			})
+			h := createMachineDefaulter(awsPlatformStatus, "clusterID")
+			err := h.Default(ctx, m)
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.expectedError)))
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+
+			providerSpec := &machinev1beta1.AWSMachineProviderConfig{}
+			g.Expect(json.Unmarshal(m.Spec.ProviderSpec.Value.Raw, providerSpec)).To(Succeed())
+			g.Expect(providerSpec.InstanceType).To(Equal(tc.expectedInstanceType))
+			g.Expect(providerSpec.Placement.Region).To(Equal(tc.expectedRegion))
+		})
+	}
+}
+
+func TestValidateMachineDeletion(t *testing.T) {
+	testCases := []struct {
+		name          string
+		annotations   map[string]string
+		expectedError string
+	}{
+		{
+			name: "with an unprotected Machine",
+		},
+		{
+			name:          "with a protected Machine",
+			annotations:   map[string]string{MachineProtectedAnnotation: "true"},
+			expectedError: "metadata.annotations[machine.openshift.io/protected]: Forbidden: Machine is protected from deletion, set the machine.openshift.io/force-delete annotation to \"true\" to delete it",
+		},
+		{
+			name: "with a protected Machine forced to be deleted",
+			annotations: map[string]string{
+				MachineProtectedAnnotation:   "true",
+				MachineForceDeleteAnnotation: "true",
+			},
+		},
+		{
+			name:        "with a protected annotation which is not true",
+			annotations: map[string]string{MachineProtectedAnnotation: "false"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			m := &machinev1beta1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test",
+					Namespace:   "openshift-machine-api",
+					Annotations: tc.annotations,
+				},
+			}
+
+			h := &machineValidatorHandler{}
+			_, err := h.ValidateDelete(context.Background(), m)
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestValidateAdoptProviderIDAnnotation(t *testing.T) {
+	const providerID = "vsphere://4225d5f2-6d19-4a0a-b3f8-8e6e0f0e7a1c"
+	const expectedError = "metadata.annotations[machine.openshift.io/adopt-provider-id]: Forbidden: the annotation is immutable, it can only be set when the Machine is created"
+
+	testCases := []struct {
+		name           string
+		oldAnnotations map[string]string
+		annotations    map[string]string
+		create         bool
+		expectedError  string
+	}{
+		{
+			name:        "when the annotation is set on create",
+			annotations: map[string]string{machinecontroller.AdoptProviderIDAnnotation: providerID},
+			create:      true,
+		},
+		{
+			name:           "when the annotation is unchanged",
+			oldAnnotations: map[string]string{machinecontroller.AdoptProviderIDAnnotation: providerID},
+			annotations:    map[string]string{machinecontroller.AdoptProviderIDAnnotation: providerID, "other": "value"},
+		},
+		{
+			name:          "when the annotation is added",
+			annotations:   map[string]string{machinecontroller.AdoptProviderIDAnnotation: providerID},
+			expectedError: expectedError,
+		},
+		{
+			name:           "when the annotation is changed",
+			oldAnnotations: map[string]string{machinecontroller.AdoptProviderIDAnnotation: providerID},
+			annotations:    map[string]string{machinecontroller.AdoptProviderIDAnnotation: "vsphere://4225d5f2-0000-0000-0000-8e6e0f0e7a1c"},
+			expectedError:  expectedError,
+		},
+		{
+			name:           "when the annotation is removed",
+			oldAnnotations: map[string]string{machinecontroller.AdoptProviderIDAnnotation: providerID},
+			expectedError:  expectedError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			m := &machinev1beta1.Machine{ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations}}
+			var oldM *machinev1beta1.Machine
+			if !tc.create {
+				oldM = &machinev1beta1.Machine{ObjectMeta: metav1.ObjectMeta{Annotations: tc.oldAnnotations}}
+			}
+
+			errs := validateAdoptProviderIDAnnotation(m, oldM)
+			if tc.expectedError != "" {
+				g.Expect(errs.ToAggregate()).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+}
+
 func TestValidateAzureCapacityReservationGroupID(t *testing.T) {
 	testCases := []struct {
 		name        string
@@ -5510,3 +6781,232 @@ func TestValidateAzureCapacityReservationGroupID(t *testing.T) {
 		})
 	}
 }
+
+func TestCredentialsSecretWarnings(t *testing.T) {
+	testCases := []struct {
+		name             string
+		data             map[string][]byte
+		expectedKeys     credentialsSecretKeys
+		expectedWarnings []string
+	}{
+		{
+			name:         "with a well-formed AWS secret",
+			data:         map[string][]byte{"aws_access_key_id": []byte("id"), "aws_secret_access_key": []byte("secret")},
+			expectedKeys: awsCredentialsSecretKeys,
+		},
+		{
+			name:         "with an AWS shared credentials file",
+			data:         map[string][]byte{"credentials": []byte("[default]\nrole_arn = arn:aws:iam::123456789012:role/role")},
+			expectedKeys: awsCredentialsSecretKeys,
+		},
+		{
+			name:             "with an AWS secret missing the secret access key",
+			data:             map[string][]byte{"aws_access_key_id": []byte("id")},
+			expectedKeys:     awsCredentialsSecretKeys,
+			expectedWarnings: []string{"providerSpec.credentialsSecret: Invalid value: \"secret\": is missing keys aws_secret_access_key. Expected CredentialsSecret to contain either aws_access_key_id, aws_secret_access_key or credentials"},
+		},
+		{
+			name:         "with a well-formed Azure secret",
+			data:         map[string][]byte{"azure_subscription_id": []byte("subscription"), "azure_client_id": []byte("client"), "azure_tenant_id": []byte("tenant"), "azure_client_secret": []byte("secret")},
+			expectedKeys: azureCredentialsSecretKeys,
+		},
+		{
+			name:         "with an Azure workload identity secret",
+			data:         map[string][]byte{"azure_subscription_id": []byte("subscription"), "azure_client_id": []byte("client"), "azure_tenant_id": []byte("tenant"), "azure_federated_token_file": []byte("/var/run/secrets/token")},
+			expectedKeys: azureCredentialsSecretKeys,
+		},
+		{
+			name:             "with an Azure secret missing the client id",
+			data:             map[string][]byte{"azure_subscription_id": []byte("subscription"), "azure_tenant_id": []byte("tenant"), "azure_client_secret": []byte("secret")},
+			expectedKeys:     azureCredentialsSecretKeys,
+			expectedWarnings: []string{"providerSpec.credentialsSecret: Invalid value: \"secret\": is missing keys azure_client_id. Expected CredentialsSecret to contain either azure_subscription_id, azure_client_id, azure_tenant_id, azure_client_secret or azure_subscription_id, azure_client_id, azure_tenant_id, azure_federated_token_file"},
+		},
+		{
+			name:         "with a well-formed GCP secret",
+			data:         map[string][]byte{"service_account.json": []byte("{}")},
+			expectedKeys: gcpCredentialsSecretKeys,
+		},
+		{
+			name:             "with a GCP secret with an empty service account",
+			data:             map[string][]byte{"service_account.json": {}},
+			expectedKeys:     gcpCredentialsSecretKeys,
+			expectedWarnings: []string{"providerSpec.credentialsSecret: Invalid value: \"secret\": is missing keys service_account.json. Expected CredentialsSecret to contain service_account.json"},
+		},
+		{
+			name:         "with a well-formed vSphere secret",
+			data:         map[string][]byte{"vcenter.username": []byte("username"), "vcenter.password": []byte("password")},
+			expectedKeys: vSphereCredentialsSecretKeys(&machinev1beta1.Workspace{Server: "vcenter"}),
+		},
+		{
+			name:             "with a vSphere secret for another vCenter",
+			data:             map[string][]byte{"other.username": []byte("username"), "other.password": []byte("password")},
+			expectedKeys:     vSphereCredentialsSecretKeys(&machinev1beta1.Workspace{Server: "vcenter"}),
+			expectedWarnings: []string{"providerSpec.credentialsSecret: Invalid value: \"secret\": is missing keys vcenter.username, vcenter.password. Expected CredentialsSecret to contain vcenter.username, vcenter.password"},
+		},
+		{
+			name:         "with a vSphere secret and no workspace server",
+			data:         map[string][]byte{"other.username": []byte("username"), "other.password": []byte("password")},
+			expectedKeys: vSphereCredentialsSecretKeys(&machinev1beta1.Workspace{}),
+		},
+		{
+			name:         "with a well-formed Nutanix secret",
+			data:         map[string][]byte{"credentials": []byte("[]")},
+			expectedKeys: nutanixCredentialsSecretKeys,
+		},
+		{
+			name:             "with an empty Nutanix secret",
+			expectedKeys:     nutanixCredentialsSecretKeys,
+			expectedWarnings: []string{"providerSpec.credentialsSecret: Invalid value: \"secret\": is missing keys credentials. Expected CredentialsSecret to contain credentials"},
+		},
+		{
+			name:         "with a well-formed PowerVS secret",
+			data:         map[string][]byte{"ibmcloud_api_key": []byte("key")},
+			expectedKeys: powerVSCredentialsSecretKeys,
+		},
+		{
+			name:             "with a PowerVS secret holding an AWS key",
+			data:             map[string][]byte{"aws_access_key_id": []byte("id")},
+			expectedKeys:     powerVSCredentialsSecretKeys,
+			expectedWarnings: []string{"providerSpec.credentialsSecret: Invalid value: \"secret\": is missing keys ibmcloud_api_key. Expected CredentialsSecret to contain ibmcloud_api_key"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "secret",
+					Namespace: "default",
+				},
+				Data: tc.data,
+			}
+			c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(secret).Build()
+
+			g.Expect(credentialsSecretWarnings(c, "secret", "default", tc.expectedKeys)).To(ConsistOf(tc.expectedWarnings))
+		})
+	}
+
+	t.Run("with a missing secret", func(t *testing.T) {
+		g := NewWithT(t)
+
+		c := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+
+		g.Expect(credentialsSecretWarnings(c, "secret", "default", awsCredentialsSecretKeys)).To(ConsistOf(
+			"providerSpec.credentialsSecret: Invalid value: \"secret\": not found. Expected CredentialsSecret to exist",
+		))
+	})
+}
+
+func TestDefaultInstanceTypeFromMachineArchitecture(t *testing.T) {
+	platformStatuses := map[osconfigv1.PlatformType]*osconfigv1.PlatformStatus{
+		osconfigv1.AWSPlatformType: {
+			Type: osconfigv1.AWSPlatformType,
+			AWS:  &osconfigv1.AWSPlatformStatus{Region: "region"},
+		},
+		osconfigv1.GCPPlatformType: {
+			Type: osconfigv1.GCPPlatformType,
+			GCP:  &osconfigv1.GCPPlatformStatus{ProjectID: "projectID"},
+		},
+	}
+
+	testCases := []struct {
+		testCase             string
+		platformType         osconfigv1.PlatformType
+		labels               map[string]string
+		nodeLabels           map[string]string
+		expectedInstanceType string
+		expectedDiskImage    string
+	}{
+		{
+			testCase:             "AWS with an arm64 node label",
+			platformType:         osconfigv1.AWSPlatformType,
+			nodeLabels:           map[string]string{machineArchLabel: string(ARM64)},
+			expectedInstanceType: defaultAWSARMInstanceType,
+		},
+		{
+			testCase:             "AWS with an arm64 machine label",
+			platformType:         osconfigv1.AWSPlatformType,
+			labels:               map[string]string{machineArchLabel: string(ARM64)},
+			expectedInstanceType: defaultAWSARMInstanceType,
+		},
+		{
+			testCase:             "AWS with an amd64 node label",
+			platformType:         osconfigv1.AWSPlatformType,
+			nodeLabels:           map[string]string{machineArchLabel: string(AMD64)},
+			expectedInstanceType: defaultAWSX86InstanceType,
+		},
+		{
+			testCase:             "AWS without an architecture label",
+			platformType:         osconfigv1.AWSPlatformType,
+			expectedInstanceType: defaultInstanceTypeForCloudProvider(osconfigv1.AWSPlatformType, arch, &[]string{}),
+		},
+		{
+			testCase:             "GCP with an arm64 node label",
+			platformType:         osconfigv1.GCPPlatformType,
+			nodeLabels:           map[string]string{machineArchLabel: string(ARM64)},
+			expectedInstanceType: defaultGCPARMMachineType,
+			expectedDiskImage:    defaultGCPARMDiskImage,
+		},
+		{
+			testCase:             "GCP with an arm64 machine label",
+			platformType:         osconfigv1.GCPPlatformType,
+			labels:               map[string]string{machineArchLabel: string(ARM64)},
+			expectedInstanceType: defaultGCPARMMachineType,
+			expectedDiskImage:    defaultGCPARMDiskImage,
+		},
+		{
+			testCase:             "GCP without an architecture label",
+			platformType:         osconfigv1.GCPPlatformType,
+			expectedInstanceType: defaultInstanceTypeForCloudProvider(osconfigv1.GCPPlatformType, arch, &[]string{}),
+			expectedDiskImage:    defaultGCPDiskImage(arch),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCase, func(t *testing.T) {
+			g := NewWithT(t)
+
+			h := createMachineDefaulter(platformStatuses[tc.platformType], "clusterID")
+
+			m := &machinev1beta1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Labels: tc.labels},
+			}
+			m.Spec.Labels = tc.nodeLabels
+			m.Spec.ProviderSpec.Value = &kruntime.RawExtension{Raw: []byte("{}")}
+
+			ok, warnings, errs := h.webhookOperations(m, h.admissionConfig)
+			g.Expect(errs).To(BeEmpty())
+			g.Expect(ok).To(BeTrue())
+			g.Expect(warnings).To(ContainElement(ContainSubstring(fmt.Sprintf("setting the default instance type %q", tc.expectedInstanceType))))
+
+			switch tc.platformType {
+			case osconfigv1.AWSPlatformType:
+				providerSpec := new(machinev1beta1.AWSMachineProviderConfig)
+				g.Expect(yaml.Unmarshal(m.Spec.ProviderSpec.Value.Raw, providerSpec)).To(Succeed())
+				g.Expect(providerSpec.InstanceType).To(Equal(tc.expectedInstanceType))
+			case osconfigv1.GCPPlatformType:
+				providerSpec := new(machinev1beta1.GCPMachineProviderSpec)
+				g.Expect(yaml.Unmarshal(m.Spec.ProviderSpec.Value.Raw, providerSpec)).To(Succeed())
+				g.Expect(providerSpec.MachineType).To(Equal(tc.expectedInstanceType))
+				g.Expect(providerSpec.Disks).To(HaveLen(1))
+				g.Expect(providerSpec.Disks[0].Image).To(Equal(tc.expectedDiskImage))
+			}
+		})
+	}
+}
+
+// azureDataDisks returns count valid Azure data disks with unique luns and name suffixes.
+func azureDataDisks(count int) []machinev1beta1.DataDisk {
+	disks := make([]machinev1beta1.DataDisk, count)
+	for i := range disks {
+		disks[i] = machinev1beta1.DataDisk{
+			NameSuffix:     fmt.Sprintf("disk-%d", i),
+			DiskSizeGB:     4,
+			Lun:            int32(i),
+			DeletionPolicy: machinev1beta1.DiskDeletionPolicyTypeDelete,
+		}
+	}
+	return disks
+}
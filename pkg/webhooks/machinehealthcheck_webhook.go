@@ -0,0 +1,107 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/kube-storage-version-migrator/pkg/clients/clientset/scheme"
+)
+
+// maxUnhealthyPercentRegexp matches the percentages accepted by maxUnhealthy, e.g. "40%".
+var maxUnhealthyPercentRegexp = regexp.MustCompile(`^[0-9]+%$`)
+
+// machineHealthCheckValidatorHandler validates MachineHealthCheck API resources.
+// implements type Handler interface.
+// https://godoc.org/github.com/kubernetes-sigs/controller-runtime/pkg/webhook/admission#Handler
+type machineHealthCheckValidatorHandler struct{}
+
+// NewMachineHealthCheckValidator returns a new machineHealthCheckValidatorHandler.
+func NewMachineHealthCheckValidator() *admission.Webhook {
+	return admission.WithCustomValidator(scheme.Scheme, &machinev1beta1.MachineHealthCheck{}, &machineHealthCheckValidatorHandler{})
+}
+
+// ValidateCreate validates the MachineHealthCheck on creation.
+func (h *machineHealthCheckValidatorHandler) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	mhc, ok := obj.(*machinev1beta1.MachineHealthCheck)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a MachineHealthCheck but got a %T", obj))
+	}
+
+	klog.V(3).Infof("Validate webhook called for MachineHealthCheck: %s", mhc.GetName())
+
+	return nil, validateMachineHealthCheckSpec(mhc, true).ToAggregate()
+}
+
+// ValidateUpdate validates the MachineHealthCheck on update. The selector is only validated when it
+// changes, so that MachineHealthChecks created with an empty selector before the webhook existed can
+// still be updated.
+func (h *machineHealthCheckValidatorHandler) ValidateUpdate(ctx context.Context, oldObj, obj runtime.Object) (admission.Warnings, error) {
+	mhc, ok := obj.(*machinev1beta1.MachineHealthCheck)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a MachineHealthCheck but got a %T", obj))
+	}
+	oldMHC, ok := oldObj.(*machinev1beta1.MachineHealthCheck)
+	if !ok {
+		return nil, apierrors.NewBadRequest(fmt.Sprintf("expected a MachineHealthCheck but got a %T", oldObj))
+	}
+
+	klog.V(3).Infof("Validate webhook called for MachineHealthCheck: %s", mhc.GetName())
+
+	selectorChanged := !equality.Semantic.DeepEqual(oldMHC.Spec.Selector, mhc.Spec.Selector)
+	return nil, validateMachineHealthCheckSpec(mhc, selectorChanged).ToAggregate()
+}
+
+// ValidateDelete allows the deletion of any MachineHealthCheck.
+func (h *machineHealthCheckValidatorHandler) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateMachineHealthCheckSpec validates the spec of the MachineHealthCheck, including its selector
+// when validateSelector is true.
+func validateMachineHealthCheckSpec(mhc *machinev1beta1.MachineHealthCheck, validateSelector bool) field.ErrorList {
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	if validateSelector {
+		// An empty selector matches every Machine of the namespace, control plane Machines included
+		selectorPath := specPath.Child("selector")
+		if len(mhc.Spec.Selector.MatchLabels) == 0 && len(mhc.Spec.Selector.MatchExpressions) == 0 {
+			errs = append(errs, field.Required(selectorPath, "selector must not be empty"))
+		} else if _, err := metav1.LabelSelectorAsSelector(&mhc.Spec.Selector); err != nil {
+			errs = append(errs, field.Invalid(selectorPath, mhc.Spec.Selector, fmt.Sprintf("could not convert label selector to selector: %v", err)))
+		}
+	}
+
+	conditionsPath := specPath.Child("unhealthyConditions")
+	if len(mhc.Spec.UnhealthyConditions) == 0 {
+		errs = append(errs, field.Required(conditionsPath, "at least one unhealthy condition is required"))
+	}
+	for i, condition := range mhc.Spec.UnhealthyConditions {
+		// A zero timeout is used to remediate as soon as the condition is met, e.g. for terminating instances
+		if condition.Timeout.Duration < 0 {
+			errs = append(errs, field.Invalid(conditionsPath.Index(i).Child("timeout"), condition.Timeout.Duration.String(), "timeout must be greater than or equal to 0"))
+		}
+	}
+
+	if maxUnhealthy := mhc.Spec.MaxUnhealthy; maxUnhealthy != nil {
+		maxUnhealthyPath := specPath.Child("maxUnhealthy")
+		switch {
+		case maxUnhealthy.Type == intstr.Int && maxUnhealthy.IntVal < 0:
+			errs = append(errs, field.Invalid(maxUnhealthyPath, maxUnhealthy.IntVal, "maxUnhealthy must be greater than or equal to 0"))
+		case maxUnhealthy.Type == intstr.String && !maxUnhealthyPercentRegexp.MatchString(maxUnhealthy.StrVal):
+			errs = append(errs, field.Invalid(maxUnhealthyPath, maxUnhealthy.StrVal, "maxUnhealthy must be an integer or a percentage, e.g. 40%"))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,182 @@
+package webhooks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+)
+
+func TestValidateMachineHealthCheck(t *testing.T) {
+	validSpec := func() machinev1beta1.MachineHealthCheckSpec {
+		return machinev1beta1.MachineHealthCheckSpec{
+			Selector: metav1.LabelSelector{
+				MatchLabels: map[string]string{"machine.openshift.io/cluster-api-machine-role": "worker"},
+			},
+			UnhealthyConditions: []machinev1beta1.UnhealthyCondition{
+				{
+					Type:    corev1.NodeReady,
+					Status:  corev1.ConditionFalse,
+					Timeout: metav1.Duration{Duration: 5 * time.Minute},
+				},
+			},
+			MaxUnhealthy: ptr.To(intstr.FromString("40%")),
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		modify        func(*machinev1beta1.MachineHealthCheckSpec)
+		expectedError string
+	}{
+		{
+			name:   "with a valid spec",
+			modify: func(*machinev1beta1.MachineHealthCheckSpec) {},
+		},
+		{
+			name: "with an integer maxUnhealthy",
+			modify: func(spec *machinev1beta1.MachineHealthCheckSpec) {
+				spec.MaxUnhealthy = ptr.To(intstr.FromInt32(2))
+			},
+		},
+		{
+			name: "without maxUnhealthy",
+			modify: func(spec *machinev1beta1.MachineHealthCheckSpec) {
+				spec.MaxUnhealthy = nil
+			},
+		},
+		{
+			name: "with a malformed maxUnhealthy percentage",
+			modify: func(spec *machinev1beta1.MachineHealthCheckSpec) {
+				spec.MaxUnhealthy = ptr.To(intstr.FromString("50%%"))
+			},
+			expectedError: "spec.maxUnhealthy: Invalid value: \"50%%\": maxUnhealthy must be an integer or a percentage, e.g. 40%",
+		},
+		{
+			name: "with a non numeric maxUnhealthy",
+			modify: func(spec *machinev1beta1.MachineHealthCheckSpec) {
+				spec.MaxUnhealthy = ptr.To(intstr.FromString("all"))
+			},
+			expectedError: "spec.maxUnhealthy: Invalid value: \"all\": maxUnhealthy must be an integer or a percentage, e.g. 40%",
+		},
+		{
+			name: "with a negative maxUnhealthy",
+			modify: func(spec *machinev1beta1.MachineHealthCheckSpec) {
+				spec.MaxUnhealthy = ptr.To(intstr.FromInt32(-1))
+			},
+			expectedError: "spec.maxUnhealthy: Invalid value: -1: maxUnhealthy must be greater than or equal to 0",
+		},
+		{
+			name: "without unhealthy conditions",
+			modify: func(spec *machinev1beta1.MachineHealthCheckSpec) {
+				spec.UnhealthyConditions = nil
+			},
+			expectedError: "spec.unhealthyConditions: Required value: at least one unhealthy condition is required",
+		},
+		{
+			name: "with a zero condition timeout",
+			modify: func(spec *machinev1beta1.MachineHealthCheckSpec) {
+				spec.UnhealthyConditions[0].Timeout = metav1.Duration{}
+			},
+		},
+		{
+			name: "with a negative condition timeout",
+			modify: func(spec *machinev1beta1.MachineHealthCheckSpec) {
+				spec.UnhealthyConditions[0].Timeout = metav1.Duration{Duration: -time.Minute}
+			},
+			expectedError: "spec.unhealthyConditions[0].timeout: Invalid value: \"-1m0s\": timeout must be greater than or equal to 0",
+		},
+		{
+			name: "with an empty selector",
+			modify: func(spec *machinev1beta1.MachineHealthCheckSpec) {
+				spec.Selector = metav1.LabelSelector{}
+			},
+			expectedError: "spec.selector: Required value: selector must not be empty",
+		},
+		{
+			name: "with an invalid selector",
+			modify: func(spec *machinev1beta1.MachineHealthCheckSpec) {
+				spec.Selector = metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{
+							Key:      "machine.openshift.io/cluster-api-machine-role",
+							Operator: metav1.LabelSelectorOpIn,
+						},
+					},
+				}
+			},
+			expectedError: "spec.selector: Invalid value: ",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			mhc := &machinev1beta1.MachineHealthCheck{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test",
+					Namespace: "openshift-machine-api",
+				},
+				Spec: validSpec(),
+			}
+			tc.modify(&mhc.Spec)
+
+			oldMHC := mhc.DeepCopy()
+			oldMHC.Spec = validSpec()
+
+			h := &machineHealthCheckValidatorHandler{}
+			_, createErr := h.ValidateCreate(context.Background(), mhc)
+			_, updateErr := h.ValidateUpdate(context.Background(), oldMHC, mhc)
+			if tc.expectedError != "" {
+				g.Expect(createErr).To(MatchError(ContainSubstring(tc.expectedError)))
+				g.Expect(updateErr).To(MatchError(ContainSubstring(tc.expectedError)))
+			} else {
+				g.Expect(createErr).ToNot(HaveOccurred())
+				g.Expect(updateErr).ToNot(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestValidateMachineHealthCheckUpdateSelector(t *testing.T) {
+	g := NewWithT(t)
+
+	// MachineHealthChecks created with an empty selector before the webhook existed
+	oldMHC := &machinev1beta1.MachineHealthCheck{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "openshift-machine-api",
+		},
+		Spec: machinev1beta1.MachineHealthCheckSpec{
+			UnhealthyConditions: []machinev1beta1.UnhealthyCondition{
+				{
+					Type:    corev1.NodeReady,
+					Status:  corev1.ConditionFalse,
+					Timeout: metav1.Duration{Duration: 5 * time.Minute},
+				},
+			},
+		},
+	}
+	h := &machineHealthCheckValidatorHandler{}
+
+	// can still be updated while their selector is left unchanged
+	mhc := oldMHC.DeepCopy()
+	mhc.Spec.MaxUnhealthy = ptr.To(intstr.FromString("40%"))
+	_, err := h.ValidateUpdate(context.Background(), oldMHC, mhc)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	// but the selector of the others can't be changed to an empty one
+	workerMHC := mhc.DeepCopy()
+	workerMHC.Spec.Selector = metav1.LabelSelector{
+		MatchLabels: map[string]string{"machine.openshift.io/cluster-api-machine-role": "worker"},
+	}
+	_, err = h.ValidateUpdate(context.Background(), workerMHC, mhc)
+	g.Expect(err).To(MatchError(ContainSubstring("spec.selector: Required value: selector must not be empty")))
+}
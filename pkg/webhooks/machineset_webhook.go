@@ -3,10 +3,14 @@ package webhooks
 import (
 	"context"
 	"fmt"
+	"maps"
 	"reflect"
+	"strconv"
 
 	osconfigv1 "github.com/openshift/api/config/v1"
 	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/machine-api-operator/pkg/util/instancetypes"
+	msutil "github.com/openshift/machine-api-operator/pkg/util/machineset"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -15,16 +19,36 @@ import (
 
 	"k8s.io/component-base/featuregate"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 	"sigs.k8s.io/kube-storage-version-migrator/pkg/clients/clientset/scheme"
 )
 
+const (
+	// machineRoleLabel and machineTypeLabel group Machines by role, they are used amongst others
+	// by the cluster autoscaler when scaling from zero and by the console.
+	machineRoleLabel = "machine.openshift.io/cluster-api-machine-role"
+	machineTypeLabel = "machine.openshift.io/cluster-api-machine-type"
+
+	// defaultMachineRole is the role and type given to MachineSets which do not set them.
+	defaultMachineRole = "worker"
+
+	// VCPUQuotaSoftLimitAnnotation is the annotation of the cluster infrastructure giving the number of vCPUs
+	// above which a MachineSet is warned about exceeding the cloud account quota.
+	VCPUQuotaSoftLimitAnnotation = "machine.openshift.io/vcpu-quota-soft-limit"
+)
+
 // machineSetValidatorHandler validates MachineSet API resources.
 // implements type Handler interface.
 // https://godoc.org/github.com/kubernetes-sigs/controller-runtime/pkg/webhook/admission#Handler
 type machineSetValidatorHandler struct {
 	*admissionHandler
+
+	// platform is the platform of the cluster, used to look up the capacity of instance types.
+	platform osconfigv1.PlatformType
+	// vCPUQuotaWarnings enables the warnings for MachineSets requesting more vCPUs than the VCPUQuotaSoftLimitAnnotation.
+	vCPUQuotaWarnings bool
 }
 
 // machineSetDefaulterHandler defaults MachineSet API resources.
@@ -34,51 +58,85 @@ type machineSetDefaulterHandler struct {
 	*admissionHandler
 }
 
-// NewMachineSetValidator returns a new machineSetValidatorHandler.
-func NewMachineSetValidator(client client.Client, featureGate featuregate.MutableFeatureGate) (*admission.Webhook, error) {
-	infra, err := getInfra()
-	if err != nil {
-		return nil, err
-	}
-
-	dns, err := getDNS()
-	if err != nil {
-		return nil, err
-	}
-
-	return createMachineSetValidator(infra, client, dns, featureGate), nil
+// createMachineSetValidator returns the MachineSet validating webhook. With vCPUQuotaWarnings, MachineSets whose
+// replicas are estimated to request more vCPUs than the VCPUQuotaSoftLimitAnnotation of the cluster
+// infrastructure are warned about. The annotation is read from the client on every request.
+func createMachineSetValidator(infra *osconfigv1.Infrastructure, client client.Client, dns *osconfigv1.DNS, featureGate featuregate.MutableFeatureGate, vCPUQuotaWarnings bool) *admission.Webhook {
+	h := newMachineSetValidatorHandler(infra, client, dns.Spec.PublicZone == nil, featureGate)
+	h.vCPUQuotaWarnings = vCPUQuotaWarnings
+	return admission.WithCustomValidator(scheme.Scheme, &machinev1beta1.MachineSet{}, h)
 }
 
-func createMachineSetValidator(infra *osconfigv1.Infrastructure, client client.Client, dns *osconfigv1.DNS, featureGate featuregate.MutableFeatureGate) *admission.Webhook {
+func newMachineSetValidatorHandler(infra *osconfigv1.Infrastructure, client client.Client, dnsDisconnected bool, featureGate featuregate.MutableFeatureGate) *machineSetValidatorHandler {
 	admissionConfig := &admissionConfig{
-		dnsDisconnected: dns.Spec.PublicZone == nil,
-		clusterID:       infra.Status.InfrastructureName,
-		client:          client,
-		featureGates:    featureGate,
+		dnsDisconnected:       dnsDisconnected,
+		clusterID:             infra.Status.InfrastructureName,
+		client:                client,
+		featureGates:          featureGate,
+		nutanixFailureDomains: getNutanixFailureDomains(infra),
 	}
 
-	return admission.WithCustomValidator(scheme.Scheme, &machinev1beta1.MachineSet{}, &machineSetValidatorHandler{
+	return &machineSetValidatorHandler{
 		admissionHandler: &admissionHandler{
 			admissionConfig:   admissionConfig,
 			webhookOperations: getMachineValidatorOperation(infra.Status.PlatformStatus.Type),
 		},
-	})
+		platform: infra.Status.PlatformStatus.Type,
+	}
 }
 
-// NewMachineSetDefaulter returns a new machineSetDefaulterHandler.
-func NewMachineSetDefaulter() (*admission.Webhook, error) {
-	infra, err := getInfra()
-	if err != nil {
-		return nil, err
+// vCPUQuotaSoftLimit returns the vCPU soft limit of the cluster infrastructure, or zero when the warnings are
+// disabled or the limit is not set. The infrastructure is read on every request from the client, which is
+// expected to be the cached client of the manager, so that changes of the limit apply without a restart.
+func (h *machineSetValidatorHandler) vCPUQuotaSoftLimit(ctx context.Context) int64 {
+	if !h.vCPUQuotaWarnings || h.client == nil {
+		return 0
 	}
 
-	return createMachineSetDefaulter(infra.Status.PlatformStatus, infra.Status.InfrastructureName), nil
+	infra := &osconfigv1.Infrastructure{}
+	if err := h.client.Get(ctx, client.ObjectKey{Name: "cluster"}, infra); err != nil {
+		klog.Warningf("failed to get the cluster infrastructure, vCPU quota warnings are skipped: %v", err)
+		return 0
+	}
+	return getVCPUQuotaSoftLimit(infra)
+}
+
+// getVCPUQuotaSoftLimit returns the vCPU soft limit of the cluster infrastructure, or zero when the limit is not set.
+func getVCPUQuotaSoftLimit(infra *osconfigv1.Infrastructure) int64 {
+	value, ok := infra.Annotations[VCPUQuotaSoftLimitAnnotation]
+	if !ok {
+		return 0
+	}
+
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || limit <= 0 {
+		klog.Warningf("invalid %s annotation %q on infrastructure %s, expected a positive integer. vCPU quota warnings are disabled",
+			VCPUQuotaSoftLimitAnnotation, value, infra.Name)
+		return 0
+	}
+	return limit
+}
+
+// ValidateMachineSet validates a MachineSet with the same selector, template and providerSpec checks
+// as the MachineSet validating webhook, without a connection to a cluster. This allows MachineSet
+// manifests to be linted before they are applied.
+// The checks which need to read from the cluster, such as the existence of the credentials secret, are skipped.
+// The cluster is assumed to be connected when dns is nil. The MachineSet is validated as it is, without the
+// webhook defaulting.
+func ValidateMachineSet(infra *osconfigv1.Infrastructure, dns *osconfigv1.DNS, featureGate featuregate.MutableFeatureGate, ms *machinev1beta1.MachineSet) ([]string, error) {
+	h := newMachineSetValidatorHandler(infra, nil, isDNSDisconnected(dns), featureGate)
+
+	ok, warnings, errs := h.validateMachineSet(context.Background(), ms, nil)
+	if !ok {
+		return warnings, errs.ToAggregate()
+	}
+	return warnings, nil
 }
 
 func createMachineSetDefaulter(platformStatus *osconfigv1.PlatformStatus, clusterID string) *admission.Webhook {
 	return admission.WithCustomDefaulter(scheme.Scheme, &machinev1beta1.MachineSet{}, &machineSetDefaulterHandler{
 		admissionHandler: &admissionHandler{
-			admissionConfig:   &admissionConfig{clusterID: clusterID},
+			admissionConfig:   &admissionConfig{clusterID: clusterID, platformStatus: platformStatus},
 			webhookOperations: getMachineDefaulterOperation(platformStatus),
 		},
 	})
@@ -95,7 +153,7 @@ func (h *machineSetValidatorHandler) ValidateCreate(ctx context.Context, obj run
 
 	klog.V(3).Infof("Validate webhook called for MachineSet: %s", ms.GetName())
 
-	ok, warnings, errs := h.validateMachineSet(ms, nil)
+	ok, warnings, errs := h.validateMachineSet(ctx, ms, nil)
 	if !ok {
 		return warnings, errs.ToAggregate()
 	}
@@ -119,7 +177,7 @@ func (h *machineSetValidatorHandler) ValidateUpdate(ctx context.Context, oldObj,
 
 	klog.V(3).Infof("Validate webhook called for MachineSet: %s", ms.GetName())
 
-	ok, warnings, errs := h.validateMachineSet(ms, oldMS)
+	ok, warnings, errs := h.validateMachineSet(ctx, ms, oldMS)
 	if !ok {
 		return warnings, errs.ToAggregate()
 	}
@@ -138,7 +196,7 @@ func (h *machineSetValidatorHandler) ValidateDelete(ctx context.Context, obj run
 
 	klog.V(3).Infof("Validate webhook called for MachineSet: %s", ms.GetName())
 
-	ok, warnings, errs := h.validateMachineSet(ms, nil)
+	ok, warnings, errs := h.validateMachineSet(ctx, ms, nil)
 	if !ok {
 		return warnings, errs.ToAggregate()
 	}
@@ -155,6 +213,16 @@ func (h *machineSetDefaulterHandler) Default(ctx context.Context, obj runtime.Ob
 
 	klog.V(3).Infof("Mutate webhook called for MachineSet: %s", ms.GetName())
 
+	// Encoded providerSpecs are also inflated on update and legacy API groups are rewritten, the MachineSet
+	// controller copies the template to the Machines it creates. The MachineSet is otherwise only defaulted
+	// when it is created.
+	if isUpdateRequest(ctx) {
+		if err := normalizeTemplateProviderSpec(ms, h.platformStatus); err != nil {
+			return field.ErrorList{err}.ToAggregate()
+		}
+		return nil
+	}
+
 	ok, _, errs := h.defaultMachineSet(ms)
 	if !ok {
 		return errs.ToAggregate()
@@ -163,8 +231,9 @@ func (h *machineSetDefaulterHandler) Default(ctx context.Context, obj runtime.Ob
 	return nil
 }
 
-func (h *machineSetValidatorHandler) validateMachineSet(ms, oldMS *machinev1beta1.MachineSet) (bool, []string, field.ErrorList) {
+func (h *machineSetValidatorHandler) validateMachineSet(ctx context.Context, ms, oldMS *machinev1beta1.MachineSet) (bool, []string, field.ErrorList) {
 	errs := validateMachineSetSpec(ms, oldMS)
+	templateWarnings := validateMachineSetTemplate(ms)
 
 	// Create a Machine from the MachineSet and validate the Machine template
 	m := &machinev1beta1.Machine{
@@ -177,6 +246,8 @@ func (h *machineSetValidatorHandler) validateMachineSet(ms, oldMS *machinev1beta
 	if !ok {
 		errs = append(errs, opsErrs...)
 	}
+	warnings = append(templateWarnings, warnings...)
+	warnings = append(warnings, vCPUQuotaWarnings(ms, h.platform, h.vCPUQuotaSoftLimit(ctx))...)
 
 	if len(errs) > 0 {
 		return false, warnings, errs
@@ -185,8 +256,20 @@ func (h *machineSetValidatorHandler) validateMachineSet(ms, oldMS *machinev1beta
 }
 
 func (h *machineSetDefaulterHandler) defaultMachineSet(ms *machinev1beta1.MachineSet) (bool, []string, field.ErrorList) {
-	// Create a Machine from the MachineSet and default the Machine template
-	m := &machinev1beta1.Machine{Spec: ms.Spec.Template.Spec}
+	// Create a Machine from the MachineSet and default the Machine template.
+	// The template labels are copied, they may carry the architecture of the Machines.
+	m := &machinev1beta1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Labels: maps.Clone(ms.Spec.Template.Labels)},
+		Spec:       ms.Spec.Template.Spec,
+	}
+	if err := inflateProviderSpec(m); err != nil {
+		return false, nil, field.ErrorList{err}
+	}
+	normalizeProviderSpecGroup(m, h.platformStatus)
+	// The cluster tags are defaulted in the template as they are in the Machines, so that the Machines
+	// created from the template are not reported as drifted from it.
+	mergeClusterResourceTags(m, h.platformStatus)
+	defaultAWSClusterTag(m, h.platformStatus, h.clusterID)
 	ok, warnings, errs := h.webhookOperations(m, h.admissionConfig)
 	if !ok {
 		return false, warnings, errs
@@ -194,9 +277,45 @@ func (h *machineSetDefaulterHandler) defaultMachineSet(ms *machinev1beta1.Machin
 
 	// Restore the defaulted template
 	ms.Spec.Template.Spec = m.Spec
+	defaultMachineSetLabels(ms)
 	return true, warnings, nil
 }
 
+// normalizeTemplateProviderSpec inflates the encoded providerSpec of the Machine template of the MachineSet
+// and rewrites its legacy API group.
+func normalizeTemplateProviderSpec(ms *machinev1beta1.MachineSet, platformStatus *osconfigv1.PlatformStatus) *field.Error {
+	m := &machinev1beta1.Machine{Spec: ms.Spec.Template.Spec}
+	if err := inflateProviderSpec(m); err != nil {
+		return err
+	}
+	normalizeProviderSpecGroup(m, platformStatus)
+	ms.Spec.Template.Spec = m.Spec
+	return nil
+}
+
+// defaultMachineSetLabels sets the role and type labels of the Machine template to worker when they are not set.
+// When the MachineSet has no selector, the defaulted labels are also used as the selector so that it
+// matches the Machines created from the template.
+func defaultMachineSetLabels(ms *machinev1beta1.MachineSet) {
+	if ms.Spec.Template.Labels == nil {
+		ms.Spec.Template.Labels = map[string]string{}
+	}
+
+	selectorEmpty := len(ms.Spec.Selector.MatchLabels) == 0 && len(ms.Spec.Selector.MatchExpressions) == 0
+	for _, label := range []string{machineRoleLabel, machineTypeLabel} {
+		if _, ok := ms.Spec.Template.Labels[label]; !ok {
+			ms.Spec.Template.Labels[label] = defaultMachineRole
+		}
+
+		if selectorEmpty {
+			if ms.Spec.Selector.MatchLabels == nil {
+				ms.Spec.Selector.MatchLabels = map[string]string{}
+			}
+			ms.Spec.Selector.MatchLabels[label] = ms.Spec.Template.Labels[label]
+		}
+	}
+}
+
 // validateMachineSetSpec is used to validate any changes to the MachineSet spec outside of
 // the providerSpec. Eg it can be used to verify changes to the selector.
 func validateMachineSetSpec(ms, oldMS *machinev1beta1.MachineSet) field.ErrorList {
@@ -210,8 +329,64 @@ func validateMachineSetSpec(ms, oldMS *machinev1beta1.MachineSet) field.ErrorLis
 		errs = append(errs, field.Invalid(field.NewPath("spec", "selector"), ms.Spec.Selector, fmt.Sprintf("could not convert label selector to selector: %v", err)))
 	}
 	if selector != nil && !selector.Matches(labels.Set(ms.Spec.Template.Labels)) {
-		errs = append(errs, field.Invalid(field.NewPath("spec", "template", "metadata", "labels"), ms.Spec.Template.Labels, "`selector` does not match template `labels`"))
+		errs = append(errs, field.Invalid(field.NewPath("spec", "template", "metadata", "labels"), ms.Spec.Template.Labels, fmt.Sprintf("`selector` does not match template `labels`, mismatched label keys: %v", mismatchedSelectorKeys(selector, ms.Spec.Template.Labels))))
+	}
+
+	if ms.Spec.Replicas != nil && *ms.Spec.Replicas < 0 {
+		errs = append(errs, field.Invalid(field.NewPath("spec", "replicas"), *ms.Spec.Replicas, "replicas must be greater than or equal to 0"))
 	}
 
 	return errs
 }
+
+// validateMachineSetTemplate returns warnings for fields of the Machine template which are managed by the
+// machine controller. Setting them in a template shows a misunderstanding of how Machines are created.
+func validateMachineSetTemplate(ms *machinev1beta1.MachineSet) []string {
+	var warnings []string
+	if providerID := ms.Spec.Template.Spec.ProviderID; providerID != nil && *providerID != "" {
+		warnings = append(warnings, field.Invalid(field.NewPath("spec", "template", "spec", "providerID"), *providerID,
+			"providerID is set by the machine controller once the instance has been created and should not be set in a template, Machines created from it may be marked as Failed").Error())
+	}
+	return warnings
+}
+
+// vCPUQuotaWarnings returns a warning when the replicas of the MachineSet are estimated to request more vCPUs
+// than the soft limit. The estimate relies on the instance type catalogs, MachineSets with an unknown
+// instance type are not checked. This is advisory, as other Machines also consume the quota.
+func vCPUQuotaWarnings(ms *machinev1beta1.MachineSet, platform osconfigv1.PlatformType, softLimit int64) []string {
+	if softLimit <= 0 {
+		return nil
+	}
+
+	instanceType, err := msutil.InstanceTypeFromProviderSpec(ms.Spec.Template.Spec.ProviderSpec.Value)
+	if err != nil || instanceType == "" {
+		return nil
+	}
+
+	cpu, _, _, ok := instancetypes.Capacity(platform, instanceType)
+	if !ok {
+		return nil
+	}
+
+	replicas := int64(ptr.Deref(ms.Spec.Replicas, 0))
+	if requested := replicas * cpu; requested > softLimit {
+		return []string{field.Invalid(field.NewPath("spec", "replicas"), replicas,
+			fmt.Sprintf("requests an estimated %d vCPUs of %s instances, above the soft limit of %d vCPUs set by the %s annotation of the cluster infrastructure: Machines may fail to be created once the cloud account quota is exhausted",
+				requested, instanceType, softLimit, VCPUQuotaSoftLimitAnnotation)).Error()}
+	}
+	return nil
+}
+
+// mismatchedSelectorKeys returns the keys of the selector requirements which are not satisfied by the given labels.
+func mismatchedSelectorKeys(selector labels.Selector, templateLabels map[string]string) []string {
+	requirements, _ := selector.Requirements()
+
+	keys := []string{}
+	for _, r := range requirements {
+		if !r.Matches(labels.Set(templateLabels)) {
+			keys = append(keys, r.Key())
+		}
+	}
+
+	return keys
+}
@@ -2,6 +2,8 @@ package webhooks
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"testing"
@@ -12,17 +14,21 @@ import (
 	osconfigv1 "github.com/openshift/api/config/v1"
 	machinev1 "github.com/openshift/api/machine/v1"
 	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	testutils "github.com/openshift/machine-api-operator/pkg/util/testing"
 )
@@ -53,30 +59,50 @@ func TestMachineSetCreation(t *testing.T) {
 			Name:      defaultAWSCredentialsSecret,
 			Namespace: namespace.Name,
 		},
+		Data: map[string][]byte{
+			"aws_access_key_id":     []byte("id"),
+			"aws_secret_access_key": []byte("secret"),
+		},
 	}
 	vSphereSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      defaultVSphereCredentialsSecret,
 			Namespace: namespace.Name,
 		},
+		Data: map[string][]byte{
+			"server.username": []byte("username"),
+			"server.password": []byte("password"),
+		},
 	}
 	GCPSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      defaultGCPCredentialsSecret,
 			Namespace: namespace.Name,
 		},
+		Data: map[string][]byte{
+			"service_account.json": []byte("{}"),
+		},
 	}
 	azureSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      defaultAzureCredentialsSecret,
 			Namespace: defaultSecretNamespace,
 		},
+		Data: map[string][]byte{
+			"azure_subscription_id": []byte("subscription"),
+			"azure_client_id":       []byte("client"),
+			"azure_tenant_id":       []byte("tenant"),
+			"azure_client_secret":   []byte("secret"),
+		},
 	}
 	powerVSSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      defaultPowerVSCredentialsSecret,
 			Namespace: defaultSecretNamespace,
 		},
+		Data: map[string][]byte{
+			"ibmcloud_api_key": []byte("key"),
+		},
 	}
 	g.Expect(c.Create(ctx, awsSecret)).To(Succeed())
 	g.Expect(c.Create(ctx, vSphereSecret)).To(Succeed())
@@ -518,7 +544,7 @@ func TestMachineSetCreation(t *testing.T) {
 			}
 
 			machineSetDefaulter := createMachineSetDefaulter(platformStatus, tc.clusterID)
-			machineSetValidator := createMachineSetValidator(infra, c, dns, gate)
+			machineSetValidator := createMachineSetValidator(infra, c, dns, gate, false)
 			mgr.GetWebhookServer().Register(DefaultMachineSetMutatingHookPath, &webhook.Admission{Handler: machineSetDefaulter})
 			mgr.GetWebhookServer().Register(DefaultMachineSetValidatingHookPath, &webhook.Admission{Handler: machineSetValidator})
 
@@ -603,7 +629,7 @@ func TestMachineSetUpdate(t *testing.T) {
 		Subnet:               defaultAzureSubnet(azureClusterID),
 		NetworkResourceGroup: defaultAzureNetworkResourceGroup(azureClusterID),
 		Image: machinev1beta1.Image{
-			ResourceID: defaultAzureImageResourceID(azureClusterID),
+			ResourceID: defaultAzureImageResourceID(azureClusterID, arch),
 		},
 		ManagedIdentity: defaultAzureManagedIdentiy(azureClusterID),
 		ResourceGroup:   defaultAzureResourceGroup(azureClusterID),
@@ -641,7 +667,7 @@ func TestMachineSetUpdate(t *testing.T) {
 				Boot:       true,
 				SizeGB:     defaultGCPDiskSizeGb,
 				Type:       defaultGCPDiskType,
-				Image:      defaultGCPDiskImage(),
+				Image:      defaultGCPDiskImage(arch),
 			},
 		},
 		Tags: defaultGCPTags(gcpClusterID),
@@ -726,30 +752,50 @@ func TestMachineSetUpdate(t *testing.T) {
 			Name:      defaultAWSCredentialsSecret,
 			Namespace: namespace.Name,
 		},
+		Data: map[string][]byte{
+			"aws_access_key_id":     []byte("id"),
+			"aws_secret_access_key": []byte("secret"),
+		},
 	}
 	vSphereSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      defaultVSphereCredentialsSecret,
 			Namespace: namespace.Name,
 		},
+		Data: map[string][]byte{
+			"server.username": []byte("username"),
+			"server.password": []byte("password"),
+		},
 	}
 	GCPSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      defaultGCPCredentialsSecret,
 			Namespace: namespace.Name,
 		},
+		Data: map[string][]byte{
+			"service_account.json": []byte("{}"),
+		},
 	}
 	azureSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      defaultAzureCredentialsSecret,
 			Namespace: defaultSecretNamespace,
 		},
+		Data: map[string][]byte{
+			"azure_subscription_id": []byte("subscription"),
+			"azure_client_id":       []byte("client"),
+			"azure_tenant_id":       []byte("tenant"),
+			"azure_client_secret":   []byte("secret"),
+		},
 	}
 	powerVSSecret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      defaultPowerVSCredentialsSecret,
 			Namespace: defaultSecretNamespace,
 		},
+		Data: map[string][]byte{
+			"ibmcloud_api_key": []byte("key"),
+		},
 	}
 	g.Expect(c.Create(ctx, awsSecret)).To(Succeed())
 	g.Expect(c.Create(ctx, vSphereSecret)).To(Succeed())
@@ -1047,7 +1093,7 @@ func TestMachineSetUpdate(t *testing.T) {
 			updateMachineSet: func(ms *machinev1beta1.MachineSet) {
 				ms.Spec.Selector.MatchLabels["foo"] = "bar"
 			},
-			expectedError: "[spec.selector: Forbidden: selector is immutable, spec.template.metadata.labels: Invalid value: map[string]string{\"machineset-name\":\"machineset-update-abcd\"}: `selector` does not match template `labels`]",
+			expectedError: "[spec.selector: Forbidden: selector is immutable, spec.template.metadata.labels: Invalid value: map[string]string{\"machine.openshift.io/cluster-api-machine-role\":\"worker\", \"machine.openshift.io/cluster-api-machine-type\":\"worker\", \"machineset-name\":\"machineset-update-abcd\"}: `selector` does not match template `labels`, mismatched label keys: [foo]]",
 		},
 		{
 			name:         "with an incompatible template labels",
@@ -1061,7 +1107,7 @@ func TestMachineSetUpdate(t *testing.T) {
 					"foo": "bar",
 				}
 			},
-			expectedError: "spec.template.metadata.labels: Invalid value: map[string]string{\"foo\":\"bar\"}: `selector` does not match template `labels`",
+			expectedError: "spec.template.metadata.labels: Invalid value: map[string]string{\"foo\":\"bar\", \"machine.openshift.io/cluster-api-machine-role\":\"worker\", \"machine.openshift.io/cluster-api-machine-type\":\"worker\"}: `selector` does not match template `labels`, mismatched label keys: [machineset-name]",
 		},
 		{
 			name:         "with a valid PowerVS ProviderSpec",
@@ -1207,7 +1253,7 @@ func TestMachineSetUpdate(t *testing.T) {
 			}
 
 			machineSetDefaulter := createMachineSetDefaulter(platformStatus, tc.clusterID)
-			machineSetValidator := createMachineSetValidator(infra, c, plainDNS, gate)
+			machineSetValidator := createMachineSetValidator(infra, c, plainDNS, gate, false)
 			mgr.GetWebhookServer().Register(DefaultMachineSetMutatingHookPath, &webhook.Admission{Handler: machineSetDefaulter})
 			mgr.GetWebhookServer().Register(DefaultMachineSetValidatingHookPath, &webhook.Admission{Handler: machineSetValidator})
 
@@ -1284,3 +1330,521 @@ func TestMachineSetUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateMachineSetSpec(t *testing.T) {
+	testCases := []struct {
+		name          string
+		selector      metav1.LabelSelector
+		labels        map[string]string
+		replicas      *int32
+		expectedError string
+	}{
+		{
+			name: "with a selector matching the template labels",
+			selector: metav1.LabelSelector{
+				MatchLabels: map[string]string{"foo": "bar"},
+			},
+			labels:   map[string]string{"foo": "bar", "baz": "qux"},
+			replicas: ptr.To[int32](1),
+		},
+		{
+			name: "with a selector not matching the template labels",
+			selector: metav1.LabelSelector{
+				MatchLabels: map[string]string{"foo": "bar", "baz": "qux"},
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      "role",
+						Operator: metav1.LabelSelectorOpIn,
+						Values:   []string{"worker"},
+					},
+				},
+			},
+			labels:        map[string]string{"foo": "bar", "baz": "other", "role": "worker"},
+			replicas:      ptr.To[int32](1),
+			expectedError: "spec.template.metadata.labels: Invalid value: map[string]string{\"baz\":\"other\", \"foo\":\"bar\", \"role\":\"worker\"}: `selector` does not match template `labels`, mismatched label keys: [baz]",
+		},
+		{
+			name:     "with an empty selector",
+			selector: metav1.LabelSelector{},
+			labels:   map[string]string{"foo": "bar"},
+			replicas: ptr.To[int32](1),
+		},
+		{
+			name: "with nil replicas",
+			selector: metav1.LabelSelector{
+				MatchLabels: map[string]string{"foo": "bar"},
+			},
+			labels: map[string]string{"foo": "bar"},
+		},
+		{
+			name: "with negative replicas",
+			selector: metav1.LabelSelector{
+				MatchLabels: map[string]string{"foo": "bar"},
+			},
+			labels:        map[string]string{"foo": "bar"},
+			replicas:      ptr.To[int32](-1),
+			expectedError: "spec.replicas: Invalid value: -1: replicas must be greater than or equal to 0",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			ms := &machinev1beta1.MachineSet{
+				Spec: machinev1beta1.MachineSetSpec{
+					Replicas: tc.replicas,
+					Selector: tc.selector,
+					Template: machinev1beta1.MachineTemplateSpec{
+						ObjectMeta: machinev1beta1.ObjectMeta{
+							Labels: tc.labels,
+						},
+					},
+				},
+			}
+
+			errs := validateMachineSetSpec(ms, nil)
+			if tc.expectedError != "" {
+				g.Expect(errs.ToAggregate()).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(errs).To(BeEmpty())
+			}
+		})
+	}
+}
+
+func TestValidateMachineSetTemplate(t *testing.T) {
+	testCases := []struct {
+		name             string
+		providerID       *string
+		expectedWarnings []string
+	}{
+		{
+			name:       "with a clean template",
+			providerID: nil,
+		},
+		{
+			name:       "with an empty providerID",
+			providerID: ptr.To[string](""),
+		},
+		{
+			name:       "with a providerID",
+			providerID: ptr.To[string]("aws:///us-east-1a/i-0123456789abcdef0"),
+			expectedWarnings: []string{
+				"spec.template.spec.providerID: Invalid value: \"aws:///us-east-1a/i-0123456789abcdef0\": providerID is set by the machine controller once the instance has been created and should not be set in a template, Machines created from it may be marked as Failed",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			ms := &machinev1beta1.MachineSet{
+				Spec: machinev1beta1.MachineSetSpec{
+					Template: machinev1beta1.MachineTemplateSpec{
+						Spec: machinev1beta1.MachineSpec{
+							ProviderID: tc.providerID,
+						},
+					},
+				},
+			}
+
+			g.Expect(validateMachineSetTemplate(ms)).To(Equal(tc.expectedWarnings))
+		})
+	}
+}
+
+func TestMachineSetDefaulterOnUpdate(t *testing.T) {
+	g := NewWithT(t)
+
+	providerSpec := `{"apiVersion":"awsproviderconfig.openshift.io/v1beta1","kind":"AWSMachineProviderConfig","instanceType":"m5.large"}`
+	ms := &machinev1beta1.MachineSet{
+		Spec: machinev1beta1.MachineSetSpec{
+			Template: machinev1beta1.MachineTemplateSpec{
+				Spec: machinev1beta1.MachineSpec{
+					ProviderSpec: machinev1beta1.ProviderSpec{
+						Value: &runtime.RawExtension{Raw: []byte(fmt.Sprintf("%q", base64.StdEncoding.EncodeToString([]byte(providerSpec))))},
+					},
+				},
+			},
+		},
+	}
+
+	platformStatus := &osconfigv1.PlatformStatus{
+		Type: osconfigv1.AWSPlatformType,
+		AWS:  &osconfigv1.AWSPlatformStatus{Region: "us-east-1"},
+	}
+	h := &machineSetDefaulterHandler{
+		admissionHandler: &admissionHandler{
+			admissionConfig:   &admissionConfig{clusterID: "clusterID", platformStatus: platformStatus},
+			webhookOperations: getMachineDefaulterOperation(platformStatus),
+		},
+	}
+
+	ctx := admission.NewContextWithRequest(context.Background(), admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{Operation: admissionv1.Update},
+	})
+	g.Expect(h.Default(ctx, ms)).To(Succeed())
+
+	// The providerSpec is inflated and its legacy API group rewritten but it is not defaulted, nor are the template labels.
+	g.Expect(string(ms.Spec.Template.Spec.ProviderSpec.Value.Raw)).To(Equal(`{"apiVersion":"machine.openshift.io/v1beta1","instanceType":"m5.large","kind":"AWSMachineProviderConfig"}`))
+	g.Expect(ms.Spec.Template.Labels).To(BeEmpty())
+}
+
+func TestDefaultMachineSetLabels(t *testing.T) {
+	testCases := []struct {
+		name             string
+		selector         metav1.LabelSelector
+		templateLabels   map[string]string
+		expectedSelector metav1.LabelSelector
+		expectedLabels   map[string]string
+	}{
+		{
+			name: "without labels or selector",
+			expectedSelector: metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					machineRoleLabel: "worker",
+					machineTypeLabel: "worker",
+				},
+			},
+			expectedLabels: map[string]string{
+				machineRoleLabel: "worker",
+				machineTypeLabel: "worker",
+			},
+		},
+		{
+			name: "with a selector",
+			selector: metav1.LabelSelector{
+				MatchLabels: map[string]string{"foo": "bar"},
+			},
+			templateLabels: map[string]string{"foo": "bar"},
+			expectedSelector: metav1.LabelSelector{
+				MatchLabels: map[string]string{"foo": "bar"},
+			},
+			expectedLabels: map[string]string{
+				"foo":            "bar",
+				machineRoleLabel: "worker",
+				machineTypeLabel: "worker",
+			},
+		},
+		{
+			name: "with a selector using match expressions",
+			selector: metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "foo", Operator: metav1.LabelSelectorOpExists}},
+			},
+			templateLabels: map[string]string{"foo": "bar"},
+			expectedSelector: metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "foo", Operator: metav1.LabelSelectorOpExists}},
+			},
+			expectedLabels: map[string]string{
+				"foo":            "bar",
+				machineRoleLabel: "worker",
+				machineTypeLabel: "worker",
+			},
+		},
+		{
+			name: "with existing role and type labels",
+			selector: metav1.LabelSelector{
+				MatchLabels: map[string]string{machineRoleLabel: "infra"},
+			},
+			templateLabels: map[string]string{
+				machineRoleLabel: "infra",
+				machineTypeLabel: "infra",
+			},
+			expectedSelector: metav1.LabelSelector{
+				MatchLabels: map[string]string{machineRoleLabel: "infra"},
+			},
+			expectedLabels: map[string]string{
+				machineRoleLabel: "infra",
+				machineTypeLabel: "infra",
+			},
+		},
+		{
+			name: "with an existing role label and no selector",
+			templateLabels: map[string]string{
+				machineRoleLabel: "infra",
+			},
+			expectedSelector: metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					machineRoleLabel: "infra",
+					machineTypeLabel: "worker",
+				},
+			},
+			expectedLabels: map[string]string{
+				machineRoleLabel: "infra",
+				machineTypeLabel: "worker",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			ms := &machinev1beta1.MachineSet{
+				Spec: machinev1beta1.MachineSetSpec{
+					Selector: tc.selector,
+					Template: machinev1beta1.MachineTemplateSpec{
+						ObjectMeta: machinev1beta1.ObjectMeta{
+							Labels: tc.templateLabels,
+						},
+					},
+				},
+			}
+
+			defaultMachineSetLabels(ms)
+			g.Expect(ms.Spec.Selector).To(Equal(tc.expectedSelector))
+			g.Expect(ms.Spec.Template.Labels).To(Equal(tc.expectedLabels))
+		})
+	}
+}
+
+func TestValidateMachineSet(t *testing.T) {
+	const namespace = "offline-validation-test"
+
+	newMachineSet := func(modify func(ms *machinev1beta1.MachineSet, providerSpec *machinev1beta1.AWSMachineProviderConfig)) *machinev1beta1.MachineSet {
+		providerSpec := &machinev1beta1.AWSMachineProviderConfig{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "AWSMachineProviderConfig",
+				APIVersion: "machine.openshift.io/v1beta1",
+			},
+			AMI: machinev1beta1.AWSResourceReference{
+				ID: ptr.To[string]("ami"),
+			},
+			Placement: machinev1beta1.Placement{
+				Region: "region",
+			},
+			InstanceType: "m5.large",
+			IAMInstanceProfile: &machinev1beta1.AWSResourceReference{
+				ID: ptr.To[string]("profileID"),
+			},
+			UserDataSecret: &corev1.LocalObjectReference{
+				Name: "secret",
+			},
+			CredentialsSecret: &corev1.LocalObjectReference{
+				Name: "secret",
+			},
+			SecurityGroups: []machinev1beta1.AWSResourceReference{
+				{
+					ID: ptr.To[string]("sg"),
+				},
+			},
+			Subnet: machinev1beta1.AWSResourceReference{
+				ID: ptr.To[string]("subnet"),
+			},
+		}
+		ms := &machinev1beta1.MachineSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "machineset",
+				Namespace: namespace,
+			},
+			Spec: machinev1beta1.MachineSetSpec{
+				Selector: metav1.LabelSelector{
+					MatchLabels: map[string]string{"foo": "bar"},
+				},
+				Template: machinev1beta1.MachineTemplateSpec{
+					ObjectMeta: machinev1beta1.ObjectMeta{
+						Labels: map[string]string{"foo": "bar"},
+					},
+				},
+			},
+		}
+		if modify != nil {
+			modify(ms, providerSpec)
+		}
+
+		rawBytes, err := json.Marshal(providerSpec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ms.Spec.Template.Spec.ProviderSpec.Value = &runtime.RawExtension{Raw: rawBytes}
+		return ms
+	}
+
+	testCases := []struct {
+		name             string
+		machineSet       *machinev1beta1.MachineSet
+		expectedError    string
+		expectedWarnings []string
+	}{
+		{
+			name:       "with a valid MachineSet",
+			machineSet: newMachineSet(nil),
+		},
+		{
+			name: "with a selector not matching the template",
+			machineSet: newMachineSet(func(ms *machinev1beta1.MachineSet, _ *machinev1beta1.AWSMachineProviderConfig) {
+				ms.Spec.Template.Labels = map[string]string{"foo": "baz"}
+			}),
+			expectedError: "spec.template.metadata.labels: Invalid value: map[string]string{\"foo\":\"baz\"}: `selector` does not match template `labels`, mismatched label keys: [foo]",
+		},
+		{
+			name: "with a providerID in the template",
+			machineSet: newMachineSet(func(ms *machinev1beta1.MachineSet, _ *machinev1beta1.AWSMachineProviderConfig) {
+				ms.Spec.Template.Spec.ProviderID = ptr.To[string]("aws:///us-east-1a/i-0123456789abcdef0")
+			}),
+			expectedWarnings: []string{
+				"spec.template.spec.providerID: Invalid value: \"aws:///us-east-1a/i-0123456789abcdef0\": providerID is set by the machine controller once the instance has been created and should not be set in a template, Machines created from it may be marked as Failed",
+			},
+		},
+		{
+			name: "with an invalid providerSpec",
+			machineSet: newMachineSet(func(_ *machinev1beta1.MachineSet, providerSpec *machinev1beta1.AWSMachineProviderConfig) {
+				providerSpec.AMI = machinev1beta1.AWSResourceReference{}
+			}),
+			expectedError: "providerSpec.ami: Required value: expected providerSpec.ami.id to be populated",
+		},
+	}
+
+	infra := plainInfra.DeepCopy()
+	infra.Status.InfrastructureName = "clusterID"
+	infra.Status.PlatformStatus.Type = osconfigv1.AWSPlatformType
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			gate, err := testutils.NewDefaultMutableFeatureGate()
+			g.Expect(err).ToNot(HaveOccurred())
+
+			warnings, err := ValidateMachineSet(infra, nil, gate, tc.machineSet)
+			if tc.expectedError != "" {
+				g.Expect(err).To(MatchError(tc.expectedError))
+			} else {
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+			g.Expect(warnings).To(ConsistOf(tc.expectedWarnings))
+
+			// The offline validation must match the admission webhook with a cluster holding the credentials secret
+			c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "secret",
+					Namespace: namespace,
+				},
+				Data: map[string][]byte{
+					"aws_access_key_id":     []byte("id"),
+					"aws_secret_access_key": []byte("secret"),
+				},
+			}).Build()
+			h := newMachineSetValidatorHandler(infra, c, false, gate)
+			admissionWarnings, admissionErr := h.ValidateCreate(context.Background(), tc.machineSet)
+			g.Expect(admissionWarnings).To(ConsistOf(warnings))
+			if err != nil {
+				g.Expect(admissionErr).To(MatchError(err.Error()))
+			} else {
+				g.Expect(admissionErr).ToNot(HaveOccurred())
+			}
+		})
+	}
+}
+
+func TestVCPUQuotaWarnings(t *testing.T) {
+	newMachineSet := func(replicas int32, instanceType string) *machinev1beta1.MachineSet {
+		return &machinev1beta1.MachineSet{
+			Spec: machinev1beta1.MachineSetSpec{
+				Replicas: ptr.To[int32](replicas),
+				Template: machinev1beta1.MachineTemplateSpec{
+					Spec: machinev1beta1.MachineSpec{
+						ProviderSpec: machinev1beta1.ProviderSpec{
+							Value: &runtime.RawExtension{
+								Raw: []byte(fmt.Sprintf(`{"instanceType":%q}`, instanceType)),
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name             string
+		warningsEnabled  bool
+		annotations      map[string]string
+		machineSet       *machinev1beta1.MachineSet
+		expectedWarnings []string
+	}{
+		{
+			name:            "above the soft limit",
+			warningsEnabled: true,
+			annotations:     map[string]string{VCPUQuotaSoftLimitAnnotation: "16"},
+			machineSet:      newMachineSet(5, "m5.xlarge"),
+			expectedWarnings: []string{
+				"spec.replicas: Invalid value: 5: requests an estimated 20 vCPUs of m5.xlarge instances, above the soft limit of 16 vCPUs set by the machine.openshift.io/vcpu-quota-soft-limit annotation of the cluster infrastructure: Machines may fail to be created once the cloud account quota is exhausted",
+			},
+		},
+		{
+			name:            "at the soft limit",
+			warningsEnabled: true,
+			annotations:     map[string]string{VCPUQuotaSoftLimitAnnotation: "16"},
+			machineSet:      newMachineSet(4, "m5.xlarge"),
+		},
+		{
+			name:            "with an unknown instance type",
+			warningsEnabled: true,
+			annotations:     map[string]string{VCPUQuotaSoftLimitAnnotation: "16"},
+			machineSet:      newMachineSet(100, "m5.unknown"),
+		},
+		{
+			name:            "without the annotation",
+			warningsEnabled: true,
+			machineSet:      newMachineSet(100, "m5.xlarge"),
+		},
+		{
+			name:            "with an invalid annotation",
+			warningsEnabled: true,
+			annotations:     map[string]string{VCPUQuotaSoftLimitAnnotation: "many"},
+			machineSet:      newMachineSet(100, "m5.xlarge"),
+		},
+		{
+			name:            "when the warnings are disabled",
+			warningsEnabled: false,
+			annotations:     map[string]string{VCPUQuotaSoftLimitAnnotation: "16"},
+			machineSet:      newMachineSet(100, "m5.xlarge"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			infra := plainInfra.DeepCopy()
+			infra.Name = "cluster"
+			infra.Annotations = tc.annotations
+			infra.Status.PlatformStatus.Type = osconfigv1.AWSPlatformType
+
+			h := &machineSetValidatorHandler{
+				admissionHandler: &admissionHandler{
+					admissionConfig: &admissionConfig{
+						client: fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(infra).Build(),
+					},
+				},
+				platform:          osconfigv1.AWSPlatformType,
+				vCPUQuotaWarnings: tc.warningsEnabled,
+			}
+
+			g.Expect(vCPUQuotaWarnings(tc.machineSet, h.platform, h.vCPUQuotaSoftLimit(context.Background()))).To(Equal(tc.expectedWarnings))
+		})
+	}
+}
+
+func TestVCPUQuotaSoftLimitChange(t *testing.T) {
+	g := NewWithT(t)
+	ctx := context.Background()
+
+	infra := plainInfra.DeepCopy()
+	infra.Name = "cluster"
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(infra).Build()
+	h := &machineSetValidatorHandler{
+		admissionHandler: &admissionHandler{
+			admissionConfig: &admissionConfig{client: c},
+		},
+		vCPUQuotaWarnings: true,
+	}
+	g.Expect(h.vCPUQuotaSoftLimit(ctx)).To(BeZero())
+
+	// The annotation set after the webhook was created is used by the next requests
+	infra.Annotations = map[string]string{VCPUQuotaSoftLimitAnnotation: "16"}
+	g.Expect(c.Update(ctx, infra)).To(Succeed())
+	g.Expect(h.vCPUQuotaSoftLimit(ctx)).To(BeEquivalentTo(16))
+}
@@ -0,0 +1,156 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	osconfigv1 "github.com/openshift/api/config/v1"
+	machinev1 "github.com/openshift/api/machine/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/component-base/featuregate"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/machine-api-operator/pkg/util"
+)
+
+// ProviderSpecDebugPath is the path on which the providerSpec debug handler is served.
+const ProviderSpecDebugPath = "/debug/providerspec"
+
+// providerSpecDebugResponse is the JSON response of the providerSpec debug handler.
+type providerSpecDebugResponse struct {
+	Namespace string                  `json:"namespace"`
+	Name      string                  `json:"name"`
+	Platform  osconfigv1.PlatformType `json:"platform"`
+	// ProviderSpec is the providerSpec decoded into the provider config of the platform.
+	// It is omitted when the providerSpec cannot be decoded.
+	ProviderSpec  interface{} `json:"providerSpec,omitempty"`
+	UnknownFields []string    `json:"unknownFields,omitempty"`
+	// Valid is false when the Machine would be rejected by the validating webhook.
+	Valid    bool     `json:"valid"`
+	Errors   []string `json:"errors,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// providerSpecDebugHandler decodes and validates the providerSpec of existing Machines, using the
+// same validation as the Machine validating webhook. It never modifies the Machines.
+type providerSpecDebugHandler struct {
+	client    client.Client
+	platform  osconfigv1.PlatformType
+	validator *machineValidatorHandler
+}
+
+// NewProviderSpecDebugHandler returns an HTTP handler which returns the decoded providerSpec of a Machine,
+// given by the namespace and name query parameters, and the validation errors it would be rejected with.
+func NewProviderSpecDebugHandler(client client.Client, featureGate featuregate.MutableFeatureGate) (http.Handler, error) {
+	infra, err := getInfra()
+	if err != nil {
+		return nil, err
+	}
+
+	dns, err := getDNS()
+	if err != nil {
+		return nil, err
+	}
+
+	return newProviderSpecDebugHandler(infra, client, dns, featureGate), nil
+}
+
+func newProviderSpecDebugHandler(infra *osconfigv1.Infrastructure, client client.Client, dns *osconfigv1.DNS, featureGate featuregate.MutableFeatureGate) *providerSpecDebugHandler {
+	return &providerSpecDebugHandler{
+		client:    client,
+		platform:  infra.Status.PlatformStatus.Type,
+		validator: createMachineValidator(infra, client, dns, featureGate),
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *providerSpecDebugHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, fmt.Sprintf("method %s is not allowed", r.Method), http.StatusMethodNotAllowed)
+		return
+	}
+
+	key := client.ObjectKey{
+		Namespace: r.URL.Query().Get("namespace"),
+		Name:      r.URL.Query().Get("name"),
+	}
+	if key.Namespace == "" || key.Name == "" {
+		http.Error(w, "the namespace and name query parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	m := &machinev1beta1.Machine{}
+	if err := h.client.Get(r.Context(), key, m); err != nil {
+		if apierrors.IsNotFound(err) {
+			http.Error(w, fmt.Sprintf("machine %s not found", key), http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("failed to get machine %s: %v", key, err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.debugProviderSpec(m)); err != nil {
+		klog.Errorf("Failed to write providerSpec debug response for machine %s: %v", key, err)
+	}
+}
+
+// debugProviderSpec decodes and validates the providerSpec of the Machine as if it was being created.
+func (h *providerSpecDebugHandler) debugProviderSpec(m *machinev1beta1.Machine) *providerSpecDebugResponse {
+	response := &providerSpecDebugResponse{
+		Namespace: m.GetNamespace(),
+		Name:      m.GetName(),
+		Platform:  h.platform,
+	}
+
+	providerSpec, unknownFields, err := decodePlatformProviderSpec(h.platform, m.Spec.ProviderSpec.Value)
+	if err != nil {
+		response.Errors = append(response.Errors, err.Error())
+	}
+	response.ProviderSpec = providerSpec
+	response.UnknownFields = unknownFields
+
+	// Validate a copy without its deletion timestamp, deletions are only validated against the previous object
+	machine := m.DeepCopy()
+	machine.DeletionTimestamp = nil
+
+	ok, warnings, errs := h.validator.validateMachine(machine, nil)
+	response.Valid = ok
+	response.Warnings = warnings
+	for _, err := range errs {
+		response.Errors = append(response.Errors, err.Error())
+	}
+	return response
+}
+
+// decodePlatformProviderSpec decodes the providerSpec into the provider config type of the platform.
+func decodePlatformProviderSpec(platform osconfigv1.PlatformType, providerSpec *runtime.RawExtension) (interface{}, []string, error) {
+	switch platform {
+	case osconfigv1.AWSPlatformType:
+		return decodeProviderSpec[machinev1beta1.AWSMachineProviderConfig](providerSpec)
+	case osconfigv1.AzurePlatformType:
+		return decodeProviderSpec[machinev1beta1.AzureMachineProviderSpec](providerSpec)
+	case osconfigv1.GCPPlatformType:
+		return decodeProviderSpec[machinev1beta1.GCPMachineProviderSpec](providerSpec)
+	case osconfigv1.VSpherePlatformType:
+		return decodeProviderSpec[machinev1beta1.VSphereMachineProviderSpec](providerSpec)
+	case osconfigv1.PowerVSPlatformType:
+		return decodeProviderSpec[machinev1.PowerVSMachineProviderConfig](providerSpec)
+	case osconfigv1.NutanixPlatformType:
+		return decodeProviderSpec[machinev1.NutanixMachineProviderConfig](providerSpec)
+	default:
+		return nil, nil, fmt.Errorf("decoding the providerSpec is not supported on platform %q", platform)
+	}
+}
+
+func decodeProviderSpec[T any](providerSpec *runtime.RawExtension) (interface{}, []string, error) {
+	config, unknownFields, err := util.DecodeProviderSpec[T](providerSpec, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	return config, unknownFields, nil
+}
@@ -0,0 +1,155 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	osconfigv1 "github.com/openshift/api/config/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	testutils "github.com/openshift/machine-api-operator/pkg/util/testing"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestProviderSpecDebugHandler(t *testing.T) {
+	const namespace = "providerspec-debug-test"
+
+	newMachine := func(name string, providerSpec *machinev1beta1.AzureMachineProviderSpec) *machinev1beta1.Machine {
+		rawBytes, err := json.Marshal(providerSpec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &machinev1beta1.Machine{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Spec: machinev1beta1.MachineSpec{
+				ProviderSpec: machinev1beta1.ProviderSpec{
+					Value: &kruntime.RawExtension{Raw: rawBytes},
+				},
+			},
+		}
+	}
+
+	validProviderSpec := &machinev1beta1.AzureMachineProviderSpec{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "AzureMachineProviderSpec",
+			APIVersion: "machine.openshift.io/v1beta1",
+		},
+		VMSize: "Standard_D4s_v3",
+		Image: machinev1beta1.Image{
+			ResourceID: "resourceID",
+		},
+		UserDataSecret: &corev1.SecretReference{
+			Name: "name",
+		},
+		CredentialsSecret: &corev1.SecretReference{
+			Name:      "name",
+			Namespace: namespace,
+		},
+		OSDisk: machinev1beta1.OSDisk{
+			DiskSizeGB: 128,
+		},
+	}
+	invalidProviderSpec := validProviderSpec.DeepCopy()
+	invalidProviderSpec.VMSize = ""
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "name",
+			Namespace: namespace,
+		},
+		Data: map[string][]byte{
+			"azure_subscription_id": []byte("subscription"),
+			"azure_client_id":       []byte("client"),
+			"azure_tenant_id":       []byte("tenant"),
+			"azure_client_secret":   []byte("secret"),
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(
+		secret,
+		newMachine("valid", validProviderSpec),
+		newMachine("invalid", invalidProviderSpec),
+	).Build()
+
+	infra := plainInfra.DeepCopy()
+	infra.Status.InfrastructureName = "clusterID"
+	infra.Status.PlatformStatus.Type = osconfigv1.AzurePlatformType
+
+	gate, err := testutils.NewDefaultMutableFeatureGate()
+	if err != nil {
+		t.Fatalf("Unexpected error setting up feature gates: %v", err)
+	}
+	h := newProviderSpecDebugHandler(infra, c, plainDNS, gate)
+
+	testCases := []struct {
+		name               string
+		query              string
+		expectedStatusCode int
+		expectedResponse   map[string]interface{}
+	}{
+		{
+			name:               "with a valid machine",
+			query:              "namespace=" + namespace + "&name=valid",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: map[string]interface{}{
+				"namespace": namespace,
+				"name":      "valid",
+				"platform":  "Azure",
+				"valid":     true,
+			},
+		},
+		{
+			name:               "with an invalid machine",
+			query:              "namespace=" + namespace + "&name=invalid",
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: map[string]interface{}{
+				"namespace": namespace,
+				"name":      "invalid",
+				"platform":  "Azure",
+				"valid":     false,
+				"errors":    []interface{}{"providerSpec.vmSize: Required value: vmSize should be set to one of the supported Azure VM sizes"},
+			},
+		},
+		{
+			name:               "with a missing machine",
+			query:              "namespace=" + namespace + "&name=missing",
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "without a name",
+			query:              "namespace=" + namespace,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, ProviderSpecDebugPath+"?"+tc.query, nil))
+			g.Expect(recorder.Code).To(Equal(tc.expectedStatusCode))
+			if tc.expectedResponse == nil {
+				return
+			}
+			g.Expect(recorder.Header().Get("Content-Type")).To(Equal("application/json"))
+
+			response := map[string]interface{}{}
+			g.Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(Succeed())
+
+			// The decoded providerSpec is checked separately as it is normalized by the provider config type
+			g.Expect(response).To(HaveKey("providerSpec"))
+			g.Expect(response["providerSpec"]).To(HaveKeyWithValue("kind", "AzureMachineProviderSpec"))
+			delete(response, "providerSpec")
+			g.Expect(response).To(Equal(tc.expectedResponse))
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package webhooks
+
+import (
+	"net/http"
+
+	osconfigv1 "github.com/openshift/api/config/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/component-base/featuregate"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// WebhookRegisterer registers an admission handler on a path of a webhook server.
+type WebhookRegisterer interface {
+	Register(path string, hook http.Handler)
+}
+
+// blank assignment to verify that the webhook server of the manager implements WebhookRegisterer
+var _ WebhookRegisterer = webhook.Server(nil)
+
+// WebhookOptions selects the admission webhooks registered by RegisterWebhooks.
+type WebhookOptions struct {
+	// MachineWebhooks registers the Machine defaulting and validating webhooks.
+	MachineWebhooks bool
+	// MachineSetWebhooks registers the MachineSet defaulting and validating webhooks.
+	MachineSetWebhooks bool
+	// VCPUQuotaWarnings enables the vCPU quota warnings of the MachineSet validating webhook.
+	VCPUQuotaWarnings bool
+}
+
+// RegisterWebhooks registers the Machine and MachineSet defaulting and validating webhooks selected by the options.
+// The cluster Infrastructure and DNS are only read when at least one of them is selected.
+func RegisterWebhooks(server WebhookRegisterer, client client.Client, featureGate featuregate.MutableFeatureGate, opts WebhookOptions) error {
+	if !opts.MachineWebhooks && !opts.MachineSetWebhooks {
+		return nil
+	}
+
+	infra, err := getInfra()
+	if err != nil {
+		return err
+	}
+
+	dns, err := getDNS()
+	if err != nil {
+		return err
+	}
+
+	registerWebhooks(server, infra, dns, client, featureGate, opts)
+	return nil
+}
+
+func registerWebhooks(server WebhookRegisterer, infra *osconfigv1.Infrastructure, dns *osconfigv1.DNS, client client.Client, featureGate featuregate.MutableFeatureGate, opts WebhookOptions) {
+	if opts.MachineWebhooks {
+		machineDefaulter := admission.WithCustomDefaulter(scheme.Scheme, &machinev1beta1.Machine{}, createMachineDefaulter(infra.Status.PlatformStatus, infra.Status.InfrastructureName))
+		machineValidator := admission.WithCustomValidator(scheme.Scheme, &machinev1beta1.Machine{}, createMachineValidator(infra, client, dns, featureGate))
+		server.Register(DefaultMachineMutatingHookPath, &webhook.Admission{Handler: &deprecatedGroupWarningHandler{
+			Handler:      machineDefaulter,
+			platform:     infra.Status.PlatformStatus.Type,
+			providerSpec: machineProviderSpec,
+		}})
+		server.Register(DefaultMachineValidatingHookPath, &webhook.Admission{Handler: machineValidator})
+	}
+
+	if opts.MachineSetWebhooks {
+		machineSetDefaulter := createMachineSetDefaulter(infra.Status.PlatformStatus, infra.Status.InfrastructureName)
+		machineSetValidator := createMachineSetValidator(infra, client, dns, featureGate, opts.VCPUQuotaWarnings)
+		server.Register(DefaultMachineSetMutatingHookPath, &webhook.Admission{Handler: &deprecatedGroupWarningHandler{
+			Handler:      machineSetDefaulter,
+			platform:     infra.Status.PlatformStatus.Type,
+			providerSpec: machineSetProviderSpec,
+		}})
+		server.Register(DefaultMachineSetValidatingHookPath, &webhook.Admission{Handler: machineSetValidator})
+	}
+}
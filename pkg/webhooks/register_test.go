@@ -0,0 +1,83 @@
+package webhooks
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	testutils "github.com/openshift/machine-api-operator/pkg/util/testing"
+)
+
+type fakeWebhookRegisterer struct {
+	paths []string
+}
+
+func (f *fakeWebhookRegisterer) Register(path string, hook http.Handler) {
+	f.paths = append(f.paths, path)
+}
+
+func TestRegisterWebhooks(t *testing.T) {
+	testCases := []struct {
+		name          string
+		opts          WebhookOptions
+		expectedPaths []string
+	}{
+		{
+			name: "with all webhooks enabled",
+			opts: WebhookOptions{MachineWebhooks: true, MachineSetWebhooks: true},
+			expectedPaths: []string{
+				DefaultMachineMutatingHookPath,
+				DefaultMachineValidatingHookPath,
+				DefaultMachineSetMutatingHookPath,
+				DefaultMachineSetValidatingHookPath,
+			},
+		},
+		{
+			name: "with the Machine webhooks enabled",
+			opts: WebhookOptions{MachineWebhooks: true},
+			expectedPaths: []string{
+				DefaultMachineMutatingHookPath,
+				DefaultMachineValidatingHookPath,
+			},
+		},
+		{
+			name: "with the MachineSet webhooks enabled",
+			opts: WebhookOptions{MachineSetWebhooks: true},
+			expectedPaths: []string{
+				DefaultMachineSetMutatingHookPath,
+				DefaultMachineSetValidatingHookPath,
+			},
+		},
+		{
+			name: "with all webhooks disabled",
+			opts: WebhookOptions{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			gate, err := testutils.NewDefaultMutableFeatureGate()
+			g.Expect(err).ToNot(HaveOccurred())
+
+			server := &fakeWebhookRegisterer{}
+			registerWebhooks(server, plainInfra, plainDNS, fake.NewClientBuilder().Build(), gate, tc.opts)
+
+			g.Expect(server.paths).To(Equal(tc.expectedPaths))
+		})
+	}
+
+	t.Run("without webhooks enabled the cluster is not read", func(t *testing.T) {
+		g := NewWithT(t)
+
+		gate, err := testutils.NewDefaultMutableFeatureGate()
+		g.Expect(err).ToNot(HaveOccurred())
+
+		server := &fakeWebhookRegisterer{}
+		g.Expect(RegisterWebhooks(server, fake.NewClientBuilder().Build(), gate, WebhookOptions{})).To(Succeed())
+		g.Expect(server.paths).To(BeEmpty())
+	})
+}
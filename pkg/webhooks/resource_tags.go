@@ -0,0 +1,150 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	osconfigv1 "github.com/openshift/api/config/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	admissionv1 "k8s.io/api/admission/v1"
+	kruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// isUpdateRequest returns true when the admission request of the context updates an existing object.
+// Without an admission request, e.g. when the defaulter is called directly, the object is considered new.
+func isUpdateRequest(ctx context.Context) bool {
+	req, err := admission.RequestFromContext(ctx)
+	return err == nil && req.Operation == admissionv1.Update
+}
+
+// mergeClusterResourceTags merges the resource tags of the cluster infrastructure into the tags of the
+// AWS and Azure providerSpecs and into the labels of the GCP providerSpecs. Tags set on the Machine win
+// over the cluster tags of the same key. It is applied to new Machines and to the Machine template of new
+// MachineSets alike. The providerSpec is left untouched when it can't be decoded, the platform defaulters
+// report the error.
+func mergeClusterResourceTags(m *machinev1beta1.Machine, platformStatus *osconfigv1.PlatformStatus) {
+	if platformStatus == nil || m.Spec.ProviderSpec.Value == nil {
+		return
+	}
+
+	var providerSpec interface{}
+	switch {
+	case platformStatus.Type == osconfigv1.AWSPlatformType && platformStatus.AWS != nil && len(platformStatus.AWS.ResourceTags) > 0:
+		spec := new(machinev1beta1.AWSMachineProviderConfig)
+		if err := unmarshalInto(m, spec); err != nil {
+			return
+		}
+		for _, tag := range platformStatus.AWS.ResourceTags {
+			if !hasAWSTag(spec.Tags, tag.Key) {
+				spec.Tags = append(spec.Tags, machinev1beta1.TagSpecification{Name: tag.Key, Value: tag.Value})
+			}
+		}
+		providerSpec = spec
+	case platformStatus.Type == osconfigv1.AzurePlatformType && platformStatus.Azure != nil && len(platformStatus.Azure.ResourceTags) > 0:
+		spec := new(machinev1beta1.AzureMachineProviderSpec)
+		if err := unmarshalInto(m, spec); err != nil {
+			return
+		}
+		if spec.Tags == nil {
+			spec.Tags = map[string]string{}
+		}
+		for _, tag := range platformStatus.Azure.ResourceTags {
+			if _, ok := spec.Tags[tag.Key]; !ok {
+				spec.Tags[tag.Key] = tag.Value
+			}
+		}
+		providerSpec = spec
+	case platformStatus.Type == osconfigv1.GCPPlatformType && platformStatus.GCP != nil && len(platformStatus.GCP.ResourceLabels) > 0:
+		spec := new(machinev1beta1.GCPMachineProviderSpec)
+		if err := unmarshalInto(m, spec); err != nil {
+			return
+		}
+		if spec.Labels == nil {
+			spec.Labels = map[string]string{}
+		}
+		for _, label := range platformStatus.GCP.ResourceLabels {
+			if _, ok := spec.Labels[label.Key]; !ok {
+				spec.Labels[label.Key] = label.Value
+			}
+		}
+		providerSpec = spec
+	default:
+		return
+	}
+
+	rawBytes, err := json.Marshal(providerSpec)
+	if err != nil {
+		klog.Errorf("failed to encode the providerSpec of Machine %s: %v", m.GetName(), err)
+		return
+	}
+	m.Spec.ProviderSpec.Value = &kruntime.RawExtension{Raw: rawBytes}
+}
+
+// hasAWSTag returns true when a tag with the given name is in tags.
+func hasAWSTag(tags []machinev1beta1.TagSpecification, name string) bool {
+	for _, tag := range tags {
+		if tag.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// awsClusterTagOwned is the value of the cluster tag of AWS resources which belong to the cluster only,
+// as opposed to "shared" for the resources which are shared with other clusters.
+const awsClusterTagOwned = "owned"
+
+// awsClusterTagName returns the name of the tag which identifies the AWS resources of the cluster,
+// e.g. for the discovery of the instances of the cluster by the node autoscaler.
+func awsClusterTagName(clusterID string) string {
+	return fmt.Sprintf("kubernetes.io/cluster/%s", clusterID)
+}
+
+// defaultAWSClusterTag adds the cluster tag with the owned value to the tags of the AWS providerSpecs
+// of new Machines and MachineSet templates which don't set it. The providerSpec is left untouched when
+// it can't be decoded, the platform defaulters report the error.
+func defaultAWSClusterTag(m *machinev1beta1.Machine, platformStatus *osconfigv1.PlatformStatus, clusterID string) {
+	if platformStatus == nil || platformStatus.Type != osconfigv1.AWSPlatformType || clusterID == "" || m.Spec.ProviderSpec.Value == nil {
+		return
+	}
+
+	providerSpec := new(machinev1beta1.AWSMachineProviderConfig)
+	if err := unmarshalInto(m, providerSpec); err != nil {
+		return
+	}
+	if hasAWSTag(providerSpec.Tags, awsClusterTagName(clusterID)) {
+		return
+	}
+	providerSpec.Tags = append(providerSpec.Tags, machinev1beta1.TagSpecification{Name: awsClusterTagName(clusterID), Value: awsClusterTagOwned})
+
+	rawBytes, err := json.Marshal(providerSpec)
+	if err != nil {
+		klog.Errorf("failed to encode the providerSpec of Machine %s: %v", m.GetName(), err)
+		return
+	}
+	m.Spec.ProviderSpec.Value = &kruntime.RawExtension{Raw: rawBytes}
+}
+
+// awsClusterTagWarnings returns a warning when the cluster tag of the AWS providerSpec is not owned.
+// Only the first value is used when the tag is duplicated.
+func awsClusterTagWarnings(tags []machinev1beta1.TagSpecification, clusterID string) []string {
+	if clusterID == "" {
+		return nil
+	}
+
+	name := awsClusterTagName(clusterID)
+	for _, tag := range tags {
+		if tag.Name != name {
+			continue
+		}
+		if tag.Value == awsClusterTagOwned {
+			return nil
+		}
+		return []string{fmt.Sprintf("providerSpec.tags: tag %s is set to %q instead of %q: the instances of the Machine "+
+			"may not be discovered as owned by the cluster", name, tag.Value, awsClusterTagOwned)}
+	}
+	return nil
+}
@@ -0,0 +1,335 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	osconfigv1 "github.com/openshift/api/config/v1"
+	machinev1beta1 "github.com/openshift/api/machine/v1beta1"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestMergeClusterResourceTags(t *testing.T) {
+	awsPlatformStatus := &osconfigv1.PlatformStatus{
+		Type: osconfigv1.AWSPlatformType,
+		AWS: &osconfigv1.AWSPlatformStatus{
+			Region: "us-east-1",
+			ResourceTags: []osconfigv1.AWSResourceTag{
+				{Key: "cost-center", Value: "cluster"},
+				{Key: "team", Value: "cluster"},
+			},
+		},
+	}
+	gcpPlatformStatus := &osconfigv1.PlatformStatus{
+		Type: osconfigv1.GCPPlatformType,
+		GCP: &osconfigv1.GCPPlatformStatus{
+			ProjectID: "project",
+			Region:    "us-central1",
+			ResourceLabels: []osconfigv1.GCPResourceLabel{
+				{Key: "cost-center", Value: "cluster"},
+				{Key: "team", Value: "cluster"},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name           string
+		platformStatus *osconfigv1.PlatformStatus
+		operation      admissionv1.Operation
+		providerSpec   interface{}
+		expectedTags   interface{}
+	}{
+		{
+			name:           "with AWS cluster tags",
+			platformStatus: awsPlatformStatus,
+			operation:      admissionv1.Create,
+			providerSpec: &machinev1beta1.AWSMachineProviderConfig{
+				AMI: machinev1beta1.AWSResourceReference{ID: ptr.To[string]("ami")},
+			},
+			expectedTags: []machinev1beta1.TagSpecification{
+				{Name: "cost-center", Value: "cluster"},
+				{Name: "team", Value: "cluster"},
+				{Name: "kubernetes.io/cluster/clusterID", Value: "owned"},
+			},
+		},
+		{
+			name:           "with AWS machine tags conflicting with the cluster tags",
+			platformStatus: awsPlatformStatus,
+			operation:      admissionv1.Create,
+			providerSpec: &machinev1beta1.AWSMachineProviderConfig{
+				AMI: machinev1beta1.AWSResourceReference{ID: ptr.To[string]("ami")},
+				Tags: []machinev1beta1.TagSpecification{
+					{Name: "team", Value: "machine"},
+					{Name: "app", Value: "machine"},
+				},
+			},
+			expectedTags: []machinev1beta1.TagSpecification{
+				{Name: "team", Value: "machine"},
+				{Name: "app", Value: "machine"},
+				{Name: "cost-center", Value: "cluster"},
+				{Name: "kubernetes.io/cluster/clusterID", Value: "owned"},
+			},
+		},
+		{
+			name:           "with AWS cluster tags on update",
+			platformStatus: awsPlatformStatus,
+			operation:      admissionv1.Update,
+			providerSpec: &machinev1beta1.AWSMachineProviderConfig{
+				AMI:  machinev1beta1.AWSResourceReference{ID: ptr.To[string]("ami")},
+				Tags: []machinev1beta1.TagSpecification{{Name: "app", Value: "machine"}},
+			},
+			expectedTags: []machinev1beta1.TagSpecification{
+				{Name: "app", Value: "machine"},
+			},
+		},
+		{
+			name:           "with GCP cluster labels",
+			platformStatus: gcpPlatformStatus,
+			operation:      admissionv1.Create,
+			providerSpec:   &machinev1beta1.GCPMachineProviderSpec{},
+			expectedTags: map[string]string{
+				"cost-center": "cluster",
+				"team":        "cluster",
+			},
+		},
+		{
+			name:           "with GCP machine labels conflicting with the cluster labels",
+			platformStatus: gcpPlatformStatus,
+			operation:      admissionv1.Create,
+			providerSpec: &machinev1beta1.GCPMachineProviderSpec{
+				Labels: map[string]string{
+					"team": "machine",
+					"app":  "machine",
+				},
+			},
+			expectedTags: map[string]string{
+				"cost-center": "cluster",
+				"team":        "machine",
+				"app":         "machine",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rawBytes, err := json.Marshal(tc.providerSpec)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			m := &machinev1beta1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "test",
+					Labels: map[string]string{machinev1beta1.MachineClusterIDLabel: "clusterID"},
+				},
+				Spec: machinev1beta1.MachineSpec{
+					ProviderSpec: machinev1beta1.ProviderSpec{
+						Value: &kruntime.RawExtension{Raw: rawBytes},
+					},
+				},
+			}
+
+			ctx := admission.NewContextWithRequest(context.Background(), admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{Operation: tc.operation},
+			})
+
+			h := createMachineDefaulter(tc.platformStatus, "clusterID")
+			g.Expect(h.Default(ctx, m)).To(Succeed())
+
+			switch tc.platformStatus.Type {
+			case osconfigv1.AWSPlatformType:
+				providerSpec := &machinev1beta1.AWSMachineProviderConfig{}
+				g.Expect(json.Unmarshal(m.Spec.ProviderSpec.Value.Raw, providerSpec)).To(Succeed())
+				g.Expect(providerSpec.Tags).To(Equal(tc.expectedTags))
+			case osconfigv1.GCPPlatformType:
+				providerSpec := &machinev1beta1.GCPMachineProviderSpec{}
+				g.Expect(json.Unmarshal(m.Spec.ProviderSpec.Value.Raw, providerSpec)).To(Succeed())
+				g.Expect(providerSpec.Labels).To(Equal(tc.expectedTags))
+			}
+		})
+	}
+}
+
+func TestDefaultAWSClusterTag(t *testing.T) {
+	awsPlatformStatus := &osconfigv1.PlatformStatus{
+		Type: osconfigv1.AWSPlatformType,
+		AWS:  &osconfigv1.AWSPlatformStatus{Region: "us-east-1"},
+	}
+
+	testCases := []struct {
+		name           string
+		platformStatus *osconfigv1.PlatformStatus
+		operation      admissionv1.Operation
+		tags           []machinev1beta1.TagSpecification
+		expectedTags   []machinev1beta1.TagSpecification
+	}{
+		{
+			name:           "without the cluster tag",
+			platformStatus: awsPlatformStatus,
+			operation:      admissionv1.Create,
+			tags:           []machinev1beta1.TagSpecification{{Name: "app", Value: "machine"}},
+			expectedTags: []machinev1beta1.TagSpecification{
+				{Name: "app", Value: "machine"},
+				{Name: "kubernetes.io/cluster/clusterID", Value: "owned"},
+			},
+		},
+		{
+			name:           "with the cluster tag shared",
+			platformStatus: awsPlatformStatus,
+			operation:      admissionv1.Create,
+			tags:           []machinev1beta1.TagSpecification{{Name: "kubernetes.io/cluster/clusterID", Value: "shared"}},
+			expectedTags:   []machinev1beta1.TagSpecification{{Name: "kubernetes.io/cluster/clusterID", Value: "shared"}},
+		},
+		{
+			name:           "without the cluster tag on update",
+			platformStatus: awsPlatformStatus,
+			operation:      admissionv1.Update,
+			tags:           []machinev1beta1.TagSpecification{{Name: "app", Value: "machine"}},
+			expectedTags:   []machinev1beta1.TagSpecification{{Name: "app", Value: "machine"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			rawBytes, err := json.Marshal(&machinev1beta1.AWSMachineProviderConfig{
+				AMI:  machinev1beta1.AWSResourceReference{ID: ptr.To[string]("ami")},
+				Tags: tc.tags,
+			})
+			g.Expect(err).ToNot(HaveOccurred())
+
+			m := &machinev1beta1.Machine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "test",
+					Labels: map[string]string{machinev1beta1.MachineClusterIDLabel: "clusterID"},
+				},
+				Spec: machinev1beta1.MachineSpec{
+					ProviderSpec: machinev1beta1.ProviderSpec{
+						Value: &kruntime.RawExtension{Raw: rawBytes},
+					},
+				},
+			}
+
+			ctx := admission.NewContextWithRequest(context.Background(), admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{Operation: tc.operation},
+			})
+
+			h := createMachineDefaulter(tc.platformStatus, "clusterID")
+			g.Expect(h.Default(ctx, m)).To(Succeed())
+
+			providerSpec := &machinev1beta1.AWSMachineProviderConfig{}
+			g.Expect(json.Unmarshal(m.Spec.ProviderSpec.Value.Raw, providerSpec)).To(Succeed())
+			g.Expect(providerSpec.Tags).To(Equal(tc.expectedTags))
+		})
+	}
+}
+
+func TestMachineSetTemplateClusterTags(t *testing.T) {
+	g := NewWithT(t)
+
+	platformStatus := &osconfigv1.PlatformStatus{
+		Type: osconfigv1.AWSPlatformType,
+		AWS: &osconfigv1.AWSPlatformStatus{
+			Region:       "us-east-1",
+			ResourceTags: []osconfigv1.AWSResourceTag{{Key: "cost-center", Value: "cluster"}},
+		},
+	}
+
+	rawBytes, err := json.Marshal(&machinev1beta1.AWSMachineProviderConfig{
+		AMI:  machinev1beta1.AWSResourceReference{ID: ptr.To[string]("ami")},
+		Tags: []machinev1beta1.TagSpecification{{Name: "app", Value: "machine"}},
+	})
+	g.Expect(err).ToNot(HaveOccurred())
+
+	ms := &machinev1beta1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: machinev1beta1.MachineSetSpec{
+			Template: machinev1beta1.MachineTemplateSpec{
+				Spec: machinev1beta1.MachineSpec{
+					ProviderSpec: machinev1beta1.ProviderSpec{
+						Value: &kruntime.RawExtension{Raw: rawBytes},
+					},
+				},
+			},
+		},
+	}
+
+	ctx := admission.NewContextWithRequest(context.Background(), admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{Operation: admissionv1.Create},
+	})
+	h := &machineSetDefaulterHandler{
+		admissionHandler: &admissionHandler{
+			admissionConfig:   &admissionConfig{clusterID: "clusterID", platformStatus: platformStatus},
+			webhookOperations: getMachineDefaulterOperation(platformStatus),
+		},
+	}
+	g.Expect(h.Default(ctx, ms)).To(Succeed())
+
+	templateSpec := &machinev1beta1.AWSMachineProviderConfig{}
+	g.Expect(json.Unmarshal(ms.Spec.Template.Spec.ProviderSpec.Value.Raw, templateSpec)).To(Succeed())
+	g.Expect(templateSpec.Tags).To(Equal([]machinev1beta1.TagSpecification{
+		{Name: "app", Value: "machine"},
+		{Name: "cost-center", Value: "cluster"},
+		{Name: "kubernetes.io/cluster/clusterID", Value: "owned"},
+	}))
+
+	// The Machines created from the template are defaulted to the same tags, so they don't drift from it.
+	m := &machinev1beta1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-machine"},
+		Spec:       *ms.Spec.Template.Spec.DeepCopy(),
+	}
+	g.Expect(createMachineDefaulter(platformStatus, "clusterID").Default(ctx, m)).To(Succeed())
+
+	machineSpec := &machinev1beta1.AWSMachineProviderConfig{}
+	g.Expect(json.Unmarshal(m.Spec.ProviderSpec.Value.Raw, machineSpec)).To(Succeed())
+	g.Expect(machineSpec.Tags).To(Equal(templateSpec.Tags))
+}
+
+func TestAWSClusterTagWarnings(t *testing.T) {
+	testCases := []struct {
+		name             string
+		tags             []machinev1beta1.TagSpecification
+		expectedWarnings []string
+	}{
+		{
+			name: "without the cluster tag",
+			tags: []machinev1beta1.TagSpecification{{Name: "app", Value: "machine"}},
+		},
+		{
+			name: "with the cluster tag owned",
+			tags: []machinev1beta1.TagSpecification{{Name: "kubernetes.io/cluster/clusterID", Value: "owned"}},
+		},
+		{
+			name: "with the cluster tag shared",
+			tags: []machinev1beta1.TagSpecification{{Name: "kubernetes.io/cluster/clusterID", Value: "shared"}},
+			expectedWarnings: []string{
+				"providerSpec.tags: tag kubernetes.io/cluster/clusterID is set to \"shared\" instead of \"owned\": the instances of the Machine may not be discovered as owned by the cluster",
+			},
+		},
+		{
+			name: "with the cluster tag of another cluster shared",
+			tags: []machinev1beta1.TagSpecification{{Name: "kubernetes.io/cluster/other", Value: "shared"}},
+		},
+		{
+			name: "with the cluster tag duplicated",
+			tags: []machinev1beta1.TagSpecification{
+				{Name: "kubernetes.io/cluster/clusterID", Value: "owned"},
+				{Name: "kubernetes.io/cluster/clusterID", Value: "shared"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(awsClusterTagWarnings(tc.tags, "clusterID")).To(Equal(tc.expectedWarnings))
+		})
+	}
+}